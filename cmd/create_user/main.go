@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/auth"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/unit_of_work"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Creates a user that authenticates to the API in the database configured in .env, the password is
+// read from the USER_PASSWORD environment variable so it is not left in the shell history.
+//
+//	USER_PASSWORD=... go run ./cmd/create_user -username ana -role warehouse_operator -warehouses 1,3
+//	USER_PASSWORD=... go run ./cmd/create_user -username frutas -role seller -seller 7
+func main() {
+	username := flag.String("username", "", "username of the user")
+	role := flag.String("role", internal.RoleAnalyst, "role of the user, one of "+strings.Join(internal.Roles, ", "))
+	warehouses := flag.String("warehouses", "", "comma separated ids of the warehouses assigned to a warehouse operator")
+	sellerID := flag.Int("seller", 0, "id of the seller a seller acts as")
+	buyerID := flag.Int("buyer", 0, "id of the buyer a buyer acts as")
+	flag.Parse()
+
+	password := os.Getenv("USER_PASSWORD")
+	if *username == "" || password == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	user := internal.User{Username: *username, Role: *role, SellerID: *sellerID, BuyerID: *buyerID}
+
+	for _, id := range strings.Split(*warehouses, ",") {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+
+		warehouseID, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid warehouse id "+id)
+			os.Exit(2)
+		}
+
+		user.WarehouseIDs = append(user.WarehouseIDs, warehouseID)
+	}
+
+	err := utils.LoadProperties("./.env")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(run(user, password))
+}
+
+// run creates the user and returns the exit status
+func run(user internal.User, password string) int {
+	cfg := mysql.Config{
+		User:      os.Getenv("DB.USERNAME"),
+		Passwd:    os.Getenv("DB.PASSWORD"),
+		Net:       "tcp",
+		Addr:      "localhost" + os.Getenv("DB.ADDRESS"),
+		DBName:    os.Getenv("DB.NAME"),
+		ParseTime: true,
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	defer db.Close()
+
+	// no tokens are issued, only the users are managed
+	service := auth.NewDefaultAuthService(auth.NewMySQLUserRepository(db), nil, 0, 0, unit_of_work.NewMySQLUnitOfWork(db))
+
+	user, err = service.CreateUser(context.Background(), user, password)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("created user %s with id %d and role %s\n", user.Username, user.ID, user.Role)
+
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/country"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/locality"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/province"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/unit_of_work"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Imports a CSV file of countries, provinces and localities into the database configured in .env
+// and prints the report as JSON. It exits with status 1 when a row fails.
+//
+//	go run ./cmd/import_localities -file localities.csv
+func main() {
+	path := flag.String("file", "", "CSV file with the country_name, province_name, locality_id, locality_name, latitude and longitude columns")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := utils.LoadProperties("./.env")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(run(*path))
+}
+
+// run imports the file and returns the exit status
+func run(path string) int {
+	cfg := mysql.Config{
+		User:   os.Getenv("DB.USERNAME"),
+		Passwd: os.Getenv("DB.PASSWORD"),
+		Net:    "tcp",
+		Addr:   "localhost" + os.Getenv("DB.ADDRESS"),
+		DBName: os.Getenv("DB.NAME"),
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	defer db.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	defer file.Close()
+
+	service := locality.NewBasicLocalityService(
+		locality.NewMysqlLocalityRepository(db),
+		province.NewMysqlProvinceRepository(db),
+		country.NewMysqlCountryRepository(db),
+		unit_of_work.NewMySQLUnitOfWork(db),
+	)
+
+	report, err := service.Import(context.Background(), file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if report.Failed > 0 {
+		return 1
+	}
+
+	return 0
+}
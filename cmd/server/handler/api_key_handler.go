@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// CreateAPIKeyRequest is the key to issue to a user, each scope is a permission such as products:read
+type CreateAPIKeyRequest struct {
+	UserID int      `json:"user_id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type APIKeyHandler struct {
+	service internal.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler with the provided APIKeyService.
+func NewAPIKeyHandler(service internal.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// GetAll handles the request to list the API keys.
+//
+//	@Summary		List API keys
+//	@Description	Lists the API keys, the revoked ones too, without their secrets
+//	@Tags			API keys
+//	@Produce		json
+//	@Success		200	{array}		internal.APIKey
+//	@Failure		403	{object}	utils.ErrorResponse	"Missing permission"
+//	@Router			/api/v1/apiKeys [get]
+func (handler *APIKeyHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := handler.service.GetAll(r.Context())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, keys)
+	}
+}
+
+// Create handles the request to issue an API key to a user.
+//
+//	@Summary		Create API key
+//	@Description	Issues an API key, sent as X-API-Key in the requests of a machine client. The key is only returned here
+//	@Tags			API keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		CreateAPIKeyRequest	true	"User, name and scopes of the key"
+//	@Success		201	{object}	internal.IssuedAPIKey
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid body"
+//	@Failure		422	{object}	utils.ErrorResponse	"Missing fields, unknown user or scope not granted to the role of the user"
+//	@Router			/api/v1/apiKeys [post]
+func (handler *APIKeyHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		key, err := handler.service.Create(r.Context(), internal.APIKey{
+			UserID: request.UserID,
+			Name:   request.Name,
+			Scopes: request.Scopes,
+		})
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusCreated, key)
+	}
+}
+
+// Rotate handles the request to issue a new secret for an API key.
+//
+//	@Summary		Rotate API key
+//	@Description	Issues a new secret for an API key, the previous one stops working. The key is only returned here
+//	@Tags			API keys
+//	@Produce		json
+//	@Param			id	path		int	true	"API key ID"
+//	@Success		200	{object}	internal.IssuedAPIKey
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"API key not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"API key revoked"
+//	@Router			/api/v1/apiKeys/{id}/rotate [post]
+func (handler *APIKeyHandler) Rotate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		key, err := handler.service.Rotate(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, key)
+	}
+}
+
+// Revoke handles the request to revoke an API key.
+//
+//	@Summary		Revoke API key
+//	@Description	Revokes an API key, it stops working and is still listed
+//	@Tags			API keys
+//	@Param			id	path	int	true	"API key ID"
+//	@Success		204
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"API key not found or already revoked"
+//	@Router			/api/v1/apiKeys/{id} [delete]
+func (handler *APIKeyHandler) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		if err := handler.service.Revoke(r.Context(), id); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusNoContent, nil)
+	}
+}
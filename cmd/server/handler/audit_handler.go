@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+type AuditHandler struct {
+	service internal.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler with the provided AuditService.
+func NewAuditHandler(service internal.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAuditEntries handles the request to retrieve a page of the audit trail.
+//
+//	@Summary		List the audit trail
+//	@Description	Lists the changes made to the entities, paged by limit and cursor and sorted by sort.
+//	@Description	Filtered by entity, entity_id, actor, action and created_at, as created_at[gte]=2024-01-01
+//	@Tags			Audit
+//	@Produce		json
+//	@Param			entity			query		string	false	"Entity changed, as sellers"
+//	@Param			entity_id		query		int		false	"ID of the entity changed"
+//	@Param			actor			query		string	false	"Who made the change"
+//	@Param			created_at[gte]	query		string	false	"Changes made since, RFC 3339 or date"
+//	@Param			created_at[lte]	query		string	false	"Changes made until, RFC 3339 or date"
+//	@Success		200				{object}	utils.PageResponse{data=[]internal.AuditEntry}
+//	@Failure		400				{object}	utils.ErrorResponse	"Invalid or unknown query param"
+//	@Failure		422				{object}	utils.ErrorResponse	"Invalid filter or sort field"
+//	@Router			/api/v1/audit [get]
+func (handler *AuditHandler) GetAuditEntries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := utils.ParseListQuery(r.URL.Query())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		entries, pagination, err := handler.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, entries, pagination)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// LoginRequest are the credentials of a user
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the refresh token issued with an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AuthHandler struct {
+	service internal.AuthService
+}
+
+// NewAuthHandler creates a new AuthHandler with the provided AuthService.
+func NewAuthHandler(service internal.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Login handles the request to issue the tokens of a user.
+//
+//	@Summary		Issue tokens
+//	@Description	Issues an access token, sent as Authorization: Bearer in the other requests, and a refresh token
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		LoginRequest	true	"Username and password"
+//	@Success		200			{object}	internal.TokenPair
+//	@Failure		400			{object}	utils.ErrorResponse	"Invalid body"
+//	@Failure		401			{object}	utils.ErrorResponse	"Invalid username or password"
+//	@Failure		422			{object}	utils.ErrorResponse	"Missing username or password"
+//	@Router			/api/v1/auth/token [post]
+func (handler *AuthHandler) Login() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var credentials LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		tokens, err := handler.service.Login(r.Context(), credentials.Username, credentials.Password)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, tokens)
+	}
+}
+
+// Refresh handles the request to issue new tokens from a refresh token.
+//
+//	@Summary		Refresh tokens
+//	@Description	Issues new tokens from a refresh token that is not expired
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		RefreshRequest	true	"Refresh token"
+//	@Success		200		{object}	internal.TokenPair
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid body"
+//	@Failure		401		{object}	utils.ErrorResponse	"Invalid or expired refresh token"
+//	@Router			/api/v1/auth/refresh [post]
+func (handler *AuthHandler) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		tokens, err := handler.service.Refresh(r.Context(), request.RefreshToken)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, tokens)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAuthService struct {
+	mock.Mock
+}
+
+func (m *mockAuthService) Login(ctx context.Context, username, password string) (internal.TokenPair, error) {
+	args := m.Called(username, password)
+	return args.Get(0).(internal.TokenPair), args.Error(1)
+}
+
+func (m *mockAuthService) Refresh(ctx context.Context, refreshToken string) (internal.TokenPair, error) {
+	args := m.Called(refreshToken)
+	return args.Get(0).(internal.TokenPair), args.Error(1)
+}
+
+func (m *mockAuthService) Authenticate(ctx context.Context, accessToken string) (internal.Principal, error) {
+	args := m.Called(accessToken)
+	return args.Get(0).(internal.Principal), args.Error(1)
+}
+
+func (m *mockAuthService) CreateUser(ctx context.Context, user internal.User, password string) (internal.User, error) {
+	args := m.Called(user, password)
+	return args.Get(0).(internal.User), args.Error(1)
+}
+
+func TestUnitAuthHandler_Login(t *testing.T) {
+	tokens := internal.TokenPair{AccessToken: "a.b.c", RefreshToken: "d.e.f", TokenType: "Bearer", ExpiresIn: 900}
+
+	cases := []struct {
+		TestName           string
+		Body               string
+		Tokens             internal.TokenPair
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "Login",
+			Body:               `{"username":"ana","password":"s3cret-password"}`,
+			Tokens:             tokens,
+			ExpectedBody:       `{"data":{"access_token":"a.b.c","refresh_token":"d.e.f","token_type":"Bearer","expires_in":900}}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "Login - Invalid Credentials",
+			Body:               `{"username":"ana","password":"guess"}`,
+			ErrorToReturn:      utils.EUnauthorized("invalid username or password"),
+			ExpectedBody:       `{"status":"Unauthorized","message":"unauthorized: invalid username or password"}`,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			TestName:           "Login - Invalid Body",
+			Body:               `{"username":`,
+			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: body with invalid format"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockAuthService)
+			service.On("Login", mock.Anything, mock.Anything).Return(c.Tokens, c.ErrorToReturn)
+			handler := NewAuthHandler(service)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/token", strings.NewReader(c.Body))
+			res := httptest.NewRecorder()
+			handler.Login()(res, req)
+			require.Equal(t, c.ExpectedStatusCode, res.Code)
+			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestUnitAuthHandler_Refresh(t *testing.T) {
+	t.Run("Refresh", func(t *testing.T) {
+		service := new(mockAuthService)
+		service.On("Refresh", "d.e.f").Return(internal.TokenPair{AccessToken: "g.h.i", RefreshToken: "j.k.l", TokenType: "Bearer", ExpiresIn: 900}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"d.e.f"}`))
+		res := httptest.NewRecorder()
+		NewAuthHandler(service).Refresh()(res, req)
+		require.Equal(t, http.StatusOK, res.Code)
+		require.JSONEq(t, `{"data":{"access_token":"g.h.i","refresh_token":"j.k.l","token_type":"Bearer","expires_in":900}}`, res.Body.String())
+	})
+
+	t.Run("Refresh - Expired Token", func(t *testing.T) {
+		service := new(mockAuthService)
+		service.On("Refresh", "d.e.f").Return(internal.TokenPair{}, utils.EUnauthorized("token expired"))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"d.e.f"}`))
+		res := httptest.NewRecorder()
+		NewAuthHandler(service).Refresh()(res, req)
+		require.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
@@ -21,21 +21,29 @@ func NewBuyerHandler(service internal.BuyerService) *BuyerHandler {
 	return &BuyerHandler{service: service}
 }
 
+// GetAll returns a page of buyers, filtered by field=value or field[operator]=value,
+// sorted by sort (comma separated, prefix with - for descending) and paged by limit and cursor,
+// include_deleted=true lists the deleted buyers too, for the admins only
 func (handler *BuyerHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		buyers, err := handler.service.GetAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
-			http.Error(w, "500 Erro Internal server error", http.StatusInternalServerError)
+			utils.HandleError(w, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
 
-		if err := json.NewEncoder(w).Encode(buyers); err != nil {
-			http.Error(w, "Failed to encode buyers: "+err.Error(), http.StatusInternalServerError)
+		buyers, pagination, err := handler.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
 			return
 		}
+
+		utils.JSONPage(w, http.StatusOK, buyers, pagination)
 	}
 }
 
@@ -47,11 +55,16 @@ func (handler *BuyerHandler) GetOne() http.HandlerFunc {
 			utils.Error(w, http.StatusBadRequest, err.Error())
 		}
 
-		buyer, err := handler.service.GetOne(id)
+		buyer, err := handler.service.GetOne(r.Context(), id)
 
 		if err != nil {
 			log.Println("Error to get an user - ", err)
 			utils.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if buyer != nil {
+			utils.SetETag(w, buyer.Version)
 		}
 
 		utils.JSON(w, http.StatusOK, buyer)
@@ -65,7 +78,7 @@ func (handler *BuyerHandler) CreateBuyer() http.HandlerFunc {
 			utils.JSON(w, http.StatusInternalServerError, utils.ErrInvalidFormat)
 		}
 
-		buyer, err := handler.service.CreateBuyer(newBuyer)
+		buyer, err := handler.service.CreateBuyer(r.Context(), newBuyer)
 		if err != nil {
 			if errors.Is(err, utils.ErrConflict) {
 				utils.Error(w, http.StatusConflict, err.Error())
@@ -90,12 +103,19 @@ func (handler *BuyerHandler) UpdateBuyer() http.HandlerFunc {
 			utils.JSON(w, http.StatusBadRequest, utils.ErrInvalidFormat)
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		if err := json.NewDecoder(r.Body).Decode(&newBuyer); err != nil {
 			utils.JSON(w, http.StatusInternalServerError, utils.ErrInvalidFormat)
 		}
 
 		updatedBuyer := internal.Buyer{
-			ID: int64(id),
+			ID:      int64(id),
+			Version: version,
 			BuyerAttributes: internal.BuyerAttributes{
 				CardNumberID: newBuyer.CardNumberID,
 				FirstName:    newBuyer.FirstName,
@@ -103,7 +123,7 @@ func (handler *BuyerHandler) UpdateBuyer() http.HandlerFunc {
 			},
 		}
 
-		buyer, err := handler.service.UpdateBuyer(&updatedBuyer)
+		buyer, err := handler.service.UpdateBuyer(r.Context(), &updatedBuyer)
 
 		if err != nil {
 			if errors.Is(err, utils.ErrConflict) {
@@ -111,11 +131,17 @@ func (handler *BuyerHandler) UpdateBuyer() http.HandlerFunc {
 				return
 			}
 
+			if errors.Is(err, utils.ErrPreconditionFailed) {
+				utils.HandleError(w, err)
+				return
+			}
+
 			utils.Error(w, http.StatusInternalServerError, "500")
 
 			return
 		}
 
+		utils.SetETag(w, buyer.Version)
 		utils.JSON(w, http.StatusCreated, buyer)
 	}
 }
@@ -126,15 +152,48 @@ func (handler *BuyerHandler) DeleteBuyer() http.HandlerFunc {
 		if err != nil {
 			log.Println("Error in parse param to int")
 			utils.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
 		}
 
-		err = handler.service.DeleteBuyer(id)
+		err = handler.service.DeleteBuyer(r.Context(), id, version)
 
 		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) || errors.Is(err, utils.ErrPreconditionFailed) {
+				utils.HandleError(w, err)
+				return
+			}
+
 			log.Println("Error to  an user - ", err)
 			utils.Error(w, http.StatusInternalServerError, err.Error())
+
+			return
 		}
 
 		utils.JSON(w, http.StatusNoContent, nil)
 	}
 }
+
+// RestoreBuyer brings back a deleted buyer and returns it
+func (handler *BuyerHandler) RestoreBuyer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		buyer, err := handler.service.RestoreBuyer(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, buyer)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
@@ -21,31 +22,46 @@ type BuyerServiceMock struct {
 	mock.Mock
 }
 
-func (m *BuyerServiceMock) GetAll() ([]internal.Buyer, error) {
+func (m *BuyerServiceMock) GetAll(ctx context.Context) ([]internal.Buyer, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerServiceMock) GetOne(id int) (*internal.Buyer, error) {
+func (m *BuyerServiceMock) List(ctx context.Context, query utils.ListQuery) ([]internal.Buyer, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Buyer), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *BuyerServiceMock) GetOne(ctx context.Context, id int) (*internal.Buyer, error) {
 	args := m.Called(id)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerServiceMock) CreateBuyer(buyer internal.BuyerAttributes) (*internal.Buyer, error) {
+func (m *BuyerServiceMock) CreateBuyer(ctx context.Context, buyer internal.BuyerAttributes) (*internal.Buyer, error) {
 	args := m.Called(buyer)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerServiceMock) UpdateBuyer(buyer *internal.Buyer) (*internal.Buyer, error) {
+func (m *BuyerServiceMock) UpdateBuyer(ctx context.Context, buyer *internal.Buyer) (*internal.Buyer, error) {
 	args := m.Called(buyer)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerServiceMock) DeleteBuyer(id int) error {
-	args := m.Called(id)
+func (m *BuyerServiceMock) DeleteBuyer(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *BuyerServiceMock) RestoreBuyer(ctx context.Context, id int) (*internal.Buyer, error) {
+	args := m.Called(id)
+	return args.Get(0).(*internal.Buyer), args.Error(1)
+}
+
+func (m *BuyerServiceMock) PurgeBuyers(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 func TestUnitBuyer_GetAllBuyers(t *testing.T) {
 	type testCase struct {
 		name            string
@@ -61,7 +77,7 @@ func TestUnitBuyer_GetAllBuyers(t *testing.T) {
 			mockBuyers:      []internal.Buyer{},
 			mockError:       nil,
 			expectedStatus:  http.StatusOK,
-			expectedContent: "[]",
+			expectedContent: `{"data":[],"pagination":{"limit":50,"has_more":false}}`,
 		},
 		{
 			name: "OK - one buyer",
@@ -84,7 +100,7 @@ func TestUnitBuyer_GetAllBuyers(t *testing.T) {
 			mockBuyers:      nil,
 			mockError:       errors.New("some service error"),
 			expectedStatus:  http.StatusInternalServerError,
-			expectedContent: "500 Erro Internal server error",
+			expectedContent: "internal server error",
 		},
 	}
 
@@ -92,8 +108,8 @@ func TestUnitBuyer_GetAllBuyers(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			service := new(BuyerServiceMock)
 			service.
-				On("GetAll").
-				Return(tc.mockBuyers, tc.mockError)
+				On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).
+				Return(tc.mockBuyers, utils.Pagination{Limit: utils.DefaultListLimit}, tc.mockError)
 
 			h := handler.NewBuyerHandler(service)
 
@@ -251,6 +267,7 @@ func TestBuyerHandler_CreateBuyer(t *testing.T) {
 func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		Body               string
 		ExpectedBody       string
@@ -258,6 +275,7 @@ func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 	}{
 		{
 			TestName:      "UpdateBuyer - Success",
+			IfMatch:       `"1"`,
 			ErrorToReturn: nil,
 			Body: `{
 					"card_number_id": "402323",
@@ -269,6 +287,7 @@ func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 		},
 		{
 			TestName:      "UpdateBuyer - Error Conflict",
+			IfMatch:       `"1"`,
 			ErrorToReturn: utils.ErrConflict,
 			Body: `{
 					"card_number_id": "402323",
@@ -278,6 +297,20 @@ func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 			ExpectedBody:       `{"message":"entity already exists", "status": "Conflict"}`,
 			ExpectedStatusCode: 409,
 		},
+		{
+			TestName:           "UpdateBuyer - Error Precondition Failed",
+			IfMatch:            `"1"`,
+			ErrorToReturn:      utils.EPreconditionFailed("buyer"),
+			Body:               `{"first_name": "Jhon"}`,
+			ExpectedBody:       `{"message":"precondition failed: buyer was changed, read it again to get its current version", "status": "Precondition Failed"}`,
+			ExpectedStatusCode: 412,
+		},
+		{
+			TestName:           "UpdateBuyer - Error Precondition Required",
+			Body:               `{"first_name": "Jhon"}`,
+			ExpectedBody:       `{"message":"precondition required: If-Match header with the ETag of the resource is required", "status": "Precondition Required"}`,
+			ExpectedStatusCode: 428,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.TestName, func(t *testing.T) {
@@ -293,6 +326,9 @@ func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 			handler := handler.NewBuyerHandler(service)
 
 			req, _ := http.NewRequest("PUT", "http://localhost:8080/api/v1/buyers/1", strings.NewReader(tc.Body))
+			if tc.IfMatch != "" {
+				req.Header.Set("If-Match", tc.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			if tc.TestName == "UpdateProduct_ErrorBadRequest" {
@@ -311,30 +347,52 @@ func TestUnitBuyerHandler_UpdateBuyer(t *testing.T) {
 func TestUnitBuyerHandler_DeleteBuyer(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		ExpectedBody       string
 		ExpectedStatusCode int
 	}{
 		{
 			TestName:           "DeleteBuyer - Success",
+			IfMatch:            `"1"`,
 			ErrorToReturn:      nil,
 			ExpectedBody:       `{"message":"Buyer deleted successfully"}`,
 			ExpectedStatusCode: 204,
 		},
 		{
 			TestName:           "DeleteBuyer - Error",
-			ErrorToReturn:      utils.ErrNotFound,
-			ExpectedBody:       `{"message":"entity not found", "status": "Internal Server Error"}`,
+			IfMatch:            `"1"`,
+			ErrorToReturn:      errors.New("connection refused"),
+			ExpectedBody:       `{"message":"connection refused", "status": "Internal Server Error"}`,
 			ExpectedStatusCode: 500,
 		},
+		{
+			TestName:           "DeleteBuyer - Error Not Found",
+			IfMatch:            `"1"`,
+			ErrorToReturn:      utils.ErrNotFound,
+			ExpectedStatusCode: 404,
+		},
+		{
+			TestName:           "DeleteBuyer - Error Precondition Failed",
+			IfMatch:            `"2"`,
+			ErrorToReturn:      utils.EPreconditionFailed("buyer"),
+			ExpectedStatusCode: 412,
+		},
+		{
+			TestName:           "DeleteBuyer - Error Precondition Required",
+			ExpectedStatusCode: 428,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.TestName, func(t *testing.T) {
 			service := new(BuyerServiceMock)
-			service.On("DeleteBuyer", 1).Return(tc.ErrorToReturn)
+			service.On("DeleteBuyer", 1, mock.Anything).Return(tc.ErrorToReturn)
 			handler := handler.NewBuyerHandler(service)
 
 			req, _ := http.NewRequest("DELETE", "http://localhost:8080/api/v1/buyers/1", nil)
+			if tc.IfMatch != "" {
+				req.Header.Set("If-Match", tc.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
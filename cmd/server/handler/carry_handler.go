@@ -45,7 +45,7 @@ func (handler *CarryHandler) SaveCarry() http.HandlerFunc {
 			return
 		}
 
-		if err := handler.service.Save(carry); err != nil {
+		if err := handler.service.Save(r.Context(), carry); err != nil {
 			utils.HandleError(w, err)
 			return
 		}
@@ -54,18 +54,44 @@ func (handler *CarryHandler) SaveCarry() http.HandlerFunc {
 	}
 }
 
-// GetAllCarries handles the HTTP request to retrieve all carries.
-// It returns an HTTP handler function that writes the carries data as a JSON response.
-// If an error occurs while retrieving the carries, it responds with a 404 status code and an error message.
+// GetAllCarries handles the HTTP request to retrieve a page of carries.
+// It reads the limit, cursor and sort query params, any other param filters the carries
+// as field=value or field[operator]=value, include_deleted=true lists the deleted carries too for the
+// admins. The fields query param selects the returned fields
+// and include=locality embeds the locality of each carry.
+// It returns an HTTP handler function that writes the carries and the pagination as a JSON response.
 func (handler *CarryHandler) GetAllCarries() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		carries, err := handler.service.GetAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, carries)
+		if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		shape, err := utils.ParseResponseShape[internal.Carry](r.URL.Query(), internal.CarryIncludeLocality)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		carries, pagination, err := handler.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		body, err := utils.Shape(r.Context(), carries, shape, handler.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, body, pagination)
 	}
 }
 
@@ -74,7 +100,7 @@ func (handler *CarryHandler) GetAllCarries() http.HandlerFunc {
 // calls the service layer to fetch the carry. If the ID is invalid or
 // the carry is not found, it responds with the appropriate HTTP error
 // status and message. On success, it responds with the carry data in
-// JSON format.
+// JSON format, shaped by the fields and include query params as in GetAllCarries.
 //
 // Returns an http.HandlerFunc that can be used to handle the request.
 func (handler *CarryHandler) GetCarryByID() http.HandlerFunc {
@@ -85,13 +111,26 @@ func (handler *CarryHandler) GetCarryByID() http.HandlerFunc {
 			return
 		}
 
-		carry, err := handler.service.GetByID(id)
+		shape, err := utils.ParseResponseShape[internal.Carry](r.URL.Query(), internal.CarryIncludeLocality)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, carry)
+		carry, err := handler.service.GetByID(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		body, err := utils.ShapeOne(r.Context(), carry, shape, handler.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, carry.Version)
+		utils.JSON(w, http.StatusOK, body)
 	}
 }
 
@@ -100,7 +139,9 @@ func (handler *CarryHandler) GetCarryByID() http.HandlerFunc {
 // and calls the service layer to update the carry item in the database.
 // If the ID is invalid, the request body cannot be decoded, or the update fails,
 // it responds with the appropriate HTTP status code and error message.
-// On success, it responds with the updated carry item and a 200 OK status.
+// The If-Match header must hold the ETag of the carry item, a 428 Precondition Required status is
+// returned without it and a 412 Precondition Failed status when the carry item was changed since.
+// On success, it responds with the updated carry item, its new ETag and a 200 OK status.
 func (handler *CarryHandler) UpdateCarry() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
@@ -109,6 +150,12 @@ func (handler *CarryHandler) UpdateCarry() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		carry := &internal.Carry{}
 
 		err = json.NewDecoder(r.Body).Decode(carry)
@@ -118,12 +165,14 @@ func (handler *CarryHandler) UpdateCarry() http.HandlerFunc {
 		}
 
 		carry.ID = id
+		carry.Version = version
 
-		if err := handler.service.Update(carry); err != nil {
+		if err := handler.service.Update(r.Context(), carry); err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
+		utils.SetETag(w, carry.Version)
 		utils.JSON(w, http.StatusOK, carry)
 	}
 }
@@ -132,6 +181,8 @@ func (handler *CarryHandler) UpdateCarry() http.HandlerFunc {
 // It extracts the ID from the URL parameters, validates it, and calls the service layer to delete the carry item.
 // If the ID is invalid, it responds with a 400 Bad Request status.
 // If the carry item is not found, it responds with a 404 Not Found status.
+// If the If-Match header is missing or is not the ETag of the carry item, it responds with a
+// 428 Precondition Required or a 412 Precondition Failed status.
 // If there is an internal server error during deletion, it responds with a 500 Internal Server Error status.
 // On successful deletion, it responds with a 204 No Content status.
 func (handler *CarryHandler) DeleteCarry() http.HandlerFunc {
@@ -142,7 +193,13 @@ func (handler *CarryHandler) DeleteCarry() http.HandlerFunc {
 			return
 		}
 
-		if err := handler.service.Delete(id); err != nil {
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if err := handler.service.Delete(r.Context(), id, version); err != nil {
 			utils.HandleError(w, err)
 			return
 		}
@@ -150,3 +207,25 @@ func (handler *CarryHandler) DeleteCarry() http.HandlerFunc {
 		utils.JSON(w, http.StatusNoContent, "Carry deleted successfully")
 	}
 }
+
+// RestoreCarry handles the HTTP request for restoring a soft deleted carry item by its ID.
+// If the ID is invalid, it responds with a 400 Bad Request status.
+// If there is no deleted carry item with the ID, it responds with a 404 Not Found status.
+// On success, it responds with a 200 OK status and the restored carry item.
+func (handler *CarryHandler) RestoreCarry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+			return
+		}
+
+		carry, err := handler.service.Restore(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, carry)
+	}
+}
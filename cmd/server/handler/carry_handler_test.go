@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -18,31 +19,51 @@ type mockCarryService struct {
 	mock.Mock
 }
 
-func (m *mockCarryService) GetByID(id int) (internal.Carry, error) {
+func (m *mockCarryService) GetByID(ctx context.Context, id int) (internal.Carry, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Carry), args.Error(1)
 }
 
-func (m *mockCarryService) GetAll() ([]internal.Carry, error) {
+func (m *mockCarryService) GetRelations(ctx context.Context, carries []internal.Carry, include []string) ([]internal.CarryRelations, error) {
+	args := m.Called(carries, include)
+	return args.Get(0).([]internal.CarryRelations), args.Error(1)
+}
+
+func (m *mockCarryService) GetAll(ctx context.Context) ([]internal.Carry, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Carry), args.Error(1)
 }
 
-func (m *mockCarryService) Save(carry *internal.Carry) error {
+func (m *mockCarryService) List(ctx context.Context, query utils.ListQuery) ([]internal.Carry, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Carry), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *mockCarryService) Save(ctx context.Context, carry *internal.Carry) error {
 	args := m.Called(carry)
 	return args.Error(0)
 }
 
-func (m *mockCarryService) Update(carry *internal.Carry) error {
+func (m *mockCarryService) Update(ctx context.Context, carry *internal.Carry) error {
 	args := m.Called(carry)
 	return args.Error(0)
 }
 
-func (m *mockCarryService) Delete(id int) error {
-	args := m.Called(id)
+func (m *mockCarryService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *mockCarryService) Restore(ctx context.Context, id int) (internal.Carry, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Carry), args.Error(1)
+}
+
+func (m *mockCarryService) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 func TestUnitCarryHandler_SaveCarry(t *testing.T) {
 	cases := []struct {
 		TestName           string
@@ -119,7 +140,8 @@ func TestUnitCarryHandler_GetAllCarries(t *testing.T) {
 			TestName:      "GetAllCarries",
 			ErrorToReturn: nil,
 			ExpectedBody: `{
-							"data": []
+							"data": [],
+							"pagination": {"limit": 50, "has_more": false}
 						}`,
 			ExpectedStatusCode: http.StatusOK,
 		},
@@ -133,7 +155,7 @@ func TestUnitCarryHandler_GetAllCarries(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockCarryService)
-			service.On("GetAll").Return([]internal.Carry{}, c.ErrorToReturn)
+			service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Carry{}, utils.Pagination{Limit: utils.DefaultListLimit}, c.ErrorToReturn)
 			handler := CarryHandler{service: service}
 			req, _ := http.NewRequest("GET", "http://localhost:8080/api/v1/carries/", nil)
 			res := httptest.NewRecorder()
@@ -215,6 +237,7 @@ func TestUnitCarryHandler_GetCarryByID(t *testing.T) {
 func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		Body               string
 		ExpectedBody       string
@@ -222,6 +245,7 @@ func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 	}{
 		{
 			TestName:      "UpdateCarry",
+			IfMatch:       `"1"`,
 			ErrorToReturn: nil,
 			Body: `{
 					"cid": 6,
@@ -237,13 +261,15 @@ func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 										"company_name": "New Alkemy",
 										"address": "Monroe 860",
 										"telephone": "47470000",
-										"locality_id": 2
+										"locality_id": 2,
+										"version": 2
 									}
 								}`,
 			ExpectedStatusCode: http.StatusOK,
 		},
 		{
 			TestName:           "UpdateCarryError_ErrorUnprocessableEntity",
+			IfMatch:            `"1"`,
 			ErrorToReturn:      utils.EZeroValue("Carry"),
 			Body:               `{"cid": 6,"company_name": "New Alkemy","address": "Monroe 860","telephone": "47470000","locality_id": 2}`,
 			ExpectedBody:       `{"status":"Unprocessable Entity","message":"invalid arguments: Carry cannot be empty/null"}`,
@@ -251,6 +277,7 @@ func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 		},
 		{
 			TestName:      "UpdateCarryError_ErrorNotFound",
+			IfMatch:       `"1"`,
 			ErrorToReturn: utils.ENotFound("Carry"),
 			Body: `{
 					"cid": 6,
@@ -267,19 +294,39 @@ func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 		},
 		{
 			TestName:      "UpdateCarryError_ErrorBadRequest",
+			IfMatch:       `"1"`,
 			ErrorToReturn: utils.EBadRequest("Invalid ID"),
 			Body: `{"cid": 6,"company
 			_name": "New Alkemy","address": "Monroe 860","telephone": "47470000","locality_id": 2}`,
 			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: Invalid ID with invalid format"}`,
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			TestName:           "UpdateCarryError_ErrorPreconditionFailed",
+			IfMatch:            `"1"`,
+			ErrorToReturn:      utils.EPreconditionFailed("carry"),
+			Body:               `{"company_name": "New Alkemy"}`,
+			ExpectedBody:       `{"status":"Precondition Failed","message":"precondition failed: carry was changed, read it again to get its current version"}`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			TestName:           "UpdateCarryError_ErrorPreconditionRequired",
+			Body:               `{"company_name": "New Alkemy"}`,
+			ExpectedBody:       `{"status":"Precondition Required","message":"precondition required: If-Match header with the ETag of the resource is required"}`,
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockCarryService)
-			service.On("Update", mock.Anything).Return(c.ErrorToReturn)
+			service.On("Update", mock.Anything).Return(c.ErrorToReturn).Run(func(args mock.Arguments) {
+				args.Get(0).(*internal.Carry).Version++
+			})
 			handler := CarryHandler{service: service}
 			req, _ := http.NewRequest("PUT", "http://localhost:8080/api/v1/carries/", strings.NewReader(c.Body))
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			if c.TestName == "UpdateCarryError_ErrorBadRequest" {
@@ -300,12 +347,14 @@ func TestUnitCarryHandler_UpdateCarry(t *testing.T) {
 func TestUnitCarryHandler_DeleteCarry(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		ExpectedBody       string
 		ExpectedStatusCode int
 	}{
 		{
 			TestName:      "DeleteCarry",
+			IfMatch:       `"1"`,
 			ErrorToReturn: nil,
 			ExpectedBody: `{
 							"data": "Carry deleted successfully"
@@ -314,23 +363,35 @@ func TestUnitCarryHandler_DeleteCarry(t *testing.T) {
 		},
 		{
 			TestName:           "DeleteCarryError_ErrorNotFound",
+			IfMatch:            `"1"`,
 			ErrorToReturn:      utils.ENotFound("Carry"),
 			ExpectedBody:       `{"status":"Not Found","message":"entity not found: Carry doesn't exist"}`,
 			ExpectedStatusCode: http.StatusNotFound,
 		},
 		{
 			TestName:           "DeleteCarryError_ErrorBadRequest",
+			IfMatch:            `"1"`,
 			ErrorToReturn:      utils.EBadRequest("Invalid ID"),
 			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: Invalid ID with invalid format"}`,
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			TestName:           "DeleteCarryError_ErrorPreconditionFailed",
+			IfMatch:            `"2"`,
+			ErrorToReturn:      utils.EPreconditionFailed("carry"),
+			ExpectedBody:       `{"status":"Precondition Failed","message":"precondition failed: carry was changed, read it again to get its current version"}`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockCarryService)
-			service.On("Delete", 1).Return(c.ErrorToReturn)
+			service.On("Delete", 1, mock.Anything).Return(c.ErrorToReturn)
 			handler := CarryHandler{service: service}
 			req, _ := http.NewRequest("DELETE", "http://localhost:8080/api/v1/carries/", nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			if c.TestName == "DeleteCarryError_ErrorBadRequest" {
@@ -347,3 +408,51 @@ func TestUnitCarryHandler_DeleteCarry(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitCarryHandler_RestoreCarry(t *testing.T) {
+	cases := []struct {
+		TestName           string
+		ID                 string
+		CarryToReturn      internal.Carry
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "RestoreCarry",
+			ID:                 "1",
+			CarryToReturn:      internal.Carry{ID: 1, CID: 10, CompanyName: "Carry", Address: "Street 1", Telephone: "123", LocalityID: 1},
+			ExpectedBody:       `{"data":{"id":1,"cid":10,"company_name":"Carry","address":"Street 1","telephone":"123","locality_id":1}}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "RestoreCarryError_ErrorNotFound",
+			ID:                 "1",
+			ErrorToReturn:      utils.ENotFound("Deleted carry"),
+			ExpectedBody:       `{"status":"Not Found","message":"entity not found: Deleted carry doesn't exist"}`,
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			TestName:           "RestoreCarryError_ErrorBadRequest",
+			ID:                 "a",
+			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: Invalid ID with invalid format"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockCarryService)
+			service.On("Restore", 1).Return(c.CarryToReturn, c.ErrorToReturn)
+			handler := CarryHandler{service: service}
+			req := httptest.NewRequest("POST", "/api/v1/carries/"+c.ID+"/restore", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", c.ID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			res := httptest.NewRecorder()
+			funcHandler := handler.RestoreCarry()
+			funcHandler(res, req)
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+type CountryHandler struct {
+	service internal.CountryService
+}
+
+func NewCountryHandler(service internal.CountryService) *CountryHandler {
+	return &CountryHandler{
+		service: service,
+	}
+}
+
+type reqCountry struct {
+	CountryName string `json:"country_name"`
+}
+
+// GetAll responds with all the countries
+//
+//	@Summary		Get all countries
+//	@Description	Retrieve all the countries
+//	@Tags			countries
+//	@Produce		json
+//	@Success		200	{array}	internal.Country
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries [get]
+func (h *CountryHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		countries, err := h.service.GetAll(r.Context())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, countries)
+	}
+}
+
+// GetByID responds with the country of the id path param
+//
+//	@Summary		Get country by ID
+//	@Description	Get a country by its ID, its version is the ETag header
+//	@Tags			countries
+//	@Produce		json
+//	@Param			id	path	int	true	"Country ID"
+//	@Success		200	{object}	internal.Country
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Country not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries/{id} [get]
+func (h *CountryHandler) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		country, err := h.service.GetByID(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, country.Version)
+		utils.JSON(w, http.StatusOK, country)
+	}
+}
+
+// Create creates a country, responds 409 when the name is already taken
+//
+//	@Summary		Create a new country
+//	@Description	Create a country with a name not taken by another one
+//	@Tags			countries
+//	@Accept			json
+//	@Produce		json
+//	@Param			country	body	reqCountry	true	"Country name"
+//	@Success		201	{object}	internal.Country
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid request body"
+//	@Failure		409	{object}	utils.ErrorResponse	"Name already taken"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries [post]
+func (h *CountryHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body reqCountry
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		country := internal.Country{CountryName: body.CountryName}
+
+		if err := h.service.Save(r.Context(), &country); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusCreated, country)
+	}
+}
+
+// Update renames the country of the id path param, in the version of the If-Match header
+//
+//	@Summary		Update a country
+//	@Description	Update a country by its ID, in the version of the If-Match header
+//	@Tags			countries
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Country ID"
+//	@Param			If-Match	header	string	true	"ETag of the country read, or *"
+//	@Param			country	body	reqCountry	true	"Country data"
+//	@Success		200	{object}	internal.Country
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID or request body"
+//	@Failure		404	{object}	utils.ErrorResponse	"Country not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Name already taken"
+//	@Failure		412	{object}	utils.ErrorResponse	"The country was changed since its ETag was read"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries/{id} [patch]
+func (h *CountryHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		var body reqCountry
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		country := internal.Country{ID: id, CountryName: body.CountryName, Version: version}
+
+		if err := h.service.Update(r.Context(), &country); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, country.Version)
+		utils.JSON(w, http.StatusOK, country)
+	}
+}
+
+// Delete removes the country of the id path param with its provinces and localities,
+// responds 409 when sellers, warehouses or carriers are located in it and 412 when it is not in
+// the version of the If-Match header
+//
+//	@Summary		Delete a country
+//	@Description	Delete a country by its ID, in the version of the If-Match header, along with its provinces and localities
+//	@Tags			countries
+//	@Param			id	path	int	true	"Country ID"
+//	@Param			If-Match	header	string	true	"ETag of the country read, or *"
+//	@Success		204
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Country not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Sellers, warehouses or carriers are located in the country"
+//	@Failure		412	{object}	utils.ErrorResponse	"The country was changed since its ETag was read"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries/{id} [delete]
+func (h *CountryHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if err := h.service.Delete(r.Context(), id, version); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// GetProvinces responds with the provinces of the country of the id path param
+//
+//	@Summary		Get the provinces of a country
+//	@Description	Retrieve the provinces of a country by its ID
+//	@Tags			countries
+//	@Produce		json
+//	@Param			id	path	int	true	"Country ID"
+//	@Success		200	{array}	internal.Province
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Country not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries/{id}/provinces [get]
+func (h *CountryHandler) GetProvinces() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		provinces, err := h.service.GetProvinces(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, provinces)
+	}
+}
+
+// GetHierarchy responds with the countries, their provinces and localities,
+// only the country of the optional id query param when present
+//
+//	@Summary		Get the hierarchy of the countries
+//	@Description	Retrieve the countries with their provinces and localities, only the one of id when given
+//	@Tags			countries
+//	@Produce		json
+//	@Param			id	query	int	false	"Country ID"
+//	@Success		200	{array}	internal.CountryHierarchy
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Country not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/countries/hierarchy [get]
+func (h *CountryHandler) GetHierarchy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := 0
+
+		var err error
+
+		if strings.TrimSpace(r.URL.Query().Get("id")) != "" {
+			id, err = strconv.Atoi(r.URL.Query().Get("id"))
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("id"))
+				return
+			}
+		}
+
+		hierarchy, err := h.service.GetHierarchy(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, hierarchy)
+	}
+}
@@ -0,0 +1,323 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCountryService struct {
+	mock.Mock
+}
+
+func (m *MockCountryService) GetAll(ctx context.Context) ([]internal.Country, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Country), args.Error(1)
+}
+
+func (m *MockCountryService) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryService) Save(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryService) Update(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockCountryService) GetProvinces(ctx context.Context, id int) ([]internal.Province, error) {
+	args := m.Called(id)
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockCountryService) GetHierarchy(ctx context.Context, id int) ([]internal.CountryHierarchy, error) {
+	args := m.Called(id)
+	return args.Get(0).([]internal.CountryHierarchy), args.Error(1)
+}
+
+func TestUnitCountry_Create(t *testing.T) {
+	cases := []struct {
+		Name               string
+		Body               string
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "CREATED",
+			Body:               `{"country_name":"Argentina"}`,
+			ExpectedBody:       `{"data":{"id":0,"country_name":"Argentina"}}`,
+			ExpectedStatusCode: http.StatusCreated,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			Body:               `{"country_name":`,
+			ExpectedBody:       `{"message":"invalid format: body with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "CONFLICT",
+			Body:               `{"country_name":"Argentina"}`,
+			MockError:          utils.EConflict("country", "country_name"),
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			Name:               "UNPROCESSABLE_ENTITY",
+			Body:               `{"country_name":""}`,
+			MockError:          utils.EZeroValue("country_name"),
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			service := new(MockCountryService)
+			service.On("Save", mock.Anything).Return(c.MockError)
+			countryHandler := handler.NewCountryHandler(service)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/countries", strings.NewReader(c.Body))
+			res := httptest.NewRecorder()
+
+			countryHandler.Create()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnitCountry_Update(t *testing.T) {
+	cases := []struct {
+		Name               string
+		IfMatch            string
+		Body               string
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "OK",
+			IfMatch:            `"1"`,
+			Body:               `{"country_name":"Chile"}`,
+			ExpectedBody:       `{"data":{"id":1,"country_name":"Chile","version":2}}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "PRECONDITION_FAILED",
+			IfMatch:            `"1"`,
+			Body:               `{"country_name":"Chile"}`,
+			MockError:          utils.EPreconditionFailed("country"),
+			ExpectedBody:       `{"message":"precondition failed: country was changed, read it again to get its current version", "status":"Precondition Failed"}`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "PRECONDITION_REQUIRED",
+			Body:               `{"country_name":"Chile"}`,
+			ExpectedBody:       `{"message":"precondition required: If-Match header with the ETag of the resource is required", "status":"Precondition Required"}`,
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			IfMatch:            `"1", "2"`,
+			Body:               `{"country_name":"Chile"}`,
+			ExpectedBody:       `{"message":"invalid format: If-Match with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", "1")
+
+			service := new(MockCountryService)
+			service.On("Update", &internal.Country{ID: 1, CountryName: "Chile", Version: 1}).Return(c.MockError).Run(func(args mock.Arguments) {
+				if c.MockError == nil {
+					args.Get(0).(*internal.Country).Version++
+				}
+			})
+			countryHandler := handler.NewCountryHandler(service)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/countries/1", strings.NewReader(c.Body))
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			countryHandler.Update()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			if c.ExpectedStatusCode == http.StatusOK {
+				require.Equal(t, `"2"`, res.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestUnitCountry_Delete(t *testing.T) {
+	cases := []struct {
+		Name               string
+		IfMatch            string
+		ID                 string
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "NO_CONTENT",
+			IfMatch:            `"1"`,
+			ID:                 "1",
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			IfMatch:            `"1"`,
+			ID:                 "asd",
+			ExpectedBody:       `{"message":"invalid format: id with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "NOT_FOUND",
+			IfMatch:            `"1"`,
+			ID:                 "9",
+			MockError:          utils.ENotFound("country"),
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:               "CONFLICT",
+			IfMatch:            `"1"`,
+			ID:                 "1",
+			MockError:          utils.EInUse("country", "2 sellers, 0 warehouses and 0 carriers"),
+			ExpectedBody:       `{"message":"entity in use: country is referenced by 2 sellers, 0 warehouses and 0 carriers", "status":"Conflict"}`,
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			Name:               "PRECONDITION_FAILED",
+			ID:                 "1",
+			IfMatch:            `"2"`,
+			MockError:          utils.EPreconditionFailed("country"),
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "PRECONDITION_REQUIRED",
+			ID:                 "1",
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", c.ID)
+
+			service := new(MockCountryService)
+			service.On("Delete", mock.Anything, mock.Anything).Return(c.MockError)
+			countryHandler := handler.NewCountryHandler(service)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/countries/"+c.ID, nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			countryHandler.Delete()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnitCountry_GetHierarchy(t *testing.T) {
+	hierarchy := []internal.CountryHierarchy{
+		{
+			Country: internal.Country{ID: 1, CountryName: "Argentina"},
+			Provinces: []internal.ProvinceHierarchy{
+				{
+					Province:   internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1},
+					Localities: []internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Name               string
+		RawQuery           string
+		ID                 int
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "OK",
+			RawQuery:           "id=1",
+			ID:                 1,
+			ExpectedBody:       `{"data":[{"id":1,"country_name":"Argentina","provinces":[{"id":1,"province_name":"Buenos Aires","country_id":1,"localities":[{"id":6700,"locality_name":"Lujan","province_id":1}]}]}]}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "OK_ALL",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			RawQuery:           "id=asd",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "NOT_FOUND",
+			RawQuery:           "id=9",
+			ID:                 9,
+			MockError:          utils.ENotFound("country"),
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:               "INTERNAL_SERVER_ERROR",
+			MockError:          errors.New("internal error"),
+			ExpectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			service := new(MockCountryService)
+			service.On("GetHierarchy", c.ID).Return(hierarchy, c.MockError)
+			countryHandler := handler.NewCountryHandler(service)
+
+			req := &http.Request{URL: &url.URL{RawQuery: c.RawQuery}}
+			res := httptest.NewRecorder()
+
+			countryHandler.GetHierarchy()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -23,23 +24,28 @@ func NewEmployeeHandler(sv internal.EmployeeService) *EmployeeDefault {
 	return &EmployeeDefault{sv: sv}
 }
 
-// GetAllEmployees handles the GET /employees route
+// GetAllEmployees handles the GET /employees route, paged by limit and cursor, sorted by sort
+// and filtered by any other query param, the deleted employees are listed with include_deleted by the admins
 func (h *EmployeeDefault) GetAllEmployees() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		employees, err := h.sv.FindAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
-			if err == utils.ErrNotFound {
-				utils.Error(w, http.StatusNotFound, "No employees found")
-			} else {
-				utils.Error(w, http.StatusInternalServerError, "An error occurred while retrieving employees")
-			}
+			utils.HandleError(w, err)
 			return
 		}
-		// returns status 200 and the data if all ok
-		response.JSON(w, http.StatusOK, map[string]any{
-			"message": "success",
-			"data":    employees,
-		})
+
+		if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		employees, pagination, err := h.sv.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, employees, pagination)
 	}
 }
 
@@ -54,7 +60,7 @@ func (h *EmployeeDefault) GetEmployeesByID() http.HandlerFunc {
 			return
 		}
 
-		employee, err := h.sv.FindByID(id)
+		employee, err := h.sv.FindByID(r.Context(), id)
 		if err != nil {
 			utils.HandleError(w, utils.ErrNotFound)
 			return
@@ -68,9 +74,11 @@ func (h *EmployeeDefault) GetEmployeesByID() http.HandlerFunc {
 				LastName:     employee.Attributes.LastName,
 				WarehouseID:  employee.Attributes.WarehouseID,
 			},
+			Version: employee.Version,
 		}
 
 		// returns status 200 and the data if all ok
+		utils.SetETag(w, employee.Version)
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
 			"data":    data,
@@ -91,7 +99,7 @@ func (h *EmployeeDefault) PostEmployees() http.HandlerFunc {
 		}
 
 		// create the employee
-		employee, err := h.sv.CreateEmployee(newEmployee)
+		employee, err := h.sv.CreateEmployee(r.Context(), newEmployee)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -106,6 +114,7 @@ func (h *EmployeeDefault) PostEmployees() http.HandlerFunc {
 }
 
 // PatchEmployees handles the PATCH /employees route
+// the If-Match header must hold the ETag of the employee the changes are made on
 func (h *EmployeeDefault) PatchEmployees() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := chi.URLParam(r, "id")
@@ -116,6 +125,12 @@ func (h *EmployeeDefault) PatchEmployees() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		var inputEmployee internal.Employee
 		// decode the json request body into Employee struct
 		err = json.NewDecoder(r.Body).Decode(&inputEmployee)
@@ -125,14 +140,16 @@ func (h *EmployeeDefault) PatchEmployees() http.HandlerFunc {
 		}
 
 		inputEmployee.ID = id
+		inputEmployee.Version = version
 		// update the employee
-		employee, err := h.sv.UpdateEmployee(inputEmployee)
+		employee, err := h.sv.UpdateEmployee(r.Context(), inputEmployee)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
 		// returns status 200 and the data if all ok
+		utils.SetETag(w, employee.Version)
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "success",
 			"data":    employee,
@@ -141,7 +158,7 @@ func (h *EmployeeDefault) PatchEmployees() http.HandlerFunc {
 }
 
 // DeleteEmployees handles the DELETE /employees/{id} route
-// it deletes an existing employee based on the provided ID
+// it deletes an existing employee based on the provided ID, in the version of the If-Match header
 func (h *EmployeeDefault) DeleteEmployees() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// extract the employee ID from the URL parameters and converts it to int
@@ -153,11 +170,17 @@ func (h *EmployeeDefault) DeleteEmployees() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		// delete the employee
-		err = h.sv.DeleteEmployee(id)
+		err = h.sv.DeleteEmployee(r.Context(), id, version)
 		if err != nil {
-			if err == utils.ErrNotFound {
-				utils.HandleError(w, utils.ErrNotFound)
+			if errors.Is(err, utils.ErrNotFound) || errors.Is(err, utils.ErrPreconditionFailed) {
+				utils.HandleError(w, err)
 			} else {
 				utils.HandleError(w, utils.ErrInvalidArguments)
 			}
@@ -171,3 +194,27 @@ func (h *EmployeeDefault) DeleteEmployees() http.HandlerFunc {
 		})
 	}
 }
+
+// RestoreEmployees handles the POST /employees/{id}/restore route
+// it brings back a soft deleted employee based on the provided ID
+func (h *EmployeeDefault) RestoreEmployees() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.ErrInvalidFormat)
+			return
+		}
+
+		employee, err := h.sv.RestoreEmployee(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		// returns status 200 and the restored employee if all ok
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "success",
+			"data":    employee,
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -18,31 +19,46 @@ type mockEmployeeService struct {
 	mock.Mock
 }
 
-func (m *mockEmployeeService) FindAll() (map[int]internal.Employee, error) {
+func (m *mockEmployeeService) FindAll(ctx context.Context) (map[int]internal.Employee, error) {
 	args := m.Called()
 	return args.Get(0).(map[int]internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeService) FindByID(id int) (internal.Employee, error) {
+func (m *mockEmployeeService) List(ctx context.Context, query utils.ListQuery) ([]internal.Employee, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Employee), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *mockEmployeeService) FindByID(ctx context.Context, id int) (internal.Employee, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeService) CreateEmployee(inputEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
+func (m *mockEmployeeService) CreateEmployee(ctx context.Context, inputEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
 	args := m.Called(inputEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeService) UpdateEmployee(newEmployee internal.Employee) (internal.Employee, error) {
+func (m *mockEmployeeService) UpdateEmployee(ctx context.Context, newEmployee internal.Employee) (internal.Employee, error) {
 	args := m.Called(newEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeService) DeleteEmployee(id int) (err error) {
-	args := m.Called(id)
+func (m *mockEmployeeService) DeleteEmployee(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *mockEmployeeService) RestoreEmployee(ctx context.Context, id int) (employee internal.Employee, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Employee), args.Error(1)
+}
+
+func (m *mockEmployeeService) PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 type mockWarehouseValidation struct {
 	mock.Mock
 }
@@ -139,7 +155,7 @@ func TestEmployeeHandler_FindAll(t *testing.T) {
 	t.Run("FindAll - Success", func(t *testing.T) {
 		mockService := new(mockEmployeeService)
 		handler := NewEmployeeHandler(mockService)
-		mockService.On("FindAll").Return(map[int]internal.Employee{}, nil)
+		mockService.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Employee{mockEmployee}, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
 
 		req := httptest.NewRequest("GET", "/employees", nil)
 		res := httptest.NewRecorder()
@@ -147,12 +163,14 @@ func TestEmployeeHandler_FindAll(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, res.Result().StatusCode)
 		assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"data":[{"id":1,"attributes":{"card_number_id":"12345","first_name":"Aelin",`+
+			`"last_name":"Galanthynius","warehouse_id":1}}],"pagination":{"limit":50,"has_more":false}}`, res.Body.String())
 	})
 
 	t.Run("FindAll - Internal Error", func(t *testing.T) {
 		mockService := new(mockEmployeeService)
 		handler := NewEmployeeHandler(mockService)
-		mockService.On("FindAll").Return(map[int]internal.Employee{}, assert.AnError)
+		mockService.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Employee{}, utils.Pagination{}, assert.AnError)
 
 		req := httptest.NewRequest("GET", "/employees", nil)
 		res := httptest.NewRecorder()
@@ -215,9 +233,10 @@ func TestEmployeeHandler_Delete(t *testing.T) {
 	handler := NewEmployeeHandler(mockService)
 
 	t.Run("Delete - Valid ID", func(t *testing.T) {
-		mockService.On("DeleteEmployee", 1).Return(nil)
+		mockService.On("DeleteEmployee", 1, 1).Return(nil)
 
 		req := httptest.NewRequest("DELETE", "/employees/1", nil)
+		req.Header.Set("If-Match", `"1"`)
 		rctx := chi.NewRouteContext()
 		rctx.URLParams.Add("id", "1")
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -229,9 +248,10 @@ func TestEmployeeHandler_Delete(t *testing.T) {
 	})
 
 	t.Run("Delete - Invalid ID", func(t *testing.T) {
-		mockService.On("DeleteEmployee", 99).Return(utils.ErrNotFound)
+		mockService.On("DeleteEmployee", 99, 1).Return(utils.ErrNotFound)
 
 		req := httptest.NewRequest("DELETE", "/employees/99", nil)
+		req.Header.Set("If-Match", `"1"`)
 		rctx := chi.NewRouteContext()
 		rctx.URLParams.Add("id", "99")
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -255,6 +275,32 @@ func TestEmployeeHandler_Delete(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, res.Result().StatusCode)
 		assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
 	})
+
+	t.Run("Delete - Changed Version", func(t *testing.T) {
+		mockService.On("DeleteEmployee", 1, 2).Return(utils.EPreconditionFailed("employee"))
+
+		req := httptest.NewRequest("DELETE", "/employees/1", nil)
+		req.Header.Set("If-Match", `"2"`)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		res := httptest.NewRecorder()
+		handler.DeleteEmployees()(res, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+	})
+
+	t.Run("Delete - Missing If-Match", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/employees/1", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		res := httptest.NewRecorder()
+		handler.DeleteEmployees()(res, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+		mockService.AssertNumberOfCalls(t, "DeleteEmployee", 3)
+	})
 }
 
 func TestEmployeeHandler_Update(t *testing.T) {
@@ -265,6 +311,7 @@ func TestEmployeeHandler_Update(t *testing.T) {
 		mockService.On("UpdateEmployee", mockUpdatedEmployee).Return(mockUpdatedEmployee, nil)
 
 		req := httptest.NewRequest("PATCH", "/employees/1", bytes.NewBufferString(mockJsonEmployee))
+		req.Header.Set("If-Match", "*")
 		rctx := chi.NewRouteContext()
 		rctx.URLParams.Add("id", "1")
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -280,6 +327,7 @@ func TestEmployeeHandler_Update(t *testing.T) {
 		mockService.On("UpdateEmployee", mock.Anything).Return(internal.Employee{}, utils.ErrNotFound)
 
 		req := httptest.NewRequest("PATCH", "/employees/99", bytes.NewBufferString(mockJsonEmployee))
+		req.Header.Set("If-Match", `"1"`)
 		rctx := chi.NewRouteContext()
 		rctx.URLParams.Add("id", "99")
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -289,6 +337,18 @@ func TestEmployeeHandler_Update(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
 		assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
 	})
+
+	t.Run("Update - Without If-Match", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/employees/1", bytes.NewBufferString(mockJsonEmployee))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		res := httptest.NewRecorder()
+		handler.PatchEmployees()(res, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+		mockService.AssertNumberOfCalls(t, "UpdateEmployee", 2)
+	})
 }
 
 func TestEmployeeHandler_Create(t *testing.T) {
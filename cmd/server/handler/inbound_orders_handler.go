@@ -36,7 +36,7 @@ func (h *InboundOrderHandler) CreateInboundOrder() http.HandlerFunc {
 		newOrder := request.Data
 
 		// Cria a ordem usando o serviço
-		order, err := h.service.CreateInboundOrder(newOrder)
+		order, err := h.service.CreateInboundOrder(r.Context(), newOrder)
 		if err != nil {
 			if errors.Is(err, utils.ErrConflict) {
 				utils.Error(w, http.StatusConflict, err.Error())
@@ -72,9 +72,16 @@ func (h *InboundOrderHandler) CreateInboundOrder() http.HandlerFunc {
 
 // GenerateInboundOrdersReport sHandle GET /api/v1/employees/reportInboundOrders
 //
-//	handles the generation of the report.
+//	handles the generation of the report, exported as CSV or XLSX when asked by the format query
+//	param or the Accept header.
 func (h *InboundOrderHandler) GenerateInboundOrdersReport() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		idsParam := r.URL.Query().Get("id")
 
 		var ids []int
@@ -82,7 +89,9 @@ func (h *InboundOrderHandler) GenerateInboundOrdersReport() http.HandlerFunc {
 		if idsParam != "" {
 			idsStrings := strings.Split(idsParam, ",")
 			for _, idStr := range idsStrings {
-				id, err := strconv.Atoi(strings.TrimSpace(idStr))
+				var id int
+
+				id, err = strconv.Atoi(strings.TrimSpace(idStr))
 				if err != nil {
 					utils.HandleError(w, utils.ErrInvalidFormat)
 					return
@@ -92,7 +101,27 @@ func (h *InboundOrderHandler) GenerateInboundOrdersReport() http.HandlerFunc {
 			}
 		}
 
-		report, err := h.service.GenerateInboundOrdersReport(ids)
+		// the report of every employee is streamed a page at a time
+		if format != utils.FormatJSON && len(ids) == 0 {
+			afterEmployeeID := 0
+
+			utils.ExportPages(w, format, "inbound_orders", func() ([]internal.EmployeeInboundOrdersReport, bool, error) {
+				page, err := h.service.GenerateInboundOrdersReportPage(r.Context(), afterEmployeeID, utils.ExportPageSize)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if len(page) > 0 {
+					afterEmployeeID = page[len(page)-1].ID
+				}
+
+				return page, len(page) == utils.ExportPageSize, nil
+			})
+
+			return
+		}
+
+		report, err := h.service.GenerateInboundOrdersReport(r.Context(), ids)
 		if err != nil {
 			if errors.Is(err, utils.ErrConflict) {
 				utils.Error(w, http.StatusConflict, err.Error())
@@ -114,6 +143,11 @@ func (h *InboundOrderHandler) GenerateInboundOrdersReport() http.HandlerFunc {
 			return
 		}
 
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "inbound_orders", report)
+			return
+		}
+
 		utils.JSON(w, http.StatusOK, report)
 	}
 }
@@ -1,12 +1,14 @@
 package handler_test
 
 import (
+	"context"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -20,16 +22,21 @@ type MockInBoundService struct {
 	mock.Mock
 }
 
-func (m *MockInBoundService) CreateInboundOrder(inboundOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
+func (m *MockInBoundService) CreateInboundOrder(ctx context.Context, inboundOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
 	args := m.Called(inboundOrder)
 	return args.Get(0).(internal.InboundOrder), args.Error(1)
 }
 
-func (m *MockInBoundService) GenerateInboundOrdersReport(ids []int) ([]internal.EmployeeInboundOrdersReport, error) {
+func (m *MockInBoundService) GenerateInboundOrdersReport(ctx context.Context, ids []int) ([]internal.EmployeeInboundOrdersReport, error) {
 	args := m.Called(ids)
 	return args.Get(0).([]internal.EmployeeInboundOrdersReport), args.Error(1)
 }
 
+func (m *MockInBoundService) GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]internal.EmployeeInboundOrdersReport, error) {
+	args := m.Called(afterEmployeeID, limit)
+	return args.Get(0).([]internal.EmployeeInboundOrdersReport), args.Error(1)
+}
+
 func (m *MockInBoundService) FindByID(id int) (internal.InboundOrder, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.InboundOrder), args.Error(1)
@@ -169,3 +176,26 @@ func TestUnitInboundOrder_GenerateInboundOrdersReport(t *testing.T) {
 	}
 
 }
+
+func TestUnitInboundOrder_GenerateInboundOrdersReport_Export(t *testing.T) {
+	firstPage := make([]internal.EmployeeInboundOrdersReport, utils.ExportPageSize)
+	for i := range firstPage {
+		firstPage[i] = internal.EmployeeInboundOrdersReport{ID: i + 1, CardNumberID: "C" + strconv.Itoa(i+1), WarehouseID: 1}
+	}
+
+	service := new(MockInBoundService)
+	service.On("GenerateInboundOrdersReportPage", 0, utils.ExportPageSize).Return(firstPage, nil)
+	service.On("GenerateInboundOrdersReportPage", utils.ExportPageSize, utils.ExportPageSize).
+		Return([]internal.EmployeeInboundOrdersReport{{ID: utils.ExportPageSize + 1, CardNumberID: "LAST", WarehouseID: 1, InboundOrdersCount: 2}}, nil)
+	handler := handler.NewInboundOrderHandler(service)
+	request := &http.Request{URL: &url.URL{RawQuery: "format=csv"}}
+	response := httptest.NewRecorder()
+	handler.GenerateInboundOrdersReport()(response, request)
+
+	lines := strings.Split(strings.TrimSuffix(response.Body.String(), "\n"), "\n")
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Len(t, lines, utils.ExportPageSize+2)
+	require.Equal(t, "id,id_card_number,first_name,last_name,warehouse_id,inbound_orders_count", lines[0])
+	require.Equal(t, "501,LAST,,,1,2", lines[len(lines)-1])
+	service.AssertNotCalled(t, "GenerateInboundOrdersReport", mock.Anything)
+}
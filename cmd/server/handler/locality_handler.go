@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
@@ -22,18 +23,191 @@ func NewLocalityHandler(service internal.LocalityService) *LocalityHandler {
 
 type reqPostLocality struct {
 	Data struct {
-		ID           int    `json:"id"`
-		LocalityName string `json:"locality_name"`
-		ProvinceName string `json:"province_name"`
-		CountryName  string `json:"country_name"`
+		ID           int      `json:"id"`
+		LocalityName string   `json:"locality_name"`
+		ProvinceName string   `json:"province_name"`
+		CountryName  string   `json:"country_name"`
+		Latitude     *float64 `json:"latitude"`
+		Longitude    *float64 `json:"longitude"`
 	} `json:"data"`
 }
 
+type reqPatchLocality struct {
+	LocalityName string   `json:"locality_name"`
+	ProvinceID   int      `json:"province_id"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+}
+
+// GetAll responds with all the localities
+//
+//	@Summary		Get all localities
+//	@Description	Retrieve all the localities
+//	@Tags			localities
+//	@Produce		json
+//	@Success		200	{array}	internal.Locality
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities [get]
+func (h *LocalityHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		localities, err := h.service.GetAll(r.Context())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, localities)
+	}
+}
+
+// GetByID responds with the locality of the id path param
+//
+//	@Summary		Get locality by ID
+//	@Description	Get a locality by its ID, its version is the ETag header
+//	@Tags			localities
+//	@Produce		json
+//	@Param			id	path	int	true	"Locality ID"
+//	@Success		200	{object}	internal.Locality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Locality not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/{id} [get]
+func (h *LocalityHandler) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		locality, err := h.service.GetByID(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, locality.Version)
+		utils.JSON(w, http.StatusOK, locality)
+	}
+}
+
+// Update changes the name and/or the province of the locality of the id path param, in the
+// version of the If-Match header
+//
+//	@Summary		Update a locality
+//	@Description	Update a locality by its ID, in the version of the If-Match header
+//	@Tags			localities
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Locality ID"
+//	@Param			If-Match	header	string	true	"ETag of the locality read, or *"
+//	@Param			locality	body	reqPatchLocality	true	"Locality data"
+//	@Success		200	{object}	internal.Locality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID or request body"
+//	@Failure		404	{object}	utils.ErrorResponse	"Locality not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Name already taken"
+//	@Failure		412	{object}	utils.ErrorResponse	"The locality was changed since its ETag was read"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/{id} [patch]
+func (h *LocalityHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		var body reqPatchLocality
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		locality := internal.Locality{
+			ID:           id,
+			LocalityName: body.LocalityName,
+			ProvinceID:   body.ProvinceID,
+			Latitude:     body.Latitude,
+			Longitude:    body.Longitude,
+			Version:      version,
+		}
+
+		if err := h.service.Update(r.Context(), &locality); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, locality.Version)
+		utils.JSON(w, http.StatusOK, locality)
+	}
+}
+
+// Delete removes the locality of the id path param,
+// responds 409 when sellers, warehouses or carriers are located in it and 412 when it is not in
+// the version of the If-Match header
+//
+//	@Summary		Delete a locality
+//	@Description	Delete a locality by its ID, in the version of the If-Match header
+//	@Tags			localities
+//	@Param			id	path	int	true	"Locality ID"
+//	@Param			If-Match	header	string	true	"ETag of the locality read, or *"
+//	@Success		204
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Locality not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Sellers, warehouses or carriers are located in the locality"
+//	@Failure		412	{object}	utils.ErrorResponse	"The locality was changed since its ETag was read"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/{id} [delete]
+func (h *LocalityHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if err := h.service.Delete(r.Context(), id, version); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusNoContent, nil)
+	}
+}
+
 // CreateLocality handles the creation of a new locality.
 // It decodes the request body into a reqPostLocality struct, validates it,
 // and then creates a new Locality, Province, and Country based on the provided data.
 // If the locality already exists, it returns a 409 Conflict status.
 // If the provided arguments are invalid, it returns a 422 Unprocessable Entity status.
+//
+//	@Summary		Create a new locality
+//	@Description	Create a locality, along with its province and country when they do not exist
+//	@Tags			localities
+//	@Accept			json
+//	@Produce		json
+//	@Param			locality	body	reqPostLocality	true	"Locality details"
+//	@Success		201	{object}	internal.Locality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid request body"
+//	@Failure		409	{object}	utils.ErrorResponse	"Locality already exists"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities [post]
 func (h *LocalityHandler) CreateLocality() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var body reqPostLocality
@@ -46,6 +220,8 @@ func (h *LocalityHandler) CreateLocality() http.HandlerFunc {
 		newLocality := internal.Locality{
 			ID:           body.Data.ID,
 			LocalityName: body.Data.LocalityName,
+			Latitude:     body.Data.Latitude,
+			Longitude:    body.Data.Longitude,
 		}
 		province := internal.Province{
 			ProvinceName: body.Data.ProvinceName,
@@ -54,7 +230,7 @@ func (h *LocalityHandler) CreateLocality() http.HandlerFunc {
 			CountryName: body.Data.CountryName,
 		}
 
-		err := h.service.Save(&newLocality, &province, &country)
+		err := h.service.Save(r.Context(), &newLocality, &province, &country)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -69,12 +245,29 @@ func (h *LocalityHandler) CreateLocality() http.HandlerFunc {
 // If the 'id' parameter is invalid, it responds with a 400 Bad Request status.
 // If no sellers are found for the given ID, it responds with a 404 Not Found status.
 // For any other errors, it responds with a 500 Internal Server Error status.
-// On success, it responds with a 200 OK status and the sellers data in JSON format.
+// On success, it responds with a 200 OK status and the sellers data in JSON format, or as a CSV or XLSX
+// file when asked by the format query param or the Accept header.
+//
+//	@Summary		Get the sellers of the localities
+//	@Description	Count the sellers of a locality, or of every locality
+//	@Tags			localities
+//	@Produce		json
+//	@Param			id	query	int	false	"Locality ID, every locality when missing"
+//	@Param			format	query	string	false	"json, csv or xlsx"
+//	@Success		200	{array}	internal.SellersByLocality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID or format"
+//	@Failure		404	{object}	utils.ErrorResponse	"Locality not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/reportSellers [get]
 func (h *LocalityHandler) GetSellersByLocalityID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := 0
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
 
-		var err error
+		id := 0
 
 		if strings.TrimSpace(r.URL.Query().Get("id")) != "" {
 			id, err = strconv.Atoi(r.URL.Query().Get("id"))
@@ -84,13 +277,38 @@ func (h *LocalityHandler) GetSellersByLocalityID() http.HandlerFunc {
 			}
 		}
 
-		locality, err := h.service.GetSellersByLocalityID(id)
+		// the report of every locality is streamed a page at a time
+		if format != utils.FormatJSON && id == 0 {
+			afterLocalityID := 0
+
+			utils.ExportPages(w, format, "sellers_by_locality", func() ([]internal.SellersByLocality, bool, error) {
+				page, err := h.service.GetSellersByLocalityPage(r.Context(), afterLocalityID, utils.ExportPageSize)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if len(page) > 0 {
+					afterLocalityID = page[len(page)-1].LocalityID
+				}
+
+				return page, len(page) == utils.ExportPageSize, nil
+			})
+
+			return
+		}
+
+		locality, err := h.service.GetSellersByLocalityID(r.Context(), id)
 
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "sellers_by_locality", locality)
+			return
+		}
+
 		utils.JSON(w, http.StatusOK, locality)
 	}
 }
@@ -101,12 +319,29 @@ func (h *LocalityHandler) GetSellersByLocalityID() http.HandlerFunc {
 // If the "id" parameter is missing or invalid, it defaults to 0.
 // If an error occurs during the service call or while encoding the response, it returns
 // an appropriate HTTP error response.
-// The response is returned as a JSON-encoded list of carriers with a status code of 200 OK.
+// The response is returned as a JSON-encoded list of carriers with a status code of 200 OK,
+// or as a CSV or XLSX file when asked by the format query param or the Accept header.
+//
+//	@Summary		Get the carriers of the localities
+//	@Description	Count the carriers of a locality, or of every locality
+//	@Tags			localities
+//	@Produce		json
+//	@Param			id	query	int	false	"Locality ID, every locality when missing"
+//	@Param			format	query	string	false	"json, csv or xlsx"
+//	@Success		200	{array}	internal.CarriesByLocality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID or format"
+//	@Failure		404	{object}	utils.ErrorResponse	"Locality not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/reportCarries [get]
 func (handler *LocalityHandler) GetCarriesByLocalityID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := 0
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
 
-		var err error
+		id := 0
 
 		if strings.TrimSpace(r.URL.Query().Get("id")) != "" {
 			id, err = strconv.Atoi(r.URL.Query().Get("id"))
@@ -116,12 +351,73 @@ func (handler *LocalityHandler) GetCarriesByLocalityID() http.HandlerFunc {
 			}
 		}
 
-		buyers, err := handler.service.GetCarriesByLocalityID(id)
+		// the report of every locality is streamed a page at a time
+		if format != utils.FormatJSON && id == 0 {
+			afterLocalityID := 0
+
+			utils.ExportPages(w, format, "carries_by_locality", func() ([]internal.CarriesByLocality, bool, error) {
+				page, err := handler.service.GetCarriesByLocalityPage(r.Context(), afterLocalityID, utils.ExportPageSize)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if len(page) > 0 {
+					afterLocalityID = page[len(page)-1].LocalityID
+				}
+
+				return page, len(page) == utils.ExportPageSize, nil
+			})
+
+			return
+		}
+
+		buyers, err := handler.service.GetCarriesByLocalityID(r.Context(), id)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "carries_by_locality", buyers)
+			return
+		}
+
 		utils.JSON(w, http.StatusOK, buyers)
 	}
 }
+
+// ImportLocalities handles the bulk import of a CSV file of countries, provinces and localities.
+// The file is sent as the request body or as the file field of a multipart form, it must have the
+// country_name, province_name, locality_id and locality_name columns, and optionally latitude and longitude.
+// It responds 200 with a report of the result of every row, rows failing validation don't stop the import.
+// If the file is malformed it responds 400, if it lacks a column it responds 422.
+//
+//	@Summary		Import localities
+//	@Description	Upsert the countries, provinces and localities of a CSV file, sent as the body or the file field of a multipart form
+//	@Tags			localities
+//	@Accept			text/csv,mpfd
+//	@Produce		json
+//	@Success		200	{object}	internal.LocalityImportReport
+//	@Failure		400	{object}	utils.ErrorResponse	"Malformed file"
+//	@Failure		422	{object}	utils.ErrorResponse	"Missing column"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/localities/import [post]
+func (h *LocalityHandler) ImportLocalities() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		file, err := utils.ImportFile(w, r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		defer file.Close()
+
+		report, err := h.service.Import(r.Context(), file)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, report)
+	}
+}
@@ -1,14 +1,17 @@
 package handler_test
 
 import (
+	"context"
 	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -21,20 +24,55 @@ type MockLocalityService struct {
 	mock.Mock
 }
 
-func (m *MockLocalityService) Save(locality *internal.Locality, province *internal.Province, country *internal.Country) error {
+func (m *MockLocalityService) Save(ctx context.Context, locality *internal.Locality, province *internal.Province, country *internal.Country) error {
 	args := m.Called(locality, province, country)
 	return args.Error(0)
 }
-func (m *MockLocalityService) GetSellersByLocalityID(localityId int) ([]internal.SellersByLocality, error) {
+func (m *MockLocalityService) GetSellersByLocalityID(ctx context.Context, localityId int) ([]internal.SellersByLocality, error) {
 	args := m.Called(localityId)
 	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
 }
 
-func (m *MockLocalityService) GetCarriesByLocalityID(localityId int) ([]internal.CarriesByLocality, error) {
+func (m *MockLocalityService) GetCarriesByLocalityID(ctx context.Context, localityId int) ([]internal.CarriesByLocality, error) {
 	args := m.Called(localityId)
 	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
 }
 
+func (m *MockLocalityService) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityService) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func (m *MockLocalityService) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityService) GetByID(ctx context.Context, id int) (internal.Locality, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityService) Update(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockLocalityService) Import(ctx context.Context, file io.Reader) (internal.LocalityImportReport, error) {
+	args := m.Called(file)
+	return args.Get(0).(internal.LocalityImportReport), args.Error(1)
+}
+
 func TestUnitLocality_CreateLocality(t *testing.T) {
 	cases := []struct {
 		Name               string
@@ -92,6 +130,63 @@ func TestUnitLocality_CreateLocality(t *testing.T) {
 	}
 }
 
+func TestUnitLocality_Delete(t *testing.T) {
+	cases := []struct {
+		Name               string
+		IfMatch            string
+		MockError          error
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "NO_CONTENT",
+			IfMatch:            `"3"`,
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:               "CONFLICT",
+			IfMatch:            `"3"`,
+			MockError:          utils.EInUse("locality", "1 sellers, 0 warehouses and 0 carriers"),
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			Name:               "PRECONDITION_FAILED",
+			IfMatch:            `"2"`,
+			MockError:          utils.EPreconditionFailed("locality"),
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "PRECONDITION_FAILED_WEAK",
+			IfMatch:            `W/"3"`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "PRECONDITION_REQUIRED",
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", "1")
+
+			service := new(MockLocalityService)
+			service.On("Delete", mock.Anything, mock.Anything).Return(c.MockError)
+			localityHandler := handler.NewLocalityHandler(service)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/localities/1", nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			localityHandler.Delete()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
+
 func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 	cases := []struct {
 		TestName           string
@@ -146,6 +241,61 @@ func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 
 }
 
+func TestUnitLocality_GetSellersByLocalityID_Export(t *testing.T) {
+	sellers := []internal.SellersByLocality{{LocalityID: 1, LocalityName: "Rosario", SellersCount: 3}}
+
+	t.Run("CSV from the Accept header", func(t *testing.T) {
+		service := new(MockLocalityService)
+		service.On("GetSellersByLocalityPage", 0, utils.ExportPageSize).Return(sellers, nil)
+		handler := handler.NewLocalityHandler(service)
+		request := &http.Request{
+			URL:    &url.URL{},
+			Header: http.Header{"Accept": []string{"text/csv"}},
+		}
+		response := httptest.NewRecorder()
+		handler.GetSellersByLocalityID()(response, request)
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "text/csv", response.Header().Get("Content-Type"))
+		require.Equal(t, "attachment; filename=sellers_by_locality.csv", response.Header().Get("Content-Disposition"))
+		require.Equal(t, "locality_id,locality_name,seller_count\n1,Rosario,3\n", response.Body.String())
+		service.AssertNotCalled(t, "GetSellersByLocalityID", mock.Anything)
+	})
+
+	t.Run("a locality exported at once", func(t *testing.T) {
+		service := new(MockLocalityService)
+		service.On("GetSellersByLocalityID", 1).Return(sellers, nil)
+		handler := handler.NewLocalityHandler(service)
+		request := &http.Request{URL: &url.URL{RawQuery: "id=1&format=csv"}}
+		response := httptest.NewRecorder()
+		handler.GetSellersByLocalityID()(response, request)
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "locality_id,locality_name,seller_count\n1,Rosario,3\n", response.Body.String())
+		service.AssertNotCalled(t, "GetSellersByLocalityPage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		service := new(MockLocalityService)
+		handler := handler.NewLocalityHandler(service)
+		request := &http.Request{URL: &url.URL{RawQuery: "format=pdf"}}
+		response := httptest.NewRecorder()
+		handler.GetSellersByLocalityID()(response, request)
+		require.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "GetSellersByLocalityID", mock.Anything)
+	})
+}
+
+func TestUnitLocality_GetCarriesByLocalityID_Export(t *testing.T) {
+	service := new(MockLocalityService)
+	service.On("GetCarriesByLocalityPage", 0, utils.ExportPageSize).Return([]internal.CarriesByLocality{{LocalityID: 2, LocalityName: "Mendoza", CarriesCount: 1}}, nil)
+	handler := handler.NewLocalityHandler(service)
+	request := &http.Request{URL: &url.URL{RawQuery: "format=csv"}}
+	response := httptest.NewRecorder()
+	handler.GetCarriesByLocalityID()(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Equal(t, "attachment; filename=carries_by_locality.csv", response.Header().Get("Content-Disposition"))
+	require.Equal(t, "locality_id,locality_name,carries_count\n2,Mendoza,1\n", response.Body.String())
+}
+
 func TestUnitLocality_GetCarriersByLocalityID(t *testing.T) {
 	cases := []struct {
 		TestName           string
@@ -199,3 +349,80 @@ func TestUnitLocality_GetCarriersByLocalityID(t *testing.T) {
 	}
 
 }
+
+func TestUnitLocality_ImportLocalities(t *testing.T) {
+	csvFile := "country_name,province_name,locality_id,locality_name\nArgentina,Buenos Aires,6700,Lujan\n"
+	report := internal.LocalityImportReport{
+		Created: 1,
+		Rows:    []internal.LocalityImportResult{{Line: 2, LocalityID: 6700, Status: internal.LocalityImportCreated}},
+	}
+
+	newMultipart := func(field string) (string, string) {
+		var body strings.Builder
+
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile(field, "localities.csv")
+		_, _ = part.Write([]byte(csvFile))
+		_ = writer.Close()
+
+		return body.String(), writer.FormDataContentType()
+	}
+
+	multipartBody, multipartContentType := newMultipart("file")
+	wrongFieldBody, wrongFieldContentType := newMultipart("document")
+
+	cases := []struct {
+		Name               string
+		Body               string
+		ContentType        string
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "OK",
+			Body:               csvFile,
+			ContentType:        "text/csv",
+			ExpectedBody:       `{"data":{"created":1,"updated":0,"unchanged":0,"failed":0,"rows":[{"line":2,"locality_id":6700,"status":"created"}]}}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "OK_MULTIPART",
+			Body:               multipartBody,
+			ContentType:        multipartContentType,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "BAD_REQUEST_MULTIPART_WITHOUT_FILE",
+			Body:               wrongFieldBody,
+			ContentType:        wrongFieldContentType,
+			ExpectedBody:       `{"message":"invalid format: file with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "UNPROCESSABLE_ENTITY",
+			Body:               "country_name\nArgentina\n",
+			ContentType:        "text/csv",
+			ErrorToReturn:      utils.EBR("csv file is missing the province_name column"),
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			service := new(MockLocalityService)
+			service.On("Import", mock.Anything).Return(report, c.ErrorToReturn)
+			localityHandler := handler.NewLocalityHandler(service)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/localities/import", strings.NewReader(c.Body))
+			req.Header.Set("Content-Type", c.ContentType)
+			res := httptest.NewRecorder()
+
+			localityHandler.ImportLocalities()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+type PackagingUnitHandler struct {
+	service internal.PackagingUnitService
+}
+
+func NewPackagingUnitHandler(service internal.PackagingUnitService) *PackagingUnitHandler {
+	return &PackagingUnitHandler{service: service}
+}
+
+func (h *PackagingUnitHandler) GetPackagingUnits(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	units, err := h.service.GetPackagingUnits(r.Context(), productID)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": units,
+	})
+}
+
+func (h *PackagingUnitHandler) GetPackagingUnitByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	unit, err := h.service.GetPackagingUnitByID(r.Context(), id)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	utils.SetETag(w, unit.Version)
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": unit,
+	})
+}
+
+func (h *PackagingUnitHandler) CreatePackagingUnit(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	var newUnit internal.PackagingUnitAttributes
+
+	err = json.NewDecoder(r.Body).Decode(&newUnit)
+	if err != nil {
+		utils.HandleError(w, utils.ErrInvalidFormat)
+		return
+	}
+
+	newUnit.ProductID = productID
+
+	unit, err := h.service.CreatePackagingUnit(r.Context(), newUnit)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"data": unit,
+	})
+}
+
+func (h *PackagingUnitHandler) UpdatePackagingUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	var inputUnit internal.PackagingUnit
+
+	err = json.NewDecoder(r.Body).Decode(&inputUnit)
+	if err != nil {
+		utils.HandleError(w, utils.ErrInvalidFormat)
+		return
+	}
+
+	inputUnit.ID = id
+	inputUnit.Version = version
+
+	unit, err := h.service.UpdatePackagingUnit(r.Context(), inputUnit)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	utils.SetETag(w, unit.Version)
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": unit,
+	})
+}
+
+func (h *PackagingUnitHandler) DeletePackagingUnit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	err = h.service.DeletePackagingUnit(r.Context(), id, version)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// ConvertQuantity converts the query param quantity of the product between the packaging units
+// given by from and to, omitting any of them means the product base unit
+func (h *PackagingUnitHandler) ConvertQuantity(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	quantity, err := strconv.ParseFloat(query.Get("quantity"), 64)
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("quantity"))
+		return
+	}
+
+	unitIDs := map[string]*int{"from": new(int), "to": new(int)}
+	for name, target := range unitIDs {
+		if value := query.Get(name); value != "" {
+			*target, err = strconv.Atoi(value)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest(name))
+				return
+			}
+		}
+	}
+
+	conversion, err := h.service.ConvertQuantity(r.Context(), productID, quantity, *unitIDs["from"], *unitIDs["to"])
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": conversion,
+	})
+}
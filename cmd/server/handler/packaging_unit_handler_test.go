@@ -0,0 +1,207 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPackagingUnitService struct {
+	mock.Mock
+}
+
+func (m *mockPackagingUnitService) GetPackagingUnits(ctx context.Context, productID int) ([]internal.PackagingUnit, error) {
+	args := m.Called(productID)
+	return args.Get(0).([]internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitService) GetPackagingUnitByID(ctx context.Context, id int) (internal.PackagingUnit, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitService) CreatePackagingUnit(ctx context.Context, newUnit internal.PackagingUnitAttributes) (internal.PackagingUnit, error) {
+	args := m.Called(newUnit)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitService) UpdatePackagingUnit(ctx context.Context, inputUnit internal.PackagingUnit) (internal.PackagingUnit, error) {
+	args := m.Called(inputUnit)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitService) DeletePackagingUnit(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockPackagingUnitService) ConvertQuantity(ctx context.Context, productID int, quantity float64, fromUnitID, toUnitID int) (internal.PackagingConversion, error) {
+	args := m.Called(productID, quantity, fromUnitID, toUnitID)
+	return args.Get(0).(internal.PackagingConversion), args.Error(1)
+}
+
+func (m *mockPackagingUnitService) ToBaseUnits(ctx context.Context, productID, packagingUnitID, quantity int) (int, error) {
+	args := m.Called(productID, packagingUnitID, quantity)
+	return args.Int(0), args.Error(1)
+}
+
+func newPackagingUnitRequest(method, target, id, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestUnitPackagingUnitHandler_CreatePackagingUnit(t *testing.T) {
+	newUnit := internal.PackagingUnitAttributes{
+		ProductID:       1,
+		Name:            "Box",
+		UnitsPerPackage: 12,
+		Width:           30,
+		Height:          15,
+		Length:          40,
+		NetWeight:       14.4,
+		Barcode:         "17791234000019",
+	}
+
+	cases := []struct {
+		TestName           string
+		ProductID          string
+		Body               string
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "CreatePackagingUnit_OK",
+			ProductID:          "1",
+			Body:               `{"name":"Box","units_per_package":12,"width":30,"height":15,"length":40,"net_weight":14.4,"barcode":"17791234000019"}`,
+			ExpectedBody:       `{"data":{"id":1,"product_id":1,"name":"Box","units_per_package":12,"width":30,"height":15,"length":40,"net_weight":14.4,"barcode":"17791234000019"}}`,
+			ExpectedStatusCode: http.StatusCreated,
+		},
+		{
+			TestName:           "CreatePackagingUnit_Conflict",
+			ProductID:          "1",
+			Body:               `{"name":"Box","units_per_package":12,"width":30,"height":15,"length":40,"net_weight":14.4,"barcode":"17791234000019"}`,
+			ErrorToReturn:      utils.EConflict("Packaging unit", "barcode"),
+			ExpectedBody:       `{"status":"Conflict","message":"entity already exists: Packaging unit with attribute 'barcode' already exists"}`,
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			TestName:           "CreatePackagingUnit_BadID",
+			ProductID:          "a",
+			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: Invalid ID with invalid format"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockPackagingUnitService)
+			service.On("CreatePackagingUnit", newUnit).Return(internal.PackagingUnit{ID: 1, PackagingUnitAttributes: newUnit}, c.ErrorToReturn)
+
+			handler := handler.NewPackagingUnitHandler(service)
+
+			req := newPackagingUnitRequest(http.MethodPost, "/api/v1/products/"+c.ProductID+"/packagingUnits", c.ProductID, c.Body)
+			res := httptest.NewRecorder()
+			handler.CreatePackagingUnit(res, req)
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestUnitPackagingUnitHandler_ConvertQuantity(t *testing.T) {
+	cases := []struct {
+		TestName           string
+		Query              string
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "ConvertQuantity_OK",
+			Query:              "quantity=2&from=2&to=1",
+			ExpectedBody:       `{"data":{"product_id":1,"from_unit_id":2,"to_unit_id":1,"quantity":2,"base_quantity":96,"converted_quantity":8}}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "ConvertQuantity_MissingQuantity",
+			Query:              "from=2&to=1",
+			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: quantity with invalid format"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "ConvertQuantity_UnknownUnit",
+			Query:              "quantity=2&from=2&to=1",
+			ErrorToReturn:      utils.EDependencyNotFound("Packaging unit", "id"),
+			ExpectedBody:       `{"status":"Unprocessable Entity","message":"invalid arguments: Packaging unit with 'id' doesn't exist"}`,
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockPackagingUnitService)
+			service.On("ConvertQuantity", 1, 2.0, 2, 1).Return(internal.PackagingConversion{
+				ProductID:         1,
+				FromUnitID:        2,
+				ToUnitID:          1,
+				Quantity:          2,
+				BaseQuantity:      96,
+				ConvertedQuantity: 8,
+			}, c.ErrorToReturn)
+
+			handler := handler.NewPackagingUnitHandler(service)
+
+			req := newPackagingUnitRequest(http.MethodGet, "/api/v1/products/1/packagingUnits/convert?"+c.Query, "1", "")
+			res := httptest.NewRecorder()
+			handler.ConvertQuantity(res, req)
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestUnitPackagingUnitHandler_DeletePackagingUnit(t *testing.T) {
+	service := new(mockPackagingUnitService)
+	service.On("DeletePackagingUnit", 1, 1).Return(nil)
+	service.On("DeletePackagingUnit", 2, 1).Return(utils.ENotFound("Packaging unit"))
+	service.On("DeletePackagingUnit", 1, 2).Return(utils.EPreconditionFailed("packaging unit"))
+
+	handler := handler.NewPackagingUnitHandler(service)
+
+	req := newPackagingUnitRequest(http.MethodDelete, "/api/v1/packagingUnits/1", "1", "")
+	req.Header.Set("If-Match", `"1"`)
+	res := httptest.NewRecorder()
+	handler.DeletePackagingUnit(res, req)
+	require.Equal(t, http.StatusNoContent, res.Result().StatusCode)
+
+	req = newPackagingUnitRequest(http.MethodDelete, "/api/v1/packagingUnits/2", "2", "")
+	req.Header.Set("If-Match", `"1"`)
+	res = httptest.NewRecorder()
+	handler.DeletePackagingUnit(res, req)
+	require.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+
+	req = newPackagingUnitRequest(http.MethodDelete, "/api/v1/packagingUnits/1", "1", "")
+	req.Header.Set("If-Match", `"2"`)
+	res = httptest.NewRecorder()
+	handler.DeletePackagingUnit(res, req)
+	require.Equal(t, http.StatusPreconditionFailed, res.Result().StatusCode)
+
+	res = httptest.NewRecorder()
+	handler.DeletePackagingUnit(res, newPackagingUnitRequest(http.MethodDelete, "/api/v1/packagingUnits/1", "1", ""))
+	require.Equal(t, http.StatusPreconditionRequired, res.Result().StatusCode)
+
+	service.AssertNumberOfCalls(t, "DeletePackagingUnit", 3)
+}
@@ -23,7 +23,7 @@ func (h *ProductBatchHandler) Create() http.HandlerFunc {
 			return
 		}
 
-		newBatch, err := h.service.Save(&body)
+		newBatch, err := h.service.Save(r.Context(), &body)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -32,3 +32,22 @@ func (h *ProductBatchHandler) Create() http.HandlerFunc {
 		utils.JSON(w, http.StatusCreated, newBatch)
 	}
 }
+
+// Lookup returns the batch of the GS1-128 label given in the barcode query param
+func (h *ProductBatchHandler) Lookup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		barcode := r.URL.Query().Get("barcode")
+		if barcode == "" {
+			utils.HandleError(w, utils.EZeroValue("barcode"))
+			return
+		}
+
+		batch, err := h.service.Lookup(r.Context(), barcode)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, batch)
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -18,11 +19,16 @@ type MockProductBatchService struct {
 	mock.Mock
 }
 
-func (m *MockProductBatchService) Save(newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
+func (m *MockProductBatchService) Save(ctx context.Context, newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
 	args := m.Called(newBatch)
 	return args.Get(0).(internal.ProductBatch), args.Error(1)
 }
 
+func (m *MockProductBatchService) Lookup(ctx context.Context, barcode string) (internal.ProductBatch, error) {
+	args := m.Called(barcode)
+	return args.Get(0).(internal.ProductBatch), args.Error(1)
+}
+
 func TestUnitProductBatch_Create_Success(t *testing.T) {
 	productBatch := internal.ProductBatch{
 		ID: 1,
@@ -190,3 +196,59 @@ func TestUnitProductBatch_Create_InternalServerError(t *testing.T) {
 	require.Equal(t, expectedResponseBody, string(responseBody))
 
 }
+
+func TestUnitProductBatch_Lookup(t *testing.T) {
+	batch := internal.ProductBatch{
+		ID: 1,
+		ProductBatchRequest: internal.ProductBatchRequest{
+			BatchNumber: 100,
+			DueDate:     "2025-01-15",
+			ProductID:   1,
+			SectionID:   1,
+			Barcode:     "(01)07791234000012(10)100(17)250115",
+		},
+	}
+
+	cases := []struct {
+		name               string
+		query              string
+		errorToReturn      error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Lookup_OK",
+			query:              "?barcode=(01)07791234000012(10)100",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"data":{"id":1,"batch_number":100,"current_quantity":0,"current_temperature":0,"due_date":"2025-01-15","initial_quantity":0,"manufacturing_date":"","manufacturing_hour":0,"minimum_temperature":0,"product_id":1,"section_id":1,"barcode":"(01)07791234000012(10)100(17)250115"}}`,
+		},
+		{
+			name:               "Lookup_MissingBarcode",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedBody:       `{"status":"Unprocessable Entity","message":"invalid arguments: barcode cannot be empty/null"}`,
+		},
+		{
+			name:               "Lookup_NotFound",
+			query:              "?barcode=(01)07791234000012(10)100",
+			errorToReturn:      utils.ENotFound("Product batch"),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       `{"status":"Not Found","message":"entity not found: Product batch doesn't exist"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service := new(MockProductBatchService)
+			service.On("Lookup", "(01)07791234000012(10)100").Return(batch, c.errorToReturn)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/productBatches/lookup"+c.query, nil)
+			writer := httptest.NewRecorder()
+			handler := NewProductBatchHandler(service)
+
+			handler.Lookup()(writer, request)
+
+			require.Equal(t, c.expectedStatusCode, writer.Result().StatusCode)
+			require.JSONEq(t, c.expectedBody, writer.Body.String())
+		})
+	}
+}
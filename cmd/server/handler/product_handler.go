@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 
@@ -20,18 +21,111 @@ func NewProductHandler(service internal.ProductService) *ProductHandler {
 	return &ProductHandler{service: service}
 }
 
+// GetProducts returns a page of products, filtered by field=value or field[operator]=value,
+// sorted by sort (comma separated, prefix with - for descending) and paged by limit and cursor,
+// include_deleted=true lists the deleted products too, for the admins only.
+// fields selects the returned fields and include embeds the seller and product_type of each product
 func (p *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := p.service.GetProducts()
+	query, err := utils.ParseListQuery(r.URL.Query())
 	if err != nil {
 		utils.HandleError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, map[string]any{
-		"data": products,
-	})
+	if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	shape, err := utils.ParseResponseShape[internal.Product](r.URL.Query(), internal.ProductIncludeSeller, internal.ProductIncludeProductType)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	products, pagination, err := p.service.ListProducts(r.Context(), query)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	body, err := utils.Shape(r.Context(), products, shape, p.service.GetProductRelations)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	utils.JSONPage(w, http.StatusOK, body, pagination)
+}
+
+// SearchProducts filters the catalogue with the query params q, product_type, seller_id,
+// min_/max_ width, height, length, net_weight and recommended_freezing_temperature,
+// sort (prefix with - for descending), page and page_size
+func (p *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := internal.ProductSearchFilter{
+		Text: query.Get("q"),
+	}
+
+	intParams := map[string]*int{
+		"product_type": &filter.ProductTypeID,
+		"seller_id":    &filter.SellerID,
+		"page":         &filter.Page,
+		"page_size":    &filter.PageSize,
+	}
+
+	for name, target := range intParams {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest(name))
+				return
+			}
+
+			*target = parsed
+		}
+	}
+
+	rangeParams := map[string]*internal.ProductRange{
+		"width":                            &filter.Width,
+		"height":                           &filter.Height,
+		"length":                           &filter.Length,
+		"net_weight":                       &filter.NetWeight,
+		"recommended_freezing_temperature": &filter.RecommendedFreezingTemperature,
+	}
+
+	for name, target := range rangeParams {
+		for _, bound := range []struct {
+			param string
+			value **float64
+		}{{"min_" + name, &target.Min}, {"max_" + name, &target.Max}} {
+			if value := query.Get(bound.param); value != "" {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					utils.HandleError(w, utils.EBadRequest(bound.param))
+					return
+				}
+
+				*bound.value = &parsed
+			}
+		}
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		filter.SortDesc = strings.HasPrefix(sort, "-")
+		filter.SortBy = strings.TrimPrefix(sort, "-")
+	}
+
+	result, err := p.service.SearchProducts(r.Context(), filter)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, result)
 }
 
+// GetProductByID returns a product, shaped by the fields and include query params as in GetProducts
 func (p *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
@@ -39,7 +133,33 @@ func (p *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	product, err := p.service.GetProductByID(id)
+	shape, err := utils.ParseResponseShape[internal.Product](r.URL.Query(), internal.ProductIncludeSeller, internal.ProductIncludeProductType)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	product, err := p.service.GetProductByID(r.Context(), id)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	body, err := utils.ShapeOne(r.Context(), product, shape, p.service.GetProductRelations)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	utils.SetETag(w, product.Version)
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": body,
+	})
+}
+
+// GetProductByBarcode returns the product of a scanned GTIN or GS1-128 label
+func (p *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	product, err := p.service.GetProductByBarcode(r.Context(), chi.URLParam(r, "barcode"))
 	if err != nil {
 		utils.HandleError(w, err)
 		return
@@ -59,7 +179,7 @@ func (p *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := p.service.CreateProduct(newProduct)
+	product, err := p.service.CreateProduct(r.Context(), newProduct)
 	if err != nil {
 		utils.HandleError(w, err)
 		return
@@ -77,6 +197,12 @@ func (p *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
 	var inputProduct internal.Product
 
 	err = json.NewDecoder(r.Body).Decode(&inputProduct)
@@ -86,13 +212,15 @@ func (p *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	inputProduct.ID = id
+	inputProduct.Version = version
 
-	product, err := p.service.UpdateProduct(inputProduct)
+	product, err := p.service.UpdateProduct(r.Context(), inputProduct)
 	if err != nil {
 		utils.HandleError(w, err)
 		return
 	}
 
+	utils.SetETag(w, product.Version)
 	response.JSON(w, http.StatusOK, map[string]any{
 		"data": product,
 	})
@@ -105,7 +233,13 @@ func (p *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = p.service.DeleteProduct(id)
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	err = p.service.DeleteProduct(r.Context(), id, version)
 	if err != nil {
 		utils.HandleError(w, err)
 		return
@@ -113,3 +247,47 @@ func (p *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusNoContent, map[string]any{})
 }
+
+// RestoreProduct brings back a deleted product and returns it
+func (p *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.HandleError(w, utils.EBadRequest("Invalid ID"))
+		return
+	}
+
+	product, err := p.service.RestoreProduct(r.Context(), id)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"data": product,
+	})
+}
+
+// ImportProducts creates products in bulk from a CSV file, whose columns are the json names of the
+// product attributes, or from a JSON array when the content type is application/json.
+// With dry_run=true the rows are only validated, with atomic=true none is created if any row fails
+func (p *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	options, err := utils.ParseImportOptions(r.URL.Query())
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	items, err := utils.DecodeImport[internal.ProductAttributes](w, r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	report, err := p.service.ImportProducts(r.Context(), items, options)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	utils.JSON(w, report.StatusCode(), report)
+}
@@ -2,11 +2,11 @@ package handler_test
 
 import (
 	"context"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
@@ -20,31 +20,66 @@ type mockProductService struct {
 	mock.Mock
 }
 
-func (m *mockProductService) GetProducts() ([]internal.Product, error) {
+func (m *mockProductService) GetProducts(ctx context.Context) ([]internal.Product, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Product), args.Error(1)
 }
 
-func (m *mockProductService) GetProductByID(id int) (internal.Product, error) {
+func (m *mockProductService) GetProductRelations(ctx context.Context, listProducts []internal.Product, include []string) ([]internal.ProductRelations, error) {
+	args := m.Called(listProducts, include)
+	return args.Get(0).([]internal.ProductRelations), args.Error(1)
+}
+
+func (m *mockProductService) ListProducts(ctx context.Context, query utils.ListQuery) ([]internal.Product, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Product), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *mockProductService) GetProductByID(ctx context.Context, id int) (internal.Product, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductService) CreateProduct(product internal.ProductAttributes) (internal.Product, error) {
+func (m *mockProductService) CreateProduct(ctx context.Context, product internal.ProductAttributes) (internal.Product, error) {
 	args := m.Called(product)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductService) UpdateProduct(product internal.Product) (internal.Product, error) {
+func (m *mockProductService) UpdateProduct(ctx context.Context, product internal.Product) (internal.Product, error) {
 	args := m.Called(product)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductService) DeleteProduct(id int) error {
-	args := m.Called(id)
+func (m *mockProductService) DeleteProduct(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *mockProductService) RestoreProduct(ctx context.Context, id int) (product internal.Product, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+func (m *mockProductService) PurgeProducts(ctx context.Context, before time.Time) (purged int, err error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockProductService) GetProductByBarcode(ctx context.Context, barcode string) (internal.Product, error) {
+	args := m.Called(barcode)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+func (m *mockProductService) SearchProducts(ctx context.Context, filter internal.ProductSearchFilter) (internal.ProductSearchResult, error) {
+	args := m.Called(filter)
+	return args.Get(0).(internal.ProductSearchResult), args.Error(1)
+}
+
+func (m *mockProductService) ImportProducts(ctx context.Context, items []utils.ImportItem[internal.ProductAttributes], options utils.ImportOptions) (utils.ImportReport, error) {
+	args := m.Called(items, options)
+	return args.Get(0).(utils.ImportReport), args.Error(1)
+}
+
 func TestUnitProductHandler_GetProducts(t *testing.T) {
 	cases := []struct {
 		TestName           string
@@ -54,7 +89,7 @@ func TestUnitProductHandler_GetProducts(t *testing.T) {
 	}{
 		{
 			TestName:           "GetProducts_OK",
-			ExpectedBody:       `{"data":[{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"freezing_rate":10,"product_type":1,"seller_id":1}]}`,
+			ExpectedBody:       `{"data":[{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"freezing_rate":10,"product_type":1,"seller_id":1}],"pagination":{"limit":50,"has_more":false}}`,
 			ExpectedStatusCode: http.StatusOK,
 			ErrorToReturn:      nil,
 		},
@@ -69,7 +104,7 @@ func TestUnitProductHandler_GetProducts(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockProductService)
-			service.On("GetProducts").Return([]internal.Product{
+			service.On("ListProducts", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Product{
 				{
 					ID: 1,
 					ProductAttributes: internal.ProductAttributes{
@@ -86,14 +121,11 @@ func TestUnitProductHandler_GetProducts(t *testing.T) {
 						SellerID:                       1,
 					},
 				},
-			}, c.ErrorToReturn)
+			}, utils.Pagination{Limit: utils.DefaultListLimit}, c.ErrorToReturn)
 
 			handler := handler.NewProductHandler(service)
 
-			request := &http.Request{
-				Body:   io.NopCloser(strings.NewReader(c.ExpectedBody)),
-				Header: http.Header{"Content-Type": []string{"application/json"}},
-			}
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
 			response := httptest.NewRecorder()
 			handler.GetProducts(response, request)
 			require.Equal(t, c.ExpectedStatusCode, response.Result().StatusCode)
@@ -278,6 +310,7 @@ func TestUnitProductHandler_CreateProduct(t *testing.T) {
 func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		Body               string
 		ExpectedBody       string
@@ -285,6 +318,7 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 	}{
 		{
 			TestName:           "UpdateProduct_OK",
+			IfMatch:            `"1"`,
 			Body:               `{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"freezing_rate":10,"product_type":1,"seller_id":1}`,
 			ExpectedBody:       `{"data":{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"freezing_rate":10,"product_type":1,"seller_id":1}}`,
 			ExpectedStatusCode: http.StatusOK,
@@ -292,6 +326,7 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 		},
 		{
 			TestName:           "UpdateProduct_ErrorBadRequest",
+			IfMatch:            `"1"`,
 			Body:               `{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"product_type":1,"seller_id":1}`,
 			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: Invalid ID with invalid format"}`,
 			ExpectedStatusCode: http.StatusBadRequest,
@@ -299,6 +334,7 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 		},
 		{
 			TestName:           "UpdateProduct_ErrorUnprocessableEntity",
+			IfMatch:            `"1"`,
 			Body:               `{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"product_type":1,"seller_id":1}`,
 			ExpectedBody:       `{"status":"Unprocessable Entity","message":"invalid arguments: Freezing Rate cannot be empty/null"}`,
 			ExpectedStatusCode: http.StatusUnprocessableEntity,
@@ -306,6 +342,7 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 		},
 		{
 			TestName:           "UpdateProduct_ErrorConflict",
+			IfMatch:            `"1"`,
 			Body:               `{"id":1,"product_code":"123","description":"product1","width":1000,"height":10,"length":10,"net_weight":10,"expiration_rate":10,"recommended_freezing_temperature":10,"freezing_rate":10,"product_type":1,"seller_id":1}`,
 			ExpectedBody:       `{"status":"Conflict","message":"entity already exists: Product with attribute 'Product Code' already exists"}`,
 			ExpectedStatusCode: http.StatusConflict,
@@ -313,11 +350,26 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 		},
 		{
 			TestName:           "UpdateProduct_InternalServerError",
+			IfMatch:            `"1"`,
 			Body:               "",
 			ExpectedBody:       `{"status":"Internal Server Error","message":"internal server error"}`,
 			ExpectedStatusCode: http.StatusInternalServerError,
 			ErrorToReturn:      utils.EBadRequest("Invalid Message Format"),
 		},
+		{
+			TestName:           "UpdateProduct_ErrorPreconditionFailed",
+			IfMatch:            `"2"`,
+			Body:               `{"description":"product1"}`,
+			ExpectedBody:       `{"status":"Precondition Failed","message":"precondition failed: product was changed, read it again to get its current version"}`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+			ErrorToReturn:      utils.EPreconditionFailed("product"),
+		},
+		{
+			TestName:           "UpdateProduct_ErrorPreconditionRequired",
+			Body:               `{"description":"product1"}`,
+			ExpectedBody:       `{"status":"Precondition Required","message":"precondition required: If-Match header with the ETag of the resource is required"}`,
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
@@ -341,6 +393,9 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 			handler := handler.NewProductHandler(service)
 
 			req, _ := http.NewRequest("PATCH", "http://localhost:8080/api/v1/products/", strings.NewReader(c.Body))
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			if c.TestName == "UpdateProduct_ErrorBadRequest" {
@@ -359,33 +414,50 @@ func TestUnitProductHandler_UpdateProduct(t *testing.T) {
 func TestUnitProductHandler_DeleteProduct(t *testing.T) {
 	cases := []struct {
 		TestName           string
+		IfMatch            string
 		ErrorToReturn      error
 		ExpectedStatusCode int
 	}{
 		{
 			TestName:           "DeleteProduct_OK",
+			IfMatch:            `"1"`,
 			ExpectedStatusCode: http.StatusNoContent,
 			ErrorToReturn:      nil,
 		},
 		{
 			TestName:           "DeleteProduct_ErrorBadRequest",
+			IfMatch:            `"1"`,
 			ExpectedStatusCode: http.StatusBadRequest,
 			ErrorToReturn:      utils.EBadRequest("Invalid ID"),
 		},
 		{
 			TestName:           "DeleteProduct_ErrorNotFound",
+			IfMatch:            `"1"`,
 			ExpectedStatusCode: http.StatusNotFound,
 			ErrorToReturn:      utils.ENotFound("Product"),
 		},
+		{
+			TestName:           "DeleteProduct_ErrorPreconditionFailed",
+			IfMatch:            `"2"`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+			ErrorToReturn:      utils.EPreconditionFailed("product"),
+		},
+		{
+			TestName:           "DeleteProduct_ErrorPreconditionRequired",
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockProductService)
-			service.On("DeleteProduct", 1).Return(c.ErrorToReturn)
+			service.On("DeleteProduct", 1, mock.Anything).Return(c.ErrorToReturn)
 			handler := handler.NewProductHandler(service)
 
 			req, _ := http.NewRequest("DELETE", "http://localhost:8080/api/v1/products/", nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", "1")
 			if c.TestName == "DeleteProduct_ErrorBadRequest" {
@@ -399,3 +471,68 @@ func TestUnitProductHandler_DeleteProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitProductHandler_SearchProducts(t *testing.T) {
+	minWidth := 2.5
+
+	cases := []struct {
+		TestName           string
+		Query              string
+		ExpectedFilter     *internal.ProductSearchFilter
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName: "SearchProducts_OK",
+			Query:    "q=apple&seller_id=1&min_width=2.5&sort=-net_weight&page=2&page_size=10",
+			ExpectedFilter: &internal.ProductSearchFilter{
+				Text:     "apple",
+				SellerID: 1,
+				Width:    internal.ProductRange{Min: &minWidth},
+				SortBy:   "net_weight",
+				SortDesc: true,
+				Page:     2,
+				PageSize: 10,
+			},
+			ExpectedBody:       `{"data":[],"page":2,"page_size":10,"total":0}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "SearchProducts_BadRange",
+			Query:              "max_net_weight=heavy",
+			ExpectedBody:       `{"status":"Bad Request","message":"invalid format: max_net_weight with invalid format"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "SearchProducts_InvalidSort",
+			Query:              "sort=price",
+			ExpectedFilter:     &internal.ProductSearchFilter{SortBy: "price"},
+			ErrorToReturn:      utils.EBR("cannot sort by price"),
+			ExpectedBody:       `{"status":"Unprocessable Entity","message":"invalid arguments: cannot sort by price"}`,
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockProductService)
+			if c.ExpectedFilter != nil {
+				service.On("SearchProducts", *c.ExpectedFilter).Return(internal.ProductSearchResult{
+					Data:     []internal.Product{},
+					Page:     c.ExpectedFilter.Page,
+					PageSize: c.ExpectedFilter.PageSize,
+				}, c.ErrorToReturn)
+			}
+
+			handler := handler.NewProductHandler(service)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/products/search?"+c.Query, nil)
+			response := httptest.NewRecorder()
+			handler.SearchProducts(response, request)
+			require.Equal(t, c.ExpectedStatusCode, response.Result().StatusCode)
+			require.JSONEq(t, c.ExpectedBody, response.Body.String())
+			service.AssertExpectations(t)
+		})
+	}
+}
@@ -20,14 +20,20 @@ func NewProductRecordsHandler(service internal.ProductRecordsService) *ProductRe
 	return &ProductRecordsHandler{service: service}
 }
 
+// GetProductRecords returns the number of records of a product, or of every product, as JSON or,
+// when asked by the format query param or the Accept header, as a CSV or XLSX file
 func (p *ProductRecordsHandler) GetProductRecords(w http.ResponseWriter, r *http.Request) {
+	format, err := utils.ExportFormat(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
 	idStr := r.URL.Query().Get("id")
 
 	var id int
 
 	if idStr != "" {
-		var err error
-
 		id, err = strconv.Atoi(idStr)
 		if err != nil {
 			response.Error(w, http.StatusBadRequest, "Invalid 'id' format")
@@ -35,12 +41,37 @@ func (p *ProductRecordsHandler) GetProductRecords(w http.ResponseWriter, r *http
 		}
 	}
 
-	products, err := p.service.GetProductRecords(id)
+	// the report of every product is streamed a page at a time
+	if format != utils.FormatJSON && id == 0 {
+		afterProductID := 0
+
+		utils.ExportPages(w, format, "product_records", func() ([]internal.ProductReport, bool, error) {
+			page, err := p.service.GetProductRecordsPage(r.Context(), afterProductID, utils.ExportPageSize)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if len(page) > 0 {
+				afterProductID = page[len(page)-1].ProductID
+			}
+
+			return page, len(page) == utils.ExportPageSize, nil
+		})
+
+		return
+	}
+
+	products, err := p.service.GetProductRecords(r.Context(), id)
 	if err != nil {
 		response.Error(w, http.StatusNotFound, utils.ErrNotFound.Error())
 		return
 	}
 
+	if format != utils.FormatJSON {
+		utils.Export(w, format, "product_records", products)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, map[string]any{
 		"data": products,
 	})
@@ -55,7 +86,7 @@ func (p *ProductRecordsHandler) CreateProductRecord(w http.ResponseWriter, r *ht
 		return
 	}
 
-	product, err := p.service.CreateProductRecord(newProduct)
+	product, err := p.service.CreateProductRecord(r.Context(), newProduct)
 	if err != nil {
 		if errors.Is(err, utils.ErrConflict) {
 			response.Error(w, http.StatusConflict, utils.ErrConflict.Error())
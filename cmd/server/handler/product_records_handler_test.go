@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	errors2 "errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -18,12 +20,17 @@ type mockProductRecordsService struct {
 	mock.Mock
 }
 
-func (m *mockProductRecordsService) GetProductRecords(productID int) ([]internal.ProductReport, error) {
+func (m *mockProductRecordsService) GetProductRecords(ctx context.Context, productID int) ([]internal.ProductReport, error) {
 	args := m.Called(productID)
 	return args.Get(0).([]internal.ProductReport), args.Error(1)
 }
 
-func (m *mockProductRecordsService) CreateProductRecord(newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
+func (m *mockProductRecordsService) GetProductRecordsPage(ctx context.Context, afterProductID, limit int) ([]internal.ProductReport, error) {
+	args := m.Called(afterProductID, limit)
+	return args.Get(0).([]internal.ProductReport), args.Error(1)
+}
+
+func (m *mockProductRecordsService) CreateProductRecord(ctx context.Context, newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
 	args := m.Called(newProductRecord)
 	return args.Get(0).(internal.ProductRecords), args.Error(1)
 }
@@ -82,6 +89,46 @@ func TestProductRecordsHandler_GetProductRecords(t *testing.T) {
 	}
 }
 
+func TestProductRecordsHandler_GetProductRecords_Export(t *testing.T) {
+	t.Run("GetProductRecords_StreamsEveryPage", func(t *testing.T) {
+		firstPage := make([]internal.ProductReport, utils.ExportPageSize)
+		for i := range firstPage {
+			firstPage[i] = internal.ProductReport{ProductID: i + 1, Description: "Product", RecordsCount: 1}
+		}
+
+		service := new(mockProductRecordsService)
+		service.On("GetProductRecordsPage", 0, utils.ExportPageSize).Return(firstPage, nil)
+		service.On("GetProductRecordsPage", utils.ExportPageSize, utils.ExportPageSize).
+			Return([]internal.ProductReport{{ProductID: 900, Description: "=SUM(A1)", RecordsCount: 2}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/product-records?format=csv", nil)
+		res := httptest.NewRecorder()
+
+		NewProductRecordsHandler(service).GetProductRecords(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+		require.Len(t, lines, utils.ExportPageSize+2)
+		require.Equal(t, "product_id,description,records_count", lines[0])
+		require.Equal(t, "900,'=SUM(A1),2", lines[len(lines)-1])
+		service.AssertNotCalled(t, "GetProductRecords", mock.Anything)
+	})
+
+	t.Run("GetProductRecords_NoReportsFound", func(t *testing.T) {
+		service := new(mockProductRecordsService)
+		service.On("GetProductRecordsPage", 0, utils.ExportPageSize).Return([]internal.ProductReport(nil), utils.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/product-records?format=csv", nil)
+		res := httptest.NewRecorder()
+
+		NewProductRecordsHandler(service).GetProductRecords(res, req)
+
+		require.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
 func TestProductRecordsHandler_CreateProductRecord(t *testing.T) {
 	cases := []struct {
 		TestName           string
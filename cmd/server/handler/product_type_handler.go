@@ -20,8 +20,8 @@ func NewProductTypeHandler(service internal.ProductTypeService) *ProductTypeHand
 	return &ProductTypeHandler{service: service}
 }
 
-func (h *ProductTypeHandler) GetProductTypes(w http.ResponseWriter, _ *http.Request) {
-	productTypes, err := h.service.GetProductTypes()
+func (h *ProductTypeHandler) GetProductTypes(w http.ResponseWriter, r *http.Request) {
+	productTypes, err := h.service.GetProductTypes(r.Context())
 	if err != nil {
 		response.Error(w, http.StatusNotFound, utils.ErrNotFound.Error())
 		return
@@ -39,12 +39,13 @@ func (h *ProductTypeHandler) GetProductTypeByID(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	productType, err := h.service.GetProductTypeByID(id)
+	productType, err := h.service.GetProductTypeByID(r.Context(), id)
 	if err != nil {
 		response.Error(w, http.StatusNotFound, utils.ErrNotFound.Error())
 		return
 	}
 
+	utils.SetETag(w, productType.Version)
 	response.JSON(w, http.StatusOK, map[string]any{
 		"data": productType,
 	})
@@ -59,15 +60,10 @@ func (h *ProductTypeHandler) CreateProductType(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	productType, err := h.service.CreateProductType(newProductType)
+	productType, err := h.service.CreateProductType(r.Context(), newProductType)
 	if err != nil {
-		if errors.Is(err, utils.ErrConflict) {
-			response.Error(w, http.StatusConflict, utils.ErrConflict.Error())
-			return
-		} else {
-			response.Error(w, http.StatusUnprocessableEntity, utils.ErrInvalidArguments.Error())
-			return
-		}
+		utils.HandleError(w, err)
+		return
 	}
 
 	response.JSON(w, http.StatusCreated, map[string]any{
@@ -82,6 +78,12 @@ func (h *ProductTypeHandler) UpdateProductType(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
 	var inputProductType internal.ProductType
 
 	err = json.NewDecoder(r.Body).Decode(&inputProductType)
@@ -91,13 +93,15 @@ func (h *ProductTypeHandler) UpdateProductType(w http.ResponseWriter, r *http.Re
 	}
 
 	inputProductType.ID = id
+	inputProductType.Version = version
 
-	productType, err := h.service.UpdateProductType(inputProductType)
+	productType, err := h.service.UpdateProductType(r.Context(), inputProductType)
 	if err != nil {
-		response.Error(w, http.StatusNotFound, utils.ErrNotFound.Error())
+		utils.HandleError(w, err)
 		return
 	}
 
+	utils.SetETag(w, productType.Version)
 	response.JSON(w, http.StatusOK, map[string]any{
 		"data": productType,
 	})
@@ -110,8 +114,19 @@ func (h *ProductTypeHandler) DeleteProductType(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = h.service.DeleteProductType(id)
+	version, err := utils.IfMatch(r)
+	if err != nil {
+		utils.HandleError(w, err)
+		return
+	}
+
+	err = h.service.DeleteProductType(r.Context(), id, version)
 	if err != nil {
+		if errors.Is(err, utils.ErrPreconditionFailed) {
+			utils.HandleError(w, err)
+			return
+		}
+
 		response.Error(w, http.StatusNotFound, utils.ErrNotFound.Error())
 		return
 	}
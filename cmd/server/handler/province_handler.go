@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+type ProvinceHandler struct {
+	service internal.ProvinceService
+}
+
+func NewProvinceHandler(service internal.ProvinceService) *ProvinceHandler {
+	return &ProvinceHandler{
+		service: service,
+	}
+}
+
+type reqProvince struct {
+	ProvinceName string `json:"province_name"`
+	CountryID    int    `json:"country_id"`
+}
+
+// GetAll responds with all the provinces
+//
+//	@Summary		Get all provinces
+//	@Description	Retrieve all the provinces
+//	@Tags			provinces
+//	@Produce		json
+//	@Success		200	{array}	internal.Province
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces [get]
+func (h *ProvinceHandler) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provinces, err := h.service.GetAll(r.Context())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, provinces)
+	}
+}
+
+// GetByID responds with the province of the id path param
+//
+//	@Summary		Get province by ID
+//	@Description	Get a province by its ID, its version is the ETag header
+//	@Tags			provinces
+//	@Produce		json
+//	@Param			id	path	int	true	"Province ID"
+//	@Success		200	{object}	internal.Province
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Province not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces/{id} [get]
+func (h *ProvinceHandler) GetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		province, err := h.service.GetByID(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, province.Version)
+		utils.JSON(w, http.StatusOK, province)
+	}
+}
+
+// Create creates a province of an existing country, responds 409 when the name is already taken
+//
+//	@Summary		Create a new province
+//	@Description	Create a province of an existing country with a name not taken by another one
+//	@Tags			provinces
+//	@Accept			json
+//	@Produce		json
+//	@Param			province	body	reqProvince	true	"Province name and country"
+//	@Success		201	{object}	internal.Province
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid request body"
+//	@Failure		409	{object}	utils.ErrorResponse	"Name already taken"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments or country not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces [post]
+func (h *ProvinceHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body reqProvince
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		province := internal.Province{ProvinceName: body.ProvinceName, CountryID: body.CountryID}
+
+		if err := h.service.Save(r.Context(), &province); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusCreated, province)
+	}
+}
+
+// Update changes the name and/or the country of the province of the id path param, in the
+// version of the If-Match header
+//
+//	@Summary		Update a province
+//	@Description	Update a province by its ID, in the version of the If-Match header
+//	@Tags			provinces
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Province ID"
+//	@Param			If-Match	header	string	true	"ETag of the province read, or *"
+//	@Param			province	body	reqProvince	true	"Province data"
+//	@Success		200	{object}	internal.Province
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID or request body"
+//	@Failure		404	{object}	utils.ErrorResponse	"Province not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Name already taken"
+//	@Failure		412	{object}	utils.ErrorResponse	"The province was changed since its ETag was read"
+//	@Failure		422	{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces/{id} [patch]
+func (h *ProvinceHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		var body reqProvince
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.HandleError(w, utils.EBadRequest("body"))
+			return
+		}
+
+		province := internal.Province{ID: id, ProvinceName: body.ProvinceName, CountryID: body.CountryID, Version: version}
+
+		if err := h.service.Update(r.Context(), &province); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, province.Version)
+		utils.JSON(w, http.StatusOK, province)
+	}
+}
+
+// Delete removes the province of the id path param with its localities,
+// responds 409 when sellers, warehouses or carriers are located in it and 412 when it is not in
+// the version of the If-Match header
+//
+//	@Summary		Delete a province
+//	@Description	Delete a province by its ID, in the version of the If-Match header, along with its localities
+//	@Tags			provinces
+//	@Param			id	path	int	true	"Province ID"
+//	@Param			If-Match	header	string	true	"ETag of the province read, or *"
+//	@Success		204
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Province not found"
+//	@Failure		409	{object}	utils.ErrorResponse	"Sellers, warehouses or carriers are located in the province"
+//	@Failure		412	{object}	utils.ErrorResponse	"The province was changed since its ETag was read"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces/{id} [delete]
+func (h *ProvinceHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if err := h.service.Delete(r.Context(), id, version); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusNoContent, nil)
+	}
+}
+
+// GetLocalities responds with the localities of the province of the id path param
+//
+//	@Summary		Get the localities of a province
+//	@Description	Retrieve the localities of a province by its ID
+//	@Tags			provinces
+//	@Produce		json
+//	@Param			id	path	int	true	"Province ID"
+//	@Success		200	{array}	internal.Locality
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Province not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/provinces/{id}/localities [get]
+func (h *ProvinceHandler) GetLocalities() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		localities, err := h.service.GetLocalities(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, localities)
+	}
+}
@@ -0,0 +1,209 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockProvinceService struct {
+	mock.Mock
+}
+
+func (m *MockProvinceService) GetAll(ctx context.Context) ([]internal.Province, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceService) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceService) Save(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceService) Update(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockProvinceService) GetLocalities(ctx context.Context, id int) ([]internal.Locality, error) {
+	args := m.Called(id)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func TestUnitProvince_Create(t *testing.T) {
+	cases := []struct {
+		Name               string
+		Body               string
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "CREATED",
+			Body:               `{"province_name":"Cordoba","country_id":1}`,
+			ExpectedBody:       `{"data":{"id":0,"province_name":"Cordoba","country_id":1}}`,
+			ExpectedStatusCode: http.StatusCreated,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			Body:               `{"province_name":`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "CONFLICT",
+			Body:               `{"province_name":"Cordoba","country_id":1}`,
+			MockError:          utils.EConflict("province", "province_name"),
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			Name:               "UNPROCESSABLE_ENTITY",
+			Body:               `{"province_name":"Cordoba","country_id":99}`,
+			MockError:          utils.EDependencyNotFound("country", "id: 99"),
+			ExpectedBody:       `{"message":"invalid arguments: country with 'id: 99' doesn't exist", "status":"Unprocessable Entity"}`,
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			service := new(MockProvinceService)
+			service.On("Save", mock.Anything).Return(c.MockError)
+			provinceHandler := handler.NewProvinceHandler(service)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/provinces", strings.NewReader(c.Body))
+			res := httptest.NewRecorder()
+
+			provinceHandler.Create()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnitProvince_Delete(t *testing.T) {
+	cases := []struct {
+		Name               string
+		IfMatch            string
+		ID                 string
+		MockError          error
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "NO_CONTENT",
+			IfMatch:            `"1"`,
+			ID:                 "1",
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:               "BAD_REQUEST",
+			IfMatch:            `"1"`,
+			ID:                 "asd",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "CONFLICT",
+			IfMatch:            `"1"`,
+			ID:                 "1",
+			MockError:          utils.EInUse("province", "0 sellers, 1 warehouses and 0 carriers"),
+			ExpectedStatusCode: http.StatusConflict,
+		},
+		{
+			Name:               "PRECONDITION_FAILED",
+			ID:                 "1",
+			IfMatch:            `"2"`,
+			MockError:          utils.EPreconditionFailed("province"),
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "PRECONDITION_REQUIRED",
+			ID:                 "1",
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", c.ID)
+
+			service := new(MockProvinceService)
+			service.On("Delete", mock.Anything, mock.Anything).Return(c.MockError)
+			provinceHandler := handler.NewProvinceHandler(service)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/provinces/"+c.ID, nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			provinceHandler.Delete()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
+
+func TestUnitProvince_GetLocalities(t *testing.T) {
+	cases := []struct {
+		Name               string
+		ID                 string
+		MockError          error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "OK",
+			ID:                 "1",
+			ExpectedBody:       `{"data":[{"id":6700,"locality_name":"Lujan","province_id":1}]}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "NOT_FOUND",
+			ID:                 "9",
+			MockError:          utils.ENotFound("province"),
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", c.ID)
+
+			service := new(MockProvinceService)
+			service.On("GetLocalities", mock.Anything).Return([]internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}}, c.MockError)
+			provinceHandler := handler.NewProvinceHandler(service)
+
+			req := &http.Request{Method: http.MethodGet}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			provinceHandler.GetLocalities()(res, req)
+
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 
 	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
@@ -22,16 +23,23 @@ func NewPurchaseOrdersHandler(sv internal.PurchaseOrderService) *PurchaseOrderDe
 	return &PurchaseOrderDefault{sv: sv}
 }
 
+// GetAllPurchaseOrders handles the GET /buyers/reportPurchaseOrders route, the summary of the
+// purchase orders of a buyer or of every buyer, exported as CSV or XLSX when asked by the format
+// query param or the Accept header
 func (h *PurchaseOrderDefault) GetAllPurchaseOrders() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		queryParams := r.URL.Query()
 		buyerIDParam := queryParams.Get("id")
 
 		var buyerID int
 
 		if buyerIDParam != "" {
-			var err error
-
 			buyerID, err = strconv.Atoi(buyerIDParam)
 			if err != nil {
 				utils.HandleError(w, utils.ErrInvalidFormat)
@@ -39,18 +47,24 @@ func (h *PurchaseOrderDefault) GetAllPurchaseOrders() http.HandlerFunc {
 			}
 		}
 
-		PurchaseOrdersSummary, err := h.sv.FindAllByBuyerID(buyerID)
+		PurchaseOrdersSummary, err := h.sv.FindAllByBuyerID(r.Context(), buyerID)
 		if err != nil {
 			utils.HandleError(w, err)
 
 			return
 		}
 
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "purchase_orders", PurchaseOrdersSummary)
+			return
+		}
+
 		data := make(map[int]map[string]any)
 		for _, value := range PurchaseOrdersSummary {
 			data[value.BuyerID] = map[string]any{
 				"total_orders": value.TotalOrders,
 				"order_codes":  value.OrderCodes,
+				"total_spent":  value.TotalSpent,
 			}
 		}
 
@@ -61,6 +75,49 @@ func (h *PurchaseOrderDefault) GetAllPurchaseOrders() http.HandlerFunc {
 	}
 }
 
+// GetPurchaseOrdersByBuyerID handles the GET /buyers/{id}/purchaseOrders route
+// it accepts the page, page_size, date_from and date_to query params
+func (h *PurchaseOrderDefault) GetPurchaseOrdersByBuyerID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		buyerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		queryParams := r.URL.Query()
+		filter := internal.PurchaseOrderFilter{
+			BuyerID:  buyerID,
+			DateFrom: queryParams.Get("date_from"),
+			DateTo:   queryParams.Get("date_to"),
+		}
+
+		if page := queryParams.Get("page"); page != "" {
+			filter.Page, err = strconv.Atoi(page)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("page"))
+				return
+			}
+		}
+
+		if pageSize := queryParams.Get("page_size"); pageSize != "" {
+			filter.PageSize, err = strconv.Atoi(pageSize)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("page_size"))
+				return
+			}
+		}
+
+		page, err := h.sv.FindDetailsByBuyerID(r.Context(), filter)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		response.JSON(w, http.StatusOK, page)
+	}
+}
+
 // PostPurchaseOrders handles the POST /PurchaseOrders route
 func (h *PurchaseOrderDefault) PostPurchaseOrders() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -74,7 +131,7 @@ func (h *PurchaseOrderDefault) PostPurchaseOrders() http.HandlerFunc {
 		}
 
 		// create the PurchaseOrder
-		PurchaseOrder, err := h.sv.CreatePurchaseOrder(newPurchaseOrder)
+		PurchaseOrder, err := h.sv.CreatePurchaseOrder(r.Context(), newPurchaseOrder)
 		if err != nil {
 			utils.HandleError(w, err)
 
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 	"github.com/stretchr/testify/assert"
@@ -21,12 +23,17 @@ func (m *mockPurchaseOrderService) FindAll() (map[int]internal.PurchaseOrder, er
 	return args.Get(0).(map[int]internal.PurchaseOrder), args.Error(1)
 }
 
-func (m *mockPurchaseOrderService) FindAllByBuyerID(buyerID int) (PurchaseOrders []internal.PurchaseOrderSummary, err error) {
+func (m *mockPurchaseOrderService) FindAllByBuyerID(ctx context.Context, buyerID int) (PurchaseOrders []internal.PurchaseOrderSummary, err error) {
 	args := m.Called(buyerID)
 	return args.Get(0).([]internal.PurchaseOrderSummary), args.Error(1)
 }
 
-func (m *mockPurchaseOrderService) CreatePurchaseOrder(inputPurchaseOrder internal.PurchaseOrderAttributes) (PurchaseOrder internal.PurchaseOrder, err error) {
+func (m *mockPurchaseOrderService) FindDetailsByBuyerID(ctx context.Context, filter internal.PurchaseOrderFilter) (internal.PurchaseOrderPage, error) {
+	args := m.Called(filter)
+	return args.Get(0).(internal.PurchaseOrderPage), args.Error(1)
+}
+
+func (m *mockPurchaseOrderService) CreatePurchaseOrder(ctx context.Context, inputPurchaseOrder internal.PurchaseOrderAttributes) (PurchaseOrder internal.PurchaseOrder, err error) {
 	args := m.Called(inputPurchaseOrder)
 	return args.Get(0).(internal.PurchaseOrder), args.Error(1)
 }
@@ -56,9 +63,10 @@ var (
 			ProductRecordID: 1,
 		}}
 	mockPurchaseOrderSummary = internal.PurchaseOrderSummary{
-		OrderCodes:  "order#1",
+		OrderCodes:  []string{"order#1"},
 		TotalOrders: 1,
 		BuyerID:     1,
+		TotalSpent:  15.00,
 	}
 )
 
@@ -143,3 +151,58 @@ func TestPurchaseOrdersHandler_Create(t *testing.T) {
 		assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
 	})
 }
+
+func TestPurchaseOrdersHandler_GetPurchaseOrdersByBuyerID(t *testing.T) {
+	newRequest := func(id, query string) *http.Request {
+		req := httptest.NewRequest("GET", "/buyers/"+id+"/purchaseOrders"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	t.Run("GetPurchaseOrdersByBuyerID - Success", func(t *testing.T) {
+		mockService := new(mockPurchaseOrderService)
+		handler := NewPurchaseOrdersHandler(mockService)
+		filter := internal.PurchaseOrderFilter{BuyerID: 1, DateFrom: "2021-01-01", DateTo: "2021-12-31", Page: 2, PageSize: 5}
+		mockService.On("FindDetailsByBuyerID", filter).Return(internal.PurchaseOrderPage{Page: 2, PageSize: 5, Total: 6}, nil)
+
+		res := httptest.NewRecorder()
+		handler.GetPurchaseOrdersByBuyerID()(res, newRequest("1", "?date_from=2021-01-01&date_to=2021-12-31&page=2&page_size=5"))
+
+		assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+		assert.JSONEq(t, `{"data":null,"page":2,"page_size":5,"total":6}`, res.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("GetPurchaseOrdersByBuyerID - Invalid ID", func(t *testing.T) {
+		mockService := new(mockPurchaseOrderService)
+		handler := NewPurchaseOrdersHandler(mockService)
+
+		res := httptest.NewRecorder()
+		handler.GetPurchaseOrdersByBuyerID()(res, newRequest("x", ""))
+
+		assert.Equal(t, http.StatusBadRequest, res.Result().StatusCode)
+	})
+
+	t.Run("GetPurchaseOrdersByBuyerID - Invalid page", func(t *testing.T) {
+		mockService := new(mockPurchaseOrderService)
+		handler := NewPurchaseOrdersHandler(mockService)
+
+		res := httptest.NewRecorder()
+		handler.GetPurchaseOrdersByBuyerID()(res, newRequest("1", "?page=x"))
+
+		assert.Equal(t, http.StatusBadRequest, res.Result().StatusCode)
+	})
+
+	t.Run("GetPurchaseOrdersByBuyerID - Buyer not found", func(t *testing.T) {
+		mockService := new(mockPurchaseOrderService)
+		handler := NewPurchaseOrdersHandler(mockService)
+		mockService.On("FindDetailsByBuyerID", mock.Anything).Return(internal.PurchaseOrderPage{}, utils.ENotFound("buyer"))
+
+		res := httptest.NewRecorder()
+		handler.GetPurchaseOrdersByBuyerID()(res, newRequest("99", ""))
+
+		assert.Equal(t, http.StatusNotFound, res.Result().StatusCode)
+	})
+}
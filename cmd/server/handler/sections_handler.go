@@ -14,14 +14,16 @@ import (
 
 // Simple structure to hold the data when POST request
 type reqPostSection struct {
-	SectionNumber      int     `json:"section_number"`
-	CurrentCapacity    int     `json:"current_capacity"`
-	MaximumCapacity    int     `json:"maximum_capacity"`
-	MinimumCapacity    int     `json:"minimum_capacity"`
-	CurrentTemperature float64 `json:"current_temperature"`
-	MinimumTemperature float64 `json:"minimum_temperature"`
-	ProductTypeID      int     `json:"product_type_id"`
-	WarehouseID        int     `json:"warehouse_id"`
+	SectionNumber      int      `json:"section_number"`
+	CurrentCapacity    int      `json:"current_capacity"`
+	MaximumCapacity    int      `json:"maximum_capacity"`
+	MinimumCapacity    int      `json:"minimum_capacity"`
+	CurrentTemperature float64  `json:"current_temperature"`
+	MinimumTemperature float64  `json:"minimum_temperature"`
+	ProductTypeID      int      `json:"product_type_id"`
+	WarehouseID        int      `json:"warehouse_id"`
+	MaximumVolume      *float64 `json:"maximum_volume"`
+	MaximumWeight      *float64 `json:"maximum_weight"`
 }
 
 type SectionHandler struct {
@@ -35,21 +37,46 @@ func NewSectionHandler(service internal.SectionService) *SectionHandler {
 
 // GetAll handles the HTTP request to retrieve all sections.
 // @Summary Get all sections
-// @Description Retrieve a list of all sections
+// @Description Retrieve a page of sections, filtered by field=value or field[operator]=value and sorted by sort
 // @Tags sections
 // @Produce json
-// @Success 200 {array} internal.Section "List of sections"
+// @Param limit query int false "Page size"
+// @Param cursor query string false "Cursor of the page, next_cursor of the previous one"
+// @Param sort query string false "Comma separated fields, prefixed with - for descending order"
+// @Param fields query string false "Comma separated fields to return"
+// @Param include query string false "Comma separated relations to embed: warehouse, product_type"
+// @Success 200 {object} utils.PageResponse{data=[]internal.Section} "Page of sections"
+// @Failure 400 {object} utils.ErrorResponse "Invalid or unknown query params"
+// @Failure 422 {object} utils.ErrorResponse "Invalid filter or sort"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /api/v1/sections [get]
 func (h *SectionHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sections, err := h.service.GetAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, sections)
+		shape, err := utils.ParseResponseShape[internal.Section](r.URL.Query(), internal.SectionIncludeWarehouse, internal.SectionIncludeProductType)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		sections, pagination, err := h.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		body, err := utils.Shape(r.Context(), sections, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, body, pagination)
 	}
 }
 
@@ -60,6 +87,8 @@ func (h *SectionHandler) GetAll() http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id path int true "Section ID"
+// @Param fields query string false "Comma separated fields to return"
+// @Param include query string false "Comma separated relations to embed: warehouse, product_type"
 // @Success 200 {object} internal.Section
 // @Failure 400 {object} utils.ErrorResponse "Invalid ID"
 // @Failure 404 {object} utils.ErrorResponse "Section not found"
@@ -74,14 +103,29 @@ func (h *SectionHandler) GetById() http.HandlerFunc {
 			return
 		}
 
-		section, err := h.service.GetByID(id)
+		shape, err := utils.ParseResponseShape[internal.Section](r.URL.Query(), internal.SectionIncludeWarehouse, internal.SectionIncludeProductType)
 		if err != nil {
 			utils.HandleError(w, err)
 
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, section)
+		section, err := h.service.GetByID(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+
+			return
+		}
+
+		body, err := utils.ShapeOne(r.Context(), section, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+
+			return
+		}
+
+		utils.SetETag(w, section.Version)
+		utils.JSON(w, http.StatusOK, body)
 	}
 }
 
@@ -116,9 +160,11 @@ func (h *SectionHandler) CreateSection() http.HandlerFunc {
 			MinimumTemperature: body.MinimumTemperature,
 			ProductTypeID:      body.ProductTypeID,
 			WarehouseID:        body.WarehouseID,
+			MaximumVolume:      body.MaximumVolume,
+			MaximumWeight:      body.MaximumWeight,
 		}
 
-		newSection, err := h.service.Save(newSection)
+		newSection, err := h.service.Save(r.Context(), newSection)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -135,11 +181,14 @@ func (h *SectionHandler) CreateSection() http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id path int true "Section ID"
+// @Param If-Match header string true "ETag of the section the changes are made on"
 // @Param section body internal.SectionPointers true "Section data"
 // @Success 200 {object} internal.Section
 // @Failure 400 {object} utils.ErrorResponse "Invalid ID or request body"
 // @Failure 409 {object} utils.ErrorResponse "Conflict error"
+// @Failure 412 {object} utils.ErrorResponse "The section was changed since its ETag was read"
 // @Failure 422 {object} utils.ErrorResponse "Unprocessable entity"
+// @Failure 428 {object} utils.ErrorResponse "If-Match is missing"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /api/v1/sections/{id} [put]
 func (h *SectionHandler) Update() http.HandlerFunc {
@@ -150,13 +199,21 @@ func (h *SectionHandler) Update() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		var body internal.SectionPointers
 		if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
 			utils.HandleError(w, utils.EBadRequest("body"))
 			return
 		}
 
-		updatedSection, err := h.service.Update(id, body)
+		body.Version = version
+
+		updatedSection, err := h.service.Update(r.Context(), id, body)
 
 		if err != nil {
 			utils.HandleError(w, err)
@@ -164,6 +221,7 @@ func (h *SectionHandler) Update() http.HandlerFunc {
 			return
 		}
 
+		utils.SetETag(w, updatedSection.Version)
 		utils.JSON(w, http.StatusOK, updatedSection)
 	}
 }
@@ -176,9 +234,12 @@ func (h *SectionHandler) Update() http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id path int true "Section ID"
+// @Param If-Match header string true "ETag of the section to delete"
 // @Success 204 {object} nil "No Content"
 // @Failure 400 {object} utils.ErrorResponse "Invalid ID"
 // @Failure 404 {object} utils.ErrorResponse "Section not found"
+// @Failure 412 {object} utils.ErrorResponse "The section was changed since its ETag was read"
+// @Failure 428 {object} utils.ErrorResponse "If-Match is missing"
 // @Failure 500 {object} utils.ErrorResponse "Internal Server Error"
 // @Router /api/v1/sections/{id} [delete]
 func (h *SectionHandler) Delete() http.HandlerFunc {
@@ -189,7 +250,13 @@ func (h *SectionHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		err = h.service.Delete(id)
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		err = h.service.Delete(r.Context(), id, version)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -206,6 +273,7 @@ func (h *SectionHandler) Delete() http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id query int false "Section ID"
+// @Param format query string false "json, csv or xlsx, the Accept header is used when missing"
 // @Success 200 {object} internal.SectionProductsReport
 // @Failure 400 {object} utils.ErrorResponse "Invalid ID"
 // @Failure 404 {object} utils.ErrorResponse "Section not found"
@@ -213,11 +281,15 @@ func (h *SectionHandler) Delete() http.HandlerFunc {
 // @Router /api/v1/sections/products/report [get]
 func (h *SectionHandler) GetSectionProductsReport() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		idReq := strings.TrimSpace(r.URL.Query().Get("id"))
 		id := 0
 
-		var err error
-
 		if idReq != "" {
 			id, err = strconv.Atoi(idReq)
 			if err != nil {
@@ -226,12 +298,151 @@ func (h *SectionHandler) GetSectionProductsReport() http.HandlerFunc {
 			}
 		}
 
-		sectionProductReport, err := h.service.GetSectionProductsReport(id)
+		// the report of every section is streamed a page at a time
+		if format != utils.FormatJSON && id == 0 {
+			afterSectionID := 0
+
+			utils.ExportPages(w, format, "section_products", func() ([]internal.SectionProductsReport, bool, error) {
+				page, err := h.service.GetSectionProductsReportPage(r.Context(), afterSectionID, utils.ExportPageSize)
+				if err != nil {
+					return nil, false, err
+				}
+
+				if len(page) > 0 {
+					afterSectionID = page[len(page)-1].SectionID
+				}
+
+				return page, len(page) == utils.ExportPageSize, nil
+			})
+
+			return
+		}
+
+		sectionProductReport, err := h.service.GetSectionProductsReport(r.Context(), id)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "section_products", sectionProductReport)
+			return
+		}
+
 		utils.JSON(w, http.StatusOK, sectionProductReport)
 	}
 }
+
+// GetSectionCapacityReport godoc
+// @Summary Get section capacity report
+// @Description Retrieves the volume and weight used by the batches of a section, or of all sections
+// @Tags sections
+// @Produce json
+// @Param id query int false "Section ID"
+// @Param format query string false "json, csv or xlsx, the Accept header is used when missing"
+// @Success 200 {array} internal.SectionCapacityReport
+// @Failure 400 {object} utils.ErrorResponse "Invalid ID"
+// @Failure 404 {object} utils.ErrorResponse "Section not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /api/v1/sections/reportCapacity [get]
+func (h *SectionHandler) GetSectionCapacityReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		idReq := strings.TrimSpace(r.URL.Query().Get("id"))
+		id := 0
+
+		if idReq != "" {
+			id, err = strconv.Atoi(idReq)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("id"))
+				return
+			}
+		}
+
+		report, err := h.service.GetSectionCapacityReport(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "section_capacity", report)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, report)
+	}
+}
+
+// GetPutawaySections godoc
+// @Summary Suggest sections to store a product
+// @Description Lists the sections for the product type of the product with room for the quantity, tightest fit first
+// @Tags sections
+// @Produce json
+// @Param product_id query int true "Product ID"
+// @Param quantity query int true "Quantity in base units"
+// @Success 200 {array} internal.SectionPutaway
+// @Failure 400 {object} utils.ErrorResponse "Invalid query params"
+// @Failure 422 {object} utils.ErrorResponse "Unprocessable entity"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /api/v1/sections/putaway [get]
+func (h *SectionHandler) GetPutawaySections() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := strconv.Atoi(r.URL.Query().Get("product_id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("product_id"))
+			return
+		}
+
+		quantity, err := strconv.Atoi(r.URL.Query().Get("quantity"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("quantity"))
+			return
+		}
+
+		sections, err := h.service.GetPutawaySections(r.Context(), productID, quantity)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, sections)
+	}
+}
+
+// GetSectionViolations godoc
+// @Summary Get product type violations report
+// @Description Lists the sections breaking the temperature range or incompatibility rules of the product types they store
+// @Tags sections
+// @Produce json
+// @Param format query string false "json, csv or xlsx, the Accept header is used when missing"
+// @Success 200 {array} internal.SectionViolation
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /api/v1/sections/reportViolations [get]
+func (h *SectionHandler) GetSectionViolations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		violations, err := h.service.GetSectionViolations(r.Context())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "section_violations", violations)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, violations)
+	}
+}
@@ -21,36 +21,66 @@ type MockSectionService struct {
 	mock.Mock
 }
 
-func (m *MockSectionService) GetAll() ([]internal.Section, error) {
+func (m *MockSectionService) GetAll(ctx context.Context) ([]internal.Section, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Section), args.Error(1)
 }
 
-func (m *MockSectionService) Save(section internal.Section) (internal.Section, error) {
+func (m *MockSectionService) GetRelations(ctx context.Context, sections []internal.Section, include []string) ([]internal.SectionRelations, error) {
+	args := m.Called(sections, include)
+	return args.Get(0).([]internal.SectionRelations), args.Error(1)
+}
+
+func (m *MockSectionService) List(ctx context.Context, query utils.ListQuery) ([]internal.Section, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Section), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *MockSectionService) Save(ctx context.Context, section internal.Section) (internal.Section, error) {
 	args := m.Called(section)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (m *MockSectionService) Update(id int, toUpdate internal.SectionPointers) (internal.Section, error) {
+func (m *MockSectionService) Update(ctx context.Context, id int, toUpdate internal.SectionPointers) (internal.Section, error) {
 	args := m.Called(id, toUpdate)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (m *MockSectionService) GetByID(id int) (internal.Section, error) {
+func (m *MockSectionService) GetByID(ctx context.Context, id int) (internal.Section, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (m *MockSectionService) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockSectionService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
-func (m *MockSectionService) GetSectionProductsReport(id int) ([]internal.SectionProductsReport, error) {
+func (m *MockSectionService) GetSectionProductsReport(ctx context.Context, id int) ([]internal.SectionProductsReport, error) {
 	args := m.Called(id)
 	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
 }
 
+func (m *MockSectionService) GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]internal.SectionProductsReport, error) {
+	args := m.Called(afterSectionID, limit)
+	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
+}
+
+func (m *MockSectionService) GetSectionCapacityReport(ctx context.Context, id int) ([]internal.SectionCapacityReport, error) {
+	args := m.Called(id)
+	return args.Get(0).([]internal.SectionCapacityReport), args.Error(1)
+}
+
+func (m *MockSectionService) GetPutawaySections(ctx context.Context, productID, quantity int) ([]internal.SectionPutaway, error) {
+	args := m.Called(productID, quantity)
+	return args.Get(0).([]internal.SectionPutaway), args.Error(1)
+}
+
+func (m *MockSectionService) GetSectionViolations(ctx context.Context) ([]internal.SectionViolation, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.SectionViolation), args.Error(1)
+}
+
 var mockSection = internal.Section{
 	ID:                 1,
 	SectionNumber:      1,
@@ -74,10 +104,10 @@ func TestUnitHandler_GetAll(t *testing.T) {
 
 	t.Run("READ-FIND_ALL-200", func(t *testing.T) {
 		mockService := new(MockSectionService)
-		mockService.On("GetAll").Return([]internal.Section{mockSection}, nil)
+		mockService.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Section{mockSection}, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
 		sectionHandler := handler.NewSectionHandler(mockService)
 
-		req := &http.Request{Method: "GET"}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/sections", nil)
 		res := httptest.NewRecorder()
 		sectionHandler.GetAll()(res, req)
 
@@ -86,10 +116,10 @@ func TestUnitHandler_GetAll(t *testing.T) {
 
 	t.Run("READ-FIND_ALL-500", func(t *testing.T) {
 		mockService := new(MockSectionService)
-		mockService.On("GetAll").Return([]internal.Section{}, errors.New("internal error"))
+		mockService.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Section{}, utils.Pagination{}, errors.New("internal error"))
 		sectionHandler := handler.NewSectionHandler(mockService)
 
-		req := &http.Request{Method: "GET"}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/sections", nil)
 		res := httptest.NewRecorder()
 		sectionHandler.GetAll()(res, req)
 
@@ -150,7 +180,7 @@ func TestUnitSection_GetById(t *testing.T) {
 
 			mockService.On("GetByID", mock.Anything).Return(scenario.MockData, scenario.MockError)
 
-			req := &http.Request{Method: "GET"}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/sections/"+scenario.ID, nil)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
 			res := httptest.NewRecorder()
 
@@ -209,9 +239,10 @@ func TestUnitSection_Delete(t *testing.T) {
 
 			sectionHandler := handler.NewSectionHandler(mockService)
 
-			mockService.On("Delete", mock.Anything).Return(c.MockError)
+			mockService.On("Delete", mock.Anything, 2).Return(c.MockError)
 
-			req := &http.Request{Method: "DELETE"}
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/sections/"+c.ID, nil)
+			req.Header.Set("If-Match", `"2"`)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
 			res := httptest.NewRecorder()
 
@@ -377,6 +408,7 @@ func TestUnitSection_Patch(t *testing.T) {
 			mockService.On("Update", mock.Anything, mock.Anything).Return(mockSection, c.MockError)
 
 			req := httptest.NewRequest("PATCH", "/", strings.NewReader(c.Body))
+			req.Header.Set("If-Match", `"1"`)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
 			res := httptest.NewRecorder()
 
@@ -389,6 +421,52 @@ func TestUnitSection_Patch(t *testing.T) {
 	}
 }
 
+func TestUnitSection_Preconditions(t *testing.T) {
+	cases := []struct {
+		Name               string
+		Method             string
+		IfMatch            string
+		ExpectedStatusCode int
+	}{
+		{Name: "PATCH-428", Method: http.MethodPatch, ExpectedStatusCode: http.StatusPreconditionRequired},
+		{Name: "PATCH-412", Method: http.MethodPatch, IfMatch: `"1"`, ExpectedStatusCode: http.StatusPreconditionFailed},
+		{Name: "PATCH-412_WEAK", Method: http.MethodPatch, IfMatch: `W/"2"`, ExpectedStatusCode: http.StatusPreconditionFailed},
+		{Name: "DELETE-428", Method: http.MethodDelete, ExpectedStatusCode: http.StatusPreconditionRequired},
+		{Name: "DELETE-412", Method: http.MethodDelete, IfMatch: `"1"`, ExpectedStatusCode: http.StatusPreconditionFailed},
+		{Name: "DELETE-204_ANY", Method: http.MethodDelete, IfMatch: "*", ExpectedStatusCode: http.StatusNoContent},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(s *testing.T) {
+			routeContext := chi.NewRouteContext()
+			routeContext.URLParams.Add("id", "1")
+
+			mockService := new(MockSectionService)
+			mockService.On("GetByID", 1).Return(internal.Section{ID: 1, Version: 2}, nil)
+			mockService.On("Update", 1, internal.SectionPointers{Version: 1}).Return(internal.Section{}, utils.EPreconditionFailed("section"))
+			mockService.On("Delete", 1, 1).Return(utils.EPreconditionFailed("section"))
+			mockService.On("Delete", 1, utils.AnyVersion).Return(nil)
+
+			req := httptest.NewRequest(c.Method, "/api/v1/sections/1", strings.NewReader(`{}`))
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+			res := httptest.NewRecorder()
+
+			sectionHandler := handler.NewSectionHandler(mockService)
+			if c.Method == http.MethodPatch {
+				sectionHandler.Update()(res, req)
+			} else {
+				sectionHandler.Delete()(res, req)
+			}
+
+			require.Equal(s, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
+
 func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 	cases := []struct {
 		Name               string
@@ -452,3 +530,186 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitSection_GetSectionProductsReport_Export(t *testing.T) {
+	t.Run("every section streamed as CSV", func(t *testing.T) {
+		mockService := new(MockSectionService)
+		mockService.On("GetSectionProductsReportPage", 0, utils.ExportPageSize).Return(mockSectionProductsReport, nil)
+
+		req := httptest.NewRequest("GET", "/?format=csv", nil)
+		res := httptest.NewRecorder()
+
+		handler.NewSectionHandler(mockService).GetSectionProductsReport()(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "section_id,section_number,products_count\n1,1,20\n", res.Body.String())
+		mockService.AssertNotCalled(t, "GetSectionProductsReport", mock.Anything)
+	})
+
+	t.Run("a section exported at once", func(t *testing.T) {
+		mockService := new(MockSectionService)
+		mockService.On("GetSectionProductsReport", 1).Return(mockSectionProductsReport, nil)
+
+		req := httptest.NewRequest("GET", "/?id=1&format=csv", nil)
+		res := httptest.NewRecorder()
+
+		handler.NewSectionHandler(mockService).GetSectionProductsReport()(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, "section_id,section_number,products_count\n1,1,20\n", res.Body.String())
+		mockService.AssertNotCalled(t, "GetSectionProductsReportPage", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnitSection_GetSectionCapacityReport(t *testing.T) {
+	maximumVolume, volumeUsage := float64(8000), float64(25)
+
+	cases := []struct {
+		Name               string
+		RawQuery           string
+		ExpectedBody       string
+		ExpectedStatusCode int
+		MockError          error
+		MockData           []internal.SectionCapacityReport
+	}{
+		{
+			Name:               "GET-SECTION_CAPACITY_REPORT-200",
+			RawQuery:           "id=1",
+			ExpectedBody:       `{"data":[{"section_id":1, "section_number":1, "warehouse_id":1, "product_type_id":1, "maximum_capacity":10, "products_count":2, "used_volume":2000, "maximum_volume":8000, "volume_usage":25, "used_weight":20}]}`,
+			ExpectedStatusCode: 200,
+			MockData: []internal.SectionCapacityReport{{SectionID: 1, SectionNumber: 1, WarehouseID: 1, ProductTypeID: 1, MaximumCapacity: 10,
+				ProductsCount: 2, UsedVolume: 2000, MaximumVolume: &maximumVolume, VolumeUsage: &volumeUsage, UsedWeight: 20}},
+		},
+		{
+			Name:               "GET-SECTION_CAPACITY_REPORT-400",
+			RawQuery:           "id=asd",
+			ExpectedBody:       `{"message":"invalid format: id with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: 400,
+		},
+		{
+			Name:               "GET-SECTION_CAPACITY_REPORT-404",
+			RawQuery:           "id=9999",
+			ExpectedBody:       `{"message":"entity not found: section doesn't exist", "status":"Not Found"}`,
+			ExpectedStatusCode: 404,
+			MockError:          utils.ENotFound("section"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(s *testing.T) {
+			mockService := new(MockSectionService)
+
+			sectionHandler := handler.NewSectionHandler(mockService)
+
+			mockService.On("GetSectionCapacityReport", mock.Anything).Return(c.MockData, c.MockError)
+
+			req := httptest.NewRequest("GET", "/?"+c.RawQuery, nil)
+			res := httptest.NewRecorder()
+
+			sectionHandler.GetSectionCapacityReport()(res, req)
+
+			require.JSONEq(s, c.ExpectedBody, strings.TrimSpace(res.Body.String()))
+			require.Equal(s, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
+
+func TestUnitSection_GetPutawaySections(t *testing.T) {
+	remainingVolume := float64(1000)
+
+	cases := []struct {
+		Name               string
+		RawQuery           string
+		ExpectedBody       string
+		ExpectedStatusCode int
+		MockError          error
+		MockData           []internal.SectionPutaway
+	}{
+		{
+			Name:               "GET-SECTION_PUTAWAY-200",
+			RawQuery:           "product_id=1&quantity=2",
+			ExpectedBody:       `{"data":[{"section_id":3, "section_number":3, "warehouse_id":1, "remaining_volume":1000}, {"section_id":1, "section_number":1, "warehouse_id":1}]}`,
+			ExpectedStatusCode: 200,
+			MockData: []internal.SectionPutaway{
+				{SectionID: 3, SectionNumber: 3, WarehouseID: 1, RemainingVolume: &remainingVolume},
+				{SectionID: 1, SectionNumber: 1, WarehouseID: 1},
+			},
+		},
+		{
+			Name:               "GET-SECTION_PUTAWAY-400-PRODUCT_ID",
+			RawQuery:           "quantity=2",
+			ExpectedBody:       `{"message":"invalid format: product_id with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: 400,
+		},
+		{
+			Name:               "GET-SECTION_PUTAWAY-400-QUANTITY",
+			RawQuery:           "product_id=1&quantity=a",
+			ExpectedBody:       `{"message":"invalid format: quantity with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: 400,
+		},
+		{
+			Name:               "GET-SECTION_PUTAWAY-422",
+			RawQuery:           "product_id=1&quantity=0",
+			ExpectedBody:       `{"message":"invalid arguments: quantity must be greater than zero", "status":"Unprocessable Entity"}`,
+			ExpectedStatusCode: 422,
+			MockError:          utils.EBR("quantity must be greater than zero"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(s *testing.T) {
+			mockService := new(MockSectionService)
+
+			sectionHandler := handler.NewSectionHandler(mockService)
+
+			mockService.On("GetPutawaySections", mock.Anything, mock.Anything).Return(c.MockData, c.MockError)
+
+			req := httptest.NewRequest("GET", "/?"+c.RawQuery, nil)
+			res := httptest.NewRecorder()
+
+			sectionHandler.GetPutawaySections()(res, req)
+
+			require.JSONEq(s, c.ExpectedBody, strings.TrimSpace(res.Body.String()))
+			require.Equal(s, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
+
+func TestUnitSection_GetSectionViolations(t *testing.T) {
+	cases := []struct {
+		Name               string
+		ExpectedBody       string
+		ExpectedStatusCode int
+		MockError          error
+		MockData           []internal.SectionViolation
+	}{
+		{
+			Name:               "GET-SECTION_VIOLATIONS-200",
+			ExpectedBody:       `{"data":[{"section_id":2, "section_number":2, "rule":"incompatibility", "product_type_id":2, "conflicting_product_type_id":4, "message":"product type 2 cannot share a section with product type 4"}]}`,
+			ExpectedStatusCode: 200,
+			MockData: []internal.SectionViolation{{SectionID: 2, SectionNumber: 2, Rule: internal.SectionViolationIncompatibility, ProductTypeID: 2,
+				ConflictingProductTypeID: 4, Message: "product type 2 cannot share a section with product type 4"}},
+		},
+		{
+			Name:               "GET-SECTION_VIOLATIONS-500",
+			ExpectedBody:       `{"message":"internal server error", "status":"Internal Server Error"}`,
+			ExpectedStatusCode: 500,
+			MockError:          errors.New("Internal error occurs"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(s *testing.T) {
+			mockService := new(MockSectionService)
+
+			sectionHandler := handler.NewSectionHandler(mockService)
+
+			mockService.On("GetSectionViolations").Return(c.MockData, c.MockError)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			res := httptest.NewRecorder()
+
+			sectionHandler.GetSectionViolations()(res, req)
+
+			require.JSONEq(s, c.ExpectedBody, strings.TrimSpace(res.Body.String()))
+			require.Equal(s, c.ExpectedStatusCode, res.Result().StatusCode)
+		})
+	}
+}
@@ -29,23 +29,53 @@ type SellerHandler struct {
 // GetAll retrieves all sellers.
 //
 //	@Summary		Get all sellers
-//	@Description	Retrieve a list of all sellers
+//	@Description	Retrieve a page of sellers, filtered by field=value or field[operator]=value and sorted by sort
 //	@Tags			sellers
 //	@Produce		json
-//	@Success		200	{array}		internal.Seller		"List of sellers"
-//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Param			limit	query		int					false	"Page size"
+//	@Param			cursor	query		string				false	"Cursor of the page, next_cursor of the previous one"
+//	@Param			sort	query		string				false	"Comma separated fields, prefixed with - for descending order"
+//	@Param			include_deleted	query		bool		false	"List the deleted sellers too, admins only"
+//	@Param			fields	query		string				false	"Comma separated fields to return"
+//	@Param			include	query		string				false	"Comma separated relations to embed: locality"
+//	@Success		200		{object}	utils.PageResponse{data=[]internal.Seller}	"Page of sellers"
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid or unknown query params"
+//	@Failure		403		{object}	utils.ErrorResponse	"Deleted sellers listed by a user who is not an admin"
+//	@Failure		422		{object}	utils.ErrorResponse	"Invalid filter or sort"
+//	@Failure		500		{object}	utils.ErrorResponse	"Internal server error"
 //	@Router			/sellers [get]
 func (h *SellerHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sellers, err := h.service.GetAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
-			fmt.Println(err.Error())
-			utils.JSON(w, http.StatusInternalServerError, nil)
+			utils.HandleError(w, err)
+			return
+		}
 
+		if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		shape, err := utils.ParseResponseShape[internal.Seller](r.URL.Query(), internal.SellerIncludeLocality)
+		if err != nil {
+			utils.HandleError(w, err)
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, sellers)
+		sellers, pagination, err := h.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		body, err := utils.Shape(r.Context(), sellers, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, body, pagination)
 	}
 }
 
@@ -55,11 +85,14 @@ func (h *SellerHandler) GetAll() http.HandlerFunc {
 //	@Description	Retrieve a seller by its ID
 //	@Tags			sellers
 //	@Produce		json
-//	@Param			id	path		int					true	"Seller ID"
-//	@Success		200	{object}	internal.Seller		"Seller details"
-//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
-//	@Failure		404	{object}	utils.ErrorResponse	"Seller not found"
-//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Param			id		path		int					true	"Seller ID"
+//	@Param			fields	query		string				false	"Comma separated fields to return"
+//	@Param			include	query		string				false	"Comma separated relations to embed: locality"
+//	@Success		200		{object}	internal.Seller		"Seller details"
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404		{object}	utils.ErrorResponse	"Seller not found"
+//	@Failure		422		{object}	utils.ErrorResponse	"Invalid fields or include"
+//	@Failure		500		{object}	utils.ErrorResponse	"Internal server error"
 //	@Router			/api/v1/sellers/{id} [get]
 func (h *SellerHandler) GetById() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -69,7 +102,13 @@ func (h *SellerHandler) GetById() http.HandlerFunc {
 			return
 		}
 
-		seller, err := h.service.GetByID(id)
+		shape, err := utils.ParseResponseShape[internal.Seller](r.URL.Query(), internal.SellerIncludeLocality)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		seller, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
 			if errors.Is(err, utils.ErrNotFound) {
 				utils.Error(w, http.StatusNotFound, fmt.Sprintln("id:", id, "not found"))
@@ -81,7 +120,14 @@ func (h *SellerHandler) GetById() http.HandlerFunc {
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, seller)
+		body, err := utils.ShapeOne(r.Context(), seller, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, seller.Version)
+		utils.JSON(w, http.StatusOK, body)
 	}
 }
 
@@ -115,7 +161,7 @@ func (h *SellerHandler) Create() http.HandlerFunc {
 			LocalityID:  reqBody.LocalityID,
 		}
 
-		err := h.service.Create(&newSeller)
+		err := h.service.Create(r.Context(), &newSeller)
 		if err != nil {
 			fmt.Println(err.Error())
 
@@ -145,12 +191,15 @@ func (h *SellerHandler) Create() http.HandlerFunc {
 //	@Tags			sellers
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		int								true	"Seller ID"
-//	@Param			seller	body		internal.SellerRequestPointer	true	"Updated seller details"
+//	@Param			id			path		int								true	"Seller ID"
+//	@Param			If-Match	header		string							true	"ETag of the seller the changes are made on"
+//	@Param			seller		body		internal.SellerRequestPointer	true	"Updated seller details"
 //	@Success		200		{object}	internal.Seller					"Updated seller"
 //	@Failure		400		{object}	utils.ErrorResponse				"Invalid request format"
 //	@Failure		404		{object}	utils.ErrorResponse				"Seller not found"
 //	@Failure		409		{object}	utils.ErrorResponse				"Seller already exists"
+//	@Failure		412		{object}	utils.ErrorResponse				"The seller was changed since its ETag was read"
+//	@Failure		428		{object}	utils.ErrorResponse				"If-Match is missing"
 //	@Failure		500		{object}	utils.ErrorResponse				"Internal server error"
 //	@Router			/sellers/{id} [put]
 func (h *SellerHandler) Update() http.HandlerFunc {
@@ -161,6 +210,12 @@ func (h *SellerHandler) Update() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		var reqBody internal.Seller
 
 		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
@@ -168,8 +223,15 @@ func (h *SellerHandler) Update() http.HandlerFunc {
 			return
 		}
 
-		seller, err := h.service.Update(id, &reqBody)
+		reqBody.Version = version
+
+		seller, err := h.service.Update(r.Context(), id, &reqBody)
 		if err != nil {
+			if errors.Is(err, utils.ErrPreconditionFailed) {
+				utils.HandleError(w, err)
+				return
+			}
+
 			if errors.Is(err, utils.ErrConflict) {
 				utils.Error(w, http.StatusConflict, err.Error())
 				return
@@ -185,6 +247,7 @@ func (h *SellerHandler) Update() http.HandlerFunc {
 			return
 		}
 
+		utils.SetETag(w, seller.Version)
 		utils.JSON(w, http.StatusOK, seller)
 	}
 }
@@ -195,10 +258,13 @@ func (h *SellerHandler) Update() http.HandlerFunc {
 //	@Description	Delete a seller by its ID
 //	@Tags			sellers
 //	@Produce		json
-//	@Param			id	path	int	true	"Seller ID"
+//	@Param			id			path	int		true	"Seller ID"
+//	@Param			If-Match	header	string	true	"ETag of the seller to delete"
 //	@Success		204	"No content"
 //	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
 //	@Failure		404	{object}	utils.ErrorResponse	"Seller not found"
+//	@Failure		412	{object}	utils.ErrorResponse	"The seller was changed since its ETag was read"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
 //	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
 //	@Router			/sellers/{id} [delete]
 func (h *SellerHandler) Delete() http.HandlerFunc {
@@ -209,13 +275,24 @@ func (h *SellerHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		err = h.service.Delete(id)
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		err = h.service.Delete(r.Context(), id, version)
 		if err != nil {
 			if errors.Is(err, utils.ErrNotFound) {
 				utils.Error(w, http.StatusNotFound, err.Error())
 				return
 			}
 
+			if errors.Is(err, utils.ErrPreconditionFailed) {
+				utils.HandleError(w, err)
+				return
+			}
+
 			utils.Error(w, http.StatusInternalServerError, "Internal error")
 
 			return
@@ -224,3 +301,125 @@ func (h *SellerHandler) Delete() http.HandlerFunc {
 		utils.JSON(w, http.StatusNoContent, nil)
 	}
 }
+
+// Restore handles the restoration of a soft deleted seller.
+//
+//	@Summary		Restore a seller
+//	@Description	Restore a deleted seller by its ID
+//	@Tags			sellers
+//	@Produce		json
+//	@Param			id	path		int					true	"Seller ID"
+//	@Success		200	{object}	internal.Seller		"Restored seller"
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	utils.ErrorResponse	"Deleted seller not found"
+//	@Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/sellers/{id}/restore [post]
+func (h *SellerHandler) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		seller, err := h.service.Restore(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, seller)
+	}
+}
+
+// GetReport retrieves the performance and catalogue report of a seller.
+//
+//	@Summary		Get seller report
+//	@Description	Retrieve products per product type, stock on hand, units sold, revenue and near-expiry exposure of a seller
+//	@Tags			sellers
+//	@Produce		json
+//	@Param			id		path		int						true	"Seller ID"
+//	@Param			days	query		int						false	"Near-expiry window in days"
+//	@Param			format	query		string					false	"json, csv or xlsx, the Accept header is used when missing"
+//	@Success		200		{object}	internal.SellerReport	"Seller report"
+//	@Failure		400		{object}	utils.ErrorResponse		"Invalid ID"
+//	@Failure		404		{object}	utils.ErrorResponse		"Seller not found"
+//	@Failure		422		{object}	utils.ErrorResponse		"Invalid arguments"
+//	@Failure		500		{object}	utils.ErrorResponse		"Internal server error"
+//	@Router			/api/v1/sellers/{id}/report [get]
+func (h *SellerHandler) GetReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := utils.ExportFormat(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("id"))
+			return
+		}
+
+		days := 0
+
+		if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+			days, err = strconv.Atoi(daysParam)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("days"))
+				return
+			}
+		}
+
+		report, err := h.service.GetReport(r.Context(), id, days)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		if format != utils.FormatJSON {
+			utils.Export(w, format, "seller_report", report)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, report)
+	}
+}
+
+// Import creates sellers in bulk from a CSV file, or a JSON array when the content type is application/json.
+//
+//	@Summary		Import sellers
+//	@Description	Create sellers in bulk from a CSV file or a JSON array, reporting the result of every row
+//	@Tags			sellers
+//	@Accept			text/csv,json,mpfd
+//	@Produce		json
+//	@Param			dry_run	query		bool				false	"Only validate the rows"
+//	@Param			atomic	query		bool				false	"Create no seller if any row fails"
+//	@Success		200		{object}	utils.ImportReport	"Import report"
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid file or query params"
+//	@Failure		422		{object}	utils.ImportReport	"Atomic import rejected"
+//	@Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/sellers/import [post]
+func (h *SellerHandler) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		options, err := utils.ParseImportOptions(r.URL.Query())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		items, err := utils.DecodeImport[internal.SellerRequest](w, r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		report, err := h.service.Import(r.Context(), items, options)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, report.StatusCode(), report)
+	}
+}
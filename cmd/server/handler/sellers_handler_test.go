@@ -12,45 +12,82 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockSellerService struct {
 	mock.Mock
 }
 
-func (s *MockSellerService) GetAll() ([]internal.Seller, error) {
+func (s *MockSellerService) GetAll(ctx context.Context) ([]internal.Seller, error) {
 	args := s.Called()
 	return args.Get(0).([]internal.Seller), args.Error(1)
 }
 
-func (s *MockSellerService) GetByID(id int) (internal.Seller, error) {
+func (s *MockSellerService) GetByIDs(ctx context.Context, ids []int) ([]internal.Seller, error) {
+	args := s.Called(ids)
+	return args.Get(0).([]internal.Seller), args.Error(1)
+}
+
+func (s *MockSellerService) GetRelations(ctx context.Context, sellers []internal.Seller, include []string) ([]internal.SellerRelations, error) {
+	args := s.Called(sellers, include)
+	return args.Get(0).([]internal.SellerRelations), args.Error(1)
+}
+
+func (s *MockSellerService) List(ctx context.Context, query utils.ListQuery) ([]internal.Seller, utils.Pagination, error) {
+	args := s.Called(query)
+	return args.Get(0).([]internal.Seller), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (s *MockSellerService) GetByID(ctx context.Context, id int) (internal.Seller, error) {
 	args := s.Called(id)
 	return args.Get(0).(internal.Seller), args.Error(1)
 }
 
-func (s *MockSellerService) Create(newSeller *internal.Seller) error {
+func (s *MockSellerService) Create(ctx context.Context, newSeller *internal.Seller) error {
 	args := s.Called(newSeller)
 	return args.Error(0)
 }
 
-func (s *MockSellerService) Update(id int, newSeller *internal.Seller) (internal.Seller, error) {
+func (s *MockSellerService) Update(ctx context.Context, id int, newSeller *internal.Seller) (internal.Seller, error) {
 	args := s.Called(id, newSeller)
 	return args.Get(0).(internal.Seller), args.Error(1)
 }
 
-func (s *MockSellerService) Delete(id int) error {
-	args := s.Called(id)
+func (s *MockSellerService) Delete(ctx context.Context, id int, version int) error {
+	args := s.Called(id, version)
 	return args.Error(0)
 }
 
+func (s *MockSellerService) Restore(ctx context.Context, id int) (internal.Seller, error) {
+	args := s.Called(id)
+	return args.Get(0).(internal.Seller), args.Error(1)
+}
+
+func (s *MockSellerService) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := s.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (s *MockSellerService) GetReport(ctx context.Context, id int, nearExpiryDays int) (internal.SellerReport, error) {
+	args := s.Called(id, nearExpiryDays)
+	return args.Get(0).(internal.SellerReport), args.Error(1)
+}
+
+func (s *MockSellerService) Import(ctx context.Context, items []utils.ImportItem[internal.SellerRequest], options utils.ImportOptions) (utils.ImportReport, error) {
+	args := s.Called(items, options)
+	return args.Get(0).(utils.ImportReport), args.Error(1)
+}
+
 func TestUnitSeller_GetAll_Success(t *testing.T) {
 	sellers := []internal.Seller{
 		{ID: 1, Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1},
 		{ID: 2, Cid: 45, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 2}}
 
 	service := new(MockSellerService)
-	service.On("GetAll").Return(sellers, nil)
+	service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return(sellers, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers", nil)
@@ -61,10 +98,108 @@ func TestUnitSeller_GetAll_Success(t *testing.T) {
 	require.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestUnitSeller_GetAll_Pagination(t *testing.T) {
+	sellers := []internal.Seller{{ID: 3, Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1}}
+	query := utils.ListQuery{
+		Limit:   1,
+		Cursor:  "abc",
+		Sort:    []utils.ListSort{{Field: "company_name", Desc: true}},
+		Filters: []utils.ListFilter{{Field: "locality_id", Operator: "eq", Text: "1"}},
+	}
+
+	service := new(MockSellerService)
+	service.On("List", query).Return(sellers, utils.Pagination{Limit: 1, NextCursor: "def", HasMore: true}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?limit=1&cursor=abc&sort=-company_name&locality_id=1", nil)
+
+	handler := NewSellerHandler(service)
+	handler.GetAll()(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":[{"id":3,"cid":55,"company_name":"Company","address":"Address","telephone":"1199999999","locality_id":1}],`+
+		`"pagination":{"limit":1,"next_cursor":"def","has_more":true}}`, w.Body.String())
+}
+
+func TestUnitSeller_GetAll_InvalidLimit(t *testing.T) {
+	service := new(MockSellerService)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?limit=many", nil)
+
+	handler := NewSellerHandler(service)
+	handler.GetAll()(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	service.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func TestUnitSeller_GetAll_IncludeDeleted(t *testing.T) {
+	t.Run("listed by an admin", func(t *testing.T) {
+		service := new(MockSellerService)
+		service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit, IncludeDeleted: true}).Return([]internal.Seller{}, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?include_deleted=true", nil)
+		req = req.WithContext(internal.WithPrincipal(req.Context(), internal.Principal{UserID: 1, Role: internal.RoleAdmin}))
+
+		handler := NewSellerHandler(service)
+		handler.GetAll()(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("forbidden to other roles", func(t *testing.T) {
+		service := new(MockSellerService)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?include_deleted=true", nil)
+		req = req.WithContext(internal.WithPrincipal(req.Context(), internal.Principal{UserID: 2, Role: internal.RoleAnalyst}))
+
+		handler := NewSellerHandler(service)
+		handler.GetAll()(w, req)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		service.AssertNotCalled(t, "List", mock.Anything)
+	})
+}
+
+func TestUnitSeller_GetAll_FieldsAndInclude(t *testing.T) {
+	sellers := []internal.Seller{{ID: 3, Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1}}
+	relations := []internal.SellerRelations{{Locality: &internal.Locality{ID: 1, LocalityName: "Rosario", ProvinceID: 2}}}
+
+	service := new(MockSellerService)
+	service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return(sellers, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
+	service.On("GetRelations", sellers, []string{internal.SellerIncludeLocality}).Return(relations, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?fields=company_name&include=locality", nil)
+
+	handler := NewSellerHandler(service)
+	handler.GetAll()(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":[{"id":3,"company_name":"Company","locality":{"id":1,"locality_name":"Rosario","province_id":2}}],`+
+		`"pagination":{"limit":50,"has_more":false}}`, w.Body.String())
+}
+
+func TestUnitSeller_GetAll_UnknownInclude(t *testing.T) {
+	service := new(MockSellerService)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers?include=products", nil)
+
+	handler := NewSellerHandler(service)
+	handler.GetAll()(w, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	service.AssertNotCalled(t, "List", mock.Anything)
+}
+
 func TestUnitSeller_GetAll_InternalServerError(t *testing.T) {
 
 	service := new(MockSellerService)
-	service.On("GetAll").Return([]internal.Seller{}, errors.New("some error"))
+	service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Seller{}, utils.Pagination{}, errors.New("some error"))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers", nil)
@@ -272,6 +407,7 @@ func TestUnitSeller_Update_Success(t *testing.T) {
 	bodyByte, _ := json.Marshal(newSeller)
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -290,6 +426,7 @@ func TestUnitSeller_Update_BadRequestBody(t *testing.T) {
 	bodyByte, _ := json.Marshal("a")
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -315,6 +452,7 @@ func TestUnitSeller_Update_BadRequestInvalidID(t *testing.T) {
 	bodyByte, _ := json.Marshal(newSeller)
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "a")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -341,6 +479,7 @@ func TestUnitSeller_Update_Conflict(t *testing.T) {
 	bodyByte, _ := json.Marshal(newSeller)
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -367,6 +506,7 @@ func TestUnitSeller_Update_NotFound(t *testing.T) {
 	bodyByte, _ := json.Marshal(newSeller)
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -393,6 +533,7 @@ func TestUnitSeller_Update_InternalServerError(t *testing.T) {
 	bodyByte, _ := json.Marshal(newSeller)
 	bodyReader := bytes.NewReader(bodyByte)
 	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", bodyReader)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -407,10 +548,11 @@ func TestUnitSeller_Update_InternalServerError(t *testing.T) {
 func TestUnitSeller_Delete_Success(t *testing.T) {
 
 	service := new(MockSellerService)
-	service.On("Delete", mock.Anything).Return(nil)
+	service.On("Delete", 1, 1).Return(nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -427,6 +569,7 @@ func TestUnitSeller_Delete_BadRequest(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "a")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -440,10 +583,11 @@ func TestUnitSeller_Delete_BadRequest(t *testing.T) {
 func TestUnitSeller_Delete_NotFound(t *testing.T) {
 
 	service := new(MockSellerService)
-	service.On("Delete", mock.Anything).Return(utils.ENotFound("Seller"))
+	service.On("Delete", 1, 1).Return(utils.ENotFound("Seller"))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -457,10 +601,11 @@ func TestUnitSeller_Delete_NotFound(t *testing.T) {
 func TestUnitSeller_Delete_InternalServerError(t *testing.T) {
 
 	service := new(MockSellerService)
-	service.On("Delete", mock.Anything).Return(errors.New("some error"))
+	service.On("Delete", 1, 1).Return(errors.New("some error"))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	req.Header.Set("If-Match", `"1"`)
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -470,3 +615,174 @@ func TestUnitSeller_Delete_InternalServerError(t *testing.T) {
 
 	require.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestUnitSeller_Update_PreconditionRequired(t *testing.T) {
+
+	service := new(MockSellerService)
+
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", strings.NewReader(`{"company_name":"Company changed"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	handler := NewSellerHandler(service)
+	handler.Update()(w, req)
+
+	require.Equal(t, http.StatusPreconditionRequired, w.Code)
+	service.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUnitSeller_Update_PreconditionFailed(t *testing.T) {
+
+	service := new(MockSellerService)
+	service.On("Update", 1, &internal.Seller{CompanyName: "Company changed", Version: 1}).Return(internal.Seller{}, utils.EPreconditionFailed("seller"))
+
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/sellers", strings.NewReader(`{"company_name":"Company changed"}`))
+	req.Header.Set("If-Match", `"1"`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	handler := NewSellerHandler(service)
+	handler.Update()(w, req)
+
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestUnitSeller_Delete_PreconditionFailed(t *testing.T) {
+
+	service := new(MockSellerService)
+	service.On("Delete", 1, 1).Return(utils.EPreconditionFailed("seller"))
+
+	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	req.Header.Set("If-Match", `"1"`)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	handler := NewSellerHandler(service)
+	handler.Delete()(w, req)
+
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestUnitSeller_Delete_PreconditionRequired(t *testing.T) {
+
+	service := new(MockSellerService)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/sellers/{id}", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+
+	handler := NewSellerHandler(service)
+	handler.Delete()(w, req)
+
+	require.Equal(t, http.StatusPreconditionRequired, w.Code)
+	service.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUnitSeller_GetReport_Success(t *testing.T) {
+	service := new(MockSellerService)
+	service.On("GetReport", 1, 30).Return(internal.SellerReport{SellerID: 1, StockOnHand: 10}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers/{id}/report?days=30", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler := NewSellerHandler(service)
+	handler.GetReport()(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	service.AssertExpectations(t)
+}
+
+func TestUnitSeller_GetReport_BadRequest(t *testing.T) {
+	service := new(MockSellerService)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers/{id}/report?days=abc", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler := NewSellerHandler(service)
+	handler.GetReport()(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUnitSeller_GetReport_NotFound(t *testing.T) {
+	service := new(MockSellerService)
+	service.On("GetReport", 1, 0).Return(internal.SellerReport{}, utils.ENotFound("Seller"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/sellers/{id}/report", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler := NewSellerHandler(service)
+	handler.GetReport()(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUnitSeller_Import_Success(t *testing.T) {
+	items := []utils.ImportItem[internal.SellerRequest]{
+		{Row: 2, Value: internal.SellerRequest{Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1}},
+	}
+	report := utils.ImportReport{Committed: true, Valid: 1, Created: 1, Rows: []utils.ImportRowResult{{Row: 2, ID: 1, Status: utils.ImportCreated}}}
+
+	service := new(MockSellerService)
+	service.On("Import", items, utils.ImportOptions{}).Return(report, nil)
+
+	body := bytes.NewBufferString("cid,company_name,address,telephone,locality_id\n55,Company,Address,1199999999,1\n")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/sellers/import", body)
+	req.Header.Set("Content-Type", "text/csv")
+
+	handler := NewSellerHandler(service)
+	handler.Import()(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"data":{"dry_run":false,"atomic":false,"committed":true,"valid":1,"created":1,"failed":0,"rows":[{"row":2,"id":1,"status":"created"}]}}`, w.Body.String())
+	service.AssertExpectations(t)
+}
+
+func TestUnitSeller_Import_AtomicRejected(t *testing.T) {
+	items := []utils.ImportItem[internal.SellerRequest]{{Row: 1, Value: internal.SellerRequest{Cid: 55}}}
+	report := utils.ImportReport{Atomic: true, Failed: 1, Rows: []utils.ImportRowResult{{Row: 1, Status: utils.ImportFailed, Error: "invalid arguments: Company name cannot be empty"}}}
+
+	service := new(MockSellerService)
+	service.On("Import", items, utils.ImportOptions{Atomic: true}).Return(report, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/sellers/import?atomic=true", bytes.NewBufferString(`[{"cid":55}]`))
+	req.Header.Set("Content-Type", "application/json")
+
+	handler := NewSellerHandler(service)
+	handler.Import()(w, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	service.AssertExpectations(t)
+}
+
+func TestUnitSeller_Import_BadRequest(t *testing.T) {
+	service := new(MockSellerService)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/sellers/import?dry_run=maybe", bytes.NewBufferString(""))
+
+	handler := NewSellerHandler(service)
+	handler.Import()(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	service.AssertNotCalled(t, "Import", mock.Anything, mock.Anything)
+}
@@ -13,6 +13,9 @@ import (
 
 const (
 	INVALID = "Invalid ID"
+
+	// defaultNearestLimit is the number of warehouses returned by GetNearestWithStock without a limit
+	defaultNearestLimit = 5
 )
 
 // reqPostWarehouse represents the request payload for creating a new warehouse.
@@ -47,6 +50,16 @@ type reqPostWarehouse struct {
 	// required: true
 	// example: -5
 	MinimumTemperature int `json:"minimum_temperature"`
+
+	// The latitude of the warehouse, in decimal degrees
+	// required: false
+	// example: 34.052235
+	Latitude *float64 `json:"latitude"`
+
+	// The longitude of the warehouse, in decimal degrees
+	// required: false
+	// example: -118.243683
+	Longitude *float64 `json:"longitude"`
 }
 
 // WarehouseHandler handles HTTP requests related to warehouse operations.
@@ -65,22 +78,53 @@ func NewWarehouseHandler(service internal.WarehouseService) *WarehouseHandler {
 // GetAll handles the HTTP request to retrieve all warehouses.
 //
 //	@Summary		Get all warehouses
-//	@Description	Retrieve a list of all warehouses
+//	@Description	Retrieve a page of warehouses, filtered by field=value or field[operator]=value and sorted by sort
 //	@Tags			warehouses
 //	@Produce		json
-//	@Success		200	{array}		internal.Warehouse	"List of warehouses"
-//	@Failure		404	{object}	utils.ErrorResponse	"No warehouses found"
-//	@Failure		500	{object}	utils.ErrorResponse	"An error occurred while retrieving warehouses"
+//	@Param			limit	query		int					false	"Page size"
+//	@Param			cursor	query		string				false	"Cursor of the page, next_cursor of the previous one"
+//	@Param			sort	query		string				false	"Comma separated fields, prefixed with - for descending order"
+//	@Param			include_deleted	query		bool				false	"List the deleted warehouses too, admins only"
+//	@Param			fields	query		string				false	"Comma separated fields to return"
+//	@Param			include	query		string				false	"Comma separated relations to embed: locality"
+//	@Success		200		{object}	utils.PageResponse{data=[]internal.Warehouse}	"Page of warehouses"
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid or unknown query params"
+//	@Failure		403		{object}	utils.ErrorResponse	"Deleted warehouses listed by a user who is not an admin"
+//	@Failure		422		{object}	utils.ErrorResponse	"Invalid filter or sort"
+//	@Failure		500		{object}	utils.ErrorResponse	"An error occurred while retrieving warehouses"
 //	@Router			/warehouses [get]
 func (h *WarehouseHandler) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		warehouses, err := h.service.GetAll()
+		query, err := utils.ParseListQuery(r.URL.Query())
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, warehouses)
+		if err := internal.CheckIncludeDeleted(r.Context(), query); err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		shape, err := utils.ParseResponseShape[internal.Warehouse](r.URL.Query(), internal.WarehouseIncludeLocality)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		warehouses, pagination, err := h.service.List(r.Context(), query)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		body, err := utils.Shape(r.Context(), warehouses, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSONPage(w, http.StatusOK, body, pagination)
 	}
 }
 
@@ -90,11 +134,14 @@ func (h *WarehouseHandler) GetAll() http.HandlerFunc {
 //	@Description	Get a warehouse by its ID
 //	@Tags			warehouses
 //	@Produce		json
-//	@Param			id	path		int	true	"Warehouse ID"
-//	@Success		200	{object}	internal.Warehouse
-//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID format"
-//	@Failure		404	{object}	utils.ErrorResponse	"No warehouse found with ID"
-//	@Failure		500	{object}	utils.ErrorResponse	"An error occurred while retrieving the warehouse"
+//	@Param			id		path		int		true	"Warehouse ID"
+//	@Param			fields	query		string	false	"Comma separated fields to return"
+//	@Param			include	query		string	false	"Comma separated relations to embed: locality"
+//	@Success		200		{object}	internal.Warehouse
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid ID format"
+//	@Failure		404		{object}	utils.ErrorResponse	"No warehouse found with ID"
+//	@Failure		422		{object}	utils.ErrorResponse	"Invalid fields or include"
+//	@Failure		500		{object}	utils.ErrorResponse	"An error occurred while retrieving the warehouse"
 //	@Router			/warehouses/{id} [get]
 func (h *WarehouseHandler) GetByID() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -104,14 +151,27 @@ func (h *WarehouseHandler) GetByID() http.HandlerFunc {
 			return
 		}
 
-		warehouse, err := h.service.GetByID(id)
+		shape, err := utils.ParseResponseShape[internal.Warehouse](r.URL.Query(), internal.WarehouseIncludeLocality)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		warehouse, err := h.service.GetByID(r.Context(), id)
 		if err != nil {
 			utils.HandleError(w, err)
 
 			return
 		}
 
-		utils.JSON(w, http.StatusOK, warehouse)
+		body, err := utils.ShapeOne(r.Context(), warehouse, shape, h.service.GetRelations)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.SetETag(w, warehouse.Version)
+		utils.JSON(w, http.StatusOK, body)
 	}
 }
 
@@ -145,9 +205,11 @@ func (h *WarehouseHandler) Post() http.HandlerFunc {
 			LocalityID:         body.LocalityID,
 			MinimumCapacity:    body.MinimumCapacity,
 			MinimumTemperature: body.MinimumTemperature,
+			Latitude:           body.Latitude,
+			Longitude:          body.Longitude,
 		}
 
-		newWarehouse, err := h.service.Save(newWarehouse)
+		newWarehouse, err := h.service.Save(r.Context(), newWarehouse)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
@@ -165,12 +227,15 @@ func (h *WarehouseHandler) Post() http.HandlerFunc {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id			path		int							true	"Warehouse ID"
+//	@Param			If-Match	header		string						true	"ETag of the warehouse the changes are made on"
 //	@Param			warehouse	body		internal.WarehousePointers	true	"Warehouse data"
 //	@Success		200			{object}	internal.Warehouse
 //	@Failure		400			{object}	utils.ErrorResponse	"Invalid ID format or request body"
 //	@Failure		404			{object}	utils.ErrorResponse	"Warehouse not found"
 //	@Failure		409			{object}	utils.ErrorResponse	"Conflict error"
+//	@Failure		412			{object}	utils.ErrorResponse	"The warehouse was changed since its ETag was read"
 //	@Failure		422			{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		428			{object}	utils.ErrorResponse	"If-Match is missing"
 //	@Failure		500			{object}	utils.ErrorResponse	"Internal server error"
 //	@Router			/warehouses/{id} [put]
 func (h *WarehouseHandler) Update() http.HandlerFunc {
@@ -181,18 +246,27 @@ func (h *WarehouseHandler) Update() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
 		var body internal.WarehousePointers
 		if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
-		updatedWarehouse, err := h.service.Update(id, body)
+		body.Version = version
+
+		updatedWarehouse, err := h.service.Update(r.Context(), id, body)
 		if err != nil {
 			utils.HandleError(w, err)
 			return
 		}
 
+		utils.SetETag(w, updatedWarehouse.Version)
 		utils.JSON(w, http.StatusOK, updatedWarehouse)
 	}
 }
@@ -204,10 +278,13 @@ func (h *WarehouseHandler) Update() http.HandlerFunc {
 //	@Tags			warehouses
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int					true	"Warehouse ID"
+//	@Param			id			path		int					true	"Warehouse ID"
+//	@Param			If-Match	header		string				true	"ETag of the warehouse to delete"
 //	@Success		204	{object}	nil					"No Content"
 //	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID format"
 //	@Failure		404	{object}	utils.ErrorResponse	"No warehouse found with the given ID"
+//	@Failure		412	{object}	utils.ErrorResponse	"The warehouse was changed since its ETag was read"
+//	@Failure		428	{object}	utils.ErrorResponse	"If-Match is missing"
 //	@Failure		500	{object}	utils.ErrorResponse	"An error occurred while deleting the warehouse"
 //	@Router			/warehouses/{id} [delete]
 func (h *WarehouseHandler) Delete() http.HandlerFunc {
@@ -218,7 +295,13 @@ func (h *WarehouseHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		err = h.service.Delete(id)
+		version, err := utils.IfMatch(r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		err = h.service.Delete(r.Context(), id, version)
 
 		if err != nil {
 			utils.HandleError(w, err)
@@ -228,3 +311,131 @@ func (h *WarehouseHandler) Delete() http.HandlerFunc {
 		utils.JSON(w, http.StatusNoContent, nil)
 	}
 }
+
+// Restore handles the restoration of a soft deleted warehouse by its ID.
+//
+//	@Summary		Restore a warehouse
+//	@Description	Restores a deleted warehouse by its ID
+//	@Tags			warehouses
+//	@Produce		json
+//	@Param			id	path		int					true	"Warehouse ID"
+//	@Success		200	{object}	internal.Warehouse	"Restored warehouse"
+//	@Failure		400	{object}	utils.ErrorResponse	"Invalid ID format"
+//	@Failure		404	{object}	utils.ErrorResponse	"No deleted warehouse found with the given ID"
+//	@Failure		500	{object}	utils.ErrorResponse	"An error occurred while restoring the warehouse"
+//	@Router			/warehouses/{id}/restore [post]
+func (h *WarehouseHandler) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest(INVALID))
+			return
+		}
+
+		warehouse, err := h.service.Restore(r.Context(), id)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, warehouse)
+	}
+}
+
+// GetNearestWithStock handles the HTTP request to find the warehouses with stock of a product closest to a locality.
+//
+//	@Summary		Get the nearest warehouses with stock
+//	@Description	Lists the warehouses holding stock of a product sorted by great-circle distance to a locality
+//	@Tags			warehouses
+//	@Produce		json
+//	@Param			locality_id	query		int	true	"Locality ID"
+//	@Param			product_id	query		int	true	"Product ID"
+//	@Param			quantity	query		int	false	"Minimum stock, 1 by default"
+//	@Param			limit		query		int	false	"Maximum number of warehouses, 5 by default"
+//	@Success		200			{array}		internal.WarehouseDistance
+//	@Failure		400			{object}	utils.ErrorResponse	"Invalid query params"
+//	@Failure		404			{object}	utils.ErrorResponse	"Locality or product not found"
+//	@Failure		422			{object}	utils.ErrorResponse	"Invalid arguments"
+//	@Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/warehouses/nearest [get]
+func (h *WarehouseHandler) GetNearestWithStock() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		localityID, err := strconv.Atoi(query.Get("locality_id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("locality_id"))
+			return
+		}
+
+		productID, err := strconv.Atoi(query.Get("product_id"))
+		if err != nil {
+			utils.HandleError(w, utils.EBadRequest("product_id"))
+			return
+		}
+
+		quantity := 1
+		if query.Get("quantity") != "" {
+			quantity, err = strconv.Atoi(query.Get("quantity"))
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("quantity"))
+				return
+			}
+		}
+
+		limit := defaultNearestLimit
+		if query.Get("limit") != "" {
+			limit, err = strconv.Atoi(query.Get("limit"))
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("limit"))
+				return
+			}
+		}
+
+		warehouses, err := h.service.GetNearestWithStock(r.Context(), localityID, productID, quantity, limit)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, http.StatusOK, warehouses)
+	}
+}
+
+// Import handles the HTTP request to create warehouses in bulk from a CSV file or a JSON array.
+//
+//	@Summary		Import warehouses
+//	@Description	Creates warehouses in bulk from a CSV file or a JSON array, reporting the result of every row
+//	@Tags			warehouses
+//	@Accept			text/csv,json,mpfd
+//	@Produce		json
+//	@Param			dry_run	query		bool				false	"Only validate the rows"
+//	@Param			atomic	query		bool				false	"Create no warehouse if any row fails"
+//	@Success		200		{object}	utils.ImportReport
+//	@Failure		400		{object}	utils.ErrorResponse	"Invalid file or query params"
+//	@Failure		422		{object}	utils.ImportReport	"Atomic import rejected"
+//	@Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+//	@Router			/api/v1/warehouses/import [post]
+func (h *WarehouseHandler) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		options, err := utils.ParseImportOptions(r.URL.Query())
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		items, err := utils.DecodeImport[internal.Warehouse](w, r)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		report, err := h.service.Import(r.Context(), items, options)
+		if err != nil {
+			utils.HandleError(w, err)
+			return
+		}
+
+		utils.JSON(w, report.StatusCode(), report)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
@@ -20,31 +21,66 @@ type mockWarehouseService struct {
 	mock.Mock
 }
 
-func (m *mockWarehouseService) GetAll() ([]internal.Warehouse, error) {
+func (m *mockWarehouseService) GetAll(ctx context.Context) ([]internal.Warehouse, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseService) GetByID(id int) (internal.Warehouse, error) {
+func (m *mockWarehouseService) GetByIDs(ctx context.Context, ids []int) ([]internal.Warehouse, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Warehouse), args.Error(1)
+}
+
+func (m *mockWarehouseService) GetRelations(ctx context.Context, warehouses []internal.Warehouse, include []string) ([]internal.WarehouseRelations, error) {
+	args := m.Called(warehouses, include)
+	return args.Get(0).([]internal.WarehouseRelations), args.Error(1)
+}
+
+func (m *mockWarehouseService) List(ctx context.Context, query utils.ListQuery) ([]internal.Warehouse, utils.Pagination, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Warehouse), args.Get(1).(utils.Pagination), args.Error(2)
+}
+
+func (m *mockWarehouseService) GetByID(ctx context.Context, id int) (internal.Warehouse, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseService) Save(warehouse internal.Warehouse) (internal.Warehouse, error) {
+func (m *mockWarehouseService) Save(ctx context.Context, warehouse internal.Warehouse) (internal.Warehouse, error) {
 	args := m.Called(warehouse)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseService) Update(id int, warehouse internal.WarehousePointers) (internal.Warehouse, error) {
+func (m *mockWarehouseService) Update(ctx context.Context, id int, warehouse internal.WarehousePointers) (internal.Warehouse, error) {
 	args := m.Called(id, warehouse)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseService) Delete(id int) error {
-	args := m.Called(id)
+func (m *mockWarehouseService) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *mockWarehouseService) Restore(ctx context.Context, id int) (internal.Warehouse, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Warehouse), args.Error(1)
+}
+
+func (m *mockWarehouseService) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockWarehouseService) GetNearestWithStock(ctx context.Context, localityID, productID, quantity, limit int) ([]internal.WarehouseDistance, error) {
+	args := m.Called(localityID, productID, quantity, limit)
+	return args.Get(0).([]internal.WarehouseDistance), args.Error(1)
+}
+
+func (m *mockWarehouseService) Import(ctx context.Context, items []utils.ImportItem[internal.Warehouse], options utils.ImportOptions) (utils.ImportReport, error) {
+	args := m.Called(items, options)
+	return args.Get(0).(utils.ImportReport), args.Error(1)
+}
+
 func TestWarehouseHandler_GetAll(t *testing.T) {
 	cases := []struct {
 		TestName           string
@@ -55,7 +91,7 @@ func TestWarehouseHandler_GetAll(t *testing.T) {
 		{
 			TestName:           "GetAll_OK",
 			ErrorToReturn:      nil,
-			ExpectedBody:       `{"data":[{"id":1,"address":"1234 Cold Storage St, LA","telephone":"555-3456","warehouse_code":"WH001","locality_id":1,"minimum_capacity":30,"minimum_temperature":20},{"id":2,"address":"5678 Cool Goods Ave, Toronto","telephone":"555-7890","warehouse_code":"WH002","locality_id":3,"minimum_capacity":30,"minimum_temperature":15}]}`,
+			ExpectedBody:       `{"data":[{"id":1,"address":"1234 Cold Storage St, LA","telephone":"555-3456","warehouse_code":"WH001","locality_id":1,"minimum_capacity":30,"minimum_temperature":20},{"id":2,"address":"5678 Cool Goods Ave, Toronto","telephone":"555-7890","warehouse_code":"WH002","locality_id":3,"minimum_capacity":30,"minimum_temperature":15}],"pagination":{"limit":50,"has_more":false}}`,
 			ExpectedStatusCode: http.StatusOK,
 		},
 		{
@@ -70,16 +106,16 @@ func TestWarehouseHandler_GetAll(t *testing.T) {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockWarehouseService)
 			if c.ErrorToReturn == nil {
-				service.On("GetAll").Return([]internal.Warehouse{
+				service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Warehouse{
 					{
 						ID: 1, Address: "1234 Cold Storage St, LA", Telephone: "555-3456", WarehouseCode: "WH001", LocalityID: 1, MinimumCapacity: 30, MinimumTemperature: 20,
 					},
 					{
 						ID: 2, Address: "5678 Cool Goods Ave, Toronto", Telephone: "555-7890", WarehouseCode: "WH002", LocalityID: 3, MinimumCapacity: 30, MinimumTemperature: 15,
 					},
-				}, nil)
+				}, utils.Pagination{Limit: utils.DefaultListLimit}, nil)
 			} else {
-				service.On("GetAll").Return([]internal.Warehouse{}, c.ErrorToReturn)
+				service.On("List", utils.ListQuery{Limit: utils.DefaultListLimit}).Return([]internal.Warehouse{}, utils.Pagination{}, c.ErrorToReturn)
 			}
 
 			h := handler.NewWarehouseHandler(service)
@@ -213,6 +249,7 @@ func TestWarehouseHandler_Update(t *testing.T) {
 		TestName           string
 		ID                 string
 		RequestBody        string
+		IfMatch            string
 		ErrorToReturn      error
 		ExpectedBody       string
 		ExpectedStatusCode int
@@ -220,14 +257,16 @@ func TestWarehouseHandler_Update(t *testing.T) {
 		{
 			TestName:           "Update_OK",
 			ID:                 "1",
+			IfMatch:            `"1"`,
 			RequestBody:        `{"warehouse_code":"WH001","address":"1234 Cold Storage St, LA","telephone":"555-3456","locality_id":1,"minimum_capacity":30,"minimum_temperature":20}`,
 			ErrorToReturn:      nil,
-			ExpectedBody:       `{"data": {"id":1,"address":"1234 Cold Storage St, LA","telephone":"555-3456","warehouse_code":"WH001","locality_id":1,"minimum_capacity":30,"minimum_temperature":20}}`,
+			ExpectedBody:       `{"data": {"id":1,"address":"1234 Cold Storage St, LA","telephone":"555-3456","warehouse_code":"WH001","locality_id":1,"minimum_capacity":30,"minimum_temperature":20,"version":2}}`,
 			ExpectedStatusCode: http.StatusOK,
 		},
 		{
 			TestName:           "Update_NotFound",
 			ID:                 "2",
+			IfMatch:            `"1"`,
 			RequestBody:        `{"address":"Updated Address"}`,
 			ErrorToReturn:      utils.ENotFound("Warehouse"),
 			ExpectedBody:       `{"message":"entity not found: Warehouse doesn't exist", "status":"Not Found"}`,
@@ -236,6 +275,7 @@ func TestWarehouseHandler_Update(t *testing.T) {
 		{
 			TestName:           "Update_BadRequest",
 			ID:                 "abc",
+			IfMatch:            `"1"`,
 			RequestBody:        `{"address":"Updated Address"}`,
 			ErrorToReturn:      utils.EBadRequest("Invalid ID"),
 			ExpectedBody:       `{"message":"invalid format: Invalid ID with invalid format", "status":"Bad Request"}`,
@@ -244,11 +284,28 @@ func TestWarehouseHandler_Update(t *testing.T) {
 		{
 			TestName:           "Update_InvalidJson",
 			ID:                 "1",
+			IfMatch:            `"1"`,
 			RequestBody:        `{INVALID_JSON}`,
 			ErrorToReturn:      errors.New("invalid Json"),
 			ExpectedBody:       `{"message":"internal server error", "status":"Internal Server Error"}`,
 			ExpectedStatusCode: http.StatusInternalServerError,
 		},
+		{
+			TestName:           "Update_PreconditionFailed",
+			ID:                 "1",
+			IfMatch:            `"1"`,
+			RequestBody:        `{"address":"Updated Address"}`,
+			ErrorToReturn:      utils.EPreconditionFailed("warehouse"),
+			ExpectedBody:       `{"message":"precondition failed: warehouse was changed, read it again to get its current version", "status":"Precondition Failed"}`,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			TestName:           "Update_PreconditionRequired",
+			ID:                 "1",
+			RequestBody:        `{"address":"Updated Address"}`,
+			ExpectedBody:       `{"message":"precondition required: If-Match header with the ETag of the resource is required", "status":"Precondition Required"}`,
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
 	}
 
 	for _, c := range cases {
@@ -262,11 +319,15 @@ func TestWarehouseHandler_Update(t *testing.T) {
 				LocalityID:         1,
 				MinimumCapacity:    30,
 				MinimumTemperature: 20,
+				Version:            2,
 			}, c.ErrorToReturn)
 
 			h := handler.NewWarehouseHandler(service)
 			req := httptest.NewRequest(http.MethodPut, "/warehouses/"+c.ID, strings.NewReader(c.RequestBody))
 			req.Header.Set("Content-Type", "application/json")
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", c.ID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -275,6 +336,9 @@ func TestWarehouseHandler_Update(t *testing.T) {
 			h.Update()(res, req)
 			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
 			require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			if c.ExpectedStatusCode == http.StatusOK {
+				require.Equal(t, `"2"`, res.Header().Get("ETag"))
+			}
 		})
 	}
 }
@@ -283,18 +347,24 @@ func TestWarehouseHandler_Delete(t *testing.T) {
 	cases := []struct {
 		TestName           string
 		ID                 string
+		IfMatch            string
+		Version            int
 		ErrorToReturn      error
 		ExpectedStatusCode int
 	}{
 		{
 			TestName:           "Delete_OK",
 			ID:                 "1",
+			IfMatch:            `"3"`,
+			Version:            3,
 			ErrorToReturn:      nil,
 			ExpectedStatusCode: http.StatusNoContent,
 		},
 		{
 			TestName:           "Delete_NotFound",
 			ID:                 "2",
+			IfMatch:            `"3"`,
+			Version:            3,
 			ErrorToReturn:      utils.ENotFound("Warehouse"),
 			ExpectedStatusCode: http.StatusNotFound,
 		},
@@ -304,15 +374,38 @@ func TestWarehouseHandler_Delete(t *testing.T) {
 			ErrorToReturn:      utils.EBadRequest("Invalid ID"),
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			TestName:           "Delete_AnyVersion",
+			ID:                 "1",
+			IfMatch:            "*",
+			Version:            utils.AnyVersion,
+			ExpectedStatusCode: http.StatusNoContent,
+		},
+		{
+			TestName:           "Delete_PreconditionFailed",
+			ID:                 "1",
+			IfMatch:            `"2"`,
+			Version:            2,
+			ErrorToReturn:      utils.EPreconditionFailed("warehouse"),
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			TestName:           "Delete_PreconditionRequired",
+			ID:                 "1",
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
 			service := new(mockWarehouseService)
-			service.On("Delete", mock.Anything).Return(c.ErrorToReturn)
+			service.On("Delete", mock.Anything, c.Version).Return(c.ErrorToReturn)
 
 			h := handler.NewWarehouseHandler(service)
 			req := httptest.NewRequest(http.MethodDelete, "/warehouses/"+c.ID, nil)
+			if c.IfMatch != "" {
+				req.Header.Set("If-Match", c.IfMatch)
+			}
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", c.ID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
@@ -323,3 +416,77 @@ func TestWarehouseHandler_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestWarehouseHandler_GetNearestWithStock(t *testing.T) {
+	latitude, longitude := 49.28273, -123.120735
+	nearest := []internal.WarehouseDistance{
+		{
+			WarehouseStock: internal.WarehouseStock{
+				Warehouse: internal.Warehouse{ID: 3, Address: "1 Harbour St", Telephone: "555-0000", WarehouseCode: "WH003", LocalityID: 3,
+					Latitude: &latitude, Longitude: &longitude},
+				Stock: 10,
+			},
+			DistanceKm: 1741.32,
+		},
+	}
+
+	cases := []struct {
+		TestName           string
+		Query              string
+		Limit              int
+		ErrorToReturn      error
+		ExpectedBody       string
+		ExpectedStatusCode int
+	}{
+		{
+			TestName:           "GetNearestWithStock_OK",
+			Query:              "locality_id=1&product_id=1",
+			Limit:              5,
+			ExpectedBody:       `{"data":[{"id":3,"address":"1 Harbour St","telephone":"555-0000","warehouse_code":"WH003","locality_id":3,"minimum_capacity":0,"minimum_temperature":0,"latitude":49.28273,"longitude":-123.120735,"stock":10,"distance_km":1741.32}]}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "GetNearestWithStock_Limit",
+			Query:              "locality_id=1&product_id=1&limit=1",
+			Limit:              1,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			TestName:           "GetNearestWithStock_BadRequest",
+			Query:              "product_id=1",
+			ExpectedBody:       `{"message":"invalid format: locality_id with invalid format", "status":"Bad Request"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			TestName:           "GetNearestWithStock_LocalityWithoutCoordinates",
+			Query:              "locality_id=5&product_id=1",
+			Limit:              5,
+			ErrorToReturn:      utils.EBR("locality has no coordinates"),
+			ExpectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			TestName:           "GetNearestWithStock_NotFound",
+			Query:              "locality_id=9&product_id=1",
+			Limit:              5,
+			ErrorToReturn:      utils.ENotFound("Locality"),
+			ExpectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			service := new(mockWarehouseService)
+			service.On("GetNearestWithStock", mock.Anything, 1, 1, c.Limit).Return(nearest, c.ErrorToReturn)
+
+			h := handler.NewWarehouseHandler(service)
+			req := httptest.NewRequest(http.MethodGet, "/warehouses/nearest?"+c.Query, nil)
+			res := httptest.NewRecorder()
+
+			h.GetNearestWithStock()(res, req)
+			require.Equal(t, c.ExpectedStatusCode, res.Result().StatusCode)
+			if c.ExpectedBody != "" {
+				require.JSONEq(t, c.ExpectedBody, res.Body.String())
+			}
+		})
+	}
+}
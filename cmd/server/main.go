@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/application"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
@@ -27,9 +30,56 @@ func main() {
 			Net:    "tcp",
 			Addr:   "localhost" + os.Getenv("DB.ADDRESS"),
 			DBName: os.Getenv("DB.NAME"),
+			// the deleted_at of the soft deleted records is scanned into a time, the DATETIME
+			// columns sent as strings are formatted with utils.DateTime
+			ParseTime: true,
 		},
 		Addr: "127.0.0.1" + os.Getenv("SERVER.PORT"),
 	}
+	// - soft deleted records are kept for the default retention when not set
+	if days, err := strconv.Atoi(os.Getenv("SOFT_DELETE.RETENTION_DAYS")); err == nil {
+		cfg.PurgeRetention = time.Duration(days) * 24 * time.Hour
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("SOFT_DELETE.PURGE_INTERVAL_HOURS")); err == nil {
+		cfg.PurgeInterval = time.Duration(hours) * time.Hour
+	}
+
+	// - the responses of the requests with an Idempotency-Key are replayed for the default window when not set
+	if hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY.WINDOW_HOURS")); err == nil {
+		cfg.IdempotencyWindow = time.Duration(hours) * time.Hour
+	}
+
+	// - the requests get the default deadline when not set
+	if seconds, err := strconv.Atoi(os.Getenv("SERVER.REQUEST_TIMEOUT_SECONDS")); err == nil {
+		cfg.RequestTimeout = time.Duration(seconds) * time.Second
+	}
+
+	// - the tokens are signed with the HS256 secret, or the RS256 private key of the file
+	cfg.AuthAlgorithm = os.Getenv("AUTH.ALGORITHM")
+	cfg.AuthSecret = os.Getenv("AUTH.SECRET")
+	cfg.AuthPrivateKeyFile = os.Getenv("AUTH.PRIVATE_KEY_FILE")
+
+	if minutes, err := strconv.Atoi(os.Getenv("AUTH.ACCESS_TOKEN_MINUTES")); err == nil {
+		cfg.AccessTokenTTL = time.Duration(minutes) * time.Minute
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("AUTH.REFRESH_TOKEN_HOURS")); err == nil {
+		cfg.RefreshTokenTTL = time.Duration(hours) * time.Hour
+	}
+
+	// - the requests of each API key or IP get the default limits when not set
+	if requests, err := strconv.Atoi(os.Getenv("RATE_LIMIT.REQUESTS_PER_MINUTE")); err == nil {
+		cfg.RateLimit = internal.RateLimit{Requests: requests, Period: time.Minute}
+	}
+
+	if requests, err := strconv.Atoi(os.Getenv("RATE_LIMIT.AUTH_REQUESTS_PER_MINUTE")); err == nil {
+		cfg.AuthRateLimit = internal.RateLimit{Requests: requests, Period: time.Minute}
+	}
+
+	if requests, err := strconv.Atoi(os.Getenv("RATE_LIMIT.IMPORT_REQUESTS_PER_MINUTE")); err == nil {
+		cfg.ImportRateLimit = internal.RateLimit{Requests: requests, Period: time.Minute}
+	}
 	app := application.NewApplicationDefault(cfg)
 	// - set up
 	err = app.SetUp()
@@ -10,7 +10,9 @@ CREATE TABLE sellers(
     company_name VARCHAR(255),
     address VARCHAR(255),
     telephone VARCHAR(255),
-    locality_id INT
+    locality_id INT,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 -- Sprint 1, requirement 2
@@ -21,7 +23,11 @@ CREATE TABLE warehouses(
     warehouse_code VARCHAR(255),
     locality_id INT,
     minimum_capacity INT,
-    minimum_temperature INT
+    minimum_temperature INT,
+    latitude DECIMAL(9,6) NULL,
+    longitude DECIMAL(9,6) NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 -- Sprint 1, requirement 3
@@ -35,7 +41,11 @@ CREATE TABLE sections(
     current_temperature DECIMAL(19,2),
     minimum_temperature DECIMAL(19,2),
     product_type_id INT,
-    warehouse_id INT
+    warehouse_id INT,
+    -- volume in the units of the product dimensions, NULL means no limit
+    maximum_volume DECIMAL(19,2) NULL,
+    maximum_weight DECIMAL(19,2) NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 -- Sprint 1, requirement 4
@@ -51,11 +61,41 @@ CREATE TABLE products(
     recommended_freezing_temperature DECIMAL(19,2),
     width DECIMAL(19,2),
     product_type_id INT,
-    seller_id INT
+    seller_id INT,
+    barcode VARCHAR(14) NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1,
+    UNIQUE KEY uq_products_barcode (barcode)
 );
 CREATE TABLE product_types(
     id INT PRIMARY KEY AUTO_INCREMENT,
-    description VARCHAR(255)
+    description VARCHAR(255),
+    -- storage range in Celsius, NULL means no bound
+    minimum_temperature DECIMAL(19,2) NULL,
+    maximum_temperature DECIMAL(19,2) NULL,
+    version INT NOT NULL DEFAULT 1
+);
+-- stored in both directions, so they can be read from either product type
+CREATE TABLE product_type_incompatibilities(
+    product_type_id INT NOT NULL,
+    incompatible_product_type_id INT NOT NULL,
+    PRIMARY KEY (product_type_id, incompatible_product_type_id),
+    FOREIGN KEY (product_type_id) REFERENCES product_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (incompatible_product_type_id) REFERENCES product_types(id) ON DELETE CASCADE
+);
+CREATE TABLE product_packaging_units(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    units_per_package INT NOT NULL,
+    width DECIMAL(19,2) NOT NULL,
+    height DECIMAL(19,2) NOT NULL,
+    length DECIMAL(19,2) NOT NULL,
+    net_weight DECIMAL(19,2) NOT NULL,
+    barcode VARCHAR(255) NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    UNIQUE KEY uq_packaging_units_product_name (product_id, name),
+    UNIQUE KEY uq_packaging_units_barcode (barcode)
 );
 
 -- Sprint 1, requirement 5
@@ -64,7 +104,9 @@ CREATE TABLE employees(
     id_card_number VARCHAR(255) NOT NULL UNIQUE,
     first_name VARCHAR(255) NOT NULL,
     last_name VARCHAR(255) NOT NULL,
-    warehouse_id INT NOT NULL
+    warehouse_id INT NOT NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 -- Sprint 1, requirement 6
@@ -72,7 +114,9 @@ CREATE TABLE buyers(
     id INT PRIMARY KEY AUTO_INCREMENT,
     id_card_number VARCHAR(255),
     first_name VARCHAR(255),
-    last_name VARCHAR(255)
+    last_name VARCHAR(255),
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 
@@ -80,17 +124,22 @@ CREATE TABLE buyers(
 CREATE TABLE localities(
     id INT PRIMARY KEY NOT NULL,
     locality_name VARCHAR(255),
-    province_id INT
+    province_id INT,
+    latitude DECIMAL(9,6) NULL,
+    longitude DECIMAL(9,6) NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 CREATE TABLE provinces(
     id INT PRIMARY KEY AUTO_INCREMENT,
     province_name VARCHAR(255),
-    country_id INT
+    country_id INT,
+    version INT NOT NULL DEFAULT 1
 );
 CREATE TABLE countries(
     id INT PRIMARY KEY AUTO_INCREMENT,
-    country_name VARCHAR(255)
+    country_name VARCHAR(255),
+    version INT NOT NULL DEFAULT 1
 );
 
 
@@ -101,7 +150,9 @@ CREATE TABLE carriers(
     company_name VARCHAR(255),
     address VARCHAR(255),
     telephone VARCHAR(255),
-    locality_id INT
+    locality_id INT,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
 );
 
 -- Sprint 2, requirement 3
@@ -116,7 +167,9 @@ CREATE TABLE product_batches(
     manufacturing_hour INT(2),
     minimum_temperature DECIMAL(19,2),
     product_id INT,
-    section_id INT
+    section_id INT,
+    barcode VARCHAR(255) NULL,
+    UNIQUE KEY uq_product_batches_barcode (barcode)
 );
 
 -- Sprint 2, requirement 4
@@ -145,7 +198,8 @@ CREATE TABLE purchase_orders(
     order_date DATETIME(6),
     tracking_code VARCHAR(255),
     buyer_id INT,
-    product_record_id INT
+    product_record_id INT,
+    order_status_id INT NOT NULL DEFAULT 1
 );
 CREATE TABLE order_status(
     id INT PRIMARY KEY AUTO_INCREMENT,
@@ -153,6 +207,71 @@ CREATE TABLE order_status(
 );
 
 
+-- Audit trail of the changes made through the API
+CREATE TABLE audit_log(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity VARCHAR(100) NOT NULL,
+    entity_id INT NOT NULL,
+    changes JSON NOT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_audit_log_entity (entity, entity_id),
+    KEY idx_audit_log_actor (actor),
+    KEY idx_audit_log_created_at (created_at)
+);
+
+-- Responses of the requests made with an Idempotency-Key, status_code is 0 while in progress
+CREATE TABLE idempotency_keys(
+    client VARCHAR(64) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response MEDIUMBLOB NULL,
+    created_at DATETIME NOT NULL,
+    locked_until DATETIME NOT NULL,
+    PRIMARY KEY (client, idempotency_key, method, path),
+    KEY idx_idempotency_keys_created_at (created_at)
+);
+
+CREATE TABLE users(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'analyst',
+    seller_id INT NULL,
+    buyer_id INT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_users_username (username),
+    FOREIGN KEY (seller_id) REFERENCES sellers(id),
+    FOREIGN KEY (buyer_id) REFERENCES buyers(id)
+);
+
+CREATE TABLE user_warehouses(
+    user_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    PRIMARY KEY (user_id, warehouse_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id) ON DELETE CASCADE
+);
+
+CREATE TABLE api_keys(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix CHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(1024) NOT NULL,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    UNIQUE KEY uq_api_keys_prefix (prefix),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- Sprint 1 constraints
 -- R1
 ALTER TABLE sellers ADD FOREIGN KEY (locality_id) REFERENCES localities(id);
@@ -185,6 +304,22 @@ ALTER TABLE inbound_orders ADD FOREIGN KEY (warehouse_id) REFERENCES warehouses(
 -- R6
 ALTER TABLE purchase_orders ADD FOREIGN KEY (buyer_id) REFERENCES buyers(id);
 ALTER TABLE purchase_orders ADD FOREIGN KEY (product_record_id) REFERENCES product_records(id);
+-- Purchase order status, see migrations/017_purchase_order_status.sql
+ALTER TABLE purchase_orders ADD FOREIGN KEY (order_status_id) REFERENCES order_status(id);
+CREATE INDEX idx_purchase_orders_buyer_date ON purchase_orders (buyer_id, order_date);
+
+-- Product catalogue search, see migrations/001_product_search_indexes.sql
+CREATE INDEX idx_products_dimensions ON products (width, height, `length`);
+CREATE INDEX idx_products_net_weight ON products (net_weight);
+CREATE INDEX idx_products_freezing_temperature ON products (recommended_freezing_temperature);
+CREATE INDEX idx_products_product_code ON products (product_code);
+CREATE FULLTEXT INDEX ftx_products_description_code ON products (description, product_code);
+
+-- Product packaging units, see migrations/002_product_packaging_units.sql
+ALTER TABLE product_packaging_units ADD FOREIGN KEY (product_id) REFERENCES products(id);
+
+-- Barcode lookup, see migrations/003_barcodes.sql
+CREATE INDEX idx_product_batches_product_number ON product_batches (product_id, batch_number);
 
 
 
@@ -201,10 +336,10 @@ INSERT INTO provinces (province_name, country_id) VALUES
 ('Ontario', 2);
 
 -- Insert sample localities
-INSERT INTO localities (id, locality_name, province_id) VALUES
-(1, 'Los Angeles', 1),
-(2, 'Toronto', 2),
-(3, 'Vancouver', 2);
+INSERT INTO localities (id, locality_name, province_id, latitude, longitude) VALUES
+(1, 'Los Angeles', 1, 34.052235, -118.243683),
+(2, 'Toronto', 2, 43.651070, -79.347015),
+(3, 'Vancouver', 2, 49.282730, -123.120735);
 
 -- Insert sample carriers
 INSERT INTO carriers (id, cid, company_name, address, telephone, locality_id) VALUES
@@ -228,25 +363,36 @@ INSERT INTO warehouses (address, telephone, warehouse_code, locality_id, minimum
 ('5678 Cool Goods Ave, Toronto', '555-7890', 'WH002', 2, 30, 15);
 
 -- Insert sample product types
-INSERT INTO product_types (description) VALUES
-('Vegetables'),
-('Fruits'),
-('Dairy'),
-('Meat');
+INSERT INTO product_types (description, minimum_temperature, maximum_temperature) VALUES
+('Vegetables', 0.0, 10.0),
+('Fruits', 0.0, 12.0),
+('Dairy', 1.0, 8.0),
+('Meat', -2.0, 4.0);
+
+-- Meat cannot share a section with vegetables nor fruits
+INSERT INTO product_type_incompatibilities (product_type_id, incompatible_product_type_id) VALUES
+(4, 1), (1, 4),
+(4, 2), (2, 4);
 
 -- Insert sample products
-INSERT INTO products (description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id) VALUES
-('Fresh Apples', 0.1, 0.2, 4.5, 7.5, 1.2, 'PA001', -2.5, 5.0, 2, 1),
-('Organic Carrots', 0.2, 0.1, 6.0, 5.0, 0.8, 'CA001', -3.0, 4.0, 1, 2),
-('Skimmed Milk', 0.05, 0.03, 10.0, 15.0, 1.0, 'MI001', -4.0, 8.0, 3, 1),
-('Chicken Breasts', 0.3, 0.4, 8.0, 10.0, 1.5, 'CH001', -5.0, 7.0, 4, 2);
+INSERT INTO products (description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, barcode) VALUES
+('Fresh Apples', 0.1, 0.2, 4.5, 7.5, 1.2, 'PA001', -2.5, 5.0, 2, 1, '07791234000012'),
+('Organic Carrots', 0.2, 0.1, 6.0, 5.0, 0.8, 'CA001', -3.0, 4.0, 1, 2, '07791234000029'),
+('Skimmed Milk', 0.05, 0.03, 10.0, 15.0, 1.0, 'MI001', -4.0, 8.0, 3, 1, '07791234000036'),
+('Chicken Breasts', 0.3, 0.4, 8.0, 10.0, 1.5, 'CH001', -5.0, 7.0, 4, 2, '07791234000043');
+
+-- Insert sample packaging units
+INSERT INTO product_packaging_units (product_id, name, units_per_package, width, height, length, net_weight, barcode) VALUES
+(1, 'Box', 12, 30.0, 15.0, 40.0, 14.4, '17791234000019'),
+(1, 'Crate', 48, 60.0, 30.0, 80.0, 57.6, '27791234000016'),
+(3, 'Pack', 6, 25.0, 20.0, 30.0, 6.0, '17791234000033');
 
 -- Insert sample sections
 INSERT INTO sections (section_number, current_capacity, maximum_capacity, minimum_capacity, current_temperature, minimum_temperature, product_type_id, warehouse_id) VALUES
 (1, 50, 100, 20, 5.0, -2.0, 1, 1),
 (2, 30, 80, 15, 4.0, -3.0, 2, 2),
 (3, 20, 50, 10, 7.0, -5.0, 3, 1),
-(4, 40, 100, 20, 6.0, -4.0, 4, 2);
+(4, 40, 100, 20, 2.0, -4.0, 4, 2);
 
 
 -- Insert sample employees
@@ -260,9 +406,9 @@ INSERT INTO buyers (id_card_number, first_name, last_name) VALUES
 ('B002', 'Diana', 'White');
 
 -- Insert sample product batches
-INSERT INTO product_batches (batch_number, current_quantity, current_temperature, due_date, initial_quantity, manufacturing_date, manufacturing_hour, minimum_temperature, product_id, section_id) VALUES
-(100, 500, 5.0, '2025-01-15 12:00:00', 1000, '2025-01-10', 8, 3.0, 1, 1),
-(200, 300, 4.0, '2025-01-18 12:00:00', 800, '2025-01-12', 9, 2.0, 2, 2);
+INSERT INTO product_batches (batch_number, current_quantity, current_temperature, due_date, initial_quantity, manufacturing_date, manufacturing_hour, minimum_temperature, product_id, section_id, barcode) VALUES
+(100, 500, 5.0, '2025-01-15 12:00:00', 1000, '2025-01-10', 8, 3.0, 1, 1, '(01)07791234000012(10)100(17)250115'),
+(200, 300, 4.0, '2025-01-18 12:00:00', 800, '2025-01-12', 9, 2.0, 2, 2, '(01)07791234000029(10)200(17)250118');
 
 -- Insert sample product records
 INSERT INTO product_records (last_update_date, purchase_price, sale_price, product_id) VALUES
@@ -277,9 +423,9 @@ INSERT INTO inbound_orders (order_date, order_number, employee_id, product_batch
 ('2025-01-06 12:00:00', 'IN002', 2, 2, 2);
 
 -- Insert sample purchase orders
-INSERT INTO purchase_orders (order_number, order_date, tracking_code, buyer_id, product_record_id) VALUES
-('PO001', '2025-01-05 12:00:00', 'TRK001', 1, 1),
-('PO002', '2025-01-06 12:00:00', 'TRK002', 2, 2);
+INSERT INTO purchase_orders (order_number, order_date, tracking_code, buyer_id, product_record_id, order_status_id) VALUES
+('PO001', '2025-01-05 12:00:00', 'TRK001', 1, 1, 3),
+('PO002', '2025-01-06 12:00:00', 'TRK002', 2, 2, 2);
 
 -- Insert sample product records for tracking prices
 INSERT INTO product_records (last_update_date, purchase_price, sale_price, product_id) VALUES
@@ -0,0 +1,10 @@
+-- Product catalogue search
+-- Indexes backing GET /api/v1/products/search filters, sorting and free-text search
+-- product_type_id and seller_id are already indexed by their foreign keys
+USE fresh_products;
+
+CREATE INDEX idx_products_dimensions ON products (width, height, `length`);
+CREATE INDEX idx_products_net_weight ON products (net_weight);
+CREATE INDEX idx_products_freezing_temperature ON products (recommended_freezing_temperature);
+CREATE INDEX idx_products_product_code ON products (product_code);
+CREATE FULLTEXT INDEX ftx_products_description_code ON products (description, product_code);
@@ -0,0 +1,19 @@
+-- Product packaging units
+-- Units a product is sold or stored in, with their conversion factor to the product base unit
+USE fresh_products;
+
+CREATE TABLE product_packaging_units(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    units_per_package INT NOT NULL,
+    width DECIMAL(19,2) NOT NULL,
+    height DECIMAL(19,2) NOT NULL,
+    length DECIMAL(19,2) NOT NULL,
+    net_weight DECIMAL(19,2) NOT NULL,
+    barcode VARCHAR(255) NOT NULL,
+    UNIQUE KEY uq_packaging_units_product_name (product_id, name),
+    UNIQUE KEY uq_packaging_units_barcode (barcode)
+);
+
+ALTER TABLE product_packaging_units ADD FOREIGN KEY (product_id) REFERENCES products(id);
@@ -0,0 +1,10 @@
+-- Barcodes for products and product batches
+-- Products carry their GTIN-14, batches their canonical GS1-128 label
+USE fresh_products;
+
+ALTER TABLE products ADD COLUMN barcode VARCHAR(14) NULL;
+ALTER TABLE products ADD UNIQUE KEY uq_products_barcode (barcode);
+
+ALTER TABLE product_batches ADD COLUMN barcode VARCHAR(255) NULL;
+ALTER TABLE product_batches ADD UNIQUE KEY uq_product_batches_barcode (barcode);
+CREATE INDEX idx_product_batches_product_number ON product_batches (product_id, batch_number);
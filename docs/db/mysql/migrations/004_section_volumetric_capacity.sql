@@ -0,0 +1,5 @@
+-- Volume and weight limits for sections, NULL means the section has no such limit
+USE fresh_products;
+
+ALTER TABLE sections ADD COLUMN maximum_volume DECIMAL(19,2) NULL;
+ALTER TABLE sections ADD COLUMN maximum_weight DECIMAL(19,2) NULL;
@@ -0,0 +1,14 @@
+-- Temperature ranges and incompatibilities of product types
+-- Incompatibilities are stored in both directions, so they can be read from either product type
+USE fresh_products;
+
+ALTER TABLE product_types ADD COLUMN minimum_temperature DECIMAL(19,2) NULL;
+ALTER TABLE product_types ADD COLUMN maximum_temperature DECIMAL(19,2) NULL;
+
+CREATE TABLE product_type_incompatibilities(
+    product_type_id INT NOT NULL,
+    incompatible_product_type_id INT NOT NULL,
+    PRIMARY KEY (product_type_id, incompatible_product_type_id),
+    FOREIGN KEY (product_type_id) REFERENCES product_types(id) ON DELETE CASCADE,
+    FOREIGN KEY (incompatible_product_type_id) REFERENCES product_types(id) ON DELETE CASCADE
+);
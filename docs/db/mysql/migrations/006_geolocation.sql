@@ -0,0 +1,9 @@
+-- Coordinates of localities and warehouses, in decimal degrees
+-- A warehouse without coordinates is located at the coordinates of its locality
+USE fresh_products;
+
+ALTER TABLE localities ADD COLUMN latitude DECIMAL(9,6) NULL;
+ALTER TABLE localities ADD COLUMN longitude DECIMAL(9,6) NULL;
+
+ALTER TABLE warehouses ADD COLUMN latitude DECIMAL(9,6) NULL;
+ALTER TABLE warehouses ADD COLUMN longitude DECIMAL(9,6) NULL;
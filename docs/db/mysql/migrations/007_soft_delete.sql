@@ -0,0 +1,10 @@
+-- Soft delete of master data, a deleted record keeps its row with the time it was deleted
+-- until it is restored or purged after the retention period
+USE fresh_products;
+
+ALTER TABLE sellers ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE warehouses ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE products ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE buyers ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE carriers ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE employees ADD COLUMN deleted_at DATETIME NULL;
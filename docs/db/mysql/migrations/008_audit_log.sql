@@ -0,0 +1,16 @@
+-- Audit trail of the changes made through the API
+-- changes holds the fields that changed, as {"field": {"before": ..., "after": ...}}
+USE fresh_products;
+
+CREATE TABLE audit_log(
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity VARCHAR(100) NOT NULL,
+    entity_id INT NOT NULL,
+    changes JSON NOT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_audit_log_entity (entity, entity_id),
+    KEY idx_audit_log_actor (actor),
+    KEY idx_audit_log_created_at (created_at)
+);
@@ -0,0 +1,16 @@
+-- Optimistic concurrency of the mutable records, the version is bumped by every change and is the
+-- ETag of the record, a change is only made on the version given in If-Match
+USE fresh_products;
+
+ALTER TABLE sellers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE warehouses ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE sections ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE product_types ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE product_packaging_units ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE employees ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE buyers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE localities ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE provinces ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE countries ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE carriers ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+-- Responses of the requests made with an Idempotency-Key, replayed to their retries until they expire
+-- status_code is 0 while the first request with the key is in progress
+USE fresh_products;
+
+CREATE TABLE idempotency_keys(
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response MEDIUMBLOB NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (idempotency_key, method, path),
+    KEY idx_idempotency_keys_created_at (created_at)
+);
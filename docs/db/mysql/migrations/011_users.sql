@@ -0,0 +1,10 @@
+-- Users that authenticate to the API, the passwords are kept as salted PBKDF2 SHA-256 hashes
+USE fresh_products;
+
+CREATE TABLE users(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_users_username (username)
+);
@@ -0,0 +1,13 @@
+-- Roles of the users and the warehouses assigned to the warehouse operators
+-- The users created before the roles are analysts, they only read
+USE fresh_products;
+
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'analyst';
+
+CREATE TABLE user_warehouses(
+    user_id INT NOT NULL,
+    warehouse_id INT NOT NULL,
+    PRIMARY KEY (user_id, warehouse_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+-- Seller and buyer the users with the seller and buyer roles act as, they only see the records of it
+USE fresh_products;
+
+ALTER TABLE users ADD COLUMN seller_id INT NULL;
+ALTER TABLE users ADD COLUMN buyer_id INT NULL;
+ALTER TABLE users ADD FOREIGN KEY (seller_id) REFERENCES sellers(id);
+ALTER TABLE users ADD FOREIGN KEY (buyer_id) REFERENCES buyers(id);
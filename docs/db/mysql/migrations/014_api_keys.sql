@@ -0,0 +1,16 @@
+-- API keys of the machine clients, only the hash of a key is kept and the prefix finds it
+USE fresh_products;
+
+CREATE TABLE api_keys(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix CHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(1024) NOT NULL,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    UNIQUE KEY uq_api_keys_prefix (prefix),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
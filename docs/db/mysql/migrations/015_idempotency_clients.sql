@@ -0,0 +1,6 @@
+-- The idempotency keys are of the client that made the request, a user or an API key, the same key
+-- of another client is another request and its response is not replayed to it
+USE fresh_products;
+
+ALTER TABLE idempotency_keys ADD COLUMN client VARCHAR(64) NOT NULL DEFAULT '' FIRST;
+ALTER TABLE idempotency_keys DROP PRIMARY KEY, ADD PRIMARY KEY (client, idempotency_key, method, path);
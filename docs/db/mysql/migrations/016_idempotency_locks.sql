@@ -0,0 +1,7 @@
+-- A request in progress with an Idempotency-Key reserves it until locked_until, a retry made later
+-- takes the key over, as the request died with the server
+USE fresh_products;
+
+ALTER TABLE idempotency_keys ADD COLUMN locked_until DATETIME NULL;
+UPDATE idempotency_keys SET locked_until = created_at;
+ALTER TABLE idempotency_keys MODIFY locked_until DATETIME NOT NULL;
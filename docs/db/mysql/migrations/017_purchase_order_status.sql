@@ -0,0 +1,12 @@
+-- Status of the purchase orders, the existing orders are pending
+-- The details of the orders of a buyer are listed by order date
+USE fresh_products;
+
+INSERT IGNORE INTO order_status (id, description) VALUES
+(1, 'Pending'),
+(2, 'Shipped'),
+(3, 'Delivered');
+
+ALTER TABLE purchase_orders ADD COLUMN order_status_id INT NOT NULL DEFAULT 1;
+ALTER TABLE purchase_orders ADD FOREIGN KEY (order_status_id) REFERENCES order_status(id);
+CREATE INDEX idx_purchase_orders_buyer_date ON purchase_orders (buyer_id, order_date);
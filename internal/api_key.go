@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey is a credential of a machine client, it acts as its user with only the permissions of its
+// scopes. Only the hash of the key is kept, the key is given once when it is created or rotated
+type APIKey struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	// Prefix is the public part of the key, it finds the key to check
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// IssuedAPIKey is an API key with its secret, sent in the X-API-Key header of the requests
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyRepository interface {
+	GetAll(ctx context.Context) ([]APIKey, error)
+	// GetByID returns a key, utils.ErrNotFound when there is none with the id
+	GetByID(ctx context.Context, id int) (APIKey, error)
+	// GetByPrefix returns a key, utils.ErrNotFound when there is none with the prefix
+	GetByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	// Create saves a key and sets its id
+	Create(ctx context.Context, key *APIKey) error
+	// Rotate replaces the prefix and hash of a key that is not revoked
+	Rotate(ctx context.Context, id int, prefix, hash string) error
+	// Revoke marks a key as revoked, utils.ErrNotFound when there is no key with the id that isn't revoked yet
+	Revoke(ctx context.Context, id int, at time.Time) error
+	// Touch sets the last time a key was used
+	Touch(ctx context.Context, id int, at time.Time) error
+}
+
+type APIKeyService interface {
+	GetAll(ctx context.Context) ([]APIKey, error)
+	// Create issues a key of a user with scopes the role of the user has
+	Create(ctx context.Context, key APIKey) (IssuedAPIKey, error)
+	// Rotate issues a new secret for a key, the previous one stops working
+	Rotate(ctx context.Context, id int) (IssuedAPIKey, error)
+	Revoke(ctx context.Context, id int) error
+	// Authenticate returns the principal of a key that is not revoked, limited to its scopes
+	Authenticate(ctx context.Context, key string) (Principal, error)
+}
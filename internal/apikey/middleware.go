@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"net/http"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Header is the header of the requests authenticated with an API key
+const Header = "X-API-Key"
+
+// Middleware authenticates the requests with the API key of their X-API-Key header, the principal of
+// the key is put in their context. The requests without the header are served as they are, so it
+// must be mounted before the middleware of the user authentication, which serves them with a token.
+func Middleware(service internal.APIKeyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := service.Authenticate(r.Context(), key)
+			if err != nil {
+				utils.HandleError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(internal.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
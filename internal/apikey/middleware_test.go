@@ -0,0 +1,63 @@
+package apikey
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	secret, err := generate()
+	require.NoError(t, err)
+
+	repo := new(mockAPIKeyRepository)
+	users := new(mockUserRepository)
+	repo.On("GetByPrefix", secret[:prefixLength]).Return(internal.APIKey{ID: 1, UserID: 7, Hash: hash(secret), Scopes: []string{"products:read"}}, nil)
+	repo.On("GetByPrefix", "mfp_000000000000").Return(internal.APIKey{}, utils.ErrNotFound)
+	repo.On("Touch", 1, mock.AnythingOfType("time.Time")).Return(nil)
+	users.On("GetByID", 7).Return(lucia, nil)
+
+	router := chi.NewRouter()
+	router.Use(Middleware(NewDefaultAPIKeyService(repo, users, newMockUnitOfWork(repo))))
+	router.Get("/api/v1/products", func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := internal.PrincipalFromContext(r.Context())
+		if ok {
+			_, _ = w.Write([]byte(principal.Username))
+		}
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+		body   string
+	}{
+		{name: "valid key", key: secret, status: http.StatusOK, body: "lucia"},
+		{name: "without key", status: http.StatusOK},
+		{name: "invalid key", key: "mfp_000000000000_guess", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			if tc.key != "" {
+				request.Header.Set(Header, tc.key)
+			}
+
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+
+			require.Equal(t, tc.status, response.Code)
+
+			if tc.status == http.StatusOK {
+				require.Equal(t, tc.body, response.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+const selectAPIKeys = "SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at FROM api_keys"
+
+type MySQLAPIKeyRepository struct {
+	db utils.DBTX
+}
+
+// NewMySQLAPIKeyRepository creates a new MySQLAPIKeyRepository with the given database connection.
+func NewMySQLAPIKeyRepository(db utils.DBTX) *MySQLAPIKeyRepository {
+	return &MySQLAPIKeyRepository{db: db}
+}
+
+// GetAll retrieves every key ordered by id.
+func (r *MySQLAPIKeyRepository) GetAll(ctx context.Context) ([]internal.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, selectAPIKeys+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []internal.APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetByID retrieves the key with the id.
+func (r *MySQLAPIKeyRepository) GetByID(ctx context.Context, id int) (internal.APIKey, error) {
+	return r.getOne(ctx, selectAPIKeys+" WHERE id = ?", id)
+}
+
+// GetByPrefix retrieves the key with the prefix.
+func (r *MySQLAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (internal.APIKey, error) {
+	return r.getOne(ctx, selectAPIKeys+" WHERE prefix = ?", prefix)
+}
+
+// Create inserts a key into the api_keys table and sets its id.
+func (r *MySQLAPIKeyRepository) Create(ctx context.Context, key *internal.APIKey) error {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, created_at) VALUES(?, ?, ?, ?, ?, ?)",
+		key.UserID, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.CreatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+			return utils.EDependencyNotFound("user", "id: "+strconv.Itoa(key.UserID))
+		}
+
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	key.ID = int(id)
+
+	return nil
+}
+
+// Rotate replaces the prefix and hash of the key with the id that is not revoked.
+func (r *MySQLAPIKeyRepository) Rotate(ctx context.Context, id int, prefix, hash string) error {
+	return r.exec(ctx, "UPDATE api_keys SET prefix = ?, key_hash = ? WHERE id = ? AND revoked_at IS NULL", prefix, hash, id)
+}
+
+// Revoke sets when the key with the id was revoked, if it is not revoked yet.
+func (r *MySQLAPIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	return r.exec(ctx, "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", at, id)
+}
+
+// Touch sets the last time the key with the id was used.
+func (r *MySQLAPIKeyRepository) Touch(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+// exec runs an update of one key, utils.ErrNotFound when no key was updated
+func (r *MySQLAPIKeyRepository) exec(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return utils.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MySQLAPIKeyRepository) getOne(ctx context.Context, query string, args ...any) (internal.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.APIKey{}, utils.ErrNotFound
+		}
+
+		return internal.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// scanAPIKey reads a key, the scopes are kept separated by commas
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (internal.APIKey, error) {
+	var key internal.APIKey
+
+	var scopes string
+
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return internal.APIKey{}, err
+	}
+
+	key.Scopes = strings.Split(scopes, ",")
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
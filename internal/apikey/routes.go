@@ -0,0 +1,22 @@
+package apikey
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+)
+
+// RegisterAPIKeyRoutes registers the routes managing the API keys, the keys are checked by Middleware
+func RegisterAPIKeyRoutes(mux *chi.Mux, service internal.APIKeyService) error {
+	apiKeyHandler := handler.NewAPIKeyHandler(service)
+
+	mux.Route("/api/v1/apiKeys", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceAPIKeys, rbac.ActionRead))).Get("/", apiKeyHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceAPIKeys, rbac.ActionWrite))).Post("/", apiKeyHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceAPIKeys, rbac.ActionWrite))).Post("/{id}/rotate", apiKeyHandler.Rotate())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceAPIKeys, rbac.ActionDelete))).Delete("/{id}", apiKeyHandler.Revoke())
+	})
+
+	return nil
+}
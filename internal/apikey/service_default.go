@@ -0,0 +1,243 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// auditEntity is the name of the API keys in the audit trail
+const auditEntity = "apiKeys"
+
+const (
+	// keyMark starts the keys, so a leaked one is recognized as a key of the API
+	keyMark = "mfp_"
+	// prefixLength is the length of the public part of a key, the mark and 12 hex digits
+	prefixLength = len(keyMark) + 12
+	secretBytes  = 32
+	// touchInterval is how often the last use of a key is saved, so not every request writes it
+	touchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidKey is the error of a key that does not exist, is revoked or whose user was removed
+	ErrInvalidKey = utils.EUnauthorized("invalid API key")
+	// ErrRevoked is the error of rotating a revoked key
+	ErrRevoked = errors.Join(utils.ErrConflict, errors.New("the API key is revoked"))
+)
+
+type DefaultAPIKeyService struct {
+	repo  internal.APIKeyRepository
+	users internal.UserRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
+}
+
+// NewDefaultAPIKeyService creates a new DefaultAPIKeyService with the given repositories.
+func NewDefaultAPIKeyService(repo internal.APIKeyRepository, users internal.UserRepository, uow internal.UnitOfWork) *DefaultAPIKeyService {
+	return &DefaultAPIKeyService{repo: repo, users: users, uow: uow}
+}
+
+// GetAll returns every key, the revoked ones too.
+func (s *DefaultAPIKeyService) GetAll(ctx context.Context) ([]internal.APIKey, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// Create issues a key of a user, its scopes must be permissions of the role of the user.
+func (s *DefaultAPIKeyService) Create(ctx context.Context, key internal.APIKey) (internal.IssuedAPIKey, error) {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return internal.IssuedAPIKey{}, utils.EZeroValue("name")
+	}
+
+	if key.UserID <= 0 {
+		return internal.IssuedAPIKey{}, utils.EZeroValue("user_id")
+	}
+
+	if len(key.Scopes) == 0 {
+		return internal.IssuedAPIKey{}, utils.EZeroValue("scopes")
+	}
+
+	user, err := s.users.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.IssuedAPIKey{}, utils.EDependencyNotFound("user", "id: "+strconv.Itoa(key.UserID))
+		}
+
+		return internal.IssuedAPIKey{}, err
+	}
+
+	for _, scope := range key.Scopes {
+		if !rbac.Allowed(internal.RoleAdmin, scope) {
+			return internal.IssuedAPIKey{}, utils.EBR("unknown scope " + scope)
+		}
+
+		if !rbac.Allowed(user.Role, scope) {
+			return internal.IssuedAPIKey{}, utils.EBR("the role " + user.Role + " does not have the scope " + scope)
+		}
+	}
+
+	key.Scopes = slices.Clone(key.Scopes)
+	slices.Sort(key.Scopes)
+	key.Scopes = slices.Compact(key.Scopes)
+	key.CreatedAt = time.Now().UTC()
+	key.LastUsedAt, key.RevokedAt = nil, nil
+
+	secret, err := generate()
+	if err != nil {
+		return internal.IssuedAPIKey{}, err
+	}
+
+	key.Prefix, key.Hash = secret[:prefixLength], hash(secret)
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.APIKeys.Create(ctx, &key); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, key.ID, nil, key)
+	})
+	if err != nil {
+		return internal.IssuedAPIKey{}, err
+	}
+
+	return internal.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// Rotate issues a new secret for a key that is not revoked, the previous one stops working at once.
+func (s *DefaultAPIKeyService) Rotate(ctx context.Context, id int) (internal.IssuedAPIKey, error) {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return internal.IssuedAPIKey{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return internal.IssuedAPIKey{}, ErrRevoked
+	}
+
+	secret, err := generate()
+	if err != nil {
+		return internal.IssuedAPIKey{}, err
+	}
+
+	previous := key
+	key.Prefix, key.Hash = secret[:prefixLength], hash(secret)
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.APIKeys.Rotate(ctx, id, key.Prefix, key.Hash); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRotate, auditEntity, id, previous, key)
+	})
+	if err != nil {
+		return internal.IssuedAPIKey{}, err
+	}
+
+	return internal.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// Revoke stops a key from working, it is kept to be listed.
+func (s *DefaultAPIKeyService) Revoke(ctx context.Context, id int) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		key, err := repos.APIKeys.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		revoked := key
+		revokedAt := time.Now().UTC()
+		revoked.RevokedAt = &revokedAt
+
+		if err := repos.APIKeys.Revoke(ctx, id, revokedAt); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRevoke, auditEntity, id, key, revoked)
+	})
+}
+
+// Authenticate returns the principal of the user of a key that is not revoked, with the scopes of
+// the key. The last use of the key is saved at most once every touchInterval.
+func (s *DefaultAPIKeyService) Authenticate(ctx context.Context, secret string) (internal.Principal, error) {
+	if len(secret) <= prefixLength || !strings.HasPrefix(secret, keyMark) {
+		return internal.Principal{}, ErrInvalidKey
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, secret[:prefixLength])
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Principal{}, ErrInvalidKey
+		}
+
+		return internal.Principal{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(key.Hash)) != 1 || key.RevokedAt != nil {
+		return internal.Principal{}, ErrInvalidKey
+	}
+
+	user, err := s.users.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Principal{}, ErrInvalidKey
+		}
+
+		return internal.Principal{}, err
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		// a request is not failed because its use could not be saved
+		if err := s.repo.Touch(ctx, key.ID, now); err != nil {
+			log.Printf("error saving the last use of the API key %s: %s", key.Prefix, err.Error())
+		}
+	}
+
+	return internal.Principal{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		WarehouseIDs: user.WarehouseIDs,
+		SellerID:     user.SellerID,
+		BuyerID:      user.BuyerID,
+		Scopes:       append([]string{}, key.Scopes...),
+		APIKeyID:     key.ID,
+	}, nil
+}
+
+// generate returns a new key, the mark and a random prefix followed by the random secret
+func generate() (string, error) {
+	prefix := make([]byte, (prefixLength-len(keyMark))/2)
+	secret := make([]byte, secretBytes)
+
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return keyMark + hex.EncodeToString(prefix) + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hash returns the hash of a key, the keys are random so a fast hash is enough
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
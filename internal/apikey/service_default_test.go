@@ -0,0 +1,280 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.APIKeyRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{APIKeys: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type mockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyRepository) GetAll(ctx context.Context) ([]internal.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) GetByID(ctx context.Context, id int) (internal.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (internal.APIKey, error) {
+	args := m.Called(prefix)
+	return args.Get(0).(internal.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepository) Create(ctx context.Context, key *internal.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepository) Rotate(ctx context.Context, id int, prefix, hash string) error {
+	args := m.Called(id, prefix, hash)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepository) Touch(ctx context.Context, id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+type mockUserRepository struct {
+	mock.Mock
+}
+
+func (m *mockUserRepository) GetByID(ctx context.Context, id int) (internal.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetByUsername(ctx context.Context, username string) (internal.User, error) {
+	args := m.Called(username)
+	return args.Get(0).(internal.User), args.Error(1)
+}
+
+func (m *mockUserRepository) Create(ctx context.Context, user *internal.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+var lucia = internal.User{ID: 7, Username: "lucia", Role: internal.RoleSeller, SellerID: 2}
+
+func TestUnitAPIKey_Create(t *testing.T) {
+	t.Run("issues a key with the hash saved", func(t *testing.T) {
+		repo := new(mockAPIKeyRepository)
+		users := new(mockUserRepository)
+		users.On("GetByID", 7).Return(lucia, nil)
+		repo.On("Create", mock.AnythingOfType("*internal.APIKey")).Run(func(args mock.Arguments) {
+			args.Get(0).(*internal.APIKey).ID = 1
+		}).Return(nil)
+		uow := newMockUnitOfWork(repo)
+
+		key, err := NewDefaultAPIKeyService(repo, users, uow).Create(context.Background(), internal.APIKey{
+			UserID: 7,
+			Name:   " erp ",
+			Scopes: []string{"products:write", "products:read", "products:write"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, key.ID)
+		require.Equal(t, "erp", key.Name)
+		require.Equal(t, []string{"products:read", "products:write"}, key.Scopes)
+		require.Equal(t, key.Key[:prefixLength], key.Prefix)
+		require.Equal(t, hash(key.Key), key.Hash)
+		require.NotContains(t, key.Hash, key.Key)
+
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, internal.AuditCreate, uow.entries[0].Action)
+		require.Equal(t, auditEntity, uow.entries[0].Entity)
+		require.Equal(t, 1, uow.entries[0].EntityID)
+		require.NotContains(t, uow.entries[0].Changes, "key")
+	})
+
+	tests := []struct {
+		name   string
+		key    internal.APIKey
+		user   internal.User
+		err    error
+		target error
+	}{
+		{name: "without name", key: internal.APIKey{UserID: 7, Scopes: []string{"products:read"}}, target: utils.ErrInvalidArguments},
+		{name: "without scopes", key: internal.APIKey{UserID: 7, Name: "erp"}, target: utils.ErrInvalidArguments},
+		{name: "unknown user", key: internal.APIKey{UserID: 7, Name: "erp", Scopes: []string{"products:read"}}, err: utils.ErrNotFound, target: utils.ErrInvalidArguments},
+		{name: "unknown scope", key: internal.APIKey{UserID: 7, Name: "erp", Scopes: []string{"products:sell"}}, user: lucia, target: utils.ErrInvalidArguments},
+		{name: "scope the role lacks", key: internal.APIKey{UserID: 7, Name: "erp", Scopes: []string{"sections:read"}}, user: lucia, target: utils.ErrInvalidArguments},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockAPIKeyRepository)
+			users := new(mockUserRepository)
+			users.On("GetByID", 7).Return(tc.user, tc.err)
+
+			_, err := NewDefaultAPIKeyService(repo, users, newMockUnitOfWork(repo)).Create(context.Background(), tc.key)
+			require.ErrorIs(t, err, tc.target)
+			repo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestUnitAPIKey_Rotate(t *testing.T) {
+	t.Run("issues a new secret", func(t *testing.T) {
+		repo := new(mockAPIKeyRepository)
+		repo.On("GetByID", 1).Return(internal.APIKey{ID: 1, Prefix: "mfp_000000000000", Hash: "old"}, nil)
+		repo.On("Rotate", 1, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+		uow := newMockUnitOfWork(repo)
+
+		key, err := NewDefaultAPIKeyService(repo, nil, uow).Rotate(context.Background(), 1)
+		require.NoError(t, err)
+		require.NotEqual(t, "mfp_000000000000", key.Prefix)
+		repo.AssertCalled(t, "Rotate", 1, key.Prefix, hash(key.Key))
+
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, internal.AuditRotate, uow.entries[0].Action)
+		require.Contains(t, uow.entries[0].Changes, "prefix")
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revokedAt := time.Now()
+
+		repo := new(mockAPIKeyRepository)
+		repo.On("GetByID", 1).Return(internal.APIKey{ID: 1, RevokedAt: &revokedAt}, nil)
+
+		_, err := NewDefaultAPIKeyService(repo, nil, newMockUnitOfWork(repo)).Rotate(context.Background(), 1)
+		require.ErrorIs(t, err, utils.ErrConflict)
+	})
+}
+
+func TestUnitAPIKey_Revoke(t *testing.T) {
+	t.Run("revokes the key", func(t *testing.T) {
+		repo := new(mockAPIKeyRepository)
+		repo.On("GetByID", 1).Return(internal.APIKey{ID: 1, Prefix: "mfp_000000000000"}, nil)
+		repo.On("Revoke", 1, mock.AnythingOfType("time.Time")).Return(nil)
+		uow := newMockUnitOfWork(repo)
+
+		err := NewDefaultAPIKeyService(repo, nil, uow).Revoke(context.Background(), 1)
+		require.NoError(t, err)
+
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, internal.AuditRevoke, uow.entries[0].Action)
+		require.Equal(t, 1, uow.entries[0].EntityID)
+		require.Contains(t, uow.entries[0].Changes, "revoked_at")
+	})
+
+	t.Run("already revoked key", func(t *testing.T) {
+		repo := new(mockAPIKeyRepository)
+		repo.On("GetByID", 1).Return(internal.APIKey{ID: 1}, nil)
+		repo.On("Revoke", 1, mock.AnythingOfType("time.Time")).Return(utils.ErrNotFound)
+		uow := newMockUnitOfWork(repo)
+
+		err := NewDefaultAPIKeyService(repo, nil, uow).Revoke(context.Background(), 1)
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		require.Empty(t, uow.entries)
+	})
+}
+
+func TestUnitAPIKey_Authenticate(t *testing.T) {
+	secret, err := generate()
+	require.NoError(t, err)
+
+	recently := time.Now().UTC().Add(-time.Second)
+	revokedAt := time.Now().UTC()
+	key := internal.APIKey{ID: 1, UserID: 7, Prefix: secret[:prefixLength], Hash: hash(secret), Scopes: []string{"products:read"}}
+
+	t.Run("principal of the user with the scopes of the key", func(t *testing.T) {
+		repo := new(mockAPIKeyRepository)
+		users := new(mockUserRepository)
+		repo.On("GetByPrefix", key.Prefix).Return(key, nil)
+		repo.On("Touch", 1, mock.AnythingOfType("time.Time")).Return(errors.New("db down"))
+		users.On("GetByID", 7).Return(lucia, nil)
+
+		principal, err := NewDefaultAPIKeyService(repo, users, newMockUnitOfWork(repo)).Authenticate(context.Background(), secret)
+		require.NoError(t, err)
+		require.Equal(t, internal.Principal{UserID: 7, Username: "lucia", Role: internal.RoleSeller, SellerID: 2, Scopes: []string{"products:read"}, APIKeyID: 1}, principal)
+		repo.AssertNumberOfCalls(t, "Touch", 1)
+	})
+
+	t.Run("used recently", func(t *testing.T) {
+		used := key
+		used.LastUsedAt = &recently
+
+		repo := new(mockAPIKeyRepository)
+		users := new(mockUserRepository)
+		repo.On("GetByPrefix", key.Prefix).Return(used, nil)
+		users.On("GetByID", 7).Return(lucia, nil)
+
+		_, err := NewDefaultAPIKeyService(repo, users, newMockUnitOfWork(repo)).Authenticate(context.Background(), secret)
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+	})
+
+	revoked := key
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name   string
+		secret string
+		key    internal.APIKey
+		err    error
+	}{
+		{name: "not a key", secret: "token"},
+		{name: "unknown prefix", secret: secret, err: utils.ErrNotFound},
+		{name: "wrong secret", secret: secret[:prefixLength] + "_guess", key: key},
+		{name: "revoked", secret: secret, key: revoked},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockAPIKeyRepository)
+			repo.On("GetByPrefix", key.Prefix).Return(tc.key, tc.err)
+
+			_, err := NewDefaultAPIKeyService(repo, nil, newMockUnitOfWork(repo)).Authenticate(context.Background(), tc.secret)
+			require.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+}
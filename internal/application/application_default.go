@@ -1,24 +1,38 @@
 package application
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/apikey"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/auth"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/buyer"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/carry"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/country"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/employee"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/idempotency"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/inbound_order"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/locality"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/packaging_unit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/product"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_batch"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_record"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_type"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/province"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/purchase_order"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/ratelimit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/section"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/seller"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/unit_of_work"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/warehouse"
 
 	"github.com/go-chi/chi/v5"
@@ -27,20 +41,57 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// placeholderAuthSecret is the AUTH.SECRET the example .env had, the server does not start with it
+const placeholderAuthSecret = "change-me-to-a-secret-of-32-bytes-or-more"
+
 // ConfigApplicationDefault is the configuration for NewApplicationDefault.
 type ConfigApplicationDefault struct {
 	// DB is the database configuration.
 	DB *mysql.Config
 	// Addr is the server address.
 	Addr string
+	// PurgeRetention is how long the soft deleted records are kept before being purged.
+	PurgeRetention time.Duration
+	// PurgeInterval is the time between two purges of the soft deleted records.
+	PurgeInterval time.Duration
+	// IdempotencyWindow is how long the responses of the requests made with an Idempotency-Key are replayed.
+	IdempotencyWindow time.Duration
+	// RequestTimeout is the deadline of each request, its queries are canceled once it passes.
+	RequestTimeout time.Duration
+	// AuthAlgorithm is the algorithm the tokens are signed with, HS256 or RS256.
+	AuthAlgorithm string
+	// AuthSecret is the secret of the HS256 tokens, of 32 bytes at least.
+	AuthSecret string
+	// AuthPrivateKeyFile is the PEM file of the RSA private key of the RS256 tokens.
+	AuthPrivateKeyFile string
+	// AccessTokenTTL is how long an access token authenticates the requests.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token issues new tokens.
+	RefreshTokenTTL time.Duration
+	// RateLimit is the limit of the requests of each API key or IP to the routes of no other group.
+	RateLimit internal.RateLimit
+	// AuthRateLimit is the limit of the requests of each IP to the routes issuing the tokens.
+	AuthRateLimit internal.RateLimit
+	// ImportRateLimit is the limit of the requests of each API key or IP to the import routes.
+	ImportRateLimit internal.RateLimit
 }
 
 // NewApplicationDefault creates a new ApplicationDefault.
 func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault {
 	// default values
 	defaultCfg := &ConfigApplicationDefault{
-		DB:   nil,
-		Addr: ":8080",
+		DB:                nil,
+		Addr:              ":8080",
+		PurgeRetention:    30 * 24 * time.Hour,
+		PurgeInterval:     24 * time.Hour,
+		IdempotencyWindow: 24 * time.Hour,
+		RequestTimeout:    30 * time.Second,
+		AuthAlgorithm:     auth.HS256,
+		AccessTokenTTL:    15 * time.Minute,
+		RefreshTokenTTL:   7 * 24 * time.Hour,
+		RateLimit:         internal.RateLimit{Requests: 120, Period: time.Minute},
+		AuthRateLimit:     internal.RateLimit{Requests: 10, Period: time.Minute},
+		ImportRateLimit:   internal.RateLimit{Requests: 5, Period: time.Minute},
 	}
 
 	if config != nil {
@@ -51,11 +102,66 @@ func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault
 		if config.Addr != "" {
 			defaultCfg.Addr = config.Addr
 		}
+
+		if config.PurgeRetention > 0 {
+			defaultCfg.PurgeRetention = config.PurgeRetention
+		}
+
+		if config.PurgeInterval > 0 {
+			defaultCfg.PurgeInterval = config.PurgeInterval
+		}
+
+		if config.IdempotencyWindow > 0 {
+			defaultCfg.IdempotencyWindow = config.IdempotencyWindow
+		}
+
+		if config.RequestTimeout > 0 {
+			defaultCfg.RequestTimeout = config.RequestTimeout
+		}
+
+		if config.AuthAlgorithm != "" {
+			defaultCfg.AuthAlgorithm = config.AuthAlgorithm
+		}
+
+		defaultCfg.AuthSecret = config.AuthSecret
+		defaultCfg.AuthPrivateKeyFile = config.AuthPrivateKeyFile
+
+		if config.AccessTokenTTL > 0 {
+			defaultCfg.AccessTokenTTL = config.AccessTokenTTL
+		}
+
+		if config.RefreshTokenTTL > 0 {
+			defaultCfg.RefreshTokenTTL = config.RefreshTokenTTL
+		}
+
+		if config.RateLimit.Requests > 0 && config.RateLimit.Period > 0 {
+			defaultCfg.RateLimit = config.RateLimit
+		}
+
+		if config.AuthRateLimit.Requests > 0 && config.AuthRateLimit.Period > 0 {
+			defaultCfg.AuthRateLimit = config.AuthRateLimit
+		}
+
+		if config.ImportRateLimit.Requests > 0 && config.ImportRateLimit.Period > 0 {
+			defaultCfg.ImportRateLimit = config.ImportRateLimit
+		}
 	}
 
 	return &ApplicationDefault{
-		cfgDB:   defaultCfg.DB,
-		cfgAddr: defaultCfg.Addr,
+		cfgDB:                defaultCfg.DB,
+		cfgAddr:              defaultCfg.Addr,
+		cfgPurgeRetention:    defaultCfg.PurgeRetention,
+		cfgPurgeInterval:     defaultCfg.PurgeInterval,
+		cfgIdempotencyWindow: defaultCfg.IdempotencyWindow,
+		cfgRequestTimeout:    defaultCfg.RequestTimeout,
+		cfgAuthAlgorithm:     defaultCfg.AuthAlgorithm,
+		cfgAuthSecret:        defaultCfg.AuthSecret,
+		cfgAuthPrivateKey:    defaultCfg.AuthPrivateKeyFile,
+		cfgAccessTokenTTL:    defaultCfg.AccessTokenTTL,
+		cfgRefreshTokenTTL:   defaultCfg.RefreshTokenTTL,
+		cfgRateLimit:         defaultCfg.RateLimit,
+		cfgAuthRateLimit:     defaultCfg.AuthRateLimit,
+		cfgImportRateLimit:   defaultCfg.ImportRateLimit,
 	}
 }
 
@@ -65,14 +171,45 @@ type ApplicationDefault struct {
 	cfgDB *mysql.Config
 	// cfgAddr is the server address.
 	cfgAddr string
+	// cfgPurgeRetention is how long the soft deleted records are kept.
+	cfgPurgeRetention time.Duration
+	// cfgPurgeInterval is the time between two purges.
+	cfgPurgeInterval time.Duration
+	// cfgIdempotencyWindow is how long the idempotency keys are kept.
+	cfgIdempotencyWindow time.Duration
+	// cfgRequestTimeout is the deadline of each request.
+	cfgRequestTimeout time.Duration
+	// cfgAuthAlgorithm is the algorithm the tokens are signed with.
+	cfgAuthAlgorithm string
+	// cfgAuthSecret is the secret of the HS256 tokens.
+	cfgAuthSecret string
+	// cfgAuthPrivateKey is the PEM file of the RSA private key of the RS256 tokens.
+	cfgAuthPrivateKey string
+	// cfgAccessTokenTTL is how long an access token is valid.
+	cfgAccessTokenTTL time.Duration
+	// cfgRefreshTokenTTL is how long a refresh token is valid.
+	cfgRefreshTokenTTL time.Duration
+	// cfgRateLimit is the limit of the requests to the routes of no other group.
+	cfgRateLimit internal.RateLimit
+	// cfgAuthRateLimit is the limit of the requests to the routes issuing the tokens.
+	cfgAuthRateLimit internal.RateLimit
+	// cfgImportRateLimit is the limit of the requests to the import routes.
+	cfgImportRateLimit internal.RateLimit
 	// db is the database connection.
 	db *sql.DB
 	// router is the chi router.
 	router *chi.Mux
+	// purgeJob purges the soft deleted records.
+	purgeJob *PurgeJob
 }
 
 // TearDown tears down the application.
 func (a *ApplicationDefault) TearDown() {
+	// stop the purge of the deleted records
+	if a.purgeJob != nil {
+		a.purgeJob.Stop()
+	}
+
 	// close db
 	if a.db != nil {
 		a.db.Close()
@@ -94,6 +231,63 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	}
 
 	router := chi.NewRouter()
+
+	// Deadline of the requests, mounted first so every query of a request runs under it
+	router.Use(utils.Timeout(a.cfgRequestTimeout))
+
+	// Authentication of the requests with the tokens issued to the users, only the routes issuing the
+	// tokens and the docs are public
+	tokens, err := a.tokens()
+	if err != nil {
+		return err
+	}
+
+	// The changes run in a single transaction along with their audit entries
+	unitOfWork := unit_of_work.NewMySQLUnitOfWork(a.db)
+
+	userRepo := auth.NewMySQLUserRepository(a.db)
+	authService := auth.NewDefaultAuthService(userRepo, tokens, a.cfgAccessTokenTTL, a.cfgRefreshTokenTTL, unitOfWork)
+
+	// Failed authentications of each IP, with the limit of the routes issuing the tokens. Mounted
+	// before the API keys so the keys can not be guessed
+	rateLimitStore := ratelimit.NewMemoryStore()
+	router.Use(ratelimit.Failures(rateLimitStore, ratelimit.Group{Name: "unauthorized", Limit: a.cfgAuthRateLimit}, http.StatusUnauthorized))
+
+	// API keys of the machine clients, a request with one is not asked for a token
+	apiKeyService := apikey.NewDefaultAPIKeyService(apikey.NewMySQLAPIKeyRepository(a.db), userRepo, unitOfWork)
+	router.Use(apikey.Middleware(apiKeyService))
+
+	// Rate limit of each API key, or IP for the other requests, mounted before the user authentication
+	// so the requests issuing the tokens are limited too
+	router.Use(ratelimit.Middleware(rateLimitStore,
+		ratelimit.Group{Name: "auth", Paths: []string{"/api/v1/auth/*"}, Limit: a.cfgAuthRateLimit},
+		ratelimit.Group{Name: "import", Paths: []string{"/api/v1/*/import"}, Limit: a.cfgImportRateLimit},
+		ratelimit.Group{Name: "default", Limit: a.cfgRateLimit},
+	))
+	router.Use(auth.Middleware(authService, "/api/v1/auth/token", "/api/v1/auth/refresh", "/swagger/*"))
+
+	// Idempotency keys of the POST requests retried by the integrations. A key is reserved for twice
+	// the deadline of its request, a request past its deadline can still be writing its response
+	idempotencyRepo := idempotency.NewMySQLIdempotencyRepository(a.db)
+	idempotencyService := idempotency.NewDefaultIdempotencyService(idempotencyRepo, a.cfgIdempotencyWindow, 2*a.cfgRequestTimeout)
+	router.Use(idempotency.Middleware(idempotencyService, "/api/v1/purchaseOrders", "/api/v1/inboundOrders", "/api/v1/productBatches"))
+
+	// Audit trail, the services record their changes in the transaction of the change
+	auditRepo := audit.NewMySQLAuditRepository(a.db)
+	auditService := audit.NewDefaultAuditService(auditRepo)
+
+	if err := audit.RegisterAuditRoutes(router, auditService); err != nil {
+		panic(err)
+	}
+
+	if err := auth.RegisterAuthRoutes(router, authService); err != nil {
+		panic(err)
+	}
+
+	if err := apikey.RegisterAPIKeyRoutes(router, apiKeyService); err != nil {
+		panic(err)
+	}
+
 	router.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"), //The url pointing to API definition"
 	))
@@ -101,13 +295,23 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	localityRepo := locality.NewMysqlLocalityRepository(a.db)
 	provinceRepo := province.NewMysqlProvinceRepository(a.db)
 	countryRepo := country.NewMysqlCountryRepository(a.db)
-	localityService := locality.NewBasicLocalityService(localityRepo, provinceRepo, countryRepo)
+	localityService := locality.NewBasicLocalityService(localityRepo, provinceRepo, countryRepo, unitOfWork)
 	err = locality.NewLocalityRoutes(router, localityService)
 
 	if err != nil {
 		panic(err)
 	}
 
+	provinceService := province.NewBasicProvinceService(provinceRepo, countryRepo, localityRepo, unitOfWork)
+	if err := province.NewProvinceRoutes(router, provinceService); err != nil {
+		panic(err)
+	}
+
+	countryService := country.NewBasicCountryService(countryRepo, provinceRepo, localityRepo, unitOfWork)
+	if err := country.NewCountryRoutes(router, countryService); err != nil {
+		panic(err)
+	}
+
 	// Requisito 1 - Seller
 	// ldSellers := internal.NewSellerJSONFile("./internal/sellers.json")
 	// dbSellers, err := ldSellers.Load()
@@ -116,7 +320,7 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// }
 	// sellerRepo := repository.NewSellerDBRepository(dbSellers)
 	sellerRepo := seller.NewSellerRepository(a.db)
-	sellerService := seller.NewSellerService(sellerRepo, localityRepo)
+	sellerService := seller.NewSellerService(sellerRepo, localityRepo, unitOfWork)
 
 	if err := seller.RegisterSellerRoutes(router, sellerService); err != nil {
 		panic(err)
@@ -125,23 +329,32 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// Requisito 4 - ProductType
 	productTypeRepo := product_type.NewProductTypeDB(a.db)
 
-	productTypeService := product_type.NewProductTypeService(productTypeRepo)
+	productTypeService := product_type.NewProductTypeService(productTypeRepo, unitOfWork)
 	if err := product_type.NewProductTypeRoutes(router, productTypeService); err != nil {
 		panic(err)
 	}
 
 	// Requisito 4 - Product
 	productRepo := product.NewProductDB(a.db)
-	productService := product.NewProductService(productRepo, productTypeService, sellerService)
+	productService := product.NewProductService(productRepo, productTypeService, sellerService, unitOfWork)
 	err = product.NewProductRoutes(router, productService)
 
 	if err != nil {
 		panic(err)
 	}
 
+	// Product packaging units
+	packagingUnitRepo := packaging_unit.NewPackagingUnitDB(a.db)
+	packagingUnitService := packaging_unit.NewPackagingUnitService(packagingUnitRepo, productService, unitOfWork)
+
+	err = packaging_unit.NewPackagingUnitRoutes(router, packagingUnitService)
+	if err != nil {
+		panic(err)
+	}
+
 	//Requisito 4 - Product Records
 	productRecordsRepo := product_record.NewProductRecordDB(a.db)
-	productRecordsService := product_record.NewProductRecordService(productRecordsRepo, productService)
+	productRecordsService := product_record.NewProductRecordService(productRecordsRepo, productService, unitOfWork)
 
 	err = product_record.NewProductRecordsRoutes(router, productRecordsService)
 	if err != nil {
@@ -150,7 +363,7 @@ func (a *ApplicationDefault) SetUp() (err error) {
 
 	// Requisito 2 - Warehouses
 	warehouseRepo := warehouse.NewWarehouseDB(a.db)
-	warehouseService := warehouse.NewWarehouseService(warehouseRepo, localityRepo)
+	warehouseService := warehouse.NewWarehouseService(warehouseRepo, localityRepo, productRepo, unitOfWork)
 
 	err = warehouse.NewWarehouseRoutes(router, warehouseService)
 	if err != nil {
@@ -160,7 +373,7 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// Requisito 3 - Section
 
 	sectionRepo := section.NewSectionMysql(a.db)
-	sectionService := section.NewBasicSectionService(sectionRepo, warehouseService, productTypeService)
+	sectionService := section.NewBasicSectionService(sectionRepo, warehouseService, productTypeService, productService, unitOfWork)
 
 	err = section.RegisterSectionRoutes(router, sectionService)
 	if err != nil {
@@ -170,14 +383,14 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// Requisito 5 - Employees
 	employeesRepo := employee.NewEmployeeRepository(a.db)
 
-	employeesService := employee.NewEmployeeService(employeesRepo, warehouseService)
+	employeesService := employee.NewEmployeeService(employeesRepo, warehouseService, unitOfWork)
 	if err := employee.RegisterEmployeesRoutes(router, employeesService); err != nil {
 		panic(err)
 	}
 
 	// Requisito 6 - Buyers
 	buyersRepo := buyer.NewBuyerDB(a.db)
-	buyersService := buyer.NewBuyer(buyersRepo)
+	buyersService := buyer.NewBuyer(buyersRepo, unitOfWork)
 	// CreateInboundOrder the routes and deps
 	if err = buyer.BuyerRoutes(router, buyersService); err != nil {
 		panic(err)
@@ -185,7 +398,7 @@ func (a *ApplicationDefault) SetUp() (err error) {
 
 	// Requisito 6 - Purchase Orders
 	purchaseOrdersRepo := purchase_order.NewPurchaseOrderDB(a.db)
-	purchaseOrdersService := purchase_order.NewPurchaseOrderService(purchaseOrdersRepo, buyersService, productRecordsRepo)
+	purchaseOrdersService := purchase_order.NewPurchaseOrderService(purchaseOrdersRepo, buyersService, productRecordsRepo, unitOfWork)
 
 	err = purchase_order.RegisterPurchaseOrdersRoutes(router, purchaseOrdersService)
 	if err != nil {
@@ -196,36 +409,80 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	carriesRepo := carry.NewMySQLCarryRepository(a.db)
 
 	//carryService := carry.NewMySQLCarryService(carriesRepo, localityRepo)
-	carryService := carry.NewMySQLCarryService(carriesRepo, localityRepo)
+	carryService := carry.NewMySQLCarryService(carriesRepo, localityRepo, unitOfWork)
 	if err = carry.CarryRoutes(router, carryService); err != nil {
 		panic(err)
 	}
 
 	// Sprint2 Requisito 3 - Product Batch
 	productBatchRepo := product_batch.NewProductBatchRepository(a.db)
-	productBatchService := product_batch.NewProductBatchService(productBatchRepo, productRepo, sectionRepo)
+	productBatchService := product_batch.NewProductBatchService(productBatchRepo, productRepo, sectionRepo, packagingUnitService, productTypeService, unitOfWork)
 
 	if err = product_batch.ProductBatchRoutes(router, productBatchService); err != nil {
 		panic(err)
 	}
 
 	inboundOrderRepo := inbound_order.NewMySqlInboundOrderRepository(a.db)
-	inboundOrderService := inbound_order.NewInboundOrderService(inboundOrderRepo)
+	inboundOrderService := inbound_order.NewInboundOrderService(inboundOrderRepo, unitOfWork)
 
 	if err := inbound_order.RegisterInboundOrderRoutes(router, inboundOrderService); err != nil {
 		panic(err)
 	}
 
+	// Soft deleted records past their retention
+	a.purgeJob = NewPurgeJob(map[string]PurgeFunc{
+		"sellers":    sellerService.Purge,
+		"warehouses": warehouseService.Purge,
+		"products":   productService.PurgeProducts,
+		"buyers":     buyersService.PurgeBuyers,
+		"carriers":   carryService.Purge,
+		"employees":  employeesService.PurgeEmployees,
+		// the idempotency keys expire after their own window, not the retention
+		"idempotency keys": func(ctx context.Context, _ time.Time) (int, error) {
+			return idempotencyService.Expire(ctx, time.Now())
+		},
+	}, a.cfgPurgeRetention, a.cfgPurgeInterval)
+
 	a.router = router
 
 	return nil
 }
 
+// tokens returns the JWT of the configured algorithm, with the secret or the RSA private key of its file
+func (a *ApplicationDefault) tokens() (*auth.JWT, error) {
+	switch a.cfgAuthAlgorithm {
+	case auth.HS256:
+		// the secret of the example .env is public, anyone could sign the tokens with it
+		if a.cfgAuthSecret == "" || a.cfgAuthSecret == placeholderAuthSecret {
+			return nil, errors.New("AUTH.SECRET is not set, generate a secret of 32 bytes at least for the HS256 tokens")
+		}
+
+		return auth.NewHS256JWT([]byte(a.cfgAuthSecret))
+	case auth.RS256:
+		content, err := os.ReadFile(a.cfgAuthPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("reading the RSA private key: %w", err)
+		}
+
+		key, err := auth.ParseRSAPrivateKey(content)
+		if err != nil {
+			return nil, err
+		}
+
+		return auth.NewRS256JWT(key), nil
+	}
+
+	return nil, fmt.Errorf("unknown JWT algorithm %s, it must be HS256 or RS256", a.cfgAuthAlgorithm)
+}
+
 // Run runs the application.
 func (a *ApplicationDefault) Run() (err error) {
 	defer a.db.Close()
 	log.Printf("starting server at %s\n", a.cfgAddr)
 
+	a.purgeJob.Start()
+	defer a.purgeJob.Stop()
+
 	err = http.ListenAndServe(a.cfgAddr, a.router)
 
 	return
@@ -0,0 +1,35 @@
+package application
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationDefault_Tokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "secret set", secret: strings.Repeat("s", 32)},
+		{name: "empty secret", secret: "", wantErr: true},
+		{name: "secret of the example .env", secret: placeholderAuthSecret, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApplicationDefault(&ConfigApplicationDefault{AuthAlgorithm: auth.HS256, AuthSecret: tt.secret})
+
+			_, err := app.tokens()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
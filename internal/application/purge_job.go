@@ -0,0 +1,100 @@
+package application
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PurgeFunc removes for good the records of an entity soft deleted before a time, returns how many were removed
+type PurgeFunc func(ctx context.Context, before time.Time) (purged int, err error)
+
+// PurgeJob removes for good, every interval, the soft deleted records kept for longer than the retention.
+type PurgeJob struct {
+	// purges are the purge functions by the name of their entity.
+	purges map[string]PurgeFunc
+	// retention is how long the soft deleted records are kept.
+	retention time.Duration
+	// interval is the time between two runs.
+	interval time.Duration
+	// ctx is the context of the runs, canceled to stop the job.
+	ctx context.Context
+	// cancel cancels ctx.
+	cancel context.CancelFunc
+	// wg waits for the job to stop.
+	wg sync.WaitGroup
+}
+
+// NewPurgeJob creates a new PurgeJob.
+func NewPurgeJob(purges map[string]PurgeFunc, retention, interval time.Duration) *PurgeJob {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PurgeJob{
+		purges:    purges,
+		retention: retention,
+		interval:  interval,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start runs the job in background, the first run happens at once.
+func (j *PurgeJob) Start() {
+	j.wg.Add(1)
+
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.Run(j.ctx, time.Now())
+
+			select {
+			case <-ticker.C:
+			case <-j.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the job and waits for the run in progress to end, the purges it is running are canceled.
+// It can be called more than once.
+func (j *PurgeJob) Stop() {
+	j.cancel()
+	j.wg.Wait()
+}
+
+// Run purges, once, the records deleted before now minus the retention, an entity failing to
+// be purged doesn't stop the others. It returns how many records of each entity were removed.
+func (j *PurgeJob) Run(ctx context.Context, now time.Time) map[string]int {
+	before := now.Add(-j.retention)
+	purged := map[string]int{}
+
+	names := make([]string, 0, len(j.purges))
+	for name := range j.purges {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		count, err := j.purges[name](ctx, before)
+		if err != nil {
+			log.Printf("error purging deleted %s: %s", name, err.Error())
+			continue
+		}
+
+		if count > 0 {
+			log.Printf("purged %d deleted %s", count, name)
+		}
+
+		purged[name] = count
+	}
+
+	return purged
+}
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeJob_Run(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	retention := 30 * 24 * time.Hour
+
+	var sellersBefore time.Time
+
+	job := NewPurgeJob(map[string]PurgeFunc{
+		"sellers": func(_ context.Context, before time.Time) (int, error) {
+			sellersBefore = before
+			return 2, nil
+		},
+		"buyers": func(_ context.Context, before time.Time) (int, error) {
+			return 0, errors.New("connection lost")
+		},
+		"carriers": func(_ context.Context, before time.Time) (int, error) {
+			return 0, nil
+		},
+	}, retention, time.Hour)
+
+	purged := job.Run(context.Background(), now)
+
+	require.Equal(t, map[string]int{"sellers": 2, "carriers": 0}, purged)
+	require.Equal(t, now.Add(-retention), sellersBefore)
+}
+
+func TestPurgeJob_StartStop(t *testing.T) {
+	runs := make(chan time.Time, 1)
+
+	job := NewPurgeJob(map[string]PurgeFunc{
+		"sellers": func(_ context.Context, before time.Time) (int, error) {
+			select {
+			case runs <- before:
+			default:
+			}
+
+			return 0, nil
+		},
+	}, time.Hour, time.Hour)
+
+	job.Start()
+
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("the job did not run when started")
+	}
+
+	job.Stop()
+	job.Stop()
+}
+
+func TestPurgeJob_StopCancelsRun(t *testing.T) {
+	started := make(chan struct{})
+
+	job := NewPurgeJob(map[string]PurgeFunc{
+		"sellers": func(ctx context.Context, before time.Time) (int, error) {
+			close(started)
+			<-ctx.Done()
+
+			return 0, ctx.Err()
+		},
+	}, time.Hour, time.Hour)
+
+	job.Start()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		job.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the job did not cancel the purge in progress when stopped")
+	}
+}
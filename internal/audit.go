@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Actions of the audit entries
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditImport  = "import"
+	AuditPurge   = "purge"
+	AuditRotate  = "rotate"
+	AuditRevoke  = "revoke"
+)
+
+// AuditChange is the value of a field before and after a change, null when the field didn't exist
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditEntry is a change made to an entity, who made it and when. Changes holds only the fields that changed
+type AuditEntry struct {
+	ID        int                    `json:"id"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	Entity    string                 `json:"entity"`
+	EntityID  int                    `json:"entity_id"`
+	Changes   map[string]AuditChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditPurged is the entity of a purge entry, how many entities deleted before a time were removed for good
+type AuditPurged struct {
+	Purged        int       `json:"purged"`
+	DeletedBefore time.Time `json:"deleted_before"`
+}
+
+type AuditRepository interface {
+	Save(ctx context.Context, entry *AuditEntry) error
+	// List returns the entries of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) ([]AuditEntry, error)
+}
+
+type AuditService interface {
+	// List returns a page of entries filtered by entity, entity_id, actor, action and created_at
+	List(ctx context.Context, query utils.ListQuery) ([]AuditEntry, utils.Pagination, error)
+}
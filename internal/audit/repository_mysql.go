@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// listFields are the fields the audit entries can be filtered and sorted by, created_at can only
+// be filtered, the entries are in the order they were made by id
+var listFields = utils.ListFields{
+	"id":         {Column: "id", Kind: utils.ListInt, Sortable: true},
+	"actor":      {Column: "actor", Kind: utils.ListString, Sortable: true},
+	"action":     {Column: "action", Kind: utils.ListString, Sortable: true},
+	"entity":     {Column: "entity", Kind: utils.ListString, Sortable: true},
+	"entity_id":  {Column: "entity_id", Kind: utils.ListInt, Sortable: true},
+	"created_at": {Column: "created_at", Kind: utils.ListTime},
+}
+
+type MySQLAuditRepository struct {
+	db utils.DBTX
+}
+
+// NewMySQLAuditRepository creates a new MySQLAuditRepository with the given database connection.
+func NewMySQLAuditRepository(db utils.DBTX) *MySQLAuditRepository {
+	return &MySQLAuditRepository{db: db}
+}
+
+// Save inserts an entry into the audit_log table and sets its ID.
+func (r *MySQLAuditRepository) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, "INSERT INTO audit_log(actor, action, entity, entity_id, changes, created_at) VALUES(?, ?, ?, ?, ?, ?)",
+		entry.Actor, entry.Action, entry.Entity, entry.EntityID, changes, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = int(id)
+
+	return nil
+}
+
+// List retrieves a page of audit entries, filtered and sorted as asked by a resolved list query.
+// It reads one more entry than the limit of the query when there are more.
+func (r *MySQLAuditRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	clause, args := query.SQL(listFields)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, actor, action, entity, entity_id, changes, created_at FROM audit_log"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []internal.AuditEntry{}
+
+	for rows.Next() {
+		var (
+			entry   internal.AuditEntry
+			changes []byte
+		)
+
+		err = rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityID, &changes, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
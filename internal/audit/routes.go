@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+)
+
+// RegisterAuditRoutes registers the routes of the audit trail, the changes are recorded by the services
+func RegisterAuditRoutes(mux *chi.Mux, service internal.AuditService) error {
+	auditHandler := handler.NewAuditHandler(service)
+
+	mux.Route("/api/v1/audit", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceAudit, rbac.ActionRead))).Get("/", auditHandler.GetAuditEntries())
+	})
+
+	return nil
+}
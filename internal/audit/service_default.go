@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// System is the actor of the changes made without a principal, as the ones of the purge job and of
+// the commands
+const System = "system"
+
+type DefaultAuditService struct {
+	repo internal.AuditRepository
+}
+
+// NewDefaultAuditService creates a new DefaultAuditService with the given repository.
+func NewDefaultAuditService(repo internal.AuditRepository) *DefaultAuditService {
+	return &DefaultAuditService{repo: repo}
+}
+
+// List retrieves a page of audit entries filtered and sorted as asked by the query.
+func (s *DefaultAuditService) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, utils.Pagination, error) {
+	err := query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	entries, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	entries, pagination := utils.Paginate(entries, query)
+
+	return entries, pagination, nil
+}
+
+// Record saves to repo an entry of a change made now by the principal of ctx, or by System without
+// one, with the fields that differ between the entity before and after the change. Either of them is
+// nil when the entity was created or deleted. The services record their changes with the audit
+// repository of the unit of work making them, so an entry is saved only when its change is.
+func Record(ctx context.Context, repo internal.AuditRepository, action, entity string, entityID int, before, after any) error {
+	beforeJSON, err := marshal(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshal(after)
+	if err != nil {
+		return err
+	}
+
+	changes, err := diff(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	actor := System
+	if principal, ok := internal.PrincipalFromContext(ctx); ok {
+		actor = principal.Username
+	}
+
+	return repo.Save(ctx, &internal.AuditEntry{
+		Actor:     actor,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Changes:   changes,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// marshal returns the json of an entity, empty for nil
+func marshal(entity any) (json.RawMessage, error) {
+	if entity == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(entity)
+}
+
+// diff compares the top level fields of two json objects, an empty one has no fields
+func diff(before, after json.RawMessage) (map[string]internal.AuditChange, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]internal.AuditChange{}
+
+	for name, value := range beforeFields {
+		if !sameJSON(value, afterFields[name]) {
+			changes[name] = internal.AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = internal.AuditChange{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+func fields(object json.RawMessage) (map[string]json.RawMessage, error) {
+	values := map[string]json.RawMessage{}
+
+	if len(object) == 0 {
+		return values, nil
+	}
+
+	err := json.Unmarshal(object, &values)
+
+	return values, err
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *mockAuditRepository) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *mockAuditRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.AuditEntry), args.Error(1)
+}
+
+func TestUnitAudit_Record(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		before  any
+		after   any
+		changes map[string]internal.AuditChange
+	}{
+		{
+			name:   "create",
+			action: internal.AuditCreate,
+			after:  json.RawMessage(`{"id":1,"cid":10}`),
+			changes: map[string]internal.AuditChange{
+				"id":  {After: json.RawMessage(`1`)},
+				"cid": {After: json.RawMessage(`10`)},
+			},
+		},
+		{
+			name:   "update keeps only the changed fields",
+			action: internal.AuditUpdate,
+			before: json.RawMessage(`{"id":1,"cid":10,"address":"Street 1"}`),
+			after: struct {
+				ID      int    `json:"id"`
+				CID     int    `json:"cid"`
+				Address string `json:"address"`
+			}{ID: 1, CID: 10, Address: "Street 2"},
+			changes: map[string]internal.AuditChange{
+				"address": {Before: json.RawMessage(`"Street 1"`), After: json.RawMessage(`"Street 2"`)},
+			},
+		},
+		{
+			name:   "delete",
+			action: internal.AuditDelete,
+			before: json.RawMessage(`{"id":1}`),
+			changes: map[string]internal.AuditChange{
+				"id": {Before: json.RawMessage(`1`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockAuditRepository)
+			repo.On("Save", mock.MatchedBy(func(entry *internal.AuditEntry) bool {
+				return entry.Actor == "ana" && entry.Action == tt.action && entry.Entity == "carries" &&
+					entry.EntityID == 1 && !entry.CreatedAt.IsZero()
+			})).Return(nil)
+
+			ctx := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 1, Username: "ana"})
+
+			err := Record(ctx, repo, tt.action, "carries", 1, tt.before, tt.after)
+			require.NoError(t, err)
+
+			entry := repo.Calls[0].Arguments.Get(0).(*internal.AuditEntry)
+			require.Equal(t, tt.changes, entry.Changes)
+		})
+	}
+}
+
+func TestUnitAudit_Record_WithoutPrincipal(t *testing.T) {
+	repo := new(mockAuditRepository)
+	repo.On("Save", mock.MatchedBy(func(entry *internal.AuditEntry) bool {
+		return entry.Actor == System && entry.Action == internal.AuditPurge
+	})).Return(nil)
+
+	err := Record(context.Background(), repo, internal.AuditPurge, "carries", 0, nil, map[string]int{"purged": 2})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestUnitAudit_Record_Errors(t *testing.T) {
+	t.Run("not an object", func(t *testing.T) {
+		err := Record(context.Background(), new(mockAuditRepository), internal.AuditCreate, "carries", 1, nil, []int{1})
+		require.Error(t, err)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := new(mockAuditRepository)
+		repo.On("Save", mock.Anything).Return(errors.New("connection lost"))
+
+		err := Record(context.Background(), repo, internal.AuditCreate, "carries", 1, nil, json.RawMessage(`{"id":1}`))
+		require.EqualError(t, err, "connection lost")
+	})
+}
+
+func TestUnitAudit_List(t *testing.T) {
+	t.Run("resolved query", func(t *testing.T) {
+		repo := new(mockAuditRepository)
+		repo.On("List", mock.Anything).Return([]internal.AuditEntry{{ID: 1}, {ID: 2}}, nil)
+
+		service := NewDefaultAuditService(repo)
+
+		entries, pagination, err := service.List(context.Background(), utils.ListQuery{Limit: 1, Filters: []utils.ListFilter{
+			{Field: "created_at", Operator: "gte", Text: "2024-01-01"},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, []internal.AuditEntry{{ID: 1}}, entries)
+		require.True(t, pagination.HasMore)
+	})
+
+	t.Run("created_at cannot be sorted", func(t *testing.T) {
+		service := NewDefaultAuditService(new(mockAuditRepository))
+
+		_, _, err := service.List(context.Background(), utils.ListQuery{Limit: 1, Sort: []utils.ListSort{{Field: "created_at"}}})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+}
@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Roles of the users, each one with the permissions of rbac.Require
+const (
+	RoleAdmin             = "admin"
+	RoleWarehouseOperator = "warehouse_operator"
+	RoleSeller            = "seller"
+	RoleBuyer             = "buyer"
+	RoleAnalyst           = "analyst"
+)
+
+// Roles are the roles a user can have
+var Roles = []string{RoleAdmin, RoleWarehouseOperator, RoleSeller, RoleBuyer, RoleAnalyst}
+
+// User is who can authenticate to the API. WarehouseIDs are the warehouses a warehouse operator is
+// assigned to, SellerID and BuyerID are the seller and buyer a seller or a buyer acts as
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	WarehouseIDs []int     `json:"warehouse_ids"`
+	SellerID     int       `json:"seller_id,omitempty"`
+	BuyerID      int       `json:"buyer_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Principal is the authenticated user of a request. The requests made with an API key only have the
+// permissions of its Scopes, nil for the ones made with a token, and APIKeyID is the id of the key
+type Principal struct {
+	UserID       int      `json:"user_id"`
+	Username     string   `json:"username"`
+	Role         string   `json:"role"`
+	WarehouseIDs []int    `json:"warehouse_ids"`
+	SellerID     int      `json:"seller_id,omitempty"`
+	BuyerID      int      `json:"buyer_id,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	APIKeyID     int      `json:"api_key_id,omitempty"`
+}
+
+// Client returns who made the requests of the principal, the API key they were made with or else its user
+func (p Principal) Client() string {
+	if p.APIKeyID != 0 {
+		return "key:" + strconv.Itoa(p.APIKeyID)
+	}
+
+	return "user:" + strconv.Itoa(p.UserID)
+}
+
+// TokenPair are the tokens issued to a user, the access token authenticates the requests until it
+// expires and the refresh token issues a new pair
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the seconds the access token is valid for
+	ExpiresIn int `json:"expires_in"`
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id int) (User, error)
+	GetByUsername(ctx context.Context, username string) (User, error)
+	Create(ctx context.Context, user *User) error
+}
+
+type AuthService interface {
+	// Login issues the tokens of the user with the username and password
+	Login(ctx context.Context, username, password string) (TokenPair, error)
+	// Refresh issues new tokens from a refresh token, while its user still exists
+	Refresh(ctx context.Context, refreshToken string) (TokenPair, error)
+	// Authenticate returns the principal of an access token
+	Authenticate(ctx context.Context, accessToken string) (Principal, error)
+	// CreateUser saves a user with the hash of the password
+	CreateUser(ctx context.Context, user User, password string) (User, error)
+}
+
+// principalKey is the key of the principal in the context of a request
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the authenticated principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal the request of ctx was authenticated as, false when
+// it was not authenticated
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// WarehouseScope returns the warehouses the principal of ctx is assigned to when it only sees the
+// sections, employees and inbound orders of those, false when it sees the ones of every warehouse
+func WarehouseScope(ctx context.Context) ([]int, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Role != RoleWarehouseOperator {
+		return nil, false
+	}
+
+	return principal.WarehouseIDs, true
+}
+
+// WarehouseCondition returns the SQL condition of the column being one of the warehouses of the
+// scope of ctx, TRUE when the principal sees the records of every warehouse
+func WarehouseCondition(ctx context.Context, column string) string {
+	ids, scoped := WarehouseScope(ctx)
+	if !scoped {
+		return "TRUE"
+	}
+
+	return utils.InIDs(column, ids)
+}
+
+// InWarehouseScope reports whether the principal of ctx can manage the records of the warehouse
+func InWarehouseScope(ctx context.Context, warehouseID int) bool {
+	ids, scoped := WarehouseScope(ctx)
+	return !scoped || slices.Contains(ids, warehouseID)
+}
+
+// CheckWarehouseScope returns a utils.ErrForbidden error when the principal of ctx cannot manage the
+// records of the warehouse
+func CheckWarehouseScope(ctx context.Context, warehouseID int) error {
+	if !InWarehouseScope(ctx, warehouseID) {
+		return utils.EForbidden("warehouse " + strconv.Itoa(warehouseID) + " is not assigned to the user")
+	}
+
+	return nil
+}
+
+// CheckIncludeDeleted returns a utils.ErrForbidden error when the list includes the soft deleted
+// rows and the principal of ctx is not an admin, only the admins see the deleted records
+func CheckIncludeDeleted(ctx context.Context, query utils.ListQuery) error {
+	if principal, _ := PrincipalFromContext(ctx); query.IncludeDeleted && principal.Role != RoleAdmin {
+		return utils.EForbidden("only the admins can list the deleted records")
+	}
+
+	return nil
+}
+
+// SellerScope returns the seller the principal of ctx acts as when it only sees the seller, its
+// products and their packaging units and records, false when it sees the ones of every seller
+func SellerScope(ctx context.Context) (int, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Role != RoleSeller {
+		return 0, false
+	}
+
+	return principal.SellerID, true
+}
+
+// SellerCondition returns the SQL condition of the column being the seller of the scope of ctx,
+// TRUE when the principal sees the records of every seller
+func SellerCondition(ctx context.Context, column string) string {
+	id, scoped := SellerScope(ctx)
+	return ownerCondition(column, id, scoped)
+}
+
+// CheckSellerScope returns a utils.ErrForbidden error when the principal of ctx cannot manage the
+// records of the seller
+func CheckSellerScope(ctx context.Context, sellerID int) error {
+	if id, scoped := SellerScope(ctx); scoped && id != sellerID {
+		return utils.EForbidden("seller " + strconv.Itoa(sellerID) + " is not the one of the user")
+	}
+
+	return nil
+}
+
+// BuyerScope returns the buyer the principal of ctx acts as when it only sees the buyer and its
+// purchase orders, false when it sees the ones of every buyer
+func BuyerScope(ctx context.Context) (int, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Role != RoleBuyer {
+		return 0, false
+	}
+
+	return principal.BuyerID, true
+}
+
+// BuyerCondition returns the SQL condition of the column being the buyer of the scope of ctx, TRUE
+// when the principal sees the records of every buyer
+func BuyerCondition(ctx context.Context, column string) string {
+	id, scoped := BuyerScope(ctx)
+	return ownerCondition(column, id, scoped)
+}
+
+// CheckBuyerScope returns a utils.ErrForbidden error when the principal of ctx cannot manage the
+// records of the buyer
+func CheckBuyerScope(ctx context.Context, buyerID int) error {
+	if id, scoped := BuyerScope(ctx); scoped && id != buyerID {
+		return utils.EForbidden("buyer " + strconv.Itoa(buyerID) + " is not the one of the user")
+	}
+
+	return nil
+}
+
+// ownerCondition returns the condition of the column being the owner id when scoped
+func ownerCondition(column string, id int, scoped bool) string {
+	if !scoped {
+		return "TRUE"
+	}
+
+	return utils.InIDs(column, []int{id})
+}
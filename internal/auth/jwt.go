@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Algorithms the tokens can be signed with
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// Types of the tokens, an access token cannot refresh and a refresh token cannot authenticate a request
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// minSecretLength is the length in bytes of the shortest HS256 secret, as long as the hash
+const minSecretLength = 32
+
+var (
+	// ErrInvalidToken is the error of a token that is malformed, not signed with the key or of another type
+	ErrInvalidToken = utils.EUnauthorized("invalid token")
+	// ErrExpiredToken is the error of a token used after it expired
+	ErrExpiredToken = utils.EUnauthorized("token expired")
+)
+
+// Claims are the claims of the tokens issued, Subject is the id of the user
+type Claims struct {
+	Subject      string `json:"sub"`
+	Username     string `json:"name"`
+	Role         string `json:"role"`
+	WarehouseIDs []int  `json:"warehouses,omitempty"`
+	SellerID     int    `json:"seller,omitempty"`
+	BuyerID      int    `json:"buyer,omitempty"`
+	Type         string `json:"typ"`
+	IssuedAt     int64  `json:"iat"`
+	ExpiresAt    int64  `json:"exp"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+// JWT signs and verifies tokens with a single algorithm, the tokens signed with another one are rejected
+type JWT struct {
+	algorithm string
+	sign      func(content []byte) ([]byte, error)
+	verify    func(content, signature []byte) bool
+}
+
+// NewHS256JWT creates a new JWT that signs with HMAC SHA-256 and the secret, of 32 bytes at least
+func NewHS256JWT(secret []byte) (*JWT, error) {
+	if len(secret) < minSecretLength {
+		return nil, errors.New("the HS256 secret must have 32 bytes at least")
+	}
+
+	mac := func(content []byte) []byte {
+		hash := hmac.New(sha256.New, secret)
+		hash.Write(content)
+
+		return hash.Sum(nil)
+	}
+
+	return &JWT{
+		algorithm: HS256,
+		sign: func(content []byte) ([]byte, error) {
+			return mac(content), nil
+		},
+		verify: func(content, signature []byte) bool {
+			return hmac.Equal(mac(content), signature)
+		},
+	}, nil
+}
+
+// NewRS256JWT creates a new JWT that signs with RSA SHA-256 and the private key, and verifies with its public key
+func NewRS256JWT(key *rsa.PrivateKey) *JWT {
+	return &JWT{
+		algorithm: RS256,
+		sign: func(content []byte) ([]byte, error) {
+			digest := sha256.Sum256(content)
+			return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		},
+		verify: func(content, signature []byte) bool {
+			digest := sha256.Sum256(content)
+			return rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) == nil
+		},
+	}
+}
+
+// ParseRSAPrivateKey reads a PEM encoded RSA private key, in PKCS #1 or PKCS #8 form
+func ParseRSAPrivateKey(content []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("the RSA private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// Sign returns the token of the claims
+func (j *JWT) Sign(claims Claims) (string, error) {
+	headerJSON, err := json.Marshal(header{Algorithm: j.algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	content := encode(headerJSON) + "." + encode(claimsJSON)
+
+	signature, err := j.sign([]byte(content))
+	if err != nil {
+		return "", err
+	}
+
+	return content + "." + encode(signature), nil
+}
+
+// Parse verifies the token and returns its claims, ErrInvalidToken when it wasn't signed with the key
+// of j and ErrExpiredToken when it expired by now
+func (j *JWT) Parse(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var h header
+	if err := decode(parts[0], &h); err != nil || h.Algorithm != j.algorithm {
+		return Claims{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !j.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decode(parts[1], &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func encode(content []byte) string {
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decode(part string, target any) error {
+	content, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, target)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitJWT(t *testing.T) {
+	now := time.Now()
+	claims := Claims{Subject: "3", Username: "ana", Type: AccessToken, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+
+	hs256, err := NewHS256JWT([]byte(strings.Repeat("s", 32)))
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rs256 := NewRS256JWT(key)
+
+	for name, tokens := range map[string]*JWT{HS256: hs256, RS256: rs256} {
+		t.Run(name+" signs and verifies the claims", func(t *testing.T) {
+			token, err := tokens.Sign(claims)
+			require.NoError(t, err)
+
+			parsed, err := tokens.Parse(token, now)
+			require.NoError(t, err)
+			require.Equal(t, claims, parsed)
+		})
+
+		t.Run(name+" rejects the expired tokens", func(t *testing.T) {
+			token, err := tokens.Sign(claims)
+			require.NoError(t, err)
+
+			_, err = tokens.Parse(token, now.Add(time.Minute))
+			require.ErrorIs(t, err, ErrExpiredToken)
+		})
+
+		t.Run(name+" rejects the tampered tokens", func(t *testing.T) {
+			token, err := tokens.Sign(claims)
+			require.NoError(t, err)
+
+			forged := claims
+			forged.Username = "admin"
+
+			forgedToken, err := tokens.Sign(forged)
+			require.NoError(t, err)
+
+			parts := strings.Split(token, ".")
+			forgedParts := strings.Split(forgedToken, ".")
+
+			_, err = tokens.Parse(parts[0]+"."+forgedParts[1]+"."+parts[2], now)
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	t.Run("rejects the tokens of another key", func(t *testing.T) {
+		other, err := NewHS256JWT([]byte(strings.Repeat("o", 32)))
+		require.NoError(t, err)
+
+		token, err := other.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = hs256.Parse(token, now)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects the tokens of another algorithm", func(t *testing.T) {
+		token, err := rs256.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = hs256.Parse(token, now)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects the malformed tokens", func(t *testing.T) {
+		for _, token := range []string{"", "a.b", "a.b.c", "a.b.c.d"} {
+			_, err := hs256.Parse(token, now)
+			require.ErrorIs(t, err, ErrInvalidToken)
+		}
+	})
+
+	t.Run("short HS256 secret", func(t *testing.T) {
+		_, err := NewHS256JWT([]byte("short"))
+		require.Error(t, err)
+	})
+}
+
+func TestUnitParseRSAPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"PKCS #1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"PKCS #8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseRSAPrivateKey(pem.EncodeToMemory(block))
+			require.NoError(t, err)
+			require.True(t, key.Equal(parsed))
+		})
+	}
+
+	t.Run("not PEM encoded", func(t *testing.T) {
+		_, err := ParseRSAPrivateKey([]byte("not a key"))
+		require.Error(t, err)
+	})
+}
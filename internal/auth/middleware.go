@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// bearerPrefix is the scheme of the Authorization header of the authenticated requests
+const bearerPrefix = "Bearer "
+
+// Middleware authenticates the requests with the access token of their Authorization header, the
+// principal is put in their context for the handlers and services. The public paths are served without
+// a token, a path ending in /* is public with every path under it, and the requests already
+// authenticated with an API key are served as they are.
+func Middleware(service internal.AuthService, public ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, authenticated := internal.PrincipalFromContext(r.Context()); authenticated || isPublic(r.URL.Path, public) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authorization := r.Header.Get("Authorization")
+			if len(authorization) < len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				utils.HandleError(w, utils.EUnauthorized("missing bearer token"))
+
+				return
+			}
+
+			principal, err := service.Authenticate(r.Context(), strings.TrimSpace(authorization[len(bearerPrefix):]))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				utils.HandleError(w, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(internal.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// isPublic reports whether the path is one of the public paths or under one ending in /*
+func isPublic(path string, public []string) bool {
+	path = strings.TrimSuffix(path, "/")
+
+	for _, publicPath := range public {
+		if prefix, found := strings.CutSuffix(publicPath, "/*"); found {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+
+			continue
+		}
+
+		if path == strings.TrimSuffix(publicPath, "/") {
+			return true
+		}
+	}
+
+	return false
+}
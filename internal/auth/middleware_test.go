@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	service := newTestAuthService(t, nil)
+
+	tokens, err := service.issue(internal.User{ID: 3, Username: "ana"})
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	// stands for the API key middleware, mounted before
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-API-Key") != "" {
+				r = r.WithContext(internal.WithPrincipal(r.Context(), internal.Principal{UserID: 5, Username: "erp", Scopes: []string{}}))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(Middleware(service, "/api/v1/auth/token", "/swagger/*"))
+
+	whoAmI := func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := internal.PrincipalFromContext(r.Context())
+		_, _ = w.Write([]byte(principal.Username))
+	}
+
+	router.Get("/api/v1/sellers", whoAmI)
+	router.Post("/api/v1/auth/token", whoAmI)
+	router.Get("/swagger/*", whoAmI)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		apiKey        string
+		status        int
+		body          string
+	}{
+		{name: "access token", method: http.MethodGet, path: "/api/v1/sellers", authorization: "Bearer " + tokens.AccessToken, status: http.StatusOK, body: "ana"},
+		{name: "scheme in lower case", method: http.MethodGet, path: "/api/v1/sellers", authorization: "bearer " + tokens.AccessToken, status: http.StatusOK, body: "ana"},
+		{name: "without token", method: http.MethodGet, path: "/api/v1/sellers", status: http.StatusUnauthorized},
+		{name: "another scheme", method: http.MethodGet, path: "/api/v1/sellers", authorization: "Basic YW5hOnB3", status: http.StatusUnauthorized},
+		{name: "refresh token", method: http.MethodGet, path: "/api/v1/sellers", authorization: "Bearer " + tokens.RefreshToken, status: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/api/v1/sellers", authorization: "Bearer a.b.c", status: http.StatusUnauthorized},
+		{name: "authenticated with an API key", method: http.MethodGet, path: "/api/v1/sellers", apiKey: "mfp_key", status: http.StatusOK, body: "erp"},
+		{name: "public path", method: http.MethodPost, path: "/api/v1/auth/token", status: http.StatusOK},
+		{name: "under a public path", method: http.MethodGet, path: "/swagger/index.html", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tt.status, res.Code)
+
+			if tt.status == http.StatusUnauthorized {
+				require.Contains(t, res.Header().Get("WWW-Authenticate"), "Bearer")
+			} else {
+				require.Equal(t, tt.body, res.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// passwordScheme prefixes the hashes, pbkdf2-sha256$iterations$salt$key
+	passwordScheme = "pbkdf2-sha256"
+	// passwordIterations is the cost of the hashes made, the hashes keep the cost they were made with
+	passwordIterations = 600000
+	saltLength         = 16
+)
+
+// HashPassword returns the salted PBKDF2 SHA-256 hash of a password
+func HashPassword(password string) (string, error) {
+	return hashPassword(password, passwordIterations)
+}
+
+func hashPassword(password string, iterations int) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := pbkdf2([]byte(password), salt, iterations)
+
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, iterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether the password is the one of the hash
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, pbkdf2([]byte(password), salt, iterations)) == 1
+}
+
+// pbkdf2 derives a key of the size of a SHA-256 hash, the first block of RFC 8018 PBKDF2
+func pbkdf2(password, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+
+	u := prf.Sum(nil)
+	key := append([]byte(nil), u...)
+
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+
+	return key
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitPBKDF2(t *testing.T) {
+	// known PBKDF2-HMAC-SHA256 vectors
+	require.Equal(t, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b",
+		hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), 1)))
+	require.Equal(t, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a",
+		hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), 4096)))
+}
+
+func TestUnitPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret-password")
+	require.NoError(t, err)
+
+	t.Run("the password of the hash", func(t *testing.T) {
+		require.True(t, CheckPassword(hash, "s3cret-password"))
+	})
+
+	t.Run("another password", func(t *testing.T) {
+		require.False(t, CheckPassword(hash, "s3cret-passwore"))
+	})
+
+	t.Run("the hashes are salted", func(t *testing.T) {
+		other, err := HashPassword("s3cret-password")
+		require.NoError(t, err)
+		require.NotEqual(t, hash, other)
+	})
+
+	t.Run("malformed hash", func(t *testing.T) {
+		require.False(t, CheckPassword("plain", "plain"))
+		require.False(t, CheckPassword("pbkdf2-sha256$x$c2FsdA$a2V5", "plain"))
+	})
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+const selectUsers = "SELECT id, username, password_hash, role, seller_id, buyer_id, created_at FROM users"
+
+type MySQLUserRepository struct {
+	db utils.DBTX
+}
+
+// NewMySQLUserRepository creates a new MySQLUserRepository with the given database connection.
+func NewMySQLUserRepository(db utils.DBTX) *MySQLUserRepository {
+	return &MySQLUserRepository{db: db}
+}
+
+// GetByID retrieves the user with the id and the warehouses it is assigned to.
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id int) (internal.User, error) {
+	return r.getOne(ctx, selectUsers+" WHERE id = ?", id)
+}
+
+// GetByUsername retrieves the user with the username and the warehouses it is assigned to.
+func (r *MySQLUserRepository) GetByUsername(ctx context.Context, username string) (internal.User, error) {
+	return r.getOne(ctx, selectUsers+" WHERE username = ?", username)
+}
+
+// Create inserts a user into the users table with its warehouses, and sets its id.
+func (r *MySQLUserRepository) Create(ctx context.Context, user *internal.User) error {
+	return utils.InTx(ctx, r.db, func(tx utils.DBTX) error {
+		result, err := tx.ExecContext(ctx, "INSERT INTO users(username, password_hash, role, seller_id, buyer_id, created_at) VALUES(?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), ?)",
+			user.Username, user.PasswordHash, user.Role, user.SellerID, user.BuyerID, user.CreatedAt)
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+				return utils.EConflict("user", "username")
+			}
+
+			// only one of them is set, a seller or a buyer is bound to the user
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 && user.SellerID != 0 {
+				return utils.EDependencyNotFound("seller", "id: "+strconv.Itoa(user.SellerID))
+			}
+
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+				return utils.EDependencyNotFound("buyer", "id: "+strconv.Itoa(user.BuyerID))
+			}
+
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for _, warehouseID := range user.WarehouseIDs {
+			_, err = tx.ExecContext(ctx, "INSERT INTO user_warehouses(user_id, warehouse_id) VALUES(?, ?)", id, warehouseID)
+			if err != nil {
+				var mysqlErr *mysql.MySQLError
+				if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+					return utils.EDependencyNotFound("warehouse", "id: "+strconv.Itoa(warehouseID))
+				}
+
+				return err
+			}
+		}
+
+		user.ID = int(id)
+
+		return nil
+	})
+}
+
+func (r *MySQLUserRepository) getOne(ctx context.Context, query string, args ...any) (internal.User, error) {
+	var user internal.User
+
+	var sellerID, buyerID sql.NullInt64
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &sellerID, &buyerID, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.User{}, utils.ErrNotFound
+		}
+
+		return internal.User{}, err
+	}
+
+	user.SellerID, user.BuyerID = int(sellerID.Int64), int(buyerID.Int64)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT warehouse_id FROM user_warehouses WHERE user_id = ? ORDER BY warehouse_id", user.ID)
+	if err != nil {
+		return internal.User{}, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var warehouseID int
+		if err := rows.Scan(&warehouseID); err != nil {
+			return internal.User{}, err
+		}
+
+		user.WarehouseIDs = append(user.WarehouseIDs, warehouseID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return internal.User{}, err
+	}
+
+	return user, nil
+}
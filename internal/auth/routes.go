@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+)
+
+// RegisterAuthRoutes registers the routes that issue the tokens, they must be public paths of Middleware
+func RegisterAuthRoutes(mux *chi.Mux, service internal.AuthService) error {
+	authHandler := handler.NewAuthHandler(service)
+
+	mux.Route("/api/v1/auth", func(router chi.Router) {
+		router.Post("/token", authHandler.Login())
+		router.Post("/refresh", authHandler.Refresh())
+	})
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// minPasswordLength is the length of the shortest password of a user
+const minPasswordLength = 8
+
+// auditEntity is the name of the users in the audit trail
+const auditEntity = "users"
+
+// ErrInvalidCredentials is the error of a login with an unknown username or a wrong password, the
+// same for both so the usernames cannot be guessed
+var ErrInvalidCredentials = utils.EUnauthorized("invalid username or password")
+
+// unknownUserHash is checked against the password of an unknown username, so its login takes as long
+// as the one of a wrong password
+var unknownUserHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("unknown user")
+	return hash
+})
+
+type DefaultAuthService struct {
+	users  internal.UserRepository
+	tokens *JWT
+	// accessTTL is how long an access token authenticates the requests
+	accessTTL time.Duration
+	// refreshTTL is how long a refresh token issues new tokens
+	refreshTTL time.Duration
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
+}
+
+// NewDefaultAuthService creates a new DefaultAuthService that signs its tokens with tokens, the
+// access tokens expire after accessTTL and the refresh tokens after refreshTTL.
+func NewDefaultAuthService(users internal.UserRepository, tokens *JWT, accessTTL, refreshTTL time.Duration, uow internal.UnitOfWork) *DefaultAuthService {
+	return &DefaultAuthService{users: users, tokens: tokens, accessTTL: accessTTL, refreshTTL: refreshTTL, uow: uow}
+}
+
+// Login issues the tokens of the user when the password is the one of its hash.
+func (s *DefaultAuthService) Login(ctx context.Context, username, password string) (internal.TokenPair, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return internal.TokenPair{}, utils.EZeroValue("username")
+	}
+
+	if password == "" {
+		return internal.TokenPair{}, utils.EZeroValue("password")
+	}
+
+	user, err := s.users.GetByUsername(ctx, username)
+	if errors.Is(err, utils.ErrNotFound) {
+		CheckPassword(unknownUserHash(), password)
+		return internal.TokenPair{}, ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return internal.TokenPair{}, err
+	}
+
+	if !CheckPassword(user.PasswordHash, password) {
+		return internal.TokenPair{}, ErrInvalidCredentials
+	}
+
+	return s.issue(user)
+}
+
+// Refresh issues new tokens from a refresh token that is not expired, the user it was issued to
+// must still exist.
+func (s *DefaultAuthService) Refresh(ctx context.Context, refreshToken string) (internal.TokenPair, error) {
+	claims, err := s.parse(refreshToken, RefreshToken)
+	if err != nil {
+		return internal.TokenPair{}, err
+	}
+
+	id, _ := strconv.Atoi(claims.Subject)
+
+	user, err := s.users.GetByID(ctx, id)
+	if errors.Is(err, utils.ErrNotFound) {
+		return internal.TokenPair{}, ErrInvalidToken
+	}
+
+	if err != nil {
+		return internal.TokenPair{}, err
+	}
+
+	return s.issue(user)
+}
+
+// Authenticate returns the principal of an access token that is not expired, the token is enough
+// so no user is read. A change of the role or warehouses of a user applies to its next tokens.
+func (s *DefaultAuthService) Authenticate(_ context.Context, accessToken string) (internal.Principal, error) {
+	claims, err := s.parse(accessToken, AccessToken)
+	if err != nil {
+		return internal.Principal{}, err
+	}
+
+	id, _ := strconv.Atoi(claims.Subject)
+
+	return internal.Principal{
+		UserID:       id,
+		Username:     claims.Username,
+		Role:         claims.Role,
+		WarehouseIDs: claims.WarehouseIDs,
+		SellerID:     claims.SellerID,
+		BuyerID:      claims.BuyerID,
+	}, nil
+}
+
+// CreateUser saves a user with the hash of the password, the username must be unique. Only the
+// warehouse operators are assigned to warehouses, and the sellers and buyers act as the seller or
+// buyer they are bound to.
+func (s *DefaultAuthService) CreateUser(ctx context.Context, user internal.User, password string) (internal.User, error) {
+	user.Username = strings.TrimSpace(user.Username)
+	if user.Username == "" {
+		return internal.User{}, utils.EZeroValue("username")
+	}
+
+	if user.Role == "" {
+		return internal.User{}, utils.EZeroValue("role")
+	}
+
+	if !slices.Contains(internal.Roles, user.Role) {
+		return internal.User{}, utils.EBR("unknown role " + user.Role)
+	}
+
+	if len(user.WarehouseIDs) > 0 && user.Role != internal.RoleWarehouseOperator {
+		return internal.User{}, utils.EBR("only the warehouse operators are assigned to warehouses")
+	}
+
+	if err := validateOwner(user.Role, internal.RoleSeller, "seller_id", user.SellerID); err != nil {
+		return internal.User{}, err
+	}
+
+	if err := validateOwner(user.Role, internal.RoleBuyer, "buyer_id", user.BuyerID); err != nil {
+		return internal.User{}, err
+	}
+
+	if len(password) < minPasswordLength {
+		return internal.User{}, utils.EBR("password must have " + strconv.Itoa(minPasswordLength) + " characters at least")
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return internal.User{}, err
+	}
+
+	user.PasswordHash = hash
+	user.CreatedAt = time.Now().UTC()
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Users.Create(ctx, &user); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, user.ID, nil, user)
+	})
+	if err != nil {
+		return internal.User{}, err
+	}
+
+	return user, nil
+}
+
+// parse verifies a token of the type, the other types are invalid
+func (s *DefaultAuthService) parse(token, tokenType string) (Claims, error) {
+	claims, err := s.tokens.Parse(token, time.Now())
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Type != tokenType {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if _, err := strconv.Atoi(claims.Subject); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// issue signs the access and refresh tokens of a user
+func (s *DefaultAuthService) issue(user internal.User) (internal.TokenPair, error) {
+	now := time.Now()
+	claims := Claims{
+		Subject:      strconv.Itoa(user.ID),
+		Username:     user.Username,
+		Role:         user.Role,
+		WarehouseIDs: user.WarehouseIDs,
+		SellerID:     user.SellerID,
+		BuyerID:      user.BuyerID,
+		IssuedAt:     now.Unix(),
+	}
+
+	access := claims
+	access.Type = AccessToken
+	access.ExpiresAt = now.Add(s.accessTTL).Unix()
+
+	accessToken, err := s.tokens.Sign(access)
+	if err != nil {
+		return internal.TokenPair{}, err
+	}
+
+	refresh := claims
+	refresh.Type = RefreshToken
+	refresh.ExpiresAt = now.Add(s.refreshTTL).Unix()
+
+	refreshToken, err := s.tokens.Sign(refresh)
+	if err != nil {
+		return internal.TokenPair{}, err
+	}
+
+	return internal.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// validateOwner checks a user of the owner role is bound to an owner, and a user of another role is not
+func validateOwner(role, ownerRole, field string, ownerID int) error {
+	if role == ownerRole && ownerID <= 0 {
+		return utils.EZeroValue(field)
+	}
+
+	if role != ownerRole && ownerID != 0 {
+		return utils.EBR("only the users with the role " + ownerRole + " have a " + field)
+	}
+
+	return nil
+}
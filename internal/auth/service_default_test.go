@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(users internal.UserRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Users: users, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type mockUserRepository struct {
+	mock.Mock
+}
+
+func (m *mockUserRepository) GetByID(ctx context.Context, id int) (internal.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetByUsername(ctx context.Context, username string) (internal.User, error) {
+	args := m.Called(username)
+	return args.Get(0).(internal.User), args.Error(1)
+}
+
+func (m *mockUserRepository) Create(ctx context.Context, user *internal.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func newTestAuthService(t *testing.T, users internal.UserRepository) *DefaultAuthService {
+	tokens, err := NewHS256JWT([]byte(strings.Repeat("s", 32)))
+	require.NoError(t, err)
+
+	return NewDefaultAuthService(users, tokens, 15*time.Minute, time.Hour, newMockUnitOfWork(users))
+}
+
+func TestUnitAuth_Login(t *testing.T) {
+	hash, err := hashPassword("s3cret-password", 1000)
+	require.NoError(t, err)
+
+	ana := internal.User{ID: 3, Username: "ana", PasswordHash: hash, Role: internal.RoleWarehouseOperator, WarehouseIDs: []int{1, 4}}
+
+	t.Run("issues the tokens of the user", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("GetByUsername", "ana").Return(ana, nil)
+
+		service := newTestAuthService(t, users)
+
+		tokens, err := service.Login(context.Background(), " ana ", "s3cret-password")
+		require.NoError(t, err)
+		require.Equal(t, "Bearer", tokens.TokenType)
+		require.Equal(t, 900, tokens.ExpiresIn)
+
+		principal, err := service.Authenticate(context.Background(), tokens.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, internal.Principal{UserID: 3, Username: "ana", Role: internal.RoleWarehouseOperator, WarehouseIDs: []int{1, 4}}, principal)
+
+		_, err = service.Authenticate(context.Background(), tokens.RefreshToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("GetByUsername", "ana").Return(ana, nil)
+
+		_, err := newTestAuthService(t, users).Login(context.Background(), "ana", "guess")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("GetByUsername", "bob").Return(internal.User{}, utils.ErrNotFound)
+
+		_, err := newTestAuthService(t, users).Login(context.Background(), "bob", "guess")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		service := newTestAuthService(t, new(mockUserRepository))
+
+		_, err := service.Login(context.Background(), "", "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+
+		_, err = service.Login(context.Background(), "ana", "")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+}
+
+func TestUnitAuth_Refresh(t *testing.T) {
+	ana := internal.User{ID: 3, Username: "ana", Role: internal.RoleSeller, SellerID: 7}
+
+	t.Run("issues new tokens", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("GetByID", 3).Return(ana, nil)
+
+		service := newTestAuthService(t, users)
+		issued, err := service.issue(ana)
+		require.NoError(t, err)
+
+		tokens, err := service.Refresh(context.Background(), issued.RefreshToken)
+		require.NoError(t, err)
+
+		principal, err := service.Authenticate(context.Background(), tokens.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, "ana", principal.Username)
+		require.Equal(t, 7, principal.SellerID)
+	})
+
+	t.Run("an access token cannot refresh", func(t *testing.T) {
+		service := newTestAuthService(t, new(mockUserRepository))
+		issued, err := service.issue(ana)
+		require.NoError(t, err)
+
+		_, err = service.Refresh(context.Background(), issued.AccessToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("the user no longer exists", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("GetByID", 3).Return(internal.User{}, utils.ErrNotFound)
+
+		service := newTestAuthService(t, users)
+		issued, err := service.issue(ana)
+		require.NoError(t, err)
+
+		_, err = service.Refresh(context.Background(), issued.RefreshToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("expired refresh token", func(t *testing.T) {
+		service := newTestAuthService(t, new(mockUserRepository))
+		service.refreshTTL = -time.Second
+
+		issued, err := service.issue(ana)
+		require.NoError(t, err)
+
+		_, err = service.Refresh(context.Background(), issued.RefreshToken)
+		require.ErrorIs(t, err, ErrExpiredToken)
+	})
+}
+
+func TestUnitAuth_CreateUser(t *testing.T) {
+	ana := internal.User{Username: "ana", Role: internal.RoleSeller, SellerID: 7}
+
+	t.Run("saves the hash of the password", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("Create", mock.AnythingOfType("*internal.User")).Run(func(args mock.Arguments) {
+			args.Get(0).(*internal.User).ID = 4
+		}).Return(nil)
+
+		service := newTestAuthService(t, users)
+
+		user, err := service.CreateUser(context.Background(), ana, "s3cret-password")
+		require.NoError(t, err)
+		require.Equal(t, "ana", user.Username)
+		require.NotContains(t, user.PasswordHash, "s3cret-password")
+		require.True(t, CheckPassword(user.PasswordHash, "s3cret-password"))
+
+		entries := service.uow.(*mockUnitOfWork).entries
+		require.Len(t, entries, 1)
+		require.Equal(t, internal.AuditCreate, entries[0].Action)
+		require.Equal(t, auditEntity, entries[0].Entity)
+		require.Equal(t, 4, entries[0].EntityID)
+		require.NotContains(t, entries[0].Changes, "password_hash")
+	})
+
+	t.Run("saves the warehouses of an operator", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("Create", mock.AnythingOfType("*internal.User")).Return(nil)
+
+		operator := internal.User{Username: "ana", Role: internal.RoleWarehouseOperator, WarehouseIDs: []int{2}}
+
+		user, err := newTestAuthService(t, users).CreateUser(context.Background(), operator, "s3cret-password")
+		require.NoError(t, err)
+		require.Equal(t, []int{2}, user.WarehouseIDs)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		service := newTestAuthService(t, new(mockUserRepository))
+
+		_, err := service.CreateUser(context.Background(), internal.User{Username: "ana"}, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+
+		_, err = service.CreateUser(context.Background(), internal.User{Username: "ana", Role: "owner"}, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("seller or buyer without its owner", func(t *testing.T) {
+		service := newTestAuthService(t, new(mockUserRepository))
+
+		_, err := service.CreateUser(context.Background(), internal.User{Username: "ana", Role: internal.RoleSeller}, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+
+		_, err = service.CreateUser(context.Background(), internal.User{Username: "ana", Role: internal.RoleBuyer}, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("owner of a role that is not a seller or buyer", func(t *testing.T) {
+		analyst := internal.User{Username: "ana", Role: internal.RoleAnalyst, BuyerID: 2}
+
+		_, err := newTestAuthService(t, new(mockUserRepository)).CreateUser(context.Background(), analyst, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("warehouses of a role that is not an operator", func(t *testing.T) {
+		seller := internal.User{Username: "ana", Role: internal.RoleSeller, WarehouseIDs: []int{2}}
+
+		_, err := newTestAuthService(t, new(mockUserRepository)).CreateUser(context.Background(), seller, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("short password", func(t *testing.T) {
+		_, err := newTestAuthService(t, new(mockUserRepository)).CreateUser(context.Background(), ana, "short")
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("username taken", func(t *testing.T) {
+		users := new(mockUserRepository)
+		users.On("Create", mock.AnythingOfType("*internal.User")).Return(utils.EConflict("user", "username"))
+
+		service := newTestAuthService(t, users)
+
+		_, err := service.CreateUser(context.Background(), ana, "s3cret-password")
+		require.ErrorIs(t, err, utils.ErrConflict)
+		require.Empty(t, service.uow.(*mockUnitOfWork).entries)
+	})
+}
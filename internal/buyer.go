@@ -1,5 +1,12 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type BuyerAttributes struct {
 	CardNumberID string `json:"card_number_id"`
 	FirstName    string `json:"first_name"`
@@ -9,20 +16,35 @@ type BuyerAttributes struct {
 type Buyer struct {
 	ID int64 `json:"id"`
 	BuyerAttributes
+	// DeletedAt is the time the buyer was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is bumped by every change, it is the ETag of the buyer
+	Version int `json:"version,omitempty"`
 }
 
 type BuyerService interface {
-	GetAll() ([]Buyer, error)
-	GetOne(id int) (*Buyer, error)
-	CreateBuyer(BuyerAttributes) (*Buyer, error)
-	UpdateBuyer(*Buyer) (*Buyer, error)
-	DeleteBuyer(id int) error
+	GetAll(ctx context.Context) ([]Buyer, error)
+	List(ctx context.Context, query utils.ListQuery) ([]Buyer, utils.Pagination, error)
+	GetOne(ctx context.Context, id int) (*Buyer, error)
+	CreateBuyer(context.Context, BuyerAttributes) (*Buyer, error)
+	UpdateBuyer(context.Context, *Buyer) (*Buyer, error)
+	// DeleteBuyer soft deletes a buyer in the version given, it is left out of the listings until it is restored
+	DeleteBuyer(ctx context.Context, id int, version int) error
+	// RestoreBuyer brings back a soft deleted buyer
+	RestoreBuyer(ctx context.Context, id int) (*Buyer, error)
+	// PurgeBuyers removes for good the buyers deleted before a time, returns how many were removed
+	PurgeBuyers(ctx context.Context, before time.Time) (int, error)
 }
 
 type BuyerRepository interface {
-	GetAll() ([]Buyer, error)
-	GetOne(id int) (*Buyer, error)
-	CreateBuyer(Buyer) (*Buyer, error)
-	UpdateBuyer(*Buyer) (*Buyer, error)
-	DeleteBuyer(id int) error
+	GetAll(ctx context.Context) ([]Buyer, error)
+	// List returns the buyers of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) ([]Buyer, error)
+	GetOne(ctx context.Context, id int) (*Buyer, error)
+	CreateBuyer(context.Context, Buyer) (*Buyer, error)
+	UpdateBuyer(context.Context, *Buyer) (*Buyer, error)
+	// DeleteBuyer soft deletes a buyer when it is still in the version it was read
+	DeleteBuyer(ctx context.Context, id int, version int) error
+	RestoreBuyer(ctx context.Context, id int) error
+	PurgeBuyers(ctx context.Context, before time.Time) (int, error)
 }
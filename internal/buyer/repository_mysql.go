@@ -1,25 +1,62 @@
 package buyer
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the buyers can be filtered and sorted by
+var listFields = utils.ListFields{
+	"id":             {Column: "id", Kind: utils.ListInt, Sortable: true},
+	"card_number_id": {Column: "id_card_number", Kind: utils.ListString, Sortable: true},
+	"first_name":     {Column: "first_name", Kind: utils.ListString, Sortable: true},
+	"last_name":      {Column: "last_name", Kind: utils.ListString, Sortable: true},
+}
+
 type BuyerRepo struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewBuyerDB(db *sql.DB) *BuyerRepo {
+func NewBuyerDB(db utils.DBTX) *BuyerRepo {
 	return &BuyerRepo{db}
 }
 
-func (repo *BuyerRepo) GetAll() ([]internal.Buyer, error) {
-	query := "SELECT id, id_card_number, first_name, last_name FROM buyers"
+func (repo *BuyerRepo) GetAll(ctx context.Context) ([]internal.Buyer, error) {
+	query := "SELECT id, id_card_number, first_name, last_name, version FROM buyers WHERE deleted_at IS NULL"
+
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var buyers []internal.Buyer
+
+	for rows.Next() {
+		var b internal.Buyer
+
+		err := rows.Scan(&b.ID, &b.CardNumberID, &b.FirstName, &b.LastName, &b.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		buyers = append(buyers, b)
+	}
+
+	return buyers, rows.Err()
+}
+
+// List returns a page of buyers
+func (repo *BuyerRepo) List(ctx context.Context, query utils.ListQuery) ([]internal.Buyer, error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition(), internal.BuyerCondition(ctx, "id"))
 
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.QueryContext(ctx, "SELECT id, id_card_number, first_name, last_name, deleted_at, version FROM buyers"+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +68,7 @@ func (repo *BuyerRepo) GetAll() ([]internal.Buyer, error) {
 	for rows.Next() {
 		var b internal.Buyer
 
-		err := rows.Scan(&b.ID, &b.CardNumberID, &b.FirstName, &b.LastName)
+		err = rows.Scan(&b.ID, &b.CardNumberID, &b.FirstName, &b.LastName, &b.DeletedAt, &b.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -42,13 +79,13 @@ func (repo *BuyerRepo) GetAll() ([]internal.Buyer, error) {
 	return buyers, rows.Err()
 }
 
-func (repo *BuyerRepo) GetOne(id int) (*internal.Buyer, error) {
-	query := "SELECT id, id_card_number, first_name, last_name FROM buyers WHERE id = ?"
-	row := repo.db.QueryRow(query, id)
+func (repo *BuyerRepo) GetOne(ctx context.Context, id int) (*internal.Buyer, error) {
+	query := "SELECT id, id_card_number, first_name, last_name, version FROM buyers WHERE id = ? AND deleted_at IS NULL AND " + internal.BuyerCondition(ctx, "id")
+	row := repo.db.QueryRowContext(ctx, query, id)
 
 	var buyer internal.Buyer
 
-	err := row.Scan(&buyer.ID, &buyer.CardNumberID, &buyer.FirstName, &buyer.LastName)
+	err := row.Scan(&buyer.ID, &buyer.CardNumberID, &buyer.FirstName, &buyer.LastName, &buyer.Version)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, utils.ErrNotFound
 	}
@@ -60,10 +97,10 @@ func (repo *BuyerRepo) GetOne(id int) (*internal.Buyer, error) {
 	return &buyer, nil
 }
 
-func (repo *BuyerRepo) CreateBuyer(newBuyer internal.Buyer) (*internal.Buyer, error) {
+func (repo *BuyerRepo) CreateBuyer(ctx context.Context, newBuyer internal.Buyer) (*internal.Buyer, error) {
 	query := "INSERT INTO buyers (id_card_number, first_name, last_name) VALUES (?, ?, ?)"
 
-	result, err := repo.db.Exec(query, newBuyer.CardNumberID, newBuyer.FirstName, newBuyer.LastName)
+	result, err := repo.db.ExecContext(ctx, query, newBuyer.CardNumberID, newBuyer.FirstName, newBuyer.LastName)
 	if err != nil {
 		return nil, err
 	}
@@ -74,37 +111,41 @@ func (repo *BuyerRepo) CreateBuyer(newBuyer internal.Buyer) (*internal.Buyer, er
 	}
 
 	newBuyer.ID = insertedID
+	newBuyer.Version = 1
 
 	return &newBuyer, nil
 }
 
-func (repo *BuyerRepo) UpdateBuyer(updatedBuyer *internal.Buyer) (*internal.Buyer, error) {
-	query := "UPDATE buyers SET id_card_number = ?, first_name = ?, last_name = ? WHERE id = ?"
+// UpdateBuyer updates a buyer when it is still in the version it was read, and bumps its version
+func (repo *BuyerRepo) UpdateBuyer(ctx context.Context, updatedBuyer *internal.Buyer) (*internal.Buyer, error) {
+	query := "UPDATE buyers SET id_card_number = ?, first_name = ?, last_name = ?, version = version + 1 WHERE id = ? AND version = ?"
 
-	_, err := repo.db.Exec(query, updatedBuyer.CardNumberID, updatedBuyer.FirstName, updatedBuyer.LastName, updatedBuyer.ID)
+	result, err := repo.db.ExecContext(ctx, query, updatedBuyer.CardNumberID, updatedBuyer.FirstName, updatedBuyer.LastName, updatedBuyer.ID, updatedBuyer.Version)
 	if err != nil {
 		return nil, err
 	}
 
-	return updatedBuyer, nil
-}
-
-func (repo *BuyerRepo) DeleteBuyer(id int) error {
-	query := "DELETE FROM buyers WHERE id = ?"
-
-	result, err := repo.db.Exec(query, id)
+	err = utils.CheckVersionUpdated(result, "buyer")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	updatedBuyer.Version++
 
-	if rowsAffected == 0 {
-		return utils.ErrNotFound
-	}
+	return updatedBuyer, nil
+}
+
+// DeleteBuyer soft deletes a buyer still in the version it was read, its row is kept with the time it was deleted
+func (repo *BuyerRepo) DeleteBuyer(ctx context.Context, id int, version int) error {
+	return utils.SoftDelete(ctx, repo.db, "buyers", "buyer", id, version, internal.BuyerCondition(ctx, "id"))
+}
+
+// RestoreBuyer clears the deletion of a soft deleted buyer
+func (repo *BuyerRepo) RestoreBuyer(ctx context.Context, id int) error {
+	return utils.Restore(ctx, repo.db, "buyers", id, internal.BuyerCondition(ctx, "id"))
+}
 
-	return nil
+// PurgeBuyers removes for good the buyers deleted before a time, but the ones other records still refer to
+func (repo *BuyerRepo) PurgeBuyers(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, repo.db, "buyers", before)
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -16,11 +17,12 @@ func BuyerRoutes(mux *chi.Mux, service internal.BuyerService) error {
 	buyerHandler := handler.NewBuyerHandler(service)
 
 	mux.Route("/api/v1/buyers", func(router chi.Router) {
-		router.Get("/", buyerHandler.GetAll())
-		router.Get("/{id}", buyerHandler.GetOne())
-		router.Post("/", buyerHandler.CreateBuyer())
-		router.Patch("/{id}", buyerHandler.UpdateBuyer())
-		router.Delete("/{id}", buyerHandler.DeleteBuyer())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionRead))).Get("/", buyerHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionRead))).Get("/{id}", buyerHandler.GetOne())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionWrite))).Post("/", buyerHandler.CreateBuyer())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionWrite))).Patch("/{id}", buyerHandler.UpdateBuyer())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionDelete))).Delete("/{id}", buyerHandler.DeleteBuyer())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceBuyers, rbac.ActionWrite))).Post("/{id}/restore", buyerHandler.RestoreBuyer())
 	})
 
 	return nil
@@ -1,28 +1,54 @@
 package buyer
 
 import (
+	"context"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the buyers in the audit trail
+const auditEntity = "buyers"
+
 type BuyerService struct {
 	repo internal.BuyerRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewBuyer(repo internal.BuyerRepository) *BuyerService {
-	return &BuyerService{repo: repo}
+func NewBuyer(repo internal.BuyerRepository, uow internal.UnitOfWork) *BuyerService {
+	return &BuyerService{repo: repo, uow: uow}
 }
 
-func (service *BuyerService) GetAll() (buyer []internal.Buyer, err error) {
-	buyer, err = service.repo.GetAll()
+func (service *BuyerService) GetAll(ctx context.Context) (buyer []internal.Buyer, err error) {
+	buyer, err = service.repo.GetAll(ctx)
 	return buyer, err
 }
 
-func (service *BuyerService) GetOne(id int) (*internal.Buyer, error) {
-	buyers, err := service.GetAll()
+// List returns a page of buyers filtered and sorted as asked by the query
+func (service *BuyerService) List(ctx context.Context, query utils.ListQuery) ([]internal.Buyer, utils.Pagination, error) {
+	err := query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	buyers, err := service.repo.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	buyers, pagination := utils.Paginate(buyers, query)
+
+	return buyers, pagination, nil
+}
+
+func (service *BuyerService) GetOne(ctx context.Context, id int) (*internal.Buyer, error) {
+	buyers, err := service.GetAll(ctx)
 
 	if err != nil {
 		log.Println("Error in GetAll - ", err)
@@ -38,8 +64,8 @@ func (service *BuyerService) GetOne(id int) (*internal.Buyer, error) {
 	return nil, err
 }
 
-func (service *BuyerService) CreateBuyer(buyer internal.BuyerAttributes) (*internal.Buyer, error) {
-	buyers, err := service.GetAll()
+func (service *BuyerService) CreateBuyer(ctx context.Context, buyer internal.BuyerAttributes) (*internal.Buyer, error) {
+	buyers, err := service.GetAll(ctx)
 
 	if err != nil {
 		log.Println("Error to load - ", err)
@@ -57,16 +83,30 @@ func (service *BuyerService) CreateBuyer(buyer internal.BuyerAttributes) (*inter
 		},
 	}
 
-	err = service.validation(newBuyer)
+	err = service.validation(ctx, newBuyer)
 	if err != nil {
 		return nil, err
 	}
 
-	return service.repo.CreateBuyer(newBuyer)
+	var created *internal.Buyer
+
+	err = service.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		created, err = repos.Buyers.CreateBuyer(ctx, newBuyer)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, int(created.ID), nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
 }
 
-func (service *BuyerService) UpdateBuyer(updatedBuyer *internal.Buyer) (*internal.Buyer, error) {
-	buyers, err := service.GetAll()
+func (service *BuyerService) UpdateBuyer(ctx context.Context, updatedBuyer *internal.Buyer) (*internal.Buyer, error) {
+	buyers, err := service.GetAll(ctx)
 
 	if err != nil {
 		log.Println("Error internal - ", err)
@@ -84,6 +124,12 @@ func (service *BuyerService) UpdateBuyer(updatedBuyer *internal.Buyer) (*interna
 		return nil, utils.ErrNotFound
 	}
 
+	if err = utils.CheckVersion("buyer", updatedBuyer.Version, buyerFound.Version); err != nil {
+		return nil, err
+	}
+
+	updatedBuyer.Version = buyerFound.Version
+
 	for _, buyer := range buyers {
 		if buyer.CardNumberID == updatedBuyer.CardNumberID {
 			log.Println("Error Card number already exist in our system")
@@ -91,32 +137,87 @@ func (service *BuyerService) UpdateBuyer(updatedBuyer *internal.Buyer) (*interna
 		}
 	}
 
-	return service.repo.UpdateBuyer(updatedBuyer)
-}
+	var updated *internal.Buyer
 
-func (service *BuyerService) DeleteBuyer(id int) error {
-	buyers, err := service.GetAll()
+	err = service.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		updated, err = repos.Buyers.UpdateBuyer(ctx, updatedBuyer)
+		if err != nil {
+			return err
+		}
 
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, int(updated.ID), buyerFound, updated)
+	})
 	if err != nil {
-		log.Println("Error in GetAll - ", err)
-		return err
+		return nil, err
 	}
 
-	for _, buyer := range buyers {
-		if int(buyer.ID) == id {
-			if err := service.repo.DeleteBuyer(id); err != nil {
-				return err
+	return updated, nil
+}
+
+func (service *BuyerService) DeleteBuyer(ctx context.Context, id int, version int) error {
+	return service.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		buyer, err := repos.Buyers.GetOne(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = utils.CheckVersion("buyer", version, buyer.Version); err != nil {
+			return err
+		}
+
+		if err = repos.Buyers.DeleteBuyer(ctx, id, buyer.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, *buyer, nil)
+	})
+}
+
+// RestoreBuyer brings back a soft deleted buyer
+func (service *BuyerService) RestoreBuyer(ctx context.Context, id int) (restored *internal.Buyer, err error) {
+	err = service.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		err := repos.Buyers.RestoreBuyer(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.ENotFound("Deleted buyer")
 			}
 
-			return nil
+			return err
 		}
+
+		restored, err = repos.Buyers.GetOne(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// PurgeBuyers removes for good the buyers deleted before a time
+func (service *BuyerService) PurgeBuyers(ctx context.Context, before time.Time) (purged int, err error) {
+	err = service.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		purged, err = repos.Buyers.PurgeBuyers(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return err
+	return purged, nil
 }
 
-func (service *BuyerService) validation(newBuyer internal.Buyer) error {
-	buyers, err := service.repo.GetAll()
+func (service *BuyerService) validation(ctx context.Context, newBuyer internal.Buyer) error {
+	buyers, err := service.repo.GetAll(ctx)
 
 	if err != nil {
 		log.Println("Error in load -", err)
@@ -1,7 +1,9 @@
 package buyer
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
@@ -9,35 +11,83 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.BuyerRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Buyers: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type BuyerRepositoryMock struct {
 	mock.Mock
 }
 
-func (m *BuyerRepositoryMock) GetAll() ([]internal.Buyer, error) {
+func (m *BuyerRepositoryMock) GetAll(ctx context.Context) ([]internal.Buyer, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerRepositoryMock) GetOne(id int) (*internal.Buyer, error) {
+func (m *BuyerRepositoryMock) List(ctx context.Context, query utils.ListQuery) ([]internal.Buyer, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Buyer), args.Error(1)
+}
+
+func (m *BuyerRepositoryMock) GetOne(ctx context.Context, id int) (*internal.Buyer, error) {
 	args := m.Called(id)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerRepositoryMock) CreateBuyer(buyer internal.Buyer) (*internal.Buyer, error) {
+func (m *BuyerRepositoryMock) CreateBuyer(ctx context.Context, buyer internal.Buyer) (*internal.Buyer, error) {
 	args := m.Called(buyer)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerRepositoryMock) UpdateBuyer(buyer *internal.Buyer) (*internal.Buyer, error) {
+func (m *BuyerRepositoryMock) UpdateBuyer(ctx context.Context, buyer *internal.Buyer) (*internal.Buyer, error) {
 	args := m.Called(buyer)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
 
-func (m *BuyerRepositoryMock) DeleteBuyer(id int) error {
+func (m *BuyerRepositoryMock) DeleteBuyer(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *BuyerRepositoryMock) RestoreBuyer(ctx context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *BuyerRepositoryMock) PurgeBuyers(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 func TestBuyerService_GetAll(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -89,9 +139,9 @@ func TestBuyerService_GetAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.mockRepo()
-			service := NewBuyer(repo)
+			service := NewBuyer(repo, newMockUnitOfWork(repo))
 
-			got, err := service.GetAll()
+			got, err := service.GetAll(context.Background())
 			require.Equal(t, tt.expectedErr, err)
 			require.Equal(t, tt.expected, got)
 		})
@@ -150,9 +200,9 @@ func TestBuyerService_GetOne(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.mockRepo()
-			service := NewBuyer(repo)
+			service := NewBuyer(repo, newMockUnitOfWork(repo))
 
-			got, err := service.GetOne(tt.id)
+			got, err := service.GetOne(context.Background(), tt.id)
 			require.Equal(t, tt.expectedErr, err)
 			require.Equal(t, tt.expected, got)
 		})
@@ -164,6 +214,7 @@ func TestUnitBuyerService_Delete(t *testing.T) {
 		name        string
 		mockRepo    func() *BuyerRepositoryMock
 		id          int
+		version     int
 		wantErr     bool
 		expectedErr error
 	}{
@@ -171,37 +222,51 @@ func TestUnitBuyerService_Delete(t *testing.T) {
 			name: "Delete one buyer",
 			mockRepo: func() *BuyerRepositoryMock {
 				m := &BuyerRepositoryMock{}
-				m.On("GetAll").Return([]internal.Buyer{
-					{
-						ID: 1,
-						BuyerAttributes: internal.BuyerAttributes{
-							CardNumberID: "123456789",
-							FirstName:    "John",
-							LastName:     "Doe",
-						},
+				m.On("GetOne", 1).Return(&internal.Buyer{
+					ID: 1,
+					BuyerAttributes: internal.BuyerAttributes{
+						CardNumberID: "123456789",
+						FirstName:    "John",
+						LastName:     "Doe",
 					},
+					Version: 2,
 				}, nil)
-				m.On("DeleteBuyer", 1).Return(nil)
+				m.On("DeleteBuyer", 1, 2).Return(nil)
 				return m
 			},
 			id:      1,
+			version: 2,
 			wantErr: false,
 		},
+		{
+			name: "Delete one buyer in any version",
+			mockRepo: func() *BuyerRepositoryMock {
+				m := &BuyerRepositoryMock{}
+				m.On("GetOne", 1).Return(&internal.Buyer{ID: 1, Version: 2}, nil)
+				m.On("DeleteBuyer", 1, 2).Return(nil)
+				return m
+			},
+			id:      1,
+			version: utils.AnyVersion,
+			wantErr: false,
+		},
+		{
+			name: "Error to delete a changed buyer",
+			mockRepo: func() *BuyerRepositoryMock {
+				m := &BuyerRepositoryMock{}
+				m.On("GetOne", 1).Return(&internal.Buyer{ID: 1, Version: 2}, nil)
+				return m
+			},
+			id:          1,
+			version:     1,
+			wantErr:     true,
+			expectedErr: utils.EPreconditionFailed("buyer"),
+		},
 		{
 			name: "Error to delete one buyer",
 			mockRepo: func() *BuyerRepositoryMock {
 				m := &BuyerRepositoryMock{}
-				m.On("GetAll").Return([]internal.Buyer{
-					{
-						ID: 1,
-						BuyerAttributes: internal.BuyerAttributes{
-							CardNumberID: "123456789",
-							FirstName:    "John",
-							LastName:     "Doe",
-						},
-					},
-				}, nil)
-				m.On("DeleteBuyer", 1).Return(utils.ErrNotFound)
+				m.On("GetOne", 1).Return((*internal.Buyer)(nil), utils.ErrNotFound)
 				return m
 			},
 			id:          1,
@@ -212,9 +277,9 @@ func TestUnitBuyerService_Delete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.mockRepo()
-			service := NewBuyer(repo)
+			service := NewBuyer(repo, newMockUnitOfWork(repo))
 
-			err := service.DeleteBuyer(tt.id)
+			err := service.DeleteBuyer(context.Background(), tt.id, tt.version)
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
@@ -304,9 +369,9 @@ func TestUnitBuyerService_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.mockRepo()
-			service := NewBuyer(repo)
+			service := NewBuyer(repo, newMockUnitOfWork(repo))
 
-			_, err := service.CreateBuyer(tt.buyer)
+			_, err := service.CreateBuyer(context.Background(), tt.buyer)
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
@@ -425,10 +490,31 @@ func TestUnitBuyerService_Update(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := tt.mockRepo()
-			service := NewBuyer(repo)
+			service := NewBuyer(repo, newMockUnitOfWork(repo))
 
-			_, err := service.UpdateBuyer(&tt.buyer)
+			_, err := service.UpdateBuyer(context.Background(), &tt.buyer)
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
+
+func TestUnitBuyerService_Audit(t *testing.T) {
+	buyer := internal.Buyer{ID: 1, BuyerAttributes: internal.BuyerAttributes{CardNumberID: "123456789", FirstName: "John", LastName: "Doe"}}
+
+	repo := &BuyerRepositoryMock{}
+	repo.On("GetOne", 1).Return(&buyer, nil)
+	repo.On("DeleteBuyer", 1, 0).Return(nil)
+
+	uow := newMockUnitOfWork(repo)
+	service := NewBuyer(repo, uow)
+
+	err := service.DeleteBuyer(internal.WithPrincipal(context.Background(), internal.Principal{Username: "ana"}), 1, utils.AnyVersion)
+	require.NoError(t, err)
+	require.Len(t, uow.entries, 1)
+	require.Equal(t, "ana", uow.entries[0].Actor)
+	require.Equal(t, internal.AuditDelete, uow.entries[0].Action)
+	require.Equal(t, "buyers", uow.entries[0].Entity)
+	require.Equal(t, 1, uow.entries[0].EntityID)
+	require.JSONEq(t, `"Doe"`, string(uow.entries[0].Changes["last_name"].Before))
+	require.Nil(t, uow.entries[0].Changes["last_name"].After)
+}
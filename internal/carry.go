@@ -1,5 +1,12 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type Carry struct {
 	ID          int    `json:"id"`
 	CID         int    `json:"cid"`
@@ -7,24 +14,50 @@ type Carry struct {
 	Address     string `json:"address"`
 	Telephone   string `json:"telephone"`
 	LocalityID  int    `json:"locality_id"`
+	// DeletedAt is the time the carry was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is bumped by every change, it is the ETag of the carry
+	Version int `json:"version,omitempty"`
 }
 
 type CarryRepository interface {
-	Save(*Carry) error
-	GetAll() ([]Carry, error)
-	GetByID(id int) (Carry, error)
-	Update(*Carry) error
-	Delete(id int) error
+	Save(context.Context, *Carry) error
+	GetAll(ctx context.Context) ([]Carry, error)
+	// List returns the carries of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) ([]Carry, error)
+	GetByID(ctx context.Context, id int) (Carry, error)
+	Update(context.Context, *Carry) error
+	// Delete soft deletes a carry when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) error
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// CarryIncludeLocality embeds the locality of a carry in its responses
+const CarryIncludeLocality = "locality"
+
+// CarryRelations are the entities related to a carry embedded in its responses with include
+type CarryRelations struct {
+	Locality *Locality `json:"locality,omitempty"`
 }
 
 type CarryService interface {
-	Save(*Carry) error
-	GetAll() ([]Carry, error)
-	GetByID(id int) (Carry, error)
-	Update(*Carry) error
-	Delete(id int) error
+	Save(context.Context, *Carry) error
+	GetAll(ctx context.Context) ([]Carry, error)
+	List(ctx context.Context, query utils.ListQuery) ([]Carry, utils.Pagination, error)
+	GetByID(ctx context.Context, id int) (Carry, error)
+	// GetRelations returns the entities related to each carry that were asked by include
+	GetRelations(ctx context.Context, carries []Carry, include []string) ([]CarryRelations, error)
+	Update(context.Context, *Carry) error
+	// Delete soft deletes a carry in the version given, it is left out of the listings but kept until purged
+	Delete(ctx context.Context, id int, version int) error
+	// Restore brings back a soft deleted carry
+	Restore(ctx context.Context, id int) (Carry, error)
+	// Purge removes for good the carries deleted before a time, returns how many were removed
+	Purge(ctx context.Context, before time.Time) (int, error)
 }
 
 type LocalityValidation interface {
-	GetByID(id int) (Locality, error)
+	GetByID(ctx context.Context, id int) (Locality, error)
+	GetByIDs(ctx context.Context, ids []int) ([]Locality, error)
 }
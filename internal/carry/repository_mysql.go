@@ -1,16 +1,28 @@
 package carry
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the carries can be filtered and sorted by
+var listFields = utils.ListFields{
+	"id":           {Column: "id", Kind: utils.ListInt, Sortable: true},
+	"cid":          {Column: "cid", Kind: utils.ListInt, Sortable: true},
+	"company_name": {Column: "company_name", Kind: utils.ListString, Sortable: true},
+	"address":      {Column: "address", Kind: utils.ListString, Sortable: true},
+	"telephone":    {Column: "telephone", Kind: utils.ListString, Sortable: true},
+	"locality_id":  {Column: "locality_id", Kind: utils.ListInt, Sortable: true},
+}
+
 type MySQLCarryRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
 // NewMySQLCarryRepository creates a new instance of MySQLCarryRepository with the given database connection.
@@ -21,7 +33,7 @@ type MySQLCarryRepository struct {
 //
 // Returns:
 //   - A pointer to a MySQLCarryRepository.
-func NewMySQLCarryRepository(db *sql.DB) *MySQLCarryRepository {
+func NewMySQLCarryRepository(db utils.DBTX) *MySQLCarryRepository {
 	return &MySQLCarryRepository{db: db}
 }
 
@@ -35,14 +47,14 @@ func NewMySQLCarryRepository(db *sql.DB) *MySQLCarryRepository {
 // Returns:
 //   - error: An error if any occurs during the insertion process. If a conflict error (duplicate entry) occurs,
 //     it returns a predefined conflict error.
-func (r *MySQLCarryRepository) Save(carry *internal.Carry) error {
+func (r *MySQLCarryRepository) Save(ctx context.Context, carry *internal.Carry) error {
 	// Prepare statement for inserting data
-	stmt, err := r.db.Prepare("INSERT INTO carriers(cid, company_name, address, telephone, locality_id) VALUES(?, ?, ?, ?, ?);")
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO carriers(cid, company_name, address, telephone, locality_id) VALUES(?, ?, ?, ?, ?);")
 	if err != nil {
 		return err
 	}
 	// execute the statement
-	result, err := stmt.Exec(carry.CID, carry.CompanyName, carry.Address, carry.Telephone, carry.LocalityID)
+	result, err := stmt.ExecContext(ctx, carry.CID, carry.CompanyName, carry.Address, carry.Telephone, carry.LocalityID)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -60,6 +72,7 @@ func (r *MySQLCarryRepository) Save(carry *internal.Carry) error {
 	}
 
 	carry.ID = int(id)
+	carry.Version = 1
 
 	return nil
 }
@@ -67,8 +80,8 @@ func (r *MySQLCarryRepository) Save(carry *internal.Carry) error {
 // GetAll retrieves all carriers from the database.
 // It returns a slice of Carry objects and an error if any occurs during the query execution or row scanning.
 // If no carriers are found, it returns an empty slice.
-func (r *MySQLCarryRepository) GetAll() ([]internal.Carry, error) {
-	rows, err := r.db.Query("SELECT id, cid, company_name, address, telephone, locality_id FROM carriers;")
+func (r *MySQLCarryRepository) GetAll(ctx context.Context) ([]internal.Carry, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, cid, company_name, address, telephone, locality_id, version FROM carriers WHERE deleted_at IS NULL;")
 	if err != nil {
 		return []internal.Carry{}, utils.ENotFound("Carry")
 	}
@@ -79,7 +92,7 @@ func (r *MySQLCarryRepository) GetAll() ([]internal.Carry, error) {
 	for rows.Next() {
 		var carry internal.Carry
 
-		err := rows.Scan(&carry.ID, &carry.CID, &carry.CompanyName, &carry.Address, &carry.Telephone, &carry.LocalityID)
+		err := rows.Scan(&carry.ID, &carry.CID, &carry.CompanyName, &carry.Address, &carry.Telephone, &carry.LocalityID, &carry.Version)
 		if err != nil {
 			return []internal.Carry{}, err
 		}
@@ -90,6 +103,34 @@ func (r *MySQLCarryRepository) GetAll() ([]internal.Carry, error) {
 	return carries, nil
 }
 
+// List retrieves a page of carrier records, filtered and sorted as asked by a resolved list query.
+// It reads one more record than the limit of the query when there are more.
+func (r *MySQLCarryRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Carry, error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition())
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, cid, company_name, address, telephone, locality_id, deleted_at, version FROM carriers"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	carries := []internal.Carry{}
+
+	for rows.Next() {
+		var carry internal.Carry
+
+		err = rows.Scan(&carry.ID, &carry.CID, &carry.CompanyName, &carry.Address, &carry.Telephone, &carry.LocalityID, &carry.DeletedAt, &carry.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		carries = append(carries, carry)
+	}
+
+	return carries, rows.Err()
+}
+
 // GetByID retrieves a carrier record from the database by its ID.
 // It returns an internal.Carry object and an error if any occurs during the process.
 // If the carrier with the specified ID is not found, it returns a utils.ErrNotFound error.
@@ -100,17 +141,17 @@ func (r *MySQLCarryRepository) GetAll() ([]internal.Carry, error) {
 // Returns:
 //   - internal.Carry: The carrier object retrieved from the database.
 //   - error: An error object if any error occurs, including sql.ErrNoRows if the carrier is not found.
-func (r *MySQLCarryRepository) GetByID(id int) (internal.Carry, error) {
-	stmt, err := r.db.Prepare("SELECT id, cid, company_name, address, telephone, locality_id FROM carriers WHERE id=?;")
+func (r *MySQLCarryRepository) GetByID(ctx context.Context, id int) (internal.Carry, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, cid, company_name, address, telephone, locality_id, version FROM carriers WHERE id=? AND deleted_at IS NULL;")
 	if err != nil {
 		return internal.Carry{}, err
 	}
 
-	row := stmt.QueryRow(id)
+	row := stmt.QueryRowContext(ctx, id)
 
 	var carry internal.Carry
 
-	err = row.Scan(&carry.ID, &carry.CID, &carry.CompanyName, &carry.Address, &carry.Telephone, &carry.LocalityID)
+	err = row.Scan(&carry.ID, &carry.CID, &carry.CompanyName, &carry.Address, &carry.Telephone, &carry.LocalityID, &carry.Version)
 	if err !=
 		nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -126,18 +167,20 @@ func (r *MySQLCarryRepository) GetByID(id int) (internal.Carry, error) {
 // Update updates an existing carrier record in the database.
 // It takes a pointer to a Carry struct as input and returns an error if the update fails.
 // If the update fails due to a duplicate entry (MySQL error 1062), it returns a conflict error.
+// Only the version the carrier was read in is updated, its version is bumped, and if it was
+// changed since it returns a precondition failed error.
 // Parameters:
 //   - carry: a pointer to the Carry struct containing the updated carrier information.
 //
 // Returns:
 //   - error: an error if the update fails, otherwise nil.
-func (r *MySQLCarryRepository) Update(carry *internal.Carry) error {
-	stmt, err := r.db.Prepare("UPDATE carriers SET cid=?, company_name=?, address=?, telephone=?, locality_id=? WHERE id=?;")
+func (r *MySQLCarryRepository) Update(ctx context.Context, carry *internal.Carry) error {
+	stmt, err := r.db.PrepareContext(ctx, "UPDATE carriers SET cid=?, company_name=?, address=?, telephone=?, locality_id=?, version=version+1 WHERE id=? AND version=?;")
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(carry.CID, carry.CompanyName, carry.Address, carry.Telephone, carry.LocalityID, carry.ID)
+	result, err := stmt.ExecContext(ctx, carry.CID, carry.CompanyName, carry.Address, carry.Telephone, carry.LocalityID, carry.ID, carry.Version)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -149,36 +192,48 @@ func (r *MySQLCarryRepository) Update(carry *internal.Carry) error {
 		return err
 	}
 
+	err = utils.CheckVersionUpdated(result, "carry")
+	if err != nil {
+		return err
+	}
+
+	carry.Version++
+
 	return nil
 }
 
-// Delete removes a carrier record from the database by its ID.
-// It first checks if the carrier exists by calling GetByID.
-// If the carrier does not exist or an error occurs during the check, it returns an error.
-// If the carrier exists, it prepares a DELETE SQL statement and executes it.
-// If any error occurs during the preparation or execution of the statement, it returns an error.
-// Otherwise, it returns nil indicating the deletion was successful.
+// Delete soft deletes a carrier record by its ID, the row is kept with the time it was deleted.
+// The carrier must still be in the version it was read.
 //
 // Parameters:
 //   - id: The ID of the carrier to be deleted.
+//   - version: The version the carrier was read in.
 //
 // Returns:
-//   - error: An error if the carrier does not exist or if there is an issue with the database operation, otherwise nil.
-func (r *MySQLCarryRepository) Delete(id int) error {
-	_, err := r.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	stmt, err := r.db.Prepare("DELETE FROM carriers WHERE id=?;")
-	if err != nil {
-		return err
-	}
+//   - error: A precondition failed error if the carrier was changed or deleted since it was read, or the error of the query.
+func (r *MySQLCarryRepository) Delete(ctx context.Context, id int, version int) error {
+	return utils.SoftDelete(ctx, r.db, "carriers", "carry", id, version)
+}
 
-	_, err = stmt.Exec(id)
-	if err != nil {
-		return err
-	}
+// Restore clears the deletion of a soft deleted carrier record.
+//
+// Parameters:
+//   - id: The ID of the carrier to be restored.
+//
+// Returns:
+//   - error: utils.ErrNotFound when there is no deleted carrier with the ID, or the error of the query.
+func (r *MySQLCarryRepository) Restore(ctx context.Context, id int) error {
+	return utils.Restore(ctx, r.db, "carriers", id)
+}
 
-	return nil
+// Purge removes for good the carrier records deleted before a time, the ones other records still refer to are kept.
+//
+// Parameters:
+//   - before: The time the carriers must have been deleted before.
+//
+// Returns:
+//   - int: The number of carriers removed.
+//   - error: An error if any of the queries fails.
+func (r *MySQLCarryRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, r.db, "carriers", before)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -18,11 +19,12 @@ func CarryRoutes(mux *chi.Mux, service internal.CarryService) error {
 	carryHandler := handler.NewCarryHandler(service)
 
 	mux.Route("/api/v1/carries", func(router chi.Router) {
-		router.Post("/", carryHandler.SaveCarry())
-		router.Get("/", carryHandler.GetAllCarries())
-		router.Get("/{id}", carryHandler.GetCarryByID())
-		router.Patch("/{id}", carryHandler.UpdateCarry())
-		router.Delete("/{id}", carryHandler.DeleteCarry())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionWrite))).Post("/", carryHandler.SaveCarry())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionRead))).Get("/", carryHandler.GetAllCarries())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionRead))).Get("/{id}", carryHandler.GetCarryByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionWrite))).Patch("/{id}", carryHandler.UpdateCarry())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionDelete))).Delete("/{id}", carryHandler.DeleteCarry())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceCarries, rbac.ActionWrite))).Post("/{id}/restore", carryHandler.RestoreCarry())
 	})
 
 	return nil
@@ -1,28 +1,40 @@
 package carry
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the carries in the audit trail
+const auditEntity = "carries"
+
 type MySQLCarryService struct {
 	repo             internal.CarryRepository
 	validateLocality internal.LocalityValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
 // NewMySQLCarryService creates a new instance of MySQLCarryService with the provided
-// CarryRepository and LocalityValidation. It returns a pointer to the created MySQLCarryService.
+// CarryRepository, LocalityValidation and UnitOfWork. It returns a pointer to the created MySQLCarryService.
 //
 // Parameters:
 //   - repo: an implementation of the CarryRepository interface used for data access.
 //   - validateLocality: an implementation of the LocalityValidation interface used for validating localities.
+//   - uow: the unit of work the changes are made and audited in.
 //
 // Returns:
 //   - A pointer to a MySQLCarryService instance initialized with the provided repository and locality validation.
-func NewMySQLCarryService(repo internal.CarryRepository, validateLocality internal.LocalityValidation) *MySQLCarryService {
+func NewMySQLCarryService(repo internal.CarryRepository, validateLocality internal.LocalityValidation, uow internal.UnitOfWork) *MySQLCarryService {
 	return &MySQLCarryService{
 		repo:             repo,
 		validateLocality: validateLocality,
+		uow:              uow,
 	}
 }
 
@@ -38,8 +50,8 @@ func NewMySQLCarryService(repo internal.CarryRepository, validateLocality intern
 // Returns:
 //
 //	error: An error if any validation fails or if there is an issue saving the Carry object.
-func (s *MySQLCarryService) Save(carry *internal.Carry) error {
-	if _, err := s.validateLocality.GetByID(carry.LocalityID); err != nil {
+func (s *MySQLCarryService) Save(ctx context.Context, carry *internal.Carry) error {
+	if _, err := s.validateLocality.GetByID(ctx, carry.LocalityID); err != nil {
 		return utils.ENotFound("Locality")
 	}
 
@@ -47,13 +59,37 @@ func (s *MySQLCarryService) Save(carry *internal.Carry) error {
 		return err
 	}
 
-	return s.repo.Save(carry)
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Carries.Save(ctx, carry); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, carry.ID, nil, carry)
+	})
 }
 
 // GetAll retrieves all Carry records from the repository.
 // It returns a slice of Carry objects and an error if any issues occur during the retrieval process.
-func (s *MySQLCarryService) GetAll() ([]internal.Carry, error) {
-	return s.repo.GetAll()
+func (s *MySQLCarryService) GetAll(ctx context.Context) ([]internal.Carry, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// List retrieves a page of Carry records, filtered and sorted as asked by the query.
+// It returns the pagination of the page, with the cursor of the next one when there are more records.
+func (s *MySQLCarryService) List(ctx context.Context, query utils.ListQuery) ([]internal.Carry, utils.Pagination, error) {
+	err := query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	carries, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	carries, pagination := utils.Paginate(carries, query)
+
+	return carries, pagination, nil
 }
 
 // GetByID retrieves a Carry entity by its unique identifier.
@@ -66,8 +102,41 @@ func (s *MySQLCarryService) GetAll() ([]internal.Carry, error) {
 // Returns:
 //   - internal.Carry: The Carry entity corresponding to the provided id.
 //   - error: An error object if there is an issue with the retrieval process, otherwise nil.
-func (s *MySQLCarryService) GetByID(id int) (internal.Carry, error) {
-	return s.repo.GetByID(id)
+func (s *MySQLCarryService) GetByID(ctx context.Context, id int) (internal.Carry, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// GetRelations retrieves the entities related to each carry that were asked by include.
+// Every locality is read once, whatever the number of carries in it.
+//
+// Parameters:
+//   - carries: The carries to retrieve the relations of.
+//   - include: The names of the relations, internal.CarryIncludeLocality.
+//
+// Returns:
+//   - []internal.CarryRelations: The relations of each carry, in the order of the carries.
+//   - error: An error object if there is an issue with the retrieval process, otherwise nil.
+func (s *MySQLCarryService) GetRelations(ctx context.Context, carries []internal.Carry, include []string) ([]internal.CarryRelations, error) {
+	relations := make([]internal.CarryRelations, len(carries))
+
+	for _, name := range include {
+		if name != internal.CarryIncludeLocality {
+			continue
+		}
+
+		localityID := func(carry internal.Carry) int { return carry.LocalityID }
+
+		localities, err := s.validateLocality.GetByIDs(ctx, utils.DistinctIDs(carries, localityID))
+		if err != nil {
+			return nil, err
+		}
+
+		for i, locality := range utils.Related(carries, localityID, localities, func(locality internal.Locality) int { return locality.ID }) {
+			relations[i].Locality = locality
+		}
+	}
+
+	return relations, nil
 }
 
 // Update updates an existing carry record in the database with the provided carry data.
@@ -79,22 +148,34 @@ func (s *MySQLCarryService) GetByID(id int) (internal.Carry, error) {
 //
 // Returns:
 //   - error: An error if the update operation fails or if the LocalityID validation fails.
-func (s *MySQLCarryService) Update(carry *internal.Carry) error {
-	existingCarry, err := s.repo.GetByID(carry.CID)
+func (s *MySQLCarryService) Update(ctx context.Context, carry *internal.Carry) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		existingCarry, err := repos.Carries.GetByID(ctx, carry.ID)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	s.prepareCarryQuery(carry, existingCarry)
+		if err = utils.CheckVersion("carry", carry.Version, existingCarry.Version); err != nil {
+			return err
+		}
 
-	if carry.LocalityID == 0 {
-		(*carry).LocalityID = existingCarry.LocalityID
-	} else if _, err := s.validateLocality.GetByID(carry.LocalityID); err != nil {
-		return err
-	}
+		(*carry).Version = existingCarry.Version
+
+		s.prepareCarryQuery(carry, existingCarry)
+
+		if carry.LocalityID == 0 {
+			(*carry).LocalityID = existingCarry.LocalityID
+		} else if _, err := s.validateLocality.GetByID(ctx, carry.LocalityID); err != nil {
+			return err
+		}
 
-	return s.repo.Update(carry)
+		if err = repos.Carries.Update(ctx, carry); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, carry.ID, existingCarry, carry)
+	})
 }
 
 // Delete removes a carry record from the repository based on the provided ID.
@@ -102,11 +183,84 @@ func (s *MySQLCarryService) Update(carry *internal.Carry) error {
 //
 // Parameters:
 //   - id: The unique identifier of the carry record to be deleted.
+//   - version: The version of the carry expected, utils.AnyVersion for whatever it is.
+//
+// Returns:
+//   - error: An error object if the deletion fails or the carry is not in the version given, otherwise nil.
+func (s *MySQLCarryService) Delete(ctx context.Context, id int, version int) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		existingCarry, err := repos.Carries.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = utils.CheckVersion("carry", version, existingCarry.Version); err != nil {
+			return err
+		}
+
+		if err = repos.Carries.Delete(ctx, id, existingCarry.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, existingCarry, nil)
+	})
+}
+
+// Restore brings back a soft deleted carry record.
+//
+// Parameters:
+//   - id: The unique identifier of the deleted carry record.
+//
+// Returns:
+//   - internal.Carry: The restored carry record.
+//   - error: A not found error if there is no deleted carry with the ID, or the error of the repository.
+func (s *MySQLCarryService) Restore(ctx context.Context, id int) (restored internal.Carry, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		err := repos.Carries.Restore(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.ENotFound("Deleted carry")
+			}
+
+			return err
+		}
+
+		restored, err = repos.Carries.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, restored)
+	})
+	if err != nil {
+		return internal.Carry{}, err
+	}
+
+	return restored, nil
+}
+
+// Purge removes for good the carry records deleted before a time.
+//
+// Parameters:
+//   - before: The time the carry records must have been deleted before.
 //
 // Returns:
-//   - error: An error object if the deletion fails, otherwise nil.
-func (s *MySQLCarryService) Delete(id int) error {
-	return s.repo.Delete(id)
+//   - int: The number of carry records removed.
+//   - error: The error of the repository.
+func (s *MySQLCarryService) Purge(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		purged, err = repos.Carries.Purge(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
 }
 
 // validateEmptyFields checks if the required fields in the Carry struct are empty.
@@ -1,7 +1,9 @@
 package carry_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/carry"
@@ -10,6 +12,39 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.CarryRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Carries: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockCarryRepository struct {
 	mock.Mock
 }
@@ -18,36 +53,56 @@ type MockLocalityValidation struct {
 	mock.Mock
 }
 
-func (m *MockCarryRepository) Save(carry *internal.Carry) error {
+func (m *MockCarryRepository) Save(ctx context.Context, carry *internal.Carry) error {
 	args := m.Called(carry)
 	return args.Error(0)
 }
 
-func (m *MockCarryRepository) GetAll() ([]internal.Carry, error) {
+func (m *MockCarryRepository) GetAll(ctx context.Context) ([]internal.Carry, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Carry), args.Error(1)
 }
 
-func (m *MockCarryRepository) GetByID(id int) (internal.Carry, error) {
+func (m *MockCarryRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Carry, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Carry), args.Error(1)
+}
+
+func (m *MockCarryRepository) GetByID(ctx context.Context, id int) (internal.Carry, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Carry), args.Error(1)
 }
 
-func (m *MockCarryRepository) Update(carry *internal.Carry) error {
+func (m *MockCarryRepository) Update(ctx context.Context, carry *internal.Carry) error {
 	args := m.Called(carry)
 	return args.Error(0)
 }
 
-func (m *MockCarryRepository) Delete(id int) error {
+func (m *MockCarryRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockCarryRepository) Restore(ctx context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockLocalityValidation) GetByID(id int) (internal.Locality, error) {
+func (m *MockCarryRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLocalityValidation) GetByID(ctx context.Context, id int) (internal.Locality, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Locality), args.Error(1)
 }
 
+func (m *MockLocalityValidation) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
 func TestUnitMySQLCarryService_GetAll(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -100,9 +155,9 @@ func TestUnitMySQLCarryService_GetAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, localityValidation := tt.mockRepo()
-			s := carry.NewMySQLCarryService(repo, localityValidation)
+			s := carry.NewMySQLCarryService(repo, localityValidation, newMockUnitOfWork(repo))
 
-			got, err := s.GetAll()
+			got, err := s.GetAll(context.Background())
 			require.Equal(t, tt.expectedErr, err)
 			require.Equal(t, tt.expected, got)
 		})
@@ -160,9 +215,9 @@ func TestUnitMySQLCarryService_GetByID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, localityValidation := tt.mockRepo()
-			s := carry.NewMySQLCarryService(repo, localityValidation)
+			s := carry.NewMySQLCarryService(repo, localityValidation, newMockUnitOfWork(repo))
 
-			got, err := s.GetByID(tt.id)
+			got, err := s.GetByID(context.Background(), tt.id)
 			require.Equal(t, tt.expectedErr, err)
 			require.Equal(t, tt.expected, got)
 		})
@@ -327,8 +382,8 @@ func TestUnitMySQLCarryService_Save(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, localityValidation := tt.mockRepo()
-			s := carry.NewMySQLCarryService(repo, localityValidation)
-			err := s.Save(tt.carry)
+			s := carry.NewMySQLCarryService(repo, localityValidation, newMockUnitOfWork(repo))
+			err := s.Save(context.Background(), tt.carry)
 			gotCarry := internal.Carry{}
 			if err == nil {
 				gotCarry.ID = tt.carry.ID
@@ -451,8 +506,8 @@ func TestUnitMySQLCarryService_Update(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, localityValidation := tt.mockRepo()
-			s := carry.NewMySQLCarryService(repo, localityValidation)
-			err := s.Update(tt.carry)
+			s := carry.NewMySQLCarryService(repo, localityValidation, newMockUnitOfWork(repo))
+			err := s.Update(context.Background(), tt.carry)
 			gotCarry := internal.Carry{}
 			if err == nil {
 				gotCarry.ID = tt.carry.ID
@@ -473,17 +528,20 @@ func TestUnitMySQLCarryService_Delete(t *testing.T) {
 	tests := []struct {
 		name        string
 		id          int
+		version     int
 		mockRepo    func() (*MockCarryRepository, *MockLocalityValidation)
 		wantErr     bool
 		expectedErr error
 		Assert      func(*testing.T, *MockCarryRepository, *MockLocalityValidation)
 	}{
 		{
-			name: "Delete carry",
-			id:   1,
+			name:    "Delete carry",
+			id:      1,
+			version: 2,
 			mockRepo: func() (*MockCarryRepository, *MockLocalityValidation) {
 				repo := new(MockCarryRepository)
-				repo.On("Delete", 1).Return(nil)
+				repo.On("GetByID", 1).Return(internal.Carry{ID: 1, Version: 2}, nil)
+				repo.On("Delete", 1, 2).Return(nil)
 				localityValidation := new(MockLocalityValidation)
 				return repo, localityValidation
 			},
@@ -493,29 +551,82 @@ func TestUnitMySQLCarryService_Delete(t *testing.T) {
 				repo.AssertNumberOfCalls(t, "Delete", 1)
 			},
 		},
+		{
+			name:    "Delete carry - Error Precondition Failed",
+			id:      1,
+			version: 1,
+			mockRepo: func() (*MockCarryRepository, *MockLocalityValidation) {
+				repo := new(MockCarryRepository)
+				repo.On("GetByID", 1).Return(internal.Carry{ID: 1, Version: 2}, nil)
+				localityValidation := new(MockLocalityValidation)
+				return repo, localityValidation
+			},
+			wantErr:     true,
+			expectedErr: utils.EPreconditionFailed("carry"),
+			Assert: func(t *testing.T, repo *MockCarryRepository, localityValidation *MockLocalityValidation) {
+				repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			},
+		},
 		{
 			name: "Delete carry - Error",
 			id:   1,
 			mockRepo: func() (*MockCarryRepository, *MockLocalityValidation) {
 				repo := new(MockCarryRepository)
-				repo.On("Delete", 1).Return(utils.ENotFound("Carry"))
+				repo.On("GetByID", 1).Return(internal.Carry{}, utils.ENotFound("Carry"))
 				localityValidation := new(MockLocalityValidation)
 				return repo, localityValidation
 			},
 			wantErr:     true,
 			expectedErr: utils.ENotFound("Carry"),
 			Assert: func(t *testing.T, repo *MockCarryRepository, localityValidation *MockLocalityValidation) {
-				repo.AssertNumberOfCalls(t, "Delete", 1)
+				repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo, localityValidation := tt.mockRepo()
-			s := carry.NewMySQLCarryService(repo, localityValidation)
-			err := s.Delete(tt.id)
+			s := carry.NewMySQLCarryService(repo, localityValidation, newMockUnitOfWork(repo))
+			err := s.Delete(context.Background(), tt.id, tt.version)
 			tt.Assert(t, repo, localityValidation)
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
+
+func TestUnitMySQLCarryService_Audit(t *testing.T) {
+	ctx := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 1, Username: "ana"})
+
+	t.Run("records the changed fields of an update by its principal", func(t *testing.T) {
+		repo := new(MockCarryRepository)
+		repo.On("GetByID", 1).Return(internal.Carry{ID: 1, CID: 1, CompanyName: "Company", Address: "Street 1", Telephone: "123", LocalityID: 1, Version: 1}, nil)
+		repo.On("Update", mock.Anything).Return(nil)
+		localityValidation := new(MockLocalityValidation)
+		localityValidation.On("GetByID", 1).Return(internal.Locality{}, nil)
+		uow := newMockUnitOfWork(repo)
+		s := carry.NewMySQLCarryService(repo, localityValidation, uow)
+
+		err := s.Update(ctx, &internal.Carry{ID: 1, Address: "Street 2"})
+		require.NoError(t, err)
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, "ana", uow.entries[0].Actor)
+		require.Equal(t, internal.AuditUpdate, uow.entries[0].Action)
+		require.Equal(t, "carries", uow.entries[0].Entity)
+		require.Equal(t, 1, uow.entries[0].EntityID)
+		require.Equal(t, map[string]internal.AuditChange{
+			"address": {Before: []byte(`"Street 1"`), After: []byte(`"Street 2"`)},
+		}, uow.entries[0].Changes)
+	})
+
+	t.Run("records nothing when the change fails", func(t *testing.T) {
+		repo := new(MockCarryRepository)
+		repo.On("GetByID", 1).Return(internal.Carry{ID: 1}, nil)
+		repo.On("Delete", 1, 0).Return(utils.EPreconditionFailed("carry"))
+		uow := newMockUnitOfWork(repo)
+		s := carry.NewMySQLCarryService(repo, new(MockLocalityValidation), uow)
+
+		err := s.Delete(ctx, 1, utils.AnyVersion)
+		require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		require.Empty(t, uow.entries)
+	})
+}
@@ -1,11 +1,41 @@
 package internal
 
+import "context"
+
 type Country struct {
-	ID          int
-	CountryName string
+	ID          int    `json:"id"`
+	CountryName string `json:"country_name"`
+	// Version is bumped by every change, it is the ETag of the country
+	Version int `json:"version,omitempty"`
+}
+
+// CountryHierarchy is a country with its provinces and their localities
+type CountryHierarchy struct {
+	Country
+	Provinces []ProvinceHierarchy `json:"provinces"`
 }
 
 type CountryRepository interface {
-	Save(*Country) error
-	GetByName(string) (Country, error)
+	Save(context.Context, *Country) error
+	GetByName(context.Context, string) (Country, error)
+	GetAll(ctx context.Context) ([]Country, error)
+	GetByID(ctx context.Context, id int) (Country, error)
+	Update(context.Context, *Country) error
+	// Delete removes the country along with its provinces and their localities, when the country is
+	// still in the version it was read
+	Delete(ctx context.Context, id int, version int) error
+	// GetReferences counts the entities referencing the localities of the country
+	GetReferences(ctx context.Context, id int) (LocalityReferences, error)
+}
+
+type CountryService interface {
+	GetAll(ctx context.Context) ([]Country, error)
+	GetByID(ctx context.Context, id int) (Country, error)
+	Save(context.Context, *Country) error
+	Update(context.Context, *Country) error
+	// Delete removes the country in the version given along with its provinces and their localities
+	Delete(ctx context.Context, id int, version int) error
+	GetProvinces(ctx context.Context, id int) ([]Province, error)
+	// GetHierarchy returns the provinces and localities of a country, or of all of them when id is 0
+	GetHierarchy(ctx context.Context, id int) ([]CountryHierarchy, error)
 }
@@ -1,6 +1,7 @@
 package country_test
 
 import (
+	"context"
 	"database/sql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/country"
 	"testing"
@@ -31,14 +32,14 @@ func TestIntegrationCountry_GetByName(t *testing.T) {
 	repo := country.NewMysqlCountryRepository(db)
 
 	t.Run("Given an existing name, return the country", func(t *testing.T) {
-		country, err := repo.GetByName("USA")
+		country, err := repo.GetByName(context.Background(), "USA")
 		require.NoError(t, err)
 		require.Equal(t, "USA", country.CountryName)
 		require.NotZero(t, country.ID)
 	})
 
 	t.Run("Given a not existing name, return empty country and utils.ErrNotFound", func(t *testing.T) {
-		country, err := repo.GetByName("Ostania")
+		country, err := repo.GetByName(context.Background(), "Ostania")
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Empty(t, country)
 	})
@@ -55,7 +56,7 @@ func TestIntegrationCountry_Save(t *testing.T) {
 		newCountry := internal.Country{
 			CountryName: "Westails",
 		}
-		err := repo.Save(&newCountry)
+		err := repo.Save(context.Background(), &newCountry)
 		require.NoError(t, err)
 		require.Equal(t, "Westails", newCountry.CountryName)
 		require.NotZero(t, newCountry.ID)
@@ -1,6 +1,7 @@
 package country
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -9,20 +10,20 @@ import (
 )
 
 type MysqlContryRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewMysqlCountryRepository(db *sql.DB) internal.CountryRepository {
+func NewMysqlCountryRepository(db utils.DBTX) internal.CountryRepository {
 	return &MysqlContryRepository{db: db}
 }
 
-func (r *MysqlContryRepository) Save(country *internal.Country) error {
-	stmt, err := r.db.Prepare("INSERT INTO countries(country_name) VALUES(?);")
+func (r *MysqlContryRepository) Save(ctx context.Context, country *internal.Country) error {
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO countries(country_name) VALUES(?);")
 	if err != nil {
 		return err
 	}
 
-	res, err := stmt.Exec(country.CountryName)
+	res, err := stmt.ExecContext(ctx, country.CountryName)
 	if err != nil {
 		return err
 	}
@@ -33,20 +34,21 @@ func (r *MysqlContryRepository) Save(country *internal.Country) error {
 	}
 
 	(*country).ID = int(id)
+	(*country).Version = 1
 
 	return nil
 }
-func (r *MysqlContryRepository) GetByName(name string) (internal.Country, error) {
-	stmt, err := r.db.Prepare("SELECT id, country_name FROM countries WHERE country_name=?;")
+func (r *MysqlContryRepository) GetByName(ctx context.Context, name string) (internal.Country, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, country_name, version FROM countries WHERE country_name=?;")
 	if err != nil {
 		return internal.Country{}, err
 	}
 
-	row := stmt.QueryRow(name)
+	row := stmt.QueryRowContext(ctx, name)
 
 	var country internal.Country
 
-	err = row.Scan(&country.ID, &country.CountryName)
+	err = row.Scan(&country.ID, &country.CountryName, &country.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return internal.Country{}, utils.ErrNotFound
@@ -57,3 +59,104 @@ func (r *MysqlContryRepository) GetByName(name string) (internal.Country, error)
 
 	return country, nil
 }
+
+func (r *MysqlContryRepository) GetAll(ctx context.Context) ([]internal.Country, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, country_name, version FROM countries ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	countries := []internal.Country{}
+
+	for rows.Next() {
+		var country internal.Country
+
+		err = rows.Scan(&country.ID, &country.CountryName, &country.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		countries = append(countries, country)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return countries, nil
+}
+
+func (r *MysqlContryRepository) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	var country internal.Country
+
+	err := r.db.QueryRowContext(ctx, "SELECT id, country_name, version FROM countries WHERE id=?;", id).Scan(&country.ID, &country.CountryName, &country.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.Country{}, utils.ErrNotFound
+		}
+
+		return internal.Country{}, err
+	}
+
+	return country, nil
+}
+
+// Update renames the country when it is still in the version it was read, and bumps its version
+func (r *MysqlContryRepository) Update(ctx context.Context, country *internal.Country) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE countries SET country_name=?, version=version+1 WHERE id=? AND version=?;", country.CountryName, country.ID, country.Version)
+	if err != nil {
+		return utils.MapMySQLError(err)
+	}
+
+	if err = utils.CheckVersionUpdated(result, "country"); err != nil {
+		return err
+	}
+
+	country.Version++
+
+	return nil
+}
+
+// Delete removes the country, its provinces and their localities in a single transaction, none of
+// them when the country is no longer in the version it was read
+func (r *MysqlContryRepository) Delete(ctx context.Context, id int, version int) error {
+	return utils.InTx(ctx, r.db, func(tx utils.DBTX) error {
+		_, err := tx.ExecContext(ctx, "DELETE l FROM localities l INNER JOIN provinces p ON p.id = l.province_id WHERE p.country_id=?;", id)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM provinces WHERE country_id=?;", id)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM countries WHERE id=? AND version=?;", id, version)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		return utils.CheckVersionUpdated(result, "country")
+	})
+}
+
+func (r *MysqlContryRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	var references internal.LocalityReferences
+
+	err := r.db.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM sellers s INNER JOIN localities l ON l.id = s.locality_id
+				INNER JOIN provinces p ON p.id = l.province_id WHERE p.country_id = ?),
+			(SELECT COUNT(*) FROM warehouses w INNER JOIN localities l ON l.id = w.locality_id
+				INNER JOIN provinces p ON p.id = l.province_id WHERE p.country_id = ?),
+			(SELECT COUNT(*) FROM carriers c INNER JOIN localities l ON l.id = c.locality_id
+				INNER JOIN provinces p ON p.id = l.province_id WHERE p.country_id = ?);`, id, id, id).
+		Scan(&references.Sellers, &references.Warehouses, &references.Carriers)
+	if err != nil {
+		return internal.LocalityReferences{}, err
+	}
+
+	return references, nil
+}
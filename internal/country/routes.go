@@ -0,0 +1,24 @@
+package country
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+)
+
+func NewCountryRoutes(mux *chi.Mux, service internal.CountryService) error {
+	countryHandler := handler.NewCountryHandler(service)
+
+	mux.Route("/api/v1/countries", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/", countryHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Post("/", countryHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/hierarchy", countryHandler.GetHierarchy())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/{id}", countryHandler.GetByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Patch("/{id}", countryHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionDelete))).Delete("/{id}", countryHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/{id}/provinces", countryHandler.GetProvinces())
+	})
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package country
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// auditEntity is the name of the countries in the audit trail
+const auditEntity = "countries"
+
+type BasicCountryService struct {
+	countryRepo  internal.CountryRepository
+	provinceRepo internal.ProvinceRepository
+	localityRepo internal.LocalityRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
+}
+
+func NewBasicCountryService(
+	cr internal.CountryRepository,
+	pr internal.ProvinceRepository,
+	lr internal.LocalityRepository,
+	uow internal.UnitOfWork) internal.CountryService {
+	return &BasicCountryService{
+		cr, pr, lr, uow,
+	}
+}
+
+// GetAll returns all the countries
+func (s *BasicCountryService) GetAll(ctx context.Context) ([]internal.Country, error) {
+	return s.countryRepo.GetAll(ctx)
+}
+
+// GetByID returns a country, utils.ErrNotFound when it doesn't exist
+func (s *BasicCountryService) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	country, err := s.countryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Country{}, utils.ENotFound("country")
+		}
+
+		return internal.Country{}, err
+	}
+
+	return country, nil
+}
+
+// Save creates a country, its name must be unique
+func (s *BasicCountryService) Save(ctx context.Context, country *internal.Country) error {
+	country.CountryName = strings.TrimSpace(country.CountryName)
+	if country.CountryName == "" {
+		return utils.EZeroValue("country_name")
+	}
+
+	if err := s.nameIsAvailable(ctx, country.CountryName, 0); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Countries.Save(ctx, country); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, country.ID, nil, country)
+	})
+}
+
+// Update renames a country
+func (s *BasicCountryService) Update(ctx context.Context, country *internal.Country) error {
+	current, err := s.GetByID(ctx, country.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("country", country.Version, current.Version); err != nil {
+		return err
+	}
+
+	country.Version = current.Version
+
+	country.CountryName = strings.TrimSpace(country.CountryName)
+	if country.CountryName == "" {
+		return utils.EZeroValue("country_name")
+	}
+
+	if err := s.nameIsAvailable(ctx, country.CountryName, country.ID); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Countries.Update(ctx, country); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, country.ID, current, country)
+	})
+}
+
+// Delete removes a country in the version given with its provinces and localities, refusing
+// when sellers, warehouses or carriers are located in any of them
+func (s *BasicCountryService) Delete(ctx context.Context, id int, version int) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("country", version, current.Version); err != nil {
+		return err
+	}
+
+	references, err := s.countryRepo.GetReferences(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if references.Total() > 0 {
+		return utils.EInUse("country", references.String())
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Countries.Delete(ctx, id, current.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, current, nil)
+	})
+	if errors.Is(err, utils.ErrInUse) {
+		return utils.EInUse("country", "other entities")
+	}
+
+	return err
+}
+
+// GetProvinces returns the provinces of a country
+func (s *BasicCountryService) GetProvinces(ctx context.Context, id int) ([]internal.Province, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.provinceRepo.GetByCountryID(ctx, id)
+}
+
+// GetHierarchy returns a country with its provinces and their localities,
+// all the countries when id is 0. The provinces and the localities are read
+// in a query each, whatever the number of countries
+func (s *BasicCountryService) GetHierarchy(ctx context.Context, id int) ([]internal.CountryHierarchy, error) {
+	var (
+		countries []internal.Country
+		provinces []internal.Province
+		err       error
+	)
+
+	if id == 0 {
+		countries, err = s.countryRepo.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		provinces, err = s.provinceRepo.GetAll(ctx)
+	} else {
+		var country internal.Country
+
+		country, err = s.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		countries = []internal.Country{country}
+		provinces, err = s.provinceRepo.GetByCountryID(ctx, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	provinceIDs := make([]int, 0, len(provinces))
+	for _, province := range provinces {
+		provinceIDs = append(provinceIDs, province.ID)
+	}
+
+	localities, err := s.localityRepo.GetByProvinceIDs(ctx, provinceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	localitiesByProvince := make(map[int][]internal.Locality, len(provinces))
+	for _, locality := range localities {
+		localitiesByProvince[locality.ProvinceID] = append(localitiesByProvince[locality.ProvinceID], locality)
+	}
+
+	provincesByCountry := make(map[int][]internal.ProvinceHierarchy, len(countries))
+
+	for _, province := range provinces {
+		provinceLocalities, ok := localitiesByProvince[province.ID]
+		if !ok {
+			provinceLocalities = []internal.Locality{}
+		}
+
+		provincesByCountry[province.CountryID] = append(provincesByCountry[province.CountryID], internal.ProvinceHierarchy{Province: province, Localities: provinceLocalities})
+	}
+
+	hierarchy := make([]internal.CountryHierarchy, 0, len(countries))
+
+	for _, country := range countries {
+		countryProvinces, ok := provincesByCountry[country.ID]
+		if !ok {
+			countryProvinces = []internal.ProvinceHierarchy{}
+		}
+
+		hierarchy = append(hierarchy, internal.CountryHierarchy{Country: country, Provinces: countryProvinces})
+	}
+
+	return hierarchy, nil
+}
+
+// nameIsAvailable checks no other country than id is named name
+func (s *BasicCountryService) nameIsAvailable(ctx context.Context, name string, id int) error {
+	possibleCountry, err := s.countryRepo.GetByName(ctx, name)
+	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+		return err
+	}
+
+	if err == nil && possibleCountry.ID != id {
+		return utils.EConflict("country", "country_name")
+	}
+
+	return nil
+}
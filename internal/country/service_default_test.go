@@ -0,0 +1,340 @@
+package country_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/country"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(cr internal.CountryRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Countries: cr, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type MockCountryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCountryRepository) Save(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) GetByName(ctx context.Context, name string) (internal.Country, error) {
+	args := m.Called(name)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) GetAll(ctx context.Context) ([]internal.Country, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) Update(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+type MockProvinceRepository struct {
+	mock.Mock
+}
+
+func (m *MockProvinceRepository) Save(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) GetByName(ctx context.Context, name string) (internal.Province, error) {
+	args := m.Called(name)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetAll(ctx context.Context) ([]internal.Province, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByCountryID(ctx context.Context, countryID int) ([]internal.Province, error) {
+	args := m.Called(countryID)
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) Update(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+type MockLocalityRepository struct {
+	mock.Mock
+}
+
+func (m *MockLocalityRepository) Save(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByID(ctx context.Context, id int) (internal.Locality, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceID(ctx context.Context, provinceID int) ([]internal.Locality, error) {
+	args := m.Called(provinceID)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceIDs(ctx context.Context, provinceIDs []int) ([]internal.Locality, error) {
+	args := m.Called(provinceIDs)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) Update(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetSellersByLocalityID(ctx context.Context, localityID int) ([]internal.SellersByLocality, error) {
+	args := m.Called(localityID)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetCarriesByLocalityID(ctx context.Context, localityID int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(localityID)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func TestUnitCountry_Save(t *testing.T) {
+	t.Run("given a new name, save the country", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByName", "Chile").Return(internal.Country{}, utils.ErrNotFound)
+		cr.On("Save", &internal.Country{CountryName: "Chile"}).Return(nil)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Save(context.Background(), &internal.Country{CountryName: " Chile "})
+		require.NoError(t, err)
+	})
+
+	t.Run("given an empty name, return utils.ErrInvalidArguments", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Save(context.Background(), &internal.Country{CountryName: "  "})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("given a taken name, return utils.ErrConflict", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByName", "Argentina").Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Save(context.Background(), &internal.Country{CountryName: "Argentina"})
+		require.ErrorIs(t, err, utils.ErrConflict)
+		cr.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestUnitCountry_Update(t *testing.T) {
+	t.Run("given the current name of the same country, update it", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		cr.On("GetByName", "Argentina").Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		cr.On("Update", &internal.Country{ID: 1, CountryName: "Argentina"}).Return(nil)
+		uow := newMockUnitOfWork(cr)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), uow)
+
+		require.NoError(t, service.Update(context.Background(), &internal.Country{ID: 1, CountryName: "Argentina"}))
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, internal.AuditUpdate, uow.entries[0].Action)
+		require.Empty(t, uow.entries[0].Changes)
+	})
+
+	t.Run("given a country that doesn't exist, return utils.ErrNotFound", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 9).Return(internal.Country{}, utils.ErrNotFound)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Update(context.Background(), &internal.Country{ID: 9, CountryName: "Chile"})
+		require.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
+
+func TestUnitCountry_Delete(t *testing.T) {
+	t.Run("given a country without references, delete it", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina", Version: 2}, nil)
+		cr.On("GetReferences", 1).Return(internal.LocalityReferences{}, nil)
+		cr.On("Delete", 1, 2).Return(nil)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		require.NoError(t, service.Delete(context.Background(), 1, 2))
+	})
+
+	t.Run("given a country in another version, return utils.ErrPreconditionFailed", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina", Version: 2}, nil)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Delete(context.Background(), 1, 1)
+		require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		cr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("given a country with warehouses, return utils.ErrInUse", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		cr.On("GetReferences", 1).Return(internal.LocalityReferences{Warehouses: 1}, nil)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
+		require.ErrorIs(t, err, utils.ErrInUse)
+		cr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("given a reference created after the check, return utils.ErrInUse", func(t *testing.T) {
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		cr.On("GetReferences", 1).Return(internal.LocalityReferences{}, nil)
+		cr.On("Delete", 1, 0).Return(utils.ErrInUse)
+		service := country.NewBasicCountryService(cr, new(MockProvinceRepository), new(MockLocalityRepository), newMockUnitOfWork(cr))
+
+		require.ErrorIs(t, service.Delete(context.Background(), 1, utils.AnyVersion), utils.ErrInUse)
+	})
+}
+
+func TestUnitCountry_GetHierarchy(t *testing.T) {
+	cr := new(MockCountryRepository)
+	pr := new(MockProvinceRepository)
+	lr := new(MockLocalityRepository)
+	cr.On("GetAll").Return([]internal.Country{{ID: 1, CountryName: "Argentina"}, {ID: 2, CountryName: "Chile"}}, nil)
+	cr.On("GetByID", 2).Return(internal.Country{ID: 2, CountryName: "Chile"}, nil)
+	cr.On("GetByID", 9).Return(internal.Country{}, utils.ErrNotFound)
+	pr.On("GetAll").Return([]internal.Province{{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1}}, nil)
+	pr.On("GetByCountryID", 2).Return([]internal.Province{}, nil)
+	lr.On("GetByProvinceIDs", []int{1}).Return([]internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}}, nil)
+	lr.On("GetByProvinceIDs", []int{}).Return([]internal.Locality{}, nil)
+	service := country.NewBasicCountryService(cr, pr, lr, newMockUnitOfWork(cr))
+
+	t.Run("given id 0, return every country", func(t *testing.T) {
+		hierarchy, err := service.GetHierarchy(context.Background(), 0)
+		require.NoError(t, err)
+		require.Equal(t, []internal.CountryHierarchy{
+			{
+				Country: internal.Country{ID: 1, CountryName: "Argentina"},
+				Provinces: []internal.ProvinceHierarchy{
+					{
+						Province:   internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1},
+						Localities: []internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}},
+					},
+				},
+			},
+			{Country: internal.Country{ID: 2, CountryName: "Chile"}, Provinces: []internal.ProvinceHierarchy{}},
+		}, hierarchy)
+	})
+
+	t.Run("given a country id, return only that country", func(t *testing.T) {
+		hierarchy, err := service.GetHierarchy(context.Background(), 2)
+		require.NoError(t, err)
+		require.Equal(t, []internal.CountryHierarchy{
+			{Country: internal.Country{ID: 2, CountryName: "Chile"}, Provinces: []internal.ProvinceHierarchy{}},
+		}, hierarchy)
+		pr.AssertNumberOfCalls(t, "GetAll", 1)
+	})
+
+	t.Run("given a country that doesn't exist, return utils.ErrNotFound", func(t *testing.T) {
+		_, err := service.GetHierarchy(context.Background(), 9)
+		require.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
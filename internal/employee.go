@@ -1,9 +1,20 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 // Employee represents an employee entity with its unique ID and attributes
 type Employee struct {
 	ID         int                `json:"id"`
 	Attributes EmployeeAttributes `json:"attributes"`
+	// DeletedAt is the time the employee was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is bumped by every change, it is the ETag of the employee
+	Version int `json:"version,omitempty"`
 }
 
 // EmployeeAttributes defines the details associated with an employee
@@ -26,23 +37,36 @@ type EmployeeJSON struct {
 // EmployeeRepository defines the interface for employee data persistence
 // it specifies methods for fetching and creating employee data
 type EmployeeRepository interface {
-	FindAll() (employees map[int]Employee, err error)
-	FindByID(id int) (employee Employee, err error)
-	CreateEmployee(newEmployee EmployeeAttributes) (employee Employee, err error)
-	UpdateEmployee(inputEmployee Employee) (employee Employee, err error)
-	DeleteEmployee(id int) (err error)
+	// FindAll returns the employees that are not deleted, the card numbers of the deleted ones are
+	// still taken and CreateEmployee and UpdateEmployee return ErrConflict for them
+	FindAll(ctx context.Context) (employees map[int]Employee, err error)
+	// List returns the employees of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) (employees []Employee, err error)
+	FindByID(ctx context.Context, id int) (employee Employee, err error)
+	CreateEmployee(ctx context.Context, newEmployee EmployeeAttributes) (employee Employee, err error)
+	UpdateEmployee(ctx context.Context, inputEmployee Employee) (employee Employee, err error)
+	// DeleteEmployee soft deletes an employee when it is still in the version it was read
+	DeleteEmployee(ctx context.Context, id int, version int) (err error)
+	RestoreEmployee(ctx context.Context, id int) (err error)
+	PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error)
 }
 
 // EmployeeService defines the interface for employee-related business logic
 // it includes methods for fetching and creating employees
 type EmployeeService interface {
-	FindAll() (employees map[int]Employee, err error)
-	FindByID(id int) (employee Employee, err error)
-	CreateEmployee(newEmployee EmployeeAttributes) (employee Employee, err error)
-	UpdateEmployee(inputEmployee Employee) (employee Employee, err error)
-	DeleteEmployee(id int) (err error)
+	FindAll(ctx context.Context) (employees map[int]Employee, err error)
+	List(ctx context.Context, query utils.ListQuery) (employees []Employee, pagination utils.Pagination, err error)
+	FindByID(ctx context.Context, id int) (employee Employee, err error)
+	CreateEmployee(ctx context.Context, newEmployee EmployeeAttributes) (employee Employee, err error)
+	UpdateEmployee(ctx context.Context, inputEmployee Employee) (employee Employee, err error)
+	// DeleteEmployee soft deletes an employee in the version given
+	DeleteEmployee(ctx context.Context, id int, version int) (err error)
+	// RestoreEmployee brings back a soft deleted employee
+	RestoreEmployee(ctx context.Context, id int) (employee Employee, err error)
+	// PurgeEmployees removes for good the employees deleted before a time, returns how many were removed
+	PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error)
 }
 
 type EmployeesWarehouseValidation interface {
-	GetByID(int) (Warehouse, error)
+	GetByID(context.Context, int) (Warehouse, error)
 }
@@ -1,24 +1,38 @@
 package employee
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the employees can be filtered and sorted by, the attributes by their own names
+var listFields = utils.ListFields{
+	"id":             {Column: "id", Kind: utils.ListInt, Sortable: true},
+	"card_number_id": {Column: "id_card_number", Kind: utils.ListString, Sortable: true},
+	"first_name":     {Column: "first_name", Kind: utils.ListString, Sortable: true},
+	"last_name":      {Column: "last_name", Kind: utils.ListString, Sortable: true},
+	"warehouse_id":   {Column: "warehouse_id", Kind: utils.ListInt, Sortable: true},
+}
+
 type EmployeeRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewEmployeeRepository(db *sql.DB) *EmployeeRepository {
+func NewEmployeeRepository(db utils.DBTX) *EmployeeRepository {
 	return &EmployeeRepository{db: db}
 }
 
-// FindAll retrieves all employees from the repository
-func (r *EmployeeRepository) FindAll() (map[int]internal.Employee, error) {
-	rows, err := r.db.Query("SELECT id, id_card_number, first_name, last_name, warehouse_id FROM employees")
+// FindAll retrieves all employees from the repository that are not deleted. It is not scoped to the
+// warehouses of the user, the id card numbers are unique across every warehouse
+func (r *EmployeeRepository) FindAll(ctx context.Context) (map[int]internal.Employee, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, id_card_number, first_name, last_name, warehouse_id, version FROM employees WHERE deleted_at IS NULL")
 	if err != nil {
 		log.Printf("Error in FindAll Query: %v", err)
 		return nil, err
@@ -31,7 +45,7 @@ func (r *EmployeeRepository) FindAll() (map[int]internal.Employee, error) {
 		var emp internal.Employee
 		emp.Attributes = internal.EmployeeAttributes{}
 
-		err := rows.Scan(&emp.ID, &emp.Attributes.CardNumberID, &emp.Attributes.FirstName, &emp.Attributes.LastName, &emp.Attributes.WarehouseID)
+		err := rows.Scan(&emp.ID, &emp.Attributes.CardNumberID, &emp.Attributes.FirstName, &emp.Attributes.LastName, &emp.Attributes.WarehouseID, &emp.Version)
 		if err != nil {
 			log.Printf("Error scanning row: %v", err)
 			return nil, err
@@ -43,13 +57,41 @@ func (r *EmployeeRepository) FindAll() (map[int]internal.Employee, error) {
 	return employees, nil
 }
 
+// List retrieves a page of employees in the order of the query
+func (r *EmployeeRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Employee, error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition(), internal.WarehouseCondition(ctx, "warehouse_id"))
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, id_card_number, first_name, last_name, warehouse_id, deleted_at, version FROM employees"+clause, args...)
+	if err != nil {
+		log.Printf("Error in List Query: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var employees []internal.Employee
+
+	for rows.Next() {
+		var emp internal.Employee
+
+		err = rows.Scan(&emp.ID, &emp.Attributes.CardNumberID, &emp.Attributes.FirstName, &emp.Attributes.LastName, &emp.Attributes.WarehouseID, &emp.DeletedAt, &emp.Version)
+		if err != nil {
+			log.Printf("Error scanning row: %v", err)
+			return nil, err
+		}
+
+		employees = append(employees, emp)
+	}
+
+	return employees, rows.Err()
+}
+
 // FindByID retrieves an employee by their ID
-func (r *EmployeeRepository) FindByID(id int) (internal.Employee, error) {
+func (r *EmployeeRepository) FindByID(ctx context.Context, id int) (internal.Employee, error) {
 	var employee internal.Employee
 	employee.Attributes = internal.EmployeeAttributes{}
 
-	err := r.db.QueryRow("SELECT id, id_card_number, first_name, last_name, warehouse_id FROM employees WHERE id = ?", id).
-		Scan(&employee.ID, &employee.Attributes.CardNumberID, &employee.Attributes.FirstName, &employee.Attributes.LastName, &employee.Attributes.WarehouseID)
+	err := r.db.QueryRowContext(ctx, "SELECT id, id_card_number, first_name, last_name, warehouse_id, version FROM employees WHERE id = ? AND deleted_at IS NULL AND "+internal.WarehouseCondition(ctx, "warehouse_id"), id).
+		Scan(&employee.ID, &employee.Attributes.CardNumberID, &employee.Attributes.FirstName, &employee.Attributes.LastName, &employee.Attributes.WarehouseID, &employee.Version)
 	if err == sql.ErrNoRows {
 		return internal.Employee{}, utils.ErrNotFound
 	}
@@ -63,12 +105,12 @@ func (r *EmployeeRepository) FindByID(id int) (internal.Employee, error) {
 }
 
 // CreateEmployee adds a new employee
-func (r *EmployeeRepository) CreateEmployee(newEmployee internal.EmployeeAttributes) (internal.Employee, error) {
-	result, err := r.db.Exec("INSERT INTO employees (id_card_number, first_name, last_name, warehouse_id) VALUES (?, ?, ?, ?)",
+func (r *EmployeeRepository) CreateEmployee(ctx context.Context, newEmployee internal.EmployeeAttributes) (internal.Employee, error) {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO employees (id_card_number, first_name, last_name, warehouse_id) VALUES (?, ?, ?, ?)",
 		newEmployee.CardNumberID, newEmployee.FirstName, newEmployee.LastName, newEmployee.WarehouseID)
 	if err != nil {
 		log.Printf("Error in CreateEmployee Query: %v", err)
-		return internal.Employee{}, err
+		return internal.Employee{}, cardNumberTaken(err)
 	}
 
 	id, err := result.LastInsertId()
@@ -77,24 +119,29 @@ func (r *EmployeeRepository) CreateEmployee(newEmployee internal.EmployeeAttribu
 		return internal.Employee{}, err
 	}
 
-	return r.FindByID(int(id))
+	return r.FindByID(ctx, int(id))
 }
 
-// UpdateEmployee updates an employee's data
-func (r *EmployeeRepository) UpdateEmployee(inputEmployee internal.Employee) (internal.Employee, error) {
-	_, err := r.db.Exec("UPDATE employees SET id_card_number = ?, first_name = ?, last_name = ?, warehouse_id = ? WHERE id = ?",
-		inputEmployee.Attributes.CardNumberID, inputEmployee.Attributes.FirstName, inputEmployee.Attributes.LastName, inputEmployee.Attributes.WarehouseID, inputEmployee.ID)
+// UpdateEmployee updates an employee's data when it is still in the version it was read, and bumps its version
+func (r *EmployeeRepository) UpdateEmployee(ctx context.Context, inputEmployee internal.Employee) (internal.Employee, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE employees SET id_card_number = ?, first_name = ?, last_name = ?, warehouse_id = ?, version = version + 1 WHERE id = ? AND version = ?",
+		inputEmployee.Attributes.CardNumberID, inputEmployee.Attributes.FirstName, inputEmployee.Attributes.LastName, inputEmployee.Attributes.WarehouseID, inputEmployee.ID, inputEmployee.Version)
 	if err != nil {
 		log.Printf("Error in UpdateEmployee Query: %v", err)
+		return internal.Employee{}, cardNumberTaken(err)
+	}
+
+	err = utils.CheckVersionUpdated(result, "employee")
+	if err != nil {
 		return internal.Employee{}, err
 	}
 
-	return r.FindByID(inputEmployee.ID)
+	return r.FindByID(ctx, inputEmployee.ID)
 }
 
-// DeleteEmployee removes an employee
-func (r *EmployeeRepository) DeleteEmployee(id int) error {
-	_, err := r.db.Exec("DELETE FROM employees WHERE id = ?", id)
+// DeleteEmployee soft deletes an employee still in the version it was read, the row is kept with the time it was deleted
+func (r *EmployeeRepository) DeleteEmployee(ctx context.Context, id int, version int) error {
+	err := utils.SoftDelete(ctx, r.db, "employees", "employee", id, version, internal.WarehouseCondition(ctx, "warehouse_id"))
 	if err != nil {
 		log.Printf("Error in DeleteEmployee Query: %v", err)
 		return err
@@ -102,3 +149,23 @@ func (r *EmployeeRepository) DeleteEmployee(id int) error {
 
 	return nil
 }
+
+// RestoreEmployee clears the deletion of a soft deleted employee
+func (r *EmployeeRepository) RestoreEmployee(ctx context.Context, id int) error {
+	return utils.Restore(ctx, r.db, "employees", id, internal.WarehouseCondition(ctx, "warehouse_id"))
+}
+
+// PurgeEmployees removes for good the employees deleted before a time, the ones other records still refer to are kept
+func (r *EmployeeRepository) PurgeEmployees(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, r.db, "employees", before)
+}
+
+// cardNumberTaken returns ErrConflict when the id card number is taken, by a deleted employee too
+func cardNumberTaken(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return utils.ErrConflict
+	}
+
+	return err
+}
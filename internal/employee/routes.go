@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 // RegisterEmployeesRoutes is used to record the routes associated to the employee entity
@@ -12,14 +13,15 @@ func RegisterEmployeesRoutes(mux *chi.Mux, service internal.EmployeeService) err
 
 	mux.Route("/api/v1/employees", func(router chi.Router) {
 		// Get
-		router.Get("/", employeeHandler.GetAllEmployees())
-		router.Get("/{id}", employeeHandler.GetEmployeesByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionRead))).Get("/", employeeHandler.GetAllEmployees())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionRead))).Get("/{id}", employeeHandler.GetEmployeesByID())
 		// Post
-		router.Post("/", employeeHandler.PostEmployees())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionWrite))).Post("/", employeeHandler.PostEmployees())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionWrite))).Post("/{id}/restore", employeeHandler.RestoreEmployees())
 		// Patch
-		router.Patch("/{id}", employeeHandler.PatchEmployees())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionWrite))).Patch("/{id}", employeeHandler.PatchEmployees())
 		// Delete
-		router.Delete("/{id}", employeeHandler.DeleteEmployees())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceEmployees, rbac.ActionDelete))).Delete("/{id}", employeeHandler.DeleteEmployees())
 	})
 
 	return nil
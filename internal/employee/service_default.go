@@ -1,39 +1,65 @@
 package employee
 
 import (
+	"context"
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the employees in the audit trail
+const auditEntity = "employees"
+
 // EmployeeDefault is the default implementation of the employee service
 // it handles business logic and delegates data operations to the repository
 type EmployeeDefault struct {
 	rp               internal.EmployeeRepository
 	warehouseService internal.EmployeesWarehouseValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
 // NewEmployeeService creates a new instance of EmployeeDefault
 // takes an EmployeeRepository as a parameter to handle data operations
-func NewEmployeeService(rp internal.EmployeeRepository, warehouseService internal.EmployeesWarehouseValidation) *EmployeeDefault {
-	return &EmployeeDefault{rp: rp, warehouseService: warehouseService}
+func NewEmployeeService(rp internal.EmployeeRepository, warehouseService internal.EmployeesWarehouseValidation, uow internal.UnitOfWork) *EmployeeDefault {
+	return &EmployeeDefault{rp: rp, warehouseService: warehouseService, uow: uow}
 }
 
 // FindAll retrieves all employees from the repository
-func (s *EmployeeDefault) FindAll() (employees map[int]internal.Employee, err error) {
-	employees, err = s.rp.FindAll()
+func (s *EmployeeDefault) FindAll(ctx context.Context) (employees map[int]internal.Employee, err error) {
+	employees, err = s.rp.FindAll(ctx)
 	return
 }
 
+// List retrieves a page of employees filtered and sorted as asked by the query
+func (s *EmployeeDefault) List(ctx context.Context, query utils.ListQuery) (employees []internal.Employee, pagination utils.Pagination, err error) {
+	err = query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	employees, err = s.rp.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	employees, pagination = utils.Paginate(employees, query)
+
+	return employees, pagination, nil
+}
+
 // FindByID retrieves an employee by ID from the repository
-func (s *EmployeeDefault) FindByID(id int) (employee internal.Employee, err error) {
-	employee, err = s.rp.FindByID(id)
+func (s *EmployeeDefault) FindByID(ctx context.Context, id int) (employee internal.Employee, err error) {
+	employee, err = s.rp.FindByID(ctx, id)
 	return
 }
 
 // CreateEmployee adds a new employee to the repository
-func (s *EmployeeDefault) CreateEmployee(newEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
+func (s *EmployeeDefault) CreateEmployee(ctx context.Context, newEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
 	// validate required fields
 	err = s.validateFields(newEmployee)
 	if err != nil {
@@ -41,7 +67,7 @@ func (s *EmployeeDefault) CreateEmployee(newEmployee internal.EmployeeAttributes
 	}
 
 	// check for duplicates
-	employees, err := s.rp.FindAll()
+	employees, err := s.rp.FindAll(ctx)
 	if err != nil {
 		return employee, err
 	}
@@ -51,32 +77,50 @@ func (s *EmployeeDefault) CreateEmployee(newEmployee internal.EmployeeAttributes
 		return employee, err
 	}
 
+	// the warehouse must be one of the user
+	err = internal.CheckWarehouseScope(ctx, newEmployee.WarehouseID)
+	if err != nil {
+		return employee, err
+	}
+
 	// verify if warehouse_id exists
-	err = s.warehouseExistsByID(newEmployee.WarehouseID)
+	err = s.warehouseExistsByID(ctx, newEmployee.WarehouseID)
 	if err != nil {
 		return employee, err
 	}
 
 	// attempt to create the new employee
-	employee, err = s.rp.CreateEmployee(newEmployee)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		employee, err = repos.Employees.CreateEmployee(ctx, newEmployee)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, employee.ID, nil, employee)
+	})
 	if err != nil {
-		return employee, err
+		return internal.Employee{}, err
 	}
 
 	return employee, nil
 }
 
 // UpdateEmployee updates an employee in the repository
-func (s *EmployeeDefault) UpdateEmployee(inputEmployee internal.Employee) (employee internal.Employee, err error) {
+func (s *EmployeeDefault) UpdateEmployee(ctx context.Context, inputEmployee internal.Employee) (employee internal.Employee, err error) {
 	// find the existing employee
-	internalEmployee, err := s.rp.FindByID(inputEmployee.ID)
+	internalEmployee, err := s.rp.FindByID(ctx, inputEmployee.ID)
 	if err != nil {
 		err = utils.ErrNotFound
 		return
 	}
 
+	err = utils.CheckVersion("employee", inputEmployee.Version, internalEmployee.Version)
+	if err != nil {
+		return
+	}
+
 	// verify if warehouse_id exists
-	err = s.warehouseExistsByID(inputEmployee.Attributes.WarehouseID)
+	err = s.warehouseExistsByID(ctx, inputEmployee.Attributes.WarehouseID)
 	if err != nil {
 		return
 	}
@@ -84,27 +128,90 @@ func (s *EmployeeDefault) UpdateEmployee(inputEmployee internal.Employee) (emplo
 	// merge input fields with the existing employee
 	updatedEmployee := mergeEmployeeFields(inputEmployee, internalEmployee)
 
+	// the employee cannot be moved to a warehouse that is not one of the user
+	err = internal.CheckWarehouseScope(ctx, updatedEmployee.Attributes.WarehouseID)
+	if err != nil {
+		return
+	}
+
 	// update the employee in the repository
-	employee, err = s.rp.UpdateEmployee(updatedEmployee)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		employee, err = repos.Employees.UpdateEmployee(ctx, updatedEmployee)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, employee.ID, internalEmployee, employee)
+	})
 
 	return
 }
 
-// DeleteEmployee deletes an employee from the repository based on the provided ID
-func (s *EmployeeDefault) DeleteEmployee(id int) (err error) {
+// DeleteEmployee deletes an employee in the version given from the repository based on the provided ID
+func (s *EmployeeDefault) DeleteEmployee(ctx context.Context, id int, version int) (err error) {
 	// find the employee to ensure it exists
-	employee, err := s.rp.FindByID(id)
+	employee, err := s.rp.FindByID(ctx, id)
 	if err != nil {
 		return utils.ErrNotFound
 	}
 
-	// delete the employee by passing only the ID to the repository
-	err = s.rp.DeleteEmployee(employee.ID)
+	err = utils.CheckVersion("employee", version, employee.Version)
 	if err != nil {
-		return utils.ErrInvalidArguments
+		return err
 	}
 
-	return nil
+	// delete the employee in the version it was read
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Employees.DeleteEmployee(ctx, employee.ID, employee.Version); err != nil {
+			if errors.Is(err, utils.ErrPreconditionFailed) {
+				return err
+			}
+
+			return utils.ErrInvalidArguments
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, employee.ID, employee, nil)
+	})
+}
+
+// RestoreEmployee brings back a soft deleted employee based on the provided ID
+func (s *EmployeeDefault) RestoreEmployee(ctx context.Context, id int) (employee internal.Employee, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		err = repos.Employees.RestoreEmployee(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				err = utils.ENotFound("Deleted employee")
+			}
+
+			return err
+		}
+
+		employee, err = repos.Employees.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, employee)
+	})
+
+	return
+}
+
+// PurgeEmployees removes for good the employees deleted before a time
+func (s *EmployeeDefault) PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		purged, err = repos.Employees.PurgeEmployees(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return
 }
 
 // validateFields checks if the required fields of a new employee are not empty
@@ -130,6 +237,7 @@ func (s *EmployeeDefault) validateDuplicates(employees map[int]internal.Employee
 // mergeEmployeeFields merges the fields of the input employee with the internal employee
 func mergeEmployeeFields(inputEmployee, internalEmployee internal.Employee) (updatedEmployee internal.Employee) {
 	updatedEmployee.ID = internalEmployee.ID
+	updatedEmployee.Version = internalEmployee.Version
 
 	if inputEmployee.Attributes.FirstName != "" {
 		updatedEmployee.Attributes.FirstName = inputEmployee.Attributes.FirstName
@@ -158,8 +266,8 @@ func mergeEmployeeFields(inputEmployee, internalEmployee internal.Employee) (upd
 	return updatedEmployee
 }
 
-func (s *EmployeeDefault) warehouseExistsByID(id int) error {
-	possibleWarehouse, err := s.warehouseService.GetByID(id)
+func (s *EmployeeDefault) warehouseExistsByID(ctx context.Context, id int) error {
+	possibleWarehouse, err := s.warehouseService.GetByID(ctx, id)
 	// When internal server error
 	if err != nil && err != utils.ErrNotFound {
 		return err
@@ -1,7 +1,9 @@
 package employee
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
@@ -9,40 +11,88 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.EmployeeRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Employees: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type mockEmployeeRepository struct {
 	mock.Mock
 }
 
-func (m *mockEmployeeRepository) FindAll() (map[int]internal.Employee, error) {
+func (m *mockEmployeeRepository) FindAll(ctx context.Context) (map[int]internal.Employee, error) {
 	args := m.Called()
 	return args.Get(0).(map[int]internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeRepository) FindByID(id int) (internal.Employee, error) {
+func (m *mockEmployeeRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Employee, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Employee), args.Error(1)
+}
+
+func (m *mockEmployeeRepository) FindByID(ctx context.Context, id int) (internal.Employee, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeRepository) CreateEmployee(inputEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
+func (m *mockEmployeeRepository) CreateEmployee(ctx context.Context, inputEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
 	args := m.Called(inputEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeRepository) UpdateEmployee(newEmployee internal.Employee) (internal.Employee, error) {
+func (m *mockEmployeeRepository) UpdateEmployee(ctx context.Context, newEmployee internal.Employee) (internal.Employee, error) {
 	args := m.Called(newEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *mockEmployeeRepository) DeleteEmployee(id int) (err error) {
+func (m *mockEmployeeRepository) DeleteEmployee(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockEmployeeRepository) RestoreEmployee(ctx context.Context, id int) (err error) {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockEmployeeRepository) PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 type mockWarehouseValidation struct {
 	mock.Mock
 }
 
-func (m *mockWarehouseValidation) GetByID(id int) (internal.Warehouse, error) {
+func (m *mockWarehouseValidation) GetByID(ctx context.Context, id int) (internal.Warehouse, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
@@ -104,8 +154,8 @@ func TestEmployeeService_FindAll(t *testing.T) {
 	t.Run("FindAll - Success", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindAll").Return(map[int]internal.Employee{1: mockEmployee, 2: mockEmployee2}, nil)
-		service := NewEmployeeService(mockRepo, nil)
-		result, err := service.FindAll()
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		result, err := service.FindAll(context.Background())
 
 		assert.Equal(t, map[int]internal.Employee{1: mockEmployee, 2: mockEmployee2}, result)
 		assert.Nil(t, err)
@@ -114,8 +164,8 @@ func TestEmployeeService_FindAll(t *testing.T) {
 	t.Run("FindAll - Error", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindAll").Return(map[int]internal.Employee{}, assert.AnError)
-		service := NewEmployeeService(mockRepo, nil)
-		result, err := service.FindAll()
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		result, err := service.FindAll(context.Background())
 
 		assert.Equal(t, map[int]internal.Employee{}, result)
 		assert.Error(t, err)
@@ -126,8 +176,8 @@ func TestEmployeeService_FindById(t *testing.T) {
 	t.Run("FindByID - Valid ID", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindByID", 1).Return(mockEmployee, nil)
-		service := NewEmployeeService(mockRepo, nil)
-		result, err := service.FindByID(1)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		result, err := service.FindByID(context.Background(), 1)
 
 		assert.Equal(t, mockEmployee, result)
 		assert.Nil(t, err)
@@ -136,8 +186,8 @@ func TestEmployeeService_FindById(t *testing.T) {
 	t.Run("FindByID - Invalid ID", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindByID", 99).Return(internal.Employee{}, utils.ErrNotFound)
-		service := NewEmployeeService(mockRepo, nil)
-		result, err := service.FindByID(99)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		result, err := service.FindByID(context.Background(), 99)
 
 		assert.Equal(t, internal.Employee{}, result)
 		assert.Equal(t, utils.ErrNotFound, err)
@@ -146,8 +196,8 @@ func TestEmployeeService_FindById(t *testing.T) {
 	t.Run("FindByID - Internal Error", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindByID", 1).Return(internal.Employee{}, assert.AnError)
-		service := NewEmployeeService(mockRepo, nil)
-		result, err := service.FindByID(1)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		result, err := service.FindByID(context.Background(), 1)
 
 		assert.Equal(t, internal.Employee{}, result)
 		assert.NotNil(t, err)
@@ -158,29 +208,38 @@ func TestEmployeeService_Delete(t *testing.T) {
 	t.Run("Delete - Valid ID", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindByID", 1).Return(mockEmployee, nil)
-		mockRepo.On("DeleteEmployee", 1).Return(nil)
-		service := NewEmployeeService(mockRepo, nil)
-		err := service.DeleteEmployee(1)
+		mockRepo.On("DeleteEmployee", 1, mockEmployee.Version).Return(nil)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		err := service.DeleteEmployee(context.Background(), 1, utils.AnyVersion)
 
 		assert.Nil(t, err)
 	})
 
+	t.Run("Delete - Changed Employee", func(t *testing.T) {
+		mockRepo := new(mockEmployeeRepository)
+		mockRepo.On("FindByID", 1).Return(mockEmployee, nil)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		err := service.DeleteEmployee(context.Background(), 1, mockEmployee.Version+1)
+
+		assert.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		mockRepo.AssertNotCalled(t, "DeleteEmployee", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Delete - Invalid ID", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
 		mockRepo.On("FindByID", 99).Return(internal.Employee{}, assert.AnError)
-		mockRepo.On("DeleteEmployee", 99).Return(utils.ErrNotFound)
-		service := NewEmployeeService(mockRepo, nil)
-		err := service.DeleteEmployee(99)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		err := service.DeleteEmployee(context.Background(), 99, utils.AnyVersion)
 
 		assert.Equal(t, utils.ErrNotFound, err)
 	})
 
 	t.Run("Delete - Internal Error", func(t *testing.T) {
 		mockRepo := new(mockEmployeeRepository)
-		mockRepo.On("FindByID", 99).Return(internal.Employee{}, assert.AnError)
-		mockRepo.On("DeleteEmployee", 99).Return(assert.AnError)
-		service := NewEmployeeService(mockRepo, nil)
-		err := service.DeleteEmployee(99)
+		mockRepo.On("FindByID", 99).Return(mockEmployee, nil)
+		mockRepo.On("DeleteEmployee", mockEmployee.ID, mockEmployee.Version).Return(assert.AnError)
+		service := NewEmployeeService(mockRepo, nil, newMockUnitOfWork(mockRepo))
+		err := service.DeleteEmployee(context.Background(), 99, utils.AnyVersion)
 
 		assert.NotNil(t, err)
 	})
@@ -193,8 +252,8 @@ func TestEmployeeService_Update(t *testing.T) {
 		mockWV.On("GetByID", 1).Return(mockWarehouse, nil)
 		mockRepo.On("FindByID", 1).Return(mockEmployee, nil)
 		mockRepo.On("UpdateEmployee", mockInputEmployee).Return(mockInputEmployee, nil)
-		service := NewEmployeeService(mockRepo, mockWV)
-		result, err := service.UpdateEmployee(mockInputEmployee)
+		service := NewEmployeeService(mockRepo, mockWV, newMockUnitOfWork(mockRepo))
+		result, err := service.UpdateEmployee(context.Background(), mockInputEmployee)
 
 		assert.Equal(t, mockInputEmployee, result)
 		assert.Nil(t, err)
@@ -206,8 +265,8 @@ func TestEmployeeService_Update(t *testing.T) {
 		mockWV.On("GetByID", 1).Return(mockWarehouse, nil)
 		mockRepo.On("FindByID", 99).Return(internal.Employee{}, utils.ErrNotFound)
 		mockRepo.On("UpdateEmployee", mockInputEmployeeInvalidID).Return(internal.Employee{}, utils.ErrNotFound)
-		service := NewEmployeeService(mockRepo, mockWV)
-		result, err := service.UpdateEmployee(mockInputEmployeeInvalidID)
+		service := NewEmployeeService(mockRepo, mockWV, newMockUnitOfWork(mockRepo))
+		result, err := service.UpdateEmployee(context.Background(), mockInputEmployeeInvalidID)
 
 		assert.Equal(t, internal.Employee{}, result)
 		assert.NotNil(t, err)
@@ -222,11 +281,15 @@ func TestEmployeeService_Create(t *testing.T) {
 		mockWV.On("GetByID", 1).Return(mockWarehouse, nil)
 		mockRepo.On("FindAll").Return(map[int]internal.Employee{1: mockEmployee}, nil)
 		mockRepo.On("CreateEmployee", mockEmployeeAttr).Return(mockEmployee2, nil)
-		service := NewEmployeeService(mockRepo, mockWV)
-		result, err := service.CreateEmployee(mockEmployeeAttr)
+		uow := newMockUnitOfWork(mockRepo)
+		service := NewEmployeeService(mockRepo, mockWV, uow)
+		result, err := service.CreateEmployee(context.Background(), mockEmployeeAttr)
 
 		assert.Equal(t, mockEmployee2, result)
 		assert.Nil(t, err)
+		assert.Len(t, uow.entries, 1)
+		assert.Equal(t, internal.AuditCreate, uow.entries[0].Action)
+		assert.Equal(t, mockEmployee2.ID, uow.entries[0].EntityID)
 	})
 
 	t.Run("Create - Conflict CardNumberID", func(t *testing.T) {
@@ -235,10 +298,22 @@ func TestEmployeeService_Create(t *testing.T) {
 		mockWV.On("GetByID", 1).Return(mockWarehouse, nil)
 		mockRepo.On("FindAll").Return(map[int]internal.Employee{1: mockEmployee}, nil)
 		mockRepo.On("CreateEmployee", mockEmployeeAttr).Return(internal.Employee{}, utils.ErrConflict)
-		service := NewEmployeeService(mockRepo, mockWV)
-		result, err := service.CreateEmployee(mockEmployeeAttr)
+		service := NewEmployeeService(mockRepo, mockWV, newMockUnitOfWork(mockRepo))
+		result, err := service.CreateEmployee(context.Background(), mockEmployeeAttr)
 
 		assert.Equal(t, internal.Employee{}, result)
 		assert.Equal(t, utils.ErrConflict, err)
 	})
+
+	t.Run("Create - Warehouse Not Assigned", func(t *testing.T) {
+		mockRepo := new(mockEmployeeRepository)
+		mockRepo.On("FindAll").Return(map[int]internal.Employee{1: mockEmployee}, nil)
+		service := NewEmployeeService(mockRepo, new(mockWarehouseValidation), newMockUnitOfWork(mockRepo))
+		operator := internal.Principal{UserID: 1, Role: internal.RoleWarehouseOperator, WarehouseIDs: []int{2}}
+
+		_, err := service.CreateEmployee(internal.WithPrincipal(context.Background(), operator), mockEmployeeAttr)
+
+		assert.ErrorIs(t, err, utils.ErrForbidden)
+		mockRepo.AssertNotCalled(t, "CreateEmployee", mockEmployeeAttr)
+	})
 }
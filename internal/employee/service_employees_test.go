@@ -1,6 +1,7 @@
 package employee_test
 
 import (
+	"context"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/employee"
@@ -8,42 +9,91 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.EmployeeRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Employees: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockEmployeeRepository struct {
 	mock.Mock
 }
 
-func (m *MockEmployeeRepository) FindAll() (employees map[int]internal.Employee, err error) {
+func (m *MockEmployeeRepository) FindAll(ctx context.Context) (employees map[int]internal.Employee, err error) {
 	args := m.Called()
 	return args.Get(0).(map[int]internal.Employee), args.Error(1)
 }
 
-func (m *MockEmployeeRepository) FindByID(id int) (employee internal.Employee, err error) {
+func (m *MockEmployeeRepository) List(ctx context.Context, query utils.ListQuery) (employees []internal.Employee, err error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) FindByID(ctx context.Context, id int) (employee internal.Employee, err error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *MockEmployeeRepository) CreateEmployee(newEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
+func (m *MockEmployeeRepository) CreateEmployee(ctx context.Context, newEmployee internal.EmployeeAttributes) (employee internal.Employee, err error) {
 	args := m.Called(newEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *MockEmployeeRepository) UpdateEmployee(inputEmployee internal.Employee) (employee internal.Employee, err error) {
+func (m *MockEmployeeRepository) UpdateEmployee(ctx context.Context, inputEmployee internal.Employee) (employee internal.Employee, err error) {
 	args := m.Called(inputEmployee)
 	return args.Get(0).(internal.Employee), args.Error(1)
 }
 
-func (m *MockEmployeeRepository) DeleteEmployee(id int) (err error) {
+func (m *MockEmployeeRepository) DeleteEmployee(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) RestoreEmployee(ctx context.Context, id int) (err error) {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockEmployeeRepository) PurgeEmployees(ctx context.Context, before time.Time) (purged int, err error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 type MockWarehouseRepository struct {
 	mock.Mock
 }
 
-func (m *MockWarehouseRepository) GetByID(int) (internal.Warehouse, error) {
+func (m *MockWarehouseRepository) GetByID(context.Context, int) (internal.Warehouse, error) {
 	args := m.Called()
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
@@ -100,11 +150,11 @@ func TestUnitEmployees_GetAllEmployees(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repositoryEmployee := new(MockEmployeeRepository)
 			repositoryWarehouse := new(MockWarehouseRepository)
-			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse)
+			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse, newMockUnitOfWork(repositoryEmployee))
 
 			repositoryEmployee.On("FindAll").Return(tc.mockEmployees, tc.mockError)
 
-			result, err := service.FindAll()
+			result, err := service.FindAll(context.Background())
 
 			require.Equal(t, tc.expectedEmployees, result)
 
@@ -172,13 +222,13 @@ func TestUnitEmployees_FindByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repositoryEmployee := new(MockEmployeeRepository)
 			repositoryWarehouse := new(MockWarehouseRepository)
-			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse)
+			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse, newMockUnitOfWork(repositoryEmployee))
 
 			repositoryEmployee.
 				On("FindByID", tc.paramID).
 				Return(tc.mockEmployee, tc.mockError)
 
-			result, err := service.FindByID(tc.paramID)
+			result, err := service.FindByID(context.Background(), tc.paramID)
 
 			require.Equal(t, tc.expectedEmployee, result)
 
@@ -330,7 +380,7 @@ func TestUnitEmployees_CreateEmployee(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repositoryEmployee := new(MockEmployeeRepository)
 			repositoryWarehouse := new(MockWarehouseRepository)
-			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse)
+			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse, newMockUnitOfWork(repositoryEmployee))
 
 			emptyFields := (tc.inputAttributes.CardNumberID == "" ||
 				tc.inputAttributes.FirstName == "" ||
@@ -362,7 +412,7 @@ func TestUnitEmployees_CreateEmployee(t *testing.T) {
 				}
 			}
 
-			result, err := service.CreateEmployee(tc.inputAttributes)
+			result, err := service.CreateEmployee(context.Background(), tc.inputAttributes)
 
 			require.Equal(t, tc.expectedOutput, result)
 
@@ -488,7 +538,7 @@ func TestUnitEmployees_UpdateEmployee(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repositoryEmployee := new(MockEmployeeRepository)
 			repositoryWarehouse := new(MockWarehouseRepository)
-			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse)
+			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse, newMockUnitOfWork(repositoryEmployee))
 
 			repositoryEmployee.
 				On("FindByID", tc.inputEmployee.ID).
@@ -509,7 +559,7 @@ func TestUnitEmployees_UpdateEmployee(t *testing.T) {
 				}
 			}
 
-			actual, err := service.UpdateEmployee(tc.inputEmployee)
+			actual, err := service.UpdateEmployee(context.Background(), tc.inputEmployee)
 
 			require.Equal(t, tc.expectedEmployee, actual)
 
@@ -531,12 +581,14 @@ func TestUnitEmployees_DeleteEmployee(t *testing.T) {
 		paramID              int
 		mockFindByIDEmployee internal.Employee
 		mockFindByIDError    error
+		paramVersion         int
 		mockDeleteError      error
 		expectedError        error
 	}
 
 	existingEmployee := internal.Employee{
-		ID: 1,
+		ID:      1,
+		Version: 2,
 		Attributes: internal.EmployeeAttributes{
 			CardNumberID: "E001",
 			FirstName:    "Alice",
@@ -554,6 +606,24 @@ func TestUnitEmployees_DeleteEmployee(t *testing.T) {
 			mockDeleteError:      nil,
 			expectedError:        nil,
 		},
+		{
+			name:                 "PRECONDITION_FAILED",
+			paramID:              1,
+			paramVersion:         1,
+			mockFindByIDEmployee: existingEmployee,
+			mockFindByIDError:    nil,
+			mockDeleteError:      nil,
+			expectedError:        utils.EPreconditionFailed("employee"),
+		},
+		{
+			name:                 "PRECONDITION_FAILED_ON_DELETE",
+			paramID:              1,
+			paramVersion:         2,
+			mockFindByIDEmployee: existingEmployee,
+			mockFindByIDError:    nil,
+			mockDeleteError:      utils.EPreconditionFailed("employee"),
+			expectedError:        utils.EPreconditionFailed("employee"),
+		},
 		{
 			name:                 "NOT_FOUND",
 			paramID:              2,
@@ -577,7 +647,7 @@ func TestUnitEmployees_DeleteEmployee(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repositoryEmployee := new(MockEmployeeRepository)
 			repositoryWarehouse := new(MockWarehouseRepository)
-			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse)
+			service := employee.NewEmployeeService(repositoryEmployee, repositoryWarehouse, newMockUnitOfWork(repositoryEmployee))
 
 			repositoryEmployee.
 				On("FindByID", tc.paramID).
@@ -586,12 +656,12 @@ func TestUnitEmployees_DeleteEmployee(t *testing.T) {
 
 			if tc.mockFindByIDError == nil {
 				repositoryEmployee.
-					On("DeleteEmployee", tc.mockFindByIDEmployee.ID).
+					On("DeleteEmployee", tc.mockFindByIDEmployee.ID, tc.mockFindByIDEmployee.Version).
 					Return(tc.mockDeleteError).
 					Maybe()
 			}
 
-			err := service.DeleteEmployee(tc.paramID)
+			err := service.DeleteEmployee(context.Background(), tc.paramID, tc.paramVersion)
 
 			if tc.expectedError == nil {
 				require.NoError(t, err)
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotentResponse is the first response to a request made with an Idempotency-Key, replayed to
+// the retries of the request. A StatusCode of 0 means the request is still in progress
+type IdempotentResponse struct {
+	// Client is the principal that made the request, as Principal.Client, a key is only replayed to it
+	Client string
+	Key    string
+	Method string
+	Path   string
+	// RequestHash is the hash of the payload of the request, a retry must have the same payload
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	// LockedUntil is when a request still in progress is given up, a retry takes its key over after it
+	LockedUntil time.Time
+}
+
+type IdempotencyRepository interface {
+	// Create saves the response of a request in progress, utils.ErrConflict when its key was already used
+	Create(ctx context.Context, response IdempotentResponse) error
+	// Get returns the response of a key of the client, utils.ErrNotFound when the client did not use the key
+	Get(ctx context.Context, client, key, method, path string) (IdempotentResponse, error)
+	// Complete saves the status, content type and body of the response of a request in progress
+	Complete(ctx context.Context, response IdempotentResponse) error
+	Delete(ctx context.Context, client, key, method, path string) error
+	// DeleteBefore removes the responses created before a time, returns how many were removed
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type IdempotencyService interface {
+	// Begin reserves a key for a request of the principal of ctx, it returns the response to replay
+	// when the principal already made the request with the key and nil when the request must be handled
+	Begin(ctx context.Context, key, method, path string, payload []byte) (*IdempotentResponse, error)
+	// Complete saves the response of a request begun with a key
+	Complete(ctx context.Context, key, method, path string, statusCode int, contentType string, body []byte) error
+	// Release frees a key begun by a request that failed, so it can be retried
+	Release(ctx context.Context, key, method, path string) error
+	// Expire removes the responses older than the window of the keys, returns how many were removed
+	Expire(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,129 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+const (
+	// KeyHeader is the key a client makes a request with, its retries are made with the same key
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks the responses replayed to a retry
+	ReplayedHeader = "Idempotent-Replayed"
+	// maxKeyLength is the length of the idempotency_key column
+	maxKeyLength = 255
+)
+
+// Middleware makes the POST requests to the paths idempotent when they are made with an
+// Idempotency-Key. The first response of a key is stored and replayed to the retries with the same
+// payload, the server errors are not stored so the request can be retried. The keys are of the
+// principal of the request, so it must be mounted after the authentication middleware.
+func Middleware(service internal.IdempotencyService, paths ...string) func(http.Handler) http.Handler {
+	idempotentPaths := map[string]bool{}
+	for _, path := range paths {
+		idempotentPaths[strings.TrimSuffix(path, "/")] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimSuffix(r.URL.Path, "/")
+			key := strings.TrimSpace(r.Header.Get(KeyHeader))
+
+			if r.Method != http.MethodPost || !idempotentPaths[path] || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxKeyLength {
+				utils.HandleError(w, utils.EBadRequest(KeyHeader))
+				return
+			}
+
+			payload, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.HandleError(w, utils.EBadRequest("body"))
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+
+			stored, err := service.Begin(r.Context(), key, r.Method, path, payload)
+			if err != nil {
+				utils.HandleError(w, err)
+				return
+			}
+
+			if stored != nil {
+				replay(w, stored)
+				return
+			}
+
+			// the response is sent, so it is stored even when the request is past its deadline
+			ctx := context.WithoutCancel(r.Context())
+
+			// a handler that panics did not complete the request, its key is freed so it can be retried
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if err := service.Release(ctx, key, r.Method, path); err != nil {
+						log.Printf("error releasing %s %s with %s %s: %s", r.Method, r.URL.Path, KeyHeader, key, err.Error())
+					}
+
+					panic(recovered)
+				}
+			}()
+
+			response := &capturedResponse{writer: w, status: http.StatusOK}
+			next.ServeHTTP(response, r)
+
+			if response.status >= http.StatusInternalServerError {
+				err = service.Release(ctx, key, r.Method, path)
+			} else {
+				err = service.Complete(ctx, key, r.Method, path, response.status, w.Header().Get("Content-Type"), response.body.Bytes())
+			}
+
+			if err != nil {
+				log.Printf("error storing the response of %s %s with %s %s: %s", r.Method, r.URL.Path, KeyHeader, key, err.Error())
+			}
+		})
+	}
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, stored *internal.IdempotentResponse) {
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+}
+
+// capturedResponse keeps the status and body of a response written to writer
+type capturedResponse struct {
+	writer http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturedResponse) Header() http.Header {
+	return c.writer.Header()
+}
+
+func (c *capturedResponse) WriteHeader(status int) {
+	c.status = status
+	c.writer.WriteHeader(status)
+}
+
+func (c *capturedResponse) Write(content []byte) (int, error) {
+	c.body.Write(content)
+
+	return c.writer.Write(content)
+}
@@ -0,0 +1,198 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *mockIdempotencyService) Begin(ctx context.Context, key, method, path string, payload []byte) (*internal.IdempotentResponse, error) {
+	args := m.Called(key, method, path, string(payload))
+	return args.Get(0).(*internal.IdempotentResponse), args.Error(1)
+}
+
+func (m *mockIdempotencyService) Complete(ctx context.Context, key, method, path string, statusCode int, contentType string, body []byte) error {
+	args := m.Called(key, method, path, statusCode, contentType, string(body))
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyService) Release(ctx context.Context, key, method, path string) error {
+	args := m.Called(key, method, path)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyService) Expire(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(now)
+	return args.Int(0), args.Error(1)
+}
+
+// newIdempotentRouter returns a router whose POST /api/v1/purchaseOrders responds with the status,
+// and the number of requests the route handled
+func newIdempotentRouter(service internal.IdempotencyService, status int) (*chi.Mux, *int) {
+	handled := 0
+
+	router := chi.NewRouter()
+	router.Use(Middleware(service, "/api/v1/purchaseOrders"))
+
+	router.Route("/api/v1/purchaseOrders", func(router chi.Router) {
+		router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			handled++
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"data":{"id":7}}`))
+		})
+	})
+
+	router.Post("/api/v1/buyers", func(w http.ResponseWriter, r *http.Request) {
+		handled++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	return router, &handled
+}
+
+func newIdempotentRequest(path, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(KeyHeader, key)
+	}
+
+	return req
+}
+
+func TestMiddleware(t *testing.T) {
+	const (
+		path    = "/api/v1/purchaseOrders"
+		payload = `{"order_number":"A1"}`
+	)
+
+	t.Run("handles and stores the first request with a key", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return((*internal.IdempotentResponse)(nil), nil)
+		service.On("Complete", "k1", http.MethodPost, path, http.StatusCreated, "application/json", `{"data":{"id":7}}`).Return(nil)
+
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path+"/", "k1", payload))
+
+		require.Equal(t, http.StatusCreated, res.Code)
+		require.Equal(t, 1, *handled)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("replays the stored response to a retry", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return(&internal.IdempotentResponse{
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Body:        []byte(`{"data":{"id":7}}`),
+		}, nil)
+
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, "k1", payload))
+
+		require.Equal(t, http.StatusCreated, res.Code)
+		require.Equal(t, "application/json", res.Header().Get("Content-Type"))
+		require.Equal(t, "true", res.Header().Get(ReplayedHeader))
+		require.JSONEq(t, `{"data":{"id":7}}`, res.Body.String())
+		require.Zero(t, *handled)
+	})
+
+	t.Run("rejects a retry with another payload", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return((*internal.IdempotentResponse)(nil), utils.EBR("Idempotency-Key was already used with another payload"))
+
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, "k1", payload))
+
+		require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		require.Zero(t, *handled)
+	})
+
+	t.Run("rejects a retry while the request is in progress", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return((*internal.IdempotentResponse)(nil), ErrInProgress)
+
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, "k1", payload))
+
+		require.Equal(t, http.StatusConflict, res.Code)
+		require.Zero(t, *handled)
+	})
+
+	t.Run("releases the key of a server error", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return((*internal.IdempotentResponse)(nil), nil)
+		service.On("Release", "k1", http.MethodPost, path).Return(nil)
+
+		router, _ := newIdempotentRouter(service, http.StatusInternalServerError)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, "k1", payload))
+
+		require.Equal(t, http.StatusInternalServerError, res.Code)
+		service.AssertExpectations(t)
+		service.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("releases the key of a handler that panics", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		service.On("Begin", "k1", http.MethodPost, path, payload).Return((*internal.IdempotentResponse)(nil), nil)
+		service.On("Release", "k1", http.MethodPost, path).Return(nil)
+
+		router := chi.NewRouter()
+		router.Use(Middleware(service, path))
+		router.Post(path, func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+		require.Panics(t, func() { router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest(path, "k1", payload)) })
+		service.AssertExpectations(t)
+	})
+
+	t.Run("rejects a key too long", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, strings.Repeat("k", maxKeyLength+1), payload))
+
+		require.Equal(t, http.StatusBadRequest, res.Code)
+		require.Zero(t, *handled)
+	})
+
+	t.Run("ignores the requests without a key or to other paths", func(t *testing.T) {
+		service := &mockIdempotencyService{}
+		router, handled := newIdempotentRouter(service, http.StatusCreated)
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest(path, "", payload))
+		require.Equal(t, http.StatusCreated, res.Code)
+
+		res = httptest.NewRecorder()
+		router.ServeHTTP(res, newIdempotentRequest("/api/v1/buyers", "k1", payload))
+		require.Equal(t, http.StatusCreated, res.Code)
+
+		require.Equal(t, 2, *handled)
+		service.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+type MySQLIdempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLIdempotencyRepository creates a new MySQLIdempotencyRepository with the given database connection.
+func NewMySQLIdempotencyRepository(db *sql.DB) *MySQLIdempotencyRepository {
+	return &MySQLIdempotencyRepository{db: db}
+}
+
+// Create inserts the response of a request in progress into the idempotency_keys table.
+func (r *MySQLIdempotencyRepository) Create(ctx context.Context, response internal.IdempotentResponse) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO idempotency_keys(client, idempotency_key, method, path, request_hash, created_at, locked_until) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		response.Client, response.Key, response.Method, response.Path, response.RequestHash, response.CreatedAt, response.LockedUntil)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return utils.ErrConflict
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves the response of a key of the client.
+func (r *MySQLIdempotencyRepository) Get(ctx context.Context, client, key, method, path string) (internal.IdempotentResponse, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT client, idempotency_key, method, path, request_hash, status_code, content_type, response, created_at, locked_until FROM idempotency_keys WHERE client = ? AND idempotency_key = ? AND method = ? AND path = ?",
+		client, key, method, path)
+
+	var response internal.IdempotentResponse
+
+	err := row.Scan(&response.Client, &response.Key, &response.Method, &response.Path, &response.RequestHash, &response.StatusCode, &response.ContentType, &response.Body, &response.CreatedAt, &response.LockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.IdempotentResponse{}, utils.ErrNotFound
+		}
+
+		return internal.IdempotentResponse{}, err
+	}
+
+	return response, nil
+}
+
+// Complete updates the status, content type and body of the response of a key of the client.
+func (r *MySQLIdempotencyRepository) Complete(ctx context.Context, response internal.IdempotentResponse) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE idempotency_keys SET status_code = ?, content_type = ?, response = ? WHERE client = ? AND idempotency_key = ? AND method = ? AND path = ?",
+		response.StatusCode, response.ContentType, response.Body, response.Client, response.Key, response.Method, response.Path)
+
+	return err
+}
+
+// Delete removes the response of a key of the client.
+func (r *MySQLIdempotencyRepository) Delete(ctx context.Context, client, key, method, path string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE client = ? AND idempotency_key = ? AND method = ? AND path = ?", client, key, method, path)
+
+	return err
+}
+
+// DeleteBefore removes the responses created before a time.
+func (r *MySQLIdempotencyRepository) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
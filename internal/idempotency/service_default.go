@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// ErrInProgress is the error of a retry made while the first request with its key is being handled
+var ErrInProgress = errors.Join(utils.ErrConflict, errors.New("a request with the Idempotency-Key is in progress, retry it later"))
+
+type DefaultIdempotencyService struct {
+	repo internal.IdempotencyRepository
+	// window is how long a key is kept, a request made with an expired key is handled again
+	window time.Duration
+	// lock is how long a key is reserved for its request, a request that did not complete by then
+	// died with the server and its retries are handled again
+	lock time.Duration
+}
+
+// NewDefaultIdempotencyService creates a new DefaultIdempotencyService with the given repository,
+// the keys expire after the window and are reserved for their requests during the lock.
+func NewDefaultIdempotencyService(repo internal.IdempotencyRepository, window, lock time.Duration) *DefaultIdempotencyService {
+	return &DefaultIdempotencyService{repo: repo, window: window, lock: lock}
+}
+
+// Begin reserves the key for the request, or returns the stored response of the key when its payload
+// is the same. The keys are of the principal of ctx, the same key of another principal is another
+// request. An expired key, or one whose request is in progress past its lock, is reserved again as if
+// it was never used.
+func (s *DefaultIdempotencyService) Begin(ctx context.Context, key, method, path string, payload []byte) (*internal.IdempotentResponse, error) {
+	now := time.Now().UTC()
+	client := clientOf(ctx)
+	pending := internal.IdempotentResponse{
+		Client:      client,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hash(payload),
+		CreatedAt:   now,
+		LockedUntil: now.Add(s.lock),
+	}
+
+	// The key can be released, expire or be given up between the tries, so it is reserved once more
+	for try := 0; try < 2; try++ {
+		err := s.repo.Create(ctx, pending)
+		if err == nil {
+			return nil, nil
+		}
+
+		if !errors.Is(err, utils.ErrConflict) {
+			return nil, err
+		}
+
+		stored, err := s.repo.Get(ctx, client, key, method, path)
+		if errors.Is(err, utils.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		abandoned := stored.StatusCode == 0 && stored.LockedUntil.Before(now)
+		if stored.CreatedAt.Before(now.Add(-s.window)) || abandoned {
+			err = s.repo.Delete(ctx, client, key, method, path)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if stored.RequestHash != pending.RequestHash {
+			return nil, utils.EBR("Idempotency-Key was already used with another payload")
+		}
+
+		if stored.StatusCode == 0 {
+			return nil, ErrInProgress
+		}
+
+		return &stored, nil
+	}
+
+	return nil, ErrInProgress
+}
+
+// Complete saves the response of the request that reserved the key.
+func (s *DefaultIdempotencyService) Complete(ctx context.Context, key, method, path string, statusCode int, contentType string, body []byte) error {
+	return s.repo.Complete(ctx, internal.IdempotentResponse{
+		Client:      clientOf(ctx),
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+	})
+}
+
+// Release removes the key reserved by a request.
+func (s *DefaultIdempotencyService) Release(ctx context.Context, key, method, path string) error {
+	return s.repo.Delete(ctx, clientOf(ctx), key, method, path)
+}
+
+// Expire removes the keys created before now minus the window.
+func (s *DefaultIdempotencyService) Expire(ctx context.Context, now time.Time) (int, error) {
+	return s.repo.DeleteBefore(ctx, now.Add(-s.window))
+}
+
+// clientOf returns the client of the principal of ctx, the requests without one share the empty client
+func clientOf(ctx context.Context) string {
+	principal, ok := internal.PrincipalFromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	return principal.Client()
+}
+
+// hash returns the hash of a payload, the insignificant spaces of a json payload are ignored
+func hash(payload []byte) string {
+	var compacted bytes.Buffer
+	if json.Compact(&compacted, payload) == nil {
+		payload = compacted.Bytes()
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *mockIdempotencyRepository) Create(ctx context.Context, response internal.IdempotentResponse) error {
+	args := m.Called(response)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyRepository) Get(ctx context.Context, client, key, method, path string) (internal.IdempotentResponse, error) {
+	args := m.Called(client, key, method, path)
+	return args.Get(0).(internal.IdempotentResponse), args.Error(1)
+}
+
+func (m *mockIdempotencyRepository) Complete(ctx context.Context, response internal.IdempotentResponse) error {
+	args := m.Called(response)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyRepository) Delete(ctx context.Context, client, key, method, path string) error {
+	args := m.Called(client, key, method, path)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyRepository) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func TestUnitIdempotency_Begin(t *testing.T) {
+	const (
+		client  = "user:3"
+		key     = "k1"
+		method  = "POST"
+		path    = "/api/v1/purchaseOrders"
+		payload = `{"order_number": "A1"}`
+	)
+
+	stored := internal.IdempotentResponse{
+		Client:      client,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hash([]byte(`{"order_number":"A1"}`)),
+		StatusCode:  201,
+		ContentType: "application/json",
+		Body:        []byte(`{"data":{"id":7}}`),
+		CreatedAt:   time.Now().UTC().Add(-time.Hour),
+	}
+
+	inProgress := stored
+	inProgress.StatusCode = 0
+	inProgress.Body = nil
+	inProgress.LockedUntil = time.Now().UTC().Add(time.Minute)
+
+	abandoned := inProgress
+	abandoned.LockedUntil = time.Now().UTC().Add(-time.Second)
+
+	expired := stored
+	expired.CreatedAt = time.Now().UTC().Add(-48 * time.Hour)
+
+	otherPayload := stored
+	otherPayload.RequestHash = hash([]byte(`{"order_number":"B2"}`))
+
+	errDB := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		setup     func(repo *mockIdempotencyRepository)
+		wantReply *internal.IdempotentResponse
+		wantErr   error
+	}{
+		{
+			name: "first request with the key",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name: "retry of a completed request",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(utils.ErrConflict).Once()
+				repo.On("Get", client, key, method, path).Return(stored, nil).Once()
+			},
+			wantReply: &stored,
+		},
+		{
+			name: "retry while the request is in progress",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(utils.ErrConflict).Once()
+				repo.On("Get", client, key, method, path).Return(inProgress, nil).Once()
+			},
+			wantErr: utils.ErrConflict,
+		},
+		{
+			name: "retry of a request given up",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(utils.ErrConflict).Once()
+				repo.On("Get", client, key, method, path).Return(abandoned, nil).Once()
+				repo.On("Delete", client, key, method, path).Return(nil).Once()
+				repo.On("Create", mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name: "retry with another payload",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(utils.ErrConflict).Once()
+				repo.On("Get", client, key, method, path).Return(otherPayload, nil).Once()
+			},
+			wantErr: utils.ErrInvalidArguments,
+		},
+		{
+			name: "request with an expired key",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(utils.ErrConflict).Once()
+				repo.On("Get", client, key, method, path).Return(expired, nil).Once()
+				repo.On("Delete", client, key, method, path).Return(nil).Once()
+				repo.On("Create", mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name: "repository error",
+			setup: func(repo *mockIdempotencyRepository) {
+				repo.On("Create", mock.Anything).Return(errDB).Once()
+			},
+			wantErr: errDB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockIdempotencyRepository{}
+			tt.setup(repo)
+
+			s := NewDefaultIdempotencyService(repo, 24*time.Hour, time.Minute)
+
+			ctx := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 3})
+
+			reply, err := s.Begin(ctx, key, method, path, []byte(payload))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReply, reply)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnitIdempotency_BeginOfAnotherPrincipal(t *testing.T) {
+	const payload = `{"order_number":"A1"}`
+
+	// the key of the buyer 3 is not the one of the buyer 4, each one has its own request
+	repo := &mockIdempotencyRepository{}
+	repo.On("Create", mock.MatchedBy(func(response internal.IdempotentResponse) bool { return response.Client == "user:3" })).Return(nil).Once()
+	repo.On("Create", mock.MatchedBy(func(response internal.IdempotentResponse) bool { return response.Client == "user:4" })).Return(nil).Once()
+
+	s := NewDefaultIdempotencyService(repo, 24*time.Hour, time.Minute)
+
+	for _, userID := range []int{3, 4} {
+		ctx := internal.WithPrincipal(context.Background(), internal.Principal{UserID: userID, Role: internal.RoleBuyer, BuyerID: userID})
+
+		reply, err := s.Begin(ctx, "k1", "POST", "/api/v1/purchaseOrders", []byte(payload))
+		require.NoError(t, err)
+		require.Nil(t, reply)
+	}
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUnitIdempotency_Expire(t *testing.T) {
+	now := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	repo := &mockIdempotencyRepository{}
+	repo.On("DeleteBefore", now.Add(-24*time.Hour)).Return(3, nil)
+
+	expired, err := NewDefaultIdempotencyService(repo, 24*time.Hour, time.Minute).Expire(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, expired)
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "context"
+
 type InboundOrder struct {
 	ID         int                    `json:"id"`
 	Attributes InboundOrderAttributes `json:"attributes"`
@@ -23,14 +25,20 @@ type EmployeeInboundOrdersReport struct {
 }
 
 type InboundOrderService interface {
-	CreateInboundOrder(newOrder InboundOrderAttributes) (InboundOrder, error)
-	GenerateInboundOrdersReport(ids []int) ([]EmployeeInboundOrdersReport, error)
+	CreateInboundOrder(ctx context.Context, newOrder InboundOrderAttributes) (InboundOrder, error)
+	GenerateInboundOrdersReport(ctx context.Context, ids []int) ([]EmployeeInboundOrdersReport, error)
+	// GenerateInboundOrdersReportPage returns up to limit reports of the employees after afterEmployeeID,
+	// the report of every employee is exported a page at a time
+	GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]EmployeeInboundOrdersReport, error)
 }
 
 type InboundOrderRepository interface {
-	CreateInboundOrder(newOrder InboundOrderAttributes) (InboundOrder, error)
-	GenerateInboundOrdersReport() ([]EmployeeInboundOrdersReport, error)
-	GenerateByIDInboundOrdersReport(employeeID int) (EmployeeInboundOrdersReport, error)
-	FindByID(id int) (InboundOrder, error)
-	FindByOrderNumber(orderNumber string) (InboundOrder, error)
+	CreateInboundOrder(ctx context.Context, newOrder InboundOrderAttributes) (InboundOrder, error)
+	GenerateInboundOrdersReport(ctx context.Context) ([]EmployeeInboundOrdersReport, error)
+	GenerateByIDInboundOrdersReport(ctx context.Context, employeeID int) (EmployeeInboundOrdersReport, error)
+	// GenerateInboundOrdersReportPage returns up to limit reports of the employees with an id greater
+	// than afterEmployeeID, ordered by employee id
+	GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]EmployeeInboundOrdersReport, error)
+	FindByID(ctx context.Context, id int) (InboundOrder, error)
+	FindByOrderNumber(ctx context.Context, orderNumber string) (InboundOrder, error)
 }
@@ -1,36 +1,38 @@
 package inbound_order
 
 import (
+	"context"
 	"database/sql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
 type MysqlInboundOrderRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewMySqlInboundOrderRepository(db *sql.DB) internal.InboundOrderRepository {
+func NewMySqlInboundOrderRepository(db utils.DBTX) internal.InboundOrderRepository {
 	return &MysqlInboundOrderRepository{db: db}
 }
 
-func (r *MysqlInboundOrderRepository) CreateInboundOrder(newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
-	result, err := r.db.Exec("INSERT INTO inbound_orders (order_date, order_number, employee_id, product_batch_id, warehouse_id) VALUES (?, ?, ?, ?, ?)", newOrder.OrderDate, newOrder.OrderNumber, newOrder.EmployeeID, newOrder.ProductBatchID, newOrder.WarehouseID)
+func (r *MysqlInboundOrderRepository) CreateInboundOrder(ctx context.Context, newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO inbound_orders (order_date, order_number, employee_id, product_batch_id, warehouse_id) VALUES (?, ?, ?, ?, ?)", newOrder.OrderDate, newOrder.OrderNumber, newOrder.EmployeeID, newOrder.ProductBatchID, newOrder.WarehouseID)
 
 	if err != nil {
 		return internal.InboundOrder{}, err
 	}
 	id, _ := result.LastInsertId()
 
-	return r.FindByID(int(id))
+	return r.FindByID(ctx, int(id))
 }
 
-func (r *MysqlInboundOrderRepository) GenerateInboundOrdersReport() ([]internal.EmployeeInboundOrdersReport, error) {
+func (r *MysqlInboundOrderRepository) GenerateInboundOrdersReport(ctx context.Context) ([]internal.EmployeeInboundOrdersReport, error) {
 	report := []internal.EmployeeInboundOrdersReport{}
-	rows, err := r.db.Query(`
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT e.id, e.id_card_number, e.first_name, e.last_name, e.warehouse_id, COUNT(o.id) as inbound_orders_count
 		FROM employees e
 		LEFT JOIN inbound_orders o ON e.id = o.employee_id
+		WHERE e.deleted_at IS NULL AND `+internal.WarehouseCondition(ctx, "e.warehouse_id")+`
 		GROUP BY e.id
 	`)
 
@@ -50,13 +52,43 @@ func (r *MysqlInboundOrderRepository) GenerateInboundOrdersReport() ([]internal.
 	return report, nil
 }
 
-func (r *MysqlInboundOrderRepository) GenerateByIDInboundOrdersReport(employeeID int) (internal.EmployeeInboundOrdersReport, error) {
+func (r *MysqlInboundOrderRepository) GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]internal.EmployeeInboundOrdersReport, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT e.id, e.id_card_number, e.first_name, e.last_name, e.warehouse_id, COUNT(o.id) as inbound_orders_count
+		FROM employees e
+		LEFT JOIN inbound_orders o ON e.id = o.employee_id
+		WHERE e.id > ? AND e.deleted_at IS NULL AND `+internal.WarehouseCondition(ctx, "e.warehouse_id")+`
+		GROUP BY e.id
+		ORDER BY e.id
+		LIMIT ?
+	`, afterEmployeeID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	report := []internal.EmployeeInboundOrdersReport{}
+
+	for rows.Next() {
+		var row internal.EmployeeInboundOrdersReport
+		if err := rows.Scan(&row.ID, &row.CardNumberID, &row.FirstName, &row.LastName, &row.WarehouseID, &row.InboundOrdersCount); err != nil {
+			return nil, err
+		}
+
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+func (r *MysqlInboundOrderRepository) GenerateByIDInboundOrdersReport(ctx context.Context, employeeID int) (internal.EmployeeInboundOrdersReport, error) {
 	var report internal.EmployeeInboundOrdersReport
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT e.id, e.id_card_number, e.first_name, e.last_name, e.warehouse_id, COUNT(o.id) as inbound_orders_count
 		FROM employees e
 		LEFT JOIN inbound_orders o ON e.id = o.employee_id
-		WHERE e.id = ?
+		WHERE e.id = ? AND e.deleted_at IS NULL AND `+internal.WarehouseCondition(ctx, "e.warehouse_id")+`
 		GROUP BY e.id
 	`, employeeID).Scan(&report.ID, &report.CardNumberID, &report.FirstName, &report.LastName, &report.WarehouseID, &report.InboundOrdersCount)
 
@@ -71,14 +103,14 @@ func (r *MysqlInboundOrderRepository) GenerateByIDInboundOrdersReport(employeeID
 	return report, nil
 }
 
-func (r *MysqlInboundOrderRepository) FindByID(id int) (internal.InboundOrder, error) {
+func (r *MysqlInboundOrderRepository) FindByID(ctx context.Context, id int) (internal.InboundOrder, error) {
 	var order internal.InboundOrder
 	order.Attributes = internal.InboundOrderAttributes{}
 
-	err := r.db.QueryRow(`
-		SELECT id, order_date, order_number, employee_id, product_batch_id, warehouse_id
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, `+utils.DateTime("order_date")+`, order_number, employee_id, product_batch_id, warehouse_id
 		FROM inbound_orders
-		WHERE id = ?`, id).Scan(&order.ID, &order.Attributes.OrderDate, &order.Attributes.OrderNumber, &order.Attributes.EmployeeID, &order.Attributes.ProductBatchID, &order.Attributes.WarehouseID)
+		WHERE id = ? AND `+internal.WarehouseCondition(ctx, "warehouse_id"), id).Scan(&order.ID, &order.Attributes.OrderDate, &order.Attributes.OrderNumber, &order.Attributes.EmployeeID, &order.Attributes.ProductBatchID, &order.Attributes.WarehouseID)
 
 	if err == sql.ErrNoRows {
 		return internal.InboundOrder{}, utils.ErrNotFound
@@ -90,11 +122,11 @@ func (r *MysqlInboundOrderRepository) FindByID(id int) (internal.InboundOrder, e
 	return order, nil
 }
 
-func (r *MysqlInboundOrderRepository) FindByOrderNumber(orderNumber string) (internal.InboundOrder, error) {
+func (r *MysqlInboundOrderRepository) FindByOrderNumber(ctx context.Context, orderNumber string) (internal.InboundOrder, error) {
 	var order internal.InboundOrder
 	order.Attributes = internal.InboundOrderAttributes{}
 
-	err := r.db.QueryRow("SELECT id, order_date, order_number, employee_id, product_batch_id, warehouse_id FROM inbound_orders WHERE order_number = ?", orderNumber).Scan(&order.ID, &order.Attributes.OrderDate, &order.Attributes.OrderNumber, &order.Attributes.EmployeeID, &order.Attributes.ProductBatchID, &order.Attributes.WarehouseID)
+	err := r.db.QueryRowContext(ctx, "SELECT id, "+utils.DateTime("order_date")+", order_number, employee_id, product_batch_id, warehouse_id FROM inbound_orders WHERE order_number = ?", orderNumber).Scan(&order.ID, &order.Attributes.OrderDate, &order.Attributes.OrderNumber, &order.Attributes.EmployeeID, &order.Attributes.ProductBatchID, &order.Attributes.WarehouseID)
 	if err == sql.ErrNoRows {
 		return internal.InboundOrder{}, utils.ErrNotFound
 	}
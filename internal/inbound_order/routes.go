@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 // RegisterInboundOrderRoutes is used to register the routes associated with the inboundOrder entity
@@ -12,11 +13,11 @@ func RegisterInboundOrderRoutes(mux *chi.Mux, service internal.InboundOrderServi
 
 	// POST /api/v1/inboundOrders
 	mux.Route("/api/v1/inboundOrders", func(router chi.Router) {
-		router.Post("/", orderHandler.CreateInboundOrder())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceInboundOrders, rbac.ActionWrite))).Post("/", orderHandler.CreateInboundOrder())
 	})
 
 	mux.Route("/api/v1/employees/reportInboundOrders", func(router chi.Router) {
-		router.Get("/", orderHandler.GenerateInboundOrdersReport())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceInboundOrders, rbac.ActionRead))).Get("/", orderHandler.GenerateInboundOrdersReport())
 	})
 
 	return nil
@@ -1,24 +1,34 @@
 package inbound_order
 
 import (
+	"context"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the inbound orders in the audit trail
+const auditEntity = "inboundOrders"
+
 type InboundOrderService struct {
 	repo internal.InboundOrderRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewInboundOrderService(repo internal.InboundOrderRepository) *InboundOrderService {
-	return &InboundOrderService{repo: repo}
+func NewInboundOrderService(repo internal.InboundOrderRepository, uow internal.UnitOfWork) *InboundOrderService {
+	return &InboundOrderService{repo: repo, uow: uow}
 }
 
-func (s *InboundOrderService) CreateInboundOrder(newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
+func (s *InboundOrderService) CreateInboundOrder(ctx context.Context, newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
 	if newOrder.OrderDate == "" || newOrder.OrderNumber == "" || newOrder.EmployeeID == 0 || newOrder.ProductBatchID == 0 || newOrder.WarehouseID == 0 {
 		return internal.InboundOrder{}, utils.ErrInvalidArguments
 	}
-	_, err := s.repo.FindByID(newOrder.EmployeeID)
+	if err := internal.CheckWarehouseScope(ctx, newOrder.WarehouseID); err != nil {
+		return internal.InboundOrder{}, err
+	}
+	_, err := s.repo.FindByID(ctx, newOrder.EmployeeID)
 	if err != nil && errors.Is(err, utils.ErrNotFound) {
 		return internal.InboundOrder{}, utils.ErrNotFound
 	}
@@ -27,29 +37,38 @@ func (s *InboundOrderService) CreateInboundOrder(newOrder internal.InboundOrderA
 	}
 
 	//Check for duplicate
-	existingOrder, _ := s.repo.FindByOrderNumber(newOrder.OrderNumber)
+	existingOrder, _ := s.repo.FindByOrderNumber(ctx, newOrder.OrderNumber)
 	if existingOrder.ID != 0 {
 		return internal.InboundOrder{}, utils.ErrConflict
 	}
 
-	createdInbound, err := s.repo.CreateInboundOrder(newOrder)
+	var createdInbound internal.InboundOrder
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		createdInbound, err = repos.InboundOrders.CreateInboundOrder(ctx, newOrder)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, createdInbound.ID, nil, createdInbound)
+	})
 
 	return createdInbound, err
 }
 
-func (s *InboundOrderService) GenerateInboundOrdersReport(ids []int) ([]internal.EmployeeInboundOrdersReport, error) {
+func (s *InboundOrderService) GenerateInboundOrdersReport(ctx context.Context, ids []int) ([]internal.EmployeeInboundOrdersReport, error) {
 	var reports []internal.EmployeeInboundOrdersReport
 
 	if len(ids) == 0 {
 		var err error
 
-		reports, err = s.repo.GenerateInboundOrdersReport()
+		reports, err = s.repo.GenerateInboundOrdersReport(ctx)
 		if err != nil {
 			return []internal.EmployeeInboundOrdersReport{}, err
 		}
 	} else {
 		for _, id := range ids {
-			_, err := s.repo.FindByID(id)
+			_, err := s.repo.FindByID(ctx, id)
 			if err != nil && errors.Is(err, utils.ErrNotFound) {
 				return nil, utils.ErrNotFound
 			}
@@ -57,7 +76,7 @@ func (s *InboundOrderService) GenerateInboundOrdersReport(ids []int) ([]internal
 				return nil, err
 			}
 
-			report, err := s.repo.GenerateByIDInboundOrdersReport(id)
+			report, err := s.repo.GenerateByIDInboundOrdersReport(ctx, id)
 			if err != nil {
 				if err == utils.ErrNotFound {
 					return nil, utils.ErrNotFound
@@ -72,3 +91,7 @@ func (s *InboundOrderService) GenerateInboundOrdersReport(ids []int) ([]internal
 
 	return reports, nil
 }
+
+func (s *InboundOrderService) GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]internal.EmployeeInboundOrdersReport, error) {
+	return s.repo.GenerateInboundOrdersReportPage(ctx, afterEmployeeID, limit)
+}
@@ -1,6 +1,7 @@
 package inbound_order_test
 
 import (
+	"context"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 	"github.com/stretchr/testify/require"
@@ -11,29 +12,66 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.InboundOrderRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{InboundOrders: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockInboundOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockInboundOrderRepository) CreateInboundOrder(newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
+func (m *MockInboundOrderRepository) CreateInboundOrder(ctx context.Context, newOrder internal.InboundOrderAttributes) (internal.InboundOrder, error) {
 	args := m.Called(newOrder)
 	return args.Get(0).(internal.InboundOrder), args.Error(1)
 }
-func (m *MockInboundOrderRepository) GenerateInboundOrdersReport() ([]internal.EmployeeInboundOrdersReport, error) {
+func (m *MockInboundOrderRepository) GenerateInboundOrdersReport(ctx context.Context) ([]internal.EmployeeInboundOrdersReport, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.EmployeeInboundOrdersReport), args.Error(1)
 }
-func (m *MockInboundOrderRepository) GenerateByIDInboundOrdersReport(employeeID int) (internal.EmployeeInboundOrdersReport, error) {
+func (m *MockInboundOrderRepository) GenerateInboundOrdersReportPage(ctx context.Context, afterEmployeeID, limit int) ([]internal.EmployeeInboundOrdersReport, error) {
+	args := m.Called(afterEmployeeID, limit)
+	return args.Get(0).([]internal.EmployeeInboundOrdersReport), args.Error(1)
+}
+func (m *MockInboundOrderRepository) GenerateByIDInboundOrdersReport(ctx context.Context, employeeID int) (internal.EmployeeInboundOrdersReport, error) {
 	args := m.Called(employeeID)
 	return args.Get(0).(internal.EmployeeInboundOrdersReport), args.Error(1)
 }
 
-func (m *MockInboundOrderRepository) FindByID(id int) (internal.InboundOrder, error) {
+func (m *MockInboundOrderRepository) FindByID(ctx context.Context, id int) (internal.InboundOrder, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.InboundOrder), args.Error(1)
 }
 
-func (m *MockInboundOrderRepository) FindByOrderNumber(orderNumber string) (internal.InboundOrder, error) {
+func (m *MockInboundOrderRepository) FindByOrderNumber(ctx context.Context, orderNumber string) (internal.InboundOrder, error) {
 	args := m.Called(orderNumber)
 	return args.Get(0).(internal.InboundOrder), args.Error(1)
 }
@@ -117,13 +155,13 @@ func TestUnitInboundOrder_CreateInboundOrder(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository := new(MockInboundOrderRepository)
-			service := inbound_order.NewInboundOrderService(repository)
+			service := inbound_order.NewInboundOrderService(repository, newMockUnitOfWork(repository))
 
 			if tc.mockSetup != nil {
 				tc.mockSetup(repository)
 			}
 
-			newOrder, err := service.CreateInboundOrder(tc.input)
+			newOrder, err := service.CreateInboundOrder(context.Background(), tc.input)
 
 			if tc.expectedError != nil {
 				require.ErrorIs(t, err, tc.expectedError)
@@ -206,7 +244,7 @@ func TestUnitInboundOrder_GenerateInboundOrdersReport(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			repo := new(MockInboundOrderRepository)
-			service := inbound_order.NewInboundOrderService(repo)
+			service := inbound_order.NewInboundOrderService(repo, newMockUnitOfWork(repo))
 
 			if len(tc.ids) == 0 {
 				repo.
@@ -229,7 +267,7 @@ func TestUnitInboundOrder_GenerateInboundOrdersReport(t *testing.T) {
 				}
 			}
 
-			result, err := service.GenerateInboundOrdersReport(tc.ids)
+			result, err := service.GenerateInboundOrdersReport(context.Background(), tc.ids)
 
 			if tc.expectedError == nil {
 				require.NoError(t, err)
@@ -1,9 +1,19 @@
 package internal
 
+import (
+	"context"
+	"io"
+	"strconv"
+)
+
 type Locality struct {
-	ID           int    `json:"id"`
-	LocalityName string `json:"locality_name"`
-	ProvinceID   int    `json:"province_id"`
+	ID           int      `json:"id"`
+	LocalityName string   `json:"locality_name"`
+	ProvinceID   int      `json:"province_id"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	// Version is bumped by every change, it is the ETag of the locality
+	Version int `json:"version,omitempty"`
 }
 
 type SellersByLocality struct {
@@ -18,15 +28,88 @@ type CarriesByLocality struct {
 	CarriesCount int    `json:"carries_count"`
 }
 
+// LocalityReferences counts the sellers, warehouses and carriers located in a locality,
+// or in the localities of a province or country
+type LocalityReferences struct {
+	Sellers    int `json:"sellers"`
+	Warehouses int `json:"warehouses"`
+	Carriers   int `json:"carriers"`
+}
+
+// Total returns the number of references
+func (r LocalityReferences) Total() int {
+	return r.Sellers + r.Warehouses + r.Carriers
+}
+
+// String describes the references, e.g. "2 sellers, 1 warehouses and 0 carriers"
+func (r LocalityReferences) String() string {
+	return strconv.Itoa(r.Sellers) + " sellers, " + strconv.Itoa(r.Warehouses) + " warehouses and " +
+		strconv.Itoa(r.Carriers) + " carriers"
+}
+
+const (
+	LocalityImportCreated   = "created"
+	LocalityImportUpdated   = "updated"
+	LocalityImportUnchanged = "unchanged"
+	LocalityImportFailed    = "failed"
+)
+
+// LocalityImportResult is the outcome of importing a row
+type LocalityImportResult struct {
+	Line       int    `json:"line"`
+	LocalityID int    `json:"locality_id,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// LocalityImportReport summarises an import with the result of every row
+type LocalityImportReport struct {
+	Created   int                    `json:"created"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Failed    int                    `json:"failed"`
+	Rows      []LocalityImportResult `json:"rows"`
+}
+
 type LocalityRepository interface {
-	Save(*Locality) error
-	GetByID(id int) (Locality, error)
-	GetSellersByLocalityID(localityID int) ([]SellersByLocality, error)
-	GetCarriesByLocalityID(localityID int) ([]CarriesByLocality, error)
+	Save(context.Context, *Locality) error
+	GetByID(ctx context.Context, id int) (Locality, error)
+	// GetByIDs returns the localities with the given ids, the ids without a locality are ignored
+	GetByIDs(ctx context.Context, ids []int) ([]Locality, error)
+	GetAll(ctx context.Context) ([]Locality, error)
+	GetByProvinceID(ctx context.Context, provinceID int) ([]Locality, error)
+	// GetByProvinceIDs returns the localities of the provinces with the given ids, ordered by id
+	GetByProvinceIDs(ctx context.Context, provinceIDs []int) ([]Locality, error)
+	Update(context.Context, *Locality) error
+	// Delete removes the locality when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) error
+	GetReferences(ctx context.Context, id int) (LocalityReferences, error)
+	GetSellersByLocalityID(ctx context.Context, localityID int) ([]SellersByLocality, error)
+	GetCarriesByLocalityID(ctx context.Context, localityID int) ([]CarriesByLocality, error)
+	// GetSellersByLocalityPage returns up to limit seller counts of the localities with an id greater
+	// than afterLocalityID, ordered by locality id
+	GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]SellersByLocality, error)
+	// GetCarriesByLocalityPage returns up to limit carrier counts of the localities with an id greater
+	// than afterLocalityID, ordered by locality id
+	GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]CarriesByLocality, error)
 }
 
 type LocalityService interface {
-	Save(*Locality, *Province, *Country) error
-	GetSellersByLocalityID(localityID int) ([]SellersByLocality, error)
-	GetCarriesByLocalityID(localityID int) ([]CarriesByLocality, error)
+	Save(context.Context, *Locality, *Province, *Country) error
+	GetAll(ctx context.Context) ([]Locality, error)
+	GetByID(ctx context.Context, id int) (Locality, error)
+	Update(context.Context, *Locality) error
+	// Delete removes the locality in the version given
+	Delete(ctx context.Context, id int, version int) error
+	// Import upserts the countries, provinces and localities of a CSV file, rows failing validation
+	// are reported and don't stop the import
+	Import(ctx context.Context, file io.Reader) (LocalityImportReport, error)
+	GetSellersByLocalityID(ctx context.Context, localityID int) ([]SellersByLocality, error)
+	GetCarriesByLocalityID(ctx context.Context, localityID int) ([]CarriesByLocality, error)
+	// GetSellersByLocalityPage returns up to limit seller counts of the localities after
+	// afterLocalityID, the report of every locality is exported a page at a time
+	GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]SellersByLocality, error)
+	// GetCarriesByLocalityPage returns up to limit carrier counts of the localities after
+	// afterLocalityID, the report of every locality is exported a page at a time
+	GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]CarriesByLocality, error)
 }
@@ -1,6 +1,7 @@
 package locality
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -9,18 +10,18 @@ import (
 )
 
 type MysqlLocalityRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
 // NewMysqlLocalityRepository creates a new instance of MysqlLocalityRepository with the given database connection.
 // It returns an implementation of the LocalityRepository interface.
 //
 // Parameters:
-//   - db: A pointer to an sql.DB instance representing the database connection.
+//   - db: The database connection, or the transaction of a unit of work.
 //
 // Returns:
 //   - An implementation of the LocalityRepository interface.
-func NewMysqlLocalityRepository(db *sql.DB) internal.LocalityRepository {
+func NewMysqlLocalityRepository(db utils.DBTX) internal.LocalityRepository {
 	return &MysqlLocalityRepository{db: db}
 }
 
@@ -35,17 +36,19 @@ func NewMysqlLocalityRepository(db *sql.DB) internal.LocalityRepository {
 // Returns:
 //
 //	error: An error object if there is an issue with preparing or executing the SQL statement, otherwise nil.
-func (r *MysqlLocalityRepository) Save(locality *internal.Locality) error {
-	stmt, err := r.db.Prepare("INSERT INTO localities(id, locality_name, province_id) VALUES(?, ?, ?);")
+func (r *MysqlLocalityRepository) Save(ctx context.Context, locality *internal.Locality) error {
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO localities(id, locality_name, province_id, latitude, longitude) VALUES(?, ?, ?, ?, ?);")
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(locality.ID, locality.LocalityName, locality.ProvinceID)
+	_, err = stmt.ExecContext(ctx, locality.ID, locality.LocalityName, locality.ProvinceID, locality.Latitude, locality.Longitude)
 	if err != nil {
 		return err
 	}
 
+	locality.Version = 1
+
 	return nil
 }
 
@@ -61,18 +64,18 @@ func (r *MysqlLocalityRepository) Save(locality *internal.Locality) error {
 // Returns:
 //   - internal.Locality: The locality with the specified ID.
 //   - error: An error if the locality is not found or if any other error occurs.
-func (r *MysqlLocalityRepository) GetByID(id int) (internal.Locality, error) {
-	stmt, err := r.db.Prepare("SELECT id, locality_name, province_id FROM localities WHERE id=?;")
+func (r *MysqlLocalityRepository) GetByID(ctx context.Context, id int) (internal.Locality, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, locality_name, province_id, latitude, longitude, version FROM localities WHERE id=?;")
 	if err != nil {
 		return internal.Locality{}, err
 	}
 
 	defer stmt.Close()
-	row := stmt.QueryRow(id)
+	row := stmt.QueryRowContext(ctx, id)
 
 	var locality internal.Locality
 
-	err = row.Scan(&locality.ID, &locality.LocalityName, &locality.ProvinceID)
+	err = row.Scan(&locality.ID, &locality.LocalityName, &locality.ProvinceID, &locality.Latitude, &locality.Longitude, &locality.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return internal.Locality{}, utils.ErrNotFound
@@ -84,6 +87,41 @@ func (r *MysqlLocalityRepository) GetByID(id int) (internal.Locality, error) {
 	return locality, nil
 }
 
+// GetByIDs retrieves the localities with the given IDs in a single query, the IDs without a locality are ignored.
+func (r *MysqlLocalityRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	localities := []internal.Locality{}
+	if len(ids) == 0 {
+		return localities, nil
+	}
+
+	in, args := utils.InClause(ids)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, locality_name, province_id, latitude, longitude, version FROM localities WHERE id IN "+in, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var locality internal.Locality
+
+		err = rows.Scan(&locality.ID, &locality.LocalityName, &locality.ProvinceID, &locality.Latitude, &locality.Longitude, &locality.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		localities = append(localities, locality)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return localities, nil
+}
+
 // GetSellersByLocalityID retrieves a list of sellers by locality ID.
 // If the localityId is 0, it retrieves the count of sellers for all localities.
 // Otherwise, it retrieves the count of sellers for the specified locality ID.
@@ -94,7 +132,7 @@ func (r *MysqlLocalityRepository) GetByID(id int) (internal.Locality, error) {
 // Returns:
 //   - []internal.SellersByLocality: a slice of SellersByLocality containing the locality ID, locality name, and sellers count.
 //   - error: an error if the query fails or if there is an issue scanning the rows.
-func (r *MysqlLocalityRepository) GetSellersByLocalityID(localityID int) ([]internal.SellersByLocality, error) {
+func (r *MysqlLocalityRepository) GetSellersByLocalityID(ctx context.Context, localityID int) ([]internal.SellersByLocality, error) {
 	report := []internal.SellersByLocality{}
 
 	var rows *sql.Rows
@@ -102,9 +140,9 @@ func (r *MysqlLocalityRepository) GetSellersByLocalityID(localityID int) ([]inte
 	if localityID == 0 {
 		var err error
 
-		rows, err = r.db.Query(`SELECT l.id, l.locality_name, COUNT(s.id) AS 'sellers_count' 
+		rows, err = r.db.QueryContext(ctx, `SELECT l.id, l.locality_name, COUNT(s.id) AS 'sellers_count' 
 			FROM localities l 
-			INNER JOIN sellers s ON s.locality_id=l.id 
+			INNER JOIN sellers s ON s.locality_id=l.id AND s.deleted_at IS NULL
 			GROUP BY l.id;`)
 		if err != nil {
 			return []internal.SellersByLocality{}, err
@@ -112,9 +150,9 @@ func (r *MysqlLocalityRepository) GetSellersByLocalityID(localityID int) ([]inte
 
 		defer rows.Close()
 	} else {
-		stmt, err := r.db.Prepare(`SELECT l.id, l.locality_name, COUNT(s.id) AS 'sellers_count' 
+		stmt, err := r.db.PrepareContext(ctx, `SELECT l.id, l.locality_name, COUNT(s.id) AS 'sellers_count' 
 			FROM localities l 
-			INNER JOIN sellers s ON s.locality_id=l.id 
+			INNER JOIN sellers s ON s.locality_id=l.id AND s.deleted_at IS NULL
 			WHERE l.id = ?
 			GROUP BY l.id;`)
 		if err != nil {
@@ -123,7 +161,7 @@ func (r *MysqlLocalityRepository) GetSellersByLocalityID(localityID int) ([]inte
 
 		defer stmt.Close()
 
-		rows, err = stmt.Query(localityID)
+		rows, err = stmt.QueryContext(ctx, localityID)
 		if err != nil {
 			return []internal.SellersByLocality{}, err
 		}
@@ -154,7 +192,7 @@ func (r *MysqlLocalityRepository) GetSellersByLocalityID(localityID int) ([]inte
 // Returns:
 //   - []internal.CarriesByLocality: A slice of CarriesByLocality structs containing the locality ID, locality name, and carrier count.
 //   - error: An error object if an error occurred during the query execution.
-func (r *MysqlLocalityRepository) GetCarriesByLocalityID(localityID int) ([]internal.CarriesByLocality, error) {
+func (r *MysqlLocalityRepository) GetCarriesByLocalityID(ctx context.Context, localityID int) ([]internal.CarriesByLocality, error) {
 	report := []internal.CarriesByLocality{}
 
 	var rows *sql.Rows
@@ -162,24 +200,24 @@ func (r *MysqlLocalityRepository) GetCarriesByLocalityID(localityID int) ([]inte
 	if localityID == 0 {
 		var err error
 
-		rows, err = r.db.Query(`SELECT l.id, l.locality_name, COUNT(c.id) AS 'carries_count' 
+		rows, err = r.db.QueryContext(ctx, `SELECT l.id, l.locality_name, COUNT(c.id) AS 'carries_count' 
 			FROM localities l 
-			INNER JOIN carriers c ON c.locality_id=l.id 
+			INNER JOIN carriers c ON c.locality_id=l.id AND c.deleted_at IS NULL
 			GROUP BY l.id;`)
 		if err != nil {
 			return []internal.CarriesByLocality{}, err
 		}
 	} else {
-		stmt, err := r.db.Prepare(`SELECT l.id, l.locality_name, COUNT(c.id) AS 'carries_count' 
+		stmt, err := r.db.PrepareContext(ctx, `SELECT l.id, l.locality_name, COUNT(c.id) AS 'carries_count' 
 			FROM localities l 
-			INNER JOIN carriers c ON c.locality_id=l.id 
+			INNER JOIN carriers c ON c.locality_id=l.id AND c.deleted_at IS NULL
 			WHERE l.id = ?
 			GROUP BY l.id;`)
 		if err != nil {
 			return []internal.CarriesByLocality{}, err
 		}
 
-		rows, err = stmt.Query(localityID)
+		rows, err = stmt.QueryContext(ctx, localityID)
 		if err != nil {
 			return []internal.CarriesByLocality{}, err
 		}
@@ -198,3 +236,206 @@ func (r *MysqlLocalityRepository) GetCarriesByLocalityID(localityID int) ([]inte
 
 	return report, nil
 }
+
+// GetSellersByLocalityPage retrieves the count of sellers of up to limit localities with an id greater
+// than afterLocalityID, ordered by locality id.
+func (r *MysqlLocalityRepository) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT l.id, l.locality_name, COUNT(s.id) AS 'sellers_count'
+		FROM localities l
+		INNER JOIN sellers s ON s.locality_id=l.id AND s.deleted_at IS NULL
+		WHERE l.id > ?
+		GROUP BY l.id
+		ORDER BY l.id
+		LIMIT ?`, afterLocalityID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	report := []internal.SellersByLocality{}
+
+	for rows.Next() {
+		var row internal.SellersByLocality
+
+		err := rows.Scan(&row.LocalityID, &row.LocalityName, &row.SellersCount)
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// GetCarriesByLocalityPage retrieves the count of carriers of up to limit localities with an id greater
+// than afterLocalityID, ordered by locality id.
+func (r *MysqlLocalityRepository) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT l.id, l.locality_name, COUNT(c.id) AS 'carries_count'
+		FROM localities l
+		INNER JOIN carriers c ON c.locality_id=l.id AND c.deleted_at IS NULL
+		WHERE l.id > ?
+		GROUP BY l.id
+		ORDER BY l.id
+		LIMIT ?`, afterLocalityID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	report := []internal.CarriesByLocality{}
+
+	for rows.Next() {
+		var row internal.CarriesByLocality
+
+		err := rows.Scan(&row.LocalityID, &row.LocalityName, &row.CarriesCount)
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
+
+// GetAll retrieves all the localities ordered by id.
+//
+// Returns:
+//   - []internal.Locality: the localities.
+//   - error: an error if the query fails or if there is an issue scanning the rows.
+func (r *MysqlLocalityRepository) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, locality_name, province_id, latitude, longitude, version FROM localities ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLocalities(rows)
+}
+
+// GetByProvinceID retrieves the localities of a province ordered by id.
+//
+// Parameters:
+//   - provinceID: the ID of the province.
+//
+// Returns:
+//   - []internal.Locality: the localities of the province, empty when it has none.
+//   - error: an error if the query fails or if there is an issue scanning the rows.
+func (r *MysqlLocalityRepository) GetByProvinceID(ctx context.Context, provinceID int) ([]internal.Locality, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, locality_name, province_id, latitude, longitude, version FROM localities WHERE province_id=? ORDER BY id;", provinceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLocalities(rows)
+}
+
+func (r *MysqlLocalityRepository) GetByProvinceIDs(ctx context.Context, provinceIDs []int) ([]internal.Locality, error) {
+	if len(provinceIDs) == 0 {
+		return []internal.Locality{}, nil
+	}
+
+	in, args := utils.InClause(provinceIDs)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, locality_name, province_id, latitude, longitude, version FROM localities WHERE province_id IN "+in+" ORDER BY id;", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLocalities(rows)
+}
+
+// Update changes the name, province and coordinates of a locality.
+//
+// Parameters:
+//   - locality: a pointer to the Locality with the new values, in the version it was read.
+//
+// Returns:
+//   - error: utils.ErrNotFound if the locality doesn't exist, utils.ErrPreconditionFailed if it was
+//     changed since it was read, or an error if the statement fails.
+func (r *MysqlLocalityRepository) Update(ctx context.Context, locality *internal.Locality) error {
+	if _, err := r.GetByID(ctx, locality.ID); err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, "UPDATE localities SET locality_name=?, province_id=?, latitude=?, longitude=?, version=version+1 WHERE id=? AND version=?;",
+		locality.LocalityName, locality.ProvinceID, locality.Latitude, locality.Longitude, locality.ID, locality.Version)
+	if err != nil {
+		return utils.MapMySQLError(err)
+	}
+
+	if err = utils.CheckVersionUpdated(result, "locality"); err != nil {
+		return err
+	}
+
+	locality.Version++
+
+	return nil
+}
+
+// Delete removes a locality when it is still in the version it was read.
+//
+// Parameters:
+//   - id: the ID of the locality to delete.
+//   - version: the version the locality was read in.
+//
+// Returns:
+//   - error: utils.ErrPreconditionFailed if the locality was changed or removed since it was read, utils.ErrInUse if other entities
+//     still refer to it, or an error if the statement fails.
+func (r *MysqlLocalityRepository) Delete(ctx context.Context, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM localities WHERE id=? AND version=?;", id, version)
+	if err != nil {
+		return utils.MapMySQLError(err)
+	}
+
+	return utils.CheckVersionUpdated(result, "locality")
+}
+
+// GetReferences counts the sellers, warehouses and carriers located in a locality.
+//
+// Parameters:
+//   - id: the ID of the locality.
+//
+// Returns:
+//   - internal.LocalityReferences: the number of sellers, warehouses and carriers.
+//   - error: an error if the query fails.
+func (r *MysqlLocalityRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	var references internal.LocalityReferences
+
+	err := r.db.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM sellers WHERE locality_id = ?),
+			(SELECT COUNT(*) FROM warehouses WHERE locality_id = ?),
+			(SELECT COUNT(*) FROM carriers WHERE locality_id = ?);`, id, id, id).
+		Scan(&references.Sellers, &references.Warehouses, &references.Carriers)
+	if err != nil {
+		return internal.LocalityReferences{}, err
+	}
+
+	return references, nil
+}
+
+func scanLocalities(rows *sql.Rows) ([]internal.Locality, error) {
+	defer rows.Close()
+
+	localities := []internal.Locality{}
+
+	for rows.Next() {
+		var locality internal.Locality
+
+		err := rows.Scan(&locality.ID, &locality.LocalityName, &locality.ProvinceID, &locality.Latitude, &locality.Longitude, &locality.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		localities = append(localities, locality)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return localities, nil
+}
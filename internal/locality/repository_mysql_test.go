@@ -1,6 +1,7 @@
 package locality_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -32,7 +33,7 @@ func TestIntegrationLocality_GetById(t *testing.T) {
 	repo := locality.NewMysqlLocalityRepository(db)
 
 	t.Run("Given an existing ID, return the ", func(t *testing.T) {
-		locality, err := repo.GetByID(1)
+		locality, err := repo.GetByID(context.Background(), 1)
 		require.NoError(t, err)
 		require.Equal(t, "Los Angeles", locality.LocalityName)
 		require.NotZero(t, locality.ProvinceID)
@@ -40,7 +41,7 @@ func TestIntegrationLocality_GetById(t *testing.T) {
 	})
 
 	t.Run("Given a not existing ID, return empty locality and utils.ErrNotFound", func(t *testing.T) {
-		locality, err := repo.GetByID(9999)
+		locality, err := repo.GetByID(context.Background(), 9999)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Empty(t, locality)
 	})
@@ -59,7 +60,7 @@ func TestIntegrationLocality_Save(t *testing.T) {
 			LocalityName: "Stella Castle",
 			ProvinceID:   1,
 		}
-		err := repo.Save(&newLocality)
+		err := repo.Save(context.Background(), &newLocality)
 		require.NoError(t, err)
 		require.Equal(t, "Stella Castle", newLocality.LocalityName)
 		require.NotZero(t, newLocality.ProvinceID)
@@ -74,13 +75,13 @@ func TestIntegrationLocality_GetSellersByLocalityId(t *testing.T) {
 
 	repo := locality.NewMysqlLocalityRepository(db)
 	t.Run("Given an existing locality ID, return the sellers count for that ID", func(t *testing.T) {
-		report, err := repo.GetSellersByLocalityID(1)
+		report, err := repo.GetSellersByLocalityID(context.Background(), 1)
 		require.NoError(t, err)
 		require.Len(t, report, 1)
 	})
 
 	t.Run("Given an 0 as locality ID, return the sellers count for all locations", func(t *testing.T) {
-		report, err := repo.GetSellersByLocalityID(0)
+		report, err := repo.GetSellersByLocalityID(context.Background(), 0)
 		require.NoError(t, err)
 		require.Len(t, report, 2)
 	})
@@ -4,15 +4,21 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 func NewLocalityRoutes(mux *chi.Mux, service internal.LocalityService) error {
 	localityHandler := handler.NewLocalityHandler(service)
 
 	mux.Route("/api/v1/localities", func(router chi.Router) {
-		router.Get("/reportSellers", localityHandler.GetSellersByLocalityID())
-		router.Post("/", localityHandler.CreateLocality())
-		router.Get("/reportCarries", localityHandler.GetCarriesByLocalityID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/reportSellers", localityHandler.GetSellersByLocalityID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Post("/", localityHandler.CreateLocality())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Post("/import", localityHandler.ImportLocalities())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/reportCarries", localityHandler.GetCarriesByLocalityID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/", localityHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/{id}", localityHandler.GetByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Patch("/{id}", localityHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionDelete))).Delete("/{id}", localityHandler.Delete())
 	})
 
 	return nil
@@ -1,23 +1,41 @@
 package locality
 
 import (
+	"context"
 	"errors"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// Names of the entities in the audit trail
+const (
+	auditEntity         = "localities"
+	auditProvinceEntity = "provinces"
+	auditCountryEntity  = "countries"
+)
+
 type BasicLocalityService struct {
 	localityRepo internal.LocalityRepository
 	provinceRepo internal.ProvinceRepository
 	countryRepo  internal.CountryRepository
+	// uow saves a locality along with its province and country in a single transaction, with their
+	// audit entries
+	uow internal.UnitOfWork
 }
 
 func NewBasicLocalityService(
 	lr internal.LocalityRepository,
 	pr internal.ProvinceRepository,
-	cr internal.CountryRepository) internal.LocalityService {
+	cr internal.CountryRepository,
+	uow internal.UnitOfWork) internal.LocalityService {
 	return &BasicLocalityService{
-		lr, pr, cr,
+		lr, pr, cr, uow,
 	}
 }
 
@@ -27,6 +45,8 @@ func NewBasicLocalityService(
 // If the country does not exist, it creates a new country entry.
 // If the province does not exist, it creates a new province entry.
 // Finally, it saves the locality with the associated province and country IDs.
+// The country, province and locality are saved in a single transaction, none of them is
+// saved when one fails.
 //
 // Parameters:
 //
@@ -37,7 +57,7 @@ func NewBasicLocalityService(
 // Returns:
 //
 //	error - an error if any validation or save operation fails, otherwise nil
-func (s *BasicLocalityService) Save(locality *internal.Locality, province *internal.Province, country *internal.Country) error {
+func (s *BasicLocalityService) Save(ctx context.Context, locality *internal.Locality, province *internal.Province, country *internal.Country) error {
 	if locality.LocalityName == "" {
 		return utils.EZeroValue("locality_name")
 	}
@@ -54,69 +74,85 @@ func (s *BasicLocalityService) Save(locality *internal.Locality, province *inter
 		return utils.EZeroValue("country_name")
 	}
 
-	// If locality exists by id
-	// Check for error 500
-	possibleLocality, err := s.localityRepo.GetByID(locality.ID)
-	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+	if err := utils.ValidateCoordinates(locality.Latitude, locality.Longitude); err != nil {
 		return err
 	}
 
-	if possibleLocality != (internal.Locality{}) {
-		return utils.EConflict("id", "locality")
-	}
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		// If locality exists by id
+		// Check for error 500
+		possibleLocality, err := repos.Localities.GetByID(ctx, locality.ID)
+		if err != nil && !errors.Is(err, utils.ErrNotFound) {
+			return err
+		}
+
+		if possibleLocality != (internal.Locality{}) {
+			return utils.EConflict("id", "locality")
+		}
 
-	// Check if we find a country by its name
-	possibleCountry, err := s.countryRepo.GetByName(country.CountryName)
+		// Check if we find a country by its name
+		possibleCountry, err := repos.Countries.GetByName(ctx, country.CountryName)
 
-	// We find the country
-	if err == nil {
-		country.ID = possibleCountry.ID
-	} else if errors.Is(err, utils.ErrNotFound) { // We need to create the country
-		if err = s.countryRepo.Save(country); err != nil {
-			// Internal error
+		// We find the country
+		if err == nil {
+			country.ID = possibleCountry.ID
+		} else if errors.Is(err, utils.ErrNotFound) { // We need to create the country
+			if err = repos.Countries.Save(ctx, country); err != nil {
+				// Internal error
+				return err
+			}
+
+			if err = audit.Record(ctx, repos.Audit, internal.AuditCreate, auditCountryEntity, country.ID, nil, country); err != nil {
+				return err
+			}
+		} else { // Internal error
 			return err
 		}
-	} else { // Internal error
-		return err
-	}
 
-	(*province).CountryID = country.ID
+		(*province).CountryID = country.ID
+
+		// Check if we find a province by its name
+		possibleProvince, err := repos.Provinces.GetByName(ctx, province.ProvinceName)
 
-	// Check if we find a province by its name
-	possibleProvince, err := s.provinceRepo.GetByName(province.ProvinceName)
+		if err == nil {
+			province.ID = possibleProvince.ID
+		} else if errors.Is(err, utils.ErrNotFound) {
+			if err = repos.Provinces.Save(ctx, province); err != nil {
+				// Internal error
+				return err
+			}
 
-	if err == nil {
-		province.ID = possibleProvince.ID
-	} else if errors.Is(err, utils.ErrNotFound) {
-		if err = s.provinceRepo.Save(province); err != nil {
-			// Internal error
+			if err = audit.Record(ctx, repos.Audit, internal.AuditCreate, auditProvinceEntity, province.ID, nil, province); err != nil {
+				return err
+			}
+		} else { // Internal error
 			return err
 		}
-	} else { // Internal error
-		return err
-	}
 
-	(*locality).ProvinceID = province.ID
+		(*locality).ProvinceID = province.ID
 
-	if err = s.localityRepo.Save(locality); err != nil {
-		return err
-	}
+		if err = repos.Localities.Save(ctx, locality); err != nil {
+			return err
+		}
 
-	return nil
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, locality.ID, nil, locality)
+	})
 }
 
 // GetSellersByLocalityID the sellers quantity by there location
 // if id == 0, then all location are returned
-func (s *BasicLocalityService) GetSellersByLocalityID(localityID int) ([]internal.SellersByLocality, error) {
-	if localityID <= 0 {
+func (s *BasicLocalityService) GetSellersByLocalityID(ctx context.Context, localityID int) ([]internal.SellersByLocality, error) {
+	if localityID < 0 {
 		return []internal.SellersByLocality{}, utils.EZeroValue("locality_id")
 	}
 
-	if _, err := s.localityRepo.GetByID(localityID); err != nil {
-		return []internal.SellersByLocality{}, err
+	if localityID > 0 {
+		if _, err := s.localityRepo.GetByID(ctx, localityID); err != nil {
+			return []internal.SellersByLocality{}, err
+		}
 	}
 
-	return s.localityRepo.GetSellersByLocalityID(localityID)
+	return s.localityRepo.GetSellersByLocalityID(ctx, localityID)
 }
 
 // GetCarriesByLocalityID retrieves a list of carriers associated with a given locality ID.
@@ -130,14 +166,360 @@ func (s *BasicLocalityService) GetSellersByLocalityID(localityID int) ([]interna
 // Returns:
 //   - []internal.CarriesByLocality: A slice of carriers associated with the locality.
 //   - error: An error if the locality does not exist or if there is an issue retrieving the carriers.
-func (s *BasicLocalityService) GetCarriesByLocalityID(localityID int) ([]internal.CarriesByLocality, error) {
-	if localityID <= 0 {
+func (s *BasicLocalityService) GetCarriesByLocalityID(ctx context.Context, localityID int) ([]internal.CarriesByLocality, error) {
+	if localityID < 0 {
 		return []internal.CarriesByLocality{}, utils.EZeroValue("locality_id")
 	}
 
-	if _, err := s.localityRepo.GetByID(localityID); err != nil {
-		return []internal.CarriesByLocality{}, err
+	if localityID > 0 {
+		if _, err := s.localityRepo.GetByID(ctx, localityID); err != nil {
+			return []internal.CarriesByLocality{}, err
+		}
+	}
+
+	return s.localityRepo.GetCarriesByLocalityID(ctx, localityID)
+}
+
+// GetSellersByLocalityPage returns the sellers quantity of up to limit localities after afterLocalityID
+func (s *BasicLocalityService) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	return s.localityRepo.GetSellersByLocalityPage(ctx, afterLocalityID, limit)
+}
+
+// GetCarriesByLocalityPage returns the carriers quantity of up to limit localities after afterLocalityID
+func (s *BasicLocalityService) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	return s.localityRepo.GetCarriesByLocalityPage(ctx, afterLocalityID, limit)
+}
+
+// GetAll returns all the localities
+func (s *BasicLocalityService) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	return s.localityRepo.GetAll(ctx)
+}
+
+// GetByID returns a locality, utils.ErrNotFound when it doesn't exist
+func (s *BasicLocalityService) GetByID(ctx context.Context, id int) (internal.Locality, error) {
+	locality, err := s.localityRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Locality{}, utils.ENotFound("locality")
+		}
+
+		return internal.Locality{}, err
+	}
+
+	return locality, nil
+}
+
+// Update changes the name, the province and/or the coordinates of a locality, zero values
+// are kept as they are. The locality is updated with the stored values
+func (s *BasicLocalityService) Update(ctx context.Context, locality *internal.Locality) error {
+	current, err := s.GetByID(ctx, locality.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("locality", locality.Version, current.Version); err != nil {
+		return err
+	}
+
+	locality.Version = current.Version
+
+	if locality.LocalityName == "" {
+		locality.LocalityName = current.LocalityName
+	}
+
+	if locality.Latitude == nil && locality.Longitude == nil {
+		locality.Latitude, locality.Longitude = current.Latitude, current.Longitude
+	} else if err := utils.ValidateCoordinates(locality.Latitude, locality.Longitude); err != nil {
+		return err
+	}
+
+	if locality.ProvinceID == 0 {
+		locality.ProvinceID = current.ProvinceID
+	} else if _, err := s.provinceRepo.GetByID(ctx, locality.ProvinceID); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return utils.EDependencyNotFound("province", "id: "+strconv.Itoa(locality.ProvinceID))
+		}
+
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Localities.Update(ctx, locality); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, locality.ID, current, locality)
+	})
+}
+
+// Delete removes a locality in the version given, refusing when sellers, warehouses or carriers
+// are located in it
+func (s *BasicLocalityService) Delete(ctx context.Context, id int, version int) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("locality", version, current.Version); err != nil {
+		return err
+	}
+
+	references, err := s.localityRepo.GetReferences(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if references.Total() > 0 {
+		return utils.EInUse("locality", references.String())
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Localities.Delete(ctx, id, current.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, current, nil)
+	})
+	if errors.Is(err, utils.ErrInUse) {
+		return utils.EInUse("locality", "other entities")
+	}
+
+	return err
+}
+
+// ImportColumns are the columns a localities CSV file must have, latitude and longitude are optional
+var ImportColumns = []string{"country_name", "province_name", "locality_id", "locality_name"}
+
+// Import upserts the countries, provinces and localities of a CSV file. Countries and provinces are
+// matched by name and created when missing, localities are matched by id and created or updated,
+// so importing the same rows again leaves them unchanged. Each row is imported in a transaction of
+// its own, along with the audit entries of what it created or updated. Rows with invalid values or
+// conflicting with the stored hierarchy are reported as failed, any other error stops the import.
+//
+// Parameters:
+//   - file: a CSV file with the ImportColumns and optionally latitude and longitude.
+//
+// Returns:
+//   - internal.LocalityImportReport: the counters and the result of every row.
+//   - error: an error if the file is malformed or lacks a column, or if a repository fails.
+func (s *BasicLocalityService) Import(ctx context.Context, file io.Reader) (internal.LocalityImportReport, error) {
+	rows, err := utils.ReadCSV(file, ImportColumns...)
+	if err != nil {
+		return internal.LocalityImportReport{}, err
+	}
+
+	report := internal.LocalityImportReport{Rows: make([]internal.LocalityImportResult, 0, len(rows))}
+	countries := map[string]internal.Country{}
+	provinces := map[string]internal.Province{}
+
+	for _, row := range rows {
+		result := internal.LocalityImportResult{Line: row.Line}
+
+		locality, err := parseImportRow(row)
+		if err == nil {
+			result.LocalityID = locality.ID
+
+			// the countries and provinces created by a failed row are rolled back, so they are forgotten
+			importedCountries, importedProvinces := maps.Clone(countries), maps.Clone(provinces)
+
+			err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+				result.Status, err = s.importLocality(ctx, repos, locality, row.Get("province_name"), row.Get("country_name"), importedProvinces, importedCountries)
+				return err
+			})
+			if err == nil {
+				countries, provinces = importedCountries, importedProvinces
+			}
+		}
+
+		if err != nil {
+			if !errors.Is(err, utils.ErrInvalidArguments) && !errors.Is(err, utils.ErrConflict) {
+				return internal.LocalityImportReport{}, err
+			}
+
+			result.Status = internal.LocalityImportFailed
+			result.Error = strings.Replace(err.Error(), "\n", ": ", 1)
+		}
+
+		switch result.Status {
+		case internal.LocalityImportCreated:
+			report.Created++
+		case internal.LocalityImportUpdated:
+			report.Updated++
+		case internal.LocalityImportUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+// parseImportRow validates the values of a row and converts them into a locality
+func parseImportRow(row utils.CSVRecord) (internal.Locality, error) {
+	for _, column := range ImportColumns {
+		if row.Get(column) == "" {
+			return internal.Locality{}, utils.EZeroValue(column)
+		}
+	}
+
+	id, err := strconv.Atoi(row.Get("locality_id"))
+	if err != nil || id <= 0 {
+		return internal.Locality{}, utils.EBR("locality_id must be a positive integer")
+	}
+
+	locality := internal.Locality{ID: id, LocalityName: row.Get("locality_name")}
+
+	for _, coordinate := range []struct {
+		name  string
+		value string
+		field **float64
+	}{
+		{"latitude", row.Get("latitude"), &locality.Latitude},
+		{"longitude", row.Get("longitude"), &locality.Longitude},
+	} {
+		if coordinate.value == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(coordinate.value, 64)
+		if err != nil {
+			return internal.Locality{}, utils.EBR(coordinate.name + " must be a number")
+		}
+
+		*coordinate.field = &value
+	}
+
+	if err := utils.ValidateCoordinates(locality.Latitude, locality.Longitude); err != nil {
+		return internal.Locality{}, err
+	}
+
+	return locality, nil
+}
+
+// importLocality creates or updates a locality along with its province and country with the
+// repositories of a unit of work, returning the import status. Rows without coordinates keep the
+// stored ones
+func (s *BasicLocalityService) importLocality(
+	ctx context.Context,
+	repos internal.TxRepositories,
+	locality internal.Locality,
+	provinceName, countryName string,
+	provinces map[string]internal.Province,
+	countries map[string]internal.Country) (string, error) {
+	country, err := importCountry(ctx, repos, countryName, countries)
+	if err != nil {
+		return "", err
+	}
+
+	province, err := importProvince(ctx, repos, provinceName, country, provinces)
+	if err != nil {
+		return "", err
+	}
+
+	locality.ProvinceID = province.ID
+
+	current, err := repos.Localities.GetByID(ctx, locality.ID)
+	if errors.Is(err, utils.ErrNotFound) {
+		if err := repos.Localities.Save(ctx, &locality); err != nil {
+			return "", err
+		}
+
+		return internal.LocalityImportCreated, audit.Record(ctx, repos.Audit, internal.AuditImport, auditEntity, locality.ID, nil, locality)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if locality.Latitude == nil {
+		locality.Latitude, locality.Longitude = current.Latitude, current.Longitude
+	}
+
+	if current.LocalityName == locality.LocalityName && current.ProvinceID == locality.ProvinceID &&
+		sameCoordinate(current.Latitude, locality.Latitude) && sameCoordinate(current.Longitude, locality.Longitude) {
+		return internal.LocalityImportUnchanged, nil
+	}
+
+	locality.Version = current.Version
+
+	if err := repos.Localities.Update(ctx, &locality); err != nil {
+		return "", err
+	}
+
+	return internal.LocalityImportUpdated, audit.Record(ctx, repos.Audit, internal.AuditImport, auditEntity, locality.ID, current, locality)
+}
+
+// importCountry finds a country by name, creating it when missing
+func importCountry(ctx context.Context, repos internal.TxRepositories, name string, countries map[string]internal.Country) (internal.Country, error) {
+	key := strings.ToLower(name)
+	if country, ok := countries[key]; ok {
+		return country, nil
+	}
+
+	country, err := repos.Countries.GetByName(ctx, name)
+	if errors.Is(err, utils.ErrNotFound) {
+		country = internal.Country{CountryName: name}
+
+		err = repos.Countries.Save(ctx, &country)
+		if err == nil {
+			err = audit.Record(ctx, repos.Audit, internal.AuditImport, auditCountryEntity, country.ID, nil, country)
+		}
+	}
+
+	if err != nil {
+		return internal.Country{}, err
+	}
+
+	countries[key] = country
+
+	return country, nil
+}
+
+// importProvince finds a province by name, creating it in the country when missing. Province names
+// are unique, so a province stored in another country is a conflict
+func importProvince(
+	ctx context.Context,
+	repos internal.TxRepositories,
+	name string,
+	country internal.Country,
+	provinces map[string]internal.Province) (internal.Province, error) {
+	key := strings.ToLower(name)
+
+	province, ok := provinces[key]
+	if !ok {
+		var err error
+
+		province, err = repos.Provinces.GetByName(ctx, name)
+		if errors.Is(err, utils.ErrNotFound) {
+			province = internal.Province{ProvinceName: name, CountryID: country.ID}
+
+			err = repos.Provinces.Save(ctx, &province)
+			if err == nil {
+				err = audit.Record(ctx, repos.Audit, internal.AuditImport, auditProvinceEntity, province.ID, nil, province)
+			}
+		}
+
+		if err != nil {
+			return internal.Province{}, err
+		}
+
+		provinces[key] = province
+	}
+
+	if province.CountryID != country.ID {
+		return internal.Province{}, utils.EBR("province " + name + " belongs to another country than " + country.CountryName)
+	}
+
+	return province, nil
+}
+
+func sameCoordinate(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
 	}
 
-	return s.localityRepo.GetCarriesByLocalityID(localityID)
+	return *a == *b
 }
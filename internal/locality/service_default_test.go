@@ -1,7 +1,9 @@
 package locality_test
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/locality"
@@ -12,54 +14,195 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repositories, counting the committed and rolled back
+// ones and keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos      internal.TxRepositories
+	committed  int
+	rolledBack int
+	entries    []internal.AuditEntry
+}
+
+func newMockUnitOfWork(lr internal.LocalityRepository, pr internal.ProvinceRepository, cr internal.CountryRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Localities: lr, Provinces: pr, Countries: cr, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+		u.rolledBack++
+
+		return err
+	}
+
+	u.committed++
+
+	return nil
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockLocalityRepository struct {
 	mock.Mock
 }
 
-func (m *MockLocalityRepository) Save(locality *internal.Locality) error {
+func (m *MockLocalityRepository) Save(ctx context.Context, locality *internal.Locality) error {
 	args := m.Called(locality)
 	return args.Error(0)
 }
-func (m *MockLocalityRepository) GetByID(id int) (internal.Locality, error) {
+
+func (m *MockLocalityRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+func (m *MockLocalityRepository) GetByID(ctx context.Context, id int) (internal.Locality, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Locality), args.Error(1)
 }
-func (m *MockLocalityRepository) GetSellersByLocalityID(localityId int) ([]internal.SellersByLocality, error) {
+func (m *MockLocalityRepository) GetSellersByLocalityID(ctx context.Context, localityId int) ([]internal.SellersByLocality, error) {
 	args := m.Called(localityId)
 	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
 }
 
-func (m *MockLocalityRepository) GetCarriesByLocalityID(localityId int) ([]internal.CarriesByLocality, error) {
+func (m *MockLocalityRepository) GetCarriesByLocalityID(ctx context.Context, localityId int) ([]internal.CarriesByLocality, error) {
 	args := m.Called(localityId)
 	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
 }
 
+func (m *MockLocalityRepository) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceID(ctx context.Context, provinceID int) ([]internal.Locality, error) {
+	args := m.Called(provinceID)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceIDs(ctx context.Context, provinceIDs []int) ([]internal.Locality, error) {
+	args := m.Called(provinceIDs)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) Update(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
 type MockProvinceRepository struct {
 	mock.Mock
 }
 
-func (m *MockProvinceRepository) Save(province *internal.Province) error {
+func (m *MockProvinceRepository) Save(ctx context.Context, province *internal.Province) error {
 	args := m.Called(province)
 	return args.Error(0)
 }
-func (m *MockProvinceRepository) GetByName(name string) (internal.Province, error) {
+func (m *MockProvinceRepository) GetByName(ctx context.Context, name string) (internal.Province, error) {
 	args := m.Called(name)
 	return args.Get(0).(internal.Province), args.Error(1)
 }
 
+func (m *MockProvinceRepository) GetAll(ctx context.Context) ([]internal.Province, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByCountryID(ctx context.Context, countryID int) ([]internal.Province, error) {
+	args := m.Called(countryID)
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) Update(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
 type MockCountryRepository struct {
 	mock.Mock
 }
 
-func (m *MockCountryRepository) Save(country *internal.Country) error {
+func (m *MockCountryRepository) Save(ctx context.Context, country *internal.Country) error {
 	args := m.Called(country)
 	return args.Error(0)
 }
-func (m *MockCountryRepository) GetByName(name string) (internal.Country, error) {
+func (m *MockCountryRepository) GetByName(ctx context.Context, name string) (internal.Country, error) {
 	args := m.Called(name)
 	return args.Get(0).(internal.Country), args.Error(1)
 }
 
+func (m *MockCountryRepository) GetAll(ctx context.Context) ([]internal.Country, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) Update(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
 func TestUnitLocality_Save(t *testing.T) {
 	var internalServerError = errors.New("internal server error")
 	cases := []struct {
@@ -300,8 +443,8 @@ func TestUnitLocality_Save(t *testing.T) {
 			pr := new(MockProvinceRepository)
 			cr := new(MockCountryRepository)
 			c.Mock(lr, pr, cr)
-			service := locality.NewBasicLocalityService(lr, pr, cr)
-			err := service.Save(&c.DataLocality, &c.DataProvince, &c.DataCountry)
+			service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+			err := service.Save(context.Background(), &c.DataLocality, &c.DataProvince, &c.DataCountry)
 			require.ErrorIs(t, err, c.ErrorToReturn)
 		})
 
@@ -309,6 +452,51 @@ func TestUnitLocality_Save(t *testing.T) {
 
 }
 
+func TestUnitLocality_Save_UnitOfWork(t *testing.T) {
+	t.Run("given a province failing to be saved, roll back the country saved before it", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetByID", 7000).Return(internal.Locality{}, utils.ErrNotFound)
+		cr.On("GetByName", "Ostania").Return(internal.Country{}, utils.ErrNotFound)
+		cr.On("Save", mock.Anything).Return(nil)
+		pr.On("GetByName", "Westails").Return(internal.Province{}, utils.ErrNotFound)
+		pr.On("Save", mock.Anything).Return(errors.New("connection lost"))
+
+		uow := newMockUnitOfWork(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, uow)
+
+		err := service.Save(context.Background(), &internal.Locality{ID: 7000, LocalityName: "A random locality"},
+			&internal.Province{ProvinceName: "Westails"}, &internal.Country{CountryName: "Ostania"})
+		require.EqualError(t, err, "connection lost")
+		require.Equal(t, 1, uow.rolledBack)
+		require.Zero(t, uow.committed)
+		cr.AssertCalled(t, "Save", mock.Anything)
+		lr.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("given every repository saving, commit the country, province and locality together", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetByID", 7000).Return(internal.Locality{}, utils.ErrNotFound)
+		lr.On("Save", mock.Anything).Return(nil)
+		cr.On("GetByName", "Ostania").Return(internal.Country{}, utils.ErrNotFound)
+		cr.On("Save", mock.Anything).Return(nil)
+		pr.On("GetByName", "Westails").Return(internal.Province{}, utils.ErrNotFound)
+		pr.On("Save", mock.Anything).Return(nil)
+
+		uow := newMockUnitOfWork(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, uow)
+
+		err := service.Save(context.Background(), &internal.Locality{ID: 7000, LocalityName: "A random locality"},
+			&internal.Province{ProvinceName: "Westails"}, &internal.Country{CountryName: "Ostania"})
+		require.NoError(t, err)
+		require.Equal(t, 1, uow.committed)
+		require.Zero(t, uow.rolledBack)
+	})
+}
+
 func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 	sampleSellerByLocality := internal.SellersByLocality{
 		LocalityID:   1,
@@ -320,9 +508,9 @@ func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 		lr := new(MockLocalityRepository)
 		pr := new(MockProvinceRepository)
 		cr := new(MockCountryRepository)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
 
-		report, err := service.GetSellersByLocalityID(-1)
+		report, err := service.GetSellersByLocalityID(context.Background(), -1)
 		require.ErrorIs(t, err, utils.ErrInvalidArguments)
 		require.Len(t, report, 0)
 	})
@@ -333,11 +521,24 @@ func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 		cr := new(MockCountryRepository)
 		lr.On("GetByID", mock.Anything).Return(internal.Locality{}, nil)
 		lr.On("GetSellersByLocalityID", mock.Anything).Return([]internal.SellersByLocality{sampleSellerByLocality}, nil)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+
+		report, err := service.GetSellersByLocalityID(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, report, 1)
+	})
 
-		report, err := service.GetSellersByLocalityID(1)
+	t.Run("given no locality ID, return the report of every locality", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetSellersByLocalityID", 0).Return([]internal.SellersByLocality{sampleSellerByLocality}, nil)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+
+		report, err := service.GetSellersByLocalityID(context.Background(), 0)
 		require.NoError(t, err)
 		require.Len(t, report, 1)
+		lr.AssertNotCalled(t, "GetByID", mock.Anything)
 	})
 
 	t.Run("given a valid and not existing locality ID, return an empty report and utils.ErrNotFound", func(t *testing.T) {
@@ -345,9 +546,9 @@ func TestUnitLocality_GetSellersByLocalityId(t *testing.T) {
 		pr := new(MockProvinceRepository)
 		cr := new(MockCountryRepository)
 		lr.On("GetByID", mock.Anything).Return(internal.Locality{}, utils.ErrNotFound)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
 
-		report, err := service.GetSellersByLocalityID(99)
+		report, err := service.GetSellersByLocalityID(context.Background(), 99)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Len(t, report, 0)
 	})
@@ -364,9 +565,9 @@ func TestUnitLocality_GetCarriesByLocalityID(t *testing.T) {
 		lr := new(MockLocalityRepository)
 		pr := new(MockProvinceRepository)
 		cr := new(MockCountryRepository)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
 
-		report, err := service.GetCarriesByLocalityID(-1)
+		report, err := service.GetCarriesByLocalityID(context.Background(), -1)
 		require.ErrorIs(t, err, utils.ErrInvalidArguments)
 		require.Len(t, report, 0)
 	})
@@ -377,9 +578,9 @@ func TestUnitLocality_GetCarriesByLocalityID(t *testing.T) {
 		cr := new(MockCountryRepository)
 		lr.On("GetByID", mock.Anything).Return(internal.Locality{}, nil)
 		lr.On("GetCarriesByLocalityID", mock.Anything).Return([]internal.CarriesByLocality{sampleCarriesByLocality}, nil)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
 
-		report, err := service.GetCarriesByLocalityID(1)
+		report, err := service.GetCarriesByLocalityID(context.Background(), 1)
 		require.NoError(t, err)
 		require.Len(t, report, 1)
 	})
@@ -389,10 +590,178 @@ func TestUnitLocality_GetCarriesByLocalityID(t *testing.T) {
 		pr := new(MockProvinceRepository)
 		cr := new(MockCountryRepository)
 		lr.On("GetByID", mock.Anything).Return(internal.Locality{}, utils.ErrNotFound)
-		service := locality.NewBasicLocalityService(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
 
-		report, err := service.GetCarriesByLocalityID(99)
+		report, err := service.GetCarriesByLocalityID(context.Background(), 99)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Len(t, report, 0)
 	})
 }
+
+func TestUnitLocality_Update(t *testing.T) {
+	stored := internal.Locality{ID: 1, LocalityName: "Lujan", ProvinceID: 1}
+
+	t.Run("given only a new name, keep the province and update", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		lr.On("Update", &internal.Locality{ID: 1, LocalityName: "Pilar", ProvinceID: 1}).Return(nil)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+
+		updated := internal.Locality{ID: 1, LocalityName: "Pilar"}
+		err := service.Update(context.Background(), &updated)
+		require.NoError(t, err)
+		require.Equal(t, internal.Locality{ID: 1, LocalityName: "Pilar", ProvinceID: 1}, updated)
+		pr.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+
+	t.Run("given a province that doesn't exist, return utils.ErrInvalidArguments", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		pr.On("GetByID", 99).Return(internal.Province{}, utils.ErrNotFound)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+
+		err := service.Update(context.Background(), &internal.Locality{ID: 1, ProvinceID: 99})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+		lr.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("given only a latitude, return utils.ErrInvalidArguments", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		service := locality.NewBasicLocalityService(lr, new(MockProvinceRepository), new(MockCountryRepository), newMockUnitOfWork(lr, new(MockProvinceRepository), new(MockCountryRepository)))
+
+		latitude := 34.05
+		err := service.Update(context.Background(), &internal.Locality{ID: 1, Latitude: &latitude})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+		lr.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("given a locality that doesn't exist, return utils.ErrNotFound", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		lr.On("GetByID", 2).Return(internal.Locality{}, utils.ErrNotFound)
+		service := locality.NewBasicLocalityService(lr, pr, cr, newMockUnitOfWork(lr, pr, cr))
+
+		err := service.Update(context.Background(), &internal.Locality{ID: 2, LocalityName: "Pilar"})
+		require.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
+
+func TestUnitLocality_Delete(t *testing.T) {
+	stored := internal.Locality{ID: 1, LocalityName: "Lujan", ProvinceID: 1, Version: 2}
+
+	t.Run("given a locality without references, delete it", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		lr.On("GetReferences", 1).Return(internal.LocalityReferences{}, nil)
+		lr.On("Delete", 1, 2).Return(nil)
+		service := locality.NewBasicLocalityService(lr, new(MockProvinceRepository), new(MockCountryRepository), newMockUnitOfWork(lr, new(MockProvinceRepository), new(MockCountryRepository)))
+
+		require.NoError(t, service.Delete(context.Background(), 1, 2))
+		lr.AssertCalled(t, "Delete", 1, 2)
+	})
+
+	t.Run("given a locality in another version, return utils.ErrPreconditionFailed", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		service := locality.NewBasicLocalityService(lr, new(MockProvinceRepository), new(MockCountryRepository), newMockUnitOfWork(lr, new(MockProvinceRepository), new(MockCountryRepository)))
+
+		err := service.Delete(context.Background(), 1, 1)
+		require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		lr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("given a locality with sellers, return utils.ErrInUse", func(t *testing.T) {
+		lr := new(MockLocalityRepository)
+		lr.On("GetByID", 1).Return(stored, nil)
+		lr.On("GetReferences", 1).Return(internal.LocalityReferences{Sellers: 2}, nil)
+		service := locality.NewBasicLocalityService(lr, new(MockProvinceRepository), new(MockCountryRepository), newMockUnitOfWork(lr, new(MockProvinceRepository), new(MockCountryRepository)))
+
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
+		require.ErrorIs(t, err, utils.ErrInUse)
+		require.ErrorContains(t, err, "2 sellers, 0 warehouses and 0 carriers")
+		lr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnitLocality_Import(t *testing.T) {
+	latitude, longitude := -34.5703, -59.105
+
+	t.Run("given a file, upsert the hierarchy and report every row", func(t *testing.T) {
+		file := `country_name,province_name,locality_id,locality_name,latitude,longitude
+Argentina,Buenos Aires,6700,Lujan,-34.5703,-59.105
+argentina,Buenos Aires,6701,Pilar,,
+Argentina,Buenos Aires,6702,Mercedes,,
+Argentina,Buenos Aires,abc,Campana,,
+Argentina,Santiago,6703,Colina,,
+Argentina,Buenos Aires,6704,Zarate,91,0
+`
+		lr := new(MockLocalityRepository)
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		cr.On("GetByName", "Argentina").Return(internal.Country{}, utils.ErrNotFound).Once()
+		cr.On("Save", &internal.Country{CountryName: "Argentina"}).Run(func(args mock.Arguments) {
+			args.Get(0).(*internal.Country).ID = 1
+		}).Return(nil).Once()
+		pr.On("GetByName", "Buenos Aires").Return(internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1}, nil).Once()
+		pr.On("GetByName", "Santiago").Return(internal.Province{ID: 2, ProvinceName: "Santiago", CountryID: 2}, nil).Once()
+		lr.On("GetByID", 6700).Return(internal.Locality{}, utils.ErrNotFound)
+		lr.On("Save", &internal.Locality{ID: 6700, LocalityName: "Lujan", ProvinceID: 1, Latitude: &latitude, Longitude: &longitude}).Return(nil)
+		lr.On("GetByID", 6701).Return(internal.Locality{ID: 6701, LocalityName: "Pilar", ProvinceID: 1}, nil)
+		lr.On("GetByID", 6702).Return(internal.Locality{ID: 6702, LocalityName: "Old Mercedes", ProvinceID: 1, Latitude: &latitude, Longitude: &longitude}, nil)
+		lr.On("Update", &internal.Locality{ID: 6702, LocalityName: "Mercedes", ProvinceID: 1, Latitude: &latitude, Longitude: &longitude}).Return(nil)
+		uow := newMockUnitOfWork(lr, pr, cr)
+		service := locality.NewBasicLocalityService(lr, pr, cr, uow)
+
+		report, err := service.Import(context.Background(), strings.NewReader(file))
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Created)
+		require.Equal(t, 1, report.Unchanged)
+		require.Equal(t, 1, report.Updated)
+		require.Equal(t, 3, report.Failed)
+		require.Equal(t, []internal.LocalityImportResult{
+			{Line: 2, LocalityID: 6700, Status: internal.LocalityImportCreated},
+			{Line: 3, LocalityID: 6701, Status: internal.LocalityImportUnchanged},
+			{Line: 4, LocalityID: 6702, Status: internal.LocalityImportUpdated},
+			{Line: 5, Status: internal.LocalityImportFailed, Error: "invalid arguments: locality_id must be a positive integer"},
+			{Line: 6, LocalityID: 6703, Status: internal.LocalityImportFailed, Error: "invalid arguments: province Santiago belongs to another country than Argentina"},
+			{Line: 7, Status: internal.LocalityImportFailed, Error: "invalid arguments: latitude must be between -90 and 90"},
+		}, report.Rows)
+		cr.AssertExpectations(t)
+		pr.AssertExpectations(t)
+
+		// what the committed rows created or updated, by the system as the import has no principal
+		require.Len(t, uow.entries, 3)
+		for i, want := range []struct {
+			entity string
+			id     int
+		}{{"countries", 1}, {"localities", 6700}, {"localities", 6702}} {
+			require.Equal(t, internal.AuditImport, uow.entries[i].Action)
+			require.Equal(t, "system", uow.entries[i].Actor)
+			require.Equal(t, want.entity, uow.entries[i].Entity)
+			require.Equal(t, want.id, uow.entries[i].EntityID)
+		}
+	})
+
+	t.Run("given a file without a required column, return utils.ErrInvalidArguments", func(t *testing.T) {
+		service := locality.NewBasicLocalityService(new(MockLocalityRepository), new(MockProvinceRepository), new(MockCountryRepository), nil)
+
+		_, err := service.Import(context.Background(), strings.NewReader("country_name,province_name,locality_name\nArgentina,Buenos Aires,Lujan\n"))
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("given a repository failure, stop the import", func(t *testing.T) {
+		internalServerError := errors.New("internal server error")
+		cr := new(MockCountryRepository)
+		cr.On("GetByName", "Argentina").Return(internal.Country{}, internalServerError)
+		service := locality.NewBasicLocalityService(new(MockLocalityRepository), new(MockProvinceRepository), cr, newMockUnitOfWork(new(MockLocalityRepository), new(MockProvinceRepository), cr))
+
+		_, err := service.Import(context.Background(), strings.NewReader("country_name,province_name,locality_id,locality_name\nArgentina,Buenos Aires,6700,Lujan\n"))
+		require.ErrorIs(t, err, internalServerError)
+	})
+}
@@ -0,0 +1,62 @@
+package internal
+
+import "context"
+
+// BasePackagingUnitID identifies the product itself, the unit every packaging unit converts to
+const BasePackagingUnitID = 0
+
+// PackagingUnit represents a way a product is sold or stored (box, crate, pallet...),
+// holding how many base units it contains and its own dimensions, weight and barcode
+type PackagingUnit struct {
+	ID int `json:"id"`
+	PackagingUnitAttributes
+	// Version is bumped by every change, it is the ETag of the packaging unit
+	Version int `json:"version,omitempty"`
+}
+
+type PackagingUnitAttributes struct {
+	ProductID       int     `json:"product_id"`
+	Name            string  `json:"name"`
+	UnitsPerPackage int     `json:"units_per_package"`
+	Width           float64 `json:"width"`
+	Height          float64 `json:"height"`
+	Length          float64 `json:"length"`
+	NetWeight       float64 `json:"net_weight"`
+	Barcode         string  `json:"barcode"`
+}
+
+// PackagingConversion is a quantity of a product converted between two of its packaging units
+type PackagingConversion struct {
+	ProductID         int     `json:"product_id"`
+	FromUnitID        int     `json:"from_unit_id"`
+	ToUnitID          int     `json:"to_unit_id"`
+	Quantity          float64 `json:"quantity"`
+	BaseQuantity      float64 `json:"base_quantity"`
+	ConvertedQuantity float64 `json:"converted_quantity"`
+}
+
+type PackagingUnitRepository interface {
+	GetByProductID(ctx context.Context, productID int) (listUnits []PackagingUnit, err error)
+	GetByID(ctx context.Context, id int) (unit PackagingUnit, err error)
+	Create(ctx context.Context, newUnit PackagingUnitAttributes) (unit PackagingUnit, err error)
+	Update(ctx context.Context, inputUnit PackagingUnit) (unit PackagingUnit, err error)
+	// Delete removes a packaging unit when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) (err error)
+}
+
+type PackagingUnitService interface {
+	GetPackagingUnits(ctx context.Context, productID int) (listUnits []PackagingUnit, err error)
+	GetPackagingUnitByID(ctx context.Context, id int) (unit PackagingUnit, err error)
+	CreatePackagingUnit(ctx context.Context, newUnit PackagingUnitAttributes) (unit PackagingUnit, err error)
+	UpdatePackagingUnit(ctx context.Context, inputUnit PackagingUnit) (unit PackagingUnit, err error)
+	// DeletePackagingUnit removes a packaging unit in the version given
+	DeletePackagingUnit(ctx context.Context, id int, version int) (err error)
+	ConvertQuantity(ctx context.Context, productID int, quantity float64, fromUnitID, toUnitID int) (conversion PackagingConversion, err error)
+	PackagingUnitConversion
+}
+
+// PackagingUnitConversion converts quantities expressed in a packaging unit to base units,
+// used by the domains that store stock (batches, orders, capacity) in base units
+type PackagingUnitConversion interface {
+	ToBaseUnits(ctx context.Context, productID, packagingUnitID, quantity int) (baseQuantity int, err error)
+}
@@ -0,0 +1,122 @@
+package packaging_unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+const selectPackagingUnits = "SELECT id, product_id, name, units_per_package, width, height, `length`, net_weight, barcode, version FROM product_packaging_units"
+
+type MySQLPackagingUnitRepository struct {
+	db utils.DBTX
+}
+
+func NewPackagingUnitDB(db utils.DBTX) *MySQLPackagingUnitRepository {
+	return &MySQLPackagingUnitRepository{db: db}
+}
+
+// GetByProductID returns the packaging units of a product
+func (p *MySQLPackagingUnitRepository) GetByProductID(ctx context.Context, productID int) (listUnits []internal.PackagingUnit, err error) {
+	rows, err := p.db.QueryContext(ctx, selectPackagingUnits+" WHERE product_id = ? ORDER BY units_per_package, id", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var unit internal.PackagingUnit
+
+		err := rows.Scan(&unit.ID, &unit.ProductID, &unit.Name, &unit.UnitsPerPackage, &unit.Width, &unit.Height, &unit.Length, &unit.NetWeight, &unit.Barcode, &unit.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		listUnits = append(listUnits, unit)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return listUnits, nil
+}
+
+// GetByID returns a packaging unit by id
+func (p *MySQLPackagingUnitRepository) GetByID(ctx context.Context, id int) (unit internal.PackagingUnit, err error) {
+	row := p.db.QueryRowContext(ctx, selectPackagingUnits+" WHERE id = ? AND "+sellerCondition(ctx), id)
+
+	err = row.Scan(&unit.ID, &unit.ProductID, &unit.Name, &unit.UnitsPerPackage, &unit.Width, &unit.Height, &unit.Length, &unit.NetWeight, &unit.Barcode, &unit.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.PackagingUnit{}, utils.ErrNotFound
+		}
+
+		return internal.PackagingUnit{}, err
+	}
+
+	return unit, nil
+}
+
+// Create a packaging unit
+func (p *MySQLPackagingUnitRepository) Create(ctx context.Context, newUnit internal.PackagingUnitAttributes) (unit internal.PackagingUnit, err error) {
+	result, err := p.db.ExecContext(ctx,
+		"INSERT INTO product_packaging_units (product_id, name, units_per_package, width, height, `length`, net_weight, barcode) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		newUnit.ProductID, newUnit.Name, newUnit.UnitsPerPackage, newUnit.Width, newUnit.Height, newUnit.Length, newUnit.NetWeight, newUnit.Barcode,
+	)
+	if err != nil {
+		return internal.PackagingUnit{}, utils.MapMySQLError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	return internal.PackagingUnit{ID: int(id), PackagingUnitAttributes: newUnit, Version: 1}, nil
+}
+
+// Update a packaging unit
+func (p *MySQLPackagingUnitRepository) Update(ctx context.Context, inputUnit internal.PackagingUnit) (unit internal.PackagingUnit, err error) {
+	result, err := p.db.ExecContext(ctx,
+		"UPDATE product_packaging_units SET name = ?, units_per_package = ?, width = ?, height = ?, `length` = ?, net_weight = ?, barcode = ?, version = version + 1 WHERE id = ? AND version = ?",
+		inputUnit.Name, inputUnit.UnitsPerPackage, inputUnit.Width, inputUnit.Height, inputUnit.Length, inputUnit.NetWeight, inputUnit.Barcode, inputUnit.ID, inputUnit.Version,
+	)
+	if err != nil {
+		return internal.PackagingUnit{}, utils.MapMySQLError(err)
+	}
+
+	err = utils.CheckVersionUpdated(result, "packaging unit")
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	inputUnit.Version++
+
+	return inputUnit, nil
+}
+
+// Delete a packaging unit still in the version it was read
+func (p *MySQLPackagingUnitRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM product_packaging_units WHERE id = ? AND version = ? AND "+sellerCondition(ctx), id, version)
+	if err != nil {
+		return err
+	}
+
+	return utils.CheckVersionUpdated(result, "packaging unit")
+}
+
+// sellerCondition returns the condition of the packaging units being of the products of the seller
+// of the scope of ctx, TRUE when the principal sees the ones of every seller
+func sellerCondition(ctx context.Context) string {
+	if _, scoped := internal.SellerScope(ctx); !scoped {
+		return "TRUE"
+	}
+
+	return "product_id IN (SELECT id FROM products WHERE " + internal.SellerCondition(ctx, "seller_id") + ")"
+}
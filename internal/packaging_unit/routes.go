@@ -0,0 +1,26 @@
+package packaging_unit
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+)
+
+func NewPackagingUnitRoutes(mux *chi.Mux, service internal.PackagingUnitService) error {
+	unitHandler := handler.NewPackagingUnitHandler(service)
+
+	mux.Route("/api/v1/products/{id}/packagingUnits", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionRead))).Get("/", unitHandler.GetPackagingUnits)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionWrite))).Post("/", unitHandler.CreatePackagingUnit)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionRead))).Get("/convert", unitHandler.ConvertQuantity)
+	})
+
+	mux.Route("/api/v1/packagingUnits", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionRead))).Get("/{id}", unitHandler.GetPackagingUnitByID)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionWrite))).Patch("/{id}", unitHandler.UpdatePackagingUnit)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePackagingUnits, rbac.ActionDelete))).Delete("/{id}", unitHandler.DeletePackagingUnit)
+	})
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package packaging_unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// auditEntity is the name of the packaging units in the audit trail
+const auditEntity = "packagingUnits"
+
+type BasicPackagingUnitService struct {
+	repo              internal.PackagingUnitRepository
+	validationProduct internal.ProductValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
+}
+
+func NewPackagingUnitService(repo internal.PackagingUnitRepository, validationProduct internal.ProductValidation, uow internal.UnitOfWork) *BasicPackagingUnitService {
+	return &BasicPackagingUnitService{
+		repo:              repo,
+		validationProduct: validationProduct,
+		uow:               uow,
+	}
+}
+
+func (s *BasicPackagingUnitService) GetPackagingUnits(ctx context.Context, productID int) (listUnits []internal.PackagingUnit, err error) {
+	if _, err := s.validationProduct.GetProductByID(ctx, productID); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, utils.ENotFound("Product")
+		}
+
+		return nil, err
+	}
+
+	listUnits, err = s.repo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if listUnits == nil {
+		listUnits = []internal.PackagingUnit{}
+	}
+
+	return listUnits, nil
+}
+
+func (s *BasicPackagingUnitService) GetPackagingUnitByID(ctx context.Context, id int) (unit internal.PackagingUnit, err error) {
+	unit, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.PackagingUnit{}, utils.ENotFound("Packaging unit")
+		}
+
+		return internal.PackagingUnit{}, err
+	}
+
+	return unit, nil
+}
+
+func (s *BasicPackagingUnitService) CreatePackagingUnit(ctx context.Context, newUnit internal.PackagingUnitAttributes) (unit internal.PackagingUnit, err error) {
+	newUnit.Name = strings.TrimSpace(newUnit.Name)
+	newUnit.Barcode = strings.TrimSpace(newUnit.Barcode)
+
+	err = validateAttributes(newUnit)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	newUnit.Barcode, err = utils.NormalizeGTIN(newUnit.Barcode)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	if _, err := s.validationProduct.GetProductByID(ctx, newUnit.ProductID); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.PackagingUnit{}, utils.EDependencyNotFound("Product", "id")
+		}
+
+		return internal.PackagingUnit{}, err
+	}
+
+	listUnits, err := s.repo.GetByProductID(ctx, newUnit.ProductID)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	err = validateDuplicates(listUnits, 0, newUnit.Name)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		unit, err = repos.PackagingUnits.Create(ctx, newUnit)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, unit.ID, nil, unit)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrConflict) {
+			return internal.PackagingUnit{}, utils.EConflict("Packaging unit", "barcode")
+		}
+
+		return internal.PackagingUnit{}, err
+	}
+
+	return unit, nil
+}
+
+func (s *BasicPackagingUnitService) UpdatePackagingUnit(ctx context.Context, inputUnit internal.PackagingUnit) (unit internal.PackagingUnit, err error) {
+	internalUnit, err := s.GetPackagingUnitByID(ctx, inputUnit.ID)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	err = utils.CheckVersion("packaging unit", inputUnit.Version, internalUnit.Version)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	preparedUnit := preparePackagingUnitUpdate(inputUnit, internalUnit)
+
+	err = validateAttributes(preparedUnit.PackagingUnitAttributes)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	preparedUnit.Barcode, err = utils.NormalizeGTIN(preparedUnit.Barcode)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	listUnits, err := s.repo.GetByProductID(ctx, preparedUnit.ProductID)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	err = validateDuplicates(listUnits, preparedUnit.ID, preparedUnit.Name)
+	if err != nil {
+		return internal.PackagingUnit{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		unit, err = repos.PackagingUnits.Update(ctx, preparedUnit)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, unit.ID, internalUnit, unit)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrConflict) {
+			return internal.PackagingUnit{}, utils.EConflict("Packaging unit", "barcode")
+		}
+
+		return internal.PackagingUnit{}, err
+	}
+
+	return unit, nil
+}
+
+func (s *BasicPackagingUnitService) DeletePackagingUnit(ctx context.Context, id int, version int) (err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		unit, err := repos.PackagingUnits.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = utils.CheckVersion("packaging unit", version, unit.Version); err != nil {
+			return err
+		}
+
+		if err = repos.PackagingUnits.Delete(ctx, id, unit.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, unit, nil)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return utils.ENotFound("Packaging unit")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ConvertQuantity converts a quantity of a product from one packaging unit to another,
+// going through the base unit. BasePackagingUnitID stands for the product itself
+func (s *BasicPackagingUnitService) ConvertQuantity(ctx context.Context, productID int, quantity float64, fromUnitID, toUnitID int) (conversion internal.PackagingConversion, err error) {
+	if quantity < 0 {
+		return internal.PackagingConversion{}, utils.EBR("quantity cannot be negative")
+	}
+
+	if _, err := s.validationProduct.GetProductByID(ctx, productID); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.PackagingConversion{}, utils.ENotFound("Product")
+		}
+
+		return internal.PackagingConversion{}, err
+	}
+
+	fromFactor, err := s.unitsPerPackage(ctx, productID, fromUnitID)
+	if err != nil {
+		return internal.PackagingConversion{}, err
+	}
+
+	toFactor, err := s.unitsPerPackage(ctx, productID, toUnitID)
+	if err != nil {
+		return internal.PackagingConversion{}, err
+	}
+
+	baseQuantity := quantity * float64(fromFactor)
+
+	conversion = internal.PackagingConversion{
+		ProductID:         productID,
+		FromUnitID:        fromUnitID,
+		ToUnitID:          toUnitID,
+		Quantity:          quantity,
+		BaseQuantity:      baseQuantity,
+		ConvertedQuantity: baseQuantity / float64(toFactor),
+	}
+
+	return conversion, nil
+}
+
+// ToBaseUnits converts a whole quantity expressed in a packaging unit of the product to base units
+func (s *BasicPackagingUnitService) ToBaseUnits(ctx context.Context, productID, packagingUnitID, quantity int) (baseQuantity int, err error) {
+	factor, err := s.unitsPerPackage(ctx, productID, packagingUnitID)
+	if err != nil {
+		return 0, err
+	}
+
+	return quantity * factor, nil
+}
+
+// unitsPerPackage returns the conversion factor to base units of a packaging unit,
+// checking it belongs to the product
+func (s *BasicPackagingUnitService) unitsPerPackage(ctx context.Context, productID, packagingUnitID int) (int, error) {
+	if packagingUnitID == internal.BasePackagingUnitID {
+		return 1, nil
+	}
+
+	unit, err := s.repo.GetByID(ctx, packagingUnitID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return 0, utils.EDependencyNotFound("Packaging unit", "id")
+		}
+
+		return 0, err
+	}
+
+	if unit.ProductID != productID {
+		return 0, utils.EBR("packaging unit " + unit.Name + " does not belong to the product")
+	}
+
+	return unit.UnitsPerPackage, nil
+}
+
+func validateAttributes(unit internal.PackagingUnitAttributes) error {
+	if unit.ProductID <= 0 {
+		return utils.EZeroValue("ProductID")
+	}
+
+	if unit.Name == "" {
+		return utils.EZeroValue("Name")
+	}
+
+	if unit.UnitsPerPackage <= 0 {
+		return utils.EBR("units_per_package must be greater than zero")
+	}
+
+	if unit.Width <= 0 {
+		return utils.EZeroValue("Width")
+	}
+
+	if unit.Height <= 0 {
+		return utils.EZeroValue("Height")
+	}
+
+	if unit.Length <= 0 {
+		return utils.EZeroValue("Length")
+	}
+
+	if unit.NetWeight <= 0 {
+		return utils.EZeroValue("NetWeight")
+	}
+
+	if unit.Barcode == "" {
+		return utils.EZeroValue("Barcode")
+	}
+
+	return nil
+}
+
+func validateDuplicates(listUnits []internal.PackagingUnit, id int, name string) error {
+	for _, unit := range listUnits {
+		if unit.ID != id && strings.EqualFold(unit.Name, name) {
+			return utils.EConflict("Packaging unit", "name")
+		}
+	}
+
+	return nil
+}
+
+func preparePackagingUnitUpdate(inputUnit, internalUnit internal.PackagingUnit) (preparedUnit internal.PackagingUnit) {
+	preparedUnit = internalUnit
+
+	if name := strings.TrimSpace(inputUnit.Name); name != "" {
+		preparedUnit.Name = name
+	}
+
+	if inputUnit.UnitsPerPackage != 0 {
+		preparedUnit.UnitsPerPackage = inputUnit.UnitsPerPackage
+	}
+
+	if inputUnit.Width != 0 {
+		preparedUnit.Width = inputUnit.Width
+	}
+
+	if inputUnit.Height != 0 {
+		preparedUnit.Height = inputUnit.Height
+	}
+
+	if inputUnit.Length != 0 {
+		preparedUnit.Length = inputUnit.Length
+	}
+
+	if inputUnit.NetWeight != 0 {
+		preparedUnit.NetWeight = inputUnit.NetWeight
+	}
+
+	if barcode := strings.TrimSpace(inputUnit.Barcode); barcode != "" {
+		preparedUnit.Barcode = barcode
+	}
+
+	return preparedUnit
+}
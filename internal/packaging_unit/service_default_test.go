@@ -0,0 +1,345 @@
+package packaging_unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.PackagingUnitRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{PackagingUnits: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type mockPackagingUnitRepository struct {
+	mock.Mock
+}
+
+type mockProductValidation struct {
+	mock.Mock
+}
+
+func (m *mockPackagingUnitRepository) GetByProductID(ctx context.Context, productID int) (listUnits []internal.PackagingUnit, err error) {
+	args := m.Called(productID)
+	return args.Get(0).([]internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitRepository) GetByID(ctx context.Context, id int) (unit internal.PackagingUnit, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitRepository) Create(ctx context.Context, newUnit internal.PackagingUnitAttributes) (unit internal.PackagingUnit, err error) {
+	args := m.Called(newUnit)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitRepository) Update(ctx context.Context, inputUnit internal.PackagingUnit) (unit internal.PackagingUnit, err error) {
+	args := m.Called(inputUnit)
+	return args.Get(0).(internal.PackagingUnit), args.Error(1)
+}
+
+func (m *mockPackagingUnitRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockProductValidation) GetProductByID(ctx context.Context, id int) (product internal.Product, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+var box = internal.PackagingUnit{
+	ID: 1,
+	PackagingUnitAttributes: internal.PackagingUnitAttributes{
+		ProductID:       1,
+		Name:            "Box",
+		UnitsPerPackage: 12,
+		Width:           30,
+		Height:          15,
+		Length:          40,
+		NetWeight:       14.4,
+		Barcode:         "17791234000019",
+	},
+}
+
+// errTest is an error of the database, not of the data
+var errTest = errors.New("connection lost")
+
+var crate = internal.PackagingUnit{
+	ID: 2,
+	PackagingUnitAttributes: internal.PackagingUnitAttributes{
+		ProductID:       1,
+		Name:            "Crate",
+		UnitsPerPackage: 48,
+		Width:           60,
+		Height:          30,
+		Length:          80,
+		NetWeight:       57.6,
+		Barcode:         "27791234000016",
+	},
+}
+
+func TestUnitPackagingUnit_CreatePackagingUnit(t *testing.T) {
+	tests := []struct {
+		name       string
+		newUnit    internal.PackagingUnitAttributes
+		productErr error
+		existing   []internal.PackagingUnit
+		repoErr    error
+		wantErr    error
+	}{
+		{
+			name:     "CreatePackagingUnit OK",
+			newUnit:  crate.PackagingUnitAttributes,
+			existing: []internal.PackagingUnit{box},
+		},
+		{
+			name:    "CreatePackagingUnit zero units per package",
+			newUnit: internal.PackagingUnitAttributes{ProductID: 1, Name: "Crate"},
+			wantErr: utils.ErrInvalidArguments,
+		},
+		{
+			name:       "CreatePackagingUnit product doesn't exist",
+			newUnit:    crate.PackagingUnitAttributes,
+			productErr: utils.ErrNotFound,
+			wantErr:    utils.ErrInvalidArguments,
+		},
+		{
+			name:       "CreatePackagingUnit product not read",
+			newUnit:    crate.PackagingUnitAttributes,
+			productErr: errTest,
+			wantErr:    errTest,
+		},
+		{
+			name:     "CreatePackagingUnit duplicated name",
+			newUnit:  box.PackagingUnitAttributes,
+			existing: []internal.PackagingUnit{box},
+			wantErr:  utils.ErrConflict,
+		},
+		{
+			name:     "CreatePackagingUnit duplicated barcode",
+			newUnit:  crate.PackagingUnitAttributes,
+			existing: []internal.PackagingUnit{box},
+			repoErr:  utils.ErrConflict,
+			wantErr:  utils.ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPackagingUnitRepository{}
+			products := &mockProductValidation{}
+			products.On("GetProductByID", tt.newUnit.ProductID).Return(internal.Product{ID: tt.newUnit.ProductID}, tt.productErr)
+			repo.On("GetByProductID", tt.newUnit.ProductID).Return(tt.existing, nil)
+			repo.On("Create", tt.newUnit).Return(internal.PackagingUnit{ID: 2, PackagingUnitAttributes: tt.newUnit}, tt.repoErr)
+
+			s := NewPackagingUnitService(repo, products, newMockUnitOfWork(repo))
+
+			unit, err := s.CreatePackagingUnit(context.Background(), tt.newUnit)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, crate, unit)
+		})
+	}
+}
+
+func TestUnitPackagingUnit_UpdatePackagingUnit(t *testing.T) {
+	repo := &mockPackagingUnitRepository{}
+	repo.On("GetByID", box.ID).Return(box, nil)
+	repo.On("GetByProductID", box.ProductID).Return([]internal.PackagingUnit{box, crate}, nil)
+
+	updated := box
+	updated.UnitsPerPackage = 24
+	repo.On("Update", updated).Return(updated, nil)
+
+	s := NewPackagingUnitService(repo, &mockProductValidation{}, newMockUnitOfWork(repo))
+
+	unit, err := s.UpdatePackagingUnit(context.Background(), internal.PackagingUnit{ID: box.ID, PackagingUnitAttributes: internal.PackagingUnitAttributes{UnitsPerPackage: 24}})
+	require.NoError(t, err)
+	require.Equal(t, updated, unit)
+
+	_, err = s.UpdatePackagingUnit(context.Background(), internal.PackagingUnit{ID: box.ID, PackagingUnitAttributes: internal.PackagingUnitAttributes{Name: "crate"}})
+	require.ErrorIs(t, err, utils.ErrConflict)
+
+	_, err = s.UpdatePackagingUnit(context.Background(), internal.PackagingUnit{ID: box.ID, PackagingUnitAttributes: internal.PackagingUnitAttributes{UnitsPerPackage: 24}, Version: box.Version + 1})
+	require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+}
+
+func TestUnitPackagingUnit_ConvertQuantity(t *testing.T) {
+	otherProductUnit := box
+	otherProductUnit.ID = 3
+	otherProductUnit.ProductID = 2
+
+	tests := []struct {
+		name           string
+		quantity       float64
+		fromUnitID     int
+		toUnitID       int
+		wantConversion internal.PackagingConversion
+		wantErr        error
+	}{
+		{
+			name:           "ConvertQuantity crates to boxes",
+			quantity:       2,
+			fromUnitID:     crate.ID,
+			toUnitID:       box.ID,
+			wantConversion: internal.PackagingConversion{ProductID: 1, FromUnitID: 2, ToUnitID: 1, Quantity: 2, BaseQuantity: 96, ConvertedQuantity: 8},
+		},
+		{
+			name:           "ConvertQuantity base units to crates",
+			quantity:       24,
+			fromUnitID:     internal.BasePackagingUnitID,
+			toUnitID:       crate.ID,
+			wantConversion: internal.PackagingConversion{ProductID: 1, FromUnitID: 0, ToUnitID: 2, Quantity: 24, BaseQuantity: 24, ConvertedQuantity: 0.5},
+		},
+		{
+			name:       "ConvertQuantity negative quantity",
+			quantity:   -1,
+			fromUnitID: box.ID,
+			wantErr:    utils.ErrInvalidArguments,
+		},
+		{
+			name:       "ConvertQuantity unit of another product",
+			quantity:   1,
+			fromUnitID: otherProductUnit.ID,
+			wantErr:    utils.ErrInvalidArguments,
+		},
+		{
+			name:     "ConvertQuantity unknown unit",
+			quantity: 1,
+			toUnitID: 99,
+			wantErr:  utils.ErrInvalidArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPackagingUnitRepository{}
+			products := &mockProductValidation{}
+			products.On("GetProductByID", 1).Return(internal.Product{ID: 1}, nil)
+			repo.On("GetByID", box.ID).Return(box, nil)
+			repo.On("GetByID", crate.ID).Return(crate, nil)
+			repo.On("GetByID", otherProductUnit.ID).Return(otherProductUnit, nil)
+			repo.On("GetByID", 99).Return(internal.PackagingUnit{}, utils.ErrNotFound)
+
+			s := NewPackagingUnitService(repo, products, newMockUnitOfWork(repo))
+
+			conversion, err := s.ConvertQuantity(context.Background(), 1, tt.quantity, tt.fromUnitID, tt.toUnitID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantConversion, conversion)
+		})
+	}
+}
+
+func TestUnitPackagingUnit_GetPackagingUnits(t *testing.T) {
+	tests := []struct {
+		name       string
+		productErr error
+		wantErr    error
+	}{
+		{name: "GetPackagingUnits OK"},
+		{name: "GetPackagingUnits product doesn't exist", productErr: utils.ErrNotFound, wantErr: utils.ErrNotFound},
+		{name: "GetPackagingUnits product not read", productErr: errTest, wantErr: errTest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPackagingUnitRepository{}
+			products := &mockProductValidation{}
+			products.On("GetProductByID", 1).Return(internal.Product{ID: 1}, tt.productErr)
+			repo.On("GetByProductID", 1).Return([]internal.PackagingUnit{box}, nil)
+
+			s := NewPackagingUnitService(repo, products, newMockUnitOfWork(repo))
+
+			units, err := s.GetPackagingUnits(context.Background(), 1)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				if tt.wantErr != utils.ErrNotFound {
+					require.NotErrorIs(t, err, utils.ErrNotFound)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, []internal.PackagingUnit{box}, units)
+		})
+	}
+}
+
+func TestUnitPackagingUnit_ToBaseUnits(t *testing.T) {
+	repo := &mockPackagingUnitRepository{}
+	repo.On("GetByID", box.ID).Return(box, nil)
+
+	s := NewPackagingUnitService(repo, &mockProductValidation{}, newMockUnitOfWork(repo))
+
+	baseQuantity, err := s.ToBaseUnits(context.Background(), 1, box.ID, 3)
+	require.NoError(t, err)
+	require.Equal(t, 36, baseQuantity)
+
+	baseQuantity, err = s.ToBaseUnits(context.Background(), 1, internal.BasePackagingUnitID, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, baseQuantity)
+}
+
+func TestUnitPackagingUnit_DeletePackagingUnit(t *testing.T) {
+	repo := &mockPackagingUnitRepository{}
+	repo.On("GetByID", 1).Return(internal.PackagingUnit{ID: 1, Version: 2}, nil)
+	repo.On("GetByID", 2).Return(internal.PackagingUnit{}, utils.ErrNotFound)
+	repo.On("Delete", 1, 2).Return(nil)
+
+	uow := newMockUnitOfWork(repo)
+	s := NewPackagingUnitService(repo, &mockProductValidation{}, uow)
+
+	require.NoError(t, s.DeletePackagingUnit(context.Background(), 1, 2))
+	require.Equal(t, utils.ENotFound("Packaging unit"), s.DeletePackagingUnit(context.Background(), 2, utils.AnyVersion))
+	require.Equal(t, utils.EPreconditionFailed("packaging unit"), s.DeletePackagingUnit(context.Background(), 1, 1))
+	require.Len(t, uow.entries, 1)
+	require.Equal(t, internal.AuditDelete, uow.entries[0].Action)
+	require.Equal(t, "packagingUnits", uow.entries[0].Entity)
+	repo.AssertNumberOfCalls(t, "Delete", 1)
+}
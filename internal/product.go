@@ -1,8 +1,19 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type Product struct {
 	ID int `json:"id"`
 	ProductAttributes
+	// Version is bumped by every change, it is the ETag of the product
+	Version int `json:"version,omitempty"`
+	// DeletedAt is the time the product was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type ProductAttributes struct {
@@ -17,27 +28,101 @@ type ProductAttributes struct {
 	FreezingRate                   float64 `json:"freezing_rate"`
 	ProductType                    int     `json:"product_type"`
 	SellerID                       int     `json:"seller_id"`
+	// Barcode is the product GTIN, stored in its 14 digits form
+	Barcode string `json:"barcode,omitempty"`
+}
+
+// Volume returns the space taken by one unit of the product
+func (p ProductAttributes) Volume() float64 {
+	return p.Width * p.Height * p.Length
+}
+
+// ProductRange is an inclusive range, a nil bound means unbounded
+type ProductRange struct {
+	Min *float64
+	Max *float64
+}
+
+// ProductSearchFilter holds the criteria to search the product catalogue
+type ProductSearchFilter struct {
+	// Text is matched against description and product_code
+	Text                           string
+	ProductTypeID                  int
+	SellerID                       int
+	Width                          ProductRange
+	Height                         ProductRange
+	Length                         ProductRange
+	NetWeight                      ProductRange
+	RecommendedFreezingTemperature ProductRange
+	// SortBy is the json name of the attribute to sort by, SortDesc reverses the order
+	SortBy   string
+	SortDesc bool
+	Page     int
+	PageSize int
+}
+
+// ProductSearchResult is a page of products matching a ProductSearchFilter
+type ProductSearchResult struct {
+	Data     []Product `json:"data"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Total    int       `json:"total"`
+}
+
+const (
+	// ProductIncludeSeller embeds the seller of a product in its responses
+	ProductIncludeSeller = "seller"
+	// ProductIncludeProductType embeds the product type of a product in its responses, in place of its id
+	ProductIncludeProductType = "product_type"
+)
+
+// ProductRelations are the entities related to a product embedded in its responses with include
+type ProductRelations struct {
+	Seller      *Seller      `json:"seller,omitempty"`
+	ProductType *ProductType `json:"product_type,omitempty"`
 }
 
 type ProductService interface {
-	GetProducts() (listProducts []Product, err error)
-	GetProductByID(id int) (product Product, err error)
-	CreateProduct(newProduct ProductAttributes) (product Product, err error)
-	UpdateProduct(inputProduct Product) (product Product, err error)
-	DeleteProduct(id int) (err error)
+	GetProducts(ctx context.Context) (listProducts []Product, err error)
+	SearchProducts(ctx context.Context, filter ProductSearchFilter) (result ProductSearchResult, err error)
+	ListProducts(ctx context.Context, query utils.ListQuery) (listProducts []Product, pagination utils.Pagination, err error)
+	GetProductByID(ctx context.Context, id int) (product Product, err error)
+	// GetProductRelations returns the entities related to each product that were asked by include
+	GetProductRelations(ctx context.Context, listProducts []Product, include []string) (relations []ProductRelations, err error)
+	CreateProduct(ctx context.Context, newProduct ProductAttributes) (product Product, err error)
+	UpdateProduct(ctx context.Context, inputProduct Product) (product Product, err error)
+	// DeleteProduct soft deletes a product in the version given, it is left out of the listings until it is restored
+	DeleteProduct(ctx context.Context, id int, version int) (err error)
+	// RestoreProduct brings back a soft deleted product
+	RestoreProduct(ctx context.Context, id int) (product Product, err error)
+	// PurgeProducts removes for good the products deleted before a time, returns how many were removed
+	PurgeProducts(ctx context.Context, before time.Time) (purged int, err error)
+	GetProductByBarcode(ctx context.Context, barcode string) (product Product, err error)
+	ImportProducts(ctx context.Context, items []utils.ImportItem[ProductAttributes], options utils.ImportOptions) (report utils.ImportReport, err error)
 }
 
 type ProductRepository interface {
-	GetAll() (listProducts []Product, err error)
-	GetByID(id int) (product Product, err error)
-	Create(newproduct ProductAttributes) (product Product, err error)
-	Update(inputProduct Product) (product Product, err error)
-	Delete(id int) (err error)
+	GetAll(ctx context.Context) (listProducts []Product, err error)
+	Search(ctx context.Context, filter ProductSearchFilter) (listProducts []Product, total int, err error)
+	// List returns the products of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) (listProducts []Product, err error)
+	GetByID(ctx context.Context, id int) (product Product, err error)
+	GetByBarcode(ctx context.Context, gtin string) (product Product, err error)
+	Create(ctx context.Context, newproduct ProductAttributes) (product Product, err error)
+	// CreateAll creates the products in a single transaction, none of them when one fails
+	CreateAll(ctx context.Context, newProducts []ProductAttributes) (listProducts []Product, err error)
+	Update(ctx context.Context, inputProduct Product) (product Product, err error)
+	// Delete soft deletes a product when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) (err error)
+	Restore(ctx context.Context, id int) (err error)
+	Purge(ctx context.Context, before time.Time) (purged int, err error)
 }
 
 type ProductTypeValidation interface {
-	GetProductTypeByID(id int) (productType ProductType, err error)
+	GetProductTypeByID(ctx context.Context, id int) (productType ProductType, err error)
+	GetProductTypesByIDs(ctx context.Context, ids []int) (listProductTypes []ProductType, err error)
 }
 type SellerValidation interface {
-	GetByID(id int) (Seller, error)
+	GetByID(ctx context.Context, id int) (Seller, error)
+	GetByIDs(ctx context.Context, ids []int) ([]Seller, error)
 }
@@ -1,25 +1,61 @@
 package product
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// sortColumns maps the sortable product attributes, by their json name, to their column
+var sortColumns = map[string]string{
+	"id":                               "p.id",
+	"product_code":                     "p.product_code",
+	"description":                      "p.description",
+	"width":                            "p.width",
+	"height":                           "p.height",
+	"length":                           "p.`length`",
+	"net_weight":                       "p.net_weight",
+	"expiration_rate":                  "p.expiration_rate",
+	"recommended_freezing_temperature": "p.recommended_freezing_temperature",
+	"freezing_rate":                    "p.freezing_rate",
+}
+
+// listFields are the fields the products can be filtered and sorted by, the barcode is optional
+// so it can only be filtered
+var listFields = utils.ListFields{
+	"id":                               {Column: "p.id", Kind: utils.ListInt, Sortable: true},
+	"product_code":                     {Column: "p.product_code", Kind: utils.ListString, Sortable: true},
+	"description":                      {Column: "p.description", Kind: utils.ListString, Sortable: true},
+	"width":                            {Column: "p.width", Kind: utils.ListFloat, Sortable: true},
+	"height":                           {Column: "p.height", Kind: utils.ListFloat, Sortable: true},
+	"length":                           {Column: "p.`length`", Kind: utils.ListFloat, Sortable: true},
+	"net_weight":                       {Column: "p.net_weight", Kind: utils.ListFloat, Sortable: true},
+	"expiration_rate":                  {Column: "p.expiration_rate", Kind: utils.ListFloat, Sortable: true},
+	"recommended_freezing_temperature": {Column: "p.recommended_freezing_temperature", Kind: utils.ListFloat, Sortable: true},
+	"freezing_rate":                    {Column: "p.freezing_rate", Kind: utils.ListFloat, Sortable: true},
+	"product_type":                     {Column: "p.product_type_id", Kind: utils.ListInt, Sortable: true},
+	"seller_id":                        {Column: "p.seller_id", Kind: utils.ListInt, Sortable: true},
+	"barcode":                          {Column: "p.barcode", Kind: utils.ListString},
+}
+
 type MySQLProductRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewProductDB(db *sql.DB) *MySQLProductRepository {
+func NewProductDB(db utils.DBTX) *MySQLProductRepository {
 	return &MySQLProductRepository{db: db}
 }
 
 // GetAll returns all products
-func (p *MySQLProductRepository) GetAll() (listProducts []internal.Product, err error) {
-	rows, err := p.db.Query("SELECT id, description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id FROM fresh_products.products")
+func (p *MySQLProductRepository) GetAll(ctx context.Context) (listProducts []internal.Product, err error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT id, description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, COALESCE(barcode, ''), version FROM fresh_products.products WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +65,7 @@ func (p *MySQLProductRepository) GetAll() (listProducts []internal.Product, err
 	for rows.Next() {
 		var product internal.Product
 
-		err := rows.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID)
+		err := rows.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID, &product.Barcode, &product.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -45,14 +81,196 @@ func (p *MySQLProductRepository) GetAll() (listProducts []internal.Product, err
 	return listProducts, nil
 }
 
+// Search returns a page of the products matching the filter and the total of matching products
+func (p *MySQLProductRepository) Search(ctx context.Context, filter internal.ProductSearchFilter) (listProducts []internal.Product, total int, err error) {
+	where, args := searchConditions(filter, internal.SellerCondition(ctx, "p.seller_id"))
+
+	err = p.db.QueryRowContext(ctx, "SELECT COUNT(p.id) FROM products p"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	orderBy, ok := sortColumns[filter.SortBy]
+	if !ok {
+		orderBy = sortColumns["id"]
+	}
+
+	if filter.SortDesc {
+		orderBy += " DESC"
+	}
+
+	query := "SELECT p.id, p.description, p.expiration_rate, p.freezing_rate, p.height, p.`length`, p.net_weight, p.product_code, p.recommended_freezing_temperature, p.width, p.product_type_id, p.seller_id, COALESCE(p.barcode, ''), p.version FROM products p" +
+		where + " ORDER BY " + orderBy + ", p.id LIMIT ? OFFSET ?"
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	listProducts = []internal.Product{}
+
+	for rows.Next() {
+		var product internal.Product
+
+		err := rows.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID, &product.Barcode, &product.Version)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		listProducts = append(listProducts, product)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return listProducts, total, nil
+}
+
+// List returns a page of products
+func (p *MySQLProductRepository) List(ctx context.Context, query utils.ListQuery) (listProducts []internal.Product, err error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition(), internal.SellerCondition(ctx, "p.seller_id"))
+
+	rows, err := p.db.QueryContext(ctx, "SELECT p.id, p.description, p.expiration_rate, p.freezing_rate, p.height, p.`length`, p.net_weight, p.product_code, p.recommended_freezing_temperature, p.width, p.product_type_id, p.seller_id, COALESCE(p.barcode, ''), p.version, p.deleted_at FROM products p"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var product internal.Product
+
+		err = rows.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID, &product.Barcode, &product.Version, &product.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		listProducts = append(listProducts, product)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return listProducts, nil
+}
+
+// searchConditions builds the WHERE clause and its arguments for a product search, with the scope
+// conditions given
+func searchConditions(filter internal.ProductSearchFilter, scope ...string) (string, []any) {
+	conditions := append([]string{"p.deleted_at IS NULL"}, scope...)
+
+	var args []any
+
+	if text := strings.TrimSpace(filter.Text); text != "" {
+		condition := "p.product_code LIKE ?"
+		args = append(args, "%"+escapeLike(text)+"%")
+
+		if terms := fullTextTerms(text); terms != "" {
+			condition = "(MATCH(p.description, p.product_code) AGAINST (? IN BOOLEAN MODE) OR " + condition + ")"
+			args = append([]any{terms}, args...)
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if filter.ProductTypeID != 0 {
+		conditions = append(conditions, "p.product_type_id = ?")
+		args = append(args, filter.ProductTypeID)
+	}
+
+	if filter.SellerID != 0 {
+		conditions = append(conditions, "p.seller_id = ?")
+		args = append(args, filter.SellerID)
+	}
+
+	ranges := []struct {
+		column string
+		bounds internal.ProductRange
+	}{
+		{"p.width", filter.Width},
+		{"p.height", filter.Height},
+		{"p.`length`", filter.Length},
+		{"p.net_weight", filter.NetWeight},
+		{"p.recommended_freezing_temperature", filter.RecommendedFreezingTemperature},
+	}
+
+	for _, r := range ranges {
+		if r.bounds.Min != nil {
+			conditions = append(conditions, r.column+" >= ?")
+			args = append(args, *r.bounds.Min)
+		}
+
+		if r.bounds.Max != nil {
+			conditions = append(conditions, r.column+" <= ?")
+			args = append(args, *r.bounds.Max)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// fullTextTerms turns free text into a boolean mode full-text query requiring every word as a prefix,
+// the full-text operators are dropped so the user input cannot change the query semantics
+func fullTextTerms(text string) string {
+	var terms []string
+
+	for _, word := range strings.Fields(text) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+
+			return -1
+		}, word)
+
+		if word != "" {
+			terms = append(terms, "+"+word+"*")
+		}
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// escapeLike escapes the LIKE wildcards of a value
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
 // GetByID returns a product by id
-func (p *MySQLProductRepository) GetByID(id int) (product internal.Product, err error) {
-	row := p.db.QueryRow("SELECT id, description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id FROM products WHERE id = ?", id)
+func (p *MySQLProductRepository) GetByID(ctx context.Context, id int) (product internal.Product, err error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, COALESCE(barcode, ''), version FROM products WHERE id = ? AND deleted_at IS NULL AND "+internal.SellerCondition(ctx, "seller_id"), id)
 	if err := row.Err(); err != nil {
 		return internal.Product{}, err
 	}
 
-	err = row.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID)
+	err = row.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID, &product.Barcode, &product.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.Product{}, utils.ErrNotFound
+		}
+
+		return internal.Product{}, err
+	}
+
+	return product, nil
+}
+
+// GetByBarcode returns a product by its GTIN
+func (p *MySQLProductRepository) GetByBarcode(ctx context.Context, gtin string) (product internal.Product, err error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, description, expiration_rate, freezing_rate, height, length, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, COALESCE(barcode, ''), version FROM products WHERE barcode = ? AND deleted_at IS NULL AND "+internal.SellerCondition(ctx, "seller_id"), gtin)
+
+	err = row.Scan(&product.ID, &product.Description, &product.ExpirationRate, &product.FreezingRate, &product.Height, &product.Length, &product.NetWeight, &product.ProductCode, &product.RecommendedFreezingTemperature, &product.Width, &product.ProductType, &product.SellerID, &product.Barcode, &product.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return internal.Product{}, utils.ErrNotFound
@@ -65,14 +283,14 @@ func (p *MySQLProductRepository) GetByID(id int) (product internal.Product, err
 }
 
 // Create a product
-func (p *MySQLProductRepository) Create(newproduct internal.ProductAttributes) (product internal.Product, err error) {
-	statement, err := p.db.Prepare("INSERT INTO products (description, expiration_rate, freezing_rate, height, `length`, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+func (p *MySQLProductRepository) Create(ctx context.Context, newproduct internal.ProductAttributes) (product internal.Product, err error) {
+	statement, err := p.db.PrepareContext(ctx, "INSERT INTO products (description, expiration_rate, freezing_rate, height, `length`, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, barcode) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))")
 	if err != nil {
 		return internal.Product{}, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(newproduct.Description, newproduct.ExpirationRate, newproduct.FreezingRate, newproduct.Height, newproduct.Length, newproduct.NetWeight, newproduct.ProductCode, newproduct.RecommendedFreezingTemperature, newproduct.Width, newproduct.ProductType, newproduct.SellerID)
+	result, err := statement.ExecContext(ctx, newproduct.Description, newproduct.ExpirationRate, newproduct.FreezingRate, newproduct.Height, newproduct.Length, newproduct.NetWeight, newproduct.ProductCode, newproduct.RecommendedFreezingTemperature, newproduct.Width, newproduct.ProductType, newproduct.SellerID, newproduct.Barcode)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -96,27 +314,67 @@ func (p *MySQLProductRepository) Create(newproduct internal.ProductAttributes) (
 	product = internal.Product{
 		ID:                int(id),
 		ProductAttributes: newproduct,
+		Version:           1,
 	}
 
 	return product, nil
 }
 
+// CreateAll creates the products in a single transaction, none of them when one fails
+func (p *MySQLProductRepository) CreateAll(ctx context.Context, newProducts []internal.ProductAttributes) (listProducts []internal.Product, err error) {
+	listProducts = make([]internal.Product, 0, len(newProducts))
+
+	err = utils.InTx(ctx, p.db, func(tx utils.DBTX) error {
+		statement, err := tx.PrepareContext(ctx, "INSERT INTO products (description, expiration_rate, freezing_rate, height, `length`, net_weight, product_code, recommended_freezing_temperature, width, product_type_id, seller_id, barcode) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))")
+		if err != nil {
+			return err
+		}
+		defer statement.Close()
+
+		for _, newProduct := range newProducts {
+			result, err := statement.ExecContext(ctx, newProduct.Description, newProduct.ExpirationRate, newProduct.FreezingRate, newProduct.Height, newProduct.Length, newProduct.NetWeight, newProduct.ProductCode, newProduct.RecommendedFreezingTemperature, newProduct.Width, newProduct.ProductType, newProduct.SellerID, newProduct.Barcode)
+			if err != nil {
+				var mysqlErr *mysql.MySQLError
+				if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+					return utils.ErrConflict
+				}
+
+				return err
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			listProducts = append(listProducts, internal.Product{ID: int(id), ProductAttributes: newProduct, Version: 1})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listProducts, nil
+}
+
 // Update a product
-func (p *MySQLProductRepository) Update(inputProduct internal.Product) (product internal.Product, err error) {
-	_, err = p.GetByID(inputProduct.ID)
+func (p *MySQLProductRepository) Update(ctx context.Context, inputProduct internal.Product) (product internal.Product, err error) {
+	_, err = p.GetByID(ctx, inputProduct.ID)
 	if err != nil {
 		return internal.Product{}, err
 	}
 
-	statement, err := p.db.Prepare(
-		"UPDATE products SET description=?, expiration_rate=?, freezing_rate=?, height=?, `length`=?, net_weight=?, product_code=?, recommended_freezing_temperature=?, width=?, product_type_id=?, seller_id=? WHERE id=?",
+	statement, err := p.db.PrepareContext(ctx,
+		"UPDATE products SET description=?, expiration_rate=?, freezing_rate=?, height=?, `length`=?, net_weight=?, product_code=?, recommended_freezing_temperature=?, width=?, product_type_id=?, seller_id=?, barcode=NULLIF(?, ''), version=version+1 WHERE id=? AND version=?",
 	)
 	if err != nil {
 		return internal.Product{}, err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(inputProduct.Description, inputProduct.ExpirationRate, inputProduct.FreezingRate, inputProduct.Height, inputProduct.Length, inputProduct.NetWeight, inputProduct.ProductCode, inputProduct.RecommendedFreezingTemperature, inputProduct.Width, inputProduct.ProductType, inputProduct.SellerID, inputProduct.ID)
+	result, err := statement.ExecContext(ctx, inputProduct.Description, inputProduct.ExpirationRate, inputProduct.FreezingRate, inputProduct.Height, inputProduct.Length, inputProduct.NetWeight, inputProduct.ProductCode, inputProduct.RecommendedFreezingTemperature, inputProduct.Width, inputProduct.ProductType, inputProduct.SellerID, inputProduct.Barcode, inputProduct.ID, inputProduct.Version)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -132,26 +390,27 @@ func (p *MySQLProductRepository) Update(inputProduct internal.Product) (product
 		return internal.Product{}, err
 	}
 
-	return inputProduct, nil
-}
-
-// Delete a product
-func (p *MySQLProductRepository) Delete(id int) error {
-	_, err := p.GetByID(id)
+	err = utils.CheckVersionUpdated(result, "product")
 	if err != nil {
-		return err
+		return internal.Product{}, err
 	}
 
-	statement, err := p.db.Prepare("DELETE FROM products WHERE id = ?")
-	if err != nil {
-		return err
-	}
-	defer statement.Close()
+	inputProduct.Version++
 
-	_, err = statement.Exec(id)
-	if err != nil {
-		return err
-	}
+	return inputProduct, nil
+}
+
+// Delete soft deletes a product, its row is kept with the time it was deleted
+func (p *MySQLProductRepository) Delete(ctx context.Context, id int, version int) error {
+	return utils.SoftDelete(ctx, p.db, "products", "product", id, version, internal.SellerCondition(ctx, "seller_id"))
+}
+
+// Restore clears the deletion of a soft deleted product
+func (p *MySQLProductRepository) Restore(ctx context.Context, id int) error {
+	return utils.Restore(ctx, p.db, "products", id, internal.SellerCondition(ctx, "seller_id"))
+}
 
-	return nil
+// Purge removes for good the products deleted before a time, but the ones other records still refer to
+func (p *MySQLProductRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, p.db, "products", before)
 }
@@ -4,17 +4,22 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 func NewProductRoutes(mux *chi.Mux, service internal.ProductService) error {
 	productHandler := handler.NewProductHandler(service)
 
 	mux.Route("/api/v1/products", func(router chi.Router) {
-		router.Get("/", productHandler.GetProducts)
-		router.Post("/", productHandler.CreateProduct)
-		router.Get("/{id}", productHandler.GetProductByID)
-		router.Patch("/{id}", productHandler.UpdateProduct)
-		router.Delete("/{id}", productHandler.DeleteProduct)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionRead))).Get("/", productHandler.GetProducts)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionWrite))).Post("/", productHandler.CreateProduct)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionRead))).Get("/search", productHandler.SearchProducts)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionWrite))).Post("/import", productHandler.ImportProducts)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionRead))).Get("/barcode/{barcode}", productHandler.GetProductByBarcode)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionRead))).Get("/{id}", productHandler.GetProductByID)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionWrite))).Patch("/{id}", productHandler.UpdateProduct)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionDelete))).Delete("/{id}", productHandler.DeleteProduct)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProducts, rbac.ActionWrite))).Post("/{id}/restore", productHandler.RestoreProduct)
 	})
 
 	return nil
@@ -1,26 +1,45 @@
 package product
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+const (
+	// DefaultPageSize is the page size used when searching products without one
+	DefaultPageSize = 20
+	// MaxPageSize is the biggest page size allowed when searching products
+	MaxPageSize = 100
+)
+
+// auditEntity is the name of the products in the audit trail
+const auditEntity = "products"
+
 type BasicProductService struct {
 	repo                  internal.ProductRepository
 	validationProductType internal.ProductTypeValidation
 	validationSeller      internal.SellerValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewProductService(repo internal.ProductRepository, validationProductType internal.ProductTypeValidation, validationSeller internal.SellerValidation) *BasicProductService {
+func NewProductService(repo internal.ProductRepository, validationProductType internal.ProductTypeValidation, validationSeller internal.SellerValidation, uow internal.UnitOfWork) *BasicProductService {
 	return &BasicProductService{
 		repo:                  repo,
 		validationProductType: validationProductType,
 		validationSeller:      validationSeller,
+		uow:                   uow,
 	}
 }
 
-func (s *BasicProductService) GetProducts() (listProducts []internal.Product, err error) {
-	listProducts, err = s.repo.GetAll()
+func (s *BasicProductService) GetProducts(ctx context.Context) (listProducts []internal.Product, err error) {
+	listProducts, err = s.repo.GetAll(ctx)
 	if err != nil {
 		return nil, utils.ENotFound("Product")
 	}
@@ -28,46 +47,223 @@ func (s *BasicProductService) GetProducts() (listProducts []internal.Product, er
 	return listProducts, err
 }
 
-func (s *BasicProductService) GetProductByID(id int) (product internal.Product, err error) {
-	product, err = s.repo.GetByID(id)
+// ListProducts returns a page of products filtered and sorted as asked by the query
+func (s *BasicProductService) ListProducts(ctx context.Context, query utils.ListQuery) (listProducts []internal.Product, pagination utils.Pagination, err error) {
+	err = query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	listProducts, err = s.repo.List(ctx, query)
 	if err != nil {
-		return internal.Product{}, utils.ENotFound("Product")
+		return nil, utils.Pagination{}, err
+	}
+
+	listProducts, pagination = utils.Paginate(listProducts, query)
+
+	return listProducts, pagination, nil
+}
+
+func (s *BasicProductService) SearchProducts(ctx context.Context, filter internal.ProductSearchFilter) (result internal.ProductSearchResult, err error) {
+	err = validateSearchFilter(&filter)
+	if err != nil {
+		return internal.ProductSearchResult{}, err
+	}
+
+	listProducts, total, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return internal.ProductSearchResult{}, err
+	}
+
+	result = internal.ProductSearchResult{
+		Data:     listProducts,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}
+
+	return result, nil
+}
+
+func (s *BasicProductService) GetProductByID(ctx context.Context, id int) (product internal.Product, err error) {
+	product, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Product{}, utils.ENotFound("Product")
+		}
+
+		return internal.Product{}, err
 	}
 
 	return product, err
 }
 
-func (s *BasicProductService) CreateProduct(newProduct internal.ProductAttributes) (product internal.Product, err error) {
-	err = s.validateEmptyFields(newProduct)
+// GetProductRelations returns the seller and the product type of each product when asked by include,
+// each of them is read once for all the products
+func (s *BasicProductService) GetProductRelations(ctx context.Context, listProducts []internal.Product, include []string) (relations []internal.ProductRelations, err error) {
+	relations = make([]internal.ProductRelations, len(listProducts))
+
+	sellerID := func(product internal.Product) int { return product.SellerID }
+	productTypeID := func(product internal.Product) int { return product.ProductType }
+
+	for _, name := range include {
+		switch name {
+		case internal.ProductIncludeSeller:
+			var sellers []internal.Seller
+
+			sellers, err = s.validationSeller.GetByIDs(ctx, utils.DistinctIDs(listProducts, sellerID))
+			if err != nil {
+				return nil, err
+			}
+
+			for i, seller := range utils.Related(listProducts, sellerID, sellers, func(seller internal.Seller) int { return seller.ID }) {
+				relations[i].Seller = seller
+			}
+		case internal.ProductIncludeProductType:
+			var productTypes []internal.ProductType
+
+			productTypes, err = s.validationProductType.GetProductTypesByIDs(ctx, utils.DistinctIDs(listProducts, productTypeID))
+			if err != nil {
+				return nil, err
+			}
+
+			for i, productType := range utils.Related(listProducts, productTypeID, productTypes, func(productType internal.ProductType) int { return productType.ID }) {
+				relations[i].ProductType = productType
+			}
+		}
+	}
+
+	return relations, nil
+}
+
+func (s *BasicProductService) CreateProduct(ctx context.Context, newProduct internal.ProductAttributes) (product internal.Product, err error) {
+	err = ownSeller(ctx, &newProduct)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	err = s.validateEmptyFields(ctx, newProduct)
+
+	if err != nil {
+		return internal.Product{}, err
+	}
 
+	newProduct.Barcode, err = normalizeBarcode(newProduct.Barcode)
 	if err != nil {
 		return internal.Product{}, err
 	}
 
-	listProducts, _ := s.repo.GetAll()
+	listProducts, _ := s.repo.GetAll(ctx)
 	err = s.validateDuplicates(listProducts, newProduct)
 
 	if err != nil {
 		return internal.Product{}, utils.EConflict("Product", "ProductCode")
 	}
 
-	return s.repo.Create(newProduct)
+	return s.create(ctx, newProduct, internal.AuditCreate)
+}
+
+// create creates a product along with its audit entry of the action
+func (s *BasicProductService) create(ctx context.Context, newProduct internal.ProductAttributes, action string) (product internal.Product, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		product, err = repos.Products.Create(ctx, newProduct)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, action, auditEntity, product.ID, nil, product)
+	})
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	return product, nil
 }
 
-func (s *BasicProductService) UpdateProduct(inputProduct internal.Product) (product internal.Product, err error) {
-	internalProduct, err := s.repo.GetByID(inputProduct.ID)
+func (s *BasicProductService) UpdateProduct(ctx context.Context, inputProduct internal.Product) (product internal.Product, err error) {
+	internalProduct, err := s.repo.GetByID(ctx, inputProduct.ID)
 
 	if err != nil {
 		return internal.Product{}, utils.ErrNotFound
 	}
 
+	err = utils.CheckVersion("product", inputProduct.Version, internalProduct.Version)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	inputProduct.Barcode, err = normalizeBarcode(inputProduct.Barcode)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
 	preparedProduct := prepareProductUpdate(inputProduct, internalProduct)
 
-	return s.repo.Update(preparedProduct)
+	// a seller cannot give its products to another seller
+	err = internal.CheckSellerScope(ctx, preparedProduct.SellerID)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		product, err = repos.Products.Update(ctx, preparedProduct)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, product.ID, internalProduct, product)
+	})
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	return product, nil
 }
 
-func (s *BasicProductService) DeleteProduct(id int) (err error) {
-	err = s.repo.Delete(id)
+// GetProductByBarcode returns the product identified by a scanned GTIN or GS1-128 label
+func (s *BasicProductService) GetProductByBarcode(ctx context.Context, barcode string) (product internal.Product, err error) {
+	label, err := utils.ParseGS1(barcode)
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	if label.GTIN == "" {
+		return internal.Product{}, utils.EBR("barcode does not carry a GTIN")
+	}
+
+	product, err = s.repo.GetByBarcode(ctx, label.GTIN)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Product{}, utils.ENotFound("Product")
+		}
+
+		return internal.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (s *BasicProductService) DeleteProduct(ctx context.Context, id int, version int) (err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		product, err := repos.Products.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = utils.CheckVersion("product", version, product.Version); err != nil {
+			return err
+		}
+
+		if err = repos.Products.Delete(ctx, id, product.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, product, nil)
+	})
+	if errors.Is(err, utils.ErrPreconditionFailed) {
+		return err
+	}
+
 	if err != nil {
 		return utils.ENotFound("Product")
 	}
@@ -75,7 +271,142 @@ func (s *BasicProductService) DeleteProduct(id int) (err error) {
 	return err
 }
 
-func (s *BasicProductService) validateEmptyFields(newProduct internal.ProductAttributes) error {
+// RestoreProduct brings back a soft deleted product
+func (s *BasicProductService) RestoreProduct(ctx context.Context, id int) (product internal.Product, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		err = repos.Products.Restore(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.ENotFound("Deleted product")
+			}
+
+			return err
+		}
+
+		product, err = repos.Products.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, product)
+	})
+	if err != nil {
+		return internal.Product{}, err
+	}
+
+	return product, nil
+}
+
+// PurgeProducts removes for good the products deleted before a time
+func (s *BasicProductService) PurgeProducts(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		purged, err = repos.Products.Purge(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// ImportProducts creates products in bulk with the rules of CreateProduct, the product codes and
+// barcodes must be unique among the stored products and the imported ones
+func (s *BasicProductService) ImportProducts(ctx context.Context, items []utils.ImportItem[internal.ProductAttributes], options utils.ImportOptions) (report utils.ImportReport, err error) {
+	listProducts, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return utils.ImportReport{}, err
+	}
+
+	productCodes := map[string]bool{}
+	barcodes := map[string]bool{}
+
+	for _, product := range listProducts {
+		productCodes[product.ProductCode] = true
+		barcodes[product.Barcode] = product.Barcode != ""
+	}
+
+	return s.productImporter(ctx, productCodes, barcodes).Import(items, options)
+}
+
+// productImporter builds the steps of a products import, productCodes and barcodes hold the values already taken
+func (s *BasicProductService) productImporter(ctx context.Context, productCodes, barcodes map[string]bool) utils.Importer[internal.ProductAttributes] {
+	return utils.Importer[internal.ProductAttributes]{
+		Validate: func(newProduct *internal.ProductAttributes) error {
+			err := ownSeller(ctx, newProduct)
+			if err != nil {
+				return err
+			}
+
+			err = s.validateEmptyFields(ctx, *newProduct)
+			if err != nil {
+				return err
+			}
+
+			newProduct.Barcode, err = normalizeBarcode(newProduct.Barcode)
+			if err != nil {
+				return err
+			}
+
+			if productCodes[newProduct.ProductCode] {
+				return utils.EConflict("Product", "ProductCode")
+			}
+
+			if barcodes[newProduct.Barcode] {
+				return utils.EConflict("Product", "Barcode")
+			}
+
+			productCodes[newProduct.ProductCode] = true
+			barcodes[newProduct.Barcode] = newProduct.Barcode != ""
+
+			return nil
+		},
+		Save: func(newProduct internal.ProductAttributes) (int, error) {
+			product, err := s.create(ctx, newProduct, internal.AuditImport)
+			return product.ID, err
+		},
+		SaveAll: func(newProducts []internal.ProductAttributes) (ids []int, err error) {
+			err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+				listProducts, err := repos.Products.CreateAll(ctx, newProducts)
+				if err != nil {
+					return err
+				}
+
+				ids = make([]int, 0, len(listProducts))
+				for _, product := range listProducts {
+					if err = audit.Record(ctx, repos.Audit, internal.AuditImport, auditEntity, product.ID, nil, product); err != nil {
+						return err
+					}
+
+					ids = append(ids, product.ID)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return ids, nil
+		},
+	}
+}
+
+// ownSeller sets the seller of a product created by a seller to its own one when it is not given, a
+// seller only creates its own products
+func ownSeller(ctx context.Context, newProduct *internal.ProductAttributes) error {
+	if sellerID, scoped := internal.SellerScope(ctx); scoped && newProduct.SellerID == 0 {
+		newProduct.SellerID = sellerID
+	}
+
+	return internal.CheckSellerScope(ctx, newProduct.SellerID)
+}
+
+func (s *BasicProductService) validateEmptyFields(ctx context.Context, newProduct internal.ProductAttributes) error {
 	if newProduct.ProductCode == "" {
 		return utils.EZeroValue("ProductCode")
 	}
@@ -112,11 +443,11 @@ func (s *BasicProductService) validateEmptyFields(newProduct internal.ProductAtt
 		return utils.EZeroValue("FreezingRate")
 	}
 
-	if _, err := s.validationProductType.GetProductTypeByID(newProduct.ProductType); err != nil {
+	if _, err := s.validationProductType.GetProductTypeByID(ctx, newProduct.ProductType); err != nil {
 		return utils.EConflict("Product", "ProductType")
 	}
 
-	if _, err := s.validationSeller.GetByID(newProduct.SellerID); err != nil {
+	if _, err := s.validationSeller.GetByID(ctx, newProduct.SellerID); err != nil {
 		return utils.EConflict("Product", "Seller")
 	}
 
@@ -133,8 +464,58 @@ func (s *BasicProductService) validateDuplicates(listProducts []internal.Product
 	return nil
 }
 
+func validateSearchFilter(filter *internal.ProductSearchFilter) error {
+	if filter.SortBy != "" {
+		if _, ok := sortColumns[filter.SortBy]; !ok {
+			return utils.EBR("cannot sort by " + filter.SortBy)
+		}
+	}
+
+	ranges := map[string]internal.ProductRange{
+		"width":                            filter.Width,
+		"height":                           filter.Height,
+		"length":                           filter.Length,
+		"net_weight":                       filter.NetWeight,
+		"recommended_freezing_temperature": filter.RecommendedFreezingTemperature,
+	}
+
+	for name, r := range ranges {
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return utils.EBR("min_" + name + " cannot be greater than max_" + name)
+		}
+	}
+
+	if filter.Page < 0 {
+		return utils.EBR("page cannot be negative")
+	}
+
+	if filter.PageSize < 0 || filter.PageSize > MaxPageSize {
+		return utils.EBR("page_size must be between 1 and 100")
+	}
+
+	if filter.Page == 0 {
+		filter.Page = 1
+	}
+
+	if filter.PageSize == 0 {
+		filter.PageSize = DefaultPageSize
+	}
+
+	return nil
+}
+
+// normalizeBarcode validates an optional GTIN and returns it in its 14 digits form
+func normalizeBarcode(barcode string) (string, error) {
+	if strings.TrimSpace(barcode) == "" {
+		return "", nil
+	}
+
+	return utils.NormalizeGTIN(barcode)
+}
+
 func prepareProductUpdate(inputProduct, internalProduct internal.Product) (preparedProduct internal.Product) {
 	preparedProduct.ID = internalProduct.ID
+	preparedProduct.Version = internalProduct.Version
 
 	if inputProduct.ProductCode != "" {
 		preparedProduct.ProductCode = inputProduct.ProductCode
@@ -202,5 +583,11 @@ func prepareProductUpdate(inputProduct, internalProduct internal.Product) (prepa
 		preparedProduct.SellerID = internalProduct.SellerID
 	}
 
+	if inputProduct.Barcode != "" {
+		preparedProduct.Barcode = inputProduct.Barcode
+	} else {
+		preparedProduct.Barcode = internalProduct.Barcode
+	}
+
 	return preparedProduct
 }
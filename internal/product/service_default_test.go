@@ -1,7 +1,10 @@
 package product
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
@@ -9,6 +12,39 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.ProductRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Products: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type mockProductRepository struct {
 	mock.Mock
 }
@@ -21,41 +57,81 @@ type mockSellerValidation struct {
 	mock.Mock
 }
 
-func (m *mockProductRepository) GetAll() (listProducts []internal.Product, err error) {
+func (m *mockProductRepository) GetAll(ctx context.Context) (listProducts []internal.Product, err error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Product), args.Error(1)
 }
 
-func (m *mockProductRepository) GetByID(id int) (product internal.Product, err error) {
+func (m *mockProductRepository) List(ctx context.Context, query utils.ListQuery) (listProducts []internal.Product, err error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Product), args.Error(1)
+}
+
+func (m *mockProductRepository) GetByID(ctx context.Context, id int) (product internal.Product, err error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductRepository) Create(newproduct internal.ProductAttributes) (product internal.Product, err error) {
+func (m *mockProductRepository) GetByBarcode(ctx context.Context, gtin string) (product internal.Product, err error) {
+	args := m.Called(gtin)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+func (m *mockProductRepository) Create(ctx context.Context, newproduct internal.ProductAttributes) (product internal.Product, err error) {
 	args := m.Called(newproduct)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductRepository) Update(inputProduct internal.Product) (product internal.Product, err error) {
+func (m *mockProductRepository) CreateAll(ctx context.Context, newProducts []internal.ProductAttributes) (listProducts []internal.Product, err error) {
+	args := m.Called(newProducts)
+	return args.Get(0).([]internal.Product), args.Error(1)
+}
+
+func (m *mockProductRepository) Update(ctx context.Context, inputProduct internal.Product) (product internal.Product, err error) {
 	args := m.Called(inputProduct)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (m *mockProductRepository) Delete(id int) (err error) {
+func (m *mockProductRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockProductRepository) Restore(ctx context.Context, id int) (err error) {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *mockProductTypeValidation) GetProductTypeByID(id int) (productType internal.ProductType, err error) {
+func (m *mockProductRepository) Purge(ctx context.Context, before time.Time) (purged int, err error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockProductRepository) Search(ctx context.Context, filter internal.ProductSearchFilter) (listProducts []internal.Product, total int, err error) {
+	args := m.Called(filter)
+	return args.Get(0).([]internal.Product), args.Int(1), args.Error(2)
+}
+
+func (m *mockProductTypeValidation) GetProductTypeByID(ctx context.Context, id int) (productType internal.ProductType, err error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.ProductType), args.Error(1)
 }
 
-func (m *mockSellerValidation) GetByID(id int) (internal.Seller, error) {
+func (m *mockProductTypeValidation) GetProductTypesByIDs(ctx context.Context, ids []int) ([]internal.ProductType, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.ProductType), args.Error(1)
+}
+
+func (m *mockSellerValidation) GetByID(ctx context.Context, id int) (internal.Seller, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Seller), args.Error(1)
 }
 
+func (m *mockSellerValidation) GetByIDs(ctx context.Context, ids []int) ([]internal.Seller, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Seller), args.Error(1)
+}
+
 func TestUnitProduct_GetProducts(t *testing.T) {
 	type fields struct {
 		repo                  internal.ProductRepository
@@ -113,6 +189,7 @@ func TestUnitProduct_GetProducts(t *testing.T) {
 				repo:                  tt.fields.repo,
 				validationProductType: tt.fields.validationProductType,
 				validationSeller:      tt.fields.validationSeller,
+				uow:                   newMockUnitOfWork(tt.fields.repo),
 			}
 			// Mock the GetAll method
 			if tt.wantErr {
@@ -121,7 +198,7 @@ func TestUnitProduct_GetProducts(t *testing.T) {
 				(tt.fields.repo.(*mockProductRepository)).On("GetAll").Return(tt.wantListProducts, nil)
 			}
 
-			gotListProducts, err := s.GetProducts()
+			gotListProducts, err := s.GetProducts(context.Background())
 			if (err != nil) != tt.wantErr {
 				require.Error(t, err)
 			}
@@ -195,6 +272,7 @@ func TestUnitProduct_GetProductByID(t *testing.T) {
 				repo:                  tt.fields.repo,
 				validationProductType: tt.fields.validationProductType,
 				validationSeller:      tt.fields.validationSeller,
+				uow:                   newMockUnitOfWork(tt.fields.repo),
 			}
 			// Mock the GetByID method
 			if tt.wantErr {
@@ -203,7 +281,7 @@ func TestUnitProduct_GetProductByID(t *testing.T) {
 				(tt.fields.repo.(*mockProductRepository)).On("GetByID", tt.args.id).Return(tt.want, nil)
 			}
 
-			gotProduct, err := s.GetProductByID(tt.args.id)
+			gotProduct, err := s.GetProductByID(context.Background(), tt.args.id)
 			if tt.wantErr {
 				require.Error(t, err)
 			}
@@ -669,6 +747,7 @@ func TestUnitProduct_CreateProduct(t *testing.T) {
 				repo:                  tt.fields.repo,
 				validationProductType: tt.fields.validationProductType,
 				validationSeller:      tt.fields.validationSeller,
+				uow:                   newMockUnitOfWork(tt.fields.repo),
 			}
 			// Mock the Create method
 			if tt.wantErr {
@@ -708,7 +787,7 @@ func TestUnitProduct_CreateProduct(t *testing.T) {
 				},
 			}, nil)
 
-			got, err := s.CreateProduct(tt.args.newProduct)
+			got, err := s.CreateProduct(context.Background(), tt.args.newProduct)
 			require.Equal(t, tt.expectedErr, err)
 			require.Equal(t, tt.want, got)
 		})
@@ -863,6 +942,7 @@ func TestUnitProduct_UpdateProduct(t *testing.T) {
 				repo:                  tt.fields.repo,
 				validationProductType: tt.fields.validationProductType,
 				validationSeller:      tt.fields.validationSeller,
+				uow:                   newMockUnitOfWork(tt.fields.repo),
 			}
 			// Mock the GetByID method
 			if tt.wantErr {
@@ -900,7 +980,7 @@ func TestUnitProduct_UpdateProduct(t *testing.T) {
 				},
 			}, nil)
 
-			got, err := s.UpdateProduct(tt.args.inputProduct)
+			got, err := s.UpdateProduct(context.Background(), tt.args.inputProduct)
 			require.Equal(t, tt.want, got)
 			require.Equal(t, tt.wantErr, err != nil)
 		})
@@ -914,7 +994,8 @@ func TestUnitProduct_DeleteProduct(t *testing.T) {
 		validationSeller      internal.SellerValidation
 	}
 	type args struct {
-		id int
+		id      int
+		version int
 	}
 	test := []struct {
 		name        string
@@ -931,11 +1012,40 @@ func TestUnitProduct_DeleteProduct(t *testing.T) {
 				},
 			},
 			args: args{
-				id: 1,
+				id:      1,
+				version: 3,
+			},
+			wantErr:     false,
+			expectedErr: nil,
+		},
+		{
+			name: "DeleteProduct OK - Any Version",
+			fields: fields{
+				repo: &mockProductRepository{
+					mock.Mock{},
+				},
+			},
+			args: args{
+				id:      1,
+				version: utils.AnyVersion,
 			},
 			wantErr:     false,
 			expectedErr: nil,
 		},
+		{
+			name: "DeleteProduct Error - Product Changed",
+			fields: fields{
+				repo: &mockProductRepository{
+					mock.Mock{},
+				},
+			},
+			args: args{
+				id:      1,
+				version: 2,
+			},
+			wantErr:     true,
+			expectedErr: utils.EPreconditionFailed("product"),
+		},
 		{
 			name: "DeleteProduct Error - Product Not Found",
 			fields: fields{
@@ -957,30 +1067,280 @@ func TestUnitProduct_DeleteProduct(t *testing.T) {
 				repo:                  tt.fields.repo,
 				validationProductType: tt.fields.validationProductType,
 				validationSeller:      tt.fields.validationSeller,
+				uow:                   newMockUnitOfWork(tt.fields.repo),
 			}
-			// Mock the Delete method
-			if tt.wantErr {
-				(tt.fields.repo.(*mockProductRepository)).On("Delete", tt.args.id).Return(utils.ErrNotFound)
+			// Mock the GetByID and Delete methods
+			if errors.Is(tt.expectedErr, utils.ErrNotFound) {
+				(tt.fields.repo.(*mockProductRepository)).On("GetByID", tt.args.id).Return(internal.Product{}, utils.ErrNotFound)
 			} else {
-				(tt.fields.repo.(*mockProductRepository)).On("Delete", tt.args.id).Return(nil)
+				(tt.fields.repo.(*mockProductRepository)).On("GetByID", tt.args.id).Return(internal.Product{ID: tt.args.id, Version: 3}, nil)
+				(tt.fields.repo.(*mockProductRepository)).On("Delete", tt.args.id, 3).Return(nil)
 			}
 
-			err := s.DeleteProduct(tt.args.id)
+			err := s.DeleteProduct(context.Background(), tt.args.id, tt.args.version)
 			require.Equal(t, tt.expectedErr, err)
 		})
 	}
 }
 
+func TestUnitProduct_SellerScope(t *testing.T) {
+	seller := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 5, Role: internal.RoleSeller, SellerID: 1})
+	newProduct := internal.ProductAttributes{
+		ProductCode:                    "1234",
+		Description:                    "Product 2",
+		Width:                          1.0,
+		Height:                         1.0,
+		Length:                         1.0,
+		NetWeight:                      1.0,
+		ExpirationRate:                 1.0,
+		RecommendedFreezingTemperature: 1.0,
+		FreezingRate:                   1.0,
+		ProductType:                    1,
+	}
+
+	t.Run("a seller creates its own products", func(t *testing.T) {
+		repo := &mockProductRepository{}
+		productTypeValidation := &mockProductTypeValidation{}
+		sellerValidation := &mockSellerValidation{}
+
+		ownProduct := newProduct
+		ownProduct.SellerID = 1
+
+		productTypeValidation.On("GetProductTypeByID", 1).Return(internal.ProductType{ID: 1}, nil)
+		sellerValidation.On("GetByID", 1).Return(internal.Seller{ID: 1}, nil)
+		repo.On("GetAll").Return([]internal.Product{}, nil)
+		repo.On("Create", ownProduct).Return(internal.Product{ID: 2, ProductAttributes: ownProduct}, nil)
+
+		product, err := NewProductService(repo, productTypeValidation, sellerValidation, newMockUnitOfWork(repo)).CreateProduct(seller, newProduct)
+		require.NoError(t, err)
+		require.Equal(t, 1, product.SellerID)
+	})
+
+	t.Run("a seller cannot create the products of another seller", func(t *testing.T) {
+		repo := &mockProductRepository{}
+
+		otherProduct := newProduct
+		otherProduct.SellerID = 2
+
+		_, err := NewProductService(repo, &mockProductTypeValidation{}, &mockSellerValidation{}, newMockUnitOfWork(repo)).CreateProduct(seller, otherProduct)
+		require.ErrorIs(t, err, utils.ErrForbidden)
+		repo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("a seller cannot give its products to another seller", func(t *testing.T) {
+		repo := &mockProductRepository{}
+		repo.On("GetByID", 2).Return(internal.Product{ID: 2, ProductAttributes: internal.ProductAttributes{SellerID: 1}, Version: 1}, nil)
+
+		_, err := NewProductService(repo, nil, nil, newMockUnitOfWork(repo)).UpdateProduct(seller, internal.Product{ID: 2, ProductAttributes: internal.ProductAttributes{SellerID: 2}, Version: 1})
+		require.ErrorIs(t, err, utils.ErrForbidden)
+		repo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
 func TestNewProductService(t *testing.T) {
 	repo := new(mockProductRepository)
 	productTypeValidation := new(mockProductTypeValidation)
 	sellerValidation := new(mockSellerValidation)
 
-	service := NewProductService(repo, productTypeValidation, sellerValidation)
+	uow := newMockUnitOfWork(repo)
+
+	service := NewProductService(repo, productTypeValidation, sellerValidation, uow)
 	expectedService := &BasicProductService{
 		repo:                  repo,
 		validationProductType: productTypeValidation,
 		validationSeller:      sellerValidation,
+		uow:                   uow,
 	}
 	require.Equal(t, expectedService, service)
 }
+
+func TestUnitProduct_SearchProducts(t *testing.T) {
+	minWidth, maxWidth := 10.0, 5.0
+
+	tests := []struct {
+		name       string
+		filter     internal.ProductSearchFilter
+		repoFilter internal.ProductSearchFilter
+		callsRepo  bool
+		repoErr    error
+		wantResult internal.ProductSearchResult
+		wantErr    error
+	}{
+		{
+			name:       "SearchProducts OK with default paging",
+			filter:     internal.ProductSearchFilter{Text: "apple", SortBy: "description"},
+			repoFilter: internal.ProductSearchFilter{Text: "apple", SortBy: "description", Page: 1, PageSize: DefaultPageSize},
+			callsRepo:  true,
+			wantResult: internal.ProductSearchResult{
+				Data:     []internal.Product{{ID: 1}},
+				Page:     1,
+				PageSize: DefaultPageSize,
+				Total:    1,
+			},
+		},
+		{
+			name:    "SearchProducts invalid sort field",
+			filter:  internal.ProductSearchFilter{SortBy: "unknown"},
+			wantErr: utils.ErrInvalidArguments,
+		},
+		{
+			name:    "SearchProducts min greater than max",
+			filter:  internal.ProductSearchFilter{Width: internal.ProductRange{Min: &minWidth, Max: &maxWidth}},
+			wantErr: utils.ErrInvalidArguments,
+		},
+		{
+			name:    "SearchProducts page size too large",
+			filter:  internal.ProductSearchFilter{PageSize: MaxPageSize + 1},
+			wantErr: utils.ErrInvalidArguments,
+		},
+		{
+			name:       "SearchProducts repository error",
+			filter:     internal.ProductSearchFilter{Page: 2, PageSize: 5},
+			repoFilter: internal.ProductSearchFilter{Page: 2, PageSize: 5},
+			callsRepo:  true,
+			repoErr:    utils.ErrNotFound,
+			wantErr:    utils.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{}
+			if tt.callsRepo {
+				repo.On("Search", tt.repoFilter).Return(tt.wantResult.Data, tt.wantResult.Total, tt.repoErr)
+			}
+
+			s := &BasicProductService{repo: repo}
+
+			gotResult, err := s.SearchProducts(context.Background(), tt.filter)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				if !tt.callsRepo {
+					repo.AssertNotCalled(t, "Search", mock.Anything)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantResult, gotResult)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnitProduct_GetProductByBarcode(t *testing.T) {
+	product := internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{Barcode: "07791234000012"}}
+
+	tests := []struct {
+		name        string
+		barcode     string
+		wantProduct internal.Product
+		wantErr     error
+	}{
+		{name: "GetProductByBarcode EAN-13", barcode: "7791234000012", wantProduct: product},
+		{name: "GetProductByBarcode GS1-128", barcode: "(01)07791234000012(10)100", wantProduct: product},
+		{name: "GetProductByBarcode invalid", barcode: "7791234000013", wantErr: utils.ErrInvalidFormat},
+		{name: "GetProductByBarcode without GTIN", barcode: "(10)100", wantErr: utils.ErrInvalidArguments},
+		{name: "GetProductByBarcode not found", barcode: "7791234000029", wantErr: utils.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{}
+			repo.On("GetByBarcode", "07791234000012").Return(product, nil)
+			repo.On("GetByBarcode", mock.Anything).Return(internal.Product{}, utils.ErrNotFound)
+
+			s := &BasicProductService{repo: repo}
+
+			gotProduct, err := s.GetProductByBarcode(context.Background(), tt.barcode)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantProduct, gotProduct)
+		})
+	}
+}
+
+func TestUnitProduct_ImportProducts(t *testing.T) {
+	newProduct := func(code, barcode string) internal.ProductAttributes {
+		return internal.ProductAttributes{
+			ProductCode:                    code,
+			Description:                    "Product " + code,
+			Width:                          1.0,
+			Height:                         1.0,
+			Length:                         1.0,
+			NetWeight:                      1.0,
+			ExpirationRate:                 1.0,
+			RecommendedFreezingTemperature: 1.0,
+			FreezingRate:                   1.0,
+			ProductType:                    1,
+			SellerID:                       1,
+			Barcode:                        barcode,
+		}
+	}
+
+	items := []utils.ImportItem[internal.ProductAttributes]{
+		{Row: 2, Value: newProduct("P1", "7791234000012")},
+		{Row: 3, Value: newProduct("P2", "")},
+		{Row: 4, Value: newProduct("123", "")},
+		{Row: 5, Value: newProduct("P1", "")},
+		{Row: 6, Value: newProduct("P3", "07791234000012")},
+		{Row: 7, Value: newProduct("P4", "7791234000013")},
+	}
+
+	newService := func() (*BasicProductService, *mockProductRepository) {
+		repo := &mockProductRepository{}
+		repo.On("GetAll").Return([]internal.Product{{ID: 1, ProductAttributes: newProduct("123", "")}}, nil)
+
+		validationProductType := &mockProductTypeValidation{}
+		validationProductType.On("GetProductTypeByID", 1).Return(internal.ProductType{ID: 1}, nil)
+
+		validationSeller := &mockSellerValidation{}
+		validationSeller.On("GetByID", 1).Return(internal.Seller{ID: 1}, nil)
+
+		return NewProductService(repo, validationProductType, validationSeller, newMockUnitOfWork(repo)), repo
+	}
+
+	t.Run("ImportProducts creates the valid rows", func(t *testing.T) {
+		s, repo := newService()
+		repo.On("Create", newProduct("P1", "07791234000012")).Return(internal.Product{ID: 2}, nil)
+		repo.On("Create", newProduct("P2", "")).Return(internal.Product{ID: 3}, nil)
+
+		report, err := s.ImportProducts(context.Background(), items, utils.ImportOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Created)
+		require.Equal(t, 4, report.Failed)
+		require.Equal(t, 2, report.Rows[0].ID)
+		require.Equal(t, 3, report.Rows[1].ID)
+		require.Contains(t, report.Rows[2].Error, "ProductCode")
+		require.Contains(t, report.Rows[3].Error, "ProductCode")
+		require.Contains(t, report.Rows[4].Error, "Barcode")
+		require.Contains(t, report.Rows[5].Error, "check digit")
+
+		entries := s.uow.(*mockUnitOfWork).entries
+		require.Len(t, entries, 2)
+		require.Equal(t, internal.AuditImport, entries[0].Action)
+		require.Equal(t, "system", entries[0].Actor)
+		require.Equal(t, 3, entries[1].EntityID)
+	})
+
+	t.Run("ImportProducts atomic", func(t *testing.T) {
+		s, repo := newService()
+		repo.On("CreateAll", []internal.ProductAttributes{newProduct("P1", "07791234000012"), newProduct("P2", "")}).
+			Return([]internal.Product{{ID: 2}, {ID: 3}}, nil)
+
+		report, err := s.ImportProducts(context.Background(), items[:2], utils.ImportOptions{Atomic: true})
+		require.NoError(t, err)
+		require.True(t, report.Committed)
+		require.Equal(t, 3, report.Rows[1].ID)
+
+		report, err = s.ImportProducts(context.Background(), items, utils.ImportOptions{Atomic: true})
+		require.NoError(t, err)
+		require.False(t, report.Committed)
+		require.Equal(t, utils.ImportSkipped, report.Rows[0].Status)
+		repo.AssertNumberOfCalls(t, "CreateAll", 1)
+	})
+}
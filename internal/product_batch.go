@@ -1,5 +1,7 @@
 package internal
 
+import "context"
+
 type ProductBatch struct {
 	ID int `json:"id"`
 	ProductBatchRequest
@@ -16,13 +18,20 @@ type ProductBatchRequest struct {
 	MinimumTemperature float64 `json:"minimum_temperature"`
 	ProductID          int     `json:"product_id"`
 	SectionID          int     `json:"section_id"`
+	// PackagingUnitID is the packaging unit the quantities are expressed in, they are stored in base units
+	PackagingUnitID int `json:"packaging_unit_id,omitempty"`
+	// Barcode is the GS1-128 label of the batch, stored in its canonical human readable form
+	Barcode string `json:"barcode,omitempty"`
 }
 type (
 	ProductBatchRepository interface {
-		Save(*ProductBatchRequest) (ProductBatch, error)
-		GetBatchNumber(int) (int, error)
+		Save(context.Context, *ProductBatchRequest) (ProductBatch, error)
+		GetBatchNumber(context.Context, int) (int, error)
+		FindByBarcode(ctx context.Context, barcode string) (ProductBatch, error)
+		FindByProductAndNumber(ctx context.Context, productID, batchNumber int) (ProductBatch, error)
 	}
 	ProductBatchService interface {
-		Save(*ProductBatchRequest) (ProductBatch, error)
+		Save(context.Context, *ProductBatchRequest) (ProductBatch, error)
+		Lookup(ctx context.Context, barcode string) (ProductBatch, error)
 	}
 )
@@ -1,6 +1,7 @@
 package product_batch
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/go-sql-driver/mysql"
@@ -8,18 +9,21 @@ import (
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+var selectProductBatches = "SELECT id, batch_number, current_quantity, current_temperature, " + utils.DateTime("due_date") + ", initial_quantity, " +
+	utils.DateTime("manufacturing_date") + ", manufacturing_hour, minimum_temperature, product_id, section_id, COALESCE(barcode, '') FROM product_batches"
+
 type MySQLProductBatchRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewProductBatchRepository(db *sql.DB) internal.ProductBatchRepository {
+func NewProductBatchRepository(db utils.DBTX) internal.ProductBatchRepository {
 	return &MySQLProductBatchRepository{db: db}
 }
 
 // Save Get all the sections and return in asc order
-func (r *MySQLProductBatchRepository) Save(newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
-	result, err := r.db.Exec("INSERT INTO product_batches (batch_number, current_quantity, current_temperature, due_date, initial_quantity, manufacturing_date, manufacturing_hour, minimum_temperature, product_id, section_id) VALUES (?,?,?,?,?,?,?,?,?,?)",
-		(*newBatch).BatchNumber, (*newBatch).CurrentQuantity, (*newBatch).CurrentTemperature, (*newBatch).DueDate, (*newBatch).InitialQuantity, (*newBatch).ManufacturingDate, (*newBatch).ManufacturingHour, (*newBatch).MinimumTemperature, (*newBatch).ProductID, (*newBatch).SectionID,
+func (r *MySQLProductBatchRepository) Save(ctx context.Context, newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO product_batches (batch_number, current_quantity, current_temperature, due_date, initial_quantity, manufacturing_date, manufacturing_hour, minimum_temperature, product_id, section_id, barcode) VALUES (?,?,?,?,?,?,?,?,?,?,NULLIF(?, ''))",
+		(*newBatch).BatchNumber, (*newBatch).CurrentQuantity, (*newBatch).CurrentTemperature, (*newBatch).DueDate, (*newBatch).InitialQuantity, (*newBatch).ManufacturingDate, (*newBatch).ManufacturingHour, (*newBatch).MinimumTemperature, (*newBatch).ProductID, (*newBatch).SectionID, (*newBatch).Barcode,
 	)
 	if err != nil {
 		var mySQLError *mysql.MySQLError
@@ -47,10 +51,10 @@ func (r *MySQLProductBatchRepository) Save(newBatch *internal.ProductBatchReques
 	return createdBatch, nil
 }
 
-func (r *MySQLProductBatchRepository) GetBatchNumber(batchNumber int) (int, error) {
+func (r *MySQLProductBatchRepository) GetBatchNumber(ctx context.Context, batchNumber int) (int, error) {
 	var exists int
 
-	row := r.db.QueryRow("SELECT batch_number FROM product_batches WHERE batch_number=?", batchNumber)
+	row := r.db.QueryRowContext(ctx, "SELECT batch_number FROM product_batches WHERE batch_number=?", batchNumber)
 
 	err := row.Scan(&exists)
 
@@ -65,3 +69,29 @@ func (r *MySQLProductBatchRepository) GetBatchNumber(batchNumber int) (int, erro
 
 	return exists, nil
 }
+
+// FindByBarcode returns the batch labelled with the barcode
+func (r *MySQLProductBatchRepository) FindByBarcode(ctx context.Context, barcode string) (internal.ProductBatch, error) {
+	return r.findOne(ctx, selectProductBatches+" WHERE barcode = ?", barcode)
+}
+
+// FindByProductAndNumber returns the batch of a product with the batch number
+func (r *MySQLProductBatchRepository) FindByProductAndNumber(ctx context.Context, productID, batchNumber int) (internal.ProductBatch, error) {
+	return r.findOne(ctx, selectProductBatches+" WHERE product_id = ? AND batch_number = ?", productID, batchNumber)
+}
+
+func (r *MySQLProductBatchRepository) findOne(ctx context.Context, query string, args ...any) (internal.ProductBatch, error) {
+	var batch internal.ProductBatch
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&batch.ID, &batch.BatchNumber, &batch.CurrentQuantity, &batch.CurrentTemperature, &batch.DueDate,
+		&batch.InitialQuantity, &batch.ManufacturingDate, &batch.ManufacturingHour, &batch.MinimumTemperature, &batch.ProductID, &batch.SectionID, &batch.Barcode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.ProductBatch{}, utils.ErrNotFound
+		}
+
+		return internal.ProductBatch{}, err
+	}
+
+	return batch, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -16,7 +17,8 @@ func ProductBatchRoutes(mux *chi.Mux, service internal.ProductBatchService) erro
 	batchHandler := handler.NewProductBatchHandler(service)
 
 	mux.Route("/api/v1/productBatches", func(router chi.Router) {
-		router.Post("/", batchHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductBatches, rbac.ActionWrite))).Post("/", batchHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductBatches, rbac.ActionRead))).Get("/lookup", batchHandler.Lookup())
 	})
 
 	return nil
@@ -1,33 +1,87 @@
 package product_batch
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the product batches in the audit trail
+const auditEntity = "productBatches"
+
 type DefaultProductBatchService struct {
-	batchRepo   internal.ProductBatchRepository
-	productRepo internal.ProductRepository
-	sectionRepo internal.SectionRepository
+	batchRepo    internal.ProductBatchRepository
+	productRepo  internal.ProductRepository
+	sectionRepo  internal.SectionRepository
+	packaging    internal.PackagingUnitConversion
+	productTypes internal.ProductTypeValidation
+	// uow checks the section and saves the batch in a single transaction
+	uow internal.UnitOfWork
 }
 
 func NewProductBatchService(batch internal.ProductBatchRepository,
-	product internal.ProductRepository, section internal.SectionRepository) internal.ProductBatchService {
+	product internal.ProductRepository, section internal.SectionRepository,
+	packaging internal.PackagingUnitConversion, productTypes internal.ProductTypeValidation,
+	uow internal.UnitOfWork) internal.ProductBatchService {
 	return &DefaultProductBatchService{
-		batchRepo:   batch,
-		productRepo: product,
-		sectionRepo: section,
+		batchRepo:    batch,
+		productRepo:  product,
+		sectionRepo:  section,
+		packaging:    packaging,
+		productTypes: productTypes,
+		uow:          uow,
+	}
+}
+
+// Save checks the batch against its section and saves it along with its audit entry, the section and
+// batch repositories are bound to a single transaction so the batch is saved on the section as it was checked
+func (s *DefaultProductBatchService) Save(ctx context.Context, newBatch *internal.ProductBatchRequest) (createdBatch internal.ProductBatch, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		tx := *s
+		tx.batchRepo, tx.sectionRepo = repos.ProductBatches, repos.Sections
+
+		createdBatch, err = tx.save(ctx, newBatch)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, createdBatch.ID, nil, createdBatch)
+	})
+	if err != nil {
+		return internal.ProductBatch{}, err
 	}
+
+	return createdBatch, nil
 }
 
-func (s *DefaultProductBatchService) Save(newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
-	batchValidation := s.verify(newBatch)
+func (s *DefaultProductBatchService) save(ctx context.Context, newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
+	section, product, batchValidation := s.verify(ctx, newBatch)
 
 	if batchValidation != nil {
 		return internal.ProductBatch{}, batchValidation
 	}
 
-	createdBatch, err := s.batchRepo.Save(newBatch)
+	err := s.checkProductTypeRules(ctx, section, product)
+	if err != nil {
+		return internal.ProductBatch{}, err
+	}
+
+	err = s.toBaseUnits(ctx, newBatch)
+	if err != nil {
+		return internal.ProductBatch{}, err
+	}
+
+	err = s.checkSectionCapacity(ctx, section, product, newBatch)
+	if err != nil {
+		return internal.ProductBatch{}, err
+	}
+
+	createdBatch, err := s.batchRepo.Save(ctx, newBatch)
 	if err != nil {
 		return internal.ProductBatch{}, err
 	}
@@ -35,69 +89,229 @@ func (s *DefaultProductBatchService) Save(newBatch *internal.ProductBatchRequest
 	return createdBatch, nil
 }
 
-func (s *DefaultProductBatchService) verify(newBatch *internal.ProductBatchRequest) error {
+// toBaseUnits converts the batch quantities from its packaging unit to the product base unit
+func (s *DefaultProductBatchService) toBaseUnits(ctx context.Context, newBatch *internal.ProductBatchRequest) error {
+	if newBatch.PackagingUnitID == internal.BasePackagingUnitID {
+		return nil
+	}
+
+	initialQuantity, err := s.packaging.ToBaseUnits(ctx, newBatch.ProductID, newBatch.PackagingUnitID, newBatch.InitialQuantity)
+	if err != nil {
+		return err
+	}
+
+	currentQuantity, err := s.packaging.ToBaseUnits(ctx, newBatch.ProductID, newBatch.PackagingUnitID, newBatch.CurrentQuantity)
+	if err != nil {
+		return err
+	}
+
+	newBatch.InitialQuantity = initialQuantity
+	newBatch.CurrentQuantity = currentQuantity
+	newBatch.PackagingUnitID = internal.BasePackagingUnitID
+
+	return nil
+}
+
+// checkProductTypeRules rejects the batch when the section temperature is out of the range of the
+// product type, or the product type is incompatible with the section one or the ones in stock
+func (s *DefaultProductBatchService) checkProductTypeRules(ctx context.Context, section internal.Section, product internal.Product) error {
+	productType, err := s.productTypes.GetProductTypeByID(ctx, product.ProductType)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return utils.EDependencyNotFound("product_type", "id: "+strconv.Itoa(product.ProductType))
+		}
+
+		return err
+	}
+
+	if !productType.AcceptsTemperature(section.CurrentTemperature) {
+		return utils.EBR("section temperature is out of the range of product type " + strconv.Itoa(productType.ID))
+	}
+
+	storedTypeIDs, err := s.sectionRepo.GetSectionProductTypesByID(ctx, section.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range append([]int{section.ProductTypeID}, storedTypeIDs...) {
+		if !productType.CompatibleWith(id) {
+			return utils.EBR("product type " + strconv.Itoa(productType.ID) + " cannot share a section with product type " + strconv.Itoa(id))
+		}
+	}
+
+	return nil
+}
+
+// checkSectionCapacity rejects the batch when the section has a volume or weight limit the batch,
+// in base units, would exceed
+func (s *DefaultProductBatchService) checkSectionCapacity(ctx context.Context, section internal.Section, product internal.Product, newBatch *internal.ProductBatchRequest) error {
+	if section.MaximumVolume == nil && section.MaximumWeight == nil {
+		return nil
+	}
+
+	report, err := s.sectionRepo.GetSectionCapacityReportByID(ctx, section.ID)
+	if err != nil {
+		return err
+	}
+
+	quantity := float64(newBatch.CurrentQuantity)
+	if !report.Fits(product.Volume()*quantity, product.NetWeight*quantity) {
+		return utils.EBR("section doesn't have enough volume or weight left for the batch")
+	}
+
+	return nil
+}
+
+func (s *DefaultProductBatchService) verify(ctx context.Context, newBatch *internal.ProductBatchRequest) (internal.Section, internal.Product, error) {
 	if newBatch.BatchNumber <= 0 {
-		return utils.EZeroValue("Batch number")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Batch number")
 	}
 
 	if newBatch.CurrentQuantity < 0 {
-		return utils.EZeroValue("Current quantity")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Current quantity")
 	}
 
 	if newBatch.CurrentTemperature <= 0.0 {
-		return utils.EZeroValue("Current temperature")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Current temperature")
 	}
 
 	if len(newBatch.DueDate) == 0 {
-		return utils.EZeroValue("Due date")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Due date")
 	}
 
 	if newBatch.InitialQuantity < 0 {
-		return utils.EZeroValue("Initial quantity")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Initial quantity")
 	}
 
 	if len(newBatch.ManufacturingDate) == 0 {
-		return utils.EZeroValue("Manufacturing date")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Manufacturing date")
 	}
 
 	if newBatch.ManufacturingHour < 0 {
-		return utils.EZeroValue("Manufactoring hour")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Manufactoring hour")
 	}
 	//MinimumTemperature não validada porque pode ser positiva, negativa ou zero
 	if newBatch.ProductID <= 0 {
-		return utils.EZeroValue("Product ID")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Product ID")
 	}
 
 	if newBatch.SectionID <= 0 {
-		return utils.EZeroValue("Section ID")
+		return internal.Section{}, internal.Product{}, utils.EZeroValue("Section ID")
 	}
 
-	batchExists, err := s.batchRepo.GetBatchNumber(newBatch.BatchNumber)
+	batchExists, err := s.batchRepo.GetBatchNumber(ctx, newBatch.BatchNumber)
 	if err != nil {
-		return err
+		return internal.Section{}, internal.Product{}, err
 	}
 
 	if batchExists != 0 {
-		return utils.EConflict("batch number", "Product batch")
+		return internal.Section{}, internal.Product{}, utils.EConflict("batch number", "Product batch")
 	}
 
-	sectionExists, err := s.sectionRepo.GetByID(newBatch.SectionID)
+	// the section stays locked until the batch is saved, the batches saved on it meanwhile wait
+	sectionExists, err := s.sectionRepo.GetByIDForUpdate(ctx, newBatch.SectionID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Section{}, internal.Product{}, utils.ENotFound("Section ID")
+		}
 
-	if sectionExists == (internal.Section{}) {
-		return utils.ENotFound("Section ID")
+		return internal.Section{}, internal.Product{}, err
 	}
 
+	productExists, err := s.productRepo.GetByID(ctx, newBatch.ProductID)
 	if err != nil {
-		return err
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Section{}, internal.Product{}, utils.ENotFound("Product ID")
+		}
+
+		return internal.Section{}, internal.Product{}, err
 	}
 
-	productExists, err := s.productRepo.GetByID(newBatch.ProductID)
-	if productExists == (internal.Product{}) {
-		return utils.ENotFound("Product ID")
+	if newBatch.Barcode != "" {
+		label, err := utils.ParseGS1(newBatch.Barcode)
+		if err != nil {
+			return internal.Section{}, internal.Product{}, err
+		}
+
+		err = matchLabel(label, newBatch, productExists)
+		if err != nil {
+			return internal.Section{}, internal.Product{}, err
+		}
+
+		newBatch.Barcode = label.String()
 	}
 
+	return sectionExists, productExists, nil
+}
+
+// Lookup returns the batch a scanned GS1-128 label refers to, either the batch labelled with it
+// or the batch of the GTIN (01) product with the batch number (10)
+func (s *DefaultProductBatchService) Lookup(ctx context.Context, barcode string) (internal.ProductBatch, error) {
+	label, err := utils.ParseGS1(barcode)
 	if err != nil {
-		return err
+		return internal.ProductBatch{}, err
+	}
+
+	batch, err := s.batchRepo.FindByBarcode(ctx, label.String())
+	if err == nil {
+		return batch, nil
+	}
+
+	if !errors.Is(err, utils.ErrNotFound) {
+		return internal.ProductBatch{}, err
+	}
+
+	if label.GTIN == "" || label.BatchNumber == "" {
+		return internal.ProductBatch{}, utils.EBR("barcode must carry a GTIN (01) and a batch number (10)")
+	}
+
+	product, err := s.productRepo.GetByBarcode(ctx, label.GTIN)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.ProductBatch{}, utils.ENotFound("Product")
+		}
+
+		return internal.ProductBatch{}, err
+	}
+
+	batchNumber, err := strconv.Atoi(label.BatchNumber)
+	if err != nil {
+		return internal.ProductBatch{}, utils.ENotFound("Product batch")
+	}
+
+	batch, err = s.batchRepo.FindByProductAndNumber(ctx, product.ID, batchNumber)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.ProductBatch{}, utils.ENotFound("Product batch")
+		}
+
+		return internal.ProductBatch{}, err
+	}
+
+	err = matchLabel(label, &batch.ProductBatchRequest, product)
+	if err != nil {
+		return internal.ProductBatch{}, err
+	}
+
+	return batch, nil
+}
+
+// matchLabel checks the data carried by a label agrees with the batch and its product, the batch
+// number of the label is compared as a number so a zero padded one, as 0042, matches batch 42
+func matchLabel(label utils.GS1Label, batch *internal.ProductBatchRequest, product internal.Product) error {
+	if label.GTIN != "" && label.GTIN != product.Barcode {
+		return utils.EBR("barcode GTIN does not match the product")
+	}
+
+	if label.BatchNumber != "" {
+		batchNumber, err := strconv.Atoi(label.BatchNumber)
+		if err != nil || batchNumber != batch.BatchNumber {
+			return utils.EBR("barcode batch number does not match the batch")
+		}
+	}
+
+	if label.ExpiryDate != "" && !strings.HasPrefix(batch.DueDate, label.ExpiryDate) {
+		return utils.EBR("barcode expiry date does not match the batch due date")
 	}
 
 	return nil
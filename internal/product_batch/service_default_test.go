@@ -1,7 +1,9 @@
 package product_batch
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
@@ -12,95 +14,242 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repositories, counting the committed and rolled back ones
+// and keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos      internal.TxRepositories
+	entries    []internal.AuditEntry
+	committed  int
+	rolledBack int
+}
+
+func newMockUnitOfWork(batchRepo internal.ProductBatchRepository, sectionRepo internal.SectionRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{ProductBatches: batchRepo, Sections: sectionRepo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+		u.rolledBack++
+		return err
+	}
+
+	u.committed++
+
+	return nil
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockProductBatchRepository struct {
 	mock.Mock
 }
 
-func (mpb *MockProductBatchRepository) Save(newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
+func (mpb *MockProductBatchRepository) Save(ctx context.Context, newBatch *internal.ProductBatchRequest) (internal.ProductBatch, error) {
 	args := mpb.Called(newBatch)
 	return args.Get(0).(internal.ProductBatch), args.Error(1)
 }
 
-func (mpb *MockProductBatchRepository) GetBatchNumber(batchNumber int) (int, error) {
+func (mpb *MockProductBatchRepository) GetBatchNumber(ctx context.Context, batchNumber int) (int, error) {
 	args := mpb.Called(batchNumber)
 	return args.Int(0), args.Error(1)
 }
 
+func (mpb *MockProductBatchRepository) FindByBarcode(ctx context.Context, barcode string) (internal.ProductBatch, error) {
+	args := mpb.Called(barcode)
+	return args.Get(0).(internal.ProductBatch), args.Error(1)
+}
+
+func (mpb *MockProductBatchRepository) FindByProductAndNumber(ctx context.Context, productID, batchNumber int) (internal.ProductBatch, error) {
+	args := mpb.Called(productID, batchNumber)
+	return args.Get(0).(internal.ProductBatch), args.Error(1)
+}
+
 // Mock of ProductRepository
 type MockProductRepository struct {
 	mock.Mock
 }
 
-func (mp *MockProductRepository) GetAll() (listProducts []internal.Product, err error) {
+func (mp *MockProductRepository) GetAll(ctx context.Context) (listProducts []internal.Product, err error) {
 	args := mp.Called()
 	return args.Get(0).([]internal.Product), args.Error(1)
 }
 
-func (mp *MockProductRepository) Create(newproduct internal.ProductAttributes) (product internal.Product, err error) {
+func (mp *MockProductRepository) List(ctx context.Context, query utils.ListQuery) (listProducts []internal.Product, err error) {
+	args := mp.Called(query)
+	return args.Get(0).([]internal.Product), args.Error(1)
+}
+
+func (mp *MockProductRepository) Create(ctx context.Context, newproduct internal.ProductAttributes) (product internal.Product, err error) {
 	args := mp.Called(newproduct)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (mp *MockProductRepository) Update(inputProduct internal.Product) (product internal.Product, err error) {
+func (mp *MockProductRepository) CreateAll(ctx context.Context, newProducts []internal.ProductAttributes) (listProducts []internal.Product, err error) {
+	args := mp.Called(newProducts)
+	return args.Get(0).([]internal.Product), args.Error(1)
+}
+
+func (mp *MockProductRepository) Update(ctx context.Context, inputProduct internal.Product) (product internal.Product, err error) {
 	args := mp.Called(inputProduct)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
-func (mp *MockProductRepository) Delete(id int) (err error) {
+func (mp *MockProductRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := mp.Called(id, version)
+	return args.Error(0)
+}
+
+func (mp *MockProductRepository) Restore(ctx context.Context, id int) (err error) {
 	args := mp.Called(id)
 	return args.Error(0)
 }
 
-func (mp *MockProductRepository) GetByID(id int) (internal.Product, error) {
+func (mp *MockProductRepository) Purge(ctx context.Context, before time.Time) (purged int, err error) {
+	args := mp.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (mp *MockProductRepository) GetByID(ctx context.Context, id int) (internal.Product, error) {
 	args := mp.Called(id)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
 
+func (mp *MockProductRepository) GetByBarcode(ctx context.Context, gtin string) (internal.Product, error) {
+	args := mp.Called(gtin)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+func (mp *MockProductRepository) Search(ctx context.Context, filter internal.ProductSearchFilter) (listProducts []internal.Product, total int, err error) {
+	args := mp.Called(filter)
+	return args.Get(0).([]internal.Product), args.Int(1), args.Error(2)
+}
+
+// Mock of PackagingUnitConversion
+type MockPackagingUnitConversion struct {
+	mock.Mock
+}
+
+func (mc *MockPackagingUnitConversion) ToBaseUnits(ctx context.Context, productID, packagingUnitID, quantity int) (int, error) {
+	args := mc.Called(productID, packagingUnitID, quantity)
+	return args.Int(0), args.Error(1)
+}
+
 // Mock of SectionRepository
 type MockSectionRepository struct {
 	mock.Mock
 }
 
-func (ms *MockSectionRepository) GetAll() ([]internal.Section, error) {
+func (ms *MockSectionRepository) GetAll(ctx context.Context) ([]internal.Section, error) {
 	args := ms.Called()
 	return args.Get(0).([]internal.Section), args.Error(1)
 }
 
-func (ms *MockSectionRepository) Save(section *internal.Section) error {
+func (ms *MockSectionRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Section, error) {
+	args := ms.Called(query)
+	return args.Get(0).([]internal.Section), args.Error(1)
+}
+
+func (ms *MockSectionRepository) Save(ctx context.Context, section *internal.Section) error {
 	args := ms.Called(section)
 	return args.Error(0)
 }
 
-func (ms *MockSectionRepository) Update(section *internal.Section) error {
+func (ms *MockSectionRepository) Update(ctx context.Context, section *internal.Section) error {
 	args := ms.Called(section)
 	return args.Error(0)
 }
 
-func (ms *MockSectionRepository) GetBySectionNumber(id int) (internal.Section, error) {
+func (ms *MockSectionRepository) GetBySectionNumber(ctx context.Context, id int) (internal.Section, error) {
 	args := ms.Called(id)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (ms *MockSectionRepository) Delete(id int) error {
-	args := ms.Called(id)
+func (ms *MockSectionRepository) Delete(ctx context.Context, id int, version int) error {
+	args := ms.Called(id, version)
 	return args.Error(0)
 }
 
-func (ms *MockSectionRepository) GetSectionProductsReport() ([]internal.SectionProductsReport, error) {
+func (ms *MockSectionRepository) GetSectionProductsReport(ctx context.Context) ([]internal.SectionProductsReport, error) {
 	args := ms.Called()
 	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
 }
 
-func (ms *MockSectionRepository) GetSectionProductsReportByID(id int) ([]internal.SectionProductsReport, error) {
+func (ms *MockSectionRepository) GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]internal.SectionProductsReport, error) {
+	args := ms.Called(afterSectionID, limit)
+	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
+}
+
+func (ms *MockSectionRepository) GetSectionProductsReportByID(ctx context.Context, id int) ([]internal.SectionProductsReport, error) {
 	args := ms.Called(id)
 	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
 }
 
-func (ms *MockSectionRepository) GetByID(id int) (internal.Section, error) {
+func (ms *MockSectionRepository) GetSectionCapacityReport(ctx context.Context) ([]internal.SectionCapacityReport, error) {
+	args := ms.Called()
+	return args.Get(0).([]internal.SectionCapacityReport), args.Error(1)
+}
+
+func (ms *MockSectionRepository) GetSectionCapacityReportByID(ctx context.Context, id int) (internal.SectionCapacityReport, error) {
+	args := ms.Called(id)
+	return args.Get(0).(internal.SectionCapacityReport), args.Error(1)
+}
+
+func (ms *MockSectionRepository) GetSectionProductTypes(ctx context.Context) (map[int][]int, error) {
+	args := ms.Called()
+	return args.Get(0).(map[int][]int), args.Error(1)
+}
+
+func (ms *MockSectionRepository) GetSectionProductTypesByID(ctx context.Context, id int) ([]int, error) {
+	args := ms.Called(id)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (ms *MockSectionRepository) GetByID(ctx context.Context, id int) (internal.Section, error) {
 	args := ms.Called(id)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
+func (ms *MockSectionRepository) GetByIDForUpdate(ctx context.Context, id int) (internal.Section, error) {
+	args := ms.Called(id)
+	return args.Get(0).(internal.Section), args.Error(1)
+}
+
+type MockProductTypeValidation struct {
+	mock.Mock
+}
+
+func (m *MockProductTypeValidation) GetProductTypeByID(ctx context.Context, id int) (internal.ProductType, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.ProductType), args.Error(1)
+}
+
+func (m *MockProductTypeValidation) GetProductTypesByIDs(ctx context.Context, ids []int) ([]internal.ProductType, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.ProductType), args.Error(1)
+}
+
+// newMockProductTypeValidation returns product types without temperature range nor incompatibilities
+func newMockProductTypeValidation() *MockProductTypeValidation {
+	productTypes := new(MockProductTypeValidation)
+	productTypes.On("GetProductTypeByID", mock.Anything).Return(internal.ProductType{ID: 1}, nil)
+
+	return productTypes
+}
+
 func TestUnitProductBatch_Save_Success(t *testing.T) {
 	newBatch := internal.ProductBatchRequest{
 		BatchNumber:        100,
@@ -125,18 +274,64 @@ func TestUnitProductBatch_Save_Success(t *testing.T) {
 	sectionRepo := new(MockSectionRepository)
 
 	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
-	sectionRepo.On("GetByID", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
 	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1}, nil)
 	batchRepo.On("Save", mock.Anything).Return(batchCreated, nil)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	uow := newMockUnitOfWork(batchRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), uow)
 
 	expectedResult := batchCreated
-	result, err := service.Save(&newBatch)
+	result, err := service.Save(context.Background(), &newBatch)
 
 	require.NoError(t, err)
 	require.Equal(t, expectedResult, result)
+	require.Len(t, uow.entries, 1)
+	require.Equal(t, internal.AuditCreate, uow.entries[0].Action)
+	require.Equal(t, "productBatches", uow.entries[0].Entity)
+	require.Equal(t, 1, uow.entries[0].EntityID)
+}
+
+func TestUnitProductBatch_Save_UnitOfWork(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    50,
+		CurrentTemperature: 22.4,
+		DueDate:            "2022-01-01",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2022-01-01",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+	}
+
+	// The service repositories are not bound to the transaction, the batch is checked and saved
+	// with the ones of the unit of work
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+	txBatchRepo := new(MockProductBatchRepository)
+	txSectionRepo := new(MockSectionRepository)
+
+	txBatchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	txSectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	txSectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1}, nil)
+	txBatchRepo.On("Save", mock.Anything).Return(internal.ProductBatch{}, errors.New("connection lost"))
+
+	uow := newMockUnitOfWork(txBatchRepo, txSectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), uow)
 
+	result, err := service.Save(context.Background(), &newBatch)
+
+	require.EqualError(t, err, "connection lost")
+	require.Equal(t, internal.ProductBatch{}, result)
+	require.Equal(t, 1, uow.rolledBack)
+	require.Zero(t, uow.committed)
+	batchRepo.AssertNotCalled(t, "Save", mock.Anything)
+	sectionRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything)
 }
 
 func TestUnitProductBatch_Save_BatchNumberAlreadyExists(t *testing.T) {
@@ -159,9 +354,9 @@ func TestUnitProductBatch_Save_BatchNumberAlreadyExists(t *testing.T) {
 
 	batchRepo.On("GetBatchNumber", mock.Anything).Return(1, nil)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.EConflict("batch number", "Product batch"), err)
 
@@ -186,11 +381,11 @@ func TestUnitProductBatch_Save_SectionIdDoesNotExist(t *testing.T) {
 	sectionRepo := new(MockSectionRepository)
 
 	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
-	sectionRepo.On("GetByID", newBatch.SectionID).Return(internal.Section{}, utils.ENotFound("Section ID"))
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{}, utils.ENotFound("Section ID"))
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.ENotFound("Section ID"), err)
 
@@ -215,17 +410,67 @@ func TestUnitProductBatch_Save_ProductIdDoesNotExist(t *testing.T) {
 	sectionRepo := new(MockSectionRepository)
 
 	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
-	sectionRepo.On("GetByID", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
 	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{}, utils.ENotFound("Product ID"))
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.ENotFound("Product ID"), err)
 
 }
 
+func TestUnitProductBatch_Save_SectionOrProductNotRead(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    50,
+		CurrentTemperature: 22.4,
+		DueDate:            "2022-01-01",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2022-01-01",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+	}
+
+	t.Run("section lock wait timeout", func(t *testing.T) {
+		batchRepo := new(MockProductBatchRepository)
+		productRepo := new(MockProductRepository)
+		sectionRepo := new(MockSectionRepository)
+
+		batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+		sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{}, context.DeadlineExceeded)
+
+		service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+		_, err := service.Save(context.Background(), &newBatch)
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+		productRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+
+	t.Run("product read cancelled", func(t *testing.T) {
+		batchRepo := new(MockProductBatchRepository)
+		productRepo := new(MockProductRepository)
+		sectionRepo := new(MockSectionRepository)
+
+		batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+		sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+		productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{}, context.Canceled)
+
+		service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+		_, err := service.Save(context.Background(), &newBatch)
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, utils.ErrNotFound)
+	})
+}
+
 func TestUnitProductBatch_Save_InvalidOrEmptyCompanyName(t *testing.T) {
 	newBatch := internal.ProductBatchRequest{
 		BatchNumber:        100,
@@ -244,9 +489,9 @@ func TestUnitProductBatch_Save_InvalidOrEmptyCompanyName(t *testing.T) {
 	productRepo := new(MockProductRepository)
 	sectionRepo := new(MockSectionRepository)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.EZeroValue("Due date"), err)
 }
@@ -269,9 +514,9 @@ func TestUnitProductBatch_Save_InvalidOrEmptyBatchNumber(t *testing.T) {
 	productRepo := new(MockProductRepository)
 	sectionRepo := new(MockSectionRepository)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.EZeroValue("Batch number"), err)
 }
@@ -294,9 +539,9 @@ func TestUnitProductBatch_Save_InvalidOrEmptyProductID(t *testing.T) {
 	productRepo := new(MockProductRepository)
 	sectionRepo := new(MockSectionRepository)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.EZeroValue("Product ID"), err)
 }
@@ -319,9 +564,9 @@ func TestUnitProductBatch_Save_InvalidOrEmptySectionID(t *testing.T) {
 	productRepo := new(MockProductRepository)
 	sectionRepo := new(MockSectionRepository)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.Equal(t, utils.EZeroValue("Section ID"), err)
 }
@@ -352,14 +597,340 @@ func TestUnitProductBatch_InternalServerError(t *testing.T) {
 	internalErr := errors.New("internal server error")
 
 	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
-	sectionRepo.On("GetByID", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
 	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1}, nil)
 	batchRepo.On("Save", mock.Anything).Return(batchCreated, internalErr)
 
-	service := NewProductBatchService(batchRepo, productRepo, sectionRepo)
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
 
-	_, err := service.Save(&newBatch)
+	_, err := service.Save(context.Background(), &newBatch)
 
 	require.ErrorIs(t, err, internalErr)
 
 }
+
+func TestUnitProductBatch_Save_PackagingUnitQuantities(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    5,
+		CurrentTemperature: 22.4,
+		DueDate:            "2022-01-01",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2022-01-01",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+		PackagingUnitID:    2,
+	}
+
+	savedBatch := newBatch
+	savedBatch.InitialQuantity = 120
+	savedBatch.CurrentQuantity = 60
+	savedBatch.PackagingUnitID = internal.BasePackagingUnitID
+
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+	packaging := new(MockPackagingUnitConversion)
+
+	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1}, nil)
+	packaging.On("ToBaseUnits", 1, 2, 10).Return(120, nil)
+	packaging.On("ToBaseUnits", 1, 2, 5).Return(60, nil)
+	batchRepo.On("Save", &savedBatch).Return(internal.ProductBatch{ID: 1, ProductBatchRequest: savedBatch}, nil)
+
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, packaging, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+	result, err := service.Save(context.Background(), &newBatch)
+
+	require.NoError(t, err)
+	require.Equal(t, 120, result.InitialQuantity)
+	require.Equal(t, 60, result.CurrentQuantity)
+	batchRepo.AssertExpectations(t)
+}
+
+func TestUnitProductBatch_Save_PackagingUnitNotFound(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    5,
+		CurrentTemperature: 22.4,
+		DueDate:            "2022-01-01",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2022-01-01",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+		PackagingUnitID:    9,
+	}
+
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+	packaging := new(MockPackagingUnitConversion)
+
+	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1}, nil)
+	packaging.On("ToBaseUnits", 1, 9, 10).Return(0, utils.EDependencyNotFound("Packaging unit", "id"))
+
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, packaging, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+	_, err := service.Save(context.Background(), &newBatch)
+
+	require.Equal(t, utils.EDependencyNotFound("Packaging unit", "id"), err)
+	batchRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestUnitProductBatch_Save_SectionCapacityExceeded(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    50,
+		CurrentTemperature: 22.4,
+		DueDate:            "2022-01-01",
+		InitialQuantity:    50,
+		ManufacturingDate:  "2022-01-01",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+	}
+
+	maximumVolume := float64(10000)
+	product := internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{Width: 10, Height: 10, Length: 10, NetWeight: 1}}
+
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+
+	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1, MaximumVolume: &maximumVolume}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	sectionRepo.On("GetSectionCapacityReportByID", 1).Return(internal.SectionCapacityReport{SectionID: 1, MaximumVolume: &maximumVolume, UsedVolume: 6000}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(product, nil)
+
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+	_, err := service.Save(context.Background(), &newBatch)
+
+	require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	batchRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestUnitProductBatch_Save_ProductTypeRules(t *testing.T) {
+	minimum, maximum := float64(-2), float64(4)
+	meat := internal.ProductType{ID: 4, MinimumTemperature: &minimum, MaximumTemperature: &maximum, IncompatibleWith: []int{2}}
+
+	tests := []struct {
+		name          string
+		section       internal.Section
+		storedTypeIDs []int
+		wantMessage   string
+	}{
+		{
+			name:        "section out of the product type temperature range",
+			section:     internal.Section{ID: 1, ProductTypeID: 4, CurrentTemperature: 6},
+			wantMessage: "section temperature is out of the range of product type 4",
+		},
+		{
+			name:        "section of an incompatible product type",
+			section:     internal.Section{ID: 1, ProductTypeID: 2, CurrentTemperature: 2},
+			wantMessage: "product type 4 cannot share a section with product type 2",
+		},
+		{
+			name:          "incompatible product type in stock",
+			section:       internal.Section{ID: 1, ProductTypeID: 4, CurrentTemperature: 2},
+			storedTypeIDs: []int{4, 2},
+			wantMessage:   "product type 4 cannot share a section with product type 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newBatch := internal.ProductBatchRequest{
+				BatchNumber:        100,
+				CurrentQuantity:    50,
+				CurrentTemperature: 2,
+				DueDate:            "2022-01-01",
+				InitialQuantity:    50,
+				ManufacturingDate:  "2022-01-01",
+				ManufacturingHour:  18,
+				ProductID:          1,
+				SectionID:          1,
+			}
+
+			batchRepo := new(MockProductBatchRepository)
+			productRepo := new(MockProductRepository)
+			sectionRepo := new(MockSectionRepository)
+			productTypes := new(MockProductTypeValidation)
+
+			batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+			sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(tt.section, nil)
+			sectionRepo.On("GetSectionProductTypesByID", 1).Return(tt.storedTypeIDs, nil)
+			productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{ProductType: 4}}, nil)
+			productTypes.On("GetProductTypeByID", 4).Return(meat, nil)
+
+			service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, productTypes, newMockUnitOfWork(batchRepo, sectionRepo))
+
+			_, err := service.Save(context.Background(), &newBatch)
+
+			require.ErrorIs(t, err, utils.ErrInvalidArguments)
+			require.ErrorContains(t, err, tt.wantMessage)
+			batchRepo.AssertNotCalled(t, "Save", mock.Anything)
+		})
+	}
+}
+
+func TestUnitProductBatch_Save_Barcode(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    50,
+		CurrentTemperature: 22.4,
+		DueDate:            "2025-01-15",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2025-01-10",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+		Barcode:            "]C101077912340000121725011510100",
+	}
+
+	savedBatch := newBatch
+	savedBatch.Barcode = "(01)07791234000012(10)100(17)250115"
+
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+
+	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{Barcode: "07791234000012"}}, nil)
+	batchRepo.On("Save", &savedBatch).Return(internal.ProductBatch{ID: 1, ProductBatchRequest: savedBatch}, nil)
+
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+	result, err := service.Save(context.Background(), &newBatch)
+
+	require.NoError(t, err)
+	require.Equal(t, "(01)07791234000012(10)100(17)250115", result.Barcode)
+	batchRepo.AssertExpectations(t)
+}
+
+func TestUnitProductBatch_Save_BarcodeDoesNotMatchBatch(t *testing.T) {
+	newBatch := internal.ProductBatchRequest{
+		BatchNumber:        100,
+		CurrentQuantity:    50,
+		CurrentTemperature: 22.4,
+		DueDate:            "2025-01-15",
+		InitialQuantity:    10,
+		ManufacturingDate:  "2025-01-10",
+		ManufacturingHour:  18,
+		MinimumTemperature: -3,
+		ProductID:          1,
+		SectionID:          1,
+		Barcode:            "(01)07791234000012(17)250115(10)200",
+	}
+
+	batchRepo := new(MockProductBatchRepository)
+	productRepo := new(MockProductRepository)
+	sectionRepo := new(MockSectionRepository)
+
+	batchRepo.On("GetBatchNumber", mock.Anything).Return(0, nil)
+	sectionRepo.On("GetByIDForUpdate", newBatch.SectionID).Return(internal.Section{ID: 1}, nil)
+	sectionRepo.On("GetSectionProductTypesByID", 1).Return([]int{}, nil)
+	productRepo.On("GetByID", newBatch.ProductID).Return(internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{Barcode: "07791234000012"}}, nil)
+
+	service := NewProductBatchService(batchRepo, productRepo, sectionRepo, nil, newMockProductTypeValidation(), newMockUnitOfWork(batchRepo, sectionRepo))
+
+	_, err := service.Save(context.Background(), &newBatch)
+
+	require.Equal(t, utils.EBR("barcode batch number does not match the batch"), err)
+	batchRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestUnitProductBatch_Lookup(t *testing.T) {
+	product := internal.Product{ID: 1, ProductAttributes: internal.ProductAttributes{Barcode: "07791234000012"}}
+	batch := internal.ProductBatch{
+		ID: 1,
+		ProductBatchRequest: internal.ProductBatchRequest{
+			BatchNumber: 100,
+			DueDate:     "2025-01-15 12:00:00.000000",
+			ProductID:   1,
+		},
+	}
+
+	cases := []struct {
+		name      string
+		barcode   string
+		wantBatch internal.ProductBatch
+		wantErr   error
+	}{
+		{
+			name:      "Lookup by GTIN and batch number",
+			barcode:   "(01)07791234000012(17)250115(10)100",
+			wantBatch: batch,
+		},
+		{
+			name:      "Lookup by zero padded batch number",
+			barcode:   "(01)07791234000012(10)0100",
+			wantBatch: batch,
+		},
+		{
+			name:      "Lookup by stored label",
+			barcode:   "(01)07791234000012(10)300",
+			wantBatch: internal.ProductBatch{ID: 3},
+		},
+		{
+			name:    "Lookup expiry mismatch",
+			barcode: "(01)07791234000012(17)250116(10)100",
+			wantErr: utils.EBR("barcode expiry date does not match the batch due date"),
+		},
+		{
+			name:    "Lookup without batch number",
+			barcode: "7791234000012",
+			wantErr: utils.EBR("barcode must carry a GTIN (01) and a batch number (10)"),
+		},
+		{
+			name:    "Lookup unknown product",
+			barcode: "(01)07791234000029(10)100",
+			wantErr: utils.ENotFound("Product"),
+		},
+		{
+			name:    "Lookup unknown batch",
+			barcode: "(01)07791234000012(10)999",
+			wantErr: utils.ENotFound("Product batch"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			batchRepo := new(MockProductBatchRepository)
+			productRepo := new(MockProductRepository)
+
+			batchRepo.On("FindByBarcode", "(01)07791234000012(10)300").Return(internal.ProductBatch{ID: 3}, nil)
+			batchRepo.On("FindByBarcode", mock.Anything).Return(internal.ProductBatch{}, utils.ErrNotFound)
+			productRepo.On("GetByBarcode", "07791234000012").Return(product, nil)
+			productRepo.On("GetByBarcode", mock.Anything).Return(internal.Product{}, utils.ErrNotFound)
+			batchRepo.On("FindByProductAndNumber", 1, 100).Return(batch, nil)
+			batchRepo.On("FindByProductAndNumber", 1, 999).Return(internal.ProductBatch{}, utils.ErrNotFound)
+
+			service := NewProductBatchService(batchRepo, productRepo, new(MockSectionRepository), nil, newMockProductTypeValidation(), nil)
+
+			result, err := service.Lookup(context.Background(), c.barcode)
+			if c.wantErr != nil {
+				require.Equal(t, c.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.wantBatch, result)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "context"
+
 // ProductRecords represents a single product record with its metadata.
 type ProductRecords struct {
 	ID             int     `json:"id"`
@@ -16,15 +18,21 @@ type ProductReport struct {
 }
 
 type ProductRecordsRepository interface {
-	Read(productID int) ([]ProductReport, error)
-	Create(newProductRecord ProductRecords) (ProductRecords, error)
+	Read(ctx context.Context, productID int) ([]ProductReport, error)
+	// ReadPage returns up to limit reports of the products with an id greater than afterProductID,
+	// ordered by product id
+	ReadPage(ctx context.Context, afterProductID, limit int) ([]ProductReport, error)
+	Create(ctx context.Context, newProductRecord ProductRecords) (ProductRecords, error)
 }
 
 type ProductRecordsService interface {
-	GetProductRecords(productID int) ([]ProductReport, error)
-	CreateProductRecord(newProductRecord ProductRecords) (ProductRecords, error)
+	GetProductRecords(ctx context.Context, productID int) ([]ProductReport, error)
+	// GetProductRecordsPage returns up to limit reports of the products after afterProductID, the
+	// pages of every product are read from afterProductID 0 on with the last product id of a page
+	GetProductRecordsPage(ctx context.Context, afterProductID, limit int) ([]ProductReport, error)
+	CreateProductRecord(ctx context.Context, newProductRecord ProductRecords) (ProductRecords, error)
 }
 
 type ProductValidation interface {
-	GetProductByID(id int) (product Product, err error)
+	GetProductByID(ctx context.Context, id int) (product Product, err error)
 }
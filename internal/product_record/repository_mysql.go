@@ -1,6 +1,7 @@
 package product_record
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -10,20 +11,20 @@ import (
 )
 
 type ProductRecordDB struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewProductRecordDB(db *sql.DB) *ProductRecordDB {
+func NewProductRecordDB(db utils.DBTX) *ProductRecordDB {
 	return &ProductRecordDB{db: db}
 }
 
-func (p *ProductRecordDB) Read(productID int) ([]internal.ProductReport, error) {
+func (p *ProductRecordDB) Read(ctx context.Context, productID int) ([]internal.ProductReport, error) {
 	var rows *sql.Rows
 
 	var err error
 
 	if productID > 0 {
-		rows, err = p.db.Query(`
+		rows, err = p.db.QueryContext(ctx, `
 			SELECT 
 				p.id AS product_id,
 				p.description,
@@ -38,7 +39,7 @@ func (p *ProductRecordDB) Read(productID int) ([]internal.ProductReport, error)
 				p.id, p.description
 		`, productID)
 	} else {
-		rows, err = p.db.Query(`
+		rows, err = p.db.QueryContext(ctx, `
 			SELECT 
 				p.id AS product_id,
 				p.description,
@@ -47,6 +48,8 @@ func (p *ProductRecordDB) Read(productID int) ([]internal.ProductReport, error)
 				products p
 			INNER JOIN 
 				product_records pr ON p.id = pr.product_id
+			WHERE 
+				`+internal.SellerCondition(ctx, "p.seller_id")+`
 			GROUP BY 
 				p.id, p.description
 		`)
@@ -79,14 +82,54 @@ func (p *ProductRecordDB) Read(productID int) ([]internal.ProductReport, error)
 	return listProducts, nil
 }
 
-func (p *ProductRecordDB) Create(newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
-	statement, err := p.db.Prepare("INSERT INTO product_records (last_update_date, purchase_price, sale_price, product_id) VALUES(?, ?, ?, ?)")
+func (p *ProductRecordDB) ReadPage(ctx context.Context, afterProductID, limit int) ([]internal.ProductReport, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT 
+			p.id AS product_id,
+			p.description,
+			COUNT(pr.id) AS records_count
+		FROM 
+			products p
+		INNER JOIN 
+			product_records pr ON p.id = pr.product_id
+		WHERE 
+			p.id > ? AND `+internal.SellerCondition(ctx, "p.seller_id")+`
+		GROUP BY 
+			p.id, p.description
+		ORDER BY 
+			p.id
+		LIMIT ?
+	`, afterProductID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	listProducts := []internal.ProductReport{}
+
+	for rows.Next() {
+		var productRecord internal.ProductReport
+
+		err := rows.Scan(&productRecord.ProductID, &productRecord.Description, &productRecord.RecordsCount)
+		if err != nil {
+			return nil, err
+		}
+
+		listProducts = append(listProducts, productRecord)
+	}
+
+	return listProducts, rows.Err()
+}
+
+func (p *ProductRecordDB) Create(ctx context.Context, newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
+	statement, err := p.db.PrepareContext(ctx, "INSERT INTO product_records (last_update_date, purchase_price, sale_price, product_id) VALUES(?, ?, ?, ?)")
 	if err != nil {
 		return internal.ProductRecords{}, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(newProductRecord.LastUpdateDate, newProductRecord.PurchasePrice, newProductRecord.SalePrice, newProductRecord.ProductID)
+	result, err := statement.ExecContext(ctx, newProductRecord.LastUpdateDate, newProductRecord.PurchasePrice, newProductRecord.SalePrice, newProductRecord.ProductID)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -112,14 +155,14 @@ func (p *ProductRecordDB) Create(newProductRecord internal.ProductRecords) (inte
 	return newProductRecord, nil
 }
 
-func (p *ProductRecordDB) FindByID(productRecordID int) (internal.ProductRecords, error) {
+func (p *ProductRecordDB) FindByID(ctx context.Context, productRecordID int) (internal.ProductRecords, error) {
 	var row *sql.Row
 
 	var err error
 
 	query := "select `id` from product_records where id = ?"
 
-	row = p.db.QueryRow(query, productRecordID)
+	row = p.db.QueryRowContext(ctx, query, productRecordID)
 
 	var pr internal.ProductRecords
 
@@ -1,30 +1,38 @@
 package product_record
 
 import (
+	"context"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the product records in the audit trail
+const auditEntity = "productRecords"
+
 type ProductRecordsService struct {
 	repo              internal.ProductRecordsRepository
 	validationProduct internal.ProductValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewProductRecordService(repo internal.ProductRecordsRepository, validationProduct internal.ProductValidation) *ProductRecordsService {
+func NewProductRecordService(repo internal.ProductRecordsRepository, validationProduct internal.ProductValidation, uow internal.UnitOfWork) *ProductRecordsService {
 	return &ProductRecordsService{
 		repo:              repo,
 		validationProduct: validationProduct,
+		uow:               uow,
 	}
 }
 
-func (s *ProductRecordsService) GetProductRecords(productID int) ([]internal.ProductReport, error) {
+func (s *ProductRecordsService) GetProductRecords(ctx context.Context, productID int) ([]internal.ProductReport, error) {
 	if productID > 0 {
-		if _, err := s.validationProduct.GetProductByID(productID); err != nil {
+		if _, err := s.validationProduct.GetProductByID(ctx, productID); err != nil {
 			return nil, utils.ErrNotFound
 		}
 	}
 
-	productReports, err := s.repo.Read(productID)
+	productReports, err := s.repo.Read(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
@@ -36,16 +44,42 @@ func (s *ProductRecordsService) GetProductRecords(productID int) ([]internal.Pro
 	return productReports, nil
 }
 
-func (s *ProductRecordsService) CreateProductRecord(newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
-	err := s.validateEmptyFields(newProductRecord)
+func (s *ProductRecordsService) GetProductRecordsPage(ctx context.Context, afterProductID, limit int) ([]internal.ProductReport, error) {
+	productReports, err := s.repo.ReadPage(ctx, afterProductID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// as GetProductRecords, there are no reports when no product has records
+	if afterProductID == 0 && len(productReports) == 0 {
+		return nil, utils.ErrNotFound
+	}
+
+	return productReports, nil
+}
+
+func (s *ProductRecordsService) CreateProductRecord(ctx context.Context, newProductRecord internal.ProductRecords) (productRecord internal.ProductRecords, err error) {
+	err = s.validateEmptyFields(ctx, newProductRecord)
+	if err != nil {
+		return internal.ProductRecords{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		productRecord, err = repos.ProductRecords.Create(ctx, newProductRecord)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, productRecord.ID, nil, productRecord)
+	})
 	if err != nil {
 		return internal.ProductRecords{}, err
 	}
 
-	return s.repo.Create(newProductRecord)
+	return productRecord, nil
 }
 
-func (s *ProductRecordsService) validateEmptyFields(newProduct internal.ProductRecords) error {
+func (s *ProductRecordsService) validateEmptyFields(ctx context.Context, newProduct internal.ProductRecords) error {
 	if newProduct.LastUpdateDate == "" {
 		return utils.ErrInvalidArguments
 	}
@@ -62,7 +96,7 @@ func (s *ProductRecordsService) validateEmptyFields(newProduct internal.ProductR
 		return utils.ErrInvalidArguments
 	}
 
-	if _, err := s.validationProduct.GetProductByID(newProduct.ProductID); err != nil {
+	if _, err := s.validationProduct.GetProductByID(ctx, newProduct.ProductID); err != nil {
 		return utils.ErrConflict
 	}
 
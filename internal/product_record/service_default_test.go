@@ -1,6 +1,7 @@
 package product_record_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -11,16 +12,54 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.ProductRecordsRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{ProductRecords: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type mockProductRecordsRepository struct {
 	mock.Mock
 }
 
-func (m *mockProductRecordsRepository) Read(productID int) ([]internal.ProductReport, error) {
+func (m *mockProductRecordsRepository) Read(ctx context.Context, productID int) ([]internal.ProductReport, error) {
 	args := m.Called(productID)
 	return args.Get(0).([]internal.ProductReport), args.Error(1)
 }
 
-func (m *mockProductRecordsRepository) Create(newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
+func (m *mockProductRecordsRepository) ReadPage(ctx context.Context, afterProductID, limit int) ([]internal.ProductReport, error) {
+	args := m.Called(afterProductID, limit)
+	return args.Get(0).([]internal.ProductReport), args.Error(1)
+}
+
+func (m *mockProductRecordsRepository) Create(ctx context.Context, newProductRecord internal.ProductRecords) (internal.ProductRecords, error) {
 	args := m.Called(newProductRecord)
 	return args.Get(0).(internal.ProductRecords), args.Error(1)
 }
@@ -29,7 +68,7 @@ type mockProductValidation struct {
 	mock.Mock
 }
 
-func (m *mockProductValidation) GetProductByID(id int) (internal.Product, error) {
+func (m *mockProductValidation) GetProductByID(ctx context.Context, id int) (internal.Product, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Product), args.Error(1)
 }
@@ -92,8 +131,8 @@ func TestProductRecordsService_GetProductRecords(t *testing.T) {
 
 			repo.On("Read", c.ProductID).Return(c.RepoResponse, c.RepoError)
 
-			service := product_record.NewProductRecordService(repo, validation)
-			result, err := service.GetProductRecords(c.ProductID)
+			service := product_record.NewProductRecordService(repo, validation, newMockUnitOfWork(repo))
+			result, err := service.GetProductRecords(context.Background(), c.ProductID)
 
 			if c.ExpectedError != nil {
 				assert.Error(t, err)
@@ -106,6 +145,34 @@ func TestProductRecordsService_GetProductRecords(t *testing.T) {
 	}
 }
 
+func TestProductRecordsService_GetProductRecordsPage(t *testing.T) {
+	reports := []internal.ProductReport{{ProductID: 3, Description: "Product C", RecordsCount: 2}}
+
+	repo := new(mockProductRecordsRepository)
+	repo.On("ReadPage", 0, 2).Return(reports, nil)
+	repo.On("ReadPage", 3, 2).Return([]internal.ProductReport{}, nil)
+	repo.On("ReadPage", 0, 5).Return([]internal.ProductReport{}, nil)
+
+	service := product_record.NewProductRecordService(repo, new(mockProductValidation), newMockUnitOfWork(repo))
+
+	t.Run("GetProductRecordsPage_FirstPage", func(t *testing.T) {
+		result, err := service.GetProductRecordsPage(context.Background(), 0, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, reports, result)
+	})
+
+	t.Run("GetProductRecordsPage_LastPage", func(t *testing.T) {
+		result, err := service.GetProductRecordsPage(context.Background(), 3, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("GetProductRecordsPage_NoReportsFound", func(t *testing.T) {
+		_, err := service.GetProductRecordsPage(context.Background(), 0, 5)
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+	})
+}
+
 func TestProductRecordsService_CreateProductRecord(t *testing.T) {
 	cases := []struct {
 		TestName        string
@@ -209,14 +276,17 @@ func TestProductRecordsService_CreateProductRecord(t *testing.T) {
 			validation.On("GetProductByID", c.NewProduct.ProductID).Return(internal.Product{}, c.ValidationError)
 			repo.On("Create", c.NewProduct).Return(c.RepoResponse, c.RepoError)
 
-			service := product_record.NewProductRecordService(repo, validation)
-			result, err := service.CreateProductRecord(c.NewProduct)
+			uow := newMockUnitOfWork(repo)
+			service := product_record.NewProductRecordService(repo, validation, uow)
+			result, err := service.CreateProductRecord(context.Background(), c.NewProduct)
 
 			if c.ExpectedError != nil {
 				assert.ErrorIs(t, err, c.ExpectedError)
+				assert.Empty(t, uow.entries)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, c.RepoResponse, result)
+				assert.Len(t, uow.entries, 1)
 			}
 		})
 	}
@@ -1,23 +1,61 @@
 package internal
 
+import "context"
+
 // ProductType represents a product type
+// MinimumTemperature and MaximumTemperature are the storage range in Celsius, nil means no bound,
+// IncompatibleWith are the product types that cannot share a section with it
 type ProductType struct {
-	ID          int    `json:"id"`
-	Description string `json:"description"`
+	ID                 int      `json:"id"`
+	Description        string   `json:"description"`
+	MinimumTemperature *float64 `json:"minimum_temperature,omitempty"`
+	MaximumTemperature *float64 `json:"maximum_temperature,omitempty"`
+	IncompatibleWith   []int    `json:"incompatible_with"`
+	// Version is bumped by every change, it is the ETag of the product type
+	Version int `json:"version,omitempty"`
+}
+
+// AcceptsTemperature reports whether a temperature is within the storage range of the product type
+func (p ProductType) AcceptsTemperature(temperature float64) bool {
+	if p.MinimumTemperature != nil && temperature < *p.MinimumTemperature {
+		return false
+	}
+
+	if p.MaximumTemperature != nil && temperature > *p.MaximumTemperature {
+		return false
+	}
+
+	return true
+}
+
+// CompatibleWith reports whether products of the product type can share a section with products of another one
+func (p ProductType) CompatibleWith(productTypeID int) bool {
+	for _, id := range p.IncompatibleWith {
+		if id == productTypeID {
+			return false
+		}
+	}
+
+	return true
 }
 
 type ProductTypeRepository interface {
-	GetAll() (listProductTypes []ProductType, err error)
-	GetByID(id int) (productType ProductType, err error)
-	Create(newProductType ProductType) (productType ProductType, err error)
-	Update(inputProductType ProductType) (productType ProductType, err error)
-	Delete(id int) (err error)
+	GetAll(ctx context.Context) (listProductTypes []ProductType, err error)
+	GetByID(ctx context.Context, id int) (productType ProductType, err error)
+	// GetByIDs returns the product types with the given ids, the ids without a product type are ignored
+	GetByIDs(ctx context.Context, ids []int) (listProductTypes []ProductType, err error)
+	Create(ctx context.Context, newProductType ProductType) (productType ProductType, err error)
+	Update(ctx context.Context, inputProductType ProductType) (productType ProductType, err error)
+	// Delete removes a product type when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) (err error)
 }
 
 type ProductTypeService interface {
-	GetProductTypes() (listProductTypes []ProductType, err error)
-	GetProductTypeByID(id int) (productType ProductType, err error)
-	CreateProductType(newProductType ProductType) (productType ProductType, err error)
-	UpdateProductType(inputProductType ProductType) (productType ProductType, err error)
-	DeleteProductType(id int) (err error)
+	GetProductTypes(ctx context.Context) (listProductTypes []ProductType, err error)
+	GetProductTypeByID(ctx context.Context, id int) (productType ProductType, err error)
+	GetProductTypesByIDs(ctx context.Context, ids []int) (listProductTypes []ProductType, err error)
+	CreateProductType(ctx context.Context, newProductType ProductType) (productType ProductType, err error)
+	UpdateProductType(ctx context.Context, inputProductType ProductType) (productType ProductType, err error)
+	// DeleteProductType removes a product type in the version given
+	DeleteProductType(ctx context.Context, id int, version int) (err error)
 }
@@ -1,24 +1,26 @@
 package product_type
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"github.com/go-sql-driver/mysql"
+	"strings"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
 type ProductTypeDB struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewProductTypeDB(db *sql.DB) *ProductTypeDB {
+func NewProductTypeDB(db utils.DBTX) *ProductTypeDB {
 	return &ProductTypeDB{db: db}
 }
 
 // GetAll returns all product types
-func (p *ProductTypeDB) GetAll() (listProductTypes []internal.ProductType, err error) {
-	rows, err := p.db.Query("SELECT id, description FROM product_types")
+func (p *ProductTypeDB) GetAll(ctx context.Context) (listProductTypes []internal.ProductType, err error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT id, description, minimum_temperature, maximum_temperature, version FROM product_types")
 	if err != nil {
 		return nil, err
 	}
@@ -26,9 +28,7 @@ func (p *ProductTypeDB) GetAll() (listProductTypes []internal.ProductType, err e
 	defer rows.Close()
 
 	for rows.Next() {
-		var productType internal.ProductType
-
-		err := rows.Scan(&productType.ID, &productType.Description)
+		productType, err := scanProductType(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -41,17 +41,29 @@ func (p *ProductTypeDB) GetAll() (listProductTypes []internal.ProductType, err e
 		return nil, err
 	}
 
+	incompatibilities, err := p.getIncompatibilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range listProductTypes {
+		listProductTypes[i].IncompatibleWith = incompatibilities[listProductTypes[i].ID]
+		if listProductTypes[i].IncompatibleWith == nil {
+			listProductTypes[i].IncompatibleWith = []int{}
+		}
+	}
+
 	return listProductTypes, nil
 }
 
 // GetByID returns a product type by id
-func (p *ProductTypeDB) GetByID(id int) (productType internal.ProductType, err error) {
-	row := p.db.QueryRow("SELECT id, description FROM product_types WHERE id = ?", id)
+func (p *ProductTypeDB) GetByID(ctx context.Context, id int) (productType internal.ProductType, err error) {
+	row := p.db.QueryRowContext(ctx, "SELECT id, description, minimum_temperature, maximum_temperature, version FROM product_types WHERE id = ?", id)
 	if err := row.Err(); err != nil {
 		return internal.ProductType{}, err
 	}
 
-	err = row.Scan(&productType.ID, &productType.Description)
+	productType, err = scanProductType(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return internal.ProductType{}, utils.ErrNotFound
@@ -60,94 +72,217 @@ func (p *ProductTypeDB) GetByID(id int) (productType internal.ProductType, err e
 		return internal.ProductType{}, err
 	}
 
+	incompatibilities, err := p.getIncompatibilities(ctx, id)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	productType.IncompatibleWith = incompatibilities[id]
+	if productType.IncompatibleWith == nil {
+		productType.IncompatibleWith = []int{}
+	}
+
 	return productType, nil
 }
 
-// Create a product type
-func (p *ProductTypeDB) Create(newProductType internal.ProductType) (productType internal.ProductType, err error) {
-	statement, err := p.db.Prepare("INSERT INTO product_types (description) VALUES(?)")
+// GetByIDs returns the product types with the given ids, the ids without a product type are ignored
+func (p *ProductTypeDB) GetByIDs(ctx context.Context, ids []int) (listProductTypes []internal.ProductType, err error) {
+	listProductTypes = []internal.ProductType{}
+	if len(ids) == 0 {
+		return listProductTypes, nil
+	}
+
+	in, args := utils.InClause(ids)
+
+	rows, err := p.db.QueryContext(ctx, "SELECT id, description, minimum_temperature, maximum_temperature, version FROM product_types WHERE id IN "+in, args...)
 	if err != nil {
-		return internal.ProductType{}, err
+		return nil, err
 	}
-	defer statement.Close()
 
-	result, err := statement.Exec(newProductType.Description)
-	if err != nil {
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) {
-			switch mysqlErr.Number {
-			case 1062:
-				err = utils.ErrConflict
-				fallthrough
-			default:
-				return internal.ProductType{}, err
-			}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productType internal.ProductType
+
+		productType, err = scanProductType(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		return internal.ProductType{}, err
+		listProductTypes = append(listProductTypes, productType)
 	}
 
-	id, err := result.LastInsertId()
+	err = rows.Err()
 	if err != nil {
-		return internal.ProductType{}, err
+		return nil, err
 	}
 
-	newProductType.ID = int(id)
+	incompatibilities, err := p.getIncompatibilities(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
 
-	return newProductType, nil
+	for i := range listProductTypes {
+		listProductTypes[i].IncompatibleWith = incompatibilities[listProductTypes[i].ID]
+		if listProductTypes[i].IncompatibleWith == nil {
+			listProductTypes[i].IncompatibleWith = []int{}
+		}
+	}
+
+	return listProductTypes, nil
 }
 
-// Update a product type
-func (p *ProductTypeDB) Update(inputProductType internal.ProductType) (productType internal.ProductType, err error) {
-	_, err = p.GetByID(inputProductType.ID)
+// Create a product type along with its incompatibilities
+func (p *ProductTypeDB) Create(ctx context.Context, newProductType internal.ProductType) (productType internal.ProductType, err error) {
+	err = utils.InTx(ctx, p.db, func(tx utils.DBTX) error {
+		result, err := tx.ExecContext(ctx, "INSERT INTO product_types (description, minimum_temperature, maximum_temperature) VALUES(?, ?, ?)",
+			newProductType.Description, newProductType.MinimumTemperature, newProductType.MaximumTemperature)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		newProductType.ID = int(id)
+		newProductType.Version = 1
+
+		return saveIncompatibilities(ctx, tx, newProductType)
+	})
 	if err != nil {
 		return internal.ProductType{}, err
 	}
 
-	statement, err := p.db.Prepare(
-		"UPDATE product_types SET description=? WHERE id=?",
-	)
+	return newProductType, nil
+}
+
+// Update a product type, replacing its incompatibilities
+func (p *ProductTypeDB) Update(ctx context.Context, inputProductType internal.ProductType) (productType internal.ProductType, err error) {
+	_, err = p.GetByID(ctx, inputProductType.ID)
 	if err != nil {
 		return internal.ProductType{}, err
 	}
-	defer statement.Close()
 
-	_, err = statement.Exec(inputProductType.Description, inputProductType.ID)
-	if err != nil {
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) {
-			switch mysqlErr.Number {
-			case 1062:
-				err = utils.ErrConflict
-				fallthrough
-			default:
-				return internal.ProductType{}, err
-			}
+	err = utils.InTx(ctx, p.db, func(tx utils.DBTX) error {
+		result, err := tx.ExecContext(ctx, "UPDATE product_types SET description=?, minimum_temperature=?, maximum_temperature=?, version=version+1 WHERE id=? AND version=?",
+			inputProductType.Description, inputProductType.MinimumTemperature, inputProductType.MaximumTemperature, inputProductType.ID, inputProductType.Version)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		err = utils.CheckVersionUpdated(result, "product type")
+		if err != nil {
+			return err
 		}
 
+		_, err = tx.ExecContext(ctx, "DELETE FROM product_type_incompatibilities WHERE product_type_id = ? OR incompatible_product_type_id = ?",
+			inputProductType.ID, inputProductType.ID)
+		if err != nil {
+			return err
+		}
+
+		return saveIncompatibilities(ctx, tx, inputProductType)
+	})
+	if err != nil {
 		return internal.ProductType{}, err
 	}
 
+	inputProductType.Version++
+
 	return inputProductType, nil
 }
 
-// Delete a product type
-func (p *ProductTypeDB) Delete(id int) error {
-	_, err := p.GetByID(id)
+// Delete a product type still in the version it was read
+func (p *ProductTypeDB) Delete(ctx context.Context, id int, version int) error {
+	statement, err := p.db.PrepareContext(ctx, "DELETE FROM product_types WHERE id = ? AND version = ?")
 	if err != nil {
 		return err
 	}
+	defer statement.Close()
 
-	statement, err := p.db.Prepare("DELETE FROM product_types WHERE id = ?")
+	result, err := statement.ExecContext(ctx, id, version)
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
 
-	_, err = statement.Exec(id)
+	return utils.CheckVersionUpdated(result, "product type")
+}
+
+// getIncompatibilities returns the incompatible product types of the given product types, or of every
+// product type when none is given
+func (p *ProductTypeDB) getIncompatibilities(ctx context.Context, ids ...int) (map[int][]int, error) {
+	query := "SELECT product_type_id, incompatible_product_type_id FROM product_type_incompatibilities"
+
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if len(ids) > 0 {
+		query += " WHERE product_type_id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	}
+
+	rows, err := p.db.QueryContext(ctx, query+" ORDER BY product_type_id, incompatible_product_type_id", args...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	incompatibilities := map[int][]int{}
+
+	for rows.Next() {
+		var id, incompatibleID int
+
+		err := rows.Scan(&id, &incompatibleID)
+		if err != nil {
+			return nil, err
+		}
+
+		incompatibilities[id] = append(incompatibilities[id], incompatibleID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return incompatibilities, nil
+}
+
+// saveIncompatibilities stores the incompatibilities of a product type in both directions,
+// so they can be read from either product type
+func saveIncompatibilities(ctx context.Context, tx utils.DBTX, productType internal.ProductType) error {
+	for _, incompatibleID := range productType.IncompatibleWith {
+		_, err := tx.ExecContext(ctx, "INSERT INTO product_type_incompatibilities (product_type_id, incompatible_product_type_id) VALUES (?, ?), (?, ?)",
+			productType.ID, incompatibleID, incompatibleID, productType.ID)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
 	}
 
 	return nil
 }
+
+func scanProductType(row interface{ Scan(...any) error }) (internal.ProductType, error) {
+	var productType internal.ProductType
+
+	var minimumTemperature, maximumTemperature sql.NullFloat64
+
+	err := row.Scan(&productType.ID, &productType.Description, &minimumTemperature, &maximumTemperature, &productType.Version)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	if minimumTemperature.Valid {
+		productType.MinimumTemperature = &minimumTemperature.Float64
+	}
+
+	if maximumTemperature.Valid {
+		productType.MaximumTemperature = &maximumTemperature.Float64
+	}
+
+	return productType, nil
+}
@@ -4,17 +4,18 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 func NewProductTypeRoutes(mux *chi.Mux, service internal.ProductTypeService) error {
 	productTypeHandler := handler.NewProductTypeHandler(service)
 
 	mux.Route("/api/v1/product_types", func(router chi.Router) {
-		router.Get("/", productTypeHandler.GetProductTypes)
-		router.Post("/", productTypeHandler.CreateProductType)
-		router.Get("/{id}", productTypeHandler.GetProductTypeByID)
-		router.Patch("/{id}", productTypeHandler.UpdateProductType)
-		router.Delete("/{id}", productTypeHandler.DeleteProductType)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductTypes, rbac.ActionRead))).Get("/", productTypeHandler.GetProductTypes)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductTypes, rbac.ActionWrite))).Post("/", productTypeHandler.CreateProductType)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductTypes, rbac.ActionRead))).Get("/{id}", productTypeHandler.GetProductTypeByID)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductTypes, rbac.ActionWrite))).Patch("/{id}", productTypeHandler.UpdateProductType)
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceProductTypes, rbac.ActionDelete))).Delete("/{id}", productTypeHandler.DeleteProductType)
 	})
 
 	return nil
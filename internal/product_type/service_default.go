@@ -1,38 +1,186 @@
 package product_type
 
 import (
+	"context"
+	"errors"
+	"strconv"
+
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+const (
+	MinCelsiusTemperature = -273.15
 )
 
+// auditEntity is the name of the product types in the audit trail
+const auditEntity = "product_types"
+
 type ProductTypeSvc struct {
 	repo internal.ProductTypeRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewProductTypeService(repo internal.ProductTypeRepository) *ProductTypeSvc {
-	return &ProductTypeSvc{repo: repo}
+func NewProductTypeService(repo internal.ProductTypeRepository, uow internal.UnitOfWork) *ProductTypeSvc {
+	return &ProductTypeSvc{repo: repo, uow: uow}
 }
 
-func (s *ProductTypeSvc) GetProductTypes() (listProductTypes []internal.ProductType, err error) {
-	return s.repo.GetAll()
+func (s *ProductTypeSvc) GetProductTypes(ctx context.Context) (listProductTypes []internal.ProductType, err error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *ProductTypeSvc) GetProductTypeByID(id int) (productType internal.ProductType, err error) {
-	return s.repo.GetByID(id)
+func (s *ProductTypeSvc) GetProductTypeByID(ctx context.Context, id int) (productType internal.ProductType, err error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *ProductTypeSvc) CreateProductType(newProductType internal.ProductType) (productType internal.ProductType, err error) {
-	return s.repo.Create(newProductType)
+// GetProductTypesByIDs returns the product types with the given ids, the ids without a product type are ignored
+func (s *ProductTypeSvc) GetProductTypesByIDs(ctx context.Context, ids []int) (listProductTypes []internal.ProductType, err error) {
+	return s.repo.GetByIDs(ctx, ids)
 }
 
-func (s *ProductTypeSvc) UpdateProductType(inputProductType internal.ProductType) (productType internal.ProductType, err error) {
-	_, err = s.repo.GetByID(inputProductType.ID)
+func (s *ProductTypeSvc) CreateProductType(ctx context.Context, newProductType internal.ProductType) (productType internal.ProductType, err error) {
+	newProductType.IncompatibleWith = uniqueIDs(newProductType.IncompatibleWith)
+
+	err = s.validateProfile(ctx, newProductType)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		productType, err = repos.ProductTypes.Create(ctx, newProductType)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, productType.ID, nil, productType)
+	})
 	if err != nil {
 		return internal.ProductType{}, err
 	}
 
-	return s.repo.Update(inputProductType)
+	return productType, nil
 }
 
-func (s *ProductTypeSvc) DeleteProductType(id int) (err error) {
-	return s.repo.Delete(id)
+// UpdateProductType updates the fields present in the input, a nil incompatible_with keeps the
+// incompatibilities and an empty one removes them
+func (s *ProductTypeSvc) UpdateProductType(ctx context.Context, inputProductType internal.ProductType) (productType internal.ProductType, err error) {
+	current, err := s.repo.GetByID(ctx, inputProductType.ID)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	productType = current
+
+	err = utils.CheckVersion("product type", inputProductType.Version, productType.Version)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	if inputProductType.Description != "" {
+		productType.Description = inputProductType.Description
+	}
+
+	if inputProductType.MinimumTemperature != nil {
+		productType.MinimumTemperature = inputProductType.MinimumTemperature
+	}
+
+	if inputProductType.MaximumTemperature != nil {
+		productType.MaximumTemperature = inputProductType.MaximumTemperature
+	}
+
+	if inputProductType.IncompatibleWith != nil {
+		productType.IncompatibleWith = uniqueIDs(inputProductType.IncompatibleWith)
+	}
+
+	err = s.validateProfile(ctx, productType)
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		productType, err = repos.ProductTypes.Update(ctx, productType)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, productType.ID, current, productType)
+	})
+	if err != nil {
+		return internal.ProductType{}, err
+	}
+
+	return productType, nil
+}
+
+func (s *ProductTypeSvc) DeleteProductType(ctx context.Context, id int, version int) (err error) {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		productType, err := repos.ProductTypes.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = utils.CheckVersion("product type", version, productType.Version); err != nil {
+			return err
+		}
+
+		if err = repos.ProductTypes.Delete(ctx, id, productType.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, productType, nil)
+	})
+}
+
+// validateProfile checks the temperature range and that the incompatible product types exist
+func (s *ProductTypeSvc) validateProfile(ctx context.Context, productType internal.ProductType) error {
+	minimum, maximum := productType.MinimumTemperature, productType.MaximumTemperature
+
+	if minimum != nil && *minimum < MinCelsiusTemperature {
+		return utils.EBR("minimum_temperature cannot be less than -273.15 Celsius")
+	}
+
+	if maximum != nil && *maximum < MinCelsiusTemperature {
+		return utils.EBR("maximum_temperature cannot be less than -273.15 Celsius")
+	}
+
+	if minimum != nil && maximum != nil && *minimum > *maximum {
+		return utils.EBR("minimum_temperature cannot be greater than maximum_temperature")
+	}
+
+	for _, id := range productType.IncompatibleWith {
+		if id == productType.ID {
+			return utils.EBR("a product type cannot be incompatible with itself")
+		}
+
+		_, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.EDependencyNotFound("product_type", "id: "+strconv.Itoa(id))
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []int) []int {
+	if ids == nil {
+		return nil
+	}
+
+	seen := map[int]bool{}
+	unique := []int{}
+
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
 }
@@ -0,0 +1,183 @@
+package product_type
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.ProductTypeRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{ProductTypes: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type mockProductTypeRepository struct {
+	mock.Mock
+}
+
+func (m *mockProductTypeRepository) GetAll(ctx context.Context) (listProductTypes []internal.ProductType, err error) {
+	args := m.Called()
+	return args.Get(0).([]internal.ProductType), args.Error(1)
+}
+
+func (m *mockProductTypeRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.ProductType, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.ProductType), args.Error(1)
+}
+
+func (m *mockProductTypeRepository) GetByID(ctx context.Context, id int) (productType internal.ProductType, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.ProductType), args.Error(1)
+}
+
+func (m *mockProductTypeRepository) Create(ctx context.Context, newProductType internal.ProductType) (productType internal.ProductType, err error) {
+	args := m.Called(newProductType)
+	return args.Get(0).(internal.ProductType), args.Error(1)
+}
+
+func (m *mockProductTypeRepository) Update(ctx context.Context, inputProductType internal.ProductType) (productType internal.ProductType, err error) {
+	args := m.Called(inputProductType)
+	return args.Get(0).(internal.ProductType), args.Error(1)
+}
+
+func (m *mockProductTypeRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func temperature(value float64) *float64 {
+	return &value
+}
+
+var fruits = internal.ProductType{
+	ID:                 2,
+	Description:        "Fruits",
+	MinimumTemperature: temperature(0),
+	MaximumTemperature: temperature(12),
+	IncompatibleWith:   []int{4},
+	Version:            3,
+}
+
+func TestUnitProductType_CreateProductType(t *testing.T) {
+	tests := []struct {
+		name           string
+		newProductType internal.ProductType
+		wantCreated    internal.ProductType
+		wantErr        error
+	}{
+		{
+			name:           "CreateProductType OK",
+			newProductType: internal.ProductType{Description: "Meat", MinimumTemperature: temperature(-2), MaximumTemperature: temperature(4), IncompatibleWith: []int{2, 2}},
+			wantCreated:    internal.ProductType{Description: "Meat", MinimumTemperature: temperature(-2), MaximumTemperature: temperature(4), IncompatibleWith: []int{2}},
+		},
+		{
+			name:           "CreateProductType minimum greater than maximum",
+			newProductType: internal.ProductType{Description: "Meat", MinimumTemperature: temperature(4), MaximumTemperature: temperature(-2)},
+			wantErr:        utils.ErrInvalidArguments,
+		},
+		{
+			name:           "CreateProductType below absolute zero",
+			newProductType: internal.ProductType{Description: "Meat", MinimumTemperature: temperature(-300)},
+			wantErr:        utils.ErrInvalidArguments,
+		},
+		{
+			name:           "CreateProductType incompatible product type doesn't exist",
+			newProductType: internal.ProductType{Description: "Meat", IncompatibleWith: []int{99}},
+			wantErr:        utils.ErrInvalidArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductTypeRepository{}
+			repo.On("GetByID", fruits.ID).Return(fruits, nil)
+			repo.On("GetByID", 99).Return(internal.ProductType{}, utils.ErrNotFound)
+			repo.On("Create", tt.wantCreated).Return(tt.wantCreated, nil)
+
+			s := NewProductTypeService(repo, newMockUnitOfWork(repo))
+
+			productType, err := s.CreateProductType(context.Background(), tt.newProductType)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCreated, productType)
+		})
+	}
+}
+
+func TestUnitProductType_UpdateProductType(t *testing.T) {
+	repo := &mockProductTypeRepository{}
+	repo.On("GetByID", fruits.ID).Return(fruits, nil)
+	repo.On("GetByID", 4).Return(internal.ProductType{ID: 4, Description: "Meat"}, nil)
+
+	updated := fruits
+	updated.MaximumTemperature = temperature(10)
+	repo.On("Update", updated).Return(updated, nil)
+
+	uow := newMockUnitOfWork(repo)
+	s := NewProductTypeService(repo, uow)
+
+	productType, err := s.UpdateProductType(context.Background(), internal.ProductType{ID: fruits.ID, MaximumTemperature: temperature(10)})
+	require.NoError(t, err)
+	require.Equal(t, updated, productType)
+	require.Len(t, uow.entries, 1)
+	require.Contains(t, uow.entries[0].Changes, "maximum_temperature")
+
+	_, err = s.UpdateProductType(context.Background(), internal.ProductType{ID: fruits.ID, IncompatibleWith: []int{fruits.ID}})
+	require.ErrorIs(t, err, utils.ErrInvalidArguments)
+
+	productType, err = s.UpdateProductType(context.Background(), internal.ProductType{ID: fruits.ID, MaximumTemperature: temperature(10), Version: 3})
+	require.NoError(t, err)
+	require.Equal(t, updated, productType)
+
+	_, err = s.UpdateProductType(context.Background(), internal.ProductType{ID: fruits.ID, MaximumTemperature: temperature(10), Version: 2})
+	require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+}
+
+func TestUnitProductType_Rules(t *testing.T) {
+	require.True(t, fruits.AcceptsTemperature(0))
+	require.True(t, fruits.AcceptsTemperature(12))
+	require.False(t, fruits.AcceptsTemperature(-0.5))
+	require.False(t, fruits.AcceptsTemperature(12.5))
+	require.True(t, internal.ProductType{}.AcceptsTemperature(-50))
+
+	require.False(t, fruits.CompatibleWith(4))
+	require.True(t, fruits.CompatibleWith(1))
+}
@@ -1,12 +1,41 @@
 package internal
 
+import "context"
+
 type Province struct {
-	ID           int
-	ProvinceName string
-	CountryID    int
+	ID           int    `json:"id"`
+	ProvinceName string `json:"province_name"`
+	CountryID    int    `json:"country_id"`
+	// Version is bumped by every change, it is the ETag of the province
+	Version int `json:"version,omitempty"`
+}
+
+// ProvinceHierarchy is a province with its localities
+type ProvinceHierarchy struct {
+	Province
+	Localities []Locality `json:"localities"`
 }
 
 type ProvinceRepository interface {
-	Save(*Province) error
-	GetByName(string) (Province, error)
+	Save(context.Context, *Province) error
+	GetByName(context.Context, string) (Province, error)
+	GetAll(ctx context.Context) ([]Province, error)
+	GetByID(ctx context.Context, id int) (Province, error)
+	GetByCountryID(ctx context.Context, countryID int) ([]Province, error)
+	Update(context.Context, *Province) error
+	// Delete removes the province along with its localities, when the province is still in the
+	// version it was read
+	Delete(ctx context.Context, id int, version int) error
+	// GetReferences counts the entities referencing the localities of the province
+	GetReferences(ctx context.Context, id int) (LocalityReferences, error)
+}
+
+type ProvinceService interface {
+	GetAll(ctx context.Context) ([]Province, error)
+	GetByID(ctx context.Context, id int) (Province, error)
+	Save(context.Context, *Province) error
+	Update(context.Context, *Province) error
+	// Delete removes the province in the version given along with its localities
+	Delete(ctx context.Context, id int, version int) error
+	GetLocalities(ctx context.Context, id int) ([]Locality, error)
 }
@@ -1,6 +1,7 @@
 package province
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -9,24 +10,24 @@ import (
 )
 
 type MysqlProvinceRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewMysqlProvinceRepository(db *sql.DB) internal.ProvinceRepository {
+func NewMysqlProvinceRepository(db utils.DBTX) internal.ProvinceRepository {
 	return &MysqlProvinceRepository{db: db}
 }
 
-func (r *MysqlProvinceRepository) GetByName(name string) (internal.Province, error) {
-	stmt, err := r.db.Prepare("SELECT id, province_name, country_id FROM provinces WHERE province_name=?;")
+func (r *MysqlProvinceRepository) GetByName(ctx context.Context, name string) (internal.Province, error) {
+	stmt, err := r.db.PrepareContext(ctx, "SELECT id, province_name, country_id, version FROM provinces WHERE province_name=?;")
 	if err != nil {
 		return internal.Province{}, err
 	}
 
-	row := stmt.QueryRow(name)
+	row := stmt.QueryRowContext(ctx, name)
 
 	var province internal.Province
 
-	err = row.Scan(&province.ID, &province.ProvinceName, &province.CountryID)
+	err = row.Scan(&province.ID, &province.ProvinceName, &province.CountryID, &province.Version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return internal.Province{}, utils.ErrNotFound
@@ -38,13 +39,13 @@ func (r *MysqlProvinceRepository) GetByName(name string) (internal.Province, err
 	return province, nil
 }
 
-func (r *MysqlProvinceRepository) Save(province *internal.Province) error {
-	stmt, err := r.db.Prepare("INSERT INTO provinces(province_name, country_id) VALUES(?, ?);")
+func (r *MysqlProvinceRepository) Save(ctx context.Context, province *internal.Province) error {
+	stmt, err := r.db.PrepareContext(ctx, "INSERT INTO provinces(province_name, country_id) VALUES(?, ?);")
 	if err != nil {
 		return err
 	}
 
-	res, err := stmt.Exec(province.ProvinceName, province.CountryID)
+	res, err := stmt.ExecContext(ctx, province.ProvinceName, province.CountryID)
 	if err != nil {
 		return err
 	}
@@ -55,6 +56,116 @@ func (r *MysqlProvinceRepository) Save(province *internal.Province) error {
 	}
 
 	(*province).ID = int(id)
+	(*province).Version = 1
 
 	return nil
 }
+
+const selectProvinces = "SELECT id, province_name, country_id, version FROM provinces "
+
+func (r *MysqlProvinceRepository) GetAll(ctx context.Context) ([]internal.Province, error) {
+	rows, err := r.db.QueryContext(ctx, selectProvinces+"ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+
+	return scanProvinces(rows)
+}
+
+func (r *MysqlProvinceRepository) GetByCountryID(ctx context.Context, countryID int) ([]internal.Province, error) {
+	rows, err := r.db.QueryContext(ctx, selectProvinces+"WHERE country_id=? ORDER BY id;", countryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanProvinces(rows)
+}
+
+func (r *MysqlProvinceRepository) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	var province internal.Province
+
+	err := r.db.QueryRowContext(ctx, selectProvinces+"WHERE id=?;", id).Scan(&province.ID, &province.ProvinceName, &province.CountryID, &province.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.Province{}, utils.ErrNotFound
+		}
+
+		return internal.Province{}, err
+	}
+
+	return province, nil
+}
+
+// Update changes the province when it is still in the version it was read, and bumps its version
+func (r *MysqlProvinceRepository) Update(ctx context.Context, province *internal.Province) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE provinces SET province_name=?, country_id=?, version=version+1 WHERE id=? AND version=?;",
+		province.ProvinceName, province.CountryID, province.ID, province.Version)
+	if err != nil {
+		return utils.MapMySQLError(err)
+	}
+
+	if err = utils.CheckVersionUpdated(result, "province"); err != nil {
+		return err
+	}
+
+	province.Version++
+
+	return nil
+}
+
+// Delete removes the province and its localities in a single transaction, none of them when the
+// province is no longer in the version it was read
+func (r *MysqlProvinceRepository) Delete(ctx context.Context, id int, version int) error {
+	return utils.InTx(ctx, r.db, func(tx utils.DBTX) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM localities WHERE province_id=?;", id)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM provinces WHERE id=? AND version=?;", id, version)
+		if err != nil {
+			return utils.MapMySQLError(err)
+		}
+
+		return utils.CheckVersionUpdated(result, "province")
+	})
+}
+
+func (r *MysqlProvinceRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	var references internal.LocalityReferences
+
+	err := r.db.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(*) FROM sellers s INNER JOIN localities l ON l.id = s.locality_id WHERE l.province_id = ?),
+			(SELECT COUNT(*) FROM warehouses w INNER JOIN localities l ON l.id = w.locality_id WHERE l.province_id = ?),
+			(SELECT COUNT(*) FROM carriers c INNER JOIN localities l ON l.id = c.locality_id WHERE l.province_id = ?);`, id, id, id).
+		Scan(&references.Sellers, &references.Warehouses, &references.Carriers)
+	if err != nil {
+		return internal.LocalityReferences{}, err
+	}
+
+	return references, nil
+}
+
+func scanProvinces(rows *sql.Rows) ([]internal.Province, error) {
+	defer rows.Close()
+
+	provinces := []internal.Province{}
+
+	for rows.Next() {
+		var province internal.Province
+
+		err := rows.Scan(&province.ID, &province.ProvinceName, &province.CountryID, &province.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		provinces = append(provinces, province)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return provinces, nil
+}
@@ -1,6 +1,7 @@
 package province_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -32,7 +33,7 @@ func TestIntegrationProvince_GetByName(t *testing.T) {
 	repo := province.NewMysqlProvinceRepository(db)
 
 	t.Run("Given an existing name, return the province", func(t *testing.T) {
-		province, err := repo.GetByName("Ontario")
+		province, err := repo.GetByName(context.Background(), "Ontario")
 		require.NoError(t, err)
 		require.Equal(t, "Ontario", province.ProvinceName)
 		require.NotZero(t, province.CountryID)
@@ -40,7 +41,7 @@ func TestIntegrationProvince_GetByName(t *testing.T) {
 	})
 
 	t.Run("Given a not existing name, return empty province and utils.ErrNotFound", func(t *testing.T) {
-		province, err := repo.GetByName("Berlint")
+		province, err := repo.GetByName(context.Background(), "Berlint")
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Empty(t, province)
 	})
@@ -58,7 +59,7 @@ func TestIntegrationProvince_Save(t *testing.T) {
 			ProvinceName: "Westails",
 			CountryID:    2,
 		}
-		err := repo.Save(&newProvince)
+		err := repo.Save(context.Background(), &newProvince)
 		require.NoError(t, err)
 		require.Equal(t, "Westails", newProvince.ProvinceName)
 		require.NotZero(t, newProvince.CountryID)
@@ -0,0 +1,23 @@
+package province
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
+)
+
+func NewProvinceRoutes(mux *chi.Mux, service internal.ProvinceService) error {
+	provinceHandler := handler.NewProvinceHandler(service)
+
+	mux.Route("/api/v1/provinces", func(router chi.Router) {
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/", provinceHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Post("/", provinceHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/{id}", provinceHandler.GetByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionWrite))).Patch("/{id}", provinceHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionDelete))).Delete("/{id}", provinceHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceLocations, rbac.ActionRead))).Get("/{id}/localities", provinceHandler.GetLocalities())
+	})
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package province
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// auditEntity is the name of the provinces in the audit trail
+const auditEntity = "provinces"
+
+type BasicProvinceService struct {
+	provinceRepo internal.ProvinceRepository
+	countryRepo  internal.CountryRepository
+	localityRepo internal.LocalityRepository
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
+}
+
+func NewBasicProvinceService(
+	pr internal.ProvinceRepository,
+	cr internal.CountryRepository,
+	lr internal.LocalityRepository,
+	uow internal.UnitOfWork) internal.ProvinceService {
+	return &BasicProvinceService{
+		pr, cr, lr, uow,
+	}
+}
+
+// GetAll returns all the provinces
+func (s *BasicProvinceService) GetAll(ctx context.Context) ([]internal.Province, error) {
+	return s.provinceRepo.GetAll(ctx)
+}
+
+// GetByID returns a province, utils.ErrNotFound when it doesn't exist
+func (s *BasicProvinceService) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	province, err := s.provinceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.Province{}, utils.ENotFound("province")
+		}
+
+		return internal.Province{}, err
+	}
+
+	return province, nil
+}
+
+// Save creates a province of an existing country, its name must be unique
+func (s *BasicProvinceService) Save(ctx context.Context, province *internal.Province) error {
+	province.ProvinceName = strings.TrimSpace(province.ProvinceName)
+	if province.ProvinceName == "" {
+		return utils.EZeroValue("province_name")
+	}
+
+	if province.CountryID <= 0 {
+		return utils.EZeroValue("country_id")
+	}
+
+	if err := s.countryExists(ctx, province.CountryID); err != nil {
+		return err
+	}
+
+	if err := s.nameIsAvailable(ctx, province.ProvinceName, 0); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Provinces.Save(ctx, province); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, province.ID, nil, province)
+	})
+}
+
+// Update changes the name and/or the country of a province, zero values are kept
+// as they are. The province is updated with the stored values
+func (s *BasicProvinceService) Update(ctx context.Context, province *internal.Province) error {
+	current, err := s.GetByID(ctx, province.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("province", province.Version, current.Version); err != nil {
+		return err
+	}
+
+	province.Version = current.Version
+
+	province.ProvinceName = strings.TrimSpace(province.ProvinceName)
+	if province.ProvinceName == "" {
+		province.ProvinceName = current.ProvinceName
+	} else if err := s.nameIsAvailable(ctx, province.ProvinceName, province.ID); err != nil {
+		return err
+	}
+
+	if province.CountryID == 0 {
+		province.CountryID = current.CountryID
+	} else if err := s.countryExists(ctx, province.CountryID); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Provinces.Update(ctx, province); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, province.ID, current, province)
+	})
+}
+
+// Delete removes a province in the version given with its localities, refusing when sellers,
+// warehouses or carriers are located in any of them
+func (s *BasicProvinceService) Delete(ctx context.Context, id int, version int) error {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckVersion("province", version, current.Version); err != nil {
+		return err
+	}
+
+	references, err := s.provinceRepo.GetReferences(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if references.Total() > 0 {
+		return utils.EInUse("province", references.String())
+	}
+
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Provinces.Delete(ctx, id, current.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, current, nil)
+	})
+	if errors.Is(err, utils.ErrInUse) {
+		return utils.EInUse("province", "other entities")
+	}
+
+	return err
+}
+
+// GetLocalities returns the localities of a province
+func (s *BasicProvinceService) GetLocalities(ctx context.Context, id int) ([]internal.Locality, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.localityRepo.GetByProvinceID(ctx, id)
+}
+
+func (s *BasicProvinceService) countryExists(ctx context.Context, id int) error {
+	_, err := s.countryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return utils.EDependencyNotFound("country", "id: "+strconv.Itoa(id))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// nameIsAvailable checks no other province than id is named name
+func (s *BasicProvinceService) nameIsAvailable(ctx context.Context, name string, id int) error {
+	possibleProvince, err := s.provinceRepo.GetByName(ctx, name)
+	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+		return err
+	}
+
+	if err == nil && possibleProvince.ID != id {
+		return utils.EConflict("province", "province_name")
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package province_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/province"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(pr internal.ProvinceRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Provinces: pr, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
+type MockCountryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCountryRepository) Save(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) GetByName(ctx context.Context, name string) (internal.Country, error) {
+	args := m.Called(name)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) GetAll(ctx context.Context) ([]internal.Country, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) GetByID(ctx context.Context, id int) (internal.Country, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Country), args.Error(1)
+}
+
+func (m *MockCountryRepository) Update(ctx context.Context, country *internal.Country) error {
+	args := m.Called(country)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockCountryRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+type MockProvinceRepository struct {
+	mock.Mock
+}
+
+func (m *MockProvinceRepository) Save(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) GetByName(ctx context.Context, name string) (internal.Province, error) {
+	args := m.Called(name)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetAll(ctx context.Context) ([]internal.Province, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByID(ctx context.Context, id int) (internal.Province, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) GetByCountryID(ctx context.Context, countryID int) ([]internal.Province, error) {
+	args := m.Called(countryID)
+	return args.Get(0).([]internal.Province), args.Error(1)
+}
+
+func (m *MockProvinceRepository) Update(ctx context.Context, province *internal.Province) error {
+	args := m.Called(province)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockProvinceRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+type MockLocalityRepository struct {
+	mock.Mock
+}
+
+func (m *MockLocalityRepository) Save(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByID(ctx context.Context, id int) (internal.Locality, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetAll(ctx context.Context) ([]internal.Locality, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceID(ctx context.Context, provinceID int) ([]internal.Locality, error) {
+	args := m.Called(provinceID)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetByProvinceIDs(ctx context.Context, provinceIDs []int) ([]internal.Locality, error) {
+	args := m.Called(provinceIDs)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) Update(ctx context.Context, locality *internal.Locality) error {
+	args := m.Called(locality)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *MockLocalityRepository) GetReferences(ctx context.Context, id int) (internal.LocalityReferences, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.LocalityReferences), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetSellersByLocalityID(ctx context.Context, localityID int) ([]internal.SellersByLocality, error) {
+	args := m.Called(localityID)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetCarriesByLocalityID(ctx context.Context, localityID int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(localityID)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetSellersByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.SellersByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.SellersByLocality), args.Error(1)
+}
+
+func (m *MockLocalityRepository) GetCarriesByLocalityPage(ctx context.Context, afterLocalityID, limit int) ([]internal.CarriesByLocality, error) {
+	args := m.Called(afterLocalityID, limit)
+	return args.Get(0).([]internal.CarriesByLocality), args.Error(1)
+}
+
+func TestUnitProvince_Save(t *testing.T) {
+	t.Run("given an existing country and a new name, save the province", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		pr.On("GetByName", "Cordoba").Return(internal.Province{}, utils.ErrNotFound)
+		pr.On("Save", &internal.Province{ProvinceName: "Cordoba", CountryID: 1}).Return(nil)
+		service := province.NewBasicProvinceService(pr, cr, new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		require.NoError(t, service.Save(context.Background(), &internal.Province{ProvinceName: "Cordoba", CountryID: 1}))
+	})
+
+	t.Run("given a country that doesn't exist, return utils.ErrInvalidArguments", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 99).Return(internal.Country{}, utils.ErrNotFound)
+		service := province.NewBasicProvinceService(pr, cr, new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Save(context.Background(), &internal.Province{ProvinceName: "Cordoba", CountryID: 99})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+		pr.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("given no country, return utils.ErrInvalidArguments", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		service := province.NewBasicProvinceService(pr, new(MockCountryRepository), new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Save(context.Background(), &internal.Province{ProvinceName: "Cordoba"})
+		require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("given a taken name, return utils.ErrConflict", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		cr.On("GetByID", 1).Return(internal.Country{ID: 1, CountryName: "Argentina"}, nil)
+		pr.On("GetByName", "Cordoba").Return(internal.Province{ID: 3, ProvinceName: "Cordoba", CountryID: 1}, nil)
+		service := province.NewBasicProvinceService(pr, cr, new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Save(context.Background(), &internal.Province{ProvinceName: "Cordoba", CountryID: 1})
+		require.ErrorIs(t, err, utils.ErrConflict)
+	})
+}
+
+func TestUnitProvince_Update(t *testing.T) {
+	stored := internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1}
+
+	t.Run("given only a new country, keep the name and update", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		cr := new(MockCountryRepository)
+		pr.On("GetByID", 1).Return(stored, nil)
+		cr.On("GetByID", 2).Return(internal.Country{ID: 2, CountryName: "Chile"}, nil)
+		pr.On("Update", &internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 2}).Return(nil)
+		service := province.NewBasicProvinceService(pr, cr, new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		updated := internal.Province{ID: 1, CountryID: 2}
+		require.NoError(t, service.Update(context.Background(), &updated))
+		require.Equal(t, "Buenos Aires", updated.ProvinceName)
+		pr.AssertNotCalled(t, "GetByName", mock.Anything)
+	})
+
+	t.Run("given a name taken by another province, return utils.ErrConflict", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		pr.On("GetByID", 1).Return(stored, nil)
+		pr.On("GetByName", "Cordoba").Return(internal.Province{ID: 3, ProvinceName: "Cordoba", CountryID: 1}, nil)
+		service := province.NewBasicProvinceService(pr, new(MockCountryRepository), new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Update(context.Background(), &internal.Province{ID: 1, ProvinceName: "Cordoba"})
+		require.ErrorIs(t, err, utils.ErrConflict)
+	})
+}
+
+func TestUnitProvince_Delete(t *testing.T) {
+	stored := internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1, Version: 2}
+
+	t.Run("given a province without references, delete it", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		pr.On("GetByID", 1).Return(stored, nil)
+		pr.On("GetReferences", 1).Return(internal.LocalityReferences{}, nil)
+		pr.On("Delete", 1, 2).Return(nil)
+		service := province.NewBasicProvinceService(pr, new(MockCountryRepository), new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		require.NoError(t, service.Delete(context.Background(), 1, utils.AnyVersion))
+	})
+
+	t.Run("given a province in another version, return utils.ErrPreconditionFailed", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		pr.On("GetByID", 1).Return(stored, nil)
+		service := province.NewBasicProvinceService(pr, new(MockCountryRepository), new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Delete(context.Background(), 1, 1)
+		require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		pr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("given a province with carriers, return utils.ErrInUse", func(t *testing.T) {
+		pr := new(MockProvinceRepository)
+		pr.On("GetByID", 1).Return(stored, nil)
+		pr.On("GetReferences", 1).Return(internal.LocalityReferences{Carriers: 3}, nil)
+		service := province.NewBasicProvinceService(pr, new(MockCountryRepository), new(MockLocalityRepository), newMockUnitOfWork(pr))
+
+		err := service.Delete(context.Background(), 1, 2)
+		require.ErrorIs(t, err, utils.ErrInUse)
+		pr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnitProvince_GetLocalities(t *testing.T) {
+	pr := new(MockProvinceRepository)
+	lr := new(MockLocalityRepository)
+	pr.On("GetByID", 1).Return(internal.Province{ID: 1, ProvinceName: "Buenos Aires", CountryID: 1}, nil)
+	pr.On("GetByID", 9).Return(internal.Province{}, utils.ErrNotFound)
+	lr.On("GetByProvinceID", 1).Return([]internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}}, nil)
+	service := province.NewBasicProvinceService(pr, new(MockCountryRepository), lr, newMockUnitOfWork(pr))
+
+	localities, err := service.GetLocalities(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []internal.Locality{{ID: 6700, LocalityName: "Lujan", ProvinceID: 1}}, localities)
+
+	_, err = service.GetLocalities(context.Background(), 9)
+	require.ErrorIs(t, err, utils.ErrNotFound)
+}
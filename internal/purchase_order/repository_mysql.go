@@ -1,28 +1,28 @@
 package purchase_order
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
 type PurchaseOrderRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewPurchaseOrderDB(db *sql.DB) *PurchaseOrderRepository {
+func NewPurchaseOrderDB(db utils.DBTX) *PurchaseOrderRepository {
 	return &PurchaseOrderRepository{db}
 }
 
 // FindAll retrieves all purchase orders
-func (repo *PurchaseOrderRepository) FindAll() ([]internal.PurchaseOrder, error) {
+func (repo *PurchaseOrderRepository) FindAll(ctx context.Context) ([]internal.PurchaseOrder, error) {
 	query := `
-		SELECT po.id, po.order_number, po.tracking_code, po.order_date, po.buyer_id
+		SELECT po.id, po.order_number, po.tracking_code, ` + utils.DateTime("po.order_date") + `, po.buyer_id
 		FROM purchase_orders po
 		INNER JOIN buyers b ON po.buyer_id = b.id`
 
-	rows, err := repo.db.Query(query)
+	rows, err := repo.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -44,31 +44,29 @@ func (repo *PurchaseOrderRepository) FindAll() ([]internal.PurchaseOrder, error)
 	return purchaseOrders, rows.Err()
 }
 
-// FindAllByBuyerID retrieves all purchase orders by buyer id
-func (repo *PurchaseOrderRepository) FindAllByBuyerID(buyerID int) ([]internal.PurchaseOrderSummary, error) {
-	var query string
-
-	var rows *sql.Rows
+// FindAllByBuyerID retrieves the purchase orders summary of every buyer, or only of buyerID when it is not zero.
+// A buyer only gets its own summary. The orders are read one per row and summed up by buyer, the order codes
+// in the order they were placed
+func (repo *PurchaseOrderRepository) FindAllByBuyerID(ctx context.Context, buyerID int) ([]internal.PurchaseOrderSummary, error) {
+	query := `
+		SELECT po.buyer_id, po.order_number, IFNULL(pr.sale_price, 0) AS sale_price
+		FROM purchase_orders po
+		INNER JOIN buyers b ON po.buyer_id = b.id
+		LEFT JOIN product_records pr ON po.product_record_id = pr.id
+		WHERE ` + internal.BuyerCondition(ctx, "po.buyer_id")
 
-	var err error
+	var args []any
 
 	if buyerID != 0 {
-		query = `
-			SELECT po.buyer_id, COUNT(po.id) AS total_orders, GROUP_CONCAT(po.order_number ORDER BY po.order_date) AS order_codes
-			FROM purchase_orders po
-			INNER JOIN buyers b ON po.buyer_id = b.id
-			WHERE po.buyer_id = ?
-			GROUP BY po.buyer_id`
-		rows, err = repo.db.Query(query, buyerID)
-	} else {
-		query = `
-			SELECT po.buyer_id, COUNT(po.id) AS total_orders, GROUP_CONCAT(po.order_number ORDER BY po.order_date) AS order_codes
-			FROM purchase_orders po
-			INNER JOIN buyers b ON po.buyer_id = b.id
-			GROUP BY po.buyer_id`
-		rows, err = repo.db.Query(query)
+		query += ` AND po.buyer_id = ?`
+
+		args = append(args, buyerID)
 	}
 
+	query += `
+		ORDER BY po.buyer_id, po.order_date, po.id`
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -78,28 +76,110 @@ func (repo *PurchaseOrderRepository) FindAllByBuyerID(buyerID int) ([]internal.P
 	var purchaseOrders []internal.PurchaseOrderSummary
 
 	for rows.Next() {
-		var summary internal.PurchaseOrderSummary
+		var (
+			orderBuyerID int
+			orderNumber  string
+			salePrice    float64
+		)
 
-		err := rows.Scan(&summary.BuyerID, &summary.TotalOrders, &summary.OrderCodes)
+		err := rows.Scan(&orderBuyerID, &orderNumber, &salePrice)
 		if err != nil {
 			return nil, err
 		}
 
-		purchaseOrders = append(purchaseOrders, summary)
+		// the rows are sorted by buyer, a new buyer starts a new summary
+		if len(purchaseOrders) == 0 || purchaseOrders[len(purchaseOrders)-1].BuyerID != orderBuyerID {
+			purchaseOrders = append(purchaseOrders, internal.PurchaseOrderSummary{BuyerID: orderBuyerID, OrderCodes: []string{}})
+		}
+
+		summary := &purchaseOrders[len(purchaseOrders)-1]
+		summary.TotalOrders++
+		summary.OrderCodes = append(summary.OrderCodes, orderNumber)
+		summary.TotalSpent += salePrice
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
 	if len(purchaseOrders) == 0 {
 		return nil, utils.ErrNotFound
 	}
 
-	return purchaseOrders, rows.Err()
+	return purchaseOrders, nil
+}
+
+// FindDetailsByBuyerID retrieves a page of purchase orders of a buyer, with status, product and prices,
+// along with the total number of orders matching the filter
+func (repo *PurchaseOrderRepository) FindDetailsByBuyerID(ctx context.Context, filter internal.PurchaseOrderFilter) ([]internal.PurchaseOrderDetail, int, error) {
+	where := " WHERE po.buyer_id = ? AND " + internal.BuyerCondition(ctx, "po.buyer_id")
+	args := []any{filter.BuyerID}
+
+	if filter.DateFrom != "" {
+		where += " AND po.order_date >= ?"
+
+		args = append(args, filter.DateFrom)
+	}
+
+	if filter.DateTo != "" {
+		where += " AND po.order_date < DATE_ADD(?, INTERVAL 1 DAY)"
+
+		args = append(args, filter.DateTo)
+	}
+
+	// the orders are counted with the joins of the page, so the ones without their product record or
+	// product are not counted either
+	from := `
+		FROM purchase_orders po
+		INNER JOIN product_records pr ON po.product_record_id = pr.id
+		INNER JOIN products p ON pr.product_id = p.id`
+
+	var total int
+
+	err := repo.db.QueryRowContext(ctx, "SELECT COUNT(po.id)"+from+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT po.id, po.order_number, ` + utils.DateTime("po.order_date") + `, po.tracking_code, IFNULL(os.description, ''),
+			p.id, p.product_code, p.description, pr.id, pr.purchase_price, pr.sale_price` + from + `
+		LEFT JOIN order_status os ON po.order_status_id = os.id` + where + `
+		ORDER BY po.order_date DESC, po.id DESC
+		LIMIT ? OFFSET ?`
+
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	orders := []internal.PurchaseOrderDetail{}
+
+	for rows.Next() {
+		var order internal.PurchaseOrderDetail
+
+		err := rows.Scan(&order.ID, &order.OrderNumber, &order.OrderDate, &order.TrackingCode, &order.Status,
+			&order.Product.ID, &order.Product.ProductCode, &order.Product.Description, &order.Product.ProductRecordID,
+			&order.PurchasePrice, &order.SalePrice)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, total, rows.Err()
 }
 
 // CreatePurchaseOrder adds a new purchase order
-func (repo *PurchaseOrderRepository) CreatePurchaseOrder(newOrder internal.PurchaseOrderAttributes) (internal.PurchaseOrder, error) {
+func (repo *PurchaseOrderRepository) CreatePurchaseOrder(ctx context.Context, newOrder internal.PurchaseOrderAttributes) (internal.PurchaseOrder, error) {
 	query := "INSERT INTO purchase_orders (order_number, order_date, tracking_code, buyer_id, product_record_id) VALUES (?, ?, ?, ?, ?)"
 
-	result, err := repo.db.Exec(query, newOrder.OrderNumber, newOrder.OrderDate, newOrder.TrackingCode, newOrder.BuyerID, newOrder.ProductRecordID)
+	result, err := repo.db.ExecContext(ctx, query, newOrder.OrderNumber, newOrder.OrderDate, newOrder.TrackingCode, newOrder.BuyerID, newOrder.ProductRecordID)
 	if err != nil {
 		return internal.PurchaseOrder{}, err
 	}
@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 // RegisterPurchaseOrdersRoutes is used to record the routes associated to the purchase orders entity
@@ -12,9 +13,10 @@ func RegisterPurchaseOrdersRoutes(mux *chi.Mux, service internal.PurchaseOrderSe
 
 	mux.Route("/api/v1/purchaseOrders", func(router chi.Router) {
 		// Post
-		router.Post("/", purchaseOrdersHandler.PostPurchaseOrders())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourcePurchaseOrders, rbac.ActionWrite))).Post("/", purchaseOrdersHandler.PostPurchaseOrders())
 	})
-	mux.HandleFunc("/api/v1/buyers/reportPurchaseOrders", purchaseOrdersHandler.GetAllPurchaseOrders())
+	mux.With(rbac.Require(rbac.Permission(rbac.ResourcePurchaseOrders, rbac.ActionRead))).HandleFunc("/api/v1/buyers/reportPurchaseOrders", purchaseOrdersHandler.GetAllPurchaseOrders())
+	mux.With(rbac.Require(rbac.Permission(rbac.ResourcePurchaseOrders, rbac.ActionRead))).Get("/api/v1/buyers/{id}/purchaseOrders", purchaseOrdersHandler.GetPurchaseOrdersByBuyerID())
 
 	return nil
 }
@@ -1,29 +1,45 @@
 package purchase_order
 
 import (
+	"context"
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the purchase orders in the audit trail
+const auditEntity = "purchaseOrders"
+
+const (
+	// DefaultPageSize is the page size used when listing purchase orders without one
+	DefaultPageSize = 20
+	// MaxPageSize is the biggest page size allowed when listing purchase orders
+	MaxPageSize = 100
+)
+
 // PurchaseOrderDefault is the default implementation of the PurchaseOrder service
 // it handles business logic and delegates data operations to the repository
 type PurchaseOrderDefault struct {
 	rp                   internal.PurchaseOrderRepository
 	buyerService         internal.PurchaseOrdersBuyerValidation
 	productRecordService internal.PurchaseOrdersProductRecordValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
 // NewPurchaseOrderService creates a new instance of PurchaseOrderDefault
 // takes an PurchaseOrderRepository as a parameter to handle data operations
-func NewPurchaseOrderService(rp internal.PurchaseOrderRepository, buyerService internal.PurchaseOrdersBuyerValidation, productRecordService internal.PurchaseOrdersProductRecordValidation) *PurchaseOrderDefault {
-	return &PurchaseOrderDefault{rp: rp, buyerService: buyerService, productRecordService: productRecordService}
+func NewPurchaseOrderService(rp internal.PurchaseOrderRepository, buyerService internal.PurchaseOrdersBuyerValidation, productRecordService internal.PurchaseOrdersProductRecordValidation, uow internal.UnitOfWork) *PurchaseOrderDefault {
+	return &PurchaseOrderDefault{rp: rp, buyerService: buyerService, productRecordService: productRecordService, uow: uow}
 }
 
 // FindAllByBuyerID retrieves all PurchaseOrders from the repository
-func (s *PurchaseOrderDefault) FindAllByBuyerID(buyerID int) ([]internal.PurchaseOrderSummary, error) {
-	purchaseOrdersSummary, err := s.rp.FindAllByBuyerID(buyerID)
+func (s *PurchaseOrderDefault) FindAllByBuyerID(ctx context.Context, buyerID int) ([]internal.PurchaseOrderSummary, error) {
+	purchaseOrdersSummary, err := s.rp.FindAllByBuyerID(ctx, buyerID)
 	if err != nil {
 		return nil, err
 	}
@@ -31,8 +47,85 @@ func (s *PurchaseOrderDefault) FindAllByBuyerID(buyerID int) ([]internal.Purchas
 	return purchaseOrdersSummary, nil
 }
 
+// FindDetailsByBuyerID retrieves a page of the purchase orders of a buyer, optionally filtered by order date
+func (s *PurchaseOrderDefault) FindDetailsByBuyerID(ctx context.Context, filter internal.PurchaseOrderFilter) (page internal.PurchaseOrderPage, err error) {
+	err = s.validateFilter(&filter)
+	if err != nil {
+		return
+	}
+
+	// verify if buyer_id exists
+	err = s.buyerExistsByID(ctx, filter.BuyerID)
+	if err != nil {
+		return
+	}
+
+	orders, total, err := s.rp.FindDetailsByBuyerID(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	page = internal.PurchaseOrderPage{
+		Data:     orders,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Total:    total,
+	}
+
+	return
+}
+
+// validateFilter checks the date range and fills the pagination defaults of a purchase order filter
+func (s *PurchaseOrderDefault) validateFilter(filter *internal.PurchaseOrderFilter) error {
+	var dateFrom, dateTo time.Time
+
+	var err error
+
+	if filter.DateFrom != "" {
+		dateFrom, err = time.Parse(time.DateOnly, filter.DateFrom)
+		if err != nil {
+			return utils.EBadRequest("date_from")
+		}
+	}
+
+	if filter.DateTo != "" {
+		dateTo, err = time.Parse(time.DateOnly, filter.DateTo)
+		if err != nil {
+			return utils.EBadRequest("date_to")
+		}
+	}
+
+	if filter.DateFrom != "" && filter.DateTo != "" && dateFrom.After(dateTo) {
+		return utils.EBR("date_from cannot be after date_to")
+	}
+
+	if filter.Page < 0 || filter.PageSize < 0 || filter.PageSize > MaxPageSize {
+		return utils.EBR("page must be positive and page_size must be between 1 and " + strconv.Itoa(MaxPageSize))
+	}
+
+	if filter.Page == 0 {
+		filter.Page = 1
+	}
+
+	if filter.PageSize == 0 {
+		filter.PageSize = DefaultPageSize
+	}
+
+	return nil
+}
+
 // CreatePurchaseOrder adds a new purchaseOrder to the repository
-func (s *PurchaseOrderDefault) CreatePurchaseOrder(newPurchaseOrder internal.PurchaseOrderAttributes) (purchaseOrder internal.PurchaseOrder, err error) {
+func (s *PurchaseOrderDefault) CreatePurchaseOrder(ctx context.Context, newPurchaseOrder internal.PurchaseOrderAttributes) (purchaseOrder internal.PurchaseOrder, err error) {
+	// a buyer places its own purchase orders, the buyer_id can be left out
+	if buyerID, scoped := internal.BuyerScope(ctx); scoped && newPurchaseOrder.BuyerID == 0 {
+		newPurchaseOrder.BuyerID = buyerID
+	}
+
+	err = internal.CheckBuyerScope(ctx, newPurchaseOrder.BuyerID)
+	if err != nil {
+		return
+	}
+
 	// validate required fields
 	err = s.validateFields(newPurchaseOrder)
 	if err != nil {
@@ -40,7 +133,7 @@ func (s *PurchaseOrderDefault) CreatePurchaseOrder(newPurchaseOrder internal.Pur
 	}
 
 	// check for duplicates
-	purchaseOrders, _ := s.rp.FindAll()
+	purchaseOrders, _ := s.rp.FindAll(ctx)
 
 	err = s.validateDuplicates(purchaseOrders, newPurchaseOrder)
 	if err != nil {
@@ -48,19 +141,35 @@ func (s *PurchaseOrderDefault) CreatePurchaseOrder(newPurchaseOrder internal.Pur
 	}
 
 	// verify if buyer_id exists
-	err = s.buyerExistsByID(newPurchaseOrder.BuyerID)
+	err = s.buyerExistsByID(ctx, newPurchaseOrder.BuyerID)
+	if errors.Is(err, utils.ErrNotFound) {
+		err = utils.EDependencyNotFound("buyer", "id: "+strconv.Itoa(newPurchaseOrder.BuyerID))
+	}
+
 	if err != nil {
 		return
 	}
 
 	// verify if product_record_id exists
-	err = s.productRecordExistsByID(newPurchaseOrder.ProductRecordID)
+	err = s.productRecordExistsByID(ctx, newPurchaseOrder.ProductRecordID)
 	if err != nil {
 		return
 	}
 
-	// attempt to create the new purchaseOrder
-	return s.rp.CreatePurchaseOrder(newPurchaseOrder)
+	// attempt to create the new purchaseOrder along with its audit entry
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		purchaseOrder, err = repos.PurchaseOrders.CreatePurchaseOrder(ctx, newPurchaseOrder)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, purchaseOrder.ID, nil, purchaseOrder)
+	})
+	if err != nil {
+		return internal.PurchaseOrder{}, err
+	}
+
+	return purchaseOrder, nil
 }
 
 // validateFields checks if the required fields of a new purchaseOrder are not empty
@@ -83,23 +192,23 @@ func (s *PurchaseOrderDefault) validateDuplicates(purchaseOrders []internal.Purc
 	return nil
 }
 
-func (s *PurchaseOrderDefault) buyerExistsByID(id int) error {
-	possibleBuyer, err := s.buyerService.GetOne(id)
+// buyerExistsByID returns utils.ErrNotFound when there is no buyer with the id
+func (s *PurchaseOrderDefault) buyerExistsByID(ctx context.Context, id int) error {
+	possibleBuyer, err := s.buyerService.GetOne(ctx, id)
 	// When internal server error
-	if err != nil && err != utils.ErrNotFound {
+	if err != nil && !errors.Is(err, utils.ErrNotFound) {
 		return err
 	}
 
-	idStr := strconv.Itoa(id)
 	if possibleBuyer == nil {
-		return utils.EDependencyNotFound("buyer", "id: "+idStr)
+		return utils.ENotFound("buyer")
 	}
 
 	return nil
 }
 
-func (s *PurchaseOrderDefault) productRecordExistsByID(id int) error {
-	product, err := s.productRecordService.FindByID(id)
+func (s *PurchaseOrderDefault) productRecordExistsByID(ctx context.Context, id int) error {
+	product, err := s.productRecordService.FindByID(ctx, id)
 
 	idStr := strconv.Itoa(id)
 	if err != nil {
@@ -1,6 +1,7 @@
 package purchase_order
 
 import (
+	"context"
 	"testing"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
@@ -9,21 +10,59 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.PurchaseOrderRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{PurchaseOrders: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type mockPurchaseOrderRepository struct {
 	mock.Mock
 }
 
-func (m *mockPurchaseOrderRepository) FindAll() ([]internal.PurchaseOrder, error) {
+func (m *mockPurchaseOrderRepository) FindAll(ctx context.Context) ([]internal.PurchaseOrder, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.PurchaseOrder), args.Error(1)
 }
 
-func (m *mockPurchaseOrderRepository) FindAllByBuyerID(buyerID int) ([]internal.PurchaseOrderSummary, error) {
+func (m *mockPurchaseOrderRepository) FindAllByBuyerID(ctx context.Context, buyerID int) ([]internal.PurchaseOrderSummary, error) {
 	args := m.Called(buyerID)
 	return args.Get(0).([]internal.PurchaseOrderSummary), args.Error(1)
 }
 
-func (m *mockPurchaseOrderRepository) CreatePurchaseOrder(newPurchaseOrder internal.PurchaseOrderAttributes) (purchaseOrder internal.PurchaseOrder, err error) {
+func (m *mockPurchaseOrderRepository) FindDetailsByBuyerID(ctx context.Context, filter internal.PurchaseOrderFilter) ([]internal.PurchaseOrderDetail, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]internal.PurchaseOrderDetail), args.Int(1), args.Error(2)
+}
+
+func (m *mockPurchaseOrderRepository) CreatePurchaseOrder(ctx context.Context, newPurchaseOrder internal.PurchaseOrderAttributes) (purchaseOrder internal.PurchaseOrder, err error) {
 	args := m.Called(newPurchaseOrder)
 	return args.Get(0).(internal.PurchaseOrder), args.Error(1)
 }
@@ -32,7 +71,7 @@ type mockPurchaseOrderBuyerValidation struct {
 	mock.Mock
 }
 
-func (m *mockPurchaseOrderBuyerValidation) GetOne(id int) (*internal.Buyer, error) {
+func (m *mockPurchaseOrderBuyerValidation) GetOne(ctx context.Context, id int) (*internal.Buyer, error) {
 	args := m.Called(id)
 	return args.Get(0).(*internal.Buyer), args.Error(1)
 }
@@ -41,7 +80,7 @@ type mockPurchaseOrderProductRecordValidation struct {
 	mock.Mock
 }
 
-func (m *mockPurchaseOrderProductRecordValidation) FindByID(productRecordID int) (internal.ProductRecords, error) {
+func (m *mockPurchaseOrderProductRecordValidation) FindByID(ctx context.Context, productRecordID int) (internal.ProductRecords, error) {
 	args := m.Called(productRecordID)
 	return args.Get(0).(internal.ProductRecords), args.Error(1)
 }
@@ -87,9 +126,10 @@ var (
 			ProductRecordID: 1,
 		}}
 	mockPurchaseOrderSummary = internal.PurchaseOrderSummary{
-		OrderCodes:  "order#1",
+		OrderCodes:  []string{"order#1"},
 		TotalOrders: 1,
 		BuyerID:     1,
+		TotalSpent:  15.00,
 	}
 	mockBuyer = internal.Buyer{
 		ID: 1,
@@ -113,10 +153,10 @@ func TestPurchaseOrdersService_FindAll(t *testing.T) {
 	mockRepo := new(mockPurchaseOrderRepository)
 	mockBV := new(mockPurchaseOrderBuyerValidation)
 	mockPRV := new(mockPurchaseOrderProductRecordValidation)
-	service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV)
+	service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
 	t.Run("FindAllByBuyerID - Valid ID", func(t *testing.T) {
 		mockRepo.On("FindAllByBuyerID", 1).Return([]internal.PurchaseOrderSummary{mockPurchaseOrderSummary}, nil)
-		result, err := service.FindAllByBuyerID(1)
+		result, err := service.FindAllByBuyerID(context.Background(), 1)
 
 		assert.Equal(t, []internal.PurchaseOrderSummary{mockPurchaseOrderSummary}, result)
 		assert.Nil(t, err)
@@ -124,7 +164,7 @@ func TestPurchaseOrdersService_FindAll(t *testing.T) {
 
 	t.Run("FindAllByBuyerID - Invalid ID", func(t *testing.T) {
 		mockRepo.On("FindAllByBuyerID", 99).Return([]internal.PurchaseOrderSummary{}, utils.ErrNotFound)
-		result, err := service.FindAllByBuyerID(99)
+		result, err := service.FindAllByBuyerID(context.Background(), 99)
 
 		assert.Equal(t, []internal.PurchaseOrderSummary(nil), result)
 		assert.Equal(t, utils.ErrNotFound, err)
@@ -132,7 +172,7 @@ func TestPurchaseOrdersService_FindAll(t *testing.T) {
 
 	t.Run("FindAll - Success", func(t *testing.T) {
 		mockRepo.On("FindAllByBuyerID", 0).Return([]internal.PurchaseOrderSummary{mockPurchaseOrderSummary}, nil)
-		result, err := service.FindAllByBuyerID(0)
+		result, err := service.FindAllByBuyerID(context.Background(), 0)
 
 		assert.Equal(t, []internal.PurchaseOrderSummary{mockPurchaseOrderSummary}, result)
 		assert.Nil(t, err)
@@ -144,31 +184,47 @@ func TestPurchaseOrdersService_Create(t *testing.T) {
 		mockRepo := new(mockPurchaseOrderRepository)
 		mockBV := new(mockPurchaseOrderBuyerValidation)
 		mockPRV := new(mockPurchaseOrderProductRecordValidation)
-		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
 
 		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
 		mockPRV.On("FindByID", 1).Return(mockProductRecord, nil)
 		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{mockPurchaseOrder2}, nil)
 		mockRepo.On("CreatePurchaseOrder", mockNewPurchaseOrder).Return(mockPurchaseOrder, nil)
 
-		result, err := service.CreatePurchaseOrder(mockNewPurchaseOrder)
+		result, err := service.CreatePurchaseOrder(context.Background(), mockNewPurchaseOrder)
 
 		assert.Equal(t, mockPurchaseOrder, result)
 		assert.Nil(t, err)
 	})
 
+	t.Run("Create - Buyer not found", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		mockBV.On("GetOne", 1).Return((*internal.Buyer)(nil), nil)
+		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{mockPurchaseOrder2}, nil)
+
+		_, err := service.CreatePurchaseOrder(context.Background(), mockNewPurchaseOrder)
+
+		assert.ErrorIs(t, err, utils.ErrInvalidArguments)
+		assert.NotErrorIs(t, err, utils.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "CreatePurchaseOrder", mock.Anything)
+	})
+
 	t.Run("Create - Conflict", func(t *testing.T) {
 		mockRepo := new(mockPurchaseOrderRepository)
 		mockBV := new(mockPurchaseOrderBuyerValidation)
 		mockPRV := new(mockPurchaseOrderProductRecordValidation)
-		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
 
 		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
 		mockPRV.On("FindByID", 1).Return(mockProductRecord, nil)
 		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{}, utils.ErrConflict)
 		mockRepo.On("CreatePurchaseOrder", mockNewPurchaseOrder).Return(internal.PurchaseOrder{}, utils.ErrConflict)
 
-		result, err := service.CreatePurchaseOrder(mockNewPurchaseOrder)
+		result, err := service.CreatePurchaseOrder(context.Background(), mockNewPurchaseOrder)
 
 		assert.Equal(t, internal.PurchaseOrder{}, result)
 		assert.Equal(t, utils.ErrConflict, err)
@@ -178,14 +234,14 @@ func TestPurchaseOrdersService_Create(t *testing.T) {
 		mockRepo := new(mockPurchaseOrderRepository)
 		mockBV := new(mockPurchaseOrderBuyerValidation)
 		mockPRV := new(mockPurchaseOrderProductRecordValidation)
-		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
 
 		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
 		mockPRV.On("FindByID", 1).Return(mockProductRecord, nil)
 		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{mockPurchaseOrder2}, nil)
 		mockRepo.On("CreatePurchaseOrder", internal.PurchaseOrderAttributes{}).Return(internal.PurchaseOrder{}, nil)
 
-		result, err := service.CreatePurchaseOrder(internal.PurchaseOrderAttributes{})
+		result, err := service.CreatePurchaseOrder(context.Background(), internal.PurchaseOrderAttributes{})
 
 		assert.Equal(t, internal.PurchaseOrder{}, result)
 		assert.Equal(t, utils.ErrEmptyArguments, err)
@@ -195,16 +251,130 @@ func TestPurchaseOrdersService_Create(t *testing.T) {
 		mockRepo := new(mockPurchaseOrderRepository)
 		mockBV := new(mockPurchaseOrderBuyerValidation)
 		mockPRV := new(mockPurchaseOrderProductRecordValidation)
-		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
 
 		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
 		mockPRV.On("FindByID", 99).Return(internal.ProductRecords{}, utils.ErrNotFound)
 		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{mockPurchaseOrder2}, nil)
 		mockRepo.On("CreatePurchaseOrder", mockInvalidNewPurchaseOrder).Return(internal.PurchaseOrder{}, utils.ErrNotFound)
 
-		result, err := service.CreatePurchaseOrder(mockInvalidNewPurchaseOrder)
+		result, err := service.CreatePurchaseOrder(context.Background(), mockInvalidNewPurchaseOrder)
 
 		assert.Equal(t, internal.PurchaseOrder{}, result)
 		assert.Equal(t, utils.EDependencyNotFound("product", "id: "+"99"), err)
 	})
+
+	t.Run("Create - Buyer Of The User", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
+		mockPRV.On("FindByID", 1).Return(mockProductRecord, nil)
+		mockRepo.On("FindAll").Return([]internal.PurchaseOrder{mockPurchaseOrder2}, nil)
+		mockRepo.On("CreatePurchaseOrder", mockNewPurchaseOrder).Return(mockPurchaseOrder, nil)
+
+		buyer := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 5, Role: internal.RoleBuyer, BuyerID: 1})
+		newPurchaseOrder := mockNewPurchaseOrder
+		newPurchaseOrder.BuyerID = 0
+
+		result, err := service.CreatePurchaseOrder(buyer, newPurchaseOrder)
+
+		assert.Equal(t, mockPurchaseOrder, result)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Create - Another Buyer", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		service := NewPurchaseOrderService(mockRepo, new(mockPurchaseOrderBuyerValidation), new(mockPurchaseOrderProductRecordValidation), newMockUnitOfWork(mockRepo))
+
+		buyer := internal.WithPrincipal(context.Background(), internal.Principal{UserID: 5, Role: internal.RoleBuyer, BuyerID: 2})
+
+		_, err := service.CreatePurchaseOrder(buyer, mockNewPurchaseOrder)
+
+		assert.ErrorIs(t, err, utils.ErrForbidden)
+		mockRepo.AssertNotCalled(t, "CreatePurchaseOrder", mockNewPurchaseOrder)
+	})
+}
+
+func TestPurchaseOrdersService_FindDetailsByBuyerID(t *testing.T) {
+	mockDetail := internal.PurchaseOrderDetail{
+		ID:           1,
+		OrderNumber:  "order#101",
+		OrderDate:    "2021-04-04 00:00:00",
+		TrackingCode: "abscf1234",
+		Status:       "Pending",
+		Product:      internal.PurchaseOrderProduct{ID: 1, ProductCode: "PA001", Description: "Fresh Apples", ProductRecordID: 1},
+		SalePrice:    15.00,
+	}
+
+	t.Run("FindDetailsByBuyerID - Success with defaults", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		expectedFilter := internal.PurchaseOrderFilter{BuyerID: 1, DateFrom: "2021-01-01", Page: 1, PageSize: DefaultPageSize}
+
+		mockBV.On("GetOne", 1).Return(&mockBuyer, nil)
+		mockRepo.On("FindDetailsByBuyerID", expectedFilter).Return([]internal.PurchaseOrderDetail{mockDetail}, 1, nil)
+
+		result, err := service.FindDetailsByBuyerID(context.Background(), internal.PurchaseOrderFilter{BuyerID: 1, DateFrom: "2021-01-01"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, internal.PurchaseOrderPage{
+			Data:     []internal.PurchaseOrderDetail{mockDetail},
+			Page:     1,
+			PageSize: DefaultPageSize,
+			Total:    1,
+		}, result)
+	})
+
+	t.Run("FindDetailsByBuyerID - Buyer not found", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		mockBV.On("GetOne", 99).Return((*internal.Buyer)(nil), nil)
+
+		_, err := service.FindDetailsByBuyerID(context.Background(), internal.PurchaseOrderFilter{BuyerID: 99})
+
+		assert.ErrorIs(t, err, utils.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "FindDetailsByBuyerID", mock.Anything)
+	})
+
+	t.Run("FindDetailsByBuyerID - Invalid date", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		_, err := service.FindDetailsByBuyerID(context.Background(), internal.PurchaseOrderFilter{BuyerID: 1, DateFrom: "04/04/2021"})
+
+		assert.ErrorIs(t, err, utils.ErrInvalidFormat)
+	})
+
+	t.Run("FindDetailsByBuyerID - Date range inverted", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		_, err := service.FindDetailsByBuyerID(context.Background(), internal.PurchaseOrderFilter{BuyerID: 1, DateFrom: "2021-05-01", DateTo: "2021-04-01"})
+
+		assert.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
+
+	t.Run("FindDetailsByBuyerID - Page size too big", func(t *testing.T) {
+		mockRepo := new(mockPurchaseOrderRepository)
+		mockBV := new(mockPurchaseOrderBuyerValidation)
+		mockPRV := new(mockPurchaseOrderProductRecordValidation)
+		service := NewPurchaseOrderService(mockRepo, mockBV, mockPRV, newMockUnitOfWork(mockRepo))
+
+		_, err := service.FindDetailsByBuyerID(context.Background(), internal.PurchaseOrderFilter{BuyerID: 1, PageSize: MaxPageSize + 1})
+
+		assert.ErrorIs(t, err, utils.ErrInvalidArguments)
+	})
 }
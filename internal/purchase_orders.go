@@ -1,6 +1,9 @@
 package internal
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // PurchaseOrder represents an PurchaseOrder entity with its unique ID and attributes
 type PurchaseOrder struct {
@@ -30,27 +33,69 @@ type PurchaseOrderJSON struct {
 // PurchaseOrderRepository defines the interface for PurchaseOrder data persistence
 // it specifies methods for fetching and creating PurchaseOrder data
 type PurchaseOrderRepository interface {
-	FindAll() ([]PurchaseOrder, error)
-	FindAllByBuyerID(buyerID int) (PurchaseOrders []PurchaseOrderSummary, err error)
-	CreatePurchaseOrder(newPurchaseOrder PurchaseOrderAttributes) (PurchaseOrder PurchaseOrder, err error)
+	FindAll(ctx context.Context) ([]PurchaseOrder, error)
+	FindAllByBuyerID(ctx context.Context, buyerID int) (PurchaseOrders []PurchaseOrderSummary, err error)
+	FindDetailsByBuyerID(ctx context.Context, filter PurchaseOrderFilter) (orders []PurchaseOrderDetail, total int, err error)
+	CreatePurchaseOrder(ctx context.Context, newPurchaseOrder PurchaseOrderAttributes) (PurchaseOrder PurchaseOrder, err error)
 }
 
 // PurchaseOrderService defines the interface for PurchaseOrder-related business logic
 // it includes methods for fetching and creating PurchaseOrders
 type PurchaseOrderService interface {
-	FindAllByBuyerID(buyerID int) (PurchaseOrders []PurchaseOrderSummary, err error)
-	CreatePurchaseOrder(newPurchaseOrder PurchaseOrderAttributes) (PurchaseOrder PurchaseOrder, err error)
+	FindAllByBuyerID(ctx context.Context, buyerID int) (PurchaseOrders []PurchaseOrderSummary, err error)
+	FindDetailsByBuyerID(ctx context.Context, filter PurchaseOrderFilter) (page PurchaseOrderPage, err error)
+	CreatePurchaseOrder(ctx context.Context, newPurchaseOrder PurchaseOrderAttributes) (PurchaseOrder PurchaseOrder, err error)
 }
 
 type PurchaseOrdersBuyerValidation interface {
-	GetOne(int) (*Buyer, error)
+	GetOne(context.Context, int) (*Buyer, error)
 }
 type PurchaseOrdersProductRecordValidation interface {
-	FindByID(productRecordID int) (ProductRecords, error)
+	FindByID(ctx context.Context, productRecordID int) (ProductRecords, error)
 }
 
+// PurchaseOrderSummary aggregates the purchase orders of a buyer
 type PurchaseOrderSummary struct {
-	BuyerID     int    `json:"buyer_id"`
-	TotalOrders int    `json:"total_orders"`
-	OrderCodes  string `json:"order_codes"`
+	BuyerID     int      `json:"buyer_id"`
+	TotalOrders int      `json:"total_orders"`
+	OrderCodes  []string `json:"order_codes"`
+	TotalSpent  float64  `json:"total_spent"`
+}
+
+// PurchaseOrderDetail is a purchase order with its status, product and prices resolved
+type PurchaseOrderDetail struct {
+	ID            int                  `json:"id"`
+	OrderNumber   string               `json:"order_number"`
+	OrderDate     string               `json:"order_date"`
+	TrackingCode  string               `json:"tracking_code"`
+	Status        string               `json:"status"`
+	Product       PurchaseOrderProduct `json:"product"`
+	PurchasePrice float64              `json:"purchase_price"`
+	SalePrice     float64              `json:"sale_price"`
+}
+
+// PurchaseOrderProduct is the product bought in a purchase order
+type PurchaseOrderProduct struct {
+	ID              int    `json:"id"`
+	ProductCode     string `json:"product_code"`
+	Description     string `json:"description"`
+	ProductRecordID int    `json:"product_record_id"`
+}
+
+// PurchaseOrderFilter narrows the purchase orders of a buyer by order date and page
+// DateFrom and DateTo are inclusive and use the YYYY-MM-DD format, empty means unbounded
+type PurchaseOrderFilter struct {
+	BuyerID  int
+	DateFrom string
+	DateTo   string
+	Page     int
+	PageSize int
+}
+
+// PurchaseOrderPage is a page of purchase orders of a buyer
+type PurchaseOrderPage struct {
+	Data     []PurchaseOrderDetail `json:"data"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+	Total    int                   `json:"total"`
 }
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit is a token bucket of Requests tokens refilled over Period, a client makes up to Requests
+// requests at once and then one each Period/Requests
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// RateLimitResult is the state of the bucket of a client after taking a token for a request
+type RateLimitResult struct {
+	Allowed bool
+	Limit   int
+	// Remaining are the whole tokens left in the bucket
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the bucket has a token again, 0 when the request is allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets of the clients, they can be kept in memory or shared by
+// the instances of the server
+type RateLimitStore interface {
+	// Take takes a token from the bucket of the key for a request made at now, the request is not
+	// allowed when the bucket is empty
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+	// Peek returns the bucket of the key at now without taking a token, a request is allowed when
+	// the bucket has one
+	Peek(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
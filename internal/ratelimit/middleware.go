@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Group is a limit of the requests to some paths, the clients have a bucket in each group. Paths
+// are patterns of path.Match, as /api/v1/*/import, and a group without paths has every path. A
+// group with a limit of no requests is not limited
+type Group struct {
+	Name  string
+	Paths []string
+	Limit internal.RateLimit
+}
+
+// Middleware limits the requests of each client with a token bucket of the first group of their
+// path. A client is the API key a request was authenticated with, or else its IP, so it must be
+// mounted after the API key middleware. The requests over the limit fail with 429 and Retry-After,
+// every limited response has the RateLimit-* headers. It panics with a pattern that is not valid,
+// so it is found when the server starts.
+func Middleware(store internal.RateLimitStore, groups ...Group) func(http.Handler) http.Handler {
+	for _, group := range groups {
+		for _, pattern := range group.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				panic("ratelimit: invalid path " + pattern + " of the group " + group.Name)
+			}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			group, ok := match(groups, r.URL.Path)
+			if !ok || group.Limit.Requests <= 0 || group.Limit.Period <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := store.Take(r.Context(), group.Name+":"+client(r), group.Limit, time.Now())
+			if err != nil {
+				// the requests are not failed because their limit could not be counted
+				log.Printf("error taking a token of the rate limit %s: %s", group.Name, err.Error())
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			setHeaders(w, group.Limit, result)
+
+			if !result.Allowed {
+				tooManyRequests(w, group.Limit, result)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Failures limits the failed authentications of each IP, the responses with a status in statuses
+// take a token of its bucket in the group. The requests of an IP with an empty bucket fail with
+// 429 before they are authenticated, so it must be mounted before the API key middleware, or a
+// wrong key would never be limited. The paths of the group are not used.
+func Failures(store internal.RateLimitStore, group Group, statuses ...int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if group.Limit.Requests <= 0 || group.Limit.Period <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := group.Name + ":ip:" + ip(r)
+
+			result, err := store.Peek(r.Context(), key, group.Limit, time.Now())
+			if err != nil {
+				log.Printf("error reading the rate limit %s: %s", group.Name, err.Error())
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if !result.Allowed {
+				setHeaders(w, group.Limit, result)
+				tooManyRequests(w, group.Limit, result)
+
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if !slices.Contains(statuses, recorder.status) {
+				return
+			}
+
+			if _, err := store.Take(r.Context(), key, group.Limit, time.Now()); err != nil {
+				log.Printf("error taking a token of the rate limit %s: %s", group.Name, err.Error())
+			}
+		})
+	}
+}
+
+// statusRecorder keeps the status of a response written to ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the writer recorded, so http.ResponseController reaches its Flush
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// setHeaders sets the RateLimit-* headers of the bucket of a request
+func setHeaders(w http.ResponseWriter, limit internal.RateLimit, result internal.RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)))
+}
+
+// tooManyRequests fails a request over the limit with 429 and Retry-After
+func tooManyRequests(w http.ResponseWriter, limit internal.RateLimit, result internal.RateLimitResult) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds(result.RetryAfter), 1)))
+	utils.HandleError(w, utils.ETooManyRequests(fmt.Sprintf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Period)))
+}
+
+// match returns the first group with a pattern of the path, or without patterns
+func match(groups []Group, requestPath string) (Group, bool) {
+	for _, group := range groups {
+		if len(group.Paths) == 0 {
+			return group, true
+		}
+
+		for _, pattern := range group.Paths {
+			if matched, _ := path.Match(pattern, requestPath); matched {
+				return group, true
+			}
+		}
+	}
+
+	return Group{}, false
+}
+
+// client returns the API key of the request, or its IP. The IP is the one of the connection, the
+// server is not behind a proxy setting X-Forwarded-For
+func client(r *http.Request) string {
+	if principal, ok := internal.PrincipalFromContext(r.Context()); ok && principal.APIKeyID != 0 {
+		return "key:" + strconv.Itoa(principal.APIKeyID)
+	}
+
+	return "ip:" + ip(r)
+}
+
+// ip returns the IP of the connection of the request
+func ip(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// seconds returns the whole seconds of a duration, rounded up
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
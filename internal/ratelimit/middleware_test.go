@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit internal.RateLimit, now time.Time) (internal.RateLimitResult, error) {
+	return internal.RateLimitResult{}, errors.New("store down")
+}
+
+func (failingStore) Peek(ctx context.Context, key string, limit internal.RateLimit, now time.Time) (internal.RateLimitResult, error) {
+	return internal.RateLimitResult{}, errors.New("store down")
+}
+
+func TestMiddleware(t *testing.T) {
+	newRouter := func(store internal.RateLimitStore) *chi.Mux {
+		router := chi.NewRouter()
+		// stands for the API key middleware, mounted before
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-API-Key") == "erp" {
+					r = r.WithContext(internal.WithPrincipal(r.Context(), internal.Principal{UserID: 5, APIKeyID: 9}))
+				}
+
+				next.ServeHTTP(w, r)
+			})
+		})
+		router.Use(Middleware(store,
+			Group{Name: "auth", Paths: []string{"/api/v1/auth/*"}, Limit: internal.RateLimit{Requests: 1, Period: time.Minute}},
+			Group{Name: "default", Limit: internal.RateLimit{Requests: 2, Period: time.Minute}},
+		))
+		router.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {})
+
+		return router
+	}
+
+	send := func(router http.Handler, path, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.RemoteAddr = remoteAddr
+
+		if apiKey != "" {
+			request.Header.Set("X-API-Key", apiKey)
+		}
+
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		return response
+	}
+
+	t.Run("limits each IP", func(t *testing.T) {
+		router := newRouter(NewMemoryStore())
+
+		response := send(router, "/api/v1/products", "10.0.0.1:5000", "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Equal(t, "2", response.Header().Get("RateLimit-Limit"))
+		require.Equal(t, "1", response.Header().Get("RateLimit-Remaining"))
+		require.Equal(t, "30", response.Header().Get("RateLimit-Reset"))
+		require.Equal(t, "2;w=60", response.Header().Get("RateLimit-Policy"))
+
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.1:5001", "").Code)
+
+		response = send(router, "/api/v1/products", "10.0.0.1:5002", "")
+		require.Equal(t, http.StatusTooManyRequests, response.Code)
+		require.Equal(t, "30", response.Header().Get("Retry-After"))
+		require.Equal(t, "0", response.Header().Get("RateLimit-Remaining"))
+		require.JSONEq(t, `{"status":"Too Many Requests","message":"too many requests: rate limit of 2 requests per 1m0s exceeded"}`, response.Body.String())
+
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.2:5000", "").Code)
+	})
+
+	t.Run("limits each API key on its own", func(t *testing.T) {
+		router := newRouter(NewMemoryStore())
+
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.1:5000", "").Code)
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.1:5000", "").Code)
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.1:5000", "erp").Code)
+	})
+
+	t.Run("a bucket per group", func(t *testing.T) {
+		router := newRouter(NewMemoryStore())
+
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/auth/token", "10.0.0.1:5000", "").Code)
+		require.Equal(t, http.StatusTooManyRequests, send(router, "/api/v1/auth/token", "10.0.0.1:5000", "").Code)
+		require.Equal(t, http.StatusOK, send(router, "/api/v1/products", "10.0.0.1:5000", "").Code)
+	})
+
+	t.Run("lets the requests through when the store fails", func(t *testing.T) {
+		response := send(newRouter(failingStore{}), "/api/v1/products", "10.0.0.1:5000", "")
+		require.Equal(t, http.StatusOK, response.Code)
+		require.Empty(t, response.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		require.Panics(t, func() { Middleware(NewMemoryStore(), Group{Name: "bad", Paths: []string{"/api/v1/["}}) })
+	})
+}
+
+func TestFailures(t *testing.T) {
+	newRouter := func(store internal.RateLimitStore) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(Failures(store, Group{Name: "unauthorized", Limit: internal.RateLimit{Requests: 2, Period: time.Minute}}, http.StatusUnauthorized))
+		// stands for the API key middleware, mounted after
+		router.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-API-Key") != "erp" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		})
+
+		return router
+	}
+
+	send := func(router http.Handler, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("X-API-Key", apiKey)
+
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		return response
+	}
+
+	t.Run("limits the failed requests of each IP", func(t *testing.T) {
+		router := newRouter(NewMemoryStore())
+
+		require.Equal(t, http.StatusUnauthorized, send(router, "10.0.0.1:5000", "guess-1").Code)
+		require.Equal(t, http.StatusUnauthorized, send(router, "10.0.0.1:5001", "guess-2").Code)
+
+		response := send(router, "10.0.0.1:5002", "erp")
+		require.Equal(t, http.StatusTooManyRequests, response.Code)
+		require.Equal(t, "30", response.Header().Get("Retry-After"))
+		require.Equal(t, "0", response.Header().Get("RateLimit-Remaining"))
+
+		require.Equal(t, http.StatusUnauthorized, send(router, "10.0.0.2:5000", "guess-3").Code)
+	})
+
+	t.Run("does not count the authenticated requests", func(t *testing.T) {
+		router := newRouter(NewMemoryStore())
+
+		for range 3 {
+			response := send(router, "10.0.0.1:5000", "erp")
+			require.Equal(t, http.StatusOK, response.Code)
+			require.Empty(t, response.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("lets the requests through when the store fails", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, send(newRouter(failingStore{}), "10.0.0.1:5000", "guess").Code)
+	})
+}
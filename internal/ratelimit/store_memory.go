@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+)
+
+// sweepInterval is how often the full buckets are removed, a full bucket is the same as none
+const sweepInterval = time.Minute
+
+// bucket are the tokens of a client at the last time they were counted
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   internal.RateLimit
+}
+
+// MemoryStore keeps the buckets in the memory of the server, each instance limits the clients on
+// its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore without buckets.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+// Take refills the bucket of the key for the time since it was counted and takes a token from it.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit internal.RateLimit, now time.Time) (internal.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b := s.bucket(key, limit, now)
+
+	result := internal.RateLimitResult{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = b.until(1)
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = b.until(float64(limit.Requests))
+
+	return result, nil
+}
+
+// Peek refills the bucket of the key for the time since it was counted, without taking a token.
+func (s *MemoryStore) Peek(ctx context.Context, key string, limit internal.RateLimit, now time.Time) (internal.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(key, limit, now)
+
+	return internal.RateLimitResult{
+		Allowed:    b.tokens >= 1,
+		Limit:      limit.Requests,
+		Remaining:  int(math.Floor(b.tokens)),
+		Reset:      b.until(float64(limit.Requests)),
+		RetryAfter: b.until(1),
+	}, nil
+}
+
+// bucket returns the bucket of the key refilled until now, a new full one when there is none of
+// the limit
+func (s *MemoryStore) bucket(key string, limit internal.RateLimit, now time.Time) *bucket {
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		s.buckets[key] = b
+	}
+
+	b.refill(now)
+
+	return b
+}
+
+// sweep removes the buckets that are full by now, at most once every sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
+
+// refill adds the tokens of the time since the bucket was counted, up to its limit
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Requests), b.tokens+elapsed.Seconds()*b.rate())
+		b.updated = now
+	}
+}
+
+// until returns how long until the bucket has the tokens
+func (b *bucket) until(tokens float64) time.Duration {
+	if b.tokens >= tokens {
+		return 0
+	}
+
+	return time.Duration((tokens - b.tokens) / b.rate() * float64(time.Second))
+}
+
+// rate returns the tokens added each second
+func (b *bucket) rate() float64 {
+	return float64(b.limit.Requests) / b.limit.Period.Seconds()
+}
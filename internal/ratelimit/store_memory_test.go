@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	limit := internal.RateLimit{Requests: 3, Period: 3 * time.Second}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("takes the burst and then refills one token per second", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			result, err := store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			require.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := store.Take(context.Background(), "ip:10.0.0.1", limit, now.Add(500*time.Millisecond))
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+		require.Equal(t, 2500*time.Millisecond, result.Reset)
+
+		result, err = store.Take(context.Background(), "ip:10.0.0.1", limit, now.Add(time.Second))
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+	})
+
+	t.Run("a bucket per key", func(t *testing.T) {
+		store := NewMemoryStore()
+		single := internal.RateLimit{Requests: 1, Period: time.Minute}
+
+		result, err := store.Take(context.Background(), "key:1", single, now)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = store.Take(context.Background(), "key:2", single, now)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+
+		result, err = store.Take(context.Background(), "key:1", single, now)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+	})
+
+	t.Run("removes the full buckets", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		_, err := store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+		require.NoError(t, err)
+
+		_, err = store.Take(context.Background(), "ip:10.0.0.2", limit, now.Add(sweepInterval))
+		require.NoError(t, err)
+		require.Len(t, store.buckets, 1)
+		require.Contains(t, store.buckets, "ip:10.0.0.2")
+	})
+}
+
+func TestMemoryStore_Peek(t *testing.T) {
+	limit := internal.RateLimit{Requests: 2, Period: 2 * time.Second}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore()
+
+	result, err := store.Peek(context.Background(), "ip:10.0.0.1", limit, now)
+	require.NoError(t, err)
+	require.Equal(t, internal.RateLimitResult{Allowed: true, Limit: 2, Remaining: 2}, result)
+
+	for range 2 {
+		_, err = store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+		require.NoError(t, err)
+	}
+
+	result, err = store.Peek(context.Background(), "ip:10.0.0.1", limit, now)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	result, err = store.Peek(context.Background(), "ip:10.0.0.1", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+}
@@ -0,0 +1,124 @@
+package rbac
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
+// Resources are what the permissions are granted on, the countries, provinces and localities are
+// the locations
+const (
+	ResourceAPIKeys        = "api_keys"
+	ResourceAudit          = "audit"
+	ResourceBuyers         = "buyers"
+	ResourceCarries        = "carries"
+	ResourceEmployees      = "employees"
+	ResourceInboundOrders  = "inbound_orders"
+	ResourceLocations      = "locations"
+	ResourcePackagingUnits = "packaging_units"
+	ResourceProductBatches = "product_batches"
+	ResourceProductRecords = "product_records"
+	ResourceProductTypes   = "product_types"
+	ResourceProducts       = "products"
+	ResourcePurchaseOrders = "purchase_orders"
+	ResourceSections       = "sections"
+	ResourceSellers        = "sellers"
+	ResourceWarehouses     = "warehouses"
+)
+
+// Actions on a resource, the imports and restores are writes
+const (
+	ActionRead   = "read"
+	ActionWrite  = "write"
+	ActionDelete = "delete"
+)
+
+var resources = []string{
+	ResourceAPIKeys, ResourceAudit, ResourceBuyers, ResourceCarries, ResourceEmployees, ResourceInboundOrders,
+	ResourceLocations, ResourcePackagingUnits, ResourceProductBatches, ResourceProductRecords, ResourceProductTypes,
+	ResourceProducts, ResourcePurchaseOrders, ResourceSections, ResourceSellers, ResourceWarehouses,
+}
+
+// rolePermissions are the permissions of each role, a permission is a resource and an action
+// separated by a colon
+var rolePermissions = map[string][]string{
+	internal.RoleAdmin: permissions([]string{ActionRead, ActionWrite, ActionDelete}, resources...),
+	internal.RoleWarehouseOperator: slices.Concat(
+		permissions([]string{ActionRead}, ResourceCarries, ResourceLocations, ResourcePackagingUnits,
+			ResourceProductTypes, ResourceProducts, ResourceWarehouses),
+		permissions([]string{ActionRead, ActionWrite}, ResourceEmployees, ResourceInboundOrders,
+			ResourceProductBatches, ResourceSections),
+	),
+	internal.RoleSeller: slices.Concat(
+		permissions([]string{ActionRead}, ResourceLocations, ResourceProductTypes, ResourceSellers),
+		permissions([]string{ActionRead, ActionWrite, ActionDelete}, ResourcePackagingUnits, ResourceProducts),
+		permissions([]string{ActionRead, ActionWrite}, ResourceProductRecords),
+	),
+	internal.RoleBuyer: slices.Concat(
+		permissions([]string{ActionRead}, ResourceBuyers, ResourceLocations, ResourceProductRecords,
+			ResourceProductTypes, ResourceProducts),
+		permissions([]string{ActionRead, ActionWrite}, ResourcePurchaseOrders),
+	),
+	internal.RoleAnalyst: permissions([]string{ActionRead}, resources...),
+}
+
+// Permission returns the permission of the action on the resource, it is what the routes require
+// and what the scopes of an API key list
+func Permission(resource, action string) string {
+	return resource + ":" + action
+}
+
+// Require only lets through the requests of a principal whose role has the permission, and whose
+// scopes have it too when it was authenticated with an API key. It is mounted on each route after
+// the authentication middleware. It panics with a permission that does not exist, so a typo is
+// found when the routes are registered.
+func Require(permission string) func(http.Handler) http.Handler {
+	if !slices.Contains(rolePermissions[internal.RoleAdmin], permission) {
+		panic("auth: unknown permission " + permission)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := internal.PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				utils.HandleError(w, utils.EUnauthorized("missing bearer token"))
+
+				return
+			}
+
+			if !Allowed(principal.Role, permission) {
+				utils.HandleError(w, utils.EForbidden("the role "+principal.Role+" does not have the permission "+permission))
+				return
+			}
+
+			if principal.Scopes != nil && !slices.Contains(principal.Scopes, permission) {
+				utils.HandleError(w, utils.EForbidden("the API key does not have the scope "+permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Allowed reports whether the role has the permission
+func Allowed(role, permission string) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// permissions returns the permissions of the actions on each resource
+func permissions(actions []string, resources ...string) []string {
+	var granted []string
+
+	for _, resource := range resources {
+		for _, action := range actions {
+			granted = append(granted, Permission(resource, action))
+		}
+	}
+
+	return granted
+}
@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequire(t *testing.T) {
+	router := chi.NewRouter()
+	router.With(Require("sections:read")).Get("/api/v1/sections", func(w http.ResponseWriter, r *http.Request) {})
+	router.With(Require("sections:delete")).Delete("/api/v1/sections/1", func(w http.ResponseWriter, r *http.Request) {})
+	router.With(Require("products:write")).Post("/api/v1/products", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		role   string
+		scopes []string
+		method string
+		path   string
+		status int
+	}{
+		{name: "admin deletes", role: internal.RoleAdmin, method: http.MethodDelete, path: "/api/v1/sections/1", status: http.StatusOK},
+		{name: "operator reads the sections", role: internal.RoleWarehouseOperator, method: http.MethodGet, path: "/api/v1/sections", status: http.StatusOK},
+		{name: "operator cannot delete the sections", role: internal.RoleWarehouseOperator, method: http.MethodDelete, path: "/api/v1/sections/1", status: http.StatusForbidden},
+		{name: "seller creates products", role: internal.RoleSeller, method: http.MethodPost, path: "/api/v1/products", status: http.StatusOK},
+		{name: "seller cannot read the sections", role: internal.RoleSeller, method: http.MethodGet, path: "/api/v1/sections", status: http.StatusForbidden},
+		{name: "buyer cannot create products", role: internal.RoleBuyer, method: http.MethodPost, path: "/api/v1/products", status: http.StatusForbidden},
+		{name: "analyst reads", role: internal.RoleAnalyst, method: http.MethodGet, path: "/api/v1/sections", status: http.StatusOK},
+		{name: "analyst cannot create products", role: internal.RoleAnalyst, method: http.MethodPost, path: "/api/v1/products", status: http.StatusForbidden},
+		{name: "unknown role", role: "owner", method: http.MethodGet, path: "/api/v1/sections", status: http.StatusForbidden},
+		{name: "API key with the scope", role: internal.RoleSeller, scopes: []string{"products:write"}, method: http.MethodPost, path: "/api/v1/products", status: http.StatusOK},
+		{name: "API key without the scope", role: internal.RoleSeller, scopes: []string{"products:read"}, method: http.MethodPost, path: "/api/v1/products", status: http.StatusForbidden},
+		{name: "API key with a scope the role lacks", role: internal.RoleAnalyst, scopes: []string{"products:write"}, method: http.MethodPost, path: "/api/v1/products", status: http.StatusForbidden},
+		{name: "no principal", method: http.MethodGet, path: "/api/v1/sections", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.role != "" {
+				request = request.WithContext(internal.WithPrincipal(context.Background(), internal.Principal{UserID: 1, Role: tc.role, Scopes: tc.scopes}))
+			}
+
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+
+			require.Equal(t, tc.status, response.Code)
+		})
+	}
+
+	t.Run("unknown permission", func(t *testing.T) {
+		require.Panics(t, func() { Require("sections:read-all") })
+	})
+}
+
+func TestAllowed(t *testing.T) {
+	for _, role := range internal.Roles {
+		require.True(t, Allowed(role, "products:read"), role)
+	}
+
+	require.True(t, Allowed(internal.RoleAnalyst, "audit:read"))
+	require.False(t, Allowed(internal.RoleWarehouseOperator, "audit:read"))
+	require.True(t, Allowed(internal.RoleBuyer, "purchase_orders:write"))
+	require.False(t, Allowed(internal.RoleSeller, "purchase_orders:write"))
+}
+
+func TestPermission(t *testing.T) {
+	require.Equal(t, "sections:read", Permission(ResourceSections, ActionRead))
+	require.True(t, Allowed(internal.RoleAdmin, Permission(ResourcePurchaseOrders, ActionDelete)))
+}
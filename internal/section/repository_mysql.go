@@ -1,6 +1,7 @@
 package section
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -9,35 +10,94 @@ import (
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the sections can be filtered and sorted by, the optional limits can
+// only be filtered
+var listFields = utils.ListFields{
+	"id":                  {Column: "s.id", Kind: utils.ListInt, Sortable: true},
+	"section_number":      {Column: "s.section_number", Kind: utils.ListInt, Sortable: true},
+	"current_capacity":    {Column: "s.current_capacity", Kind: utils.ListInt, Sortable: true},
+	"maximum_capacity":    {Column: "s.maximum_capacity", Kind: utils.ListInt, Sortable: true},
+	"minimum_capacity":    {Column: "s.minimum_capacity", Kind: utils.ListInt, Sortable: true},
+	"current_temperature": {Column: "s.current_temperature", Kind: utils.ListFloat, Sortable: true},
+	"minimum_temperature": {Column: "s.minimum_temperature", Kind: utils.ListFloat, Sortable: true},
+	"warehouse_id":        {Column: "s.warehouse_id", Kind: utils.ListInt, Sortable: true},
+	"product_type_id":     {Column: "s.product_type_id", Kind: utils.ListInt, Sortable: true},
+	"maximum_volume":      {Column: "s.maximum_volume", Kind: utils.ListFloat},
+	"maximum_weight":      {Column: "s.maximum_weight", Kind: utils.ListFloat},
+}
+
 type SectionMysqlRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-// NewSectionMysql Initialize a MemorySectionRepository and set the next id to 1
-func NewSectionMysql(db *sql.DB) internal.SectionRepository {
+// NewSectionMysql Initialize a SectionMysqlRepository on the database connection, or on the
+// transaction of a unit of work
+func NewSectionMysql(db utils.DBTX) internal.SectionRepository {
 	return &SectionMysqlRepository{db}
 }
 
 // GetAll the sections and return in asc order
-func (r SectionMysqlRepository) GetAll() ([]internal.Section, error) {
+func (r SectionMysqlRepository) GetAll(ctx context.Context) ([]internal.Section, error) {
 	var sections []internal.Section
 
-	rows, err := r.db.Query("SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, " +
-		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id FROM sections AS s")
+	rows, err := r.db.QueryContext(ctx, "SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
+		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id, s.maximum_volume, s.maximum_weight, s.version FROM sections AS s WHERE "+internal.WarehouseCondition(ctx, "s.warehouse_id"))
 	if err != nil {
 		return nil, err
 	}
 
 	for rows.Next() {
 		var section internal.Section
+
+		var maximumVolume, maximumWeight sql.NullFloat64
+
 		err = rows.Scan(&section.ID, &section.SectionNumber, &section.CurrentTemperature,
 			&section.MinimumTemperature, &section.CurrentCapacity, &section.MinimumCapacity,
-			&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID)
+			&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID, &maximumVolume, &maximumWeight, &section.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		section.MaximumVolume, section.MaximumWeight = nullableFloat(maximumVolume), nullableFloat(maximumWeight)
+		sections = append(sections, section)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
 
+// List returns a page of sections
+func (r SectionMysqlRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Section, error) {
+	clause, args := query.SQL(listFields, internal.WarehouseCondition(ctx, "s.warehouse_id"))
+
+	rows, err := r.db.QueryContext(ctx, "SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
+		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id, s.maximum_volume, s.maximum_weight, s.version FROM sections AS s"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var sections []internal.Section
+
+	for rows.Next() {
+		var section internal.Section
+
+		var maximumVolume, maximumWeight sql.NullFloat64
+
+		err = rows.Scan(&section.ID, &section.SectionNumber, &section.CurrentTemperature,
+			&section.MinimumTemperature, &section.CurrentCapacity, &section.MinimumCapacity,
+			&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID, &maximumVolume, &maximumWeight, &section.Version)
 		if err != nil {
 			return nil, err
 		}
 
+		section.MaximumVolume, section.MaximumWeight = nullableFloat(maximumVolume), nullableFloat(maximumWeight)
 		sections = append(sections, section)
 	}
 
@@ -49,15 +109,27 @@ func (r SectionMysqlRepository) GetAll() ([]internal.Section, error) {
 	return sections, nil
 }
 
-func (r *SectionMysqlRepository) GetByID(id int) (internal.Section, error) {
+func (r *SectionMysqlRepository) GetByID(ctx context.Context, id int) (internal.Section, error) {
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate Returns the section with the id and locks its row until the end of the transaction
+// of the repository, the changes to the section made meanwhile wait for it
+func (r *SectionMysqlRepository) GetByIDForUpdate(ctx context.Context, id int) (internal.Section, error) {
+	return r.getByID(ctx, id, " FOR UPDATE")
+}
+
+func (r *SectionMysqlRepository) getByID(ctx context.Context, id int, lock string) (internal.Section, error) {
 	var section internal.Section
 
-	row := r.db.QueryRow("SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
-		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id FROM sections AS s WHERE id=?", id)
+	var maximumVolume, maximumWeight sql.NullFloat64
+
+	row := r.db.QueryRowContext(ctx, "SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
+		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id, s.maximum_volume, s.maximum_weight, s.version FROM sections AS s WHERE id=? AND "+internal.WarehouseCondition(ctx, "s.warehouse_id")+lock, id)
 
 	err := row.Scan(&section.ID, &section.SectionNumber, &section.CurrentTemperature,
 		&section.MinimumTemperature, &section.CurrentCapacity, &section.MinimumCapacity,
-		&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID)
+		&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID, &maximumVolume, &maximumWeight, &section.Version)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -68,20 +140,24 @@ func (r *SectionMysqlRepository) GetByID(id int) (internal.Section, error) {
 		return internal.Section{}, err
 	}
 
+	section.MaximumVolume, section.MaximumWeight = nullableFloat(maximumVolume), nullableFloat(maximumWeight)
+
 	return section, nil
 }
 
 // GetBySectionNumber Finds the section by its sectionNumber
 // If not found, pkg.Section{} is returned
-func (r *SectionMysqlRepository) GetBySectionNumber(sectionNumber int) (internal.Section, error) {
+func (r *SectionMysqlRepository) GetBySectionNumber(ctx context.Context, sectionNumber int) (internal.Section, error) {
 	var section internal.Section
 
-	row := r.db.QueryRow("SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
-		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id FROM sections AS s WHERE section_number=?", sectionNumber)
+	var maximumVolume, maximumWeight sql.NullFloat64
+
+	row := r.db.QueryRowContext(ctx, "SELECT s.id, s.section_number, s.current_temperature, s.minimum_temperature, "+
+		"s.current_capacity, s.minimum_capacity, s.maximum_capacity, s.warehouse_id, s.product_type_id, s.maximum_volume, s.maximum_weight, s.version FROM sections AS s WHERE section_number=?", sectionNumber)
 
 	err := row.Scan(&section.ID, &section.SectionNumber, &section.CurrentTemperature,
 		&section.MinimumTemperature, &section.CurrentCapacity, &section.MinimumCapacity,
-		&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID)
+		&section.MaximumCapacity, &section.WarehouseID, &section.ProductTypeID, &maximumVolume, &maximumWeight, &section.Version)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -91,14 +167,16 @@ func (r *SectionMysqlRepository) GetBySectionNumber(sectionNumber int) (internal
 		return internal.Section{}, err
 	}
 
+	section.MaximumVolume, section.MaximumWeight = nullableFloat(maximumVolume), nullableFloat(maximumWeight)
+
 	return section, nil
 }
 
 // Save Generate a new ID and save the entity
 // All validatinos should be made on service layer
-func (r *SectionMysqlRepository) Save(newSection *internal.Section) error {
-	result, err := r.db.Exec("INSERT INTO sections (section_number, current_temperature, minimum_temperature, current_capacity, minimum_capacity, maximum_capacity, warehouse_id, product_type_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		(*newSection).SectionNumber, (*newSection).CurrentTemperature, (*newSection).MinimumTemperature, (*newSection).CurrentCapacity, (*newSection).MinimumCapacity, (*newSection).MaximumCapacity, (*newSection).WarehouseID, (*newSection).ProductTypeID)
+func (r *SectionMysqlRepository) Save(ctx context.Context, newSection *internal.Section) error {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO sections (section_number, current_temperature, minimum_temperature, current_capacity, minimum_capacity, maximum_capacity, warehouse_id, product_type_id, maximum_volume, maximum_weight) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		(*newSection).SectionNumber, (*newSection).CurrentTemperature, (*newSection).MinimumTemperature, (*newSection).CurrentCapacity, (*newSection).MinimumCapacity, (*newSection).MaximumCapacity, (*newSection).WarehouseID, (*newSection).ProductTypeID, (*newSection).MaximumVolume, (*newSection).MaximumWeight)
 
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -120,16 +198,19 @@ func (r *SectionMysqlRepository) Save(newSection *internal.Section) error {
 	}
 
 	(*newSection).ID = int(id)
+	(*newSection).Version = 1
 
 	return err
 }
 
-func (r *SectionMysqlRepository) Update(newSection *internal.Section) error {
-	_, err := r.db.Exec(
-		"UPDATE sections SET section_number=?, current_temperature=?, minimum_temperature=?, current_capacity=?, minimum_capacity=?, maximum_capacity=?, warehouse_id=?, product_type_id=? WHERE id=?",
+// Update saves the section when it is still in the version it was read, and bumps its version.
+// If the section was changed since, a precondition failed error is returned
+func (r *SectionMysqlRepository) Update(ctx context.Context, newSection *internal.Section) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE sections SET section_number=?, current_temperature=?, minimum_temperature=?, current_capacity=?, minimum_capacity=?, maximum_capacity=?, warehouse_id=?, product_type_id=?, maximum_volume=?, maximum_weight=?, version=version+1 WHERE id=? AND version=?",
 		(*newSection).SectionNumber, (*newSection).CurrentTemperature, (*newSection).MinimumTemperature,
 		(*newSection).CurrentCapacity, (*newSection).MinimumCapacity, (*newSection).MaximumCapacity, (*newSection).WarehouseID,
-		(*newSection).ProductTypeID, (*newSection).ID,
+		(*newSection).ProductTypeID, (*newSection).MaximumVolume, (*newSection).MaximumWeight, (*newSection).ID, (*newSection).Version,
 	)
 
 	if err != nil {
@@ -146,25 +227,33 @@ func (r *SectionMysqlRepository) Update(newSection *internal.Section) error {
 		return err
 	}
 
+	err = utils.CheckVersionUpdated(result, "section")
+	if err != nil {
+		return err
+	}
+
+	(*newSection).Version++
+
 	return nil
 }
 
-// Delete the section by its id
-// If no sections exists by id attribute, utils.ErrNotFound is returned
-func (r *SectionMysqlRepository) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM sections WHERE id=?", id)
+// Delete the section by its id when it is still in the version it was read
+// If no section exists by id and version, a precondition failed error is returned
+func (r *SectionMysqlRepository) Delete(ctx context.Context, id int, version int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM sections WHERE id=? AND version=?", id, version)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return utils.CheckVersionUpdated(result, "section")
 }
 
-func (r *SectionMysqlRepository) GetSectionProductsReport() ([]internal.SectionProductsReport, error) {
+func (r *SectionMysqlRepository) GetSectionProductsReport(ctx context.Context) ([]internal.SectionProductsReport, error) {
 	var reports []internal.SectionProductsReport
 
-	rows, err := r.db.Query("SELECT s.id, s.section_number, ifnull(sum(p.current_quantity), 0) as products_count FROM sections s left join product_batches p on s.id = p.section_id group by s.id, s.section_number")
+	rows, err := r.db.QueryContext(ctx, "SELECT s.id, s.section_number, ifnull(sum(p.current_quantity), 0) as products_count FROM sections s left join product_batches p on s.id = p.section_id "+
+		"WHERE "+internal.WarehouseCondition(ctx, "s.warehouse_id")+" group by s.id, s.section_number")
 
 	if err != nil {
 		return nil, err
@@ -188,19 +277,48 @@ func (r *SectionMysqlRepository) GetSectionProductsReport() ([]internal.SectionP
 
 	return reports, nil
 }
-func (r *SectionMysqlRepository) GetSectionProductsReportByID(id int) ([]internal.SectionProductsReport, error) {
+
+// GetSectionProductsReportPage returns up to limit reports of the sections with an id greater than
+// afterSectionID, ordered by section id
+func (r *SectionMysqlRepository) GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]internal.SectionProductsReport, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT s.id, s.section_number, IFNULL(SUM(p.current_quantity), 0) AS products_count FROM sections s "+
+		"LEFT JOIN product_batches p ON s.id = p.section_id WHERE s.id > ? AND "+internal.WarehouseCondition(ctx, "s.warehouse_id")+
+		" GROUP BY s.id, s.section_number ORDER BY s.id LIMIT ?", afterSectionID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reports := []internal.SectionProductsReport{}
+
+	for rows.Next() {
+		var report internal.SectionProductsReport
+
+		err = rows.Scan(&report.SectionID, &report.SectionNumber, &report.ProductsCount)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *SectionMysqlRepository) GetSectionProductsReportByID(ctx context.Context, id int) ([]internal.SectionProductsReport, error) {
 	var report internal.SectionProductsReport
 
 	var reports []internal.SectionProductsReport
 
-	row := r.db.QueryRow("SELECT "+
+	row := r.db.QueryRowContext(ctx, "SELECT "+
 		"s.id, "+
 		"s.section_number, "+
 		"sum(p.current_quantity) as products_count "+
 		"FROM sections s "+
 		"left join product_batches p "+
 		"on s.id = p.section_id "+
-		"where s.id=? group by s.id", id)
+		"where s.id=? AND "+internal.WarehouseCondition(ctx, "s.warehouse_id")+" group by s.id", id)
 
 	err := row.Scan(&report.SectionID, &report.SectionNumber, &report.ProductsCount)
 	if err != nil && err == sql.ErrNoRows {
@@ -212,3 +330,140 @@ func (r *SectionMysqlRepository) GetSectionProductsReportByID(id int) ([]interna
 
 	return reports, nil
 }
+
+const selectSectionCapacity = "SELECT s.id, s.section_number, s.warehouse_id, s.product_type_id, s.maximum_capacity, s.maximum_volume, s.maximum_weight, " +
+	"COALESCE(SUM(pb.current_quantity), 0), " +
+	"COALESCE(SUM(pb.current_quantity * p.width * p.height * p.`length`), 0), " +
+	"COALESCE(SUM(pb.current_quantity * p.net_weight), 0) " +
+	"FROM sections s " +
+	"LEFT JOIN product_batches pb ON pb.section_id = s.id " +
+	"LEFT JOIN products p ON p.id = pb.product_id "
+
+// GetSectionCapacityReport returns the volume and weight used in every section
+func (r *SectionMysqlRepository) GetSectionCapacityReport(ctx context.Context) ([]internal.SectionCapacityReport, error) {
+	rows, err := r.db.QueryContext(ctx, selectSectionCapacity+"WHERE "+internal.WarehouseCondition(ctx, "s.warehouse_id")+" GROUP BY s.id ORDER BY s.id")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reports := []internal.SectionCapacityReport{}
+
+	for rows.Next() {
+		report, err := scanSectionCapacity(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// GetSectionCapacityReportByID returns the volume and weight used in a section
+func (r *SectionMysqlRepository) GetSectionCapacityReportByID(ctx context.Context, id int) (internal.SectionCapacityReport, error) {
+	report, err := scanSectionCapacity(r.db.QueryRowContext(ctx, selectSectionCapacity+"WHERE s.id = ? AND "+internal.WarehouseCondition(ctx, "s.warehouse_id")+" GROUP BY s.id", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal.SectionCapacityReport{}, utils.ErrNotFound
+		}
+
+		return internal.SectionCapacityReport{}, err
+	}
+
+	return report, nil
+}
+
+const selectSectionProductTypes = "SELECT DISTINCT pb.section_id, p.product_type_id FROM product_batches pb " +
+	"INNER JOIN products p ON p.id = pb.product_id WHERE pb.current_quantity > 0 "
+
+// GetSectionProductTypes returns the product types of the batches in stock of every section
+func (r *SectionMysqlRepository) GetSectionProductTypes(ctx context.Context) (map[int][]int, error) {
+	rows, err := r.db.QueryContext(ctx, selectSectionProductTypes+"ORDER BY pb.section_id, p.product_type_id")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	productTypes := map[int][]int{}
+
+	for rows.Next() {
+		var sectionID, productTypeID int
+
+		err = rows.Scan(&sectionID, &productTypeID)
+		if err != nil {
+			return nil, err
+		}
+
+		productTypes[sectionID] = append(productTypes[sectionID], productTypeID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return productTypes, nil
+}
+
+// GetSectionProductTypesByID returns the product types of the batches in stock of a section
+func (r *SectionMysqlRepository) GetSectionProductTypesByID(ctx context.Context, id int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, selectSectionProductTypes+"AND pb.section_id = ? ORDER BY p.product_type_id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	productTypes := []int{}
+
+	for rows.Next() {
+		var sectionID, productTypeID int
+
+		err = rows.Scan(&sectionID, &productTypeID)
+		if err != nil {
+			return nil, err
+		}
+
+		productTypes = append(productTypes, productTypeID)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return productTypes, nil
+}
+
+func scanSectionCapacity(row interface{ Scan(...any) error }) (internal.SectionCapacityReport, error) {
+	var report internal.SectionCapacityReport
+
+	var maximumVolume, maximumWeight sql.NullFloat64
+
+	err := row.Scan(&report.SectionID, &report.SectionNumber, &report.WarehouseID, &report.ProductTypeID, &report.MaximumCapacity,
+		&maximumVolume, &maximumWeight, &report.ProductsCount, &report.UsedVolume, &report.UsedWeight)
+	if err != nil {
+		return internal.SectionCapacityReport{}, err
+	}
+
+	report.MaximumVolume, report.MaximumWeight = nullableFloat(maximumVolume), nullableFloat(maximumWeight)
+
+	return report, nil
+}
+
+func nullableFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Float64
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -17,12 +18,15 @@ func RegisterSectionRoutes(mux *chi.Mux, service internal.SectionService) error
 	sectionHandler := handler.NewSectionHandler(service)
 
 	mux.Route("/api/v1/sections", func(router chi.Router) {
-		router.Get("/", sectionHandler.GetAll())
-		router.Get("/{id}", sectionHandler.GetById())
-		router.Get("/reportProducts", sectionHandler.GetSectionProductsReport())
-		router.Post("/", sectionHandler.CreateSection())
-		router.Patch("/{id}", sectionHandler.Update())
-		router.Delete("/{id}", sectionHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/", sectionHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/{id}", sectionHandler.GetById())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/reportProducts", sectionHandler.GetSectionProductsReport())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/reportCapacity", sectionHandler.GetSectionCapacityReport())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/putaway", sectionHandler.GetPutawaySections())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionRead))).Get("/reportViolations", sectionHandler.GetSectionViolations())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionWrite))).Post("/", sectionHandler.CreateSection())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionWrite))).Patch("/{id}", sectionHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSections, rbac.ActionDelete))).Delete("/{id}", sectionHandler.Delete())
 	})
 
 	return nil
@@ -1,10 +1,14 @@
 package section
 
 import (
+	"context"
 	"errors"
+	"math"
+	"sort"
 	"strconv"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
@@ -13,29 +17,90 @@ const (
 	MinCelsiusTemperature = -273.15
 )
 
+// auditEntity is the name of the sections in the audit trail
+const auditEntity = "sections"
+
 type DefaultSectionService struct {
 	repo               internal.SectionRepository
 	warehouseService   internal.SectionWarehouseValidation
 	productTypeService internal.SectionProductTypeValidation
+	productService     internal.SectionProductValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewBasicSectionService(repo internal.SectionRepository, warehouseService internal.SectionWarehouseValidation, productTypeService internal.SectionProductTypeValidation) internal.SectionService {
+func NewBasicSectionService(repo internal.SectionRepository, warehouseService internal.SectionWarehouseValidation,
+	productTypeService internal.SectionProductTypeValidation, productService internal.SectionProductValidation,
+	uow internal.UnitOfWork) internal.SectionService {
 	return &DefaultSectionService{
 		repo:               repo,
 		warehouseService:   warehouseService,
 		productTypeService: productTypeService,
+		productService:     productService,
+		uow:                uow,
 	}
 }
 
 // GetAll Returns all the sections
-func (s DefaultSectionService) GetAll() ([]internal.Section, error) {
-	return s.repo.GetAll()
+func (s DefaultSectionService) GetAll(ctx context.Context) ([]internal.Section, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// List Returns a page of sections filtered and sorted as asked by the query
+func (s DefaultSectionService) List(ctx context.Context, query utils.ListQuery) ([]internal.Section, utils.Pagination, error) {
+	err := query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	sections, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	sections, pagination := utils.Paginate(sections, query)
+
+	return sections, pagination, nil
+}
+
+// GetRelations Returns the warehouse and the product type of each section when asked by include,
+// each of them is read once for all the sections
+func (s DefaultSectionService) GetRelations(ctx context.Context, sections []internal.Section, include []string) ([]internal.SectionRelations, error) {
+	relations := make([]internal.SectionRelations, len(sections))
+
+	warehouseID := func(section internal.Section) int { return section.WarehouseID }
+	productTypeID := func(section internal.Section) int { return section.ProductTypeID }
+
+	for _, name := range include {
+		switch name {
+		case internal.SectionIncludeWarehouse:
+			warehouses, err := s.warehouseService.GetByIDs(ctx, utils.DistinctIDs(sections, warehouseID))
+			if err != nil {
+				return nil, err
+			}
+
+			for i, warehouse := range utils.Related(sections, warehouseID, warehouses, func(w internal.Warehouse) int { return w.ID }) {
+				relations[i].Warehouse = warehouse
+			}
+		case internal.SectionIncludeProductType:
+			productTypes, err := s.productTypeService.GetProductTypesByIDs(ctx, utils.DistinctIDs(sections, productTypeID))
+			if err != nil {
+				return nil, err
+			}
+
+			for i, productType := range utils.Related(sections, productTypeID, productTypes, func(p internal.ProductType) int { return p.ID }) {
+				relations[i].ProductType = productType
+			}
+		}
+	}
+
+	return relations, nil
 }
 
 // GetByID Get the section by id, if sections does not exist, utils.ErrNotFound is returned
-func (s DefaultSectionService) GetByID(id int) (internal.Section, error) {
+func (s DefaultSectionService) GetByID(ctx context.Context, id int) (internal.Section, error) {
 	// Check if section exists
-	possibleSection, err := s.repo.GetByID(id)
+	possibleSection, err := s.repo.GetByID(ctx, id)
 
 	if err != nil {
 		return internal.Section{}, err
@@ -49,8 +114,8 @@ func (s DefaultSectionService) GetByID(id int) (internal.Section, error) {
 	return possibleSection, nil
 }
 
-func (s *DefaultSectionService) warehouseExistsByID(id int) error {
-	possibleWarehouse, err := s.warehouseService.GetByID(id)
+func (s *DefaultSectionService) warehouseExistsByID(ctx context.Context, id int) error {
+	possibleWarehouse, err := s.warehouseService.GetByID(ctx, id)
 	// When internal server error
 	if err != nil && !errors.Is(err, utils.ErrNotFound) {
 		return err
@@ -63,22 +128,61 @@ func (s *DefaultSectionService) warehouseExistsByID(id int) error {
 	return nil
 }
 
-func (s *DefaultSectionService) productTypeExistsByID(id int) error {
-	possibleProductType, err := s.productTypeService.GetProductTypeByID(id)
+func (s *DefaultSectionService) productTypeExistsByID(ctx context.Context, id int) (internal.ProductType, error) {
+	possibleProductType, err := s.productTypeService.GetProductTypeByID(ctx, id)
 	// When internal server error
 	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+		return internal.ProductType{}, err
+	}
+
+	if possibleProductType.ID == 0 {
+		return internal.ProductType{}, utils.EDependencyNotFound("product_type", "id: "+strconv.Itoa(id))
+	}
+
+	return possibleProductType, nil
+}
+
+// validateProductTypeRules checks the section temperature and the product types in stock against
+// the temperature ranges and incompatibilities of the product types
+func (s *DefaultSectionService) validateProductTypeRules(ctx context.Context, section internal.Section, sectionType internal.ProductType, storedTypeIDs []int) error {
+	storedTypes, err := s.productTypesByID(ctx, storedTypeIDs, map[int]internal.ProductType{sectionType.ID: sectionType})
+	if err != nil {
 		return err
 	}
 
-	if possibleProductType == (internal.ProductType{}) {
-		return utils.EDependencyNotFound("product_type", "id: "+strconv.Itoa(id))
+	violations := productTypeViolations(section, sectionType, storedTypes)
+	if len(violations) > 0 {
+		return utils.EBR(violations[0].Message)
 	}
 
 	return nil
 }
 
-func (s *DefaultSectionService) sectionExistsBySectionNumber(sectionNumber int) error {
-	possibleSection, err := s.repo.GetBySectionNumber(sectionNumber)
+// productTypesByID fetches the product types, reusing the ones already in cache
+func (s *DefaultSectionService) productTypesByID(ctx context.Context, ids []int, cache map[int]internal.ProductType) ([]internal.ProductType, error) {
+	productTypes := make([]internal.ProductType, 0, len(ids))
+
+	for _, id := range ids {
+		productType, ok := cache[id]
+		if !ok {
+			var err error
+
+			productType, err = s.productTypeService.GetProductTypeByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+
+			cache[id] = productType
+		}
+
+		productTypes = append(productTypes, productType)
+	}
+
+	return productTypes, nil
+}
+
+func (s *DefaultSectionService) sectionExistsBySectionNumber(ctx context.Context, sectionNumber int) error {
+	possibleSection, err := s.repo.GetBySectionNumber(ctx, sectionNumber)
 	if possibleSection != (internal.Section{}) {
 		return utils.EConflict("section", "id: "+strconv.Itoa(sectionNumber))
 	}
@@ -103,11 +207,19 @@ func (s *DefaultSectionService) validateLogicRules(section internal.Section) err
 		return utils.EBR("current_temperature cannot be less than -273.15 Celsius")
 	}
 
+	if section.MaximumVolume != nil && *section.MaximumVolume <= 0 {
+		return utils.EBR("maximum_volume must be greater than zero")
+	}
+
+	if section.MaximumWeight != nil && *section.MaximumWeight <= 0 {
+		return utils.EBR("maximum_weight must be greater than zero")
+	}
+
 	return nil
 }
 
 // Save a section, check the relations, zero value when applicable, and basic logic
-func (s *DefaultSectionService) Save(newSection internal.Section) (internal.Section, error) {
+func (s *DefaultSectionService) Save(ctx context.Context, newSection internal.Section) (internal.Section, error) {
 	// Zero value validation
 	if newSection.SectionNumber <= 0 {
 		return internal.Section{}, utils.EZeroValue("section_number")
@@ -121,11 +233,16 @@ func (s *DefaultSectionService) Save(newSection internal.Section) (internal.Sect
 		return internal.Section{}, utils.EZeroValue("product_type_id")
 	}
 
-	if err := s.warehouseExistsByID(newSection.WarehouseID); err != nil {
+	if err := internal.CheckWarehouseScope(ctx, newSection.WarehouseID); err != nil {
+		return internal.Section{}, err
+	}
+
+	if err := s.warehouseExistsByID(ctx, newSection.WarehouseID); err != nil {
 		return internal.Section{}, err
 	}
 
-	if err := s.productTypeExistsByID(newSection.ProductTypeID); err != nil {
+	sectionType, err := s.productTypeExistsByID(ctx, newSection.ProductTypeID)
+	if err != nil {
 		return internal.Section{}, err
 	}
 
@@ -133,12 +250,22 @@ func (s *DefaultSectionService) Save(newSection internal.Section) (internal.Sect
 		return internal.Section{}, err
 	}
 
-	if err := s.sectionExistsBySectionNumber(newSection.SectionNumber); err != nil {
+	if err := s.validateProductTypeRules(ctx, newSection, sectionType, nil); err != nil {
+		return internal.Section{}, err
+	}
+
+	if err := s.sectionExistsBySectionNumber(ctx, newSection.SectionNumber); err != nil {
 		return internal.Section{}, err
 	}
 
 	// Save if ok
-	err := s.repo.Save(&newSection)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Sections.Save(ctx, &newSection); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditCreate, auditEntity, newSection.ID, nil, newSection)
+	})
 	if err != nil {
 		return internal.Section{}, err
 	}
@@ -146,8 +273,8 @@ func (s *DefaultSectionService) Save(newSection internal.Section) (internal.Sect
 	return newSection, nil
 }
 
-func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionPointers) (internal.Section, error) {
-	section, err := s.repo.GetByID(id)
+func (s *DefaultSectionService) Update(ctx context.Context, id int, sectionToUpdate internal.SectionPointers) (internal.Section, error) {
+	section, err := s.repo.GetByID(ctx, id)
 
 	if err != nil && !errors.Is(err, utils.ErrNotFound) {
 		return internal.Section{}, err
@@ -158,6 +285,12 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 		return internal.Section{}, utils.ENotFound("section")
 	}
 
+	if err := utils.CheckVersion("section", sectionToUpdate.Version, section.Version); err != nil {
+		return internal.Section{}, err
+	}
+
+	current := section
+
 	// Check which field will be updated
 	if sectionToUpdate.SectionNumber != nil && *sectionToUpdate.SectionNumber != section.SectionNumber {
 		section.SectionNumber = *sectionToUpdate.SectionNumber
@@ -165,7 +298,7 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 			return internal.Section{}, utils.EZeroValue("section_number")
 		}
 
-		if err := s.sectionExistsBySectionNumber(section.SectionNumber); err != nil {
+		if err := s.sectionExistsBySectionNumber(ctx, section.SectionNumber); err != nil {
 			return internal.Section{}, err
 		}
 	}
@@ -190,13 +323,21 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 		section.MinimumTemperature = *sectionToUpdate.MinimumTemperature
 	}
 
+	if sectionToUpdate.MaximumVolume != nil {
+		section.MaximumVolume = optionalLimit(*sectionToUpdate.MaximumVolume)
+	}
+
+	if sectionToUpdate.MaximumWeight != nil {
+		section.MaximumWeight = optionalLimit(*sectionToUpdate.MaximumWeight)
+	}
+
 	if sectionToUpdate.ProductTypeID != nil {
 		section.ProductTypeID = *sectionToUpdate.ProductTypeID
 		if section.ProductTypeID == 0 {
 			return internal.Section{}, utils.EZeroValue("product_type_id")
 		}
 
-		if err := s.productTypeExistsByID(section.ProductTypeID); err != nil {
+		if _, err := s.productTypeExistsByID(ctx, section.ProductTypeID); err != nil {
 			return internal.Section{}, err
 		}
 	}
@@ -207,7 +348,11 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 			return internal.Section{}, utils.EZeroValue("warehouse_id")
 		}
 
-		if err := s.warehouseExistsByID(section.WarehouseID); err != nil {
+		if err := internal.CheckWarehouseScope(ctx, section.WarehouseID); err != nil {
+			return internal.Section{}, err
+		}
+
+		if err := s.warehouseExistsByID(ctx, section.WarehouseID); err != nil {
 			return internal.Section{}, err
 		}
 	}
@@ -216,8 +361,31 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 		return internal.Section{}, err
 	}
 
+	// The product types in stock must stand the new temperature and product type of the section
+	if sectionToUpdate.CurrentTemperature != nil || sectionToUpdate.ProductTypeID != nil {
+		sectionType, err := s.productTypeExistsByID(ctx, section.ProductTypeID)
+		if err != nil {
+			return internal.Section{}, err
+		}
+
+		storedTypeIDs, err := s.repo.GetSectionProductTypesByID(ctx, section.ID)
+		if err != nil {
+			return internal.Section{}, err
+		}
+
+		if err := s.validateProductTypeRules(ctx, section, sectionType, storedTypeIDs); err != nil {
+			return internal.Section{}, err
+		}
+	}
+
 	// Update
-	err = s.repo.Update(&section)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Sections.Update(ctx, &section); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, section.ID, current, section)
+	})
 
 	if err != nil {
 		return internal.Section{}, err
@@ -226,33 +394,39 @@ func (s *DefaultSectionService) Update(id int, sectionToUpdate internal.SectionP
 	return section, nil
 }
 
-func (s *DefaultSectionService) Delete(id int) error {
-	possibleSection, err := s.repo.GetByID(id)
+func (s *DefaultSectionService) Delete(ctx context.Context, id int, version int) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		possibleSection, err := repos.Sections.GetByID(ctx, id)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	if possibleSection == (internal.Section{}) {
-		return utils.ENotFound("section")
-	}
+		if possibleSection == (internal.Section{}) {
+			return utils.ENotFound("section")
+		}
 
-	err = s.repo.Delete(id)
+		if err = utils.CheckVersion("section", version, possibleSection.Version); err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
+		err = repos.Sections.Delete(ctx, id, possibleSection.Version)
 
-	return nil
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, possibleSection, nil)
+	})
 }
 
-func (s *DefaultSectionService) GetSectionProductsReport(id int) ([]internal.SectionProductsReport, error) {
+func (s *DefaultSectionService) GetSectionProductsReport(ctx context.Context, id int) ([]internal.SectionProductsReport, error) {
 	var report []internal.SectionProductsReport
 
 	var err error
 
 	if id == 0 {
-		report, err = s.repo.GetSectionProductsReport()
+		report, err = s.repo.GetSectionProductsReport(ctx)
 
 		if err != nil {
 			return nil, err
@@ -260,7 +434,7 @@ func (s *DefaultSectionService) GetSectionProductsReport(id int) ([]internal.Sec
 
 		return report, nil
 	} else {
-		_, err := s.repo.GetByID(id)
+		_, err := s.repo.GetByID(ctx, id)
 		if err != nil {
 			if errors.Is(err, utils.ErrNotFound) {
 				return nil, utils.ENotFound("section")
@@ -268,7 +442,7 @@ func (s *DefaultSectionService) GetSectionProductsReport(id int) ([]internal.Sec
 			return nil, err
 		}
 
-		report, err = s.repo.GetSectionProductsReportByID(id)
+		report, err = s.repo.GetSectionProductsReportByID(ctx, id)
 
 		if err != nil {
 			return nil, err
@@ -277,3 +451,200 @@ func (s *DefaultSectionService) GetSectionProductsReport(id int) ([]internal.Sec
 		return report, nil
 	}
 }
+
+// GetSectionProductsReportPage returns up to limit reports of the sections after afterSectionID
+func (s *DefaultSectionService) GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]internal.SectionProductsReport, error) {
+	return s.repo.GetSectionProductsReportPage(ctx, afterSectionID, limit)
+}
+
+// GetSectionCapacityReport returns the volume and weight used in a section, or in all of them when id is 0
+func (s *DefaultSectionService) GetSectionCapacityReport(ctx context.Context, id int) ([]internal.SectionCapacityReport, error) {
+	var reports []internal.SectionCapacityReport
+
+	if id == 0 {
+		var err error
+
+		reports, err = s.repo.GetSectionCapacityReport(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		report, err := s.repo.GetSectionCapacityReportByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return nil, utils.ENotFound("section")
+			}
+
+			return nil, err
+		}
+
+		reports = []internal.SectionCapacityReport{report}
+	}
+
+	for i := range reports {
+		reports[i].VolumeUsage = usage(reports[i].UsedVolume, reports[i].MaximumVolume)
+		reports[i].WeightUsage = usage(reports[i].UsedWeight, reports[i].MaximumWeight)
+	}
+
+	return reports, nil
+}
+
+// GetPutawaySections suggests the sections for the product type of the product that can take
+// the quantity, in base units, within their volume and weight limits. Tightest fits come first
+// and the sections without limits last
+func (s *DefaultSectionService) GetPutawaySections(ctx context.Context, productID, quantity int) ([]internal.SectionPutaway, error) {
+	if quantity <= 0 {
+		return nil, utils.EBR("quantity must be greater than zero")
+	}
+
+	product, err := s.productService.GetProductByID(ctx, productID)
+	if errors.Is(err, utils.ErrNotFound) {
+		return nil, utils.EDependencyNotFound("product", "id: "+strconv.Itoa(productID))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := s.repo.GetSectionCapacityReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := product.Volume() * float64(quantity)
+	weight := product.NetWeight * float64(quantity)
+
+	sections := []internal.SectionPutaway{}
+
+	for _, report := range reports {
+		if report.ProductTypeID != product.ProductType || !report.Fits(volume, weight) {
+			continue
+		}
+
+		sections = append(sections, internal.SectionPutaway{
+			SectionID:       report.SectionID,
+			SectionNumber:   report.SectionNumber,
+			WarehouseID:     report.WarehouseID,
+			RemainingVolume: remaining(report.UsedVolume+volume, report.MaximumVolume),
+			RemainingWeight: remaining(report.UsedWeight+weight, report.MaximumWeight),
+		})
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		left, right := sections[i].RemainingVolume, sections[j].RemainingVolume
+		if left == nil || right == nil {
+			return left != nil
+		}
+
+		return *left < *right
+	})
+
+	return sections, nil
+}
+
+// GetSectionViolations lists the temperature and incompatibility rules of the product types
+// currently broken by every section
+func (s *DefaultSectionService) GetSectionViolations(ctx context.Context) ([]internal.SectionViolation, error) {
+	sections, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storedTypeIDs, err := s.repo.GetSectionProductTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := map[int]internal.ProductType{}
+	violations := []internal.SectionViolation{}
+
+	for _, section := range sections {
+		sectionTypes, err := s.productTypesByID(ctx, []int{section.ProductTypeID}, cache)
+		if err != nil {
+			return nil, err
+		}
+
+		storedTypes, err := s.productTypesByID(ctx, storedTypeIDs[section.ID], cache)
+		if err != nil {
+			return nil, err
+		}
+
+		violations = append(violations, productTypeViolations(section, sectionTypes[0], storedTypes)...)
+	}
+
+	return violations, nil
+}
+
+// productTypeViolations checks the section product type and the product types in stock against
+// the section temperature and each other
+func productTypeViolations(section internal.Section, sectionType internal.ProductType, storedTypes []internal.ProductType) []internal.SectionViolation {
+	productTypes := []internal.ProductType{sectionType}
+
+	for _, productType := range storedTypes {
+		if productType.ID != sectionType.ID {
+			productTypes = append(productTypes, productType)
+		}
+	}
+
+	violations := []internal.SectionViolation{}
+
+	for i, productType := range productTypes {
+		if !productType.AcceptsTemperature(section.CurrentTemperature) {
+			violations = append(violations, internal.SectionViolation{
+				SectionID:     section.ID,
+				SectionNumber: section.SectionNumber,
+				Rule:          internal.SectionViolationTemperature,
+				ProductTypeID: productType.ID,
+				Message: "section temperature " + strconv.FormatFloat(section.CurrentTemperature, 'f', -1, 64) +
+					" is out of the range of product type " + strconv.Itoa(productType.ID),
+			})
+		}
+
+		for _, other := range productTypes[i+1:] {
+			if !productType.CompatibleWith(other.ID) || !other.CompatibleWith(productType.ID) {
+				violations = append(violations, internal.SectionViolation{
+					SectionID:                section.ID,
+					SectionNumber:            section.SectionNumber,
+					Rule:                     internal.SectionViolationIncompatibility,
+					ProductTypeID:            productType.ID,
+					ConflictingProductTypeID: other.ID,
+					Message: "product type " + strconv.Itoa(productType.ID) + " cannot share a section with product type " +
+						strconv.Itoa(other.ID),
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// optionalLimit returns nil for a zero limit, meaning the section has no such limit
+func optionalLimit(limit float64) *float64 {
+	if limit == 0 {
+		return nil
+	}
+
+	return &limit
+}
+
+// usage returns the percentage of the limit in use, nil when there is no limit
+func usage(used float64, limit *float64) *float64 {
+	if limit == nil {
+		return nil
+	}
+
+	percentage := math.Round(used/(*limit)*10000) / 100
+
+	return &percentage
+}
+
+// remaining returns what is left of the limit, nil when there is no limit
+func remaining(used float64, limit *float64) *float64 {
+	if limit == nil {
+		return nil
+	}
+
+	left := *limit - used
+
+	return &left
+}
@@ -1,6 +1,7 @@
 package section
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -11,68 +12,335 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.SectionRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Sections: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockSectionRepository struct {
 	mock.Mock
 }
 
-func (m *MockSectionRepository) GetAll() ([]internal.Section, error) {
+func (m *MockSectionRepository) GetAll(ctx context.Context) ([]internal.Section, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Section), args.Error(1)
 }
 
-func (m *MockSectionRepository) Save(section *internal.Section) error {
+func (m *MockSectionRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Section, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Section), args.Error(1)
+}
+
+func (m *MockSectionRepository) Save(ctx context.Context, section *internal.Section) error {
 	args := m.Called(section)
 	return args.Error(0)
 }
 
-func (m *MockSectionRepository) Update(section *internal.Section) error {
+func (m *MockSectionRepository) Update(ctx context.Context, section *internal.Section) error {
 	args := m.Called(section)
 	return args.Error(0)
 }
 
-func (m *MockSectionRepository) GetByID(id int) (internal.Section, error) {
+func (m *MockSectionRepository) GetByID(ctx context.Context, id int) (internal.Section, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Section), args.Error(1)
+}
+
+func (m *MockSectionRepository) GetByIDForUpdate(ctx context.Context, id int) (internal.Section, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (m *MockSectionRepository) GetBySectionNumber(sectionNumber int) (internal.Section, error) {
+func (m *MockSectionRepository) GetBySectionNumber(ctx context.Context, sectionNumber int) (internal.Section, error) {
 	args := m.Called(sectionNumber)
 	return args.Get(0).(internal.Section), args.Error(1)
 }
 
-func (m *MockSectionRepository) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockSectionRepository) Delete(ctx context.Context, id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
-func (m *MockSectionRepository) GetSectionProductsReport() ([]internal.SectionProductsReport, error) {
+func (m *MockSectionRepository) GetSectionProductsReport(ctx context.Context) ([]internal.SectionProductsReport, error) {
 	args := m.Called()
 	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
 }
 
-func (m *MockSectionRepository) GetSectionProductsReportByID(id int) ([]internal.SectionProductsReport, error) {
+func (m *MockSectionRepository) GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]internal.SectionProductsReport, error) {
+	args := m.Called(afterSectionID, limit)
+	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
+}
+
+func (m *MockSectionRepository) GetSectionProductsReportByID(ctx context.Context, id int) ([]internal.SectionProductsReport, error) {
 	args := m.Called(id)
 	return args.Get(0).([]internal.SectionProductsReport), args.Error(1)
 }
 
+func (m *MockSectionRepository) GetSectionCapacityReport(ctx context.Context) ([]internal.SectionCapacityReport, error) {
+	args := m.Called()
+	return args.Get(0).([]internal.SectionCapacityReport), args.Error(1)
+}
+
+func (m *MockSectionRepository) GetSectionCapacityReportByID(ctx context.Context, id int) (internal.SectionCapacityReport, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.SectionCapacityReport), args.Error(1)
+}
+
+func (m *MockSectionRepository) GetSectionProductTypes(ctx context.Context) (map[int][]int, error) {
+	args := m.Called()
+	return args.Get(0).(map[int][]int), args.Error(1)
+}
+
+func (m *MockSectionRepository) GetSectionProductTypesByID(ctx context.Context, id int) ([]int, error) {
+	args := m.Called(id)
+	return args.Get(0).([]int), args.Error(1)
+}
+
 type MockSectionWarehouseService struct {
 	mock.Mock
 }
 
-func (m *MockSectionWarehouseService) GetByID(id int) (internal.Warehouse, error) {
+func (m *MockSectionWarehouseService) GetByID(ctx context.Context, id int) (internal.Warehouse, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
+func (m *MockSectionWarehouseService) GetByIDs(ctx context.Context, ids []int) ([]internal.Warehouse, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Warehouse), args.Error(1)
+}
+
 type MockSectionProductTypeService struct {
 	mock.Mock
 }
 
-func (m *MockSectionProductTypeService) GetProductTypeByID(id int) (internal.ProductType, error) {
+func (m *MockSectionProductTypeService) GetProductTypeByID(ctx context.Context, id int) (internal.ProductType, error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.ProductType), args.Error(1)
 }
 
+func (m *MockSectionProductTypeService) GetProductTypesByIDs(ctx context.Context, ids []int) ([]internal.ProductType, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.ProductType), args.Error(1)
+}
+
+type MockSectionProductService struct {
+	mock.Mock
+}
+
+func (m *MockSectionProductService) GetProductByID(ctx context.Context, id int) (internal.Product, error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
+func TestUnitSection_GetSectionCapacityReport(t *testing.T) {
+	internalError := errors.New("internal error")
+	maximumVolume := float64(8000)
+
+	t.Run("GIVEN a id == 0, WHEN no errors, RETURN the usage of the limited sections", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetSectionCapacityReport").Return([]internal.SectionCapacityReport{
+			{SectionID: 1, UsedVolume: 2000, MaximumVolume: &maximumVolume, UsedWeight: 20},
+			{SectionID: 2, UsedVolume: 1000, UsedWeight: 10},
+		}, nil)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		reports, err := service.GetSectionCapacityReport(context.Background(), 0)
+		require.NoError(s, err)
+		require.Len(s, reports, 2)
+		require.Equal(s, 25.0, *reports[0].VolumeUsage)
+		require.Nil(s, reports[0].WeightUsage)
+		require.Nil(s, reports[1].VolumeUsage)
+	})
+
+	t.Run("GIVEN a id != 0, WHEN section does not exist, RETURN utils.ErrNotFound", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetSectionCapacityReportByID", 1).Return(internal.SectionCapacityReport{}, utils.ErrNotFound)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		reports, err := service.GetSectionCapacityReport(context.Background(), 1)
+		require.ErrorIs(s, err, utils.ErrNotFound)
+		require.Nil(s, reports)
+	})
+
+	t.Run("GIVEN a id != 0, WHEN calling repo.GetSectionCapacityReportByID(), RETURN internal error", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetSectionCapacityReportByID", 1).Return(internal.SectionCapacityReport{}, internalError)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		reports, err := service.GetSectionCapacityReport(context.Background(), 1)
+		require.ErrorIs(s, err, internalError)
+		require.Nil(s, reports)
+	})
+}
+
+func TestUnitSection_GetPutawaySections(t *testing.T) {
+	small, large, weight := float64(5000), float64(10000), float64(100)
+
+	reports := []internal.SectionCapacityReport{
+		{SectionID: 1, ProductTypeID: 1},
+		{SectionID: 2, ProductTypeID: 1, MaximumVolume: &large, UsedVolume: 2000},
+		{SectionID: 3, ProductTypeID: 1, MaximumVolume: &small},
+		{SectionID: 4, ProductTypeID: 1, MaximumVolume: &large, MaximumWeight: &weight, UsedWeight: 80},
+		{SectionID: 5, ProductTypeID: 2},
+	}
+
+	t.Run("GIVEN a product and quantity, WHEN no errors, RETURN the sections it fits in, tightest first", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetSectionCapacityReport").Return(reports, nil)
+		products := new(MockSectionProductService)
+		products.On("GetProductByID", 1).Return(mockProduct, nil)
+		service := NewBasicSectionService(repo, nil, nil, products, newMockUnitOfWork(repo))
+
+		sections, err := service.GetPutawaySections(context.Background(), 1, 2)
+		require.NoError(s, err)
+
+		ids := []int{}
+		for _, section := range sections {
+			ids = append(ids, section.SectionID)
+		}
+
+		require.Equal(s, []int{3, 2, 1}, ids)
+		require.Equal(s, float64(1000), *sections[0].RemainingVolume)
+		require.Nil(s, sections[2].RemainingVolume)
+	})
+
+	t.Run("GIVEN a zero quantity, RETURN utils.ErrInvalidArguments", func(s *testing.T) {
+		service := NewBasicSectionService(new(MockSectionRepository), nil, nil, new(MockSectionProductService), nil)
+		sections, err := service.GetPutawaySections(context.Background(), 1, 0)
+		require.ErrorIs(s, err, utils.ErrInvalidArguments)
+		require.Nil(s, sections)
+	})
+
+	t.Run("GIVEN a product that doesn't exist, RETURN utils.ErrInvalidArguments", func(s *testing.T) {
+		products := new(MockSectionProductService)
+		products.On("GetProductByID", 1).Return(internal.Product{}, utils.ErrNotFound)
+		service := NewBasicSectionService(new(MockSectionRepository), nil, nil, products, nil)
+		sections, err := service.GetPutawaySections(context.Background(), 1, 2)
+		require.ErrorIs(s, err, utils.ErrInvalidArguments)
+		require.Nil(s, sections)
+	})
+
+	t.Run("GIVEN a product that can't be read, RETURN its error", func(s *testing.T) {
+		failed := errors.New("connection lost")
+		products := new(MockSectionProductService)
+		products.On("GetProductByID", 1).Return(internal.Product{}, failed)
+		service := NewBasicSectionService(new(MockSectionRepository), nil, nil, products, nil)
+		sections, err := service.GetPutawaySections(context.Background(), 1, 2)
+		require.ErrorIs(s, err, failed)
+		require.NotErrorIs(s, err, utils.ErrInvalidArguments)
+		require.Nil(s, sections)
+	})
+}
+
+func TestUnitSection_ProductTypeRules(t *testing.T) {
+	minimum, maximum := float64(-2), float64(4)
+	meat := internal.ProductType{ID: 1, Description: "Meat", MinimumTemperature: &minimum, MaximumTemperature: &maximum, IncompatibleWith: []int{2}}
+	fruits := internal.ProductType{ID: 2, Description: "Fruits", IncompatibleWith: []int{1}}
+
+	t.Run("GIVEN a section warmer than its product type range, WHEN saving, RETURN utils.ErrInvalidArguments", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		warehouseService := new(MockSectionWarehouseService)
+		warehouseService.On("GetByID", 1).Return(mockWarehouse, nil)
+		productTypeService := new(MockSectionProductTypeService)
+		productTypeService.On("GetProductTypeByID", 1).Return(meat, nil)
+
+		service := NewBasicSectionService(repo, warehouseService, productTypeService, nil, newMockUnitOfWork(repo))
+		_, err := service.Save(context.Background(), internal.Section{SectionNumber: 1, WarehouseID: 1, ProductTypeID: 1, CurrentTemperature: 6})
+		require.ErrorIs(s, err, utils.ErrInvalidArguments)
+		require.ErrorContains(s, err, "section temperature 6 is out of the range of product type 1")
+		repo.AssertNotCalled(s, "Save", mock.Anything)
+	})
+
+	t.Run("GIVEN a section storing fruits, WHEN changing it to meat, RETURN utils.ErrInvalidArguments", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetByID", 1).Return(mockSection, nil)
+		repo.On("GetSectionProductTypesByID", 1).Return([]int{2}, nil)
+		productTypeService := new(MockSectionProductTypeService)
+		productTypeService.On("GetProductTypeByID", 1).Return(meat, nil)
+		productTypeService.On("GetProductTypeByID", 2).Return(fruits, nil)
+
+		service := NewBasicSectionService(repo, nil, productTypeService, nil, newMockUnitOfWork(repo))
+		_, err := service.Update(context.Background(), 1, internal.SectionPointers{ProductTypeID: &one})
+		require.ErrorIs(s, err, utils.ErrInvalidArguments)
+		require.ErrorContains(s, err, "product type 1 cannot share a section with product type 2")
+		repo.AssertNotCalled(s, "Update", mock.Anything)
+	})
+
+	t.Run("GIVEN sections breaking the rules, WHEN getting the violations, RETURN them", func(s *testing.T) {
+		warm := mockSection
+		warm.CurrentTemperature = 6
+		mixed := mockSection2
+		mixed.CurrentTemperature = 2
+
+		repo := new(MockSectionRepository)
+		repo.On("GetAll").Return([]internal.Section{warm, mixed}, nil)
+		repo.On("GetSectionProductTypes").Return(map[int][]int{2: {1, 2}}, nil)
+		productTypeService := new(MockSectionProductTypeService)
+		productTypeService.On("GetProductTypeByID", 1).Return(meat, nil).Once()
+		productTypeService.On("GetProductTypeByID", 2).Return(fruits, nil).Once()
+
+		service := NewBasicSectionService(repo, nil, productTypeService, nil, newMockUnitOfWork(repo))
+		violations, err := service.GetSectionViolations(context.Background())
+		require.NoError(s, err)
+		require.Equal(s, []internal.SectionViolation{
+			{SectionID: 1, SectionNumber: 1, Rule: internal.SectionViolationTemperature, ProductTypeID: 1,
+				Message: "section temperature 6 is out of the range of product type 1"},
+			{SectionID: 2, SectionNumber: 2, Rule: internal.SectionViolationIncompatibility, ProductTypeID: 2, ConflictingProductTypeID: 1,
+				Message: "product type 2 cannot share a section with product type 1"},
+		}, violations)
+	})
+}
+
+func TestUnitSection_WarehouseScope(t *testing.T) {
+	operator := internal.WithPrincipal(context.Background(),
+		internal.Principal{UserID: 1, Role: internal.RoleWarehouseOperator, WarehouseIDs: []int{1}})
+
+	t.Run("GIVEN an operator, WHEN saving a section of a warehouse not assigned, RETURN utils.ErrForbidden", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		_, err := service.Save(operator, mockSection2)
+		require.ErrorIs(s, err, utils.ErrForbidden)
+		repo.AssertNotCalled(s, "Save", mock.Anything)
+	})
+
+	t.Run("GIVEN an operator, WHEN moving a section to a warehouse not assigned, RETURN utils.ErrForbidden", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetByID", 1).Return(mockSection, nil)
+
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		_, err := service.Update(operator, 1, internal.SectionPointers{WarehouseID: &two})
+		require.ErrorIs(s, err, utils.ErrForbidden)
+		repo.AssertNotCalled(s, "Update", mock.Anything)
+	})
+}
+
 var (
 	mockSection = internal.Section{
 		ID:                 1,
@@ -144,16 +412,16 @@ func TestUnitSection_GetAll(t *testing.T) {
 	t.Run("WHEN repository returns no error, RETURN successfully", func(t *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetAll").Return([]internal.Section{mockSection}, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		sections, _ := service.GetAll()
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		sections, _ := service.GetAll(context.Background())
 		require.Equal(t, 1, len(sections))
 	})
 
 	t.Run("WHEN repository returns some error, RETURN the error", func(t *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetAll").Return([]internal.Section{}, errors.New("some error"))
-		service := NewBasicSectionService(repo, nil, nil)
-		sections, _ := service.GetAll()
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		sections, _ := service.GetAll(context.Background())
 		require.Equal(t, 0, len(sections))
 	})
 
@@ -163,8 +431,8 @@ func TestUnitSection_GetById(t *testing.T) {
 	t.Run("GIVEN a valid id, WHEN section exists, RETURN successfully", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(mockSection, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		section, err := service.GetByID(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		section, err := service.GetByID(context.Background(), 1)
 		require.Equal(t, mockSection, section)
 		require.Nil(t, err)
 	})
@@ -172,8 +440,8 @@ func TestUnitSection_GetById(t *testing.T) {
 	t.Run("GIVEN a valid id, WHEN section does not exists, RETURN successfully", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(internal.Section{}, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		section, err := service.GetByID(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		section, err := service.GetByID(context.Background(), 1)
 		require.Empty(t, section)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 	})
@@ -181,8 +449,8 @@ func TestUnitSection_GetById(t *testing.T) {
 	t.Run("GIVEN a valid id, WHEN calling GetByID, RETURN internal error", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(internal.Section{}, errors.New("internal error"))
-		service := NewBasicSectionService(repo, nil, nil)
-		section, err := service.GetByID(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		section, err := service.GetByID(context.Background(), 1)
 		require.Empty(t, section)
 		require.Equal(t, err.Error(), "internal error")
 	})
@@ -192,31 +460,43 @@ func TestUnitSection_Delete(t *testing.T) {
 	t.Run("GIVEN a valid id, WHEN section exists, DELETE successfully", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(mockSection, nil)
-		repo.On("Delete", 1).Return(nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		err := service.Delete(1)
+		repo.On("Delete", 1, 0).Return(nil)
+		uow := newMockUnitOfWork(repo)
+		service := NewBasicSectionService(repo, nil, nil, nil, uow)
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
 		require.Nil(t, err)
+		require.Len(t, uow.entries, 1)
+		require.Equal(t, internal.AuditDelete, uow.entries[0].Action)
+		require.Equal(t, "sections", uow.entries[0].Entity)
+	})
+	t.Run("GIVEN a valid id, WHEN section was changed, RETURN utils.ErrPreconditionFailed", func(s *testing.T) {
+		repo := new(MockSectionRepository)
+		repo.On("GetByID", 1).Return(internal.Section{ID: 1, Version: 2}, nil)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		err := service.Delete(context.Background(), 1, 1)
+		require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 	})
 	t.Run("GIVEN a valid id, WHEN section does not exists, RETURN utils.ErrNotFound", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(internal.Section{}, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		err := service.Delete(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 	})
 	t.Run("GIVEN a valid id, WHEN calling GetByID, RETURN internal error", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(internal.Section{}, errors.New("internal error"))
-		service := NewBasicSectionService(repo, nil, nil)
-		err := service.Delete(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
 		require.Equal(t, err.Error(), "internal error")
 	})
 	t.Run("GIVEN a valid id, WHEN calling GetByID, RETURN internal error", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", 1).Return(mockSection, nil)
-		repo.On("Delete", 1).Return(errors.New("internal error"))
-		service := NewBasicSectionService(repo, nil, nil)
-		err := service.Delete(1)
+		repo.On("Delete", 1, 0).Return(errors.New("internal error"))
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		err := service.Delete(context.Background(), 1, utils.AnyVersion)
 		require.Equal(t, err.Error(), "internal error")
 	})
 }
@@ -296,6 +576,15 @@ func TestUnitSection_Save(t *testing.T) {
 				return internal.Section{}, utils.EBR("minimum_capacity cannot be greater than maximum_capacity")
 			},
 		},
+		{
+			Name: "GIVEN a non valid section, WHEN maximum_volume is negative, RETURNS error utils.ErrInvalidArguments",
+			Data: internal.Section{SectionNumber: 1, WarehouseID: 1, ProductTypeID: 1, MinimumCapacity: 3, MaximumCapacity: 4, MaximumVolume: &mThreeHundred},
+			Mock: func(repo *MockSectionRepository, warehouseService *MockSectionWarehouseService, productTypeService *MockSectionProductTypeService) (internal.Section, error) {
+				warehouseService.On("GetByID", mock.Anything).Return(mockWarehouse, nil)
+				productTypeService.On("GetProductTypeByID", mock.Anything).Return(mockProductType, nil)
+				return internal.Section{}, utils.EBR("maximum_volume must be greater than zero")
+			},
+		},
 		{
 			Name: "GIVEN a non valid section, WHEN minimum_temperature is less than -273.15 Celsius, RETURNS error utils.ErrInvalidArguments",
 			Data: internal.Section{SectionNumber: 1, WarehouseID: 1, ProductTypeID: 1, MinimumCapacity: 3, MaximumCapacity: 4, MinimumTemperature: -300},
@@ -367,8 +656,8 @@ func TestUnitSection_Save(t *testing.T) {
 			// Mock and get the expected
 			expectedData, expectedError := scenario.Mock(repo, warehouseService, productTypeService)
 
-			service := NewBasicSectionService(repo, warehouseService, productTypeService)
-			savedSection, err := service.Save(scenario.Data)
+			service := NewBasicSectionService(repo, warehouseService, productTypeService, nil, newMockUnitOfWork(repo))
+			savedSection, err := service.Save(context.Background(), scenario.Data)
 			require.Equal(m, expectedData, savedSection)
 			if expectedError == nil {
 				require.Nil(m, err)
@@ -385,10 +674,12 @@ func TestUnitSection_Update_Valid(t *testing.T) {
 	productTypeService := new(MockSectionProductTypeService)
 
 	repo.On("GetByID", mock.Anything).Return(mockSection, nil)
+	repo.On("GetSectionProductTypesByID", mockSection.ID).Return([]int{}, nil)
 	repo.On("Update", mock.Anything).Return(nil)
+	productTypeService.On("GetProductTypeByID", mockSection.ProductTypeID).Return(mockProductType, nil)
 
-	service := NewBasicSectionService(repo, warehouseService, productTypeService)
-	savedSection, err := service.Update(1, internal.SectionPointers{
+	service := NewBasicSectionService(repo, warehouseService, productTypeService, nil, newMockUnitOfWork(repo))
+	savedSection, err := service.Update(context.Background(), 1, internal.SectionPointers{
 		CurrentCapacity:    &two,
 		MaximumCapacity:    &three,
 		MinimumCapacity:    &one,
@@ -558,8 +849,8 @@ func TestUnitSection_Update_NonValid(t *testing.T) {
 			// Mock and get the expected
 			expectedData, expectedError := scenario.Mock(repo, warehouseService, productTypeService)
 
-			service := NewBasicSectionService(repo, warehouseService, productTypeService)
-			savedSection, err := service.Update(scenario.DataID, scenario.Data)
+			service := NewBasicSectionService(repo, warehouseService, productTypeService, nil, newMockUnitOfWork(repo))
+			savedSection, err := service.Update(context.Background(), scenario.DataID, scenario.Data)
 			require.Equal(m, expectedData, savedSection)
 			if expectedError == nil {
 				require.Nil(m, err)
@@ -576,16 +867,16 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 	t.Run("GIVEN a id == 0, WHEN no errors, RETURN successfully", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetSectionProductsReport").Return(mockSectionProductsReport, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(0)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 0)
 		require.Equal(t, mockSectionProductsReport, report)
 		require.Nil(t, err)
 	})
 	t.Run("GIVEN a id == 0, WHEN calling repo.GetSectionProductsReport(), RETURN internal error", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetSectionProductsReport").Return([]internal.SectionProductsReport{}, internalError)
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(0)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 0)
 		require.ErrorIs(t, err, internalError)
 		require.Nil(t, report)
 	})
@@ -593,8 +884,8 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 	t.Run("GIVEN a id != 0, WHEN calling repo.GetByID(), RETURN internal error", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", mock.Anything).Return(internal.Section{}, internalError)
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 1)
 		require.ErrorIs(t, err, internalError)
 		require.Nil(t, report)
 	})
@@ -602,8 +893,8 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 	t.Run("GIVEN a id != 0, WHEN calling repo.GetByID(), RETURN utils.ErrNotFound", func(s *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", mock.Anything).Return(internal.Section{}, utils.ENotFound("section"))
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 1)
 		require.ErrorIs(t, err, utils.ErrNotFound)
 		require.Nil(t, report)
 	})
@@ -612,8 +903,8 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", mock.Anything).Return(mockSection, nil)
 		repo.On("GetSectionProductsReportByID", mock.Anything).Return([]internal.SectionProductsReport{}, internalError)
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 1)
 		require.ErrorIs(t, err, internalError)
 		require.Nil(s, report)
 	})
@@ -622,8 +913,8 @@ func TestUnitSection_GetSectionProductsReport(t *testing.T) {
 		repo := new(MockSectionRepository)
 		repo.On("GetByID", mock.Anything).Return(mockSection, nil)
 		repo.On("GetSectionProductsReportByID", mock.Anything).Return(mockSectionProductsReport, nil)
-		service := NewBasicSectionService(repo, nil, nil)
-		report, err := service.GetSectionProductsReport(1)
+		service := NewBasicSectionService(repo, nil, nil, nil, newMockUnitOfWork(repo))
+		report, err := service.GetSectionProductsReport(context.Background(), 1)
 		require.NotNil(s, report)
 		require.NoError(s, err)
 	})
@@ -1,5 +1,10 @@
 package internal
 
+import (
+	"context"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type Section struct {
 	ID                 int     `json:"id"`
 	SectionNumber      int     `json:"section_number"`
@@ -8,8 +13,14 @@ type Section struct {
 	MinimumCapacity    int     `json:"minimum_capacity"`
 	CurrentTemperature float64 `json:"current_temperature"`
 	MinimumTemperature float64 `json:"minimum_temperature"`
-	ProductTypeID      int     `json:"warehouse_id"`
-	WarehouseID        int     `json:"product_type_id"`
+	ProductTypeID      int     `json:"product_type_id"`
+	WarehouseID        int     `json:"warehouse_id"`
+	// MaximumVolume and MaximumWeight are optional limits, in the units of the product dimensions
+	// and net weight, checked against the batches stored in the section
+	MaximumVolume *float64 `json:"maximum_volume,omitempty"`
+	MaximumWeight *float64 `json:"maximum_weight,omitempty"`
+	// Version is bumped by every change, it is the ETag of the section
+	Version int `json:"version,omitempty"`
 }
 
 type SectionPointers struct {
@@ -21,6 +32,11 @@ type SectionPointers struct {
 	MinimumTemperature *float64 `json:"minimum_temperature"`
 	ProductTypeID      *int     `json:"product_type_id"`
 	WarehouseID        *int     `json:"warehouse_id"`
+	// MaximumVolume and MaximumWeight set to 0 remove the limit
+	MaximumVolume *float64 `json:"maximum_volume"`
+	MaximumWeight *float64 `json:"maximum_weight"`
+	// Version is the version of the section read by the client, from If-Match
+	Version int `json:"-"`
 }
 
 type SectionProductsReport struct {
@@ -29,29 +45,128 @@ type SectionProductsReport struct {
 	ProductsCount int `json:"products_count"`
 }
 
+// SectionCapacityReport is the usage of a section computed from the dimensions and weight
+// of the products of its batches times their current quantity, usages are percentages
+type SectionCapacityReport struct {
+	SectionID       int      `json:"section_id"`
+	SectionNumber   int      `json:"section_number"`
+	WarehouseID     int      `json:"warehouse_id"`
+	ProductTypeID   int      `json:"product_type_id"`
+	MaximumCapacity int      `json:"maximum_capacity"`
+	ProductsCount   int      `json:"products_count"`
+	UsedVolume      float64  `json:"used_volume"`
+	MaximumVolume   *float64 `json:"maximum_volume,omitempty"`
+	VolumeUsage     *float64 `json:"volume_usage,omitempty"`
+	UsedWeight      float64  `json:"used_weight"`
+	MaximumWeight   *float64 `json:"maximum_weight,omitempty"`
+	WeightUsage     *float64 `json:"weight_usage,omitempty"`
+}
+
+// Fits reports whether the section can take the extra volume and weight within its limits
+func (r SectionCapacityReport) Fits(volume, weight float64) bool {
+	if r.MaximumVolume != nil && r.UsedVolume+volume > *r.MaximumVolume {
+		return false
+	}
+
+	if r.MaximumWeight != nil && r.UsedWeight+weight > *r.MaximumWeight {
+		return false
+	}
+
+	return true
+}
+
+// SectionPutaway is a section suggested to store a quantity of a product, with the volume
+// and weight left after placing it, nil when the section has no such limit
+type SectionPutaway struct {
+	SectionID       int      `json:"section_id"`
+	SectionNumber   int      `json:"section_number"`
+	WarehouseID     int      `json:"warehouse_id"`
+	RemainingVolume *float64 `json:"remaining_volume,omitempty"`
+	RemainingWeight *float64 `json:"remaining_weight,omitempty"`
+}
+
+const (
+	// SectionViolationTemperature is a product type stored at a temperature out of its range
+	SectionViolationTemperature = "temperature"
+	// SectionViolationIncompatibility is a pair of incompatible product types sharing a section
+	SectionViolationIncompatibility = "incompatibility"
+)
+
+// SectionViolation is a product type rule broken by a section, its own product type or the
+// product types of the batches stored in it
+type SectionViolation struct {
+	SectionID                int    `json:"section_id"`
+	SectionNumber            int    `json:"section_number"`
+	Rule                     string `json:"rule"`
+	ProductTypeID            int    `json:"product_type_id"`
+	ConflictingProductTypeID int    `json:"conflicting_product_type_id,omitempty"`
+	Message                  string `json:"message"`
+}
+
+const (
+	// SectionIncludeWarehouse embeds the warehouse of a section in its responses
+	SectionIncludeWarehouse = "warehouse"
+	// SectionIncludeProductType embeds the product type of a section in its responses
+	SectionIncludeProductType = "product_type"
+)
+
+// SectionRelations are the entities related to a section embedded in its responses with include
+type SectionRelations struct {
+	Warehouse   *Warehouse   `json:"warehouse,omitempty"`
+	ProductType *ProductType `json:"product_type,omitempty"`
+}
+
 type (
 	SectionRepository interface {
-		GetAll() ([]Section, error)
-		Save(*Section) error
-		Update(*Section) error
-		GetByID(int) (Section, error)
-		GetBySectionNumber(int) (Section, error)
-		Delete(int) error
-		GetSectionProductsReport() ([]SectionProductsReport, error)
-		GetSectionProductsReportByID(int) ([]SectionProductsReport, error)
+		GetAll(ctx context.Context) ([]Section, error)
+		// List returns the sections of a resolved list query, one more than its limit when there are more
+		List(context.Context, utils.ListQuery) ([]Section, error)
+		Save(context.Context, *Section) error
+		Update(context.Context, *Section) error
+		GetByID(context.Context, int) (Section, error)
+		// GetByIDForUpdate returns the section locked until the end of the transaction of the repository
+		GetByIDForUpdate(context.Context, int) (Section, error)
+		GetBySectionNumber(context.Context, int) (Section, error)
+		// Delete removes the section when it is still in the version it was read
+		Delete(ctx context.Context, id int, version int) error
+		GetSectionProductsReport(ctx context.Context) ([]SectionProductsReport, error)
+		GetSectionProductsReportByID(context.Context, int) ([]SectionProductsReport, error)
+		// GetSectionProductsReportPage returns up to limit reports of the sections with an id greater
+		// than afterSectionID, ordered by section id
+		GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]SectionProductsReport, error)
+		GetSectionCapacityReport(ctx context.Context) ([]SectionCapacityReport, error)
+		GetSectionCapacityReportByID(context.Context, int) (SectionCapacityReport, error)
+		// GetSectionProductTypes returns, by section id, the product types of the batches in stock
+		GetSectionProductTypes(ctx context.Context) (map[int][]int, error)
+		GetSectionProductTypesByID(context.Context, int) ([]int, error)
 	}
 	SectionService interface {
-		GetAll() ([]Section, error)
-		Save(Section) (Section, error)
-		Update(int, SectionPointers) (Section, error)
-		GetByID(int) (Section, error)
-		Delete(int) error
-		GetSectionProductsReport(int) ([]SectionProductsReport, error)
+		GetAll(ctx context.Context) ([]Section, error)
+		List(context.Context, utils.ListQuery) ([]Section, utils.Pagination, error)
+		Save(context.Context, Section) (Section, error)
+		Update(context.Context, int, SectionPointers) (Section, error)
+		GetByID(context.Context, int) (Section, error)
+		// GetRelations returns the entities related to each section that were asked by include
+		GetRelations(ctx context.Context, sections []Section, include []string) ([]SectionRelations, error)
+		// Delete removes the section in the version given
+		Delete(ctx context.Context, id int, version int) error
+		GetSectionProductsReport(context.Context, int) ([]SectionProductsReport, error)
+		// GetSectionProductsReportPage returns up to limit reports of the sections after afterSectionID,
+		// the report of every section is exported a page at a time
+		GetSectionProductsReportPage(ctx context.Context, afterSectionID, limit int) ([]SectionProductsReport, error)
+		GetSectionCapacityReport(context.Context, int) ([]SectionCapacityReport, error)
+		GetPutawaySections(ctx context.Context, productID, quantity int) ([]SectionPutaway, error)
+		GetSectionViolations(ctx context.Context) ([]SectionViolation, error)
 	}
 	SectionWarehouseValidation interface {
-		GetByID(int) (Warehouse, error)
+		GetByID(context.Context, int) (Warehouse, error)
+		GetByIDs(context.Context, []int) ([]Warehouse, error)
 	}
 	SectionProductTypeValidation interface {
-		GetProductTypeByID(int) (ProductType, error)
+		GetProductTypeByID(context.Context, int) (ProductType, error)
+		GetProductTypesByIDs(context.Context, []int) ([]ProductType, error)
+	}
+	SectionProductValidation interface {
+		GetProductByID(context.Context, int) (Product, error)
 	}
 )
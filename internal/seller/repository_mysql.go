@@ -1,29 +1,41 @@
 package seller
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the sellers can be filtered and sorted by
+var listFields = utils.ListFields{
+	"id":           {Column: "`id`", Kind: utils.ListInt, Sortable: true},
+	"cid":          {Column: "`cid`", Kind: utils.ListInt, Sortable: true},
+	"company_name": {Column: "`company_name`", Kind: utils.ListString, Sortable: true},
+	"address":      {Column: "`address`", Kind: utils.ListString, Sortable: true},
+	"telephone":    {Column: "`telephone`", Kind: utils.ListString, Sortable: true},
+	"locality_id":  {Column: "`locality_id`", Kind: utils.ListInt, Sortable: true},
+}
+
 // MySQLSellerRepository is the mysql implementation of the seller repository
 type MySQLSellerRepository struct {
 	// db is the database connection to mysql
-	db *sql.DB
+	db utils.DBTX
 }
 
 // NewSellerRepository creates a new instance of the seller repository
-func NewSellerRepository(db *sql.DB) internal.SellerRepository {
+func NewSellerRepository(db utils.DBTX) internal.SellerRepository {
 	return &MySQLSellerRepository{db}
 }
 
 // GetAll returns all sellers from the database
-func (r *MySQLSellerRepository) GetAll() (sellers []internal.Seller, err error) {
+func (r *MySQLSellerRepository) GetAll(ctx context.Context) (sellers []internal.Seller, err error) {
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id` FROM `sellers`")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id`, `version` FROM `sellers` WHERE `deleted_at` IS NULL")
 	if err != nil {
 		return
 	}
@@ -33,7 +45,7 @@ func (r *MySQLSellerRepository) GetAll() (sellers []internal.Seller, err error)
 		// create a new seller
 		var seller internal.Seller
 
-		err = rows.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID)
+		err = rows.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID, &seller.Version)
 		if err != nil {
 			return
 		}
@@ -51,13 +63,43 @@ func (r *MySQLSellerRepository) GetAll() (sellers []internal.Seller, err error)
 	return
 }
 
+// List returns a page of sellers
+func (r *MySQLSellerRepository) List(ctx context.Context, query utils.ListQuery) (sellers []internal.Seller, err error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition(), internal.SellerCondition(ctx, "`id`"))
+
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id`, `deleted_at`, `version` FROM `sellers`"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var seller internal.Seller
+
+		err = rows.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID, &seller.DeletedAt, &seller.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		sellers = append(sellers, seller)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return sellers, nil
+}
+
 // GetByID returns a seller from the database by its id
-func (r *MySQLSellerRepository) GetByID(id int) (seller internal.Seller, err error) {
+func (r *MySQLSellerRepository) GetByID(ctx context.Context, id int) (seller internal.Seller, err error) {
 	// execute the query
-	row := r.db.QueryRow("SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id` FROM `sellers` WHERE `id` = ?", id)
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id`, `version` FROM `sellers` WHERE `id` = ? AND `deleted_at` IS NULL AND "+internal.SellerCondition(ctx, "`id`"), id)
 
 	// scan the row into the seller
-	err = row.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID)
+	err = row.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID, &seller.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			err = utils.ErrNotFound
@@ -69,12 +111,48 @@ func (r *MySQLSellerRepository) GetByID(id int) (seller internal.Seller, err err
 
 	return
 }
-func (r *MySQLSellerRepository) GetByCid(cid int) (seller internal.Seller, err error) {
+
+// GetByIDs returns the sellers with the given ids in a single query, the ids without a seller are ignored
+func (r *MySQLSellerRepository) GetByIDs(ctx context.Context, ids []int) (sellers []internal.Seller, err error) {
+	sellers = []internal.Seller{}
+	if len(ids) == 0 {
+		return sellers, nil
+	}
+
+	in, args := utils.InClause(ids)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id`, `version` FROM `sellers` WHERE `id` IN "+in, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var seller internal.Seller
+
+		err = rows.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID, &seller.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		sellers = append(sellers, seller)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return sellers, nil
+}
+
+func (r *MySQLSellerRepository) GetByCid(ctx context.Context, cid int) (seller internal.Seller, err error) {
 	// execute the query
-	row := r.db.QueryRow("SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id` FROM `sellers` WHERE `cid` = ?", cid)
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `cid`, `company_name`, `address`, `telephone`, `locality_id`, `version` FROM `sellers` WHERE `cid` = ?", cid)
 
 	// scan the row into the seller
-	err = row.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID)
+	err = row.Scan(&seller.ID, &seller.Cid, &seller.CompanyName, &seller.Address, &seller.Telephone, &seller.LocalityID, &seller.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			err = utils.ErrNotFound
@@ -88,9 +166,9 @@ func (r *MySQLSellerRepository) GetByCid(cid int) (seller internal.Seller, err e
 }
 
 // Create Save saves a seller into the database
-func (r *MySQLSellerRepository) Create(seller *internal.Seller) (err error) {
+func (r *MySQLSellerRepository) Create(ctx context.Context, seller *internal.Seller) (err error) {
 	// execute the query
-	result, err := r.db.Exec(
+	result, err := r.db.ExecContext(ctx,
 		"INSERT INTO `sellers` (`cid`, `company_name`, `address`, `telephone`, `locality_id`) VALUES (?, ?, ?, ?, ?)",
 		(*seller).Cid, (*seller).CompanyName, (*seller).Address, (*seller).Telephone, (*seller).LocalityID,
 	)
@@ -117,16 +195,50 @@ func (r *MySQLSellerRepository) Create(seller *internal.Seller) (err error) {
 
 	// set the id of the seller
 	(*seller).ID = int(id)
+	(*seller).Version = 1
 
 	return err
 }
 
-// Update updates a seller in the database
-func (r *MySQLSellerRepository) Update(seller *internal.Seller) (err error) {
+// CreateAll saves the sellers in a single transaction, none of them when one fails
+func (r *MySQLSellerRepository) CreateAll(ctx context.Context, sellers []*internal.Seller) error {
+	return utils.InTx(ctx, r.db, func(tx utils.DBTX) error {
+		statement, err := tx.PrepareContext(ctx, "INSERT INTO `sellers` (`cid`, `company_name`, `address`, `telephone`, `locality_id`) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer statement.Close()
+
+		for _, seller := range sellers {
+			result, err := statement.ExecContext(ctx, seller.Cid, seller.CompanyName, seller.Address, seller.Telephone, seller.LocalityID)
+			if err != nil {
+				var mysqlErr *mysql.MySQLError
+				if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+					return utils.ErrConflict
+				}
+
+				return err
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			seller.ID = int(id)
+			seller.Version = 1
+		}
+
+		return nil
+	})
+}
+
+// Update updates a seller in the database when it is still in the version it was read, and bumps its version
+func (r *MySQLSellerRepository) Update(ctx context.Context, seller *internal.Seller) (err error) {
 	// execute the query
-	_, err = r.db.Exec(
-		"UPDATE `sellers` SET `cid` = ?, `company_name` = ?, `address` = ?, `telephone` = ?, `locality_id` = ? WHERE `id` = ?",
-		(*seller).Cid, (*seller).CompanyName, (*seller).Address, (*seller).Telephone, (*seller).LocalityID, (*seller).ID,
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE `sellers` SET `cid` = ?, `company_name` = ?, `address` = ?, `telephone` = ?, `locality_id` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?",
+		(*seller).Cid, (*seller).CompanyName, (*seller).Address, (*seller).Telephone, (*seller).LocalityID, (*seller).ID, (*seller).Version,
 	)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -143,16 +255,137 @@ func (r *MySQLSellerRepository) Update(seller *internal.Seller) (err error) {
 		return
 	}
 
+	err = utils.CheckVersionUpdated(result, "seller")
+	if err != nil {
+		return
+	}
+
+	(*seller).Version++
+
 	return
 }
 
-// Delete deletes a seller from the database
-func (r *MySQLSellerRepository) Delete(id int) error {
+// Delete soft deletes a seller, its row is kept with the time it was deleted
+func (r *MySQLSellerRepository) Delete(ctx context.Context, id int, version int) error {
+	return utils.SoftDelete(ctx, r.db, "sellers", "seller", id, version, internal.SellerCondition(ctx, "id"))
+}
+
+// Restore clears the deletion of a soft deleted seller
+func (r *MySQLSellerRepository) Restore(ctx context.Context, id int) error {
+	return utils.Restore(ctx, r.db, "sellers", id, internal.SellerCondition(ctx, "id"))
+}
+
+// Purge removes for good the sellers deleted before a time, but the ones other records still refer to
+func (r *MySQLSellerRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, r.db, "sellers", before)
+}
+
+// GetProductsByType returns the number of products of a seller grouped by product type
+func (r *MySQLSellerRepository) GetProductsByType(ctx context.Context, sellerID int) (report []internal.SellerProductTypeCount, err error) {
 	// execute the query
-	_, err := r.db.Exec("DELETE FROM `sellers` WHERE `id` = ?", id)
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT pt.`id`, pt.`description`, COUNT(p.`id`) FROM `products` p "+
+			"INNER JOIN `product_types` pt ON pt.`id` = p.`product_type_id` "+
+			"WHERE p.`seller_id` = ? GROUP BY pt.`id`, pt.`description` ORDER BY pt.`id`",
+		sellerID,
+	)
 	if err != nil {
-		return err
+		return
 	}
+	defer rows.Close()
 
-	return err
+	// iterate over the rows
+	report = []internal.SellerProductTypeCount{}
+
+	for rows.Next() {
+		var row internal.SellerProductTypeCount
+
+		err = rows.Scan(&row.ProductTypeID, &row.Description, &row.ProductsCount)
+		if err != nil {
+			return
+		}
+
+		report = append(report, row)
+	}
+
+	// check for errors
+	err = rows.Err()
+
+	return
+}
+
+// GetStockByWarehouse returns the current quantity of a seller's product batches grouped by warehouse
+func (r *MySQLSellerRepository) GetStockByWarehouse(ctx context.Context, sellerID int) (report []internal.SellerWarehouseStock, err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT w.`id`, w.`warehouse_code`, IFNULL(SUM(pb.`current_quantity`), 0) FROM `product_batches` pb "+
+			"INNER JOIN `products` p ON p.`id` = pb.`product_id` "+
+			"INNER JOIN `sections` s ON s.`id` = pb.`section_id` "+
+			"INNER JOIN `warehouses` w ON w.`id` = s.`warehouse_id` "+
+			"WHERE p.`seller_id` = ? GROUP BY w.`id`, w.`warehouse_code` ORDER BY w.`id`",
+		sellerID,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	report = []internal.SellerWarehouseStock{}
+
+	for rows.Next() {
+		var row internal.SellerWarehouseStock
+
+		err = rows.Scan(&row.WarehouseID, &row.WarehouseCode, &row.Quantity)
+		if err != nil {
+			return
+		}
+
+		report = append(report, row)
+	}
+
+	// check for errors
+	err = rows.Err()
+
+	return
+}
+
+// GetSales returns the units sold and the revenue of a seller's products,
+// each purchase order accounts for one unit sold at the sale price of its product record
+func (r *MySQLSellerRepository) GetSales(ctx context.Context, sellerID int) (sales internal.SellerSales, err error) {
+	// execute the query
+	row := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(po.`id`), IFNULL(SUM(pr.`sale_price`), 0) FROM `purchase_orders` po "+
+			"INNER JOIN `product_records` pr ON pr.`id` = po.`product_record_id` "+
+			"INNER JOIN `products` p ON p.`id` = pr.`product_id` "+
+			"WHERE p.`seller_id` = ?",
+		sellerID,
+	)
+
+	// scan the row into the sales
+	err = row.Scan(&sales.UnitsSold, &sales.Revenue)
+
+	return
+}
+
+// GetNearExpiry returns the batches of a seller with stock whose due date falls within the next nearExpiryDays,
+// the batches already expired are not near expiry
+func (r *MySQLSellerRepository) GetNearExpiry(ctx context.Context, sellerID int, nearExpiryDays int) (nearExpiry internal.SellerNearExpiry, err error) {
+	// execute the query
+	row := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(pb.`id`), IFNULL(SUM(pb.`current_quantity`), 0) FROM `product_batches` pb "+
+			"INNER JOIN `products` p ON p.`id` = pb.`product_id` "+
+			"WHERE p.`seller_id` = ? AND pb.`current_quantity` > 0 AND pb.`due_date` >= CURDATE() AND pb.`due_date` <= DATE_ADD(NOW(), INTERVAL ? DAY)",
+		sellerID, nearExpiryDays,
+	)
+
+	// scan the row into the near expiry summary
+	err = row.Scan(&nearExpiry.Batches, &nearExpiry.Quantity)
+	if err != nil {
+		return
+	}
+
+	nearExpiry.Days = nearExpiryDays
+
+	return
 }
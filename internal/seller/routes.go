@@ -4,17 +4,21 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 func RegisterSellerRoutes(mux *chi.Mux, service internal.SellerService) error {
 	sellerHandler := handler.NewSellerHandler(service)
 
 	mux.Route("/api/v1/sellers", func(router chi.Router) {
-		router.Get("/", sellerHandler.GetAll())
-		router.Get("/{id}", sellerHandler.GetById())
-		router.Post("/", sellerHandler.Create())
-		router.Patch("/{id}", sellerHandler.Update())
-		router.Delete("/{id}", sellerHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionRead))).Get("/", sellerHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionRead))).Get("/{id}", sellerHandler.GetById())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionRead))).Get("/{id}/report", sellerHandler.GetReport())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionWrite))).Post("/", sellerHandler.Create())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionWrite))).Post("/import", sellerHandler.Import())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionWrite))).Patch("/{id}", sellerHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionDelete))).Delete("/{id}", sellerHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceSellers, rbac.ActionWrite))).Post("/{id}/restore", sellerHandler.Restore())
 	})
 
 	return nil
@@ -1,23 +1,34 @@
 package seller
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// DefaultNearExpiryDays is the window used by the seller report when none is given
+const DefaultNearExpiryDays = 7
+
+// auditEntity is the name of the sellers in the audit trail
+const auditEntity = "sellers"
+
 type DefaultSellerService struct {
 	rp         internal.SellerRepository
 	localityRp internal.SellerLocalityValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
-func NewSellerService(rp internal.SellerRepository, localityRp internal.SellerLocalityValidation) internal.SellerService {
-	return &DefaultSellerService{rp: rp, localityRp: localityRp}
+func NewSellerService(rp internal.SellerRepository, localityRp internal.SellerLocalityValidation, uow internal.UnitOfWork) internal.SellerService {
+	return &DefaultSellerService{rp: rp, localityRp: localityRp, uow: uow}
 }
 
-func (s *DefaultSellerService) GetAll() ([]internal.Seller, error) {
-	sellers, err := s.rp.GetAll()
+func (s *DefaultSellerService) GetAll(ctx context.Context) ([]internal.Seller, error) {
+	sellers, err := s.rp.GetAll(ctx)
 
 	if err != nil {
 		return nil, err
@@ -26,8 +37,25 @@ func (s *DefaultSellerService) GetAll() ([]internal.Seller, error) {
 	return sellers, nil
 }
 
-func (s *DefaultSellerService) GetByID(id int) (internal.Seller, error) {
-	seller, err := s.rp.GetByID(id)
+// List returns a page of sellers filtered and sorted as asked by the query
+func (s *DefaultSellerService) List(ctx context.Context, query utils.ListQuery) ([]internal.Seller, utils.Pagination, error) {
+	err := query.Resolve(listFields)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	sellers, err := s.rp.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	sellers, pagination := utils.Paginate(sellers, query)
+
+	return sellers, pagination, nil
+}
+
+func (s *DefaultSellerService) GetByID(ctx context.Context, id int) (internal.Seller, error) {
+	seller, err := s.rp.GetByID(ctx, id)
 
 	if err != nil {
 		return internal.Seller{}, err
@@ -40,34 +68,57 @@ func (s *DefaultSellerService) GetByID(id int) (internal.Seller, error) {
 	return seller, nil
 }
 
-func (s *DefaultSellerService) Create(newSeller *internal.Seller) error {
-	sellerValidation := s.verify(*newSeller)
+// GetByIDs returns the sellers with the given ids, the ids without a seller are ignored
+func (s *DefaultSellerService) GetByIDs(ctx context.Context, ids []int) ([]internal.Seller, error) {
+	return s.rp.GetByIDs(ctx, ids)
+}
 
-	if sellerValidation != nil {
-		return sellerValidation
-	}
+// GetRelations returns the locality of each seller when asked by include, every locality is read once
+func (s *DefaultSellerService) GetRelations(ctx context.Context, sellers []internal.Seller, include []string) ([]internal.SellerRelations, error) {
+	relations := make([]internal.SellerRelations, len(sellers))
 
-	_, err := s.localityRp.GetByID(newSeller.LocalityID)
+	for _, name := range include {
+		if name != internal.SellerIncludeLocality {
+			continue
+		}
 
-	if err != nil {
-		if errors.Is(err, utils.ErrNotFound) {
-			return utils.EDependencyNotFound("Seller", "locality ID")
+		localityID := func(seller internal.Seller) int { return seller.LocalityID }
+
+		localities, err := s.localityRp.GetByIDs(ctx, utils.DistinctIDs(sellers, localityID))
+		if err != nil {
+			return nil, err
 		}
 
-		return err
+		for i, locality := range utils.Related(sellers, localityID, localities, func(locality internal.Locality) int { return locality.ID }) {
+			relations[i].Locality = locality
+		}
 	}
 
-	err = s.rp.Create(newSeller)
+	return relations, nil
+}
 
+func (s *DefaultSellerService) Create(ctx context.Context, newSeller *internal.Seller) error {
+	err := s.validateNew(ctx, *newSeller)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.create(ctx, newSeller, internal.AuditCreate)
+}
+
+// create saves a seller along with its audit entry of the action
+func (s *DefaultSellerService) create(ctx context.Context, newSeller *internal.Seller, action string) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Sellers.Create(ctx, newSeller); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, action, auditEntity, newSeller.ID, nil, newSeller)
+	})
 }
 
-func (s *DefaultSellerService) Update(id int, newSeller *internal.Seller) (internal.Seller, error) {
-	existingSeller, err := s.rp.GetByID(id)
+func (s *DefaultSellerService) Update(ctx context.Context, id int, newSeller *internal.Seller) (internal.Seller, error) {
+	existingSeller, err := s.rp.GetByID(ctx, id)
 	if err != nil {
 		return internal.Seller{}, err
 	}
@@ -76,7 +127,11 @@ func (s *DefaultSellerService) Update(id int, newSeller *internal.Seller) (inter
 		return internal.Seller{}, utils.ENotFound("Seller")
 	}
 
-	existingCid, err := s.rp.GetByCid(newSeller.Cid)
+	if err = utils.CheckVersion("seller", newSeller.Version, existingSeller.Version); err != nil {
+		return internal.Seller{}, err
+	}
+
+	existingCid, err := s.rp.GetByCid(ctx, newSeller.Cid)
 
 	if err != nil {
 		return internal.Seller{}, err
@@ -90,6 +145,8 @@ func (s *DefaultSellerService) Update(id int, newSeller *internal.Seller) (inter
 		existingSeller.Cid = newSeller.Cid
 	}
 
+	current := existingSeller
+
 	if len(newSeller.CompanyName) != 0 {
 		existingSeller.CompanyName = newSeller.CompanyName
 	}
@@ -102,7 +159,13 @@ func (s *DefaultSellerService) Update(id int, newSeller *internal.Seller) (inter
 		existingSeller.Telephone = newSeller.Telephone
 	}
 
-	err = s.rp.Update(&existingSeller)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		if err := repos.Sellers.Update(ctx, &existingSeller); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, id, current, existingSeller)
+	})
 	if err != nil {
 		return internal.Seller{}, err
 	}
@@ -110,32 +173,233 @@ func (s *DefaultSellerService) Update(id int, newSeller *internal.Seller) (inter
 	return existingSeller, nil
 }
 
-func (s *DefaultSellerService) Delete(id int) error {
-	existingSeller, err := s.rp.GetByID(id)
+func (s *DefaultSellerService) Delete(ctx context.Context, id int, version int) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		existingSeller, err := repos.Sellers.GetByID(ctx, id)
+
+		if err != nil {
+			return err
+		}
+
+		if existingSeller == (internal.Seller{}) {
+			return utils.ENotFound("Seller")
+		}
+
+		if err = utils.CheckVersion("seller", version, existingSeller.Version); err != nil {
+			return err
+		}
+
+		err = repos.Sellers.Delete(ctx, id, existingSeller.Version)
 
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, existingSeller, nil)
+	})
+}
+
+// Restore brings back a soft deleted seller, ErrNotFound when there is no deleted seller with the id
+func (s *DefaultSellerService) Restore(ctx context.Context, id int) (restored internal.Seller, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		err := repos.Sellers.Restore(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.ENotFound("Deleted seller")
+			}
+
+			return err
+		}
+
+		restored, err = repos.Sellers.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, restored)
+	})
 	if err != nil {
-		return err
+		return internal.Seller{}, err
 	}
 
-	if existingSeller == (internal.Seller{}) {
-		return utils.ENotFound("Seller")
+	return restored, nil
+}
+
+// Purge removes for good the sellers deleted before a time
+func (s *DefaultSellerService) Purge(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		purged, err = repos.Sellers.Purge(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// GetReport builds the performance and catalogue report of a seller
+func (s *DefaultSellerService) GetReport(ctx context.Context, id int, nearExpiryDays int) (internal.SellerReport, error) {
+	if nearExpiryDays < 0 {
+		return internal.SellerReport{}, utils.EBR("days cannot be negative")
+	}
+
+	if nearExpiryDays == 0 {
+		nearExpiryDays = DefaultNearExpiryDays
+	}
+
+	existingSeller, err := s.rp.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return internal.SellerReport{}, utils.ENotFound("Seller")
+		}
+
+		return internal.SellerReport{}, err
 	}
 
-	err = s.rp.Delete(id)
+	productsByType, err := s.rp.GetProductsByType(ctx, id)
+	if err != nil {
+		return internal.SellerReport{}, err
+	}
 
+	stockByWarehouse, err := s.rp.GetStockByWarehouse(ctx, id)
 	if err != nil {
+		return internal.SellerReport{}, err
+	}
+
+	sales, err := s.rp.GetSales(ctx, id)
+	if err != nil {
+		return internal.SellerReport{}, err
+	}
+
+	nearExpiry, err := s.rp.GetNearExpiry(ctx, id, nearExpiryDays)
+	if err != nil {
+		return internal.SellerReport{}, err
+	}
+
+	report := internal.SellerReport{
+		SellerID:         existingSeller.ID,
+		CompanyName:      existingSeller.CompanyName,
+		ProductsByType:   productsByType,
+		StockByWarehouse: stockByWarehouse,
+		UnitsSold:        sales.UnitsSold,
+		Revenue:          sales.Revenue,
+		NearExpiry:       nearExpiry,
+	}
+
+	for _, productType := range productsByType {
+		report.TotalProducts += productType.ProductsCount
+	}
+
+	for _, stock := range stockByWarehouse {
+		report.StockOnHand += stock.Quantity
+	}
+
+	return report, nil
+}
+
+// Import creates sellers in bulk with the rules of Create, the cids must be unique among the
+// stored sellers and the imported ones
+func (s *DefaultSellerService) Import(ctx context.Context, items []utils.ImportItem[internal.SellerRequest], options utils.ImportOptions) (utils.ImportReport, error) {
+	cids := map[int]bool{}
+
+	importer := utils.Importer[internal.SellerRequest]{
+		Validate: func(request *internal.SellerRequest) error {
+			err := s.validateNew(ctx, newSellerFromRequest(*request))
+			if err != nil {
+				return err
+			}
+
+			if cids[request.Cid] {
+				return utils.EConflict("Cid", "Seller")
+			}
+
+			cids[request.Cid] = true
+
+			return nil
+		},
+		Save: func(request internal.SellerRequest) (int, error) {
+			newSeller := newSellerFromRequest(request)
+			err := s.create(ctx, &newSeller, internal.AuditImport)
+
+			return newSeller.ID, err
+		},
+		SaveAll: func(requests []internal.SellerRequest) ([]int, error) {
+			sellers := make([]*internal.Seller, 0, len(requests))
+
+			for _, request := range requests {
+				newSeller := newSellerFromRequest(request)
+				sellers = append(sellers, &newSeller)
+			}
+
+			err := s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+				if err := repos.Sellers.CreateAll(ctx, sellers); err != nil {
+					return err
+				}
+
+				for _, newSeller := range sellers {
+					if err := audit.Record(ctx, repos.Audit, internal.AuditImport, auditEntity, newSeller.ID, nil, newSeller); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]int, 0, len(sellers))
+			for _, newSeller := range sellers {
+				ids = append(ids, newSeller.ID)
+			}
+
+			return ids, nil
+		},
+	}
+
+	return importer.Import(items, options)
+}
+
+// validateNew checks the fields of a new seller and that its locality exists
+func (s *DefaultSellerService) validateNew(ctx context.Context, newSeller internal.Seller) error {
+	err := s.verify(ctx, newSeller)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.localityRp.GetByID(ctx, newSeller.LocalityID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return utils.EDependencyNotFound("Seller", "locality ID")
+		}
+
 		return err
 	}
 
 	return nil
 }
 
-func (s *DefaultSellerService) verify(newSeller internal.Seller) error {
+func newSellerFromRequest(request internal.SellerRequest) internal.Seller {
+	return internal.Seller{
+		Cid:         request.Cid,
+		CompanyName: request.CompanyName,
+		Address:     request.Address,
+		Telephone:   request.Telephone,
+		LocalityID:  request.LocalityID,
+	}
+}
+
+func (s *DefaultSellerService) verify(ctx context.Context, newSeller internal.Seller) error {
 	if newSeller.Cid <= 0 {
 		return utils.EZeroValue("Cid")
 	}
 
-	existingCid, err := s.rp.GetByCid(newSeller.Cid)
+	existingCid, err := s.rp.GetByCid(ctx, newSeller.Cid)
 	if err != nil && !errors.Is(err, utils.ErrNotFound) {
 		return err
 	}
@@ -1,48 +1,128 @@
 package seller
 
 import (
+	"context"
 	"errors"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.SellerRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Sellers: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type MockSellerRepository struct {
 	mock.Mock
 }
 
-func (ms *MockSellerRepository) Create(seller *internal.Seller) error {
+func (ms *MockSellerRepository) Create(ctx context.Context, seller *internal.Seller) error {
 	args := ms.Called(seller)
 	return args.Error(0)
 }
 
-func (ms *MockSellerRepository) Update(seller *internal.Seller) error {
+func (ms *MockSellerRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Seller, error) {
+	args := ms.Called(ids)
+	return args.Get(0).([]internal.Seller), args.Error(1)
+}
+
+func (ms *MockSellerRepository) CreateAll(ctx context.Context, sellers []*internal.Seller) error {
+	args := ms.Called(sellers)
+	return args.Error(0)
+}
+
+func (ms *MockSellerRepository) Update(ctx context.Context, seller *internal.Seller) error {
 	args := ms.Called(seller)
 	return args.Error(0)
 }
 
-func (ms *MockSellerRepository) GetAll() (sellers []internal.Seller, err error) {
+func (ms *MockSellerRepository) GetAll(ctx context.Context) (sellers []internal.Seller, err error) {
 	args := ms.Called()
 	return args.Get(0).([]internal.Seller), args.Error(1)
 }
 
-func (ms *MockSellerRepository) GetByID(id int) (seller internal.Seller, err error) {
+func (ms *MockSellerRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Seller, error) {
+	args := ms.Called(query)
+	return args.Get(0).([]internal.Seller), args.Error(1)
+}
+
+func (ms *MockSellerRepository) GetByID(ctx context.Context, id int) (seller internal.Seller, err error) {
 	args := ms.Called(id)
 	return args.Get(0).(internal.Seller), args.Error(1)
 }
 
-func (ms *MockSellerRepository) GetByCid(cid int) (seller internal.Seller, err error) {
+func (ms *MockSellerRepository) GetByCid(ctx context.Context, cid int) (seller internal.Seller, err error) {
 	args := ms.Called(cid)
 	return args.Get(0).(internal.Seller), args.Error(1)
 }
 
-func (ms *MockSellerRepository) Delete(id int) (err error) {
+func (ms *MockSellerRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := ms.Called(id, version)
+	return args.Error(0)
+}
+
+func (ms *MockSellerRepository) Restore(ctx context.Context, id int) (err error) {
 	args := ms.Called(id)
 	return args.Error(0)
 }
 
+func (ms *MockSellerRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := ms.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (ms *MockSellerRepository) GetProductsByType(ctx context.Context, sellerID int) ([]internal.SellerProductTypeCount, error) {
+	args := ms.Called(sellerID)
+	return args.Get(0).([]internal.SellerProductTypeCount), args.Error(1)
+}
+
+func (ms *MockSellerRepository) GetStockByWarehouse(ctx context.Context, sellerID int) ([]internal.SellerWarehouseStock, error) {
+	args := ms.Called(sellerID)
+	return args.Get(0).([]internal.SellerWarehouseStock), args.Error(1)
+}
+
+func (ms *MockSellerRepository) GetSales(ctx context.Context, sellerID int) (internal.SellerSales, error) {
+	args := ms.Called(sellerID)
+	return args.Get(0).(internal.SellerSales), args.Error(1)
+}
+
+func (ms *MockSellerRepository) GetNearExpiry(ctx context.Context, sellerID int, nearExpiryDays int) (internal.SellerNearExpiry, error) {
+	args := ms.Called(sellerID, nearExpiryDays)
+	return args.Get(0).(internal.SellerNearExpiry), args.Error(1)
+}
+
 // Mock Locality Repository
 type MockLocalityRepository struct {
 	mock.Mock
@@ -53,7 +133,12 @@ func (ml *MockLocalityRepository) Save(locality internal.Locality) (id int, err
 	return args.Int(0), args.Error(1)
 }
 
-func (ml *MockLocalityRepository) GetByID(id int) (locality internal.Locality, err error) {
+func (ml *MockLocalityRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := ml.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (ml *MockLocalityRepository) GetByID(ctx context.Context, id int) (locality internal.Locality, err error) {
 	args := ml.Called(id)
 	return args.Get(0).(internal.Locality), args.Error(1)
 }
@@ -79,9 +164,9 @@ func TestUnitSeller_GetAll_Success(t *testing.T) {
 
 	msr.On("GetAll").Return(sellers, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.GetAll()
+	result, err := service.GetAll(context.Background())
 
 	require.NoError(t, err)
 	require.Len(t, result, len(sellers))
@@ -96,14 +181,91 @@ func TestUnitSeller_GetAll_InternalServerError(t *testing.T) {
 	internalError := errors.New("internal error")
 	msr.On("GetAll").Return([]internal.Seller(nil), internalError)
 
-	service := NewSellerService(msr, mlr)
-	result, err := service.GetAll()
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+	result, err := service.GetAll(context.Background())
 
 	require.ErrorIs(t, err, internalError)
 	require.Len(t, result, 0)
 	require.Equal(t, result, []internal.Seller(nil))
 }
 
+func TestUnitSeller_List_Success(t *testing.T) {
+	sellers := []internal.Seller{
+		{ID: 1, Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1},
+		{ID: 2, Cid: 56, CompanyName: "Company2", Address: "Address2", Telephone: "1199999992", LocalityID: 2},
+	}
+
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("List", mock.MatchedBy(func(query utils.ListQuery) bool {
+		return query.Limit == 1 && len(query.Sort) == 2 && query.Sort[1].Field == "id" && query.Filters[0].Value == int64(1)
+	})).Return(sellers, nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	query := utils.ListQuery{
+		Limit:   1,
+		Sort:    []utils.ListSort{{Field: "company_name"}},
+		Filters: []utils.ListFilter{{Field: "locality_id", Operator: "eq", Text: "1"}},
+	}
+	result, pagination, err := service.List(context.Background(), query)
+
+	require.NoError(t, err)
+	require.Equal(t, sellers[:1], result)
+	require.True(t, pagination.HasMore)
+	require.NotEmpty(t, pagination.NextCursor)
+}
+
+func TestUnitSeller_List_InvalidSort(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+	_, _, err := service.List(context.Background(), utils.ListQuery{Limit: 10, Sort: []utils.ListSort{{Field: "password"}}})
+
+	require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	msr.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func TestUnitSeller_GetRelations_Success(t *testing.T) {
+	sellers := []internal.Seller{
+		{ID: 1, Cid: 55, CompanyName: "Company", LocalityID: 1},
+		{ID: 2, Cid: 56, CompanyName: "Company2", LocalityID: 2},
+		{ID: 3, Cid: 57, CompanyName: "Company3", LocalityID: 1},
+	}
+	localities := []internal.Locality{{ID: 1, LocalityName: "Rosario", ProvinceID: 1}}
+
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	mlr.On("GetByIDs", []int{1, 2}).Return(localities, nil).Once()
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	result, err := service.GetRelations(context.Background(), sellers, []string{internal.SellerIncludeLocality})
+
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	require.Equal(t, &localities[0], result[0].Locality)
+	require.Nil(t, result[1].Locality)
+	require.Equal(t, &localities[0], result[2].Locality)
+	mlr.AssertExpectations(t)
+}
+
+func TestUnitSeller_GetRelations_InternalServerError(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	mlr.On("GetByIDs", []int{1}).Return([]internal.Locality{}, errors.New("internal server error"))
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	_, err := service.GetRelations(context.Background(), []internal.Seller{{ID: 1, LocalityID: 1}}, []string{internal.SellerIncludeLocality})
+
+	require.Error(t, err)
+}
+
 func TestUnitSeller_GetByID_Success(t *testing.T) {
 	seller := internal.Seller{
 		ID:          1,
@@ -119,9 +281,9 @@ func TestUnitSeller_GetByID_Success(t *testing.T) {
 
 	msr.On("GetByID", mock.Anything).Return(seller, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.GetByID(seller.ID)
+	result, err := service.GetByID(context.Background(), seller.ID)
 
 	require.NoError(t, err)
 	require.Equal(t, result, seller)
@@ -134,9 +296,9 @@ func TestUnitSeller_GetByID_NotFound(t *testing.T) {
 
 	msr.On("GetByID", mock.Anything).Return(internal.Seller{}, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.GetByID(1)
+	result, err := service.GetByID(context.Background(), 1)
 
 	require.Equal(t, utils.ENotFound("Seller"), err)
 	require.Equal(t, result, internal.Seller{})
@@ -150,9 +312,9 @@ func TestUnitSeller_GetByID_InternalServerError(t *testing.T) {
 	internalError := errors.New("internal error")
 	msr.On("GetByID", mock.Anything).Return(internal.Seller{}, internalError)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.GetByID(1)
+	result, err := service.GetByID(context.Background(), 1)
 
 	require.ErrorIs(t, err, internalError)
 	require.Equal(t, result, internal.Seller{})
@@ -175,9 +337,9 @@ func TestUnitSeller_Create_Success(t *testing.T) {
 	mlr.On("GetByID", mock.Anything).Return(internal.Locality{}, nil)
 	msr.On("Create", mock.Anything).Return(nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.NoError(t, result)
 }
@@ -199,9 +361,9 @@ func TestUnitSeller_Create_CidAlreadyExists(t *testing.T) {
 	mlr.On("GetByID", mock.Anything).Return(internal.Locality{}, nil)
 	msr.On("Create", mock.Anything).Return(nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.Equal(t, utils.EConflict("Cid", "Seller"), result)
 }
@@ -221,9 +383,9 @@ func TestUnitSeller_Create_EmptyOrInvalidCid(t *testing.T) {
 
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{}, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.Equal(t, result, utils.EZeroValue("Cid"))
 }
@@ -243,9 +405,9 @@ func TestUnitSeller_Create_EmptyOrInvalidCompany(t *testing.T) {
 
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{}, utils.ErrNotFound)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.Equal(t, result, utils.EZeroValue("Company name"))
 }
@@ -268,9 +430,9 @@ func TestUnitSeller_Create_LocalityDoesNotExist(t *testing.T) {
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{Cid: 0}, nil)
 	mlr.On("GetByID", mock.Anything).Return(internal.Locality{}, errGetLocality)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.ErrorIs(t, result, errGetLocality)
 }
@@ -294,9 +456,9 @@ func TestUnitSeller_Create_InternalServerError(t *testing.T) {
 	mlr.On("GetByID", mock.Anything).Return(internal.Locality{}, nil)
 	msr.On("Create", mock.Anything).Return(internalErr)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result := service.Create(&newSeller)
+	result := service.Create(context.Background(), &newSeller)
 
 	require.ErrorIs(t, result, internalErr)
 }
@@ -318,9 +480,9 @@ func TestUnitSeller_Update_Success(t *testing.T) {
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{ID: 1, Cid: 55}, nil)
 	msr.On("Update", mock.Anything).Return(nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.Update(1, &updatedSeller)
+	result, err := service.Update(context.Background(), 1, &updatedSeller)
 
 	require.NoError(t, err)
 	require.Equal(t, result, updatedSeller)
@@ -341,9 +503,9 @@ func TestUnitSeller_Update_SellerNotFound(t *testing.T) {
 
 	msr.On("GetByID", mock.Anything).Return(internal.Seller{}, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.Update(1, &updatedSeller)
+	result, err := service.Update(context.Background(), 1, &updatedSeller)
 
 	require.Equal(t, utils.ENotFound("Seller"), err)
 	require.Equal(t, result, internal.Seller{})
@@ -365,9 +527,9 @@ func TestUnitSeller_Update_CidAlreadyInUseByOtherSeller(t *testing.T) {
 	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1, LocalityID: 1}, nil)
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{ID: 2, Cid: 55}, nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.Update(1, &updatedSeller)
+	result, err := service.Update(context.Background(), 1, &updatedSeller)
 
 	require.Equal(t, err, utils.EConflict("Cid", "Seller"))
 	require.Equal(t, result, internal.Seller{})
@@ -392,9 +554,9 @@ func TestUnitSeller_Update_InternalServerError(t *testing.T) {
 	msr.On("GetByCid", mock.Anything).Return(internal.Seller{ID: 1, Cid: 55}, nil)
 	msr.On("Update", mock.Anything).Return(internalErr)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	result, err := service.Update(1, &updatedSeller)
+	result, err := service.Update(context.Background(), 1, &updatedSeller)
 
 	require.ErrorIs(t, err, internalErr)
 	require.Equal(t, result, internal.Seller{})
@@ -405,14 +567,30 @@ func TestUnitSeller_Delete_Success(t *testing.T) {
 	msr := new(MockSellerRepository)
 	mlr := new(MockLocalityRepository)
 
-	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1}, nil)
-	msr.On("Delete", mock.Anything).Return(nil)
+	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1, Version: 2}, nil)
+	msr.On("Delete", 1, 2).Return(nil)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	err := service.Delete(1)
+	err := service.Delete(context.Background(), 1, utils.AnyVersion)
 
 	require.NoError(t, err)
+	msr.AssertExpectations(t)
+}
+
+func TestUnitSeller_Delete_PreconditionFailed(t *testing.T) {
+
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1, Version: 2}, nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	err := service.Delete(context.Background(), 1, 1)
+
+	require.ErrorIs(t, err, utils.ErrPreconditionFailed)
+	msr.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 }
 
 func TestUnitSeller_Delete_SellerNotFound(t *testing.T) {
@@ -421,11 +599,10 @@ func TestUnitSeller_Delete_SellerNotFound(t *testing.T) {
 	mlr := new(MockLocalityRepository)
 
 	msr.On("GetByID", mock.Anything).Return(internal.Seller{}, nil)
-	msr.On("Delete", mock.Anything).Return(utils.ErrNotFound)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	err := service.Delete(1)
+	err := service.Delete(context.Background(), 1, 1)
 
 	require.Equal(t, utils.ENotFound("Seller"), err)
 }
@@ -437,12 +614,173 @@ func TestUnitSeller_Delete_InternalServerError(t *testing.T) {
 
 	internalErr := errors.New("internal server error")
 
-	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1}, nil)
-	msr.On("Delete", mock.Anything).Return(internalErr)
+	msr.On("GetByID", mock.Anything).Return(internal.Seller{ID: 1, Version: 1}, nil)
+	msr.On("Delete", 1, 1).Return(internalErr)
 
-	service := NewSellerService(msr, mlr)
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
 
-	err := service.Delete(1)
+	err := service.Delete(context.Background(), 1, 1)
 
 	require.ErrorIs(t, err, internalErr)
 }
+
+func TestUnitSeller_GetReport_Success(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("GetByID", 1).Return(internal.Seller{ID: 1, CompanyName: "Company"}, nil)
+	msr.On("GetProductsByType", 1).Return([]internal.SellerProductTypeCount{
+		{ProductTypeID: 1, Description: "Fruits", ProductsCount: 2},
+		{ProductTypeID: 2, Description: "Meat", ProductsCount: 1},
+	}, nil)
+	msr.On("GetStockByWarehouse", 1).Return([]internal.SellerWarehouseStock{
+		{WarehouseID: 1, WarehouseCode: "WH001", Quantity: 100},
+		{WarehouseID: 2, WarehouseCode: "WH002", Quantity: 50},
+	}, nil)
+	msr.On("GetSales", 1).Return(internal.SellerSales{UnitsSold: 3, Revenue: 9.5}, nil)
+	msr.On("GetNearExpiry", 1, DefaultNearExpiryDays).Return(internal.SellerNearExpiry{Days: DefaultNearExpiryDays, Batches: 1, Quantity: 20}, nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	report, err := service.GetReport(context.Background(), 1, 0)
+
+	require.NoError(t, err)
+	require.Equal(t, 3, report.TotalProducts)
+	require.Equal(t, 150, report.StockOnHand)
+	require.Equal(t, 3, report.UnitsSold)
+	require.Equal(t, 9.5, report.Revenue)
+	require.Equal(t, 20, report.NearExpiry.Quantity)
+	require.Equal(t, "Company", report.CompanyName)
+}
+
+func TestUnitSeller_GetReport_NotFound(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("GetByID", 1).Return(internal.Seller{}, utils.ErrNotFound)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	_, err := service.GetReport(context.Background(), 1, 0)
+
+	require.Equal(t, utils.ENotFound("Seller"), err)
+}
+
+func TestUnitSeller_GetReport_NegativeDays(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	_, err := service.GetReport(context.Background(), 1, -1)
+
+	require.ErrorIs(t, err, utils.ErrInvalidArguments)
+	msr.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestUnitSeller_Import(t *testing.T) {
+	items := []utils.ImportItem[internal.SellerRequest]{
+		{Row: 2, Value: internal.SellerRequest{Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1}},
+		{Row: 3, Value: internal.SellerRequest{Cid: 55, CompanyName: "Company2", Address: "Address2", Telephone: "1199999992", LocalityID: 1}},
+		{Row: 4, Value: internal.SellerRequest{Cid: 56, CompanyName: "Company3", Address: "Address3", Telephone: "1199999993", LocalityID: 2}},
+		{Row: 5, Value: internal.SellerRequest{Cid: 57, Address: "Address4", Telephone: "1199999994", LocalityID: 1}},
+	}
+
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("GetByCid", mock.Anything).Return(internal.Seller{}, utils.ErrNotFound)
+	mlr.On("GetByID", 1).Return(internal.Locality{ID: 1}, nil)
+	mlr.On("GetByID", 2).Return(internal.Locality{}, utils.ErrNotFound)
+	msr.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*internal.Seller).ID = 10
+	}).Return(nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	report, err := service.Import(context.Background(), items, utils.ImportOptions{})
+
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Created)
+	require.Equal(t, 3, report.Failed)
+	require.Equal(t, utils.ImportRowResult{Row: 2, ID: 10, Status: utils.ImportCreated}, report.Rows[0])
+	require.Contains(t, report.Rows[1].Error, "Cid")
+	require.Contains(t, report.Rows[2].Error, "locality ID")
+	require.Contains(t, report.Rows[3].Error, "Company name")
+}
+
+func TestUnitSeller_Import_Atomic(t *testing.T) {
+	items := []utils.ImportItem[internal.SellerRequest]{
+		{Row: 1, Value: internal.SellerRequest{Cid: 55, CompanyName: "Company", Address: "Address", Telephone: "1199999999", LocalityID: 1}},
+		{Row: 2, Value: internal.SellerRequest{Cid: 56, CompanyName: "Company2", Address: "Address2", Telephone: "1199999992", LocalityID: 1}},
+	}
+
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("GetByCid", mock.Anything).Return(internal.Seller{}, nil)
+	mlr.On("GetByID", 1).Return(internal.Locality{ID: 1}, nil)
+	msr.On("CreateAll", mock.Anything).Run(func(args mock.Arguments) {
+		for i, seller := range args.Get(0).([]*internal.Seller) {
+			seller.ID = i + 1
+		}
+	}).Return(nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	report, err := service.Import(context.Background(), items, utils.ImportOptions{Atomic: true})
+
+	require.NoError(t, err)
+	require.True(t, report.Committed)
+	require.Equal(t, 2, report.Rows[1].ID)
+	msr.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUnitSeller_Restore_Success(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("Restore", 1).Return(nil)
+	msr.On("GetByID", 1).Return(internal.Seller{ID: 1, CompanyName: "Company"}, nil)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	seller, err := service.Restore(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Equal(t, internal.Seller{ID: 1, CompanyName: "Company"}, seller)
+}
+
+func TestUnitSeller_Restore_NotDeleted(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	msr.On("Restore", 1).Return(utils.ErrNotFound)
+
+	service := NewSellerService(msr, mlr, newMockUnitOfWork(msr))
+
+	_, err := service.Restore(context.Background(), 1)
+
+	require.Equal(t, utils.ENotFound("Deleted seller"), err)
+	msr.AssertNotCalled(t, "GetByID", 1)
+}
+
+func TestUnitSeller_Purge(t *testing.T) {
+	msr := new(MockSellerRepository)
+	mlr := new(MockLocalityRepository)
+
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	msr.On("Purge", before).Return(3, nil)
+
+	uow := newMockUnitOfWork(msr)
+	service := NewSellerService(msr, mlr, uow)
+
+	purged, err := service.Purge(context.Background(), before)
+
+	require.NoError(t, err)
+	require.Equal(t, 3, purged)
+	require.Len(t, uow.entries, 1)
+	require.Equal(t, internal.AuditPurge, uow.entries[0].Action)
+	require.Equal(t, "system", uow.entries[0].Actor)
+	require.Equal(t, []byte(`3`), []byte(uow.entries[0].Changes["purged"].After))
+}
@@ -1,24 +1,65 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type SellerService interface {
-	GetAll() ([]Seller, error)
-	GetByID(id int) (Seller, error)
-	Create(*Seller) error
-	Update(int, *Seller) (Seller, error)
-	Delete(int) error
+	GetAll(ctx context.Context) ([]Seller, error)
+	List(ctx context.Context, query utils.ListQuery) ([]Seller, utils.Pagination, error)
+	GetByID(ctx context.Context, id int) (Seller, error)
+	// GetByIDs returns the sellers with the given ids, the ids without a seller are ignored
+	GetByIDs(ctx context.Context, ids []int) ([]Seller, error)
+	// GetRelations returns the entities related to each seller that were asked by include
+	GetRelations(ctx context.Context, sellers []Seller, include []string) ([]SellerRelations, error)
+	Create(context.Context, *Seller) error
+	Update(context.Context, int, *Seller) (Seller, error)
+	// Delete soft deletes a seller in the version given, it is left out of the listings until it is restored
+	Delete(ctx context.Context, id int, version int) error
+	// Restore brings back a soft deleted seller
+	Restore(ctx context.Context, id int) (Seller, error)
+	// Purge removes for good the sellers deleted before a time, returns how many were removed
+	Purge(ctx context.Context, before time.Time) (int, error)
+	GetReport(ctx context.Context, id int, nearExpiryDays int) (SellerReport, error)
+	Import(ctx context.Context, items []utils.ImportItem[SellerRequest], options utils.ImportOptions) (utils.ImportReport, error)
 }
 
 type SellerRepository interface {
-	GetAll() (s []Seller, err error)
-	GetByID(id int) (Seller, error)
-	GetByCid(cid int) (Seller, error)
-	Create(*Seller) error
-	Update(*Seller) error
-	Delete(int) error
+	GetAll(ctx context.Context) (s []Seller, err error)
+	// List returns the sellers of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) ([]Seller, error)
+	GetByID(ctx context.Context, id int) (Seller, error)
+	// GetByIDs returns the sellers with the given ids, the ids without a seller are ignored
+	GetByIDs(ctx context.Context, ids []int) ([]Seller, error)
+	GetByCid(ctx context.Context, cid int) (Seller, error)
+	Create(context.Context, *Seller) error
+	// CreateAll creates the sellers in a single transaction, none of them when one fails
+	CreateAll(context.Context, []*Seller) error
+	Update(context.Context, *Seller) error
+	// Delete soft deletes a seller when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) error
+	Restore(context.Context, int) error
+	Purge(ctx context.Context, before time.Time) (int, error)
+	GetProductsByType(ctx context.Context, sellerID int) ([]SellerProductTypeCount, error)
+	GetStockByWarehouse(ctx context.Context, sellerID int) ([]SellerWarehouseStock, error)
+	GetSales(ctx context.Context, sellerID int) (SellerSales, error)
+	GetNearExpiry(ctx context.Context, sellerID int, nearExpiryDays int) (SellerNearExpiry, error)
 }
 
 type SellerLocalityValidation interface {
-	GetByID(int) (Locality, error)
+	GetByID(context.Context, int) (Locality, error)
+	GetByIDs(context.Context, []int) ([]Locality, error)
+}
+
+// SellerIncludeLocality embeds the locality of a seller in its responses
+const SellerIncludeLocality = "locality"
+
+// SellerRelations are the entities related to a seller embedded in its responses with include
+type SellerRelations struct {
+	Locality *Locality `json:"locality,omitempty"`
 }
 
 type Seller struct {
@@ -28,6 +69,10 @@ type Seller struct {
 	Address     string `json:"address"`
 	Telephone   string `json:"telephone"`
 	LocalityID  int    `json:"locality_id"`
+	// DeletedAt is the time the seller was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is bumped by every change, it is the ETag of the seller
+	Version int `json:"version,omitempty"`
 }
 
 type SellerRequest struct {
@@ -44,3 +89,43 @@ type SellerRequestPointer struct {
 	Address     *string `json:"address"`
 	Telephone   *string `json:"telephone"`
 }
+
+// SellerReport gathers the catalogue and performance indicators of a seller
+type SellerReport struct {
+	SellerID         int                      `json:"seller_id"`
+	CompanyName      string                   `json:"company_name"`
+	TotalProducts    int                      `json:"total_products"`
+	ProductsByType   []SellerProductTypeCount `json:"products_by_type"`
+	StockOnHand      int                      `json:"stock_on_hand"`
+	StockByWarehouse []SellerWarehouseStock   `json:"stock_by_warehouse"`
+	UnitsSold        int                      `json:"units_sold"`
+	Revenue          float64                  `json:"revenue"`
+	NearExpiry       SellerNearExpiry         `json:"near_expiry"`
+}
+
+// SellerProductTypeCount is the number of products of a seller for a product type
+type SellerProductTypeCount struct {
+	ProductTypeID int    `json:"product_type_id"`
+	Description   string `json:"description"`
+	ProductsCount int    `json:"products_count"`
+}
+
+// SellerWarehouseStock is the quantity on hand of a seller's products in a warehouse
+type SellerWarehouseStock struct {
+	WarehouseID   int    `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	Quantity      int    `json:"quantity"`
+}
+
+// SellerSales summarizes the purchase orders of a seller's products
+type SellerSales struct {
+	UnitsSold int     `json:"units_sold"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// SellerNearExpiry is the stock of a seller whose batches are due within the given window, from today on
+type SellerNearExpiry struct {
+	Days     int `json:"days"`
+	Batches  int `json:"batches"`
+	Quantity int `json:"quantity"`
+}
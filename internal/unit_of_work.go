@@ -0,0 +1,34 @@
+package internal
+
+import "context"
+
+// TxRepositories are the repositories of a unit of work, bound to its transaction. Audit records the
+// changes made in it, so an audit entry is saved only when its change is
+type TxRepositories struct {
+	Localities     LocalityRepository
+	Provinces      ProvinceRepository
+	Countries      CountryRepository
+	Sections       SectionRepository
+	ProductBatches ProductBatchRepository
+	Buyers         BuyerRepository
+	Carries        CarryRepository
+	Employees      EmployeeRepository
+	InboundOrders  InboundOrderRepository
+	PackagingUnits PackagingUnitRepository
+	Products       ProductRepository
+	ProductRecords ProductRecordsRepository
+	ProductTypes   ProductTypeRepository
+	PurchaseOrders PurchaseOrderRepository
+	Sellers        SellerRepository
+	Warehouses     WarehouseRepository
+	Users          UserRepository
+	APIKeys        APIKeyRepository
+	Audit          AuditRepository
+}
+
+// UnitOfWork runs an operation spanning several repositories in a single transaction
+type UnitOfWork interface {
+	// Do runs fn with the repositories bound to a new transaction, committed when fn returns nil and
+	// rolled back when it returns an error or panics, so none of its changes are left half made
+	Do(ctx context.Context, fn func(repos TxRepositories) error) error
+}
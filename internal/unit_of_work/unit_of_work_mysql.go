@@ -0,0 +1,64 @@
+package unit_of_work
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/apikey"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/auth"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/buyer"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/carry"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/country"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/employee"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/inbound_order"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/locality"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/packaging_unit"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/product"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_batch"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_record"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/product_type"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/province"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/purchase_order"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/section"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/seller"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/warehouse"
+)
+
+type MySQLUnitOfWork struct {
+	db *sql.DB
+}
+
+// NewMySQLUnitOfWork creates a new MySQLUnitOfWork with the given database connection.
+func NewMySQLUnitOfWork(db *sql.DB) *MySQLUnitOfWork {
+	return &MySQLUnitOfWork{db: db}
+}
+
+// Do begins a transaction and runs fn with the MySQL repositories bound to it.
+func (u *MySQLUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	return utils.InTx(ctx, u.db, func(tx utils.DBTX) error {
+		return fn(internal.TxRepositories{
+			Localities:     locality.NewMysqlLocalityRepository(tx),
+			Provinces:      province.NewMysqlProvinceRepository(tx),
+			Countries:      country.NewMysqlCountryRepository(tx),
+			Sections:       section.NewSectionMysql(tx),
+			ProductBatches: product_batch.NewProductBatchRepository(tx),
+			Buyers:         buyer.NewBuyerDB(tx),
+			Carries:        carry.NewMySQLCarryRepository(tx),
+			Employees:      employee.NewEmployeeRepository(tx),
+			InboundOrders:  inbound_order.NewMySqlInboundOrderRepository(tx),
+			PackagingUnits: packaging_unit.NewPackagingUnitDB(tx),
+			Products:       product.NewProductDB(tx),
+			ProductRecords: product_record.NewProductRecordDB(tx),
+			ProductTypes:   product_type.NewProductTypeDB(tx),
+			PurchaseOrders: purchase_order.NewPurchaseOrderDB(tx),
+			Sellers:        seller.NewSellerRepository(tx),
+			Warehouses:     warehouse.NewWarehouseDB(tx),
+			Users:          auth.NewMySQLUserRepository(tx),
+			APIKeys:        apikey.NewMySQLAPIKeyRepository(tx),
+			Audit:          audit.NewMySQLAuditRepository(tx),
+		})
+	})
+}
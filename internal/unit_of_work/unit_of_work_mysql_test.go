@@ -0,0 +1,227 @@
+package unit_of_work
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConnector opens connections that record the statements and the end of the transactions
+// run on them, the statements containing failOn fail
+type recordingConnector struct {
+	mu     sync.Mutex
+	events []string
+	failOn string
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver {
+	return recordingDriver{connector: c}
+}
+
+func (c *recordingConnector) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, event)
+}
+
+type recordingDriver struct {
+	connector *recordingConnector
+}
+
+func (d recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{connector: d.connector}, nil
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{connector: c.connector, query: query}, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	c.connector.record("begin")
+	return &recordingTx{connector: c.connector}, nil
+}
+
+type recordingTx struct {
+	connector *recordingConnector
+}
+
+func (t *recordingTx) Commit() error {
+	t.connector.record("commit")
+	return nil
+}
+
+func (t *recordingTx) Rollback() error {
+	t.connector.record("rollback")
+	return nil
+}
+
+type recordingStmt struct {
+	connector *recordingConnector
+	query     string
+}
+
+func (s *recordingStmt) Close() error {
+	return nil
+}
+
+func (s *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.connector.failOn != "" && strings.Contains(s.query, s.connector.failOn) {
+		return nil, errors.New("connection lost")
+	}
+
+	s.connector.record(s.query)
+
+	return recordingResult{}, nil
+}
+
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.connector.record(s.query)
+	return emptyRows{}, nil
+}
+
+// recordingResult is the result of every statement, one row with the id 1
+type recordingResult struct{}
+
+func (recordingResult) LastInsertId() (int64, error) {
+	return 1, nil
+}
+
+func (recordingResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return nil
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+func TestUnitUnitOfWork_Do(t *testing.T) {
+	saveLocation := func(repos internal.TxRepositories) error {
+		err := repos.Countries.Save(context.Background(), &internal.Country{CountryName: "Ostania"})
+		if err != nil {
+			return err
+		}
+
+		err = repos.Provinces.Save(context.Background(), &internal.Province{ProvinceName: "Westails", CountryID: 1})
+		if err != nil {
+			return err
+		}
+
+		return repos.Localities.Save(context.Background(), &internal.Locality{ID: 7000, LocalityName: "Berlint", ProvinceID: 1})
+	}
+
+	t.Run("commits the changes of every repository", func(t *testing.T) {
+		connector := &recordingConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		err := NewMySQLUnitOfWork(db).Do(context.Background(), saveLocation)
+		require.NoError(t, err)
+		require.Equal(t, "begin", connector.events[0])
+		require.Equal(t, "commit", connector.events[len(connector.events)-1])
+		require.NotContains(t, connector.events, "rollback")
+	})
+
+	t.Run("rolls back the changes made before a repository fails", func(t *testing.T) {
+		connector := &recordingConnector{failOn: "INSERT INTO provinces"}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		err := NewMySQLUnitOfWork(db).Do(context.Background(), saveLocation)
+		require.EqualError(t, err, "connection lost")
+		require.Equal(t, "begin", connector.events[0])
+		require.Contains(t, connector.events[1], "INSERT INTO countries")
+		require.Equal(t, "rollback", connector.events[len(connector.events)-1])
+		require.NotContains(t, connector.events, "commit")
+	})
+
+	t.Run("rolls back the changes when the operation panics", func(t *testing.T) {
+		connector := &recordingConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		require.Panics(t, func() {
+			_ = NewMySQLUnitOfWork(db).Do(context.Background(), func(repos internal.TxRepositories) error {
+				_ = repos.Countries.Save(context.Background(), &internal.Country{CountryName: "Ostania"})
+				panic("unexpected state")
+			})
+		})
+		require.Equal(t, "rollback", connector.events[len(connector.events)-1])
+		require.NotContains(t, connector.events, "commit")
+	})
+
+	t.Run("does not begin the transaction when the request is canceled", func(t *testing.T) {
+		connector := &recordingConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewMySQLUnitOfWork(db).Do(ctx, saveLocation)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, connector.events)
+	})
+
+	t.Run("runs the nested transactions of a repository in the one of the unit of work", func(t *testing.T) {
+		connector := &recordingConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		err := NewMySQLUnitOfWork(db).Do(context.Background(), func(repos internal.TxRepositories) error {
+			return repos.Provinces.Delete(context.Background(), 1, 1)
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"begin", "DELETE FROM localities WHERE province_id=?;", "DELETE FROM provinces WHERE id=? AND version=?;", "commit"}, connector.events)
+	})
+
+	t.Run("locks the section read for update in the transaction", func(t *testing.T) {
+		connector := &recordingConnector{}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+
+		err := NewMySQLUnitOfWork(db).Do(context.Background(), func(repos internal.TxRepositories) error {
+			_, err := repos.Sections.GetByIDForUpdate(context.Background(), 1)
+			return err
+		})
+		require.ErrorIs(t, err, utils.ErrNotFound)
+		require.Equal(t, "begin", connector.events[0])
+		require.True(t, strings.HasSuffix(connector.events[1], " FOR UPDATE"))
+		require.Equal(t, "rollback", connector.events[2])
+	})
+}
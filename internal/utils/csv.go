@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// byteOrderMark is written by some spreadsheet tools at the start of UTF-8 files
+const byteOrderMark = "\uFEFF"
+
+// CSVRecord is a row of a CSV file with a header, Line is its line number in the file
+type CSVRecord struct {
+	Line   int
+	fields map[string]string
+}
+
+// Get returns the trimmed value of a column, empty when the row doesn't have it
+func (r CSVRecord) Get(column string) string {
+	return r.fields[strings.ToLower(column)]
+}
+
+// ReadCSV reads a CSV file whose first row is the header, column names are case-insensitive.
+// Blank rows are skipped and short rows leave the missing columns empty. It fails when the
+// file is malformed or a required column is missing from the header
+func ReadCSV(reader io.Reader, required ...string) ([]CSVRecord, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, EBR("csv file is empty")
+		}
+
+		return nil, EBadRequest("csv")
+	}
+
+	columns := make([]string, len(header))
+	present := map[string]bool{}
+
+	for i, column := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, byteOrderMark)))
+		present[columns[i]] = true
+	}
+
+	for _, column := range required {
+		if !present[strings.ToLower(column)] {
+			return nil, EBR("csv file is missing the " + column + " column")
+		}
+	}
+
+	records := []CSVRecord{}
+
+	for {
+		values, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, EBadRequest("csv")
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		record := CSVRecord{Line: line, fields: map[string]string{}}
+		blank := true
+
+		for i, value := range values {
+			if i >= len(columns) {
+				break
+			}
+
+			record.fields[columns[i]] = strings.TrimSpace(value)
+			blank = blank && record.fields[columns[i]] == ""
+		}
+
+		if !blank {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
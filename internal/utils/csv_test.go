@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+	file := "\uFEFFCountry_Name, province_name,locality_id\nArgentina,Buenos Aires,6700\n\n\"Chile\",\"Santiago, RM\"\n"
+
+	records, err := ReadCSV(strings.NewReader(file), "country_name", "locality_id")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	require.Equal(t, 2, records[0].Line)
+	require.Equal(t, "Argentina", records[0].Get("country_name"))
+	require.Equal(t, "Buenos Aires", records[0].Get("Province_Name"))
+	require.Equal(t, "6700", records[0].Get("locality_id"))
+
+	require.Equal(t, 4, records[1].Line)
+	require.Equal(t, "Santiago, RM", records[1].Get("province_name"))
+	require.Equal(t, "", records[1].Get("locality_id"))
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader(""))
+	require.ErrorIs(t, err, ErrInvalidArguments)
+
+	_, err = ReadCSV(strings.NewReader("country_name\nArgentina\n"), "locality_id")
+	require.ErrorIs(t, err, ErrInvalidArguments)
+	require.ErrorContains(t, err, "missing the locality_id column")
+
+	_, err = ReadCSV(strings.NewReader("country_name\n\"Argentina\n"))
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
@@ -25,6 +25,11 @@ func LoadProperties(path string) error {
 
 		key := strings.TrimSpace(keyAndValue[0])
 		value := strings.TrimSpace(keyAndValue[1])
+		// an empty property does not override the environment, where the secrets are set
+		if value == "" {
+			continue
+		}
+
 		fmt.Println(key, value)
 		os.Setenv(key, value)
 	}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -9,12 +10,18 @@ import (
 )
 
 var (
-	ErrInvalidFormat     = errors.New("invalid format")              // 400
-	ErrInvalidArguments  = errors.New("invalid arguments")           // 422
-	ErrConflict          = errors.New("entity already exists")       // 409
-	ErrNotFound          = errors.New("entity not found")            // 404
-	ErrInvalidProperties = errors.New("invalid properties format")   // For parsing the properties, panic
-	ErrEmptyArguments    = errors.New("arguments must not be empty") // 422
+	ErrInvalidFormat        = errors.New("invalid format")              // 400
+	ErrInvalidArguments     = errors.New("invalid arguments")           // 422
+	ErrConflict             = errors.New("entity already exists")       // 409
+	ErrInUse                = errors.New("entity in use")               // 409
+	ErrNotFound             = errors.New("entity not found")            // 404
+	ErrInvalidProperties    = errors.New("invalid properties format")   // For parsing the properties, panic
+	ErrEmptyArguments       = errors.New("arguments must not be empty") // 422
+	ErrPreconditionFailed   = errors.New("precondition failed")         // 412
+	ErrPreconditionRequired = errors.New("precondition required")       // 428
+	ErrUnauthorized         = errors.New("unauthorized")                // 401
+	ErrForbidden            = errors.New("forbidden")                   // 403
+	ErrTooManyRequests      = errors.New("too many requests")           // 429
 )
 
 // ENotFound When 404 status, only when entity has some relation with the url, e.g. GET /product/1
@@ -33,6 +40,11 @@ func EConflict(target, attribute string) error {
 	return errors.Join(ErrConflict, errors.New(target+" with attribute '"+attribute+"' already exists"))
 }
 
+// EInUse When 409, when trying to delete a resource other entities still refer to
+func EInUse(target, dependents string) error {
+	return errors.Join(ErrInUse, errors.New(target+" is referenced by "+dependents))
+}
+
 // EDependencyNotFound When 422, when managing a resource, and an attribute that refers other entity
 // does not exist for that value
 func EDependencyNotFound(target, attribute string) error {
@@ -44,12 +56,37 @@ func EBR(message string) error {
 	return errors.Join(ErrInvalidArguments, errors.New(message))
 }
 
+// EPreconditionFailed When 412, when the version of a resource in If-Match is not its current one
+func EPreconditionFailed(target string) error {
+	return errors.Join(ErrPreconditionFailed, errors.New(target+" was changed, read it again to get its current version"))
+}
+
+// EUnauthorized When 401, when the request is not authenticated or its credentials are not valid
+func EUnauthorized(message string) error {
+	return errors.Join(ErrUnauthorized, errors.New(message))
+}
+
+// EForbidden When 403, when the authenticated user is not allowed to make the request
+func EForbidden(message string) error {
+	return errors.Join(ErrForbidden, errors.New(message))
+}
+
+// ETooManyRequests When 429, when the client made more requests than its rate limit allows
+func ETooManyRequests(message string) error {
+	return errors.Join(ErrTooManyRequests, errors.New(message))
+}
+
 // EBadRequest When 400, when payload or query params or path value cannot be processed
 // due to their format
 func EBadRequest(attribute string) error {
 	return errors.Join(ErrInvalidFormat, errors.New(attribute+" with invalid format"))
 }
 
+// EUnknownParam When 400, when a request has a query param its route does not have
+func EUnknownParam(param string) error {
+	return errors.Join(ErrInvalidFormat, errors.New("unknown query param "+param))
+}
+
 // HandleError centralizes error handling and response formatting
 func HandleError(w http.ResponseWriter, err error) {
 	var status int
@@ -70,12 +107,30 @@ func HandleError(w http.ResponseWriter, err error) {
 	} else if errors.Is(err, ErrEmptyArguments) {
 		status = http.StatusUnprocessableEntity
 		message = err.Error()
-	} else if errors.Is(err, ErrConflict) {
+	} else if errors.Is(err, ErrConflict) || errors.Is(err, ErrInUse) {
 		status = http.StatusConflict
 		message = err.Error()
 	} else if errors.Is(err, ErrNotFound) {
 		status = http.StatusNotFound
 		message = err.Error()
+	} else if errors.Is(err, ErrPreconditionFailed) {
+		status = http.StatusPreconditionFailed
+		message = err.Error()
+	} else if errors.Is(err, ErrPreconditionRequired) {
+		status = http.StatusPreconditionRequired
+		message = err.Error()
+	} else if errors.Is(err, ErrUnauthorized) {
+		status = http.StatusUnauthorized
+		message = err.Error()
+	} else if errors.Is(err, ErrForbidden) {
+		status = http.StatusForbidden
+		message = err.Error()
+	} else if errors.Is(err, ErrTooManyRequests) {
+		status = http.StatusTooManyRequests
+		message = err.Error()
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+		message = "the request took longer than its deadline"
 	} else {
 		status = http.StatusInternalServerError
 		message = "internal server error"
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
@@ -22,6 +24,11 @@ func TestEConflict(t *testing.T) {
 	require.ErrorIs(t, err, ErrConflict)
 	require.Equal(t, err.Error(), "entity already exists\nfoo with attribute 'bar' already exists")
 }
+func TestEInUse(t *testing.T) {
+	err := EInUse("foo", "2 bars")
+	require.ErrorIs(t, err, ErrInUse)
+	require.Equal(t, "entity in use\nfoo is referenced by 2 bars", err.Error())
+}
 func TestEDependencyNotFound(t *testing.T) {
 	err := EDependencyNotFound("foo", "bar")
 	require.ErrorIs(t, err, ErrInvalidArguments)
@@ -38,6 +45,26 @@ func TestEBadRequest(t *testing.T) {
 	require.ErrorIs(t, err, ErrInvalidFormat)
 	require.Equal(t, "invalid format\nbar with invalid format", err.Error())
 }
+func TestEPreconditionFailed(t *testing.T) {
+	err := EPreconditionFailed("foo")
+	require.ErrorIs(t, err, ErrPreconditionFailed)
+	require.Equal(t, "precondition failed\nfoo was changed, read it again to get its current version", err.Error())
+}
+func TestEUnauthorized(t *testing.T) {
+	err := EUnauthorized("token expired")
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestEForbidden(t *testing.T) {
+	err := EForbidden("warehouse 2 is not assigned to the user")
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestETooManyRequests(t *testing.T) {
+	err := ETooManyRequests("rate limit of 60 requests per 1m0s exceeded")
+	require.ErrorIs(t, err, ErrTooManyRequests)
+}
+
 func TestHandleError(t *testing.T) {
 	cases := []struct {
 		Name               string
@@ -69,6 +96,36 @@ func TestHandleError(t *testing.T) {
 			Err:                ErrConflict,
 			ExpectedStatusCode: http.StatusConflict,
 		},
+		{
+			Name:               "WHEN ErrPreconditionFailed",
+			Err:                ErrPreconditionFailed,
+			ExpectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			Name:               "WHEN ErrPreconditionRequired",
+			Err:                ErrPreconditionRequired,
+			ExpectedStatusCode: http.StatusPreconditionRequired,
+		},
+		{
+			Name:               "WHEN ErrUnauthorized",
+			Err:                EUnauthorized("token expired"),
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:               "WHEN ErrForbidden",
+			Err:                EForbidden("warehouse 2 is not assigned to the user"),
+			ExpectedStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:               "WHEN ErrTooManyRequests",
+			Err:                ETooManyRequests("rate limit of 60 requests per 1m0s exceeded"),
+			ExpectedStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			Name:               "WHEN the deadline of the request passed",
+			Err:                fmt.Errorf("querying products: %w", context.DeadlineExceeded),
+			ExpectedStatusCode: http.StatusGatewayTimeout,
+		},
 		{
 			Name:               "WHEN no mapped error",
 			Err:                ErrNotFound,
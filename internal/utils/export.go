@@ -0,0 +1,488 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// exportFlushRows is the number of rows written between flushes of a streamed export
+const exportFlushRows = 500
+
+// ExportPageSize is the number of rows read for each page of an export streamed by ExportPages
+const ExportPageSize = 500
+
+// exportMediaTypes are the media types of the export formats, as sent in the Accept header
+var exportMediaTypes = map[string]string{
+	"application/json": FormatJSON,
+	"text/csv":         FormatCSV,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+}
+
+// ExportFormat negotiates the format of a report, the format query param (json, csv or xlsx) wins over
+// the Accept header and JSON is used when neither asks for a supported format. The supported media
+// type of the Accept header with the highest q-value is used, the first one on a tie, and a q-value
+// of 0 means the media type is not acceptable
+func ExportFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))); format != "" {
+		if format != FormatJSON && format != FormatCSV && format != FormatXLSX {
+			return "", EBadRequest("format")
+		}
+
+		return format, nil
+	}
+
+	best, bestQuality := FormatJSON, 0.0
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		format, ok := exportMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+
+	return best, nil
+}
+
+// Export writes rows, a slice of structs or a single struct, as a CSV or XLSX attachment called name.
+// The columns are the json names of the fields, nested structs are flattened as parent.child, slices of
+// scalars are joined with commas and any other value is written as JSON. The reports too big to be read
+// at once are streamed by ExportPages
+func Export(w http.ResponseWriter, format, name string, rows any) {
+	value := reflect.Indirect(reflect.ValueOf(rows))
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		slice := reflect.MakeSlice(reflect.SliceOf(value.Type()), 1, 1)
+		slice.Index(0).Set(value)
+		value = slice
+	}
+
+	exportRows(w, format, name, value.Type().Elem(), func() (reflect.Value, bool, error) {
+		return value, false, nil
+	})
+}
+
+// ExportPages streams the pages of rows read by next as a CSV or XLSX attachment, as Export does. A page
+// is written and flushed before next is called again for the following one, while more is true, so
+// only a page is kept in memory. An error reading the first page is written by HandleError, an error
+// reading a later one aborts the response, its status was already sent and the file is incomplete
+func ExportPages[T any](w http.ResponseWriter, format, name string, next func() (page []T, more bool, err error)) {
+	exportRows(w, format, name, reflect.TypeFor[T](), func() (reflect.Value, bool, error) {
+		page, more, err := next()
+		return reflect.ValueOf(page), more, err
+	})
+}
+
+// exportRows writes the pages of rows of elementType read by next
+func exportRows(w http.ResponseWriter, format, name string, elementType reflect.Type, next func() (reflect.Value, bool, error)) {
+	page, more, err := next()
+	if err != nil {
+		HandleError(w, err)
+		return
+	}
+
+	for elementType.Kind() == reflect.Pointer {
+		elementType = elementType.Elem()
+	}
+
+	columns := exportColumns(elementType, "", nil)
+
+	mediaType := "text/csv"
+	if format == FormatXLSX {
+		mediaType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+	w.WriteHeader(http.StatusOK)
+
+	// the flushes go through the middlewares wrapping w that can be unwrapped
+	controller := http.NewResponseController(w)
+
+	var writer exportWriter
+	if format == FormatXLSX {
+		writer = newXLSXWriter(w)
+	} else {
+		writer = &csvExportWriter{writer: csv.NewWriter(w)}
+	}
+
+	header := make([]exportCell, len(columns))
+	for i, column := range columns {
+		header[i] = exportCell{text: column.name}
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return
+	}
+
+	flush := func() error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		// a writer that can't be flushed sends the rows as its buffer fills up
+		if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+
+		return nil
+	}
+
+	written := 0
+
+	for {
+		for i := 0; i < page.Len(); i++ {
+			row := reflect.Indirect(page.Index(i))
+
+			cells := make([]exportCell, len(columns))
+			for j, column := range columns {
+				cells[j] = newExportCell(row, column.index)
+			}
+
+			if err := writer.WriteRow(cells); err != nil {
+				return
+			}
+
+			if written++; written%exportFlushRows == 0 {
+				if err := flush(); err != nil {
+					return
+				}
+			}
+		}
+
+		if !more {
+			break
+		}
+
+		if err := flush(); err != nil {
+			return
+		}
+
+		page, more, err = next()
+		if err != nil {
+			log.Printf("error reading a page of the export %s: %s", name, err.Error())
+			panic(http.ErrAbortHandler)
+		}
+	}
+
+	_ = writer.Close()
+}
+
+// exportColumn is a column of an export and the path to its field
+type exportColumn struct {
+	name  string
+	index []int
+}
+
+func exportColumns(structType reflect.Type, prefix string, index []int) []exportColumn {
+	if structType.Kind() != reflect.Struct {
+		return []exportColumn{{name: strings.TrimSuffix(prefix, "."), index: index}}
+	}
+
+	columns := []exportColumn{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			columns = append(columns, exportColumns(field.Type, prefix, fieldIndex)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			columns = append(columns, exportColumns(field.Type, prefix+name+".", fieldIndex)...)
+			continue
+		}
+
+		columns = append(columns, exportColumn{name: prefix + name, index: fieldIndex})
+	}
+
+	return columns
+}
+
+// exportCell is the text of a cell, numeric cells are written as numbers in XLSX
+type exportCell struct {
+	text    string
+	numeric bool
+}
+
+func newExportCell(row reflect.Value, index []int) exportCell {
+	value := row
+	if value.Kind() == reflect.Struct {
+		value = value.FieldByIndex(index)
+	}
+
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return exportCell{}
+		}
+
+		value = value.Elem()
+	}
+
+	if cell, ok := scalarExportCell(value); ok {
+		return cell
+	}
+
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		texts := make([]string, 0, value.Len())
+
+		for i := 0; i < value.Len(); i++ {
+			cell, ok := scalarExportCell(reflect.Indirect(value.Index(i)))
+			if !ok {
+				texts = nil
+				break
+			}
+
+			texts = append(texts, cell.text)
+		}
+
+		if texts != nil || value.Len() == 0 {
+			return exportCell{text: strings.Join(texts, ",")}
+		}
+	}
+
+	bytes, err := json.Marshal(value.Interface())
+	if err != nil {
+		return exportCell{}
+	}
+
+	return exportCell{text: string(bytes)}
+}
+
+func scalarExportCell(value reflect.Value) (exportCell, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return exportCell{text: value.String()}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return exportCell{text: strconv.FormatInt(value.Int(), 10), numeric: true}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return exportCell{text: strconv.FormatUint(value.Uint(), 10), numeric: true}, true
+	case reflect.Float32, reflect.Float64:
+		return exportCell{text: strconv.FormatFloat(value.Float(), 'f', -1, 64), numeric: true}, true
+	case reflect.Bool:
+		return exportCell{text: strconv.FormatBool(value.Bool())}, true
+	default:
+		return exportCell{}, false
+	}
+}
+
+type exportWriter interface {
+	WriteRow(cells []exportCell) error
+	Flush() error
+	Close() error
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (c *csvExportWriter) WriteRow(cells []exportCell) error {
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		c.record = append(c.record, csvText(cell))
+	}
+
+	return c.writer.Write(c.record)
+}
+
+// csvText returns the text of a CSV cell, a text starting as a formula is prefixed by ' so the
+// spreadsheets opening the file show it rather than run it. The numbers, negative ones too, are
+// written as they are
+func csvText(cell exportCell) string {
+	if cell.numeric || cell.text == "" {
+		return cell.text
+	}
+
+	if strings.ContainsRune("=+-@\t\r", rune(cell.text[0])) {
+		return "'" + cell.text
+	}
+
+	return cell.text
+}
+
+func (c *csvExportWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvExportWriter) Close() error {
+	return c.Flush()
+}
+
+// xlsxWriter writes a workbook with a single sheet, the rows of the sheet are streamed
+// into the zip archive as they are written
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+	err     error
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Report" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	x := &xlsxWriter{archive: zip.NewWriter(w)}
+
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		x.writePart(part.name, part.content)
+	}
+
+	if x.err == nil {
+		x.sheet, x.err = x.archive.Create("xl/worksheets/sheet1.xml")
+	}
+
+	x.write(xlsxSheetStart)
+
+	return x
+}
+
+func (x *xlsxWriter) writePart(name, content string) {
+	if x.err != nil {
+		return
+	}
+
+	var part io.Writer
+
+	part, x.err = x.archive.Create(name)
+	if x.err == nil {
+		_, x.err = io.WriteString(part, content)
+	}
+}
+
+func (x *xlsxWriter) write(content string) {
+	if x.err == nil {
+		_, x.err = io.WriteString(x.sheet, content)
+	}
+}
+
+func (x *xlsxWriter) WriteRow(cells []exportCell) error {
+	x.rows++
+	row := strconv.Itoa(x.rows)
+
+	var builder strings.Builder
+
+	builder.WriteString(`<row r="` + row + `">`)
+
+	for i, cell := range cells {
+		reference := xlsxColumn(i) + row
+
+		switch {
+		case cell.text == "":
+			continue
+		case cell.numeric:
+			builder.WriteString(`<c r="` + reference + `"><v>` + cell.text + `</v></c>`)
+		default:
+			builder.WriteString(`<c r="` + reference + `" t="inlineStr"><is><t xml:space="preserve">`)
+			_ = xml.EscapeText(&builder, []byte(cell.text))
+			builder.WriteString(`</t></is></c>`)
+		}
+	}
+
+	builder.WriteString(`</row>`)
+	x.write(builder.String())
+
+	return x.err
+}
+
+func (x *xlsxWriter) Flush() error {
+	if x.err == nil {
+		x.err = x.archive.Flush()
+	}
+
+	return x.err
+}
+
+func (x *xlsxWriter) Close() error {
+	x.write(xlsxSheetEnd)
+
+	if x.err == nil {
+		x.err = x.archive.Close()
+	}
+
+	return x.err
+}
+
+// xlsxColumn returns the letters of a zero based column index, A to Z, then AA and so on
+func xlsxColumn(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exportLocation struct {
+	City string `json:"city"`
+}
+
+type exportRow struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Usage    *float64       `json:"usage,omitempty"`
+	Codes    []string       `json:"codes"`
+	Location exportLocation `json:"location"`
+	Secret   string         `json:"-"`
+}
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		expected string
+		wantErr  error
+	}{
+		{name: "default", target: "/", expected: FormatJSON},
+		{name: "query param", target: "/?format=XLSX", accept: "text/csv", expected: FormatXLSX},
+		{name: "accept header", target: "/", accept: "text/html, text/csv;q=0.9", expected: FormatCSV},
+		{name: "accept xlsx", target: "/", accept: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", expected: FormatXLSX},
+		{name: "unsupported accept header", target: "/", accept: "text/html", expected: FormatJSON},
+		{name: "highest q-value", target: "/", accept: "application/json;q=0.5, text/csv;q=0.8, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet;q=0.9", expected: FormatXLSX},
+		{name: "first of the same q-value", target: "/", accept: "text/csv;q=0.5, application/json;q=0.5", expected: FormatCSV},
+		{name: "not acceptable media type", target: "/", accept: "text/csv;q=0", expected: FormatJSON},
+		{name: "unsupported query param", target: "/?format=pdf", wantErr: ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.Header.Set("Accept", tt.accept)
+
+			format, err := ExportFormat(r)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, format)
+		})
+	}
+}
+
+func TestExport_CSV(t *testing.T) {
+	usage := 12.5
+	rows := []exportRow{
+		{ID: 1, Name: "North, main", Usage: &usage, Codes: []string{"A", "B"}, Location: exportLocation{City: "Rosario"}, Secret: "x"},
+		{ID: 2, Name: "South"},
+	}
+
+	w := httptest.NewRecorder()
+	Export(w, FormatCSV, "report", rows)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	require.Equal(t, "attachment; filename=report.csv", w.Header().Get("Content-Disposition"))
+	require.Equal(t, "id,name,usage,codes,location.city\n1,\"North, main\",12.5,\"A,B\",Rosario\n2,South,,,\n", w.Body.String())
+}
+
+func TestExport_CSVFormulas(t *testing.T) {
+	type formulaRow struct {
+		Name    string  `json:"name"`
+		Balance float64 `json:"balance"`
+	}
+
+	rows := []formulaRow{{Name: "=HYPERLINK(\"x\")", Balance: -3}, {Name: "+1"}, {Name: "-1"}, {Name: "@SUM(A1)"}, {Name: "a=b"}}
+
+	w := httptest.NewRecorder()
+	Export(w, FormatCSV, "report", rows)
+
+	require.Equal(t, "name,balance\n\"'=HYPERLINK(\"\"x\"\")\",-3\n'+1,0\n'-1,0\n'@SUM(A1),0\na=b,0\n", w.Body.String())
+}
+
+func TestExportPages(t *testing.T) {
+	t.Run("streams every page", func(t *testing.T) {
+		pages := [][]exportLocation{{{City: "Rosario"}}, {{City: "Mendoza"}}}
+		read := 0
+
+		w := httptest.NewRecorder()
+		ExportPages(w, FormatCSV, "report", func() ([]exportLocation, bool, error) {
+			page := pages[read]
+			read++
+
+			return page, read < len(pages), nil
+		})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, w.Flushed)
+		require.Equal(t, "city\nRosario\nMendoza\n", w.Body.String())
+	})
+
+	t.Run("error reading the first page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ExportPages(w, FormatCSV, "report", func() ([]exportLocation, bool, error) {
+			return nil, false, ErrNotFound
+		})
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("error reading a later page aborts the response", func(t *testing.T) {
+		read := 0
+
+		w := httptest.NewRecorder()
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			ExportPages(w, FormatCSV, "report", func() ([]exportLocation, bool, error) {
+				if read++; read > 1 {
+					return nil, false, errors.New("connection lost")
+				}
+
+				return []exportLocation{{City: "Rosario"}}, true, nil
+			})
+		})
+
+		require.Equal(t, "city\nRosario\n", w.Body.String())
+	})
+}
+
+func TestExport_SingleStruct(t *testing.T) {
+	w := httptest.NewRecorder()
+	Export(w, FormatCSV, "report", exportLocation{City: "Rosario"})
+
+	require.Equal(t, "city\nRosario\n", w.Body.String())
+}
+
+func TestExport_XLSX(t *testing.T) {
+	rows := []exportRow{{ID: 1, Name: "Fish & Chips", Location: exportLocation{City: "Rosario"}}}
+
+	w := httptest.NewRecorder()
+	Export(w, FormatXLSX, "report", rows)
+
+	require.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+
+	parts := map[string]string{}
+
+	for _, file := range archive.File {
+		reader, openErr := file.Open()
+		require.NoError(t, openErr)
+
+		content, readErr := io.ReadAll(reader)
+		require.NoError(t, readErr)
+
+		parts[file.Name] = string(content)
+	}
+
+	require.Contains(t, parts, "[Content_Types].xml")
+	require.Contains(t, parts, "xl/workbook.xml")
+	require.Contains(t, parts["xl/worksheets/sheet1.xml"], `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	require.Contains(t, parts["xl/worksheets/sheet1.xml"], `<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">Fish &amp; Chips</t></is></c><c r="E2" t="inlineStr"><is><t xml:space="preserve">Rosario</t></is></c></row>`)
+}
+
+func TestXLSXColumn(t *testing.T) {
+	require.Equal(t, "A", xlsxColumn(0))
+	require.Equal(t, "Z", xlsxColumn(25))
+	require.Equal(t, "AA", xlsxColumn(26))
+	require.Equal(t, "AZ", xlsxColumn(51))
+	require.Equal(t, "BA", xlsxColumn(52))
+}
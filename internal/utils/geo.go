@@ -0,0 +1,43 @@
+package utils
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the Earth used for great-circle distances
+const EarthRadiusKm = 6371.0
+
+// GreatCircleDistance returns the distance in kilometres between two points given in decimal degrees,
+// computed with the haversine formula
+func GreatCircleDistance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	deltaLatitude := toRadians(latitude2 - latitude1)
+	deltaLongitude := toRadians(longitude2 - longitude1)
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*
+			math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// ValidateCoordinates checks latitude and longitude are both present or both missing,
+// and that they are within [-90, 90] and [-180, 180]
+func ValidateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return EBR("latitude and longitude must be set together")
+	}
+
+	if latitude == nil {
+		return nil
+	}
+
+	if *latitude < -90 || *latitude > 90 {
+		return EBR("latitude must be between -90 and 90")
+	}
+
+	if *longitude < -180 || *longitude > 180 {
+		return EBR("longitude must be between -180 and 180")
+	}
+
+	return nil
+}
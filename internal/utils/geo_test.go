@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGreatCircleDistance(t *testing.T) {
+	// Los Angeles to Toronto is about 3500 km
+	distance := GreatCircleDistance(34.052235, -118.243683, 43.651070, -79.347015)
+	require.InDelta(t, 3494, distance, 10)
+
+	require.Zero(t, GreatCircleDistance(43.651070, -79.347015, 43.651070, -79.347015))
+	require.InDelta(t, EarthRadiusKm*3.141592653589793, GreatCircleDistance(0, 0, 0, 180), 0.001)
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	latitude, longitude := 43.65, -79.34
+	outOfRange := 91.0
+
+	require.NoError(t, ValidateCoordinates(nil, nil))
+	require.NoError(t, ValidateCoordinates(&latitude, &longitude))
+	require.ErrorIs(t, ValidateCoordinates(&latitude, nil), ErrInvalidArguments)
+	require.ErrorIs(t, ValidateCoordinates(&outOfRange, &longitude), ErrInvalidArguments)
+
+	outOfRange = 181
+	require.ErrorIs(t, ValidateCoordinates(&latitude, &outOfRange), ErrInvalidArguments)
+}
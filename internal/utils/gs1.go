@@ -0,0 +1,332 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// groupSeparator is the FNC1 character ending variable length elements in a raw GS1-128 string
+const groupSeparator = "\x1d"
+
+// GS1Label is the data decoded from a GTIN or GS1-128 barcode, dates are formatted as time.DateOnly
+type GS1Label struct {
+	GTIN           string `json:"gtin,omitempty"`
+	SSCC           string `json:"sscc,omitempty"`
+	BatchNumber    string `json:"batch_number,omitempty"`
+	SerialNumber   string `json:"serial_number,omitempty"`
+	ProductionDate string `json:"production_date,omitempty"`
+	BestBeforeDate string `json:"best_before_date,omitempty"`
+	ExpiryDate     string `json:"expiry_date,omitempty"`
+	elements       map[string]string
+}
+
+// gs1AI describes the length of the value of an application identifier
+type gs1AI struct {
+	length int
+	fixed  bool
+}
+
+// gs1AIs are the application identifiers understood by the parser
+var gs1AIs = map[string]gs1AI{
+	"00": {18, true},
+	"01": {14, true},
+	"02": {14, true},
+	"10": {20, false},
+	"11": {6, true},
+	"13": {6, true},
+	"15": {6, true},
+	"16": {6, true},
+	"17": {6, true},
+	"21": {20, false},
+	"37": {8, false},
+}
+
+var symbologyIdentifiers = []string{"]C1", "]e0", "]d2", "]Q3"}
+
+var hriElement = regexp.MustCompile(`\((\d{2,4})\)([^()]*)`)
+
+// ParseGS1 decodes a barcode, either a plain GTIN (EAN-8, UPC-A, EAN-13, GTIN-14) or a GS1-128
+// string in its human readable form, e.g. (01)07791234000018(17)250115(10)100, or raw form
+// with FNC1 separators
+func ParseGS1(code string) (GS1Label, error) {
+	code = strings.TrimSpace(code)
+	for _, identifier := range symbologyIdentifiers {
+		code = strings.TrimPrefix(code, identifier)
+	}
+
+	if code == "" {
+		return GS1Label{}, gs1Error("empty")
+	}
+
+	var elements map[string]string
+
+	var err error
+
+	switch {
+	case isDigits(code) && isGTINLength(len(code)):
+		elements = map[string]string{"01": code}
+	case strings.HasPrefix(code, "("):
+		elements, err = parseHRI(code)
+	default:
+		elements, err = parseRaw(code)
+	}
+
+	if err != nil {
+		return GS1Label{}, err
+	}
+
+	return newGS1Label(elements)
+}
+
+// NormalizeGTIN validates the check digit of a GTIN and pads it to its 14 digits form
+func NormalizeGTIN(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if !isDigits(code) || !isGTINLength(len(code)) {
+		return "", gs1Error("GTIN must have 8, 12, 13 or 14 digits")
+	}
+
+	if !validCheckDigit(code) {
+		return "", gs1Error("wrong GTIN check digit")
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code, nil
+}
+
+// String returns the label in its canonical human readable form, with the elements ordered by AI,
+// so different scans of the same label can be compared
+func (l GS1Label) String() string {
+	ais := make([]string, 0, len(l.elements))
+	for ai := range l.elements {
+		ais = append(ais, ai)
+	}
+
+	sort.Strings(ais)
+
+	var builder strings.Builder
+	for _, ai := range ais {
+		builder.WriteString("(" + ai + ")" + l.elements[ai])
+	}
+
+	return builder.String()
+}
+
+func parseHRI(code string) (map[string]string, error) {
+	matches := hriElement.FindAllStringSubmatchIndex(code, -1)
+
+	elements := map[string]string{}
+	end := 0
+
+	for _, match := range matches {
+		if match[0] != end {
+			return nil, gs1Error("unexpected characters at position " + strconv.Itoa(end))
+		}
+
+		end = match[1]
+		ai, value := code[match[2]:match[3]], code[match[4]:match[5]]
+
+		err := addElement(elements, ai, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if end != len(code) {
+		return nil, gs1Error("unexpected characters at position " + strconv.Itoa(end))
+	}
+
+	return elements, nil
+}
+
+func parseRaw(code string) (map[string]string, error) {
+	elements := map[string]string{}
+
+	for code != "" {
+		ai, definition, err := lookupAI(code)
+		if err != nil {
+			return nil, err
+		}
+
+		code = code[len(ai):]
+
+		var value string
+		if definition.fixed {
+			if len(code) < definition.length {
+				return nil, gs1Error("AI " + ai + " is truncated")
+			}
+
+			value, code = code[:definition.length], code[definition.length:]
+		} else {
+			value, code, _ = strings.Cut(code, groupSeparator)
+		}
+
+		code = strings.TrimPrefix(code, groupSeparator)
+
+		err = addElement(elements, ai, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return elements, nil
+}
+
+// lookupAI finds the application identifier at the start of a raw GS1-128 string,
+// the 310n net weight AIs are four digits long
+func lookupAI(code string) (string, gs1AI, error) {
+	if len(code) >= 4 && strings.HasPrefix(code, "310") && code[3] >= '0' && code[3] <= '9' {
+		return code[:4], gs1AI{6, true}, nil
+	}
+
+	if len(code) < 2 {
+		return "", gs1AI{}, gs1Error("AI is truncated")
+	}
+
+	definition, ok := gs1AIs[code[:2]]
+	if !ok {
+		return "", gs1AI{}, gs1Error("unsupported AI " + code[:2])
+	}
+
+	return code[:2], definition, nil
+}
+
+func addElement(elements map[string]string, ai, value string) error {
+	definition, ok := gs1AIs[ai]
+	if !ok && !(len(ai) == 4 && strings.HasPrefix(ai, "310")) {
+		return gs1Error("unsupported AI " + ai)
+	}
+
+	if !ok {
+		definition = gs1AI{6, true}
+	}
+
+	if value == "" || len(value) > definition.length || (definition.fixed && len(value) != definition.length) {
+		return gs1Error("AI " + ai + " has an invalid length")
+	}
+
+	if _, duplicated := elements[ai]; duplicated {
+		return gs1Error("AI " + ai + " is repeated")
+	}
+
+	elements[ai] = value
+
+	return nil
+}
+
+func newGS1Label(elements map[string]string) (GS1Label, error) {
+	label := GS1Label{elements: elements}
+
+	var err error
+
+	for _, ai := range []string{"01", "02"} {
+		if value, ok := elements[ai]; ok && label.GTIN == "" {
+			label.GTIN, err = NormalizeGTIN(value)
+			if err != nil {
+				return GS1Label{}, err
+			}
+
+			elements[ai] = label.GTIN
+		}
+	}
+
+	if sscc, ok := elements["00"]; ok {
+		if !isDigits(sscc) || !validCheckDigit(sscc) {
+			return GS1Label{}, gs1Error("wrong SSCC check digit")
+		}
+
+		label.SSCC = sscc
+	}
+
+	label.BatchNumber = elements["10"]
+	label.SerialNumber = elements["21"]
+
+	dates := []struct {
+		ai     string
+		target *string
+	}{{"11", &label.ProductionDate}, {"15", &label.BestBeforeDate}, {"17", &label.ExpiryDate}}
+
+	for _, date := range dates {
+		if value, ok := elements[date.ai]; ok {
+			*date.target, err = parseGS1Date(value)
+			if err != nil {
+				return GS1Label{}, gs1Error("AI " + date.ai + " is not a valid date")
+			}
+		}
+	}
+
+	return label, nil
+}
+
+// parseGS1Date parses a YYMMDD date, where day 00 means the last day of the month and the century
+// follows the GS1 sliding window around the current year
+func parseGS1Date(value string) (string, error) {
+	if !isDigits(value) {
+		return "", errors.New("date must be numeric")
+	}
+
+	yy, _ := strconv.Atoi(value[:2])
+	month, _ := strconv.Atoi(value[2:4])
+	day, _ := strconv.Atoi(value[4:])
+
+	currentYear := time.Now().Year()
+	year := currentYear/100*100 + yy
+
+	switch difference := yy - currentYear%100; {
+	case difference >= 51:
+		year -= 100
+	case difference <= -50:
+		year += 100
+	}
+
+	if month < 1 || month > 12 {
+		return "", errors.New("invalid month")
+	}
+
+	if day == 0 {
+		return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Format(time.DateOnly), nil
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return "", errors.New("invalid day")
+	}
+
+	return date.Format(time.DateOnly), nil
+}
+
+// validCheckDigit checks the GS1 mod 10 check digit, the last digit of the code
+func validCheckDigit(code string) bool {
+	sum := 0
+
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+
+		sum += digit
+	}
+
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func isGTINLength(length int) bool {
+	return length == 8 || length == 12 || length == 13 || length == 14
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return value != ""
+}
+
+func gs1Error(message string) error {
+	return errors.Join(ErrInvalidFormat, errors.New("barcode: "+message))
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGS1(t *testing.T) {
+	cases := []struct {
+		name      string
+		code      string
+		wantLabel GS1Label
+		wantCode  string
+	}{
+		{
+			name:      "EAN-13",
+			code:      "7791234000012",
+			wantLabel: GS1Label{GTIN: "07791234000012"},
+			wantCode:  "(01)07791234000012",
+		},
+		{
+			name:      "EAN-8",
+			code:      "12345670",
+			wantLabel: GS1Label{GTIN: "00000012345670"},
+			wantCode:  "(01)00000012345670",
+		},
+		{
+			name:      "GS1-128 human readable",
+			code:      "(01)17791234000019(17)250115(10)A100",
+			wantLabel: GS1Label{GTIN: "17791234000019", BatchNumber: "A100", ExpiryDate: "2025-01-15"},
+			wantCode:  "(01)17791234000019(10)A100(17)250115",
+		},
+		{
+			name:      "GS1-128 raw with symbology identifier and FNC1",
+			code:      "]C1011779123400001910A100\x1d15250200",
+			wantLabel: GS1Label{GTIN: "17791234000019", BatchNumber: "A100", BestBeforeDate: "2025-02-28"},
+			wantCode:  "(01)17791234000019(10)A100(15)250200",
+		},
+		{
+			name:      "GS1-128 SSCC, serial and net weight",
+			code:      "(00)000123456000000005(21)XYZ(3102)001250",
+			wantLabel: GS1Label{SSCC: "000123456000000005", SerialNumber: "XYZ"},
+			wantCode:  "(00)000123456000000005(21)XYZ(3102)001250",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			label, err := ParseGS1(c.code)
+			require.NoError(t, err)
+			require.Equal(t, c.wantCode, label.String())
+
+			label.elements = nil
+			require.Equal(t, c.wantLabel, label)
+		})
+	}
+}
+
+func TestParseGS1_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":                  "  ",
+		"wrong check digit":      "7791234000013",
+		"unsupported AI":         "(99)ABC",
+		"truncated fixed length": "0107791234",
+		"invalid date":           "(01)07791234000012(17)251332",
+		"repeated AI":            "(10)A(10)B",
+		"text outside elements":  "(10)A)",
+		"batch too long":         "(10)ABCDEFGHIJKLMNOPQRSTU",
+	}
+
+	for name, code := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseGS1(code)
+			require.ErrorIs(t, err, ErrInvalidFormat)
+		})
+	}
+}
+
+func TestNormalizeGTIN(t *testing.T) {
+	gtin, err := NormalizeGTIN("034567890125")
+	require.NoError(t, err)
+	require.Equal(t, "00034567890125", gtin)
+
+	_, err = NormalizeGTIN("03456789012")
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
@@ -1,5 +1,10 @@
 package utils
 
+import (
+	"strconv"
+	"strings"
+)
+
 // GetBiggestID Returns the biggest id of a int map
 // if len(map) == 0, returns 1
 func GetBiggestID[K int, V any](db map[K]V) int {
@@ -18,3 +23,69 @@ func GetBiggestID[K int, V any](db map[K]V) int {
 
 	return biggest
 }
+
+// DistinctIDs returns the ids of the items, each once and in the order they are first found
+func DistinctIDs[T any](items []T, id func(T) int) []int {
+	seen := map[int]bool{}
+	ids := []int{}
+
+	for _, item := range items {
+		if value := id(item); !seen[value] {
+			seen[value] = true
+			ids = append(ids, value)
+		}
+	}
+
+	return ids
+}
+
+// IndexByID maps the items by their id
+func IndexByID[T any](items []T, id func(T) int) map[int]T {
+	index := make(map[int]T, len(items))
+	for _, item := range items {
+		index[id(item)] = item
+	}
+
+	return index
+}
+
+// Related returns, for each item, the related entity with the id the item refers to by key, nil when
+// there is none
+func Related[T any, R any](items []T, key func(T) int, related []R, id func(R) int) []*R {
+	index := IndexByID(related, id)
+	found := make([]*R, len(items))
+
+	for i, item := range items {
+		if entity, ok := index[key(item)]; ok {
+			found[i] = &entity
+		}
+	}
+
+	return found
+}
+
+// InClause returns the placeholders of an IN condition on the ids, as in (?, ?, ?), and its arguments.
+// The ids must not be empty
+func InClause(ids []int) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return "(?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+// InIDs returns the condition of the column being one of the ids, with the ids written in it so it
+// can be given to ListQuery.SQL. It is always false when there are no ids
+func InIDs(column string, ids []int) string {
+	if len(ids) == 0 {
+		return "FALSE"
+	}
+
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+
+	return column + " IN (" + strings.Join(values, ", ") + ")"
+}
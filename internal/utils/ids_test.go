@@ -24,3 +24,31 @@ func Test__GetBiggestId__WhenMapIsEmpty(t *testing.T) {
 	biggest := GetBiggestID(map[int]testStructure{})
 	require.Equal(t, 1, biggest)
 }
+
+func Test__DistinctIDs__KeepsTheFirstOrder(t *testing.T) {
+	items := []testStructure{{3, "a"}, {1, "b"}, {3, "c"}}
+	require.Equal(t, []int{3, 1}, DistinctIDs(items, func(item testStructure) int { return item.ID }))
+}
+
+func Test__Related__MatchesTheItemsByKey(t *testing.T) {
+	items := []testStructure{{1, "a"}, {2, "b"}, {1, "c"}}
+	related := []testStructure{{1, "one"}}
+
+	found := Related(items, func(item testStructure) int { return item.ID }, related, func(item testStructure) int { return item.ID })
+
+	require.Len(t, found, 3)
+	require.Equal(t, "one", found[0].Data)
+	require.Nil(t, found[1])
+	require.Equal(t, "one", found[2].Data)
+}
+
+func Test__InClause(t *testing.T) {
+	clause, args := InClause([]int{4, 7, 9})
+	require.Equal(t, "(?, ?, ?)", clause)
+	require.Equal(t, []any{4, 7, 9}, args)
+}
+
+func Test__InIDs(t *testing.T) {
+	require.Equal(t, "s.warehouse_id IN (4, 7)", InIDs("s.warehouse_id", []int{4, 7}))
+	require.Equal(t, "FALSE", InIDs("s.warehouse_id", nil))
+}
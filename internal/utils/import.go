@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxImportSize is the largest file accepted by the bulk imports, in bytes
+const MaxImportSize = 32 << 20
+
+const (
+	ImportValid   = "valid"
+	ImportCreated = "created"
+	ImportFailed  = "failed"
+	ImportSkipped = "skipped"
+)
+
+// ImportOptions are the modes of a bulk import
+type ImportOptions struct {
+	// DryRun validates the rows without saving them
+	DryRun bool
+	// Atomic saves the rows in a single transaction, and none of them when any row is invalid
+	Atomic bool
+}
+
+// ImportItem is a row of a bulk import, Row is its line in a CSV file or its position in a JSON array.
+// Err is set when the row could not be decoded
+type ImportItem[T any] struct {
+	Row   int
+	Value T
+	Err   error
+}
+
+// ImportRowResult is the outcome of a row of a bulk import
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	ID     int    `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport summarises a bulk import with the result of every row,
+// Committed tells whether the valid rows were saved
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Valid     int               `json:"valid"`
+	Created   int               `json:"created"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// StatusCode is the response status of an import, 422 when an atomic import was rejected
+// because of failed rows and 200 otherwise
+func (r ImportReport) StatusCode() int {
+	if r.Atomic && !r.DryRun && r.Failed > 0 {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusOK
+}
+
+// Importer holds the entity specific steps of a bulk import
+type Importer[T any] struct {
+	// Validate applies the rules of the entity to a row, it may normalise its values.
+	// Rows are validated in order, so uniqueness is checked against the rows already accepted
+	Validate func(value *T) error
+	// Save creates a row and returns its id
+	Save func(value T) (int, error)
+	// SaveAll creates the rows in a single transaction and returns their ids in order
+	SaveAll func(values []T) ([]int, error)
+}
+
+// Import validates the items and, unless it is a dry run, saves the valid ones. Rows failing
+// validation or conflicting with stored ones are reported as failed, any other error stops the import.
+// An atomic import saves nothing when a row fails, reporting the valid rows as skipped
+func (i Importer[T]) Import(items []ImportItem[T], options ImportOptions) (ImportReport, error) {
+	report := ImportReport{DryRun: options.DryRun, Atomic: options.Atomic, Rows: make([]ImportRowResult, len(items))}
+	valid := []int{}
+
+	for index, item := range items {
+		report.Rows[index] = ImportRowResult{Row: item.Row, Status: ImportValid}
+
+		err := item.Err
+		if err == nil {
+			err = i.Validate(&items[index].Value)
+		}
+
+		if err != nil {
+			if !isRowError(err) {
+				return ImportReport{}, err
+			}
+
+			report.Rows[index].Status = ImportFailed
+			report.Rows[index].Error = strings.Replace(err.Error(), "\n", ": ", 1)
+			report.Failed++
+
+			continue
+		}
+
+		report.Valid++
+		valid = append(valid, index)
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	if options.Atomic {
+		if report.Failed > 0 {
+			for _, index := range valid {
+				report.Rows[index].Status = ImportSkipped
+			}
+
+			return report, nil
+		}
+
+		values := make([]T, 0, len(valid))
+		for _, index := range valid {
+			values = append(values, items[index].Value)
+		}
+
+		ids, err := i.SaveAll(values)
+		if err != nil {
+			return ImportReport{}, err
+		}
+
+		for position, index := range valid {
+			report.Rows[index].ID = ids[position]
+			report.Rows[index].Status = ImportCreated
+		}
+
+		report.Created = len(valid)
+		report.Committed = true
+
+		return report, nil
+	}
+
+	for _, index := range valid {
+		id, err := i.Save(items[index].Value)
+		if err != nil {
+			if !isRowError(err) {
+				return ImportReport{}, err
+			}
+
+			report.Rows[index].Status = ImportFailed
+			report.Rows[index].Error = strings.Replace(err.Error(), "\n", ": ", 1)
+			report.Valid--
+			report.Failed++
+
+			continue
+		}
+
+		report.Rows[index].ID = id
+		report.Rows[index].Status = ImportCreated
+		report.Created++
+	}
+
+	report.Committed = report.Created > 0
+
+	return report, nil
+}
+
+// isRowError tells whether an error is caused by the values of a row
+func isRowError(err error) bool {
+	return errors.Is(err, ErrInvalidArguments) || errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalidFormat)
+}
+
+// ParseImportOptions reads the dry_run and atomic query params, both false by default
+func ParseImportOptions(query url.Values) (ImportOptions, error) {
+	var options ImportOptions
+
+	for _, option := range []struct {
+		name  string
+		value *bool
+	}{
+		{"dry_run", &options.DryRun},
+		{"atomic", &options.Atomic},
+	} {
+		if query.Get(option.name) == "" {
+			continue
+		}
+
+		value, err := strconv.ParseBool(query.Get(option.name))
+		if err != nil {
+			return ImportOptions{}, EBadRequest(option.name)
+		}
+
+		*option.value = value
+	}
+
+	return options, nil
+}
+
+// ImportFile returns the file of an import request, the file field of a multipart form or else the body,
+// limited to MaxImportSize
+func ImportFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, EBadRequest("file")
+	}
+
+	return file, nil
+}
+
+// DecodeImport reads the rows of an import request, a JSON array when the content type is
+// application/json and otherwise a CSV file whose columns are the json names of the fields of T.
+// Rows whose values cannot be decoded are returned with Err set
+func DecodeImport[T any](w http.ResponseWriter, r *http.Request) ([]ImportItem[T], error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	file, err := ImportFile(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	if mediaType == "application/json" {
+		var rows []json.RawMessage
+		err = json.NewDecoder(file).Decode(&rows)
+		if err != nil {
+			return nil, EBadRequest("body")
+		}
+
+		items := make([]ImportItem[T], len(rows))
+
+		for index, row := range rows {
+			items[index].Row = index + 1
+			if decodeErr := json.Unmarshal(row, &items[index].Value); decodeErr != nil {
+				items[index].Err = EBR("row cannot be decoded: " + decodeErr.Error())
+			}
+		}
+
+		return items, nil
+	}
+
+	records, err := ReadCSV(file)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ImportItem[T], len(records))
+
+	for index, record := range records {
+		items[index].Row = record.Line
+		items[index].Err = UnmarshalCSVRecord(record, &items[index].Value)
+	}
+
+	return items, nil
+}
+
+// UnmarshalCSVRecord sets the fields of the struct pointed by v from the columns named as their
+// json names, embedded structs included. Empty values leave the fields untouched
+func UnmarshalCSVRecord(record CSVRecord, v any) error {
+	return unmarshalCSVStruct(record, reflect.ValueOf(v).Elem())
+}
+
+func unmarshalCSVStruct(record CSVRecord, value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := unmarshalCSVStruct(record, value.Field(i)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		column, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if column == "" || column == "-" || record.Get(column) == "" {
+			continue
+		}
+
+		if err := setCSVValue(value.Field(i), record.Get(column)); err != nil {
+			return EBR(column + " has an invalid value")
+		}
+	}
+
+	return nil
+}
+
+func setCSVValue(field reflect.Value, text string) error {
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+		if err := setCSVValue(value.Elem(), text); err != nil {
+			return err
+		}
+
+		field.Set(value)
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(value)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(value)
+	default:
+		return errors.New("unsupported field type " + field.Type().String())
+	}
+
+	return nil
+}
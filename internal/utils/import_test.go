@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type importRow struct {
+	Code     string   `json:"code"`
+	Quantity int      `json:"quantity"`
+	Price    *float64 `json:"price,omitempty"`
+	Active   bool     `json:"active"`
+}
+
+// newTestImporter accepts rows with a code, each code once, and numbers the saved rows from 1
+func newTestImporter(saved *[]string) Importer[importRow] {
+	codes := map[string]bool{}
+
+	return Importer[importRow]{
+		Validate: func(row *importRow) error {
+			if row.Code == "" {
+				return EZeroValue("code")
+			}
+
+			if codes[row.Code] {
+				return EConflict("row", "code")
+			}
+
+			codes[row.Code] = true
+
+			return nil
+		},
+		Save: func(row importRow) (int, error) {
+			*saved = append(*saved, row.Code)
+			return len(*saved), nil
+		},
+		SaveAll: func(rows []importRow) ([]int, error) {
+			ids := []int{}
+
+			for _, row := range rows {
+				*saved = append(*saved, row.Code)
+				ids = append(ids, len(*saved))
+			}
+
+			return ids, nil
+		},
+	}
+}
+
+func importItems() []ImportItem[importRow] {
+	return []ImportItem[importRow]{
+		{Row: 2, Value: importRow{Code: "A"}},
+		{Row: 3, Value: importRow{Code: ""}},
+		{Row: 4, Value: importRow{Code: "A"}},
+		{Row: 5, Err: EBR("quantity has an invalid value")},
+		{Row: 6, Value: importRow{Code: "B"}},
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	t.Run("saves the valid rows one by one", func(t *testing.T) {
+		saved := []string{}
+
+		report, err := newTestImporter(&saved).Import(importItems(), ImportOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"A", "B"}, saved)
+		require.True(t, report.Committed)
+		require.Equal(t, 2, report.Valid)
+		require.Equal(t, 2, report.Created)
+		require.Equal(t, 3, report.Failed)
+		require.Equal(t, ImportRowResult{Row: 2, ID: 1, Status: ImportCreated}, report.Rows[0])
+		require.Equal(t, ImportFailed, report.Rows[1].Status)
+		require.Equal(t, ImportFailed, report.Rows[2].Status)
+		require.Equal(t, "invalid arguments: quantity has an invalid value", report.Rows[3].Error)
+		require.Equal(t, ImportRowResult{Row: 6, ID: 2, Status: ImportCreated}, report.Rows[4])
+		require.Equal(t, http.StatusOK, report.StatusCode())
+	})
+
+	t.Run("dry run saves nothing", func(t *testing.T) {
+		saved := []string{}
+
+		report, err := newTestImporter(&saved).Import(importItems(), ImportOptions{DryRun: true, Atomic: true})
+		require.NoError(t, err)
+		require.Empty(t, saved)
+		require.False(t, report.Committed)
+		require.Equal(t, 2, report.Valid)
+		require.Equal(t, 0, report.Created)
+		require.Equal(t, ImportValid, report.Rows[0].Status)
+		require.Equal(t, http.StatusOK, report.StatusCode())
+	})
+
+	t.Run("atomic import with failed rows saves nothing", func(t *testing.T) {
+		saved := []string{}
+
+		report, err := newTestImporter(&saved).Import(importItems(), ImportOptions{Atomic: true})
+		require.NoError(t, err)
+		require.Empty(t, saved)
+		require.False(t, report.Committed)
+		require.Equal(t, ImportSkipped, report.Rows[0].Status)
+		require.Equal(t, ImportSkipped, report.Rows[4].Status)
+		require.Equal(t, http.StatusUnprocessableEntity, report.StatusCode())
+	})
+
+	t.Run("atomic import saves all the rows", func(t *testing.T) {
+		saved := []string{}
+		items := []ImportItem[importRow]{{Row: 1, Value: importRow{Code: "A"}}, {Row: 2, Value: importRow{Code: "B"}}}
+
+		report, err := newTestImporter(&saved).Import(items, ImportOptions{Atomic: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"A", "B"}, saved)
+		require.True(t, report.Committed)
+		require.Equal(t, 2, report.Created)
+		require.Equal(t, 2, report.Rows[1].ID)
+	})
+
+	t.Run("other errors stop the import", func(t *testing.T) {
+		saved := []string{}
+		importer := newTestImporter(&saved)
+		importer.Save = func(importRow) (int, error) {
+			return 0, errors.New("connection refused")
+		}
+
+		_, err := importer.Import(importItems(), ImportOptions{})
+		require.EqualError(t, err, "connection refused")
+	})
+}
+
+func TestParseImportOptions(t *testing.T) {
+	options, err := ParseImportOptions(url.Values{"dry_run": {"true"}})
+	require.NoError(t, err)
+	require.Equal(t, ImportOptions{DryRun: true}, options)
+
+	options, err = ParseImportOptions(url.Values{"atomic": {"1"}, "dry_run": {"false"}})
+	require.NoError(t, err)
+	require.Equal(t, ImportOptions{Atomic: true}, options)
+
+	_, err = ParseImportOptions(url.Values{"atomic": {"yes please"}})
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestUnmarshalCSVRecord(t *testing.T) {
+	records, err := ReadCSV(strings.NewReader("code,quantity,price,active,ignored\nA,3,1.5,true,x\nB,,,,\nC,three,,,\n"))
+	require.NoError(t, err)
+
+	var row importRow
+
+	require.NoError(t, UnmarshalCSVRecord(records[0], &row))
+
+	price := 1.5
+	require.Equal(t, importRow{Code: "A", Quantity: 3, Price: &price, Active: true}, row)
+
+	row = importRow{}
+	require.NoError(t, UnmarshalCSVRecord(records[1], &row))
+	require.Equal(t, importRow{Code: "B"}, row)
+
+	err = UnmarshalCSVRecord(records[2], &row)
+	require.ErrorIs(t, err, ErrInvalidArguments)
+	require.ErrorContains(t, err, "quantity has an invalid value")
+}
+
+func TestDecodeImport(t *testing.T) {
+	t.Run("json array", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"code":"A","quantity":2},{"code":"B","quantity":"two"}]`))
+		r.Header.Set("Content-Type", "application/json")
+
+		items, err := DecodeImport[importRow](httptest.NewRecorder(), r)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, ImportItem[importRow]{Row: 1, Value: importRow{Code: "A", Quantity: 2}}, items[0])
+		require.ErrorIs(t, items[1].Err, ErrInvalidArguments)
+	})
+
+	t.Run("csv file", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("code,quantity\nA,2\n"))
+		r.Header.Set("Content-Type", "text/csv")
+
+		items, err := DecodeImport[importRow](httptest.NewRecorder(), r)
+		require.NoError(t, err)
+		require.Equal(t, []ImportItem[importRow]{{Row: 2, Value: importRow{Code: "A", Quantity: 2}}}, items)
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code":"A"}`))
+		r.Header.Set("Content-Type", "application/json")
+
+		_, err := DecodeImport[importRow](httptest.NewRecorder(), r)
+		require.ErrorIs(t, err, ErrInvalidFormat)
+	})
+}
@@ -0,0 +1,410 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultListLimit is the page size of a list without a limit
+	DefaultListLimit = 50
+	// MaxListLimit is the biggest page size of a list
+	MaxListLimit = 500
+)
+
+// listParams are the query params of a list that are not filters
+var listParams = map[string]bool{"limit": true, "cursor": true, "sort": true, "format": true, "fields": true, "include": true, "include_deleted": true}
+
+// ListKind is the type of the values of a list field
+type ListKind int
+
+const (
+	ListInt ListKind = iota
+	ListFloat
+	ListString
+	// ListTime values are RFC 3339 times or dates, as 2024-01-31
+	ListTime
+)
+
+// ListField is a field a list can be filtered and sorted by, nullable columns cannot be sorted
+// as the cursor compares the values of the sort fields
+type ListField struct {
+	Column   string
+	Kind     ListKind
+	Sortable bool
+}
+
+// ListFields are the fields of a list by their json name, they must include id,
+// the tie-breaker of every sort
+type ListFields map[string]ListField
+
+// listOperators maps the operators of a filter, as in width[gte]=10, to SQL
+var listOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"like": "LIKE",
+}
+
+// ListSort is a field of the order of a list
+type ListSort struct {
+	Field string
+	Desc  bool
+}
+
+// ListFilter is a condition on a field of a list, Value is parsed by Resolve
+type ListFilter struct {
+	Field    string
+	Operator string
+	Text     string
+	Value    any
+}
+
+// ListQuery is a page of a list, with its filters and order. The page starts after the row the
+// cursor points to, the cursor holds the values of the sort fields of that row. IncludeDeleted
+// lists the soft deleted rows too
+type ListQuery struct {
+	Limit          int
+	Cursor         string
+	Sort           []ListSort
+	Filters        []ListFilter
+	IncludeDeleted bool
+	after          []any
+}
+
+// Pagination is the metadata of a page, NextCursor requests the following page
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// listCursor is the content of an encoded cursor
+type listCursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+// ParseListQuery reads the limit, cursor, sort and include_deleted query params, sort being a comma
+// separated list of fields with a - prefix for descending order. Every other param is a filter,
+// field=value or field[operator]=value with the operators eq, ne, gt, gte, lt, lte and like, checked
+// against the fields of the list by Resolve
+func ParseListQuery(query url.Values) (ListQuery, error) {
+	list := ListQuery{Limit: DefaultListLimit, Cursor: query.Get("cursor")}
+
+	if includeDeleted := query.Get("include_deleted"); includeDeleted != "" {
+		value, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return ListQuery{}, EBadRequest("include_deleted")
+		}
+
+		list.IncludeDeleted = value
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return ListQuery{}, EBadRequest("limit")
+		}
+
+		if value < 1 || value > MaxListLimit {
+			return ListQuery{}, EBR("limit must be between 1 and " + strconv.Itoa(MaxListLimit))
+		}
+
+		list.Limit = value
+	}
+
+	for _, field := range strings.Split(query.Get("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name, desc := strings.CutPrefix(field, "-")
+		list.Sort = append(list.Sort, ListSort{Field: name, Desc: desc})
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if listParams[key] {
+			continue
+		}
+
+		field, operator := key, "eq"
+
+		if name, rest, found := strings.Cut(key, "["); found {
+			if !strings.HasSuffix(rest, "]") {
+				return ListQuery{}, EBadRequest(key)
+			}
+
+			field, operator = name, strings.TrimSuffix(rest, "]")
+		}
+
+		if _, ok := listOperators[operator]; !ok {
+			return ListQuery{}, EBR("unknown operator " + operator)
+		}
+
+		for _, value := range query[key] {
+			list.Filters = append(list.Filters, ListFilter{Field: field, Operator: operator, Text: value})
+		}
+	}
+
+	return list, nil
+}
+
+// Resolve checks the sort and filter fields against the fields of a list, parses the filter values
+// and decodes the cursor. The list is sorted by id when no sort is given, and id is added as the
+// last sort field otherwise so the order is total. A filter of a field the list does not have is an
+// unknown query param, rejected with ErrInvalidFormat rather than ignored so a typo in a filter does
+// not list every row
+func (q *ListQuery) Resolve(fields ListFields) error {
+	hasID := false
+
+	for _, order := range q.Sort {
+		field, ok := fields[order.Field]
+		if !ok || !field.Sortable {
+			return EBR("cannot sort by " + order.Field)
+		}
+
+		hasID = hasID || order.Field == "id"
+	}
+
+	if !hasID {
+		q.Sort = append(q.Sort, ListSort{Field: "id"})
+	}
+
+	for i, filter := range q.Filters {
+		field, ok := fields[filter.Field]
+		if !ok {
+			return EUnknownParam(filter.Field)
+		}
+
+		value, err := parseListValue(field.Kind, filter.Text)
+		if err != nil || (filter.Operator == "like" && field.Kind != ListString) {
+			return EBR(filter.Field + " has an invalid filter")
+		}
+
+		if filter.Operator == "like" {
+			value = "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Text) + "%"
+		}
+
+		q.Filters[i].Value = value
+	}
+
+	if q.Cursor == "" {
+		return nil
+	}
+
+	return q.decodeCursor(fields)
+}
+
+func parseListValue(kind ListKind, text string) (any, error) {
+	switch kind {
+	case ListInt:
+		return strconv.ParseInt(text, 10, 64)
+	case ListFloat:
+		return strconv.ParseFloat(text, 64)
+	case ListTime:
+		if date, err := time.Parse(time.DateOnly, text); err == nil {
+			return date, nil
+		}
+
+		return time.Parse(time.RFC3339, text)
+	default:
+		return text, nil
+	}
+}
+
+func (q *ListQuery) decodeCursor(fields ListFields) error {
+	content, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return EBadRequest("cursor")
+	}
+
+	var cursor listCursor
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&cursor); err != nil {
+		return EBadRequest("cursor")
+	}
+
+	if cursor.Sort != q.sortKey() {
+		return EBR("cursor does not match the sort")
+	}
+
+	if len(cursor.Values) != len(q.Sort) {
+		return EBadRequest("cursor")
+	}
+
+	q.after = make([]any, len(cursor.Values))
+
+	for i, value := range cursor.Values {
+		text := ""
+
+		switch v := value.(type) {
+		case json.Number:
+			text = v.String()
+		case string:
+			text = v
+		default:
+			return EBadRequest("cursor")
+		}
+
+		q.after[i], err = parseListValue(fields[q.Sort[i].Field].Kind, text)
+		if err != nil {
+			return EBadRequest("cursor")
+		}
+	}
+
+	return nil
+}
+
+// sortKey identifies the order of a list, so a cursor cannot be used with another order
+func (q ListQuery) sortKey() string {
+	keys := make([]string, len(q.Sort))
+
+	for i, order := range q.Sort {
+		keys[i] = order.Field
+		if order.Desc {
+			keys[i] = "-" + order.Field
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// SQL returns the WHERE, ORDER BY and LIMIT clauses of a resolved list query and their arguments,
+// the conditions given are added to the ones of the filters and empty ones are ignored. The limit
+// is one more than the page size so Paginate can tell whether there are more rows
+func (q ListQuery) SQL(fields ListFields, conditions ...string) (string, []any) {
+	conditions = slices.DeleteFunc(slices.Clone(conditions), func(condition string) bool { return condition == "" })
+
+	var args []any
+
+	for _, filter := range q.Filters {
+		conditions = append(conditions, fields[filter.Field].Column+" "+listOperators[filter.Operator]+" ?")
+		args = append(args, filter.Value)
+	}
+
+	// the rows after the cursor, (a > ?) OR (a = ? AND b > ?) OR ... for every sort field
+	if q.after != nil {
+		var alternatives []string
+
+		for i, order := range q.Sort {
+			var terms []string
+
+			for j := 0; j < i; j++ {
+				terms = append(terms, fields[q.Sort[j].Field].Column+" = ?")
+				args = append(args, q.after[j])
+			}
+
+			operator := " > ?"
+			if order.Desc {
+				operator = " < ?"
+			}
+
+			terms = append(terms, fields[order.Field].Column+operator)
+			args = append(args, q.after[i])
+			alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+		}
+
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+
+	clause := ""
+	if len(conditions) > 0 {
+		clause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	order := make([]string, len(q.Sort))
+
+	for i, sortField := range q.Sort {
+		order[i] = fields[sortField.Field].Column
+		if sortField.Desc {
+			order[i] += " DESC"
+		}
+	}
+
+	args = append(args, q.Limit+1)
+
+	return clause + " ORDER BY " + strings.Join(order, ", ") + " LIMIT ?", args
+}
+
+// Paginate trims the rows read with the limit of ListQuery.SQL to the page size and builds the
+// cursor of the next page from the sort fields of the last row, found by their json names
+func Paginate[T any](items []T, q ListQuery) ([]T, Pagination) {
+	pagination := Pagination{Limit: q.Limit}
+
+	if len(items) <= q.Limit {
+		if items == nil {
+			items = []T{}
+		}
+
+		return items, pagination
+	}
+
+	items = items[:q.Limit]
+	last := reflect.ValueOf(items[len(items)-1])
+
+	cursor := listCursor{Sort: q.sortKey(), Values: make([]any, len(q.Sort))}
+	for i, order := range q.Sort {
+		cursor.Values[i] = listFieldValue(last, order.Field)
+	}
+
+	content, _ := json.Marshal(cursor)
+
+	pagination.HasMore = true
+	pagination.NextCursor = base64.RawURLEncoding.EncodeToString(content)
+
+	return items, pagination
+}
+
+// listFieldValue returns the value of the field of a struct with a json name, looking into
+// embedded and nested structs
+func listFieldValue(value reflect.Value, name string) any {
+	value = reflect.Indirect(value)
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldValue := reflect.Indirect(value.Field(i))
+
+		if fieldValue.Kind() == reflect.Struct {
+			if found := listFieldValue(fieldValue, name); found != nil {
+				return found
+			}
+
+			continue
+		}
+
+		if jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ","); jsonName == name && fieldValue.IsValid() {
+			// numbers are kept as text so the cursor doesn't lose precision
+			cell, _ := scalarExportCell(fieldValue)
+			return cell.text
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type listLocation struct {
+	City string `json:"city"`
+}
+
+type listRow struct {
+	ID       int          `json:"id"`
+	Name     string       `json:"name"`
+	Weight   float64      `json:"weight"`
+	Location listLocation `json:"location"`
+}
+
+var testListFields = ListFields{
+	"id":     {Column: "id", Kind: ListInt, Sortable: true},
+	"name":   {Column: "name", Kind: ListString, Sortable: true},
+	"weight": {Column: "weight", Kind: ListFloat, Sortable: true},
+	"city":   {Column: "city", Kind: ListString},
+	"since":  {Column: "since", Kind: ListTime},
+}
+
+func TestParseListQuery(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		query, err := ParseListQuery(url.Values{})
+		require.NoError(t, err)
+		require.Equal(t, ListQuery{Limit: DefaultListLimit}, query)
+	})
+
+	t.Run("limit, sort and filters", func(t *testing.T) {
+		query, err := ParseListQuery(url.Values{
+			"limit":       {"10"},
+			"sort":        {"-weight, name"},
+			"name[like]":  {"north"},
+			"weight[gte]": {"2.5"},
+			"format":      {"json"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 10, query.Limit)
+		require.Equal(t, []ListSort{{Field: "weight", Desc: true}, {Field: "name"}}, query.Sort)
+		require.Equal(t, []ListFilter{
+			{Field: "name", Operator: "like", Text: "north"},
+			{Field: "weight", Operator: "gte", Text: "2.5"},
+		}, query.Filters)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := ParseListQuery(url.Values{"limit": {"ten"}})
+		require.ErrorIs(t, err, ErrInvalidFormat)
+
+		_, err = ParseListQuery(url.Values{"limit": {"1000"}})
+		require.ErrorIs(t, err, ErrInvalidArguments)
+	})
+
+	t.Run("unknown operator", func(t *testing.T) {
+		_, err := ParseListQuery(url.Values{"weight[between]": {"1"}})
+		require.ErrorIs(t, err, ErrInvalidArguments)
+	})
+
+	t.Run("include deleted", func(t *testing.T) {
+		query, err := ParseListQuery(url.Values{"include_deleted": {"true"}})
+		require.NoError(t, err)
+		require.True(t, query.IncludeDeleted)
+		require.Empty(t, query.Filters)
+		require.Empty(t, query.DeletedCondition())
+
+		query, err = ParseListQuery(url.Values{})
+		require.NoError(t, err)
+		require.Equal(t, NotDeleted, query.DeletedCondition())
+
+		_, err = ParseListQuery(url.Values{"include_deleted": {"maybe"}})
+		require.ErrorIs(t, err, ErrInvalidFormat)
+	})
+}
+
+func TestListQuery_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   ListQuery
+		wantErr error
+	}{
+		{name: "unknown sort field", query: ListQuery{Sort: []ListSort{{Field: "secret"}}}, wantErr: ErrInvalidArguments},
+		{name: "field cannot be sorted", query: ListQuery{Sort: []ListSort{{Field: "city"}}}, wantErr: ErrInvalidArguments},
+		{name: "unknown filter field", query: ListQuery{Filters: []ListFilter{{Field: "secret", Operator: "eq", Text: "1"}}}, wantErr: ErrInvalidFormat},
+		{name: "invalid filter value", query: ListQuery{Filters: []ListFilter{{Field: "id", Operator: "eq", Text: "one"}}}, wantErr: ErrInvalidArguments},
+		{name: "like on a number", query: ListQuery{Filters: []ListFilter{{Field: "weight", Operator: "like", Text: "1"}}}, wantErr: ErrInvalidArguments},
+		{name: "invalid time", query: ListQuery{Filters: []ListFilter{{Field: "since", Operator: "gte", Text: "yesterday"}}}, wantErr: ErrInvalidArguments},
+		{name: "malformed cursor", query: ListQuery{Cursor: "not a cursor"}, wantErr: ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Resolve(testListFields)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestListQuery_SQL(t *testing.T) {
+	t.Run("first page", func(t *testing.T) {
+		query := ListQuery{
+			Limit:   10,
+			Sort:    []ListSort{{Field: "weight", Desc: true}},
+			Filters: []ListFilter{{Field: "name", Operator: "like", Text: "50%_off"}, {Field: "id", Operator: "ne", Text: "3"}},
+		}
+		require.NoError(t, query.Resolve(testListFields))
+
+		clause, args := query.SQL(testListFields)
+		require.Equal(t, " WHERE name LIKE ? AND id <> ? ORDER BY weight DESC, id LIMIT ?", clause)
+		require.Equal(t, []any{`%50\%\_off%`, int64(3), 11}, args)
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		query := ListQuery{Limit: 10, Filters: []ListFilter{
+			{Field: "since", Operator: "gte", Text: "2024-01-31"},
+			{Field: "since", Operator: "lt", Text: "2024-02-01T10:30:00-03:00"},
+		}}
+		require.NoError(t, query.Resolve(testListFields))
+
+		clause, args := query.SQL(testListFields)
+		require.Equal(t, " WHERE since >= ? AND since < ? ORDER BY id LIMIT ?", clause)
+		require.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), args[0])
+		require.True(t, time.Date(2024, 2, 1, 13, 30, 0, 0, time.UTC).Equal(args[1].(time.Time)))
+	})
+
+	t.Run("extra conditions", func(t *testing.T) {
+		query := ListQuery{Limit: 10, Filters: []ListFilter{{Field: "id", Operator: "gt", Text: "3"}}}
+		require.NoError(t, query.Resolve(testListFields))
+
+		clause, args := query.SQL(testListFields, NotDeleted, "")
+		require.Equal(t, " WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?", clause)
+		require.Equal(t, []any{int64(3), 11}, args)
+	})
+
+	t.Run("page after a cursor", func(t *testing.T) {
+		rows := []listRow{{ID: 7, Weight: 2.5}, {ID: 4, Weight: 1.5}, {ID: 9, Weight: 1}}
+		first := ListQuery{Limit: 2, Sort: []ListSort{{Field: "weight", Desc: true}}}
+		require.NoError(t, first.Resolve(testListFields))
+
+		_, pagination := Paginate(rows, first)
+
+		query := ListQuery{Limit: 2, Cursor: pagination.NextCursor, Sort: []ListSort{{Field: "weight", Desc: true}}}
+		require.NoError(t, query.Resolve(testListFields))
+
+		clause, args := query.SQL(testListFields)
+		require.Equal(t, " WHERE ((weight < ?) OR (weight = ? AND id > ?)) ORDER BY weight DESC, id LIMIT ?", clause)
+		require.Equal(t, []any{1.5, 1.5, int64(4), 3}, args)
+	})
+
+	t.Run("cursor of another sort", func(t *testing.T) {
+		first := ListQuery{Limit: 1}
+		require.NoError(t, first.Resolve(testListFields))
+
+		_, pagination := Paginate([]listRow{{ID: 1}, {ID: 2}}, first)
+
+		query := ListQuery{Limit: 1, Cursor: pagination.NextCursor, Sort: []ListSort{{Field: "name"}}}
+		err := query.Resolve(testListFields)
+		require.ErrorIs(t, err, ErrInvalidArguments)
+		require.ErrorContains(t, err, "cursor does not match the sort")
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	query := ListQuery{Limit: 2, Sort: []ListSort{{Field: "city"}, {Field: "id"}}}
+
+	t.Run("last page", func(t *testing.T) {
+		items, pagination := Paginate([]listRow(nil), query)
+		require.Equal(t, []listRow{}, items)
+		require.Equal(t, Pagination{Limit: 2}, pagination)
+	})
+
+	t.Run("more rows", func(t *testing.T) {
+		rows := []listRow{
+			{ID: 1, Location: listLocation{City: "Rosario"}},
+			{ID: 2, Location: listLocation{City: "Salta"}},
+			{ID: 3, Location: listLocation{City: "Tandil"}},
+		}
+
+		items, pagination := Paginate(rows, query)
+		require.Equal(t, rows[:2], items)
+		require.True(t, pagination.HasMore)
+		require.NotEmpty(t, pagination.NextCursor)
+
+		next := ListQuery{Limit: 2, Cursor: pagination.NextCursor, Sort: []ListSort{{Field: "city"}, {Field: "id"}}}
+		require.NoError(t, next.decodeCursor(testListFields))
+		require.Equal(t, []any{"Salta", int64(2)}, next.after)
+	})
+}
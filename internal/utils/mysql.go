@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MapMySQLError translates the duplicated key error to ErrConflict and the foreign key ones, 1451
+// when the row is still referenced to ErrInUse and 1452 when it refers to a missing row to
+// ErrInvalidArguments. The other errors are returned as they are
+func MapMySQLError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return ErrConflict
+		case 1451:
+			return ErrInUse
+		case 1452:
+			return ErrInvalidArguments
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func Test__MapMySQLError__TranslatesTheKeyErrors(t *testing.T) {
+	require.ErrorIs(t, MapMySQLError(&mysql.MySQLError{Number: 1062}), ErrConflict)
+	require.ErrorIs(t, MapMySQLError(fmt.Errorf("saving: %w", &mysql.MySQLError{Number: 1451})), ErrInUse)
+	require.ErrorIs(t, MapMySQLError(&mysql.MySQLError{Number: 1452}), ErrInvalidArguments)
+}
+
+func Test__MapMySQLError__ReturnsTheOtherErrors(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213}
+	require.Equal(t, error(deadlock), MapMySQLError(deadlock))
+
+	failed := errors.New("connection lost")
+	require.Equal(t, failed, MapMySQLError(failed))
+}
@@ -69,3 +69,24 @@ func JSON(w http.ResponseWriter, code int, body any) {
 		return
 	}
 }
+
+// PageResponse is the body of a page of a list, documented in the routes as
+// utils.PageResponse{data=[]internal.Entity}
+type PageResponse struct {
+	Data       any        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// JSONPage writes a page of a list, {"data": [...], "pagination": {...}}
+func JSONPage(w http.ResponseWriter, code int, body any, pagination Pagination) {
+	bytes, err := json.Marshal(PageResponse{Data: body, Pagination: pagination})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_, _ = w.Write(bytes)
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// ResponseShape are the fields and the related entities asked for a response with the
+// fields and include query params
+type ResponseShape struct {
+	Fields  []string
+	Include []string
+}
+
+// ParseResponseShape reads the comma separated fields and include query params, fields can only
+// name the json fields of T and include the relations given
+func ParseResponseShape[T any](query url.Values, relations ...string) (ResponseShape, error) {
+	shape := ResponseShape{Fields: splitParam(query.Get("fields")), Include: splitParam(query.Get("include"))}
+
+	names := map[string]bool{}
+	jsonNames(reflect.TypeOf((*T)(nil)).Elem(), names)
+
+	for _, field := range shape.Fields {
+		if !names[field] {
+			return ResponseShape{}, EBR("unknown field " + field)
+		}
+	}
+
+	for _, name := range shape.Include {
+		known := false
+		for _, relation := range relations {
+			known = known || relation == name
+		}
+
+		if !known {
+			return ResponseShape{}, EBR("cannot include " + name)
+		}
+	}
+
+	return shape, nil
+}
+
+func splitParam(value string) []string {
+	var values []string
+
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
+
+// Empty reports whether the shape keeps the response as it is
+func (s ResponseShape) Empty() bool {
+	return len(s.Fields) == 0 && len(s.Include) == 0
+}
+
+// RelationsLoader returns the entities related to each item that were asked by include,
+// relations[i] for items[i]
+type RelationsLoader[T any, R any] func(ctx context.Context, items []T, include []string) (relations []R, err error)
+
+// Shape returns the items as json objects with only the fields of the shape, id is always kept, and
+// with the related entities of each item merged in, read with load when the shape includes any. A
+// related entity replaces the field of the same name, as product_type of a product. The items are
+// returned as they are when the shape is empty
+func Shape[T any, R any](ctx context.Context, items []T, shape ResponseShape, load RelationsLoader[T, R]) (any, error) {
+	if shape.Empty() {
+		return items, nil
+	}
+
+	var relations []R
+
+	if len(shape.Include) > 0 {
+		var err error
+
+		relations, err = load(ctx, items, shape.Include)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fields map[string]bool
+
+	if len(shape.Fields) > 0 {
+		fields = map[string]bool{"id": true}
+		for _, field := range shape.Fields {
+			fields[field] = true
+		}
+	}
+
+	shaped := make([]map[string]json.RawMessage, len(items))
+
+	for i, item := range items {
+		object, err := jsonObject(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if fields != nil {
+			for name := range object {
+				if !fields[name] {
+					delete(object, name)
+				}
+			}
+		}
+
+		if i < len(relations) {
+			var related map[string]json.RawMessage
+
+			related, err = jsonObject(relations[i])
+			if err != nil {
+				return nil, err
+			}
+
+			for name, value := range related {
+				object[name] = value
+			}
+		}
+
+		shaped[i] = object
+	}
+
+	return shaped, nil
+}
+
+// ShapeOne is Shape for a single item
+func ShapeOne[T any, R any](ctx context.Context, item T, shape ResponseShape, load RelationsLoader[T, R]) (any, error) {
+	if shape.Empty() {
+		return item, nil
+	}
+
+	shaped, err := Shape(ctx, []T{item}, shape, load)
+	if err != nil {
+		return nil, err
+	}
+
+	return shaped.([]map[string]json.RawMessage)[0], nil
+}
+
+// jsonNames adds the top level json names of a struct, with the ones of its embedded structs
+func jsonNames(structType reflect.Type, names map[string]bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		// as encoding/json, the fields of embedded structs are promoted even if the struct is unexported
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			jsonNames(field.Type, names)
+			continue
+		}
+
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		names[name] = true
+	}
+}
+
+func jsonObject(value any) (map[string]json.RawMessage, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	object := map[string]json.RawMessage{}
+
+	err = json.Unmarshal(content, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type shapeAttributes struct {
+	Name   string `json:"name"`
+	TypeID int    `json:"type_id"`
+}
+
+type shapeRow struct {
+	ID int `json:"id"`
+	shapeAttributes
+	Secret string `json:"-"`
+}
+
+type shapeType struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type shapeRelations struct {
+	Type *shapeType `json:"type,omitempty"`
+}
+
+func TestParseResponseShape(t *testing.T) {
+	t.Run("fields and include", func(t *testing.T) {
+		shape, err := ParseResponseShape[shapeRow](url.Values{"fields": {"name, type_id"}, "include": {"type"}}, "type")
+		require.NoError(t, err)
+		require.Equal(t, ResponseShape{Fields: []string{"name", "type_id"}, Include: []string{"type"}}, shape)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		shape, err := ParseResponseShape[shapeRow](url.Values{}, "type")
+		require.NoError(t, err)
+		require.True(t, shape.Empty())
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := ParseResponseShape[shapeRow](url.Values{"fields": {"Secret"}}, "type")
+		require.ErrorIs(t, err, ErrInvalidArguments)
+		require.ErrorContains(t, err, "unknown field Secret")
+	})
+
+	t.Run("unknown include", func(t *testing.T) {
+		_, err := ParseResponseShape[shapeRow](url.Values{"include": {"owner"}}, "type")
+		require.ErrorIs(t, err, ErrInvalidArguments)
+		require.ErrorContains(t, err, "cannot include owner")
+	})
+}
+
+func TestShape(t *testing.T) {
+	rows := []shapeRow{
+		{ID: 1, shapeAttributes: shapeAttributes{Name: "North", TypeID: 5}},
+		{ID: 2, shapeAttributes: shapeAttributes{Name: "South", TypeID: 6}},
+	}
+
+	calls := 0
+	load := func(_ context.Context, items []shapeRow, include []string) ([]shapeRelations, error) {
+		calls++
+
+		relations := make([]shapeRelations, len(items))
+		relations[0].Type = &shapeType{ID: 5, Name: "Frozen"}
+
+		return relations, nil
+	}
+
+	t.Run("empty shape keeps the items", func(t *testing.T) {
+		body, err := Shape(context.Background(), rows, ResponseShape{}, load)
+		require.NoError(t, err)
+		require.Equal(t, rows, body)
+		require.Zero(t, calls)
+	})
+
+	t.Run("fields and relations", func(t *testing.T) {
+		body, err := Shape(context.Background(), rows, ResponseShape{Fields: []string{"name"}, Include: []string{"type"}}, load)
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+
+		content, err := json.Marshal(body)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id":1,"name":"North","type":{"id":5,"name":"Frozen"}},{"id":2,"name":"South"}]`, string(content))
+	})
+
+	t.Run("single item", func(t *testing.T) {
+		body, err := ShapeOne(context.Background(), rows[1], ResponseShape{Fields: []string{"type_id"}}, load)
+		require.NoError(t, err)
+
+		content, err := json.Marshal(body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":2,"type_id":6}`, string(content))
+	})
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// NotDeleted is the condition of the rows that are not soft deleted
+const NotDeleted = "deleted_at IS NULL"
+
+// DateTime returns the select expression of a DATETIME(6) column scanned into a string. The connection
+// parses the times for the deleted_at of the soft deleted rows, the column is formatted as it was sent
+// before, as in 2021-04-04 00:00:00.000000
+func DateTime(column string) string {
+	return "DATE_FORMAT(" + column + ", '%Y-%m-%d %H:%i:%s.%f')"
+}
+
+// mysqlRowIsReferenced is the error of MySQL when deleting a row a foreign key still refers to
+const mysqlRowIsReferenced = 1451
+
+// DeletedCondition returns NotDeleted, or no condition when the list includes the soft deleted rows
+func (q ListQuery) DeletedCondition() string {
+	if q.IncludeDeleted {
+		return ""
+	}
+
+	return NotDeleted
+}
+
+// SoftDelete marks the row of a table with the id as deleted when it is still in the version it was
+// read, a precondition failed error of the target when there is no such row that isn't deleted yet.
+// The row must meet the conditions given too
+func SoftDelete(ctx context.Context, db DBTX, table, target string, id, version int, conditions ...string) error {
+	result, err := db.ExecContext(ctx, "UPDATE `"+table+"` SET `deleted_at` = NOW() WHERE `id` = ? AND `version` = ? AND `deleted_at` IS NULL"+andConditions(conditions), id, version)
+	if err != nil {
+		return err
+	}
+
+	return CheckVersionUpdated(result, target)
+}
+
+// Restore clears the deletion of the soft deleted row of a table with the id, ErrNotFound
+// when there is no deleted row with the id. The row must meet the conditions given too
+func Restore(ctx context.Context, db DBTX, table string, id int, conditions ...string) error {
+	return execOnRow(ctx, db, "UPDATE `"+table+"` SET `deleted_at` = NULL WHERE `id` = ? AND `deleted_at` IS NOT NULL"+andConditions(conditions), id)
+}
+
+// andConditions joins the conditions that are not empty, each one after an AND
+func andConditions(conditions []string) string {
+	clause := ""
+
+	for _, condition := range conditions {
+		if condition != "" {
+			clause += " AND " + condition
+		}
+	}
+
+	return clause
+}
+
+func execOnRow(ctx context.Context, db DBTX, query string, id int) error {
+	result, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeleted removes for good the rows of a table soft deleted before a time. The rows are removed
+// one by one so a row a foreign key still refers to is kept, instead of failing the whole purge.
+// Returns the number of rows removed
+func PurgeDeleted(ctx context.Context, db DBTX, table string, before time.Time) (purged int, err error) {
+	rows, err := db.QueryContext(ctx, "SELECT `id` FROM `"+table+"` WHERE `deleted_at` < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int
+
+	for rows.Next() {
+		var id int
+
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		var result sql.Result
+
+		result, err = db.ExecContext(ctx, "DELETE FROM `"+table+"` WHERE `id` = ? AND `deleted_at` < ?", id, before)
+
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlRowIsReferenced {
+			continue
+		}
+
+		if err != nil {
+			return purged, err
+		}
+
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout gives the context of each request a deadline, the queries of a request still running when
+// it passes are canceled and the request fails with 504. No deadline is set when timeout is not positive
+func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("the request gets a deadline", func(t *testing.T) {
+		var deadline time.Time
+
+		var ok bool
+
+		handler := Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("the request is canceled once the deadline passes", func(t *testing.T) {
+		handler := Timeout(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			HandleError(w, r.Context().Err())
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("no deadline when the timeout is not positive", func(t *testing.T) {
+		ok := true
+
+		handler := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+		require.False(t, ok)
+	})
+}
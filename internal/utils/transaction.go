@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX runs the statements of a repository, it is either the *sql.DB or the *sql.Tx of a unit of
+// work, so a repository can be bound to the transaction of the operation it is part of
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// InTx runs fn in a transaction of db, committed when fn returns nil and rolled back when it returns
+// an error or panics. When db is already a transaction fn runs in it, the one that began it commits.
+// The transaction is rolled back when ctx is done before it is committed
+func InTx(ctx context.Context, db DBTX, fn func(tx DBTX) error) (err error) {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AnyVersion is the version of If-Match: *, it matches the current version of a resource whatever it is
+const AnyVersion = 0
+
+// ErrIfMatchRequired is the error of a change made without If-Match
+var ErrIfMatchRequired = errors.Join(ErrPreconditionRequired, errors.New("If-Match header with the ETag of the resource is required"))
+
+// ETag returns the entity tag of a version of a resource, the version is bumped by every change
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag header of the response of a resource
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// IfMatch returns the version in the If-Match header of a request, AnyVersion for *. Only one ETag
+// is accepted and weak ones never match, as in the strong comparison of If-Match
+func IfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	switch {
+	case header == "":
+		return 0, ErrIfMatchRequired
+	case header == "*":
+		return AnyVersion, nil
+	case strings.Contains(header, ","):
+		return 0, EBadRequest("If-Match")
+	}
+
+	tag, found := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+
+	version, err := strconv.Atoi(tag)
+	if !found || !closed || err != nil || version <= 0 {
+		return 0, errors.Join(ErrPreconditionFailed, errors.New(header+" is not the ETag of a version"))
+	}
+
+	return version, nil
+}
+
+// CheckVersion returns a precondition failed error when the version expected of a resource
+// is not its current one. Every change is made on the current version of a resource, so a client
+// never overwrites the changes of another one it has not read
+func CheckVersion(target string, expected, current int) error {
+	if expected != AnyVersion && expected != current {
+		return EPreconditionFailed(target)
+	}
+
+	return nil
+}
+
+// CheckVersionUpdated returns a precondition failed error when an UPDATE or DELETE ... WHERE version = ?
+// changed no row, the resource was changed since its version was read
+func CheckVersionUpdated(result sql.Result, target string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return EPreconditionFailed(target)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	require.Equal(t, `"3"`, ETag(3))
+
+	w := httptest.NewRecorder()
+	SetETag(w, 3)
+	require.Equal(t, `"3"`, w.Header().Get("ETag"))
+}
+
+func TestIfMatch(t *testing.T) {
+	cases := []struct {
+		Name            string
+		IfMatch         string
+		ExpectedVersion int
+		ExpectedErr     error
+	}{
+		{
+			Name:            "WHEN a strong ETag",
+			IfMatch:         `"3"`,
+			ExpectedVersion: 3,
+		},
+		{
+			Name:            "WHEN any version",
+			IfMatch:         "*",
+			ExpectedVersion: AnyVersion,
+		},
+		{
+			Name:        "WHEN no If-Match",
+			ExpectedErr: ErrPreconditionRequired,
+		},
+		{
+			Name:        "WHEN a list of ETags",
+			IfMatch:     `"2", "3"`,
+			ExpectedErr: ErrInvalidFormat,
+		},
+		{
+			Name:        "WHEN a weak ETag",
+			IfMatch:     `W/"3"`,
+			ExpectedErr: ErrPreconditionFailed,
+		},
+		{
+			Name:        "WHEN an unquoted ETag",
+			IfMatch:     "3",
+			ExpectedErr: ErrPreconditionFailed,
+		},
+		{
+			Name:        "WHEN an ETag of no version",
+			IfMatch:     `"0"`,
+			ExpectedErr: ErrPreconditionFailed,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/", nil)
+			if c.IfMatch != "" {
+				r.Header.Set("If-Match", c.IfMatch)
+			}
+
+			version, err := IfMatch(r)
+			if c.ExpectedErr != nil {
+				require.ErrorIs(t, err, c.ExpectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.ExpectedVersion, version)
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	require.NoError(t, CheckVersion("foo", 2, 2))
+	require.NoError(t, CheckVersion("foo", AnyVersion, 2))
+	require.ErrorIs(t, CheckVersion("foo", 1, 2), ErrPreconditionFailed)
+}
@@ -1,40 +1,109 @@
 package internal
 
+import (
+	"context"
+	"time"
+
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
+)
+
 type Warehouse struct {
-	ID                 int    `json:"id"`
-	Address            string `json:"address"`
-	Telephone          string `json:"telephone"`
-	WarehouseCode      string `json:"warehouse_code"`
-	LocalityID         int    `json:"locality_id"`
-	MinimumCapacity    int    `json:"minimum_capacity"`
-	MinimumTemperature int    `json:"minimum_temperature"`
+	ID                 int      `json:"id"`
+	Address            string   `json:"address"`
+	Telephone          string   `json:"telephone"`
+	WarehouseCode      string   `json:"warehouse_code"`
+	LocalityID         int      `json:"locality_id"`
+	MinimumCapacity    int      `json:"minimum_capacity"`
+	MinimumTemperature int      `json:"minimum_temperature"`
+	Latitude           *float64 `json:"latitude,omitempty"`
+	Longitude          *float64 `json:"longitude,omitempty"`
+	// DeletedAt is the time the warehouse was soft deleted, only listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is bumped by every change, it is the ETag of the warehouse
+	Version int `json:"version,omitempty"`
 }
 
 type WarehousePointers struct {
-	Address            *string `json:"address"`
-	Telephone          *string `json:"telephone"`
-	WarehouseCode      *string `json:"warehouse_code"`
-	LocalityID         *int    `json:"locality_id"`
-	MinimumCapacity    *int    `json:"minimum_capacity"`
-	MinimumTemperature *int    `json:"minimum_temperature"`
+	Address            *string  `json:"address"`
+	Telephone          *string  `json:"telephone"`
+	WarehouseCode      *string  `json:"warehouse_code"`
+	LocalityID         *int     `json:"locality_id"`
+	MinimumCapacity    *int     `json:"minimum_capacity"`
+	MinimumTemperature *int     `json:"minimum_temperature"`
+	Latitude           *float64 `json:"latitude"`
+	Longitude          *float64 `json:"longitude"`
+	// Version is the version of the warehouse read by the client, from If-Match
+	Version int `json:"-"`
+}
+
+// WarehouseStock is a warehouse with the quantity of a product stored in its sections,
+// its coordinates fall back to the ones of its locality when the warehouse has none
+type WarehouseStock struct {
+	Warehouse
+	Stock int `json:"stock"`
+}
+
+// WarehouseDistance is a warehouse with stock of a product and its great-circle distance to a locality
+type WarehouseDistance struct {
+	WarehouseStock
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// WarehouseIncludeLocality embeds the locality of a warehouse in its responses
+const WarehouseIncludeLocality = "locality"
+
+// WarehouseRelations are the entities related to a warehouse embedded in its responses with include
+type WarehouseRelations struct {
+	Locality *Locality `json:"locality,omitempty"`
 }
 
 type WarehouseService interface {
-	GetAll() ([]Warehouse, error)
-	Save(Warehouse) (Warehouse, error)
-	Update(int, WarehousePointers) (Warehouse, error)
-	GetByID(int) (Warehouse, error)
-	Delete(int) error
+	GetAll(ctx context.Context) ([]Warehouse, error)
+	// List returns a page of warehouses filtered and sorted as asked by the query
+	List(ctx context.Context, query utils.ListQuery) ([]Warehouse, utils.Pagination, error)
+	Save(context.Context, Warehouse) (Warehouse, error)
+	Update(context.Context, int, WarehousePointers) (Warehouse, error)
+	GetByID(context.Context, int) (Warehouse, error)
+	// GetByIDs returns the warehouses with the given ids, the ids without a warehouse are ignored
+	GetByIDs(context.Context, []int) ([]Warehouse, error)
+	// GetRelations returns the entities related to each warehouse that were asked by include
+	GetRelations(ctx context.Context, warehouses []Warehouse, include []string) ([]WarehouseRelations, error)
+	// Delete soft deletes a warehouse in the version given, it is left out of the listings until it is restored
+	Delete(ctx context.Context, id int, version int) error
+	// Restore brings back a soft deleted warehouse
+	Restore(ctx context.Context, id int) (Warehouse, error)
+	// Purge removes for good the warehouses deleted before a time, returns how many were removed
+	Purge(ctx context.Context, before time.Time) (int, error)
+	// GetNearestWithStock returns up to limit warehouses holding at least quantity units of a product,
+	// closest to the locality first
+	GetNearestWithStock(ctx context.Context, localityID, productID, quantity, limit int) ([]WarehouseDistance, error)
+	// Import creates warehouses in bulk, see utils.Importer
+	Import(ctx context.Context, items []utils.ImportItem[Warehouse], options utils.ImportOptions) (utils.ImportReport, error)
 }
 
 type WarehouseRepository interface {
-	GetAll() ([]Warehouse, error)
-	Save(newWarehouse Warehouse) (Warehouse, error)
-	Update(updatedWarehouse Warehouse) (Warehouse, error)
-	GetByID(id int) (Warehouse, error)
-	Delete(id int) error
+	GetAll(ctx context.Context) ([]Warehouse, error)
+	// List returns the warehouses of a resolved list query, one more than its limit when there are more
+	List(ctx context.Context, query utils.ListQuery) ([]Warehouse, error)
+	Save(ctx context.Context, newWarehouse Warehouse) (Warehouse, error)
+	// SaveAll saves the warehouses in a single transaction, none of them when one fails
+	SaveAll(ctx context.Context, newWarehouses []Warehouse) ([]Warehouse, error)
+	Update(ctx context.Context, updatedWarehouse Warehouse) (Warehouse, error)
+	GetByID(ctx context.Context, id int) (Warehouse, error)
+	// GetByIDs returns the warehouses with the given ids, the ids without a warehouse are ignored
+	GetByIDs(ctx context.Context, ids []int) ([]Warehouse, error)
+	// Delete soft deletes a warehouse when it is still in the version it was read
+	Delete(ctx context.Context, id int, version int) error
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, before time.Time) (int, error)
+	GetWithStock(ctx context.Context, productID, quantity int) ([]WarehouseStock, error)
 }
 
 type WarehouseLocalityValidation interface {
-	GetByID(id int) (locality Locality, err error)
+	GetByID(ctx context.Context, id int) (locality Locality, err error)
+	GetByIDs(ctx context.Context, ids []int) (localities []Locality, err error)
+}
+
+type WarehouseProductValidation interface {
+	GetByID(ctx context.Context, id int) (product Product, err error)
 }
@@ -1,29 +1,45 @@
 package warehouse
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// listFields are the fields the warehouses can be filtered and sorted by, the coordinates
+// are nullable so they can only be filtered
+var listFields = utils.ListFields{
+	"id":                  {Column: "`id`", Kind: utils.ListInt, Sortable: true},
+	"address":             {Column: "`address`", Kind: utils.ListString, Sortable: true},
+	"telephone":           {Column: "`telephone`", Kind: utils.ListString, Sortable: true},
+	"warehouse_code":      {Column: "`warehouse_code`", Kind: utils.ListString, Sortable: true},
+	"locality_id":         {Column: "`locality_id`", Kind: utils.ListInt, Sortable: true},
+	"minimum_capacity":    {Column: "`minimum_capacity`", Kind: utils.ListInt, Sortable: true},
+	"minimum_temperature": {Column: "`minimum_temperature`", Kind: utils.ListInt, Sortable: true},
+	"latitude":            {Column: "`latitude`", Kind: utils.ListFloat},
+	"longitude":           {Column: "`longitude`", Kind: utils.ListFloat},
+}
+
 type MySQLWarehouseRepository struct {
-	db *sql.DB
+	db utils.DBTX
 }
 
-func NewWarehouseDB(db *sql.DB) *MySQLWarehouseRepository {
+func NewWarehouseDB(db utils.DBTX) *MySQLWarehouseRepository {
 	return &MySQLWarehouseRepository{db: db}
 }
 
 // GetAll retrieves all warehouses from the database.
 // It returns a slice of Warehouse structs and an error if any occurs during the query execution or row scanning.
 // The function queries the database for the following fields: id, address, telephone, warehouse_code, and locality_id.
-func (w *MySQLWarehouseRepository) GetAll() ([]internal.Warehouse, error) {
+func (w *MySQLWarehouseRepository) GetAll(ctx context.Context) ([]internal.Warehouse, error) {
 	var warehouseList []internal.Warehouse
 	// query the database
-	rows, err := w.db.Query("SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature` FROM warehouses")
+	rows, err := w.db.QueryContext(ctx, "SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature`, `latitude`, `longitude`, `version` FROM warehouses WHERE deleted_at IS NULL")
 
 	if err != nil {
 		return nil, err
@@ -34,7 +50,7 @@ func (w *MySQLWarehouseRepository) GetAll() ([]internal.Warehouse, error) {
 	for rows.Next() {
 		var warehouse internal.Warehouse
 		// scan the row into the warehouse struct
-		err := rows.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature)
+		err := rows.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature, &warehouse.Latitude, &warehouse.Longitude, &warehouse.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -53,6 +69,45 @@ func (w *MySQLWarehouseRepository) GetAll() ([]internal.Warehouse, error) {
 	return warehouseList, nil
 }
 
+// List retrieves a page of warehouses from the database.
+//
+// Parameters:
+//   - query: a list query resolved against listFields.
+//
+// Returns:
+//   - []internal.Warehouse: the warehouses of the page, one more than the limit when there are more.
+//   - error: an error if the query fails or if there is an issue scanning the rows.
+func (w *MySQLWarehouseRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Warehouse, error) {
+	clause, args := query.SQL(listFields, query.DeletedCondition())
+
+	rows, err := w.db.QueryContext(ctx, "SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature`, `latitude`, `longitude`, `deleted_at`, `version` FROM warehouses"+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	warehouseList := []internal.Warehouse{}
+
+	for rows.Next() {
+		var warehouse internal.Warehouse
+
+		err = rows.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature, &warehouse.Latitude, &warehouse.Longitude, &warehouse.DeletedAt, &warehouse.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		warehouseList = append(warehouseList, warehouse)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return warehouseList, nil
+}
+
 // GetByID retrieves a warehouse by its ID from the database.
 // It returns the warehouse details if found, otherwise it returns an error.
 // If the warehouse is not found, it returns a utils.ErrNotFound error.
@@ -63,15 +118,15 @@ func (w *MySQLWarehouseRepository) GetAll() ([]internal.Warehouse, error) {
 // Returns:
 //   - internal.Warehouse: the warehouse details.
 //   - error: an error if the warehouse is not found or if there is a database issue.
-func (w *MySQLWarehouseRepository) GetByID(id int) (internal.Warehouse, error) {
-	row := w.db.QueryRow("SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature` FROM warehouses WHERE id = ?", id)
+func (w *MySQLWarehouseRepository) GetByID(ctx context.Context, id int) (internal.Warehouse, error) {
+	row := w.db.QueryRowContext(ctx, "SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature`, `latitude`, `longitude`, `version` FROM warehouses WHERE id = ? AND deleted_at IS NULL", id)
 
 	if err := row.Err(); err != nil {
 		return internal.Warehouse{}, err
 	}
 
 	var warehouse internal.Warehouse
-	err := row.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature)
+	err := row.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature, &warehouse.Latitude, &warehouse.Longitude, &warehouse.Version)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -84,6 +139,49 @@ func (w *MySQLWarehouseRepository) GetByID(id int) (internal.Warehouse, error) {
 	return warehouse, nil
 }
 
+// GetByIDs retrieves the warehouses with the given IDs in a single query.
+// The IDs without a warehouse are ignored.
+//
+// Parameters:
+//   - ids: the IDs of the warehouses to retrieve.
+//
+// Returns:
+//   - []internal.Warehouse: the warehouses found, in no particular order.
+//   - error: an error if the query fails or if there is an issue scanning the rows.
+func (w *MySQLWarehouseRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Warehouse, error) {
+	warehouseList := []internal.Warehouse{}
+	if len(ids) == 0 {
+		return warehouseList, nil
+	}
+
+	in, args := utils.InClause(ids)
+
+	rows, err := w.db.QueryContext(ctx, "SELECT `id`, `address`, `telephone`, `warehouse_code`, `locality_id`, `minimum_capacity`, `minimum_temperature`, `latitude`, `longitude`, `version` FROM warehouses WHERE id IN "+in, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var warehouse internal.Warehouse
+
+		err = rows.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID, &warehouse.MinimumCapacity, &warehouse.MinimumTemperature, &warehouse.Latitude, &warehouse.Longitude, &warehouse.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		warehouseList = append(warehouseList, warehouse)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return warehouseList, nil
+}
+
 // Save inserts a new warehouse record into the database and returns the saved warehouse with its ID.
 // If there is a conflict (e.g., duplicate entry), it returns an error indicating the conflict.
 //
@@ -93,9 +191,9 @@ func (w *MySQLWarehouseRepository) GetByID(id int) (internal.Warehouse, error) {
 // Returns:
 //   - internal.Warehouse: The saved warehouse object with its ID populated.
 //   - error: An error object if there was an issue during the save operation.
-func (w *MySQLWarehouseRepository) Save(newWarehouse internal.Warehouse) (internal.Warehouse, error) {
+func (w *MySQLWarehouseRepository) Save(ctx context.Context, newWarehouse internal.Warehouse) (internal.Warehouse, error) {
 	// prepare the query
-	statement, err := w.db.Prepare("INSERT INTO warehouses (address, telephone, warehouse_code, locality_id, minimum_capacity, minimum_temperature) VALUES (?, ?, ?, ?,?,?)")
+	statement, err := w.db.PrepareContext(ctx, "INSERT INTO warehouses (address, telephone, warehouse_code, locality_id, minimum_capacity, minimum_temperature, latitude, longitude) VALUES (?, ?, ?, ?,?,?,?,?)")
 
 	if err != nil {
 		return internal.Warehouse{}, err
@@ -104,7 +202,7 @@ func (w *MySQLWarehouseRepository) Save(newWarehouse internal.Warehouse) (intern
 	defer statement.Close()
 
 	// execute the query
-	result, err := statement.Exec(newWarehouse.Address, newWarehouse.Telephone, newWarehouse.WarehouseCode, newWarehouse.LocalityID, newWarehouse.MinimumCapacity, newWarehouse.MinimumTemperature)
+	result, err := statement.ExecContext(ctx, newWarehouse.Address, newWarehouse.Telephone, newWarehouse.WarehouseCode, newWarehouse.LocalityID, newWarehouse.MinimumCapacity, newWarehouse.MinimumTemperature, newWarehouse.Latitude, newWarehouse.Longitude)
 
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -130,13 +228,64 @@ func (w *MySQLWarehouseRepository) Save(newWarehouse internal.Warehouse) (intern
 
 	// set the id of the warehouse
 	newWarehouse.ID = int(id)
+	newWarehouse.Version = 1
 
 	return newWarehouse, nil
 }
 
+// SaveAll inserts the warehouses in a single transaction, if any insert fails none of them is saved.
+//
+// Parameters:
+//   - newWarehouses: the warehouses to be saved.
+//
+// Returns:
+//   - []internal.Warehouse: the saved warehouses with their IDs populated, in the same order.
+//   - error: utils.ErrConflict if a warehouse code already exists, or an error if a statement fails.
+func (w *MySQLWarehouseRepository) SaveAll(ctx context.Context, newWarehouses []internal.Warehouse) (savedWarehouses []internal.Warehouse, err error) {
+	savedWarehouses = make([]internal.Warehouse, 0, len(newWarehouses))
+
+	err = utils.InTx(ctx, w.db, func(tx utils.DBTX) error {
+		statement, err := tx.PrepareContext(ctx, "INSERT INTO warehouses (address, telephone, warehouse_code, locality_id, minimum_capacity, minimum_temperature, latitude, longitude) VALUES (?, ?, ?, ?,?,?,?,?)")
+		if err != nil {
+			return err
+		}
+
+		defer statement.Close()
+
+		for _, newWarehouse := range newWarehouses {
+			result, err := statement.ExecContext(ctx, newWarehouse.Address, newWarehouse.Telephone, newWarehouse.WarehouseCode, newWarehouse.LocalityID, newWarehouse.MinimumCapacity, newWarehouse.MinimumTemperature, newWarehouse.Latitude, newWarehouse.Longitude)
+			if err != nil {
+				var mysqlErr *mysql.MySQLError
+				if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+					return utils.ErrConflict
+				}
+
+				return err
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			newWarehouse.ID = int(id)
+			newWarehouse.Version = 1
+			savedWarehouses = append(savedWarehouses, newWarehouse)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return savedWarehouses, nil
+}
+
 // Update updates an existing warehouse in the database with the provided updatedWarehouse data.
 // It first checks if the warehouse exists by its ID. If it does not exist, it returns an error.
 // If the warehouse exists, it prepares and executes an SQL update statement to update the warehouse details.
+// Only the version the warehouse was read in is updated, and its version is bumped.
 // If the update is successful, it returns the updated warehouse data.
 // If there is a MySQL error, it checks for specific error codes and returns appropriate errors.
 // Parameters:
@@ -144,15 +293,16 @@ func (w *MySQLWarehouseRepository) Save(newWarehouse internal.Warehouse) (intern
 // Returns:
 // - The updated warehouse data if the update is successful.
 // - An error if the warehouse does not exist or if there is an issue with the update operation.
-func (w *MySQLWarehouseRepository) Update(updatedWarehouse internal.Warehouse) (internal.Warehouse, error) {
-	_, err := w.GetByID(updatedWarehouse.ID)
+// - A precondition failed error if the warehouse was changed since it was read.
+func (w *MySQLWarehouseRepository) Update(ctx context.Context, updatedWarehouse internal.Warehouse) (internal.Warehouse, error) {
+	_, err := w.GetByID(ctx, updatedWarehouse.ID)
 
 	if err != nil {
 		return internal.Warehouse{}, err
 	}
 	// prepare the query
-	statement, err := w.db.Prepare(
-		"UPDATE `warehouses` AS `w` SET `address` = ?, `telephone` = ?, `warehouse_code` = ?, `locality_id` = ?, `minimum_capacity`= ?, `minimum_temperature`= ? WHERE `id` = ?",
+	statement, err := w.db.PrepareContext(ctx,
+		"UPDATE `warehouses` AS `w` SET `address` = ?, `telephone` = ?, `warehouse_code` = ?, `locality_id` = ?, `minimum_capacity`= ?, `minimum_temperature`= ?, `latitude` = ?, `longitude` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?",
 	)
 
 	if err != nil {
@@ -162,7 +312,7 @@ func (w *MySQLWarehouseRepository) Update(updatedWarehouse internal.Warehouse) (
 	defer statement.Close()
 
 	// execute the query
-	_, err = statement.Exec(updatedWarehouse.Address, updatedWarehouse.Telephone, updatedWarehouse.WarehouseCode, updatedWarehouse.LocalityID, updatedWarehouse.MinimumCapacity, updatedWarehouse.MinimumTemperature, updatedWarehouse.ID)
+	result, err := statement.ExecContext(ctx, updatedWarehouse.Address, updatedWarehouse.Telephone, updatedWarehouse.WarehouseCode, updatedWarehouse.LocalityID, updatedWarehouse.MinimumCapacity, updatedWarehouse.MinimumTemperature, updatedWarehouse.Latitude, updatedWarehouse.Longitude, updatedWarehouse.ID, updatedWarehouse.Version)
 
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -179,38 +329,94 @@ func (w *MySQLWarehouseRepository) Update(updatedWarehouse internal.Warehouse) (
 		return internal.Warehouse{}, err
 	}
 
+	err = utils.CheckVersionUpdated(result, "warehouse")
+	if err != nil {
+		return internal.Warehouse{}, err
+	}
+
+	updatedWarehouse.Version++
+
 	return updatedWarehouse, nil
 }
 
-// Delete removes a warehouse record from the database by its ID.
-// It first checks if the warehouse exists by calling GetByID.
-// If the warehouse exists, it prepares and executes a DELETE SQL statement.
-// If any error occurs during these operations, it returns the error.
+// Delete soft deletes a warehouse by its ID, the row is kept with the time it was deleted.
+// The warehouse must still be in the version it was read.
 // Parameters:
 //   - id: the ID of the warehouse to be deleted.
+//   - version: the version the warehouse was read in.
 //
 // Returns:
-//   - error: an error object if any error occurs, otherwise nil.
-func (w *MySQLWarehouseRepository) Delete(warehouseID int) error {
-	_, err := w.GetByID(warehouseID)
+//   - error: a precondition failed error when the warehouse was changed or deleted since it was read,
+//     or the error of the query.
+func (w *MySQLWarehouseRepository) Delete(ctx context.Context, warehouseID int, version int) error {
+	return utils.SoftDelete(ctx, w.db, "warehouses", "warehouse", warehouseID, version)
+}
 
-	if err != nil {
-		return err
-	}
+// Restore clears the deletion of a soft deleted warehouse.
+// Parameters:
+//   - warehouseID: the ID of the warehouse to be restored.
+//
+// Returns:
+//   - error: utils.ErrNotFound when there is no deleted warehouse with the ID, or the error of the query.
+func (w *MySQLWarehouseRepository) Restore(ctx context.Context, warehouseID int) error {
+	return utils.Restore(ctx, w.db, "warehouses", warehouseID)
+}
 
-	statement, err := w.db.Prepare("DELETE FROM warehouses WHERE id = ?")
+// Purge removes for good the warehouses deleted before a time, the ones other records still refer to are kept.
+// Parameters:
+//   - before: the time the warehouses must have been deleted before.
+//
+// Returns:
+//   - int: the number of warehouses removed.
+//   - error: an error if any of the queries fails.
+func (w *MySQLWarehouseRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	return utils.PurgeDeleted(ctx, w.db, "warehouses", before)
+}
 
+// GetWithStock retrieves the warehouses whose sections hold at least quantity units of a product
+// in batches with stock left. The coordinates of a warehouse fall back to the ones of its locality.
+//
+// Parameters:
+//   - productID: the ID of the product.
+//   - quantity: the minimum stock of the product the warehouse must hold.
+//
+// Returns:
+//   - []internal.WarehouseStock: the warehouses with their stock of the product, empty when there are none.
+//   - error: an error if the query fails or if there is an issue scanning the rows.
+func (w *MySQLWarehouseRepository) GetWithStock(ctx context.Context, productID, quantity int) ([]internal.WarehouseStock, error) {
+	rows, err := w.db.QueryContext(ctx, `SELECT w.id, w.address, w.telephone, w.warehouse_code, w.locality_id, w.minimum_capacity, w.minimum_temperature,
+			COALESCE(w.latitude, l.latitude), COALESCE(w.longitude, l.longitude), SUM(pb.current_quantity) AS stock
+		FROM warehouses w
+		INNER JOIN sections s ON s.warehouse_id = w.id
+		INNER JOIN product_batches pb ON pb.section_id = s.id
+		LEFT JOIN localities l ON l.id = w.locality_id
+		WHERE pb.product_id = ? AND pb.current_quantity > 0 AND w.deleted_at IS NULL
+		GROUP BY w.id, l.latitude, l.longitude
+		HAVING stock >= ?`, productID, quantity)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer statement.Close()
+	defer rows.Close()
+
+	warehouses := []internal.WarehouseStock{}
+
+	for rows.Next() {
+		var warehouse internal.WarehouseStock
 
-	_, err = statement.Exec(warehouseID)
+		err := rows.Scan(&warehouse.ID, &warehouse.Address, &warehouse.Telephone, &warehouse.WarehouseCode, &warehouse.LocalityID,
+			&warehouse.MinimumCapacity, &warehouse.MinimumTemperature, &warehouse.Latitude, &warehouse.Longitude, &warehouse.Stock)
+		if err != nil {
+			return nil, err
+		}
 
+		warehouses = append(warehouses, warehouse)
+	}
+
+	err = rows.Err()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return warehouses, nil
 }
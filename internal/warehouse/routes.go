@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/meli-fresh-products-api-backend-go-t2/cmd/server/handler"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/rbac"
 )
 
 // NewWarehouseRoutes sets up the routes for warehouse-related endpoints.
@@ -12,7 +13,9 @@ import (
 // - POST /api/v1/warehouses: Creates a new warehouse.
 // - GET /api/v1/warehouses/{id}: Retrieves a warehouse by its ID.
 // - PATCH /api/v1/warehouses/{id}: Updates a warehouse by its ID.
-// - DELETE /api/v1/warehouses/{id}: Deletes a warehouse by its ID.
+// - DELETE /api/v1/warehouses/{id}: Soft deletes a warehouse by its ID.
+// - POST /api/v1/warehouses/{id}/restore: Restores a deleted warehouse by its ID.
+// - GET /api/v1/warehouses/nearest: Retrieves the warehouses with stock of a product closest to a locality.
 //
 // Parameters:
 // - mux: The HTTP request multiplexer from the chi package.
@@ -25,11 +28,14 @@ func NewWarehouseRoutes(mux *chi.Mux, service internal.WarehouseService) error {
 	warehouseHandler := handler.NewWarehouseHandler(service)
 
 	mux.Route("/api/v1/warehouses", func(router chi.Router) {
-		router.Get("/", warehouseHandler.GetAll())
-		router.Post("/", warehouseHandler.Post())
-		router.Get("/{id}", warehouseHandler.GetByID())
-		router.Patch("/{id}", warehouseHandler.Update())
-		router.Delete("/{id}", warehouseHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionRead))).Get("/", warehouseHandler.GetAll())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionWrite))).Post("/", warehouseHandler.Post())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionRead))).Get("/{id}", warehouseHandler.GetByID())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionWrite))).Patch("/{id}", warehouseHandler.Update())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionDelete))).Delete("/{id}", warehouseHandler.Delete())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionWrite))).Post("/{id}/restore", warehouseHandler.Restore())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionRead))).Get("/nearest", warehouseHandler.GetNearestWithStock())
+		router.With(rbac.Require(rbac.Permission(rbac.ResourceWarehouses, rbac.ActionWrite))).Post("/import", warehouseHandler.Import())
 	})
 
 	return nil
@@ -1,17 +1,28 @@
 package warehouse
 
 import (
+	"context"
 	"errors"
+	"math"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
+	"github.com/meli-fresh-products-api-backend-go-t2/internal/audit"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
 )
 
+// auditEntity is the name of the warehouses in the audit trail
+const auditEntity = "warehouses"
+
 type BasicWarehouseService struct {
 	repo             internal.WarehouseRepository
 	validateLocality internal.WarehouseLocalityValidation
+	validateProduct  internal.WarehouseProductValidation
+	// uow makes the changes along with their audit entries
+	uow internal.UnitOfWork
 }
 
 // NewWarehouseService creates a new instance of BasicWarehouseService with the provided WarehouseRepository.
@@ -19,21 +30,30 @@ type BasicWarehouseService struct {
 //
 // Parameters:
 //   - repo: an implementation of the WarehouseRepository interface.
+//   - validateLocality: used to check the locality of a warehouse exists.
+//   - validateProduct: used to check the product of a stock query exists.
+//   - uow: used to make the changes along with their audit entries.
 //
 // Returns:
 //   - A pointer to the newly created BasicWarehouseService.
-func NewWarehouseService(repo internal.WarehouseRepository, validateLocality internal.WarehouseLocalityValidation) *BasicWarehouseService {
+func NewWarehouseService(
+	repo internal.WarehouseRepository,
+	validateLocality internal.WarehouseLocalityValidation,
+	validateProduct internal.WarehouseProductValidation,
+	uow internal.UnitOfWork) *BasicWarehouseService {
 	return &BasicWarehouseService{
 		repo:             repo,
 		validateLocality: validateLocality,
+		validateProduct:  validateProduct,
+		uow:              uow,
 	}
 }
 
 // GetAll retrieves all warehouses from the repository.
 // It returns a slice of Warehouse and an error if any occurred during the retrieval process.
 // If no warehouses are found, it returns an ErrNotFound error.
-func (s *BasicWarehouseService) GetAll() ([]internal.Warehouse, error) {
-	warehouses, err := s.repo.GetAll()
+func (s *BasicWarehouseService) GetAll(ctx context.Context) ([]internal.Warehouse, error) {
+	warehouses, err := s.repo.GetAll(ctx)
 	if err != nil {
 		return nil, utils.ENotFound("Warehouse")
 	}
@@ -41,6 +61,30 @@ func (s *BasicWarehouseService) GetAll() ([]internal.Warehouse, error) {
 	return warehouses, nil
 }
 
+// List retrieves a page of warehouses, filtered and sorted as asked by the query.
+//
+// Parameters:
+//   - query: the list query parsed from the request.
+//
+// Returns:
+//   - []internal.Warehouse: the warehouses of the page.
+//   - utils.Pagination: the limit and the cursor of the next page.
+//   - error: an error if the query asks for an unknown field or if the retrieval fails.
+func (s *BasicWarehouseService) List(ctx context.Context, query utils.ListQuery) ([]internal.Warehouse, utils.Pagination, error) {
+	if err := query.Resolve(listFields); err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	warehouses, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, utils.Pagination{}, err
+	}
+
+	warehouses, pagination := utils.Paginate(warehouses, query)
+
+	return warehouses, pagination, nil
+}
+
 // GetByID retrieves a warehouse by its ID.
 // If the warehouse is not found, it returns an ErrNotFound error.
 // If any other error occurs during the retrieval, it returns that error.
@@ -50,8 +94,8 @@ func (s *BasicWarehouseService) GetAll() ([]internal.Warehouse, error) {
 // Returns:
 //   - internal.Warehouse: the warehouse with the specified ID.
 //   - error: an error if the warehouse is not found or if any other error occurs.
-func (s *BasicWarehouseService) GetByID(id int) (internal.Warehouse, error) {
-	warehouse, err := s.repo.GetByID(id)
+func (s *BasicWarehouseService) GetByID(ctx context.Context, id int) (internal.Warehouse, error) {
+	warehouse, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, utils.ErrNotFound) {
 			return internal.Warehouse{}, utils.ENotFound("Warehouse")
@@ -63,6 +107,51 @@ func (s *BasicWarehouseService) GetByID(id int) (internal.Warehouse, error) {
 	return warehouse, nil
 }
 
+// GetByIDs retrieves the warehouses with the given IDs, the IDs without a warehouse are ignored.
+//
+// Parameters:
+//   - ids: the IDs of the warehouses to retrieve.
+//
+// Returns:
+//   - []internal.Warehouse: the warehouses found.
+//   - error: an error if the retrieval fails.
+func (s *BasicWarehouseService) GetByIDs(ctx context.Context, ids []int) ([]internal.Warehouse, error) {
+	return s.repo.GetByIDs(ctx, ids)
+}
+
+// GetRelations retrieves the entities related to each warehouse that were asked by include.
+// Every locality is read once, whatever the number of warehouses in it.
+//
+// Parameters:
+//   - warehouses: the warehouses to retrieve the relations of.
+//   - include: the names of the relations, internal.WarehouseIncludeLocality.
+//
+// Returns:
+//   - []internal.WarehouseRelations: the relations of each warehouse, in the order of the warehouses.
+//   - error: an error if the retrieval fails.
+func (s *BasicWarehouseService) GetRelations(ctx context.Context, warehouses []internal.Warehouse, include []string) ([]internal.WarehouseRelations, error) {
+	relations := make([]internal.WarehouseRelations, len(warehouses))
+
+	for _, name := range include {
+		if name != internal.WarehouseIncludeLocality {
+			continue
+		}
+
+		localityID := func(warehouse internal.Warehouse) int { return warehouse.LocalityID }
+
+		localities, err := s.validateLocality.GetByIDs(ctx, utils.DistinctIDs(warehouses, localityID))
+		if err != nil {
+			return nil, err
+		}
+
+		for i, locality := range utils.Related(warehouses, localityID, localities, func(locality internal.Locality) int { return locality.ID }) {
+			relations[i].Locality = locality
+		}
+	}
+
+	return relations, nil
+}
+
 // Save validates and saves a new warehouse to the repository.
 // It first validates the warehouse data, then checks for existing warehouse codes.
 // If the warehouse is valid and the code is unique, it saves the warehouse to the repository.
@@ -76,16 +165,28 @@ func (s *BasicWarehouseService) GetByID(id int) (internal.Warehouse, error) {
 //
 //	internal.Warehouse - the saved warehouse data.
 //	error - any error encountered during the validation or saving process.
-func (s *BasicWarehouseService) Save(newWarehouse internal.Warehouse) (internal.Warehouse, error) {
-	if err := s.validateWarehouse(newWarehouse); err != nil {
+func (s *BasicWarehouseService) Save(ctx context.Context, newWarehouse internal.Warehouse) (internal.Warehouse, error) {
+	if err := s.validateWarehouse(ctx, newWarehouse); err != nil {
 		return internal.Warehouse{}, err
 	}
 
-	if err := s.existingWarehouseCode(newWarehouse, false); err != nil {
+	if err := s.existingWarehouseCode(ctx, newWarehouse, false); err != nil {
 		return internal.Warehouse{}, utils.EConflict("Warehouse", "Warehouse code")
 	}
 
-	createdWarehouse, err := s.repo.Save(newWarehouse)
+	return s.save(ctx, newWarehouse, internal.AuditCreate)
+}
+
+// save saves a warehouse along with its audit entry of the action.
+func (s *BasicWarehouseService) save(ctx context.Context, newWarehouse internal.Warehouse, action string) (createdWarehouse internal.Warehouse, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		createdWarehouse, err = repos.Warehouses.Save(ctx, newWarehouse)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, action, auditEntity, createdWarehouse.ID, nil, createdWarehouse)
+	})
 	if err != nil {
 		return internal.Warehouse{}, err
 	}
@@ -104,8 +205,8 @@ func (s *BasicWarehouseService) Save(newWarehouse internal.Warehouse) (internal.
 //
 // Returns:
 //   - error: utils.ErrConflict if a conflict is found, otherwise nil.
-func (s *BasicWarehouseService) existingWarehouseCode(newWarehouse internal.Warehouse, isUpdate bool) error {
-	existing, _ := s.repo.GetAll()
+func (s *BasicWarehouseService) existingWarehouseCode(ctx context.Context, newWarehouse internal.Warehouse, isUpdate bool) error {
+	existing, _ := s.repo.GetAll(ctx)
 	for _, w := range existing {
 		if !isUpdate {
 			if strings.EqualFold(w.WarehouseCode, newWarehouse.WarehouseCode) {
@@ -132,8 +233,8 @@ func (s *BasicWarehouseService) existingWarehouseCode(newWarehouse internal.Ware
 // Returns:
 //   - internal.Warehouse: The updated warehouse.
 //   - error: An error if the update process fails, or nil if successful.
-func (s *BasicWarehouseService) Update(id int, updatedWarehouse internal.WarehousePointers) (internal.Warehouse, error) {
-	warehouse, err := s.repo.GetByID(id)
+func (s *BasicWarehouseService) Update(ctx context.Context, id int, updatedWarehouse internal.WarehousePointers) (internal.Warehouse, error) {
+	warehouse, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return internal.Warehouse{}, utils.ErrNotFound
 	}
@@ -142,6 +243,12 @@ func (s *BasicWarehouseService) Update(id int, updatedWarehouse internal.Warehou
 		return internal.Warehouse{}, utils.ErrNotFound
 	}
 
+	if err := utils.CheckVersion("warehouse", updatedWarehouse.Version, warehouse.Version); err != nil {
+		return internal.Warehouse{}, err
+	}
+
+	current := warehouse
+
 	if updatedWarehouse.Address != nil {
 		warehouse.Address = *updatedWarehouse.Address
 	}
@@ -166,15 +273,26 @@ func (s *BasicWarehouseService) Update(id int, updatedWarehouse internal.Warehou
 		warehouse.MinimumTemperature = *updatedWarehouse.MinimumTemperature
 	}
 
-	if err := s.validateWarehouse(warehouse); err != nil {
+	if updatedWarehouse.Latitude != nil || updatedWarehouse.Longitude != nil {
+		warehouse.Latitude, warehouse.Longitude = updatedWarehouse.Latitude, updatedWarehouse.Longitude
+	}
+
+	if err := s.validateWarehouse(ctx, warehouse); err != nil {
 		return internal.Warehouse{}, err
 	}
 
-	if err := s.existingWarehouseCode(warehouse, true); err != nil {
+	if err := s.existingWarehouseCode(ctx, warehouse, true); err != nil {
 		return internal.Warehouse{}, utils.EConflict("Warehouse", "Warehouse code")
 	}
 
-	warehouse, err = s.repo.Update(warehouse)
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+		warehouse, err = repos.Warehouses.Update(ctx, warehouse)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditUpdate, auditEntity, id, current, warehouse)
+	})
 
 	if err != nil {
 		return internal.Warehouse{}, err
@@ -186,27 +304,244 @@ func (s *BasicWarehouseService) Update(id int, updatedWarehouse internal.Warehou
 // Delete removes a warehouse entry from the repository by its ID.
 // It first checks if the warehouse exists by calling GetByID method.
 // If the warehouse does not exist or any error occurs during the check, it returns the error.
-// If the warehouse exists, it proceeds to delete it by calling the Delete method of the repository.
+// If the warehouse exists in the version given, it proceeds to delete it by calling the Delete method of the repository.
 // If any error occurs during the deletion, it returns the error.
 // Returns nil if the deletion is successful.
 //
 // Parameters:
 //   - id: the ID of the warehouse to be deleted.
+//   - version: the version of the warehouse expected, utils.AnyVersion for whatever it is.
+//
+// Returns:
+//   - error: an error if the warehouse does not exist, it is not in the version given or if there is an issue during deletion, otherwise nil.
+func (s *BasicWarehouseService) Delete(ctx context.Context, id int, version int) error {
+	return s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		warehouse, err := repos.Warehouses.GetByID(ctx, id)
+
+		if err != nil {
+			return utils.ENotFound("Warehouse")
+		}
+
+		if err := utils.CheckVersion("warehouse", version, warehouse.Version); err != nil {
+			return err
+		}
+
+		if err := repos.Warehouses.Delete(ctx, id, warehouse.Version); err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditDelete, auditEntity, id, warehouse, nil)
+	})
+}
+
+// Restore brings back a soft deleted warehouse.
+//
+// Parameters:
+//   - id: the ID of the deleted warehouse.
 //
 // Returns:
-//   - error: an error if the warehouse does not exist or if there is an issue during deletion, otherwise nil.
-func (s *BasicWarehouseService) Delete(id int) error {
-	_, err := s.repo.GetByID(id)
+//   - internal.Warehouse: the restored warehouse.
+//   - error: a not found error if there is no deleted warehouse with the ID, or the error of the repository.
+func (s *BasicWarehouseService) Restore(ctx context.Context, id int) (restored internal.Warehouse, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		err := repos.Warehouses.Restore(ctx, id)
+		if err != nil {
+			if errors.Is(err, utils.ErrNotFound) {
+				return utils.ENotFound("Deleted warehouse")
+			}
 
+			return err
+		}
+
+		restored, err = repos.Warehouses.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditRestore, auditEntity, id, nil, restored)
+	})
 	if err != nil {
-		return utils.ENotFound("Warehouse")
+		return internal.Warehouse{}, err
 	}
 
-	if err := s.repo.Delete(id); err != nil {
-		return err
+	return restored, nil
+}
+
+// Purge removes for good the warehouses deleted before a time.
+//
+// Parameters:
+//   - before: the time the warehouses must have been deleted before.
+//
+// Returns:
+//   - int: the number of warehouses removed.
+//   - error: the error of the repository.
+func (s *BasicWarehouseService) Purge(ctx context.Context, before time.Time) (purged int, err error) {
+	err = s.uow.Do(ctx, func(repos internal.TxRepositories) error {
+		purged, err = repos.Warehouses.Purge(ctx, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+
+		return audit.Record(ctx, repos.Audit, internal.AuditPurge, auditEntity, 0, nil, internal.AuditPurged{Purged: purged, DeletedBefore: before})
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return purged, nil
+}
+
+// GetNearestWithStock retrieves the warehouses holding at least quantity units of a product,
+// sorted by their great-circle distance to a locality. Warehouses without coordinates, neither
+// their own nor the ones of their locality, are left out.
+//
+// Parameters:
+//   - localityID: the ID of the locality to measure the distance from, it must have coordinates.
+//   - productID: the ID of the product.
+//   - quantity: the minimum stock of the product, at least 1.
+//   - limit: the maximum number of warehouses to return.
+//
+// Returns:
+//   - []internal.WarehouseDistance: the closest warehouses first, with the distance in kilometres.
+//   - error: utils.ErrNotFound if the locality or the product doesn't exist, utils.ErrInvalidArguments
+//     if the arguments are invalid or the locality has no coordinates.
+func (s *BasicWarehouseService) GetNearestWithStock(ctx context.Context, localityID, productID, quantity, limit int) ([]internal.WarehouseDistance, error) {
+	if quantity <= 0 {
+		return nil, utils.EBR("quantity must be greater than 0")
+	}
+
+	if limit <= 0 {
+		return nil, utils.EBR("limit must be greater than 0")
+	}
+
+	locality, err := s.validateLocality.GetByID(ctx, localityID)
+	if err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, utils.ENotFound("Locality")
+		}
+
+		return nil, err
+	}
+
+	if locality.Latitude == nil || locality.Longitude == nil {
+		return nil, utils.EBR("locality has no coordinates")
+	}
+
+	if _, err := s.validateProduct.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return nil, utils.ENotFound("Product")
+		}
+
+		return nil, err
+	}
+
+	warehouses, err := s.repo.GetWithStock(ctx, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	nearest := []internal.WarehouseDistance{}
+
+	for _, warehouse := range warehouses {
+		if warehouse.Latitude == nil || warehouse.Longitude == nil {
+			continue
+		}
+
+		distance := utils.GreatCircleDistance(*locality.Latitude, *locality.Longitude, *warehouse.Latitude, *warehouse.Longitude)
+
+		nearest = append(nearest, internal.WarehouseDistance{
+			WarehouseStock: warehouse,
+			DistanceKm:     math.Round(distance*100) / 100,
+		})
+	}
+
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return nearest[i].DistanceKm < nearest[j].DistanceKm
+	})
+
+	if len(nearest) > limit {
+		nearest = nearest[:limit]
+	}
+
+	return nearest, nil
+}
+
+// Import creates warehouses in bulk with the rules of Save, the warehouse codes must be unique,
+// ignoring case, among the stored warehouses and the imported ones.
+//
+// Parameters:
+//   - items: the decoded rows of the import.
+//   - options: whether it is a dry run and whether it is atomic.
+//
+// Returns:
+//   - utils.ImportReport: the outcome of every row.
+//   - error: an error if the warehouses cannot be read or saved.
+func (s *BasicWarehouseService) Import(ctx context.Context, items []utils.ImportItem[internal.Warehouse], options utils.ImportOptions) (utils.ImportReport, error) {
+	existing, err := s.repo.GetAll(ctx)
+	if err != nil && !errors.Is(err, utils.ErrNotFound) {
+		return utils.ImportReport{}, err
+	}
+
+	codes := map[string]bool{}
+	for _, w := range existing {
+		codes[strings.ToLower(w.WarehouseCode)] = true
+	}
+
+	return s.warehouseImporter(ctx, codes).Import(items, options)
+}
+
+// warehouseImporter builds the steps of a warehouses import, codes holds the lower case codes already taken.
+func (s *BasicWarehouseService) warehouseImporter(ctx context.Context, codes map[string]bool) utils.Importer[internal.Warehouse] {
+	return utils.Importer[internal.Warehouse]{
+		Validate: func(newWarehouse *internal.Warehouse) error {
+			newWarehouse.ID = 0
+
+			if err := s.validateWarehouse(ctx, *newWarehouse); err != nil {
+				return err
+			}
+
+			code := strings.ToLower(newWarehouse.WarehouseCode)
+			if codes[code] {
+				return utils.EConflict("Warehouse", "Warehouse code")
+			}
+
+			codes[code] = true
+
+			return nil
+		},
+		Save: func(newWarehouse internal.Warehouse) (int, error) {
+			createdWarehouse, err := s.save(ctx, newWarehouse, internal.AuditImport)
+			return createdWarehouse.ID, err
+		},
+		SaveAll: func(newWarehouses []internal.Warehouse) ([]int, error) {
+			var createdWarehouses []internal.Warehouse
+
+			err := s.uow.Do(ctx, func(repos internal.TxRepositories) (err error) {
+				createdWarehouses, err = repos.Warehouses.SaveAll(ctx, newWarehouses)
+				if err != nil {
+					return err
+				}
+
+				for _, w := range createdWarehouses {
+					if err = audit.Record(ctx, repos.Audit, internal.AuditImport, auditEntity, w.ID, nil, w); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]int, 0, len(createdWarehouses))
+			for _, w := range createdWarehouses {
+				ids = append(ids, w.ID)
+			}
+
+			return ids, nil
+		},
+	}
 }
 
 // validateWarehouse validates the given warehouse object.
@@ -218,7 +553,7 @@ func (s *BasicWarehouseService) Delete(id int) error {
 //
 // Returns:
 //   - error: an error if any validation fails, otherwise nil.
-func (s *BasicWarehouseService) validateWarehouse(warehouse internal.Warehouse) error {
+func (s *BasicWarehouseService) validateWarehouse(ctx context.Context, warehouse internal.Warehouse) error {
 	if warehouse.WarehouseCode == "" {
 		return utils.EZeroValue("Warehouse code is empty")
 	}
@@ -239,7 +574,11 @@ func (s *BasicWarehouseService) validateWarehouse(warehouse internal.Warehouse)
 		return utils.EZeroValue("Warehouse minimum temperature is invalid")
 	}
 
-	if _, err := s.validateLocality.GetByID(warehouse.LocalityID); err != nil {
+	if err := utils.ValidateCoordinates(warehouse.Latitude, warehouse.Longitude); err != nil {
+		return err
+	}
+
+	if _, err := s.validateLocality.GetByID(ctx, warehouse.LocalityID); err != nil {
 		return utils.EConflict("Warehouse", "Locality")
 	}
 
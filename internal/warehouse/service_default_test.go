@@ -1,9 +1,11 @@
 package warehouse
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/meli-fresh-products-api-backend-go-t2/internal"
 	"github.com/meli-fresh-products-api-backend-go-t2/internal/utils"
@@ -11,6 +13,39 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// mockUnitOfWork runs the operations on the mock repository, keeping the audit entries of the committed ones
+type mockUnitOfWork struct {
+	repos   internal.TxRepositories
+	entries []internal.AuditEntry
+}
+
+func newMockUnitOfWork(repo internal.WarehouseRepository) *mockUnitOfWork {
+	u := &mockUnitOfWork{}
+	u.repos = internal.TxRepositories{Warehouses: repo, Audit: u}
+
+	return u
+}
+
+func (u *mockUnitOfWork) Do(ctx context.Context, fn func(repos internal.TxRepositories) error) error {
+	saved := len(u.entries)
+
+	err := fn(u.repos)
+	if err != nil {
+		u.entries = u.entries[:saved]
+	}
+
+	return err
+}
+
+func (u *mockUnitOfWork) Save(ctx context.Context, entry *internal.AuditEntry) error {
+	u.entries = append(u.entries, *entry)
+	return nil
+}
+
+func (u *mockUnitOfWork) List(ctx context.Context, query utils.ListQuery) ([]internal.AuditEntry, error) {
+	return u.entries, nil
+}
+
 type mockWarehouseRepository struct {
 	mock.Mock
 }
@@ -19,36 +54,80 @@ type mockLocalityValidation struct {
 	mock.Mock
 }
 
-func (m *mockLocalityValidation) GetByID(id int) (locality internal.Locality, err error) {
+func (m *mockLocalityValidation) GetByID(ctx context.Context, id int) (locality internal.Locality, err error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Locality), args.Error(1)
 }
 
-func (m *mockWarehouseRepository) GetAll() (listWarehouses []internal.Warehouse, err error) {
+func (m *mockLocalityValidation) GetByIDs(ctx context.Context, ids []int) ([]internal.Locality, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Locality), args.Error(1)
+}
+
+func (m *mockWarehouseRepository) GetAll(ctx context.Context) (listWarehouses []internal.Warehouse, err error) {
 	args := m.Called()
 	return args.Get(0).([]internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseRepository) GetByID(id int) (warehouse internal.Warehouse, err error) {
+func (m *mockWarehouseRepository) GetByIDs(ctx context.Context, ids []int) ([]internal.Warehouse, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]internal.Warehouse), args.Error(1)
+}
+
+func (m *mockWarehouseRepository) List(ctx context.Context, query utils.ListQuery) ([]internal.Warehouse, error) {
+	args := m.Called(query)
+	return args.Get(0).([]internal.Warehouse), args.Error(1)
+}
+
+func (m *mockWarehouseRepository) GetByID(ctx context.Context, id int) (warehouse internal.Warehouse, err error) {
 	args := m.Called(id)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseRepository) Save(newWarehouse internal.Warehouse) (warehouse internal.Warehouse, err error) {
+func (m *mockWarehouseRepository) Save(ctx context.Context, newWarehouse internal.Warehouse) (warehouse internal.Warehouse, err error) {
 	args := m.Called(newWarehouse)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseRepository) Update(updatedWarehouse internal.Warehouse) (warehouse internal.Warehouse, err error) {
+func (m *mockWarehouseRepository) SaveAll(ctx context.Context, newWarehouses []internal.Warehouse) ([]internal.Warehouse, error) {
+	args := m.Called(newWarehouses)
+	return args.Get(0).([]internal.Warehouse), args.Error(1)
+}
+
+func (m *mockWarehouseRepository) Update(ctx context.Context, updatedWarehouse internal.Warehouse) (warehouse internal.Warehouse, err error) {
 	args := m.Called(updatedWarehouse)
 	return args.Get(0).(internal.Warehouse), args.Error(1)
 }
 
-func (m *mockWarehouseRepository) Delete(id int) (err error) {
+func (m *mockWarehouseRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockWarehouseRepository) Restore(ctx context.Context, id int) (err error) {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockWarehouseRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockWarehouseRepository) GetWithStock(ctx context.Context, productID, quantity int) ([]internal.WarehouseStock, error) {
+	args := m.Called(productID, quantity)
+	return args.Get(0).([]internal.WarehouseStock), args.Error(1)
+}
+
+type mockProductValidation struct {
+	mock.Mock
+}
+
+func (m *mockProductValidation) GetByID(ctx context.Context, id int) (product internal.Product, err error) {
+	args := m.Called(id)
+	return args.Get(0).(internal.Product), args.Error(1)
+}
+
 func TestUnitWarehouse_GetAll(t *testing.T) {
 	type fields struct {
 		repo               internal.WarehouseRepository
@@ -98,11 +177,12 @@ func TestUnitWarehouse_GetAll(t *testing.T) {
 			s := &BasicWarehouseService{
 				repo:             tt.fields.repo,
 				validateLocality: tt.fields.validationLocality,
+				uow:              newMockUnitOfWork(tt.fields.repo),
 			}
 
 			setupMock(tt.fields.repo.(*mockWarehouseRepository), tt.expectedListWarehouses, tt.expectedErr)
 
-			gotListWarehouses, err := s.GetAll()
+			gotListWarehouses, err := s.GetAll(context.Background())
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -189,11 +269,12 @@ func TestUnitWarehouse_GetById(t *testing.T) {
 			s := &BasicWarehouseService{
 				repo:             tt.fields.repo,
 				validateLocality: tt.fields.validationLocality,
+				uow:              newMockUnitOfWork(tt.fields.repo),
 			}
 
 			setupMock(tt.fields.repo.(*mockWarehouseRepository), tt.id, tt.expectedWarehouse, tt.expectedErr)
 
-			gotListWarehouses, err := s.GetByID(tt.id)
+			gotListWarehouses, err := s.GetByID(context.Background(), tt.id)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -392,11 +473,12 @@ func TestUnitWarehouse_Save(t *testing.T) {
 			s := &BasicWarehouseService{
 				repo:             tt.fields.repo,
 				validateLocality: tt.fields.validationLocality,
+				uow:              newMockUnitOfWork(tt.fields.repo),
 			}
 
 			setupMock(tt.fields, tt.expectedWarehouse, tt.newWarehouse, tt.expectedErr)
 
-			gotListWarehouses, err := s.Save(tt.newWarehouse)
+			gotListWarehouses, err := s.Save(context.Background(), tt.newWarehouse)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -567,11 +649,12 @@ func TestUnitWarehouse_Update(t *testing.T) {
 			s := &BasicWarehouseService{
 				repo:             tt.fields.repo,
 				validateLocality: tt.fields.validationLocality,
+				uow:              newMockUnitOfWork(tt.fields.repo),
 			}
 
 			setupMock(tt.fields, tt.expectedWarehouse, tt.idRequest, tt.newWarehouse, tt.expectedErr)
 
-			gotListWarehouses, err := s.Update(tt.idRequest, NewWarehousePointers(tt.newWarehouse))
+			gotListWarehouses, err := s.Update(context.Background(), tt.idRequest, NewWarehousePointers(tt.newWarehouse))
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -591,24 +674,22 @@ func TestUnitWarehouse_Delete(t *testing.T) {
 	}
 
 	setupMock := func(repo *mockWarehouseRepository, id int, expectedErr error) {
-		if expectedErr != nil {
-			if errors.Is(expectedErr, utils.ErrNotFound) {
-				repo.On("GetByID", id).Return(internal.Warehouse{}, utils.ErrNotFound)
-			} else {
-				repo.On("GetByID", id).Return(internal.Warehouse{}, nil)
-				repo.On("Delete", id).Return(expectedErr)
-			}
-		} else {
-			repo.On("GetByID", id).Return(internal.Warehouse{}, nil)
-			repo.On("Delete", id).Return(nil)
+		switch {
+		case errors.Is(expectedErr, utils.ErrNotFound):
+			repo.On("GetByID", id).Return(internal.Warehouse{}, utils.ErrNotFound)
+		case errors.Is(expectedErr, utils.ErrPreconditionFailed):
+			repo.On("GetByID", id).Return(internal.Warehouse{ID: id, Version: 3}, nil)
+		default:
+			repo.On("GetByID", id).Return(internal.Warehouse{ID: id, Version: 2}, nil)
+			repo.On("Delete", id, 2).Return(expectedErr)
 		}
-
 	}
 
 	tests := []struct {
 		name        string
 		fields      fields
 		id          int
+		version     int
 		expectedErr error
 	}{
 		{
@@ -619,8 +700,31 @@ func TestUnitWarehouse_Delete(t *testing.T) {
 				},
 			},
 			id:          1,
+			version:     2,
+			expectedErr: nil,
+		},
+		{
+			name: "DeleteWarehouseByID any version",
+			fields: fields{
+				repo: &mockWarehouseRepository{
+					mock.Mock{},
+				},
+			},
+			id:          1,
+			version:     utils.AnyVersion,
 			expectedErr: nil,
 		},
+		{
+			name: "DeleteWarehouseByID Warehouse changed",
+			fields: fields{
+				repo: &mockWarehouseRepository{
+					mock.Mock{},
+				},
+			},
+			id:          1,
+			version:     2,
+			expectedErr: utils.EPreconditionFailed("warehouse"),
+		},
 		{
 			name: "DeleteWarehouseByID Warehouse not found",
 			fields: fields{
@@ -648,11 +752,12 @@ func TestUnitWarehouse_Delete(t *testing.T) {
 			s := &BasicWarehouseService{
 				repo:             tt.fields.repo,
 				validateLocality: tt.fields.validationLocality,
+				uow:              newMockUnitOfWork(tt.fields.repo),
 			}
 
 			setupMock(tt.fields.repo.(*mockWarehouseRepository), tt.id, tt.expectedErr)
 
-			err := s.Delete(tt.id)
+			err := s.Delete(context.Background(), tt.id, tt.version)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -690,11 +795,194 @@ func NewWarehousePointers(w internal.Warehouse) internal.WarehousePointers {
 func TestNewWarehouseService(t *testing.T) {
 	repo := new(mockWarehouseRepository)
 	localityValidation := new(mockLocalityValidation)
+	productValidation := new(mockProductValidation)
 
-	service := NewWarehouseService(repo, localityValidation)
+	uow := newMockUnitOfWork(repo)
+
+	service := NewWarehouseService(repo, localityValidation, productValidation, uow)
 	expectedService := &BasicWarehouseService{
 		repo:             repo,
 		validateLocality: localityValidation,
+		validateProduct:  productValidation,
+		uow:              uow,
 	}
 	assert.Equal(t, expectedService, service)
 }
+
+func coordinate(value float64) *float64 {
+	return &value
+}
+
+func TestUnitWarehouse_GetNearestWithStock(t *testing.T) {
+	losAngeles := internal.Locality{ID: 1, LocalityName: "Los Angeles", ProvinceID: 1, Latitude: coordinate(34.052235), Longitude: coordinate(-118.243683)}
+	toronto := internal.WarehouseStock{
+		Warehouse: internal.Warehouse{ID: 2, WarehouseCode: "WH002", LocalityID: 2, Latitude: coordinate(43.651070), Longitude: coordinate(-79.347015)},
+		Stock:     40,
+	}
+	vancouver := internal.WarehouseStock{
+		Warehouse: internal.Warehouse{ID: 3, WarehouseCode: "WH003", LocalityID: 3, Latitude: coordinate(49.282730), Longitude: coordinate(-123.120735)},
+		Stock:     10,
+	}
+	withoutCoordinates := internal.WarehouseStock{Warehouse: internal.Warehouse{ID: 4, WarehouseCode: "WH004", LocalityID: 4}, Stock: 5}
+
+	tests := []struct {
+		name        string
+		localityID  int
+		productID   int
+		limit       int
+		expectedIDs []int
+		expectedErr error
+	}{
+		{
+			name:        "GetNearestWithStock closest first, without the ones lacking coordinates",
+			localityID:  1,
+			productID:   1,
+			limit:       5,
+			expectedIDs: []int{3, 2},
+		},
+		{
+			name:        "GetNearestWithStock limited",
+			localityID:  1,
+			productID:   1,
+			limit:       1,
+			expectedIDs: []int{3},
+		},
+		{
+			name:        "GetNearestWithStock locality without coordinates",
+			localityID:  5,
+			productID:   1,
+			limit:       5,
+			expectedErr: utils.ErrInvalidArguments,
+		},
+		{
+			name:        "GetNearestWithStock locality not found",
+			localityID:  9,
+			productID:   1,
+			limit:       5,
+			expectedErr: utils.ErrNotFound,
+		},
+		{
+			name:        "GetNearestWithStock product not found",
+			localityID:  1,
+			productID:   9,
+			limit:       5,
+			expectedErr: utils.ErrNotFound,
+		},
+		{
+			name:        "GetNearestWithStock invalid limit",
+			localityID:  1,
+			productID:   1,
+			limit:       0,
+			expectedErr: utils.ErrInvalidArguments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockWarehouseRepository)
+			localityValidation := new(mockLocalityValidation)
+			productValidation := new(mockProductValidation)
+
+			localityValidation.On("GetByID", 1).Return(losAngeles, nil)
+			localityValidation.On("GetByID", 5).Return(internal.Locality{ID: 5, LocalityName: "Nowhere"}, nil)
+			localityValidation.On("GetByID", 9).Return(internal.Locality{}, utils.ErrNotFound)
+			productValidation.On("GetByID", 1).Return(internal.Product{ID: 1}, nil)
+			productValidation.On("GetByID", 9).Return(internal.Product{}, utils.ErrNotFound)
+			repo.On("GetWithStock", 1, 1).Return([]internal.WarehouseStock{toronto, withoutCoordinates, vancouver}, nil)
+
+			service := NewWarehouseService(repo, localityValidation, productValidation, newMockUnitOfWork(repo))
+
+			nearest, err := service.GetNearestWithStock(context.Background(), tt.localityID, tt.productID, 1, tt.limit)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "GetWithStock", mock.Anything, mock.Anything)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			ids := []int{}
+			for _, warehouse := range nearest {
+				ids = append(ids, warehouse.ID)
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.InDelta(t, 1741, nearest[0].DistanceKm, 10)
+		})
+	}
+}
+
+func TestUnitWarehouse_Import(t *testing.T) {
+	newWarehouse := func(code string, localityID int) internal.Warehouse {
+		return internal.Warehouse{Address: "Address " + code, Telephone: "123456789", WarehouseCode: code, LocalityID: localityID}
+	}
+
+	items := []utils.ImportItem[internal.Warehouse]{
+		{Row: 2, Value: newWarehouse("WH010", 1)},
+		{Row: 3, Value: newWarehouse("wh001", 1)},
+		{Row: 4, Value: newWarehouse("WH010", 1)},
+		{Row: 5, Value: newWarehouse("WH011", 9)},
+		{Row: 6, Value: internal.Warehouse{WarehouseCode: "WH012", LocalityID: 1}},
+	}
+
+	tests := []struct {
+		name            string
+		items           []utils.ImportItem[internal.Warehouse]
+		options         utils.ImportOptions
+		expectedCreated int
+		expectedFailed  int
+		expectedErrors  []string
+	}{
+		{
+			name:            "Import saves the valid rows",
+			items:           items,
+			expectedCreated: 1,
+			expectedFailed:  4,
+			expectedErrors:  []string{"", "Warehouse code", "Warehouse code", "Locality", "address"},
+		},
+		{
+			name:           "Import atomic with failed rows",
+			items:          items,
+			options:        utils.ImportOptions{Atomic: true},
+			expectedFailed: 4,
+		},
+		{
+			name:            "Import atomic",
+			items:           items[:1],
+			options:         utils.ImportOptions{Atomic: true},
+			expectedCreated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockWarehouseRepository)
+			localityValidation := new(mockLocalityValidation)
+
+			repo.On("GetAll").Return([]internal.Warehouse{{ID: 1, WarehouseCode: "WH001"}}, nil)
+			repo.On("Save", newWarehouse("WH010", 1)).Return(internal.Warehouse{ID: 2}, nil)
+			repo.On("SaveAll", []internal.Warehouse{newWarehouse("WH010", 1)}).Return([]internal.Warehouse{{ID: 2}}, nil)
+			localityValidation.On("GetByID", 1).Return(internal.Locality{ID: 1}, nil)
+			localityValidation.On("GetByID", 9).Return(internal.Locality{}, utils.ErrNotFound)
+
+			uow := newMockUnitOfWork(repo)
+			service := NewWarehouseService(repo, localityValidation, new(mockProductValidation), uow)
+
+			report, err := service.Import(context.Background(), tt.items, tt.options)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCreated, report.Created)
+			assert.Equal(t, tt.expectedFailed, report.Failed)
+			assert.Len(t, uow.entries, tt.expectedCreated)
+
+			for i, expectedError := range tt.expectedErrors {
+				assert.Contains(t, report.Rows[i].Error, expectedError)
+			}
+
+			if tt.expectedCreated > 0 {
+				assert.Equal(t, 2, report.Rows[0].ID)
+			}
+		})
+	}
+}